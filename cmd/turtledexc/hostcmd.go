@@ -1,12 +1,15 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -70,16 +73,41 @@ Available settings:
      registrysize:       filesize
      customregistrypath: string
 
+     maxdownloadspeed:  bytes per second
+     maxuploadspeed:    bytes per second
+     bandwidthschedule: schedule
+
+     peermaxconcurrentstreams: int
+     peermaxdownloadspeed:     bytes per second
+     peermaxuploadspeed:       bytes per second
+     peermaxprogramsperminute: int
+     peerbanthreshold:         int
+     peerbanduration:          seconds
+
 Currency units can be specified, e.g. 10SC; run 'ttdxc help wallet' for details.
 
 Durations (maxduration and windowsize) must be specified in either blocks (b),
 hours (h), days (d), or weeks (w). A block is approximately 10 minutes, so one
 hour is six blocks, a day is 144 blocks, and a week is 1008 blocks.
 
-Timeouts (ephemeralaccountexpiry) must be specified in either seconds (s),
+Timeouts (ephemeralaccountexpiry and peerbanduration) must be specified in either seconds (s),
 hours (h), days (d), or weeks (w). One hour is 3600 seconds, a day is 86400
 seconds, and a week is 604800 seconds.
 
+Speeds (maxdownloadspeed and maxuploadspeed) limit the data the host receives
+from and sends to renters, e.g. 1MB/s or 8Mbps. Set both to 0 for no limit.
+
+The bandwidth schedule contains semicolon separated windows during which
+different speeds apply. Each window consists of the days ('*' or e.g.
+'mon-fri,sun'), the time of day in the local time of the host and the download
+and upload speed, e.g. "mon-fri 09:00-17:00 1MB/s 500KB/s". Set it to 'none'
+to remove all windows.
+
+The peer limits apply to every renter key and ephemeral account. Set them to 0
+for no limit. A peer which sends peerbanthreshold invalid payments or programs
+within peerbanduration is banned for peerbanduration. Set peerbanthreshold to 0
+to disable bans.
+
 For a description of each parameter, see doc/API.md.
 
 To configure the host to accept new contracts, set acceptingcontracts to true:
@@ -88,6 +116,50 @@ To configure the host to accept new contracts, set acceptingcontracts to true:
 		Run: wrap(hostconfigcmd),
 	}
 
+	hostFinancialsCmd = &cobra.Command{
+		Use:   "financials",
+		Short: "View the host's revenue ledger",
+		Long: `View the revenue, collateral and transaction fees of the host per day. The
+range of days can be limited with --from and --to, e.g. --from 2021-01-01.
+Use --format csv or --format json to export the ledger per contract and day,
+e.g. for tax reporting. Amounts of the exports are in hastings.`,
+		Run: wrap(hostfinancialscmd),
+	}
+
+	hostPricingCmd = &cobra.Command{
+		Use:   "pricing",
+		Short: "View the dynamic pricing engine",
+		Long: `View the settings of the host's dynamic pricing engine, the inputs of its last
+update and the prices the host currently advertises.`,
+		Run: wrap(hostpricingcmd),
+	}
+
+	hostPricingSetCmd = &cobra.Command{
+		Use:   "set [setting] [value]",
+		Short: "Configure the dynamic pricing engine",
+		Long: `Configure the host's dynamic pricing engine. The engine combines a set of rules
+into a factor which is applied to the minimum prices of the host's settings.
+
+Available settings:
+     enabled: boolean
+
+     emptystoragefactor: factor at 0% storage utilization
+     fullstoragefactor:  factor at 100% storage utilization
+
+     targetacceptancerate: fraction of contract negotiations to complete, e.g. 0.8
+     acceptanceadjustment: maximum relative price change, e.g. 0.2
+
+     exchangerate:       value of one coin, e.g. "0.004 USD"
+     targetstorageprice: target storage price in that currency per TB per month
+
+     minfactor: lower bound of the factor
+     maxfactor: upper bound of the factor
+
+Set a value to 0 to disable the rule or bound. The exchange rate can be removed
+by setting it to 'none'.`,
+		Run: wrap(hostpricingsetcmd),
+	}
+
 	hostContractCmd = &cobra.Command{
 		Use:   "contracts",
 		Short: "Show host contracts",
@@ -109,8 +181,8 @@ Available output types:
 
 	hostFolderCmd = &cobra.Command{
 		Use:   "folder",
-		Short: "Add, remove, or resize a storage folder",
-		Long:  "Add, remove, or resize a storage folder.",
+		Short: "Add, remove, resize, or tier a storage folder",
+		Long:  "Add, remove, resize, or set the tier of a storage folder.",
 	}
 
 	hostFolderRemoveCmd = &cobra.Command{
@@ -131,6 +203,43 @@ other storage folders.`,
 		Run: wrap(hostfolderresizecmd),
 	}
 
+	hostScrubCmd = &cobra.Command{
+		Use:   "scrub",
+		Short: "View the storage scrubber",
+		Long: `View the settings and the progress of the host's storage scrubber together
+with the corrupt or missing sectors it found and the contracts at risk.`,
+		Run: wrap(hostscrubcmd),
+	}
+
+	hostScrubSetCmd = &cobra.Command{
+		Use:   "set [setting] [value]",
+		Short: "Configure the storage scrubber",
+		Long: `Configure the host's storage scrubber. The scrubber periodically reads every
+sector and verifies its Merkle root to detect bit rot.
+
+Available settings:
+     enabled:           boolean
+     interval:          time between scrubs, e.g. "30d"
+     maxbytespersecond: maximum read speed, e.g. "16MB/s", 0 disables the limit`,
+		Run: wrap(hostscrubsetcmd),
+	}
+
+	hostScrubStartCmd = &cobra.Command{
+		Use:   "start",
+		Short: "Start a scrub",
+		Long:  "Start scrubbing the host's storage immediately.",
+		Run:   wrap(hostscrubstartcmd),
+	}
+
+	hostFolderTierCmd = &cobra.Command{
+		Use:   "tier [path] [fast|slow]",
+		Short: "Set the tier of a storage folder",
+		Long: `Set the tier of a storage folder. Folders on fast disks like SSDs should use
+the fast tier. Frequently read sectors are periodically moved to the fast
+folders while rarely read sectors are moved to the slow folders to make room.`,
+		Run: wrap(hostfoldertiercmd),
+	}
+
 	hostSectorCmd = &cobra.Command{
 		Use:   "sector",
 		Short: "Add or delete a sector (add not supported)",
@@ -311,6 +420,10 @@ RPC Stats:
 			nm.ErrorCalls, nm.UnrecognizedCalls, nm.DownloadCalls,
 			nm.RenewCalls, nm.ReviseCalls, nm.SettingsCalls,
 			nm.FormContractCalls)
+		fmt.Println()
+		rateLimitSummary(is.MaxDownloadSpeed, is.MaxUploadSpeed)
+		bandwidthScheduleSummary(is.BandwidthSchedule)
+		hostPeerLimitsSummary(is)
 	} else {
 		fmt.Printf(`Host info:
 	Connectability Status: %v
@@ -334,6 +447,7 @@ RPC Stats:
 			currencyUnits(fm.LockedStorageCollateral),
 			currencyUnits(totalRevenue))
 	}
+	hostBannedPeersSummary(hg.BannedPeers)
 
 	// if wallet is locked print warning
 	walletstatus, walleterr := httpClient.WalletGet()
@@ -355,11 +469,57 @@ RPC Stats:
 		return
 	}
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
-	fmt.Fprintf(w, "\tUsed\tCapacity\t%% Used\tPath\n")
+	fmt.Fprintf(w, "\tUsed\tCapacity\t%% Used\tTier\tHot Sectors\tPath\n")
 	for _, folder := range sg.Folders {
 		curSize := int64(folder.Capacity - folder.CapacityRemaining)
 		pctUsed := 100 * (float64(curSize) / float64(folder.Capacity))
-		fmt.Fprintf(w, "\t%s\t%s\t%.2f\t%s\n", modules.FilesizeUnits(uint64(curSize)), modules.FilesizeUnits(folder.Capacity), pctUsed, folder.Path)
+		fmt.Fprintf(w, "\t%s\t%s\t%.2f\t%s\t%v\t%s\n", modules.FilesizeUnits(uint64(curSize)), modules.FilesizeUnits(folder.Capacity), pctUsed, folder.Tier, folder.HotSectors, folder.Path)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer")
+	}
+}
+
+// hostPeerLimitsSummary displays the limits which apply to every renter key and
+// ephemeral account.
+func hostPeerLimitsSummary(is modules.HostInternalSettings) {
+	limit := func(l uint64) string {
+		if l == 0 {
+			return "no limit"
+		}
+		return fmt.Sprint(l)
+	}
+	speed := func(s int64) string {
+		if s == 0 {
+			return "no limit"
+		}
+		return ratelimitUnits(s)
+	}
+	bans := "disabled"
+	if is.PeerBanThreshold > 0 {
+		bans = fmt.Sprintf("after %v invalid payments or programs within %v", is.PeerBanThreshold, is.PeerBanDuration)
+	}
+	fmt.Printf(`Peer Limits:
+  Concurrent Streams:   %v
+  Download Speed:       %v
+  Upload Speed:         %v
+  Programs Per Minute:  %v
+  Bans:                 %v
+`, limit(is.PeerMaxConcurrentStreams), speed(is.PeerMaxDownloadSpeed), speed(is.PeerMaxUploadSpeed),
+		limit(is.PeerMaxProgramsPerMinute), bans)
+}
+
+// hostBannedPeersSummary displays the peers which are temporarily banned by the
+// host.
+func hostBannedPeersSummary(peers []modules.HostBannedPeer) {
+	if len(peers) == 0 {
+		return
+	}
+	fmt.Println("\nBanned Peers:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
+	fmt.Fprintf(w, "\tAddress\tReason\tStrikes\tExpires In\n")
+	for _, peer := range peers {
+		fmt.Fprintf(w, "\t%v\t%v\t%v\t%v\n", peer.Address, peer.Reason, peer.Strikes, time.Until(peer.Expiry).Round(time.Second))
 	}
 	if err := w.Flush(); err != nil {
 		die("failed to flush writer")
@@ -422,14 +582,31 @@ func hostconfigcmd(param, value string) {
 		}
 
 	// timeout (convert to seconds)
-	case "ephemeralaccountexpiry":
+	case "ephemeralaccountexpiry", "peerbanduration":
 		value, err = parseTimeout(value)
 		if err != nil {
 			die("Could not parse "+param+":", err)
 		}
 
+	// speed (convert to bytes per second)
+	case "maxdownloadspeed", "maxuploadspeed", "peermaxdownloadspeed", "peermaxuploadspeed":
+		speed, err := parseRatelimit(value)
+		if err != nil {
+			die("Could not parse "+param+":", err)
+		}
+		value = strconv.FormatInt(speed, 10)
+
+	// bandwidth schedule (convert speeds to bytes per second)
+	case "bandwidthschedule":
+		schedule, err := parseBandwidthSchedule(value)
+		if err != nil {
+			die("Could not parse "+param+":", err)
+		}
+		value = schedule.String()
+
 	// other valid settings
-	case "maxdownloadbatchsize", "maxrevisebatchsize", "netaddress", "customregistrypath":
+	case "maxdownloadbatchsize", "maxrevisebatchsize", "netaddress", "customregistrypath",
+		"peermaxconcurrentstreams", "peermaxprogramsperminute", "peerbanthreshold":
 
 	// invalid settings
 	default:
@@ -453,6 +630,98 @@ func hostconfigcmd(param, value string) {
 	fmt.Printf("Estimated conversion rate: %v%%\n", eg.ConversionRate)
 }
 
+// hostpricingcmd is the handler for the command `ttdxc host pricing`. It shows
+// the state of the host's dynamic pricing engine.
+func hostpricingcmd() {
+	hpg, err := httpClient.HostPricingGet()
+	if err != nil {
+		die("Could not get pricing status:", err)
+	}
+	acceptanceRate := "no recent negotiations"
+	if hpg.AcceptanceRate >= 0 {
+		acceptanceRate = fmt.Sprintf("%.2f%%", hpg.AcceptanceRate*100)
+	}
+	lastUpdate := "never"
+	if !hpg.LastUpdate.IsZero() {
+		lastUpdate = hpg.LastUpdate.Format(time.RFC1123)
+	}
+	fmt.Printf(`Pricing Engine:
+  Enabled:                %v
+  Storage Factors:        %v (empty) - %v (full)
+  Target Acceptance Rate: %v (adjustment %v)
+  Exchange Rate:          %v
+  Target Storage Price:   %v / TB / Month
+  Factor Bounds:          %v - %v
+
+Last Update:              %v
+  Storage Utilization:    %.2f%%
+  Acceptance Rate:        %v
+  Factor:                 %.4f
+
+Prices:
+  Storage Price:            %v / TB / Month
+  Upload Bandwidth Price:   %v / TB
+  Download Bandwidth Price: %v / TB
+  Sector Access Price:      %v
+  Base RPC Price:           %v
+`, yesNo(hpg.Enabled), hpg.EmptyStorageFactor, hpg.FullStorageFactor, hpg.TargetAcceptanceRate, hpg.AcceptanceAdjustment,
+		hpg.ExchangeRate, hpg.TargetStoragePrice, hpg.MinFactor, hpg.MaxFactor, lastUpdate, hpg.StorageUtilization*100,
+		acceptanceRate, hpg.Factor, currencyUnits(hpg.Prices.StoragePrice.Mul(modules.BlockBytesPerMonthTerabyte)),
+		currencyUnits(hpg.Prices.UploadBandwidthPrice.Mul(modules.BytesPerTerabyte)),
+		currencyUnits(hpg.Prices.DownloadBandwidthPrice.Mul(modules.BytesPerTerabyte)),
+		currencyUnits(hpg.Prices.SectorAccessPrice), currencyUnits(hpg.Prices.BaseRPCPrice))
+}
+
+// hostpricingsetcmd is the handler for the command `ttdxc host pricing set
+// [setting] [value]`. It changes a setting of the dynamic pricing engine.
+func hostpricingsetcmd(param, value string) {
+	hpg, err := httpClient.HostPricingGet()
+	if err != nil {
+		die("Could not get pricing settings:", err)
+	}
+	settings := hpg.HostPricingSettings
+	factors := map[string]*float64{
+		"emptystoragefactor":   &settings.EmptyStorageFactor,
+		"fullstoragefactor":    &settings.FullStorageFactor,
+		"targetacceptancerate": &settings.TargetAcceptanceRate,
+		"acceptanceadjustment": &settings.AcceptanceAdjustment,
+		"targetstorageprice":   &settings.TargetStoragePrice,
+		"minfactor":            &settings.MinFactor,
+		"maxfactor":            &settings.MaxFactor,
+	}
+	switch param {
+	case "enabled":
+		switch strings.ToLower(value) {
+		case "yes":
+			value = "true"
+		case "no":
+			value = "false"
+		}
+		settings.Enabled, err = strconv.ParseBool(value)
+		if err != nil {
+			die("Could not parse "+param+":", err)
+		}
+	case "exchangerate":
+		if value == "none" {
+			value = ""
+		}
+		settings.ExchangeRate = value
+	default:
+		f, ok := factors[param]
+		if !ok {
+			die("\"" + param + "\" is not a pricing setting")
+		}
+		*f, err = strconv.ParseFloat(value, 64)
+		if err != nil {
+			die("Could not parse "+param+":", err)
+		}
+	}
+	if err := httpClient.HostPricingPost(settings); err != nil {
+		die("Failed to update pricing settings:", err)
+	}
+	fmt.Println("Pricing settings updated.")
+}
+
 // hostcontractcmd is the handler for the command `ttdxc host contracts [type]`.
 func hostcontractcmd() {
 	cg, err := httpClient.HostContractInfoGet()
@@ -569,6 +838,230 @@ func hostfolderresizecmd(path, newsize string) {
 	fmt.Printf("Resized folder %v to %v\n", path, newsize)
 }
 
+// hostscrubcmd is the handler for the command `ttdxc host scrub`. It shows the
+// state of the host's storage scrubber.
+func hostscrubcmd() {
+	ssg, err := httpClient.HostStorageScrubGet()
+	if err != nil {
+		die("Could not get scrub status:", err)
+	}
+	maxSpeed := "unlimited"
+	if ssg.MaxBytesPerSecond > 0 {
+		maxSpeed = ratelimitUnits(int64(ssg.MaxBytesPerSecond))
+	}
+	lastStart, lastFinish := "never", "never"
+	if !ssg.LastStart.IsZero() {
+		lastStart = ssg.LastStart.Format(time.RFC1123)
+	}
+	if !ssg.LastFinish.IsZero() {
+		lastFinish = ssg.LastFinish.Format(time.RFC1123)
+	}
+	progress := "idle"
+	if ssg.Running && ssg.TotalSectors > 0 {
+		progress = fmt.Sprintf("%v / %v sectors (%.2f%%)", ssg.SectorsScrubbed, ssg.TotalSectors, float64(ssg.SectorsScrubbed)/float64(ssg.TotalSectors)*100)
+	} else if ssg.Running {
+		progress = "running"
+	}
+	fmt.Printf(`Storage Scrubber:
+  Enabled:         %v
+  Interval:        %v
+  Max Read Speed:  %v
+  Progress:        %v
+  Last Start:      %v
+  Last Finish:     %v
+  Corrupt Sectors: %v
+  Missing Sectors: %v
+  At Risk:         %v contracts
+`, yesNo(ssg.Enabled), ssg.Interval, maxSpeed, progress, lastStart, lastFinish,
+		ssg.CorruptSectors, ssg.MissingSectors, len(ssg.AtRiskContracts))
+
+	if len(ssg.Findings) > 0 {
+		fmt.Println("\nFindings:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
+		fmt.Fprintf(w, "\tFolder\tIndex\tProblem\tDetected\n")
+		for _, f := range ssg.Findings {
+			problem := "corrupt"
+			if f.Missing {
+				problem = "missing"
+			}
+			fmt.Fprintf(w, "\t%v\t%v\t%v\t%v\n", f.Path, f.SectorIndex, problem, f.DetectedAt.Format(time.RFC1123))
+		}
+		if err := w.Flush(); err != nil {
+			die("failed to flush writer:", err)
+		}
+	}
+	if len(ssg.AtRiskContracts) > 0 {
+		fmt.Println("\nContracts At Risk:")
+		for _, fcid := range ssg.AtRiskContracts {
+			fmt.Println("  " + fcid.String())
+		}
+	}
+}
+
+// hostscrubsetcmd is the handler for the command `ttdxc host scrub set
+// [setting] [value]`. It changes a setting of the storage scrubber.
+func hostscrubsetcmd(param, value string) {
+	ssg, err := httpClient.HostStorageScrubGet()
+	if err != nil {
+		die("Could not get scrub settings:", err)
+	}
+	settings := ssg.StorageScrubSettings
+	switch param {
+	case "enabled":
+		switch strings.ToLower(value) {
+		case "yes":
+			value = "true"
+		case "no":
+			value = "false"
+		}
+		settings.Enabled, err = strconv.ParseBool(value)
+		if err != nil {
+			die("Could not parse "+param+":", err)
+		}
+	case "interval":
+		seconds, err := parseTimeout(value)
+		if err != nil {
+			die("Could not parse "+param+":", err)
+		}
+		s, err := strconv.ParseUint(seconds, 10, 64)
+		if err != nil {
+			die("Could not parse "+param+":", err)
+		}
+		settings.Interval = time.Duration(s) * time.Second
+	case "maxbytespersecond":
+		speed, err := parseRatelimit(value)
+		if err != nil {
+			die("Could not parse "+param+":", err)
+		}
+		settings.MaxBytesPerSecond = uint64(speed)
+	default:
+		die("\"" + param + "\" is not a scrub setting")
+	}
+	if err := httpClient.HostStorageScrubPost(settings, false); err != nil {
+		die("Failed to update scrub settings:", err)
+	}
+	fmt.Println("Scrub settings updated.")
+}
+
+// hostscrubstartcmd is the handler for the command `ttdxc host scrub start`.
+// It starts a scrub of the host's storage.
+func hostscrubstartcmd() {
+	ssg, err := httpClient.HostStorageScrubGet()
+	if err != nil {
+		die("Could not get scrub settings:", err)
+	}
+	if err := httpClient.HostStorageScrubPost(ssg.StorageScrubSettings, true); err != nil {
+		die("Failed to start scrub:", err)
+	}
+	fmt.Println("Scrub started.")
+}
+
+// hostfinancialscmd is the handler for the command `ttdxc host financials`.
+// It prints the host's revenue ledger.
+func hostfinancialscmd() {
+	from, to := time.Unix(0, 0), time.Now()
+	var err error
+	if hostFinancialsFrom != "" {
+		from, err = time.Parse(api.HostFinancialsDateFormat, hostFinancialsFrom)
+		if err != nil {
+			die("Could not parse --from:", err)
+		}
+	}
+	if hostFinancialsTo != "" {
+		to, err = time.Parse(api.HostFinancialsDateFormat, hostFinancialsTo)
+		if err != nil {
+			die("Could not parse --to:", err)
+		}
+	}
+
+	switch hostFinancialsFormat {
+	case "csv":
+		csv, err := httpClient.HostFinancialsCSVGet(from, to)
+		if err != nil {
+			die("Could not get host financials:", err)
+		}
+		fmt.Print(string(csv))
+		return
+	case "json":
+		hfg, err := httpClient.HostFinancialsGet(from, to)
+		if err != nil {
+			die("Could not get host financials:", err)
+		}
+		json, err := json.MarshalIndent(hfg.Entries, "", "\t")
+		if err != nil {
+			die("Could not marshal the json output:", err)
+		}
+		fmt.Println(string(json))
+		return
+	case "table":
+	default:
+		die("Unknown format, use either 'table', 'csv' or 'json'")
+	}
+
+	hfg, err := httpClient.HostFinancialsGet(from, to)
+	if err != nil {
+		die("Could not get host financials:", err)
+	}
+	if len(hfg.Entries) == 0 {
+		fmt.Println("No ledger entries.")
+		return
+	}
+
+	// Sum up the entries of every day. The entries are sorted by day.
+	var days []modules.HostLedgerEntry
+	var total modules.HostLedgerEntry
+	for _, e := range hfg.Entries {
+		if len(days) == 0 || !days[len(days)-1].Day.Equal(e.Day) {
+			days = append(days, modules.HostLedgerEntry{Day: e.Day})
+		}
+		day := &days[len(days)-1]
+		for _, sum := range []*modules.HostLedgerEntry{day, &total} {
+			sum.AccountFunding = sum.AccountFunding.Add(e.AccountFunding)
+			sum.ContractCompensation = sum.ContractCompensation.Add(e.ContractCompensation)
+			sum.DownloadRevenue = sum.DownloadRevenue.Add(e.DownloadRevenue)
+			sum.StorageRevenue = sum.StorageRevenue.Add(e.StorageRevenue)
+			sum.UploadRevenue = sum.UploadRevenue.Add(e.UploadRevenue)
+			sum.RegistryRevenue = sum.RegistryRevenue.Add(e.RegistryRevenue)
+			sum.RPCRevenue = sum.RPCRevenue.Add(e.RPCRevenue)
+			sum.AccountRegistrySpending = sum.AccountRegistrySpending.Add(e.AccountRegistrySpending)
+			sum.AccountRPCSpending = sum.AccountRPCSpending.Add(e.AccountRPCSpending)
+			sum.LockedCollateral = sum.LockedCollateral.Add(e.LockedCollateral)
+			sum.LostCollateral = sum.LostCollateral.Add(e.LostCollateral)
+			sum.UnlockedCollateral = sum.UnlockedCollateral.Add(e.UnlockedCollateral)
+			sum.TransactionFees = sum.TransactionFees.Add(e.TransactionFees)
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
+	fmt.Fprintf(w, "Day\tContract Revenue\tAccount Funding\tRPC Revenue\tRegistry Revenue\tAccount Spending\tLocked Collateral\tUnlocked Collateral\tLost Collateral\tTransaction Fees\n")
+	printRow := func(day string, e modules.HostLedgerEntry) {
+		contractRevenue := e.ContractCompensation.Add(e.StorageRevenue).Add(e.UploadRevenue).Add(e.DownloadRevenue)
+		accountSpending := e.AccountRPCSpending.Add(e.AccountRegistrySpending)
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n", day,
+			currencyUnits(contractRevenue), currencyUnits(e.AccountFunding),
+			currencyUnits(e.RPCRevenue), currencyUnits(e.RegistryRevenue),
+			currencyUnits(accountSpending),
+			currencyUnits(e.LockedCollateral), currencyUnits(e.UnlockedCollateral),
+			currencyUnits(e.LostCollateral), currencyUnits(e.TransactionFees))
+	}
+	for _, day := range days {
+		printRow(day.Day.Format(api.HostFinancialsDateFormat), day)
+	}
+	printRow("Total", total)
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// hostfoldertiercmd sets the tier of a folder in the host.
+func hostfoldertiercmd(path, tier string) {
+	err := httpClient.HostStorageFoldersTierPost(abs(path), modules.StorageTier(strings.ToLower(tier)))
+	if err != nil {
+		die("Could not set tier of folder:", err)
+	}
+	fmt.Printf("Set tier of folder %v to %v\n", path, tier)
+}
+
 // hostsectordeletecmd deletes a sector from the host.
 func hostsectordeletecmd(root string) {
 	var hash crypto.Hash
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"math/big"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
@@ -19,9 +22,38 @@ const scanHistoryLen = 30
 var (
 	hostdbNumHosts int
 	hostdbVerbose  bool
+
+	hostdbScoringPolicyName             string  // Name of a tuned scoring policy
+	hostdbScoringAgeWeight              float64 // Weight of the age adjustment
+	hostdbScoringCollateralWeight       float64 // Weight of the collateral adjustment
+	hostdbScoringInteractionWeight      float64 // Weight of the interaction adjustment
+	hostdbScoringPerformanceWeight      float64 // Weight of the performance adjustment
+	hostdbScoringPriceWeight            float64 // Weight of the price adjustment
+	hostdbScoringStorageRemainingWeight float64 // Weight of the storage remaining adjustment
+	hostdbScoringUptimeWeight           float64 // Weight of the uptime adjustment
+
+	hostdbDiversityMaxASNPercentage  float64 // Max percentage of hosts per ASN
+	hostdbDiversityRequiredRegions   string  // Comma separated regions hosts need to be located in
+	hostdbDiversityExcludedCountries string  // Comma separated countries hosts may not be located in
 )
 
 var (
+	hostdbBenchmarkingCmd = &cobra.Command{
+		Use:   "benchmarking",
+		Short: "View whether hosts are benchmarked.",
+		Long:  "View whether the hostDB measures the latency and throughput of hosts while scanning them.",
+		Run:   wrap(hostdbbenchmarkingcmd),
+	}
+
+	hostdbSetBenchmarkingCmd = &cobra.Command{
+		Use:   "setbenchmarking [true/false]",
+		Short: "Enable or disable benchmarking hosts.",
+		Long: `Enable or disable measuring the latency and throughput of hosts while scanning
+them. The benchmarks affect the score of the hosts. The throughput of hosts the
+renter has a contract with is sampled with small paid reads, which costs money.`,
+		Run: wrap(hostdbsetbenchmarkingcmd),
+	}
+
 	hostdbCmd = &cobra.Command{
 		Use:   "hostdb",
 		Short: "Interact with the renter's host database.",
@@ -29,6 +61,23 @@ var (
 		Run:   wrap(hostdbcmd),
 	}
 
+	hostdbDiversityCmd = &cobra.Command{
+		Use:   "diversity",
+		Short: "View the host diversity constraints.",
+		Long:  "View the constraints that spread the renter's contracts across networks and locations.",
+		Run:   wrap(hostdbdiversitycmd),
+	}
+
+	hostdbSetDiversityCmd = &cobra.Command{
+		Use:   "setdiversity",
+		Short: "Set the host diversity constraints.",
+		Long: `Set the constraints that spread the renter's contracts across networks and
+locations. The network and location of the hosts are looked up in the IP
+database 'ipdb.csv' within the renter's persist dir. Only the constraints whose
+flags are set are changed, setting a list to "" clears it.`,
+		Run: hostdbsetdiversitycmd,
+	}
+
 	hostdbFiltermodeCmd = &cobra.Command{
 		Use:   "filtermode",
 		Short: "View hostDB filtermode.",
@@ -45,6 +94,44 @@ var (
 		Run: hostdbsetfiltermodecmd,
 	}
 
+	hostdbGroupsCmd = &cobra.Command{
+		Use:   "groups",
+		Short: "View the host groups.",
+		Long:  "View the named host groups of the hostDB and the hosts they contain.",
+		Run:   wrap(hostdbgroupscmd),
+	}
+
+	hostdbSetGroupCmd = &cobra.Command{
+		Use:   "setgroup [group] [host] [host] [host]...",
+		Short: "Set the hosts of a host group.",
+		Long: `Set the hosts of a named host group, replacing its previous hosts.
+        [group] is the name of the group. Names consist of lowercase letters,
+        digits, '-' and '_'.
+        [host] is the host public key. If no hosts are provided, the group is
+        removed.
+The number of contracts to hold with hosts of each group can be set with
+'ttdxc renter setallowance --host-group-targets'.`,
+		Run: hostdbsetgroupcmd,
+	}
+
+	hostdbScoringPolicyCmd = &cobra.Command{
+		Use:   "scoringpolicy",
+		Short: "View the hostDB scoring policy.",
+		Long:  "View the policy the hostDB uses to score hosts and the predefined profiles it can be composed of.",
+		Run:   wrap(hostdbscoringpolicycmd),
+	}
+
+	hostdbSetScoringPolicyCmd = &cobra.Command{
+		Use:   "setscoringpolicy [profile] [profile]...",
+		Short: "Set the scoring policy.",
+		Long: `Set the policy the hostDB uses to score hosts. The policy is composed of
+the provided profiles, the weights of which are multiplied. Without profiles the
+default profile is used. The weights of the composed policy can be tuned with
+the weight flags.
+        [profile] can be cheapest, default, latency-first or max-collateral.`,
+		Run: hostdbsetscoringpolicycmd,
+	}
+
 	hostdbViewCmd = &cobra.Command{
 		Use:   "view [pubkey]",
 		Short: "View the full information for a host.",
@@ -63,11 +150,33 @@ func printScoreBreakdown(info *api.HostdbHostsGET) {
 	fmt.Fprintf(w, "\t\tCollateral:\t %.3f\n", info.ScoreBreakdown.CollateralAdjustment/1e96)
 	fmt.Fprintf(w, "\t\tDuration:\t %.3f\n", info.ScoreBreakdown.DurationAdjustment)
 	fmt.Fprintf(w, "\t\tInteraction:\t %.3f\n", info.ScoreBreakdown.InteractionAdjustment)
+	fmt.Fprintf(w, "\t\tPerformance:\t %.3f\n", info.ScoreBreakdown.PerformanceAdjustment)
 	fmt.Fprintf(w, "\t\tPrice:\t %.3f\n", info.ScoreBreakdown.PriceAdjustment*1e24)
 	fmt.Fprintf(w, "\t\tStorage:\t %.3f\n", info.ScoreBreakdown.StorageRemainingAdjustment)
 	fmt.Fprintf(w, "\t\tUptime:\t %.3f\n", info.ScoreBreakdown.UptimeAdjustment)
 	fmt.Fprintf(w, "\t\tVersion:\t %.3f\n", info.ScoreBreakdown.VersionAdjustment)
 	fmt.Fprintf(w, "\t\tConversion Rate:\t %.3f\n", info.ScoreBreakdown.ConversionRate)
+	fmt.Fprintf(w, "\t\tScoring Policy:\t %v\n", info.ScoreBreakdown.ScoringPolicy)
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer")
+	}
+}
+
+// printBenchmarks prints the medians of a host's benchmarks.
+func printBenchmarks(b modules.HostBenchmarks) {
+	if len(b.RTT) == 0 {
+		return
+	}
+	fmt.Println("\n  Benchmarks (median):")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "\t\tRTT:\t %v\t(%v samples)\n", b.MedianRTT(), len(b.RTT))
+	fmt.Fprintf(w, "\t\tPrice Table Latency:\t %v\t(%v samples)\n", b.MedianPriceTableLatency(), len(b.PriceTableLatency))
+	if len(b.ReadThroughput) > 0 {
+		fmt.Fprintf(w, "\t\tRead Throughput:\t %v/s\t(%v samples)\n", modules.FilesizeUnits(b.MedianReadThroughput()), len(b.ReadThroughput))
+	}
+	if len(b.WriteThroughput) > 0 {
+		fmt.Fprintf(w, "\t\tWrite Throughput:\t %v/s\t(%v samples)\n", modules.FilesizeUnits(b.MedianWriteThroughput()), len(b.WriteThroughput))
+	}
 	if err := w.Flush(); err != nil {
 		die("failed to flush writer")
 	}
@@ -96,12 +205,13 @@ func hostdbcmd() {
 			info.Hosts = info.Hosts[len(info.Hosts)-hostdbNumHosts:]
 		}
 
+		groupsByHost := hostGroupsByHost()
 		fmt.Println(len(info.Hosts), "Active Hosts:")
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "\t\tAddress\tVersion\tPrice (per TB per Mo)")
+		fmt.Fprintln(w, "\t\tAddress\tVersion\tPrice (per TB per Mo)\tGroups")
 		for i, host := range info.Hosts {
 			price := host.StoragePrice.Mul(modules.BlockBytesPerMonthTerabyte)
-			fmt.Fprintf(w, "\t%v:\t%v\t%v\t%v\n", len(info.Hosts)-i, host.NetAddress, host.Version, currencyUnits(price))
+			fmt.Fprintf(w, "\t%v:\t%v\t%v\t%v\t%v\n", len(info.Hosts)-i, host.NetAddress, host.Version, currencyUnits(price), formatHostGroups(groupsByHost[host.PublicKeyString]))
 		}
 		if err := w.Flush(); err != nil {
 			die("failed to flush writer")
@@ -314,6 +424,86 @@ func hostdbcmd() {
 	}
 }
 
+// hostdbbenchmarkingcmd is the handler for the command `ttdxc hostdb
+// benchmarking`.
+func hostdbbenchmarkingcmd() {
+	hdg, err := httpClient.HostDbGet()
+	if err != nil {
+		die("Could not get benchmarking status:", err)
+	}
+	fmt.Println("Benchmarking enabled:", hdg.Benchmarking)
+}
+
+// hostdbsetbenchmarkingcmd is the handler for the command `ttdxc hostdb
+// setbenchmarking`. enables or disables benchmarking hosts.
+func hostdbsetbenchmarkingcmd(enabledStr string) {
+	enabled, err := strconv.ParseBool(enabledStr)
+	if err != nil {
+		die("Could not parse benchmarking status:", err)
+	}
+	if err := httpClient.HostDbBenchmarkingPost(enabled); err != nil {
+		die("Could not set benchmarking status:", err)
+	}
+	fmt.Println("Successfully set benchmarking to", enabled)
+}
+
+// hostdbdiversitycmd is the handler for the command `ttdxc hostdb diversity`.
+func hostdbdiversitycmd() {
+	rg, err := httpClient.RenterGet()
+	if err != nil {
+		die("Could not get host diversity constraints:", err)
+	}
+	c := rg.Settings.HostDiversity
+	maxASN := "unlimited"
+	if c.MaxASNPercentage > 0 {
+		maxASN = fmt.Sprintf("%v%%", c.MaxASNPercentage)
+	}
+	regions := "any"
+	if len(c.RequiredRegions) > 0 {
+		regions = strings.Join(c.RequiredRegions, ", ")
+	}
+	countries := "none"
+	if len(c.ExcludedCountries) > 0 {
+		countries = strings.Join(c.ExcludedCountries, ", ")
+	}
+	fmt.Println()
+	fmt.Println("  Max Hosts per ASN: ", maxASN)
+	fmt.Println("  Required Regions:  ", regions)
+	fmt.Println("  Excluded Countries:", countries)
+	fmt.Println()
+}
+
+// hostdbsetdiversitycmd is the handler for the command `ttdxc hostdb
+// setdiversity`. sets the host diversity constraints of the renter.
+func hostdbsetdiversitycmd(cmd *cobra.Command, _ []string) {
+	rg, err := httpClient.RenterGet()
+	if err != nil {
+		die("Could not get host diversity constraints:", err)
+	}
+	c := rg.Settings.HostDiversity
+	if cmd.Flags().Changed("max-asn-percentage") {
+		c.MaxASNPercentage = hostdbDiversityMaxASNPercentage
+	}
+	if cmd.Flags().Changed("required-regions") {
+		c.RequiredRegions = splitLocationCodes(hostdbDiversityRequiredRegions)
+	}
+	if cmd.Flags().Changed("excluded-countries") {
+		c.ExcludedCountries = splitLocationCodes(hostdbDiversityExcludedCountries)
+	}
+	if err := httpClient.RenterSetHostDiversityPost(c); err != nil {
+		die("Could not set host diversity constraints:", err)
+	}
+	fmt.Println("Successfully set the host diversity constraints")
+}
+
+// splitLocationCodes splits a comma separated list of region or country codes.
+func splitLocationCodes(str string) []string {
+	if str == "" {
+		return nil
+	}
+	return strings.Split(str, ",")
+}
+
 // hostdbfiltermodecmd is the handler for the command `ttdxc hostdb
 // filtermode`.
 func hostdbfiltermodecmd() {
@@ -367,6 +557,158 @@ func hostdbsetfiltermodecmd(cmd *cobra.Command, args []string) {
 	fmt.Println("Successfully set the filter mode")
 }
 
+// hostdbgroupscmd is the handler for the command `ttdxc hostdb groups`.
+func hostdbgroupscmd() {
+	hgg, err := httpClient.HostDbGroupsGet()
+	if err != nil {
+		die("Could not get host groups:", err)
+	}
+	if len(hgg.Groups) == 0 {
+		fmt.Println("No host groups")
+		return
+	}
+	names := make([]string, 0, len(hgg.Groups))
+	for name := range hgg.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("\n  %v (%v hosts):\n", name, len(hgg.Groups[name]))
+		for _, host := range hgg.Groups[name] {
+			fmt.Println("    ", host)
+		}
+	}
+	fmt.Println()
+}
+
+// hostdbsetgroupcmd is the handler for the command `ttdxc hostdb setgroup`.
+// sets the hosts of a host group.
+func hostdbsetgroupcmd(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		_ = cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	group := args[0]
+	var hosts []types.TurtleDexPublicKey
+	for _, hostStr := range args[1:] {
+		var host types.TurtleDexPublicKey
+		if err := host.LoadString(hostStr); err != nil {
+			die("Could not parse host public key:", err)
+		}
+		hosts = append(hosts, host)
+	}
+	if err := httpClient.HostDbGroupsPost(group, hosts); err != nil {
+		die("Could not set host group:", err)
+	}
+	if len(hosts) == 0 {
+		fmt.Printf("Successfully removed host group '%v'\n", group)
+		return
+	}
+	fmt.Printf("Successfully set host group '%v' to %v hosts\n", group, len(hosts))
+}
+
+// hostGroupsByHost fetches the host groups of the hostdb and maps the public
+// keys of the hosts to the names of their groups.
+func hostGroupsByHost() map[string][]string {
+	hgg, err := httpClient.HostDbGroupsGet()
+	if err != nil {
+		die("Could not get host groups:", err)
+	}
+	return modules.HostGroupsByHost(hgg.Groups)
+}
+
+// formatHostGroups formats the names of the groups a host belongs to.
+func formatHostGroups(groups []string) string {
+	if len(groups) == 0 {
+		return "-"
+	}
+	return strings.Join(groups, ",")
+}
+
+// formatHostGroupTargets formats the host group targets of an allowance.
+func formatHostGroupTargets(targets map[string]uint64) string {
+	if len(targets) == 0 {
+		return "none"
+	}
+	return modules.FormatHostGroupTargets(targets)
+}
+
+// hostdbscoringpolicycmd is the handler for the command `ttdxc hostdb
+// scoringpolicy`.
+func hostdbscoringpolicycmd() {
+	hdspg, err := httpClient.HostDbScoringPolicyGet()
+	if err != nil {
+		die("Could not get hostdb scoring policy:", err)
+	}
+	fmt.Println()
+	fmt.Println("  HostDB Scoring Policy:", hdspg.Policy.Name)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	printScoringPolicyWeights(w, hdspg.Policy)
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer")
+	}
+
+	fmt.Println("\n  Profiles:")
+	fmt.Fprintln(w, "\t\tName\tAge\tCollateral\tInteraction\tPerformance\tPrice\tStorage\tUptime")
+	for _, name := range modules.HostScoringProfileNames() {
+		p, exists := hdspg.Profiles[name]
+		if !exists {
+			continue
+		}
+		fmt.Fprintf(w, "\t\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n", p.Name, p.AgeWeight, p.CollateralWeight, p.InteractionWeight, p.PerformanceWeight, p.PriceWeight, p.StorageRemainingWeight, p.UptimeWeight)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer")
+	}
+	fmt.Println()
+}
+
+// hostdbsetscoringpolicycmd is the handler for the command `ttdxc hostdb
+// setscoringpolicy`. sets the policy the hostdb uses to score hosts.
+func hostdbsetscoringpolicycmd(cmd *cobra.Command, args []string) {
+	params := api.HostdbScoringPolicyPOST{
+		Name:     hostdbScoringPolicyName,
+		Profiles: args,
+	}
+	// Only the weights that were set override the weights of the profiles.
+	if cmd.Flags().Changed("age-weight") {
+		params.AgeWeight = &hostdbScoringAgeWeight
+	}
+	if cmd.Flags().Changed("collateral-weight") {
+		params.CollateralWeight = &hostdbScoringCollateralWeight
+	}
+	if cmd.Flags().Changed("interaction-weight") {
+		params.InteractionWeight = &hostdbScoringInteractionWeight
+	}
+	if cmd.Flags().Changed("performance-weight") {
+		params.PerformanceWeight = &hostdbScoringPerformanceWeight
+	}
+	if cmd.Flags().Changed("price-weight") {
+		params.PriceWeight = &hostdbScoringPriceWeight
+	}
+	if cmd.Flags().Changed("storage-weight") {
+		params.StorageRemainingWeight = &hostdbScoringStorageRemainingWeight
+	}
+	if cmd.Flags().Changed("uptime-weight") {
+		params.UptimeWeight = &hostdbScoringUptimeWeight
+	}
+	if err := httpClient.HostDbScoringPolicyPost(params); err != nil {
+		die("Could not set hostdb scoring policy:", err)
+	}
+	fmt.Println("Successfully set the scoring policy")
+}
+
+// printScoringPolicyWeights prints the weights of a scoring policy.
+func printScoringPolicyWeights(w *tabwriter.Writer, p modules.HostScoringPolicy) {
+	fmt.Fprintf(w, "\t\tAge:\t %v\n", p.AgeWeight)
+	fmt.Fprintf(w, "\t\tCollateral:\t %v\n", p.CollateralWeight)
+	fmt.Fprintf(w, "\t\tInteraction:\t %v\n", p.InteractionWeight)
+	fmt.Fprintf(w, "\t\tPerformance:\t %v\n", p.PerformanceWeight)
+	fmt.Fprintf(w, "\t\tPrice:\t %v\n", p.PriceWeight)
+	fmt.Fprintf(w, "\t\tStorage:\t %v\n", p.StorageRemainingWeight)
+	fmt.Fprintf(w, "\t\tUptime:\t %v\n", p.UptimeWeight)
+}
+
 // hostdbviewcmd is the handler for the command `ttdxc hostdb view`.
 // shows detailed information about a host in the hostdb.
 func hostdbviewcmd(pubkey string) {
@@ -384,9 +726,15 @@ func hostdbviewcmd(pubkey string) {
 	fmt.Println("  Block First Seen:         ", info.Entry.FirstSeen)
 	fmt.Println("  Absolute Score:           ", info.ScoreBreakdown.Score)
 	fmt.Println("  Filtered:                 ", info.Entry.Filtered)
+	fmt.Println("  Groups:                   ", formatHostGroups(hostGroupsByHost()[info.Entry.PublicKeyString]))
 	fmt.Println("  NetAddress:               ", info.Entry.NetAddress)
 	fmt.Println("  Last IP Net Change:       ", info.Entry.LastIPNetChange)
 	fmt.Println("  Number of IP Net Changes: ", len(info.Entry.IPNets))
+	if info.Entry.ASN != 0 {
+		fmt.Println("  ASN:                      ", info.Entry.ASN)
+		fmt.Println("  Country:                  ", info.Entry.Country)
+		fmt.Println("  Region:                   ", info.Entry.Region)
+	}
 
 	fmt.Println("\n  Host Settings:")
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
@@ -415,6 +763,7 @@ func hostdbviewcmd(pubkey string) {
 	}
 
 	printScoreBreakdown(&info)
+	printBenchmarks(info.Entry.Benchmarks)
 
 	// Compute the total measured uptime and total measured downtime for this
 	// host.
@@ -32,22 +32,55 @@ var (
 
 	// Host Flags
 	hostContractOutputType string // output type for host contracts
+	hostFinancialsFormat   string // output format of the host's ledger
+	hostFinancialsFrom     string // first day of the host's ledger
+	hostFinancialsTo       string // last day of the host's ledger
 	hostFolderRemoveForce  bool   // force folder remove
 
 	// Renter Flags
-	dataPieces                string // the number of data pieces a file should be uploaded with
-	parityPieces              string // the number of parity pieces a file should be uploaded with
-	renterAllContracts        bool   // Show all active and expired contracts
-	renterBubbleAll           bool   // Bubble the entire directory tree
-	renterDeleteRoot          bool   // Delete path start from root instead of the UserFolder.
-	renterDownloadAsync       bool   // Downloads files asynchronously
-	renterDownloadRecursive   bool   // Downloads folders recursively.
-	renterDownloadRoot        bool   // Download path start from root instead of the UserFolder.
-	renterFuseMountAllowOther bool   // Mount fuse with 'AllowOther' set to true.
-	renterListRecursive       bool   // List files of folder recursively.
-	renterListRoot            bool   // List path start from root instead of the UserFolder.
-	renterRenameRoot          bool   // Rename files relative to root instead of the UserFolder.
-	renterShowHistory         bool   // Show download history in addition to download queue.
+	dataPieces                string   // the number of data pieces a file should be uploaded with
+	parityPieces              string   // the number of parity pieces a file should be uploaded with
+	renterAllContracts        bool     // Show all active and expired contracts
+	renterBubbleAll           bool     // Bubble the entire directory tree
+	renterBudgetDownload      string   // Download limit of a budget.
+	renterBudgetRegistry      string   // Registry limit of a budget.
+	renterBudgetUpload        string   // Upload limit of a budget.
+	renterDeleteRoot          bool     // Delete path start from root instead of the UserFolder.
+	renterDownloadAsync       bool     // Downloads files asynchronously
+	renterDownloadPriority    string   // Priority class of downloads.
+	renterDownloadRecursive   bool     // Downloads folders recursively.
+	renterDownloadRoot        bool     // Download path start from root instead of the UserFolder.
+	renterFindDir             string   // Directory to search for files.
+	renterFindHasSkylink      string   // Only find files with or without skylinks.
+	renterFindLimit           uint64   // Maximum number of files to find.
+	renterFindMaxHealth       string   // Maximum health of the files to find.
+	renterFindMaxSize         string   // Maximum size of the files to find.
+	renterFindMinHealth       string   // Minimum health of the files to find.
+	renterFindMinSize         string   // Minimum size of the files to find.
+	renterFindPrefix          string   // Prefix of the paths of the files to find.
+	renterFindRoot            bool     // Search from root instead of the UserFolder.
+	renterFindSubstring       string   // Substring of the paths of the files to find.
+	renterFuseMountAllowOther bool     // Mount fuse with 'AllowOther' set to true.
+	renterFuseMountReadOnly   bool     // Mount fuse with 'ReadOnly' set to true.
+	renterListRecursive       bool     // List files of folder recursively.
+	renterListRoot            bool     // List path start from root instead of the UserFolder.
+	renterMkdirQuota          string   // Quota of the directory before redundancy.
+	renterMkdirRedundantQuota string   // Quota of the directory after redundancy.
+	renterRatelimitSchedule   string   // Bandwidth schedule of the renter.
+	renterRenameRoot          bool     // Rename files relative to root instead of the UserFolder.
+	renterRepairMaxChunks     string   // Maximum number of concurrently repaired chunks.
+	renterRepairMaxSpeed      string   // Maximum repair speed.
+	renterRepairOnly          string   // Whether new uploads are paused in favor of repairs.
+	renterShowHistory         bool     // Show download history in addition to download queue.
+	renterSpendingContract    string   // Only show spending on this contract.
+	renterSpendingFormat      string   // Output format of the spending history.
+	renterSpendingHost        string   // Only show spending with this host.
+	renterSpendingLimit       uint64   // Maximum number of spending records to show.
+	renterSpendingMaxHeight   uint64   // Maximum block height of the spending records to show.
+	renterSpendingMinHeight   uint64   // Minimum block height of the spending records to show.
+	renterSpendingSince       string   // Only show spending within this duration.
+	renterSpendingTypes       string   // Comma separated types of the spending records to show.
+	renterUploadMetadata      []string // Metadata to attach to uploaded files.
 
 	// Renter Allowance Flags
 	allowanceFunds       string // amount of money to be used within a period
@@ -68,6 +101,8 @@ var (
 	allowanceMaxSectorAccessPrice      string // max allowed price to access a sector on a host
 	allowanceMaxStoragePrice           string // max allowed price to store data on a host
 	allowanceMaxUploadBandwidthPrice   string // max allowed price to upload data to a host
+	allowanceHostGroupTargets          string // comma separated contract targets of host groups
+	allowanceDryRun                    bool   // show the contract maintenance plan without changing anything
 
 	// Skykey Flags
 	skykeyID              string // ID used to identify a Skykey.
@@ -235,6 +270,22 @@ Gateway `)
 	fmt.Printf(`
 Renter `)
 	rateLimitSummary(rg.Settings.MaxDownloadSpeed, rg.Settings.MaxUploadSpeed)
+	bandwidthScheduleSummary(rg.Settings.BandwidthSchedule)
+}
+
+// bandwidthScheduleSummary displays the windows of a bandwidth schedule.
+func bandwidthScheduleSummary(schedule modules.BandwidthSchedule) {
+	if len(schedule) == 0 {
+		return
+	}
+	fmt.Println("Bandwidth Schedule:")
+	for _, w := range schedule {
+		download, upload := "no limit", "no limit"
+		if w.MaxDownloadSpeed != 0 || w.MaxUploadSpeed != 0 {
+			download, upload = ratelimitUnits(w.MaxDownloadSpeed), ratelimitUnits(w.MaxUploadSpeed)
+		}
+		fmt.Printf("  %v\n    Download Speed: %v\n    Upload Speed:   %v\n", w.Period(), download, upload)
+	}
 }
 
 // rateLimitSummary displays the a summary of the provided rate limits
@@ -317,33 +368,59 @@ func initCmds() *cobra.Command {
 	gatewayBlocklistCmd.AddCommand(gatewayBlocklistAppendCmd, gatewayBlocklistClearCmd, gatewayBlocklistRemoveCmd, gatewayBlocklistSetCmd)
 
 	root.AddCommand(hostCmd)
-	hostCmd.AddCommand(hostAnnounceCmd, hostConfigCmd, hostContractCmd, hostFolderCmd, hostSectorCmd)
-	hostFolderCmd.AddCommand(hostFolderAddCmd, hostFolderRemoveCmd, hostFolderResizeCmd)
+	hostCmd.AddCommand(hostAnnounceCmd, hostConfigCmd, hostContractCmd, hostFinancialsCmd, hostFolderCmd, hostPricingCmd, hostScrubCmd, hostSectorCmd)
+	hostPricingCmd.AddCommand(hostPricingSetCmd)
+	hostScrubCmd.AddCommand(hostScrubSetCmd, hostScrubStartCmd)
+	hostFolderCmd.AddCommand(hostFolderAddCmd, hostFolderRemoveCmd, hostFolderResizeCmd, hostFolderTierCmd)
 	hostSectorCmd.AddCommand(hostSectorDeleteCmd)
 	hostContractCmd.Flags().StringVarP(&hostContractOutputType, "type", "t", "value", "Select output type")
+	hostFinancialsCmd.Flags().StringVar(&hostFinancialsFrom, "from", "", "First day of the ledger, e.g. 2021-01-01")
+	hostFinancialsCmd.Flags().StringVar(&hostFinancialsTo, "to", "", "Last day of the ledger, e.g. 2021-12-31")
+	hostFinancialsCmd.Flags().StringVar(&hostFinancialsFormat, "format", "table", "Output format, either 'table', 'csv' or 'json'")
 	hostFolderRemoveCmd.Flags().BoolVarP(&hostFolderRemoveForce, "force", "f", false, "Force the removal of the folder and its data")
 
 	root.AddCommand(hostdbCmd)
-	hostdbCmd.AddCommand(hostdbFiltermodeCmd, hostdbSetFiltermodeCmd, hostdbViewCmd)
+	hostdbCmd.AddCommand(hostdbBenchmarkingCmd, hostdbDiversityCmd, hostdbFiltermodeCmd, hostdbGroupsCmd, hostdbScoringPolicyCmd, hostdbSetBenchmarkingCmd, hostdbSetDiversityCmd, hostdbSetFiltermodeCmd, hostdbSetGroupCmd, hostdbSetScoringPolicyCmd, hostdbViewCmd)
+	hostdbSetDiversityCmd.Flags().Float64Var(&hostdbDiversityMaxASNPercentage, "max-asn-percentage", 0, "Max percentage of the allowance's hosts that may belong to the same ASN, 0 disables the limit")
+	hostdbSetDiversityCmd.Flags().StringVar(&hostdbDiversityRequiredRegions, "required-regions", "", "Comma separated list of regions hosts need to be located in")
+	hostdbSetDiversityCmd.Flags().StringVar(&hostdbDiversityExcludedCountries, "excluded-countries", "", "Comma separated list of countries hosts may not be located in")
+	hostdbSetScoringPolicyCmd.Flags().StringVar(&hostdbScoringPolicyName, "name", "", "Name of the scoring policy, defaults to the names of the profiles or 'custom' if weights are set")
+	hostdbSetScoringPolicyCmd.Flags().Float64Var(&hostdbScoringAgeWeight, "age-weight", 1, "Weight of the age adjustment")
+	hostdbSetScoringPolicyCmd.Flags().Float64Var(&hostdbScoringCollateralWeight, "collateral-weight", 1, "Weight of the collateral adjustment")
+	hostdbSetScoringPolicyCmd.Flags().Float64Var(&hostdbScoringInteractionWeight, "interaction-weight", 1, "Weight of the interaction adjustment")
+	hostdbSetScoringPolicyCmd.Flags().Float64Var(&hostdbScoringPerformanceWeight, "performance-weight", 1, "Weight of the performance adjustment")
+	hostdbSetScoringPolicyCmd.Flags().Float64Var(&hostdbScoringPriceWeight, "price-weight", 1, "Weight of the price adjustment")
+	hostdbSetScoringPolicyCmd.Flags().Float64Var(&hostdbScoringStorageRemainingWeight, "storage-weight", 1, "Weight of the storage remaining adjustment")
+	hostdbSetScoringPolicyCmd.Flags().Float64Var(&hostdbScoringUptimeWeight, "uptime-weight", 1, "Weight of the uptime adjustment")
 	hostdbCmd.Flags().IntVarP(&hostdbNumHosts, "numhosts", "n", 0, "Number of hosts to display from the hostdb")
 
 	root.AddCommand(minerCmd)
 	minerCmd.AddCommand(minerStartCmd, minerStopCmd)
 
 	root.AddCommand(renterCmd)
-	renterCmd.AddCommand(renterAllowanceCmd, renterBubbleCmd, renterBackupCreateCmd, renterBackupListCmd, renterBackupLoadCmd,
-		renterCleanCmd, renterContractsCmd, renterContractsRecoveryScanProgressCmd, renterDownloadCancelCmd,
-		renterDownloadsCmd, renterExportCmd, renterFilesDeleteCmd, renterFilesDownloadCmd,
+	renterCmd.AddCommand(renterAllowanceCmd, renterBubbleCmd, renterBudgetsCmd, renterBackupCreateCmd, renterBackupListCmd, renterBackupLoadCmd,
+		renterCleanCmd, renterContractsCmd, renterContractsRecoveryScanProgressCmd, renterDirSnapshotsCmd, renterDownloadCancelCmd,
+		renterDownloadsCmd, renterExportCmd, renterFilesDeleteCmd, renterFilesDownloadCmd, renterFindCmd,
 		renterFilesListCmd, renterFilesRenameCmd, renterFilesUnstuckCmd, renterFilesUploadCmd,
-		renterFuseCmd, renterLostCmd, renterPricesCmd, renterRatelimitCmd, renterSetAllowanceCmd,
-		renterSetLocalPathCmd, renterTriggerContractRecoveryScanCmd, renterUploadsCmd, renterWorkersCmd,
-		renterHealthSummaryCmd)
+		renterFuseCmd, renterLostCmd, renterMetadataCmd, renterMkdirCmd, renterPricesCmd, renterRatelimitCmd, renterRedundancyCmd, renterRepairCmd, renterSetAllowanceCmd,
+		renterSetLocalPathCmd, renterSpendingCmd, renterTriggerContractRecoveryScanCmd, renterUploadsCmd, renterVersionsCmd,
+		renterWorkersCmd, renterHealthSummaryCmd)
 	renterWorkersCmd.AddCommand(renterWorkersAccountsCmd, renterWorkersDownloadsCmd, renterWorkersPriceTableCmd, renterWorkersReadJobsCmd, renterWorkersHasSectorJobSCmd, renterWorkersUploadsCmd, renterWorkersReadRegistryCmd, renterWorkersUpdateRegistryCmd)
 
 	renterAllowanceCmd.AddCommand(renterAllowanceCancelCmd)
 	renterBubbleCmd.Flags().BoolVarP(&renterBubbleAll, "all", "A", false, "Bubble the entire directory tree")
+	renterBudgetsCmd.AddCommand(renterBudgetsRemoveCmd, renterBudgetsSetCmd)
+	renterBudgetsSetCmd.Flags().StringVar(&renterBudgetDownload, "download", "", "Maximum spending on downloads per period, e.g. '10SC'. No limit if not set")
+	renterBudgetsSetCmd.Flags().StringVar(&renterBudgetRegistry, "registry", "", "Maximum spending on registry reads and updates per period. No limit if not set")
+	renterBudgetsSetCmd.Flags().StringVar(&renterBudgetUpload, "upload", "", "Maximum spending on uploads per period. No limit if not set")
 	renterContractsCmd.AddCommand(renterContractsViewCmd)
 	renterFilesUploadCmd.AddCommand(renterFilesUploadPauseCmd, renterFilesUploadResumeCmd)
+	renterMetadataCmd.AddCommand(renterMetadataFindCmd, renterMetadataRemoveCmd, renterMetadataSetCmd)
+	renterRedundancyCmd.AddCommand(renterRedundancyPauseCmd, renterRedundancyResumeCmd, renterRedundancySetCmd)
+	renterRepairCmd.AddCommand(renterRepairSetCmd)
+	renterVersionsCmd.AddCommand(renterVersionsRestoreCmd, renterVersionsRetentionCmd)
+	renterDirSnapshotsCmd.AddCommand(renterDirSnapshotsCreateCmd, renterDirSnapshotsDeleteCmd, renterDirSnapshotsDiffCmd,
+		renterDirSnapshotsRestoreCmd, renterDirSnapshotsUploadCmd)
 
 	renterContractsCmd.Flags().BoolVarP(&renterAllContracts, "all", "A", false, "Show all expired contracts in addition to active contracts")
 	renterDownloadsCmd.Flags().BoolVarP(&renterShowHistory, "history", "H", false, "Show download history in addition to the download queue")
@@ -351,12 +428,38 @@ func initCmds() *cobra.Command {
 	renterFilesDownloadCmd.Flags().BoolVarP(&renterDownloadAsync, "async", "A", false, "Download file asynchronously")
 	renterFilesDownloadCmd.Flags().BoolVarP(&renterDownloadRecursive, "recursive", "R", false, "Download folder recursively")
 	renterFilesDownloadCmd.Flags().BoolVar(&renterDownloadRoot, "root", false, "Download files and folders from root instead of from the user home directory")
+	renterFilesDownloadCmd.Flags().StringVar(&renterDownloadPriority, "priority", "", "Priority of the download, one of 'interactive', 'normal' or 'bulk'. Defaults to 'normal'")
 	renterFilesListCmd.Flags().BoolVarP(&renterListRecursive, "recursive", "R", false, "Recursively list files and folders")
+	renterFindCmd.Flags().StringVar(&renterFindDir, "dir", "", "Directory to search, defaults to the user home directory")
+	renterRatelimitCmd.Flags().StringVar(&renterRatelimitSchedule, "schedule", "", "Semicolon separated windows with different limits, e.g. 'mon-fri 09:00-17:00 1MB/s 500KB/s'. 'none' removes all windows")
+	renterRepairSetCmd.Flags().StringVar(&renterRepairMaxChunks, "maxchunks", "", "Maximum number of chunks that are repaired at the same time, 0 for no limit")
+	renterRepairSetCmd.Flags().StringVar(&renterRepairMaxSpeed, "maxspeed", "", "Maximum repair speed, e.g. '10MB/s', 0 for no limit")
+	renterRepairSetCmd.Flags().StringVar(&renterRepairOnly, "repaironly", "", "Pause new uploads while repairs keep running, 'true' or 'false'")
+	renterSpendingCmd.Flags().StringVar(&renterSpendingContract, "contract", "", "Only show spending on the contract with this id")
+	renterSpendingCmd.Flags().StringVar(&renterSpendingFormat, "format", "table", "Output format, either 'table', 'json' or 'csv'")
+	renterSpendingCmd.Flags().StringVar(&renterSpendingHost, "host", "", "Only show spending with the host with this public key")
+	renterSpendingCmd.Flags().Uint64Var(&renterSpendingLimit, "limit", 0, "Maximum number of records to show, the most recent ones are shown, 0 for no limit")
+	renterSpendingCmd.Flags().Uint64Var(&renterSpendingMaxHeight, "max-height", 0, "Only show spending up to this block height, 0 for no limit")
+	renterSpendingCmd.Flags().Uint64Var(&renterSpendingMinHeight, "min-height", 0, "Only show spending from this block height on")
+	renterSpendingCmd.Flags().StringVar(&renterSpendingSince, "since", "", "Only show spending within this duration, e.g. '24h'")
+	renterSpendingCmd.Flags().StringVar(&renterSpendingTypes, "types", "", "Comma separated types of spending to show, e.g. 'upload,download'. Types are formation, renewal, upload, download, storage, accountfunding and rpc")
+	renterFindCmd.Flags().StringVar(&renterFindHasSkylink, "has-skylink", "", "Only find files with (true) or without (false) skylinks")
+	renterFindCmd.Flags().Uint64Var(&renterFindLimit, "limit", 0, "Maximum number of files to show, 0 for no limit")
+	renterFindCmd.Flags().StringVar(&renterFindMaxHealth, "max-health", "", "Only find files with a health of at most this value, 0 being the best health")
+	renterFindCmd.Flags().StringVar(&renterFindMaxSize, "max-size", "", "Only find files of at most this size, e.g. 10MB")
+	renterFindCmd.Flags().StringVar(&renterFindMinHealth, "min-health", "", "Only find files with a health of at least this value, 0 being the best health")
+	renterFindCmd.Flags().StringVar(&renterFindMinSize, "min-size", "", "Only find files of at least this size, e.g. 1KB")
+	renterFindCmd.Flags().StringVar(&renterFindPrefix, "prefix", "", "Only find files whose path within the directory starts with the prefix")
+	renterFindCmd.Flags().BoolVar(&renterFindRoot, "root", false, "Search from root instead of from the user home directory")
+	renterFindCmd.Flags().StringVar(&renterFindSubstring, "substring", "", "Only find files whose path within the directory contains the substring, ignoring case")
 	renterFilesListCmd.Flags().BoolVar(&renterListRoot, "root", false, "List files and folders from root instead of from the user home directory")
 	renterFilesUploadCmd.Flags().StringVar(&dataPieces, "data-pieces", "", "the number of data pieces a files should be uploaded with")
 	renterFilesUploadCmd.Flags().StringVar(&parityPieces, "parity-pieces", "", "the number of parity pieces a files should be uploaded with")
+	renterFilesUploadCmd.Flags().StringArrayVar(&renterUploadMetadata, "metadata", nil, "key=value metadata to attach to the uploaded files, can be repeated")
 	renterExportCmd.AddCommand(renterExportContractTxnsCmd)
 	renterFilesRenameCmd.Flags().BoolVar(&renterRenameRoot, "root", false, "Rename files relative to root instead of the user homedir")
+	renterMkdirCmd.Flags().StringVar(&renterMkdirQuota, "quota", "", "maximum size of the directory in bytes (B), kilobytes (KB), megabytes (MB) etc. up to yottabytes (YB), 0 for no limit")
+	renterMkdirCmd.Flags().StringVar(&renterMkdirRedundantQuota, "redundant-quota", "", "maximum size of the directory after redundancy in bytes (B), kilobytes (KB), megabytes (MB) etc. up to yottabytes (YB), 0 for no limit")
 
 	renterSetAllowanceCmd.Flags().StringVar(&allowanceFunds, "amount", "", "amount of money in allowance, specified in currency units")
 	renterSetAllowanceCmd.Flags().StringVar(&allowancePeriod, "period", "", "period of allowance in blocks (b), hours (h), days (d) or weeks (w)")
@@ -373,9 +476,13 @@ func initCmds() *cobra.Command {
 	renterSetAllowanceCmd.Flags().StringVar(&allowanceMaxSectorAccessPrice, "max-sector-access-price", "", "the maximum price that the renter will pay to access a sector on a host")
 	renterSetAllowanceCmd.Flags().StringVar(&allowanceMaxStoragePrice, "max-storage-price", "", "the maximum price that the renter will pay to store data on a host")
 	renterSetAllowanceCmd.Flags().StringVar(&allowanceMaxUploadBandwidthPrice, "max-upload-bandwidth-price", "", "the maximum price that the renter will pay to upload data to a host")
+	renterAllowanceCmd.Flags().BoolVar(&allowanceDryRun, "dry-run", false, "show what contract maintenance would do with the current allowance")
+	renterSetAllowanceCmd.Flags().BoolVar(&allowanceDryRun, "dry-run", false, "show what contract maintenance would do with the new allowance without setting it")
+	renterSetAllowanceCmd.Flags().StringVar(&allowanceHostGroupTargets, "host-group-targets", "", "number of contracts to hold with hosts of each host group, e.g. 'trusted:10,archival:5'. An empty value removes the targets")
 
 	renterFuseCmd.AddCommand(renterFuseMountCmd, renterFuseUnmountCmd)
 	renterFuseMountCmd.Flags().BoolVarP(&renterFuseMountAllowOther, "allow-other", "", false, "Allow users other than the user that mounted the fuse directory to access and use the fuse directory")
+	renterFuseMountCmd.Flags().BoolVarP(&renterFuseMountReadOnly, "read-only", "", false, "Mount the fuse directory in read-only mode")
 
 	root.AddCommand(skynetCmd)
 	skynetCmd.AddCommand(skynetBackupCmd, skynetBlocklistCmd, skynetConvertCmd, skynetDownloadCmd, skynetIsBlockedCmd, skynetLsCmd, skynetPinCmd, skynetPortalsCmd, skynetRestoreCmd, skynetUnpinCmd, skynetUploadCmd)
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/modules"
 	"github.com/turtledex/TurtleDexCore/types"
 	"github.com/turtledex/encoding"
 	"github.com/turtledex/errors"
@@ -290,6 +291,32 @@ func parseRatelimit(rateLimitStr string) (int64, error) {
 	return 0, ErrParseRateLimitUnits
 }
 
+// parseBandwidthSchedule converts a bandwidth schedule with human-readable
+// speeds like "mon-fri 09:00-17:00 1MB/s 500KB/s; * 22:00-06:00 0 0" into a
+// modules.BandwidthSchedule. The string "none" is an empty schedule.
+func parseBandwidthSchedule(scheduleStr string) (modules.BandwidthSchedule, error) {
+	if scheduleStr == "none" {
+		return modules.BandwidthSchedule{}, nil
+	}
+	windows := strings.Split(scheduleStr, ";")
+	for i, window := range windows {
+		fields := strings.Fields(window)
+		if len(fields) != 4 {
+			// Let the modules package report the malformed window.
+			continue
+		}
+		for j := 2; j < len(fields); j++ {
+			speed, err := parseRatelimit(fields[j])
+			if err != nil {
+				return nil, errors.AddContext(err, fmt.Sprintf("unable to parse speed '%v'", fields[j]))
+			}
+			fields[j] = strconv.FormatInt(speed, 10)
+		}
+		windows[i] = strings.Join(fields, " ")
+	}
+	return modules.ParseBandwidthSchedule(strings.Join(windows, ";"))
+}
+
 // ratelimitUnits converts an int64 to a string with human-readable ratelimit
 // units. The unit used will be the largest unit that results in a value greater
 // than 1. The value is rounded to 4 significant digits.
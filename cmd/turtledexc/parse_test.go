@@ -5,6 +5,7 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/turtledex/TurtleDexCore/modules"
 	"github.com/turtledex/TurtleDexCore/types"
 	"github.com/turtledex/errors"
 	"github.com/turtledex/fastrand"
@@ -235,6 +236,28 @@ func TestParseRatelimit(t *testing.T) {
 	}
 }
 
+// TestParseBandwidthSchedule probes the parseBandwidthSchedule function
+func TestParseBandwidthSchedule(t *testing.T) {
+	schedule, err := parseBandwidthSchedule("mon-fri 09:00-17:00 1MB/s 8Kbps; * 22:00-06:00 0 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "mon,tue,wed,thu,fri 09:00-17:00 1000000 1000; * 22:00-06:00 0 0"
+	if schedule.String() != expected {
+		t.Fatalf("expected '%v' but got '%v'", expected, schedule.String())
+	}
+	schedule, err = parseBandwidthSchedule("none")
+	if err != nil || len(schedule) != 0 {
+		t.Fatal("expected empty schedule", schedule, err)
+	}
+	if _, err := parseBandwidthSchedule("* 09:00-17:00 1 2"); !errors.Contains(err, ErrParseRateLimitUnits) {
+		t.Fatal("expected ErrParseRateLimitUnits", err)
+	}
+	if _, err := parseBandwidthSchedule("* 09:00-17:00 1MB/s"); !errors.Contains(err, modules.ErrInvalidBandwidthSchedule) {
+		t.Fatal("expected ErrInvalidBandwidthSchedule", err)
+	}
+}
+
 // TestParsePercentages probes the parsePercentages function
 func TestParsePercentages(t *testing.T) {
 	tests := []struct {
@@ -85,6 +85,35 @@ To bubble the root directory pass in '.' as the directory.`,
 		Run:   wrap(renterbackuplistcmd),
 	}
 
+	renterBudgetsCmd = &cobra.Command{
+		Use:   "budgets",
+		Short: "View the budgets of API callers",
+		Long: `View the budgets of the callers of the renter's API and what they spent in
+the current period. Callers are identified by the API token they pass as the
+username of the basic auth credentials or by their user agent. S3 gateway
+callers are identified by their access key ID and fuse mounts by 'fuse'.
+Callers without a budget of their own share the 'default' budget.`,
+		Run: wrap(renterbudgetscmd),
+	}
+
+	renterBudgetsRemoveCmd = &cobra.Command{
+		Use:   "remove [identity]",
+		Short: "Remove the budget of an API caller",
+		Long:  "Remove the budget of an API caller. The caller's spending is charged to the 'default' budget from then on.",
+		Run:   wrap(renterbudgetsremovecmd),
+	}
+
+	renterBudgetsSetCmd = &cobra.Command{
+		Use:   "set [identity]",
+		Short: "Set the budget of an API caller",
+		Long: `Set the maximum amount an API caller may spend on uploads, downloads and
+registry operations per period. [identity] is the API token or user agent of
+the caller. Set the budget of 'default' to limit all callers without a budget
+of their own. Limits which are not set are unlimited. Operations exceeding the
+budget fail with '402 Payment Required'.`,
+		Run: wrap(renterbudgetssetcmd),
+	}
+
 	renterCleanCmd = &cobra.Command{
 		Use:   "clean",
 		Short: "Cleans up lost files",
@@ -153,6 +182,17 @@ the command 'ttdxc renter lost' to see the renter's lost files.`,
 		Run:   wrap(renterfilesdownloadcmd),
 	}
 
+	renterFindCmd = &cobra.Command{
+		Use:   "find [pattern]",
+		Short: "Search for files",
+		Long: `Search for files using the renter's search index. The optional pattern is a
+shell pattern like '*.jpg'. Patterns without a '/' are matched against the
+names of the files, all other patterns against their paths within the searched
+directory. The flags can be used to further filter the files by their path,
+size, health and whether they have a skylink.`,
+		Run: renterfindcmd,
+	}
+
 	renterFilesListCmd = &cobra.Command{
 		Use:   "ls [path]",
 		Short: "List the status of a specific file or all files within specified dir",
@@ -179,10 +219,10 @@ the command 'ttdxc renter lost' to see the renter's lost files.`,
 		Use:   "mount [path] [siapath]",
 		Short: "Mount a TurtleDex folder to your disk",
 		Long: `Mount a TurtleDex folder to your disk. Applications will be able to see this folder
-as though it is a normal part of your filesystem.  Currently experimental, and
-read-only. When TurtleDex is ready to support read-write fuse mounting, ttdxc will be
-updated to mount in read-write mode as the default. If you must guarantee that
-read-only mode is used, you must use the API.`,
+as though it is a normal part of your filesystem.  Currently experimental. The
+folder is mounted read-write by default, use the --read-only flag to prevent
+applications from modifying the mounted files. Writes are buffered locally and
+uploaded when the file is closed.`,
 		Run: wrap(renterfusemountcmd),
 	}
 
@@ -201,6 +241,17 @@ local path where the TurtleDex folder is mounted.`,
 		Run:   wrap(rentersetlocalpathcmd),
 	}
 
+	renterSpendingCmd = &cobra.Command{
+		Use:   "spending",
+		Short: "View the spending history",
+		Long: `View the renter's spending ledger. It contains a record for every contract
+formation, renewal, upload, download, storage payment, ephemeral account funding
+and other paid RPC. The flags can be used to filter the records. With
+'--format json' or '--format csv' the records can be exported, e.g.
+'ttdxc renter spending --format csv > spending.csv'.`,
+		Run: wrap(renterspendingcmd),
+	}
+
 	renterFilesUnstuckCmd = &cobra.Command{
 		Use:   "unstuckall",
 		Short: "Set all files to unstuck",
@@ -212,7 +263,8 @@ local path where the TurtleDex folder is mounted.`,
 		Use:   "upload [source] [path]",
 		Short: "Upload a file or folder",
 		Long: `Upload a file or folder to [path] on the TurtleDex network. The --data-pieces and --parity-pieces
-flags can be used to set a custom redundancy for the file.`,
+flags can be used to set a custom redundancy for the file. The --metadata flag
+can be used to attach key=value metadata to the uploaded files.`,
 		Run: wrap(renterfilesuploadcmd),
 	}
 
@@ -251,10 +303,33 @@ allowance of 500SC, 12w period, 50 hosts, and 4w renew window will be used.`,
 Bytes per second: B/s, KB/s, MB/s, GB/s, TB/s
 or
 Bits per second: Bps, Kbps, Mbps, Gbps, Tbps
-Set them to 0 for no limit.`,
+Set them to 0 for no limit.
+
+Different limits can be set for recurring windows of time with the --schedule
+flag. Each window consists of the days ('*' or e.g. 'mon-fri,sun'), the time of
+day in the local time of the daemon and the download and upload speed. Windows
+are separated by semicolons and the first matching window applies, e.g.
+  ttdxc renter ratelimit 0 0 --schedule "mon-fri 09:00-17:00 1MB/s 500KB/s"`,
 		Run: wrap(renterratelimitcmd),
 	}
 
+	renterRepairCmd = &cobra.Command{
+		Use:   "repair",
+		Short: "View the repair progress",
+		Long: `View the repair settings, the amount of data that needs to be repaired, the
+repair throughput and an estimate of how long the repairs take.`,
+		Run: wrap(renterrepaircmd),
+	}
+
+	renterRepairSetCmd = &cobra.Command{
+		Use:   "set",
+		Short: "Change the repair settings",
+		Long: `Limit the resources used for repairs independently of new uploads, or pause new
+uploads while repairs keep running. Settings which are not provided are left
+unchanged. Set a limit to 0 to remove it.`,
+		Run: wrap(renterrepairsetcmd),
+	}
+
 	renterSetAllowanceCmd = &cobra.Command{
 		Use:   "setallowance",
 		Short: "Set the allowance",
@@ -270,7 +345,9 @@ is renewed automatically.
 
 Note that setting the allowance will cause ttdxd to immediately begin forming
 contracts! You should only set the allowance once you are fully synced and you
-have a reasonable number (>30) of hosts in your hostdb.`,
+have a reasonable number (>30) of hosts in your hostdb. Use '--dry-run' to
+see what contract maintenance would do with the new allowance without setting
+it.`,
 		Run: rentersetallowancecmd,
 	}
 
@@ -358,6 +435,160 @@ have a reasonable number (>30) of hosts in your hostdb.`,
 		Run:   wrap(renterhealthsummarycmd),
 	}
 
+	renterDirSnapshotsCmd = &cobra.Command{
+		Use:   "dirsnapshots",
+		Short: "List the directory snapshots",
+		Long: `List the read-only snapshots of directories. The files of a snapshot are kept
+when the files of the snapshotted directory are overwritten or deleted.`,
+		Run: wrap(renterdirsnapshotscmd),
+	}
+
+	renterDirSnapshotsCreateCmd = &cobra.Command{
+		Use:   "create [dirpath] [name]",
+		Short: "Create a snapshot of a directory",
+		Long:  "Create a read-only snapshot of a directory with the given name.",
+		Run:   wrap(renterdirsnapshotscreatecmd),
+	}
+
+	renterDirSnapshotsDeleteCmd = &cobra.Command{
+		Use:   "delete [name]",
+		Short: "Delete a directory snapshot",
+		Long:  "Delete the directory snapshot with the given name.",
+		Run:   wrap(renterdirsnapshotsdeletecmd),
+	}
+
+	renterDirSnapshotsDiffCmd = &cobra.Command{
+		Use:   "diff [name]",
+		Short: "Show the changes since a directory snapshot was created",
+		Long: `Show the files of the snapshotted directory which were added, modified or
+removed since the snapshot was created.`,
+		Run: wrap(renterdirsnapshotsdiffcmd),
+	}
+
+	renterDirSnapshotsRestoreCmd = &cobra.Command{
+		Use:   "restore [name] [dirpath]",
+		Short: "Restore a directory snapshot",
+		Long:  "Restore the files of a directory snapshot into a new directory.",
+		Run:   wrap(renterdirsnapshotsrestorecmd),
+	}
+
+	renterDirSnapshotsUploadCmd = &cobra.Command{
+		Use:   "upload [name]",
+		Short: "Upload a directory snapshot to hosts",
+		Long: `Upload a directory snapshot to hosts as a backup of the same name. The backup
+can be listed and restored using the 'listbackups' and 'restorebackup'
+commands.`,
+		Run: wrap(renterdirsnapshotsuploadcmd),
+	}
+
+	renterVersionsCmd = &cobra.Command{
+		Use:   "versions [path]",
+		Short: "List the prior versions of a file",
+		Long: `List the prior versions of a file. Versions are kept when a file is
+overwritten and the version retention of its directory is set. Versions can be
+downloaded from the listed siapath using the --root flag.`,
+		Run: wrap(renterversionscmd),
+	}
+
+	renterVersionsRestoreCmd = &cobra.Command{
+		Use:   "restore [path] [versionid]",
+		Short: "Restore a prior version of a file",
+		Long:  "Restore a prior version of a file. The current file is kept as a new version.",
+		Run:   wrap(renterversionsrestorecmd),
+	}
+
+	renterVersionsRetentionCmd = &cobra.Command{
+		Use:   "retention [dirpath] [versions]",
+		Short: "Set the version retention of a directory",
+		Long: `Set the number of prior versions that are kept for files within a
+directory when they are overwritten. Subdirectories inherit the setting unless
+they set their own. A value of 0 inherits the setting of the parent directory
+and a value of -1 disables versioning even if a parent directory enables it.`,
+		Run: wrap(renterversionsretentioncmd),
+	}
+
+	renterMetadataCmd = &cobra.Command{
+		Use:   "metadata [path]",
+		Short: "Display the user metadata of a file or directory",
+		Long: `Display the key/value metadata that is attached to a file or directory.
+Metadata can be attached when uploading a file with the --metadata flag or
+later on with the 'set' subcommand.`,
+		Run: wrap(rentermetadatacmd),
+	}
+
+	renterMetadataFindCmd = &cobra.Command{
+		Use:   "find [key=value]...",
+		Short: "List the files with matching user metadata",
+		Long: `List all files whose user metadata contains all of the provided
+key=value pairs. A key without a value matches all files that have the key set.`,
+		Run: rentermetadatafindcmd,
+	}
+
+	renterMetadataRemoveCmd = &cobra.Command{
+		Use:   "remove [path] [key]...",
+		Short: "Remove user metadata from a file or directory",
+		Long:  "Remove the provided keys from the user metadata of a file or directory.",
+		Run:   rentermetadataremovecmd,
+	}
+
+	renterMetadataSetCmd = &cobra.Command{
+		Use:   "set [path] [key=value]...",
+		Short: "Set user metadata of a file or directory",
+		Long: `Set the provided keys of the user metadata of a file or directory.
+Existing keys that aren't provided are kept.`,
+		Run: rentermetadatasetcmd,
+	}
+
+	renterRedundancyCmd = &cobra.Command{
+		Use:   "redundancy [dirpath]",
+		Short: "Display the redundancy policy of a directory",
+		Long: `Display the redundancy policy of a directory, the files within it that
+still need to be migrated to the policy and the progress of the migration.`,
+		Run: wrap(renterredundancycmd),
+	}
+
+	renterRedundancyPauseCmd = &cobra.Command{
+		Use:   "pause [duration]",
+		Short: "Pause the redundancy migration for a duration",
+		Long: `Temporarily pause the migration of files to the redundancy policy of their
+directory for the duration specified. A migration that is in progress is
+finished first.
+Available durations include "s" for seconds, "m" for minutes, and "h" for hours.
+For Example: 'ttdxc renter redundancy pause 3h' would pause the migration for 3 hours.`,
+		Run: wrap(renterredundancypausecmd),
+	}
+
+	renterRedundancyResumeCmd = &cobra.Command{
+		Use:   "resume",
+		Short: "Resume the redundancy migration",
+		Long:  "Resume the redundancy migration that was previously paused.",
+		Run:   wrap(renterredundancyresumecmd),
+	}
+
+	renterRedundancySetCmd = &cobra.Command{
+		Use:   "set [dirpath] [tier]...",
+		Short: "Set the redundancy policy of a directory",
+		Long: `Set the redundancy policy of a directory. Each tier is provided in the
+format 'data+parity@after' and applies to files that haven't been accessed for
+the duration 'after'. The first tier has to apply after 0s, which can be
+omitted. Files are migrated to the erasure coding settings of their tier in the
+background. Subdirectories inherit the policy unless they set their own.
+Providing no tiers unsets the policy of the directory.
+For Example: 'ttdxc renter redundancy set photos 10+20 10+10@720h' keeps files
+at 10-of-30 and moves them to 10-of-20 after 30 days without access.`,
+		Run: renterredundancysetcmd,
+	}
+
+	renterMkdirCmd = &cobra.Command{
+		Use:   "mkdir [path]",
+		Short: "Create a directory or update its quota",
+		Long: `Create a directory. If --quota or --redundant-quota are provided, the
+quota of the directory is set as well. Running the command on an existing
+directory updates its quota. Quotas apply to the directory and all of its
+subdirectories.`,
+		Run: wrap(rentermkdircmd),
+	}
+
 	renterLostCmd = &cobra.Command{
 		Use:   "lost",
 		Short: "Display the renter's lost files",
@@ -463,6 +694,13 @@ func rentercmd() {
 	fmt.Fprintf(w, "  Requested Priority Memory\t%v\t%v\t%v\t%v\t%v\n", sizeString(ud.PriorityRequested), sizeString(uu.PriorityRequested), sizeString(reg.PriorityRequested), sizeString(sys.PriorityRequested), sizeString(ms.PriorityRequested))
 	fmt.Fprintln(w, "")
 
+	// Print out the dedup savings
+	ds := rg.DedupStatus
+	fmt.Fprintf(w, "\nDeduplication\n")
+	fmt.Fprintf(w, "  Unique Chunks:\t%v\n", ds.UniqueChunks)
+	fmt.Fprintf(w, "  Chunk References:\t%v\n", ds.ChunkReferences)
+	fmt.Fprintf(w, "  Storage Saved:\t%v\n", sizeString(ds.StorageSaved))
+
 	// Print out if the uploads are paused
 	if verbose {
 		var pauseEndTime time.Duration
@@ -483,6 +721,7 @@ func rentercmd() {
 	// Print out ratelimit info about the renter
 	fmt.Println()
 	rateLimitSummary(rg.Settings.MaxDownloadSpeed, rg.Settings.MaxUploadSpeed)
+	bandwidthScheduleSummary(rg.Settings.BandwidthSchedule)
 }
 
 // renterlostcmd is the handler for displaying the renter's lost files.
@@ -580,6 +819,15 @@ func renterdownloadscmd() {
 // renterallowancecmd is the handler for the command `ttdxc renter allowance`.
 // displays the current allowance.
 func renterallowancecmd() {
+	if allowanceDryRun {
+		plan, err := httpClient.RenterContractsPlanGet()
+		if err != nil {
+			die("Could not get contract maintenance plan:", err)
+		}
+		writeContractMaintenancePlan(plan)
+		return
+	}
+
 	rg, err := httpClient.RenterGet()
 	if err != nil {
 		die("Could not get allowance:", err)
@@ -597,6 +845,7 @@ func renterallowancecmd() {
   Period:               %v blocks
   Renew Window:         %v blocks
   Hosts:                %v
+  Host Group Targets:   %v
 
 Skynet Portal Per-Contract Budget: %v
 
@@ -614,7 +863,8 @@ Price Protections:
   MaxStoragePrice:           %v per TB per Month
   MaxUploadBandwidthPrice:   %v per TB
 `, currencyUnitsWithExchangeRate(allowance.Funds, rate), allowance.Period, allowance.RenewWindow,
-		allowance.Hosts, currencyUnitsWithExchangeRate(allowance.PaymentContractInitialFunding, rate),
+		allowance.Hosts, formatHostGroupTargets(allowance.HostGroupTargets),
+		currencyUnitsWithExchangeRate(allowance.PaymentContractInitialFunding, rate),
 		modules.FilesizeUnits(allowance.ExpectedStorage),
 		modules.FilesizeUnits(allowance.ExpectedUpload*uint64(allowance.Period)),
 		modules.FilesizeUnits(allowance.ExpectedDownload*uint64(allowance.Period)),
@@ -663,7 +913,7 @@ to be accessible after a short period of time.`)
 
 // rentersetallowancecmd is the handler for `ttdxc renter setallowance`.
 // set the allowance or modify individual allowance fields.
-func rentersetallowancecmd(_ *cobra.Command, _ []string) {
+func rentersetallowancecmd(cmd *cobra.Command, _ []string) {
 	// Get the current period setting.
 	rg, err := httpClient.RenterGet()
 	if err != nil {
@@ -878,12 +1128,25 @@ func rentersetallowancecmd(_ *cobra.Command, _ []string) {
 		req = req.WithMaxUploadBandwidthPrice(price)
 		changedFields++
 	}
+	// parse hostgrouptargets, an empty value removes the targets
+	if cmd.Flags().Changed("host-group-targets") {
+		targets, err := modules.ParseHostGroupTargets(allowanceHostGroupTargets)
+		if err != nil {
+			die("Could not parse host group targets:", err)
+		}
+		req = req.WithHostGroupTargets(targets)
+		changedFields++
+	}
 
 	// check if any fields were updated.
 	if changedFields == 0 {
 		// If no fields were set then walk the user through the interactive
 		// allowance setting
 		req = rentersetallowancecmdInteractive(req, rg.Settings.Allowance)
+		if allowanceDryRun {
+			rentersetallowancedryrun(req)
+			return
+		}
 		if err := req.Send(); err != nil {
 			die("Could not set allowance:", err)
 		}
@@ -898,12 +1161,27 @@ func rentersetallowancecmd(_ *cobra.Command, _ []string) {
 		die("Expected storage must be set in initial allowance")
 	}
 
+	if allowanceDryRun {
+		rentersetallowancedryrun(req)
+		return
+	}
 	if err := req.Send(); err != nil {
 		die("Could not set allowance:", err)
 	}
 	fmt.Printf("Allowance updated. %v setting(s) changed.\n", changedFields)
 }
 
+// rentersetallowancedryrun displays what contract maintenance would do with
+// the allowance of the request without setting it.
+func rentersetallowancedryrun(req *client.AllowanceRequestPost) {
+	plan, err := req.Plan()
+	if err != nil {
+		die("Could not get contract maintenance plan:", err)
+	}
+	writeContractMaintenancePlan(plan)
+	fmt.Println("\nDry run, the allowance was not changed.")
+}
+
 // rentersetallowancecmdInteractive is the interactive handler for `ttdxc renter
 // setallowance`.
 func rentersetallowancecmdInteractive(req *client.AllowanceRequestPost, allowance modules.Allowance) *client.AllowanceRequestPost {
@@ -1446,13 +1724,16 @@ func rentercontractscmd() {
 
 `, modules.FilesizeUnits(totalStored), modules.FilesizeUnits(totalWasted), currencyUnits(totalRemaining), currencyUnits(totalSpent), currencyUnits(totalFees))
 
+	// Get the host groups to show the group membership of the hosts.
+	groupsByHost := hostGroupsByHost()
+
 	// List out contracts
 	fmt.Println("Active Contracts:")
 	if len(rc.ActiveContracts) == 0 {
 		fmt.Println("  No active contracts.")
 	} else {
 		// Display Active Contracts
-		writeContracts(rc.ActiveContracts)
+		writeContracts(rc.ActiveContracts, groupsByHost)
 	}
 
 	fmt.Println("\nPassive Contracts:")
@@ -1460,7 +1741,7 @@ func rentercontractscmd() {
 		fmt.Println("  No passive contracts.")
 	} else {
 		// Display Passive Contracts
-		writeContracts(rc.PassiveContracts)
+		writeContracts(rc.PassiveContracts, groupsByHost)
 	}
 
 	fmt.Println("\nRefreshed Contracts:")
@@ -1468,7 +1749,7 @@ func rentercontractscmd() {
 		fmt.Println("  No refreshed contracts.")
 	} else {
 		// Display Refreshed Contracts
-		writeContracts(rc.RefreshedContracts)
+		writeContracts(rc.RefreshedContracts, groupsByHost)
 	}
 
 	fmt.Println("\nDisabled Contracts:")
@@ -1476,7 +1757,7 @@ func rentercontractscmd() {
 		fmt.Println("  No disabled contracts.")
 	} else {
 		// Display Disabled Contracts
-		writeContracts(rc.DisabledContracts)
+		writeContracts(rc.DisabledContracts, groupsByHost)
 	}
 
 	if renterAllContracts {
@@ -1506,14 +1787,14 @@ func rentercontractscmd() {
 		if len(rce.ExpiredContracts) == 0 {
 			fmt.Println("  No expired contracts.")
 		} else {
-			writeContracts(rce.ExpiredContracts)
+			writeContracts(rce.ExpiredContracts, groupsByHost)
 		}
 
 		fmt.Println("\nExpired Refresh Contracts:")
 		if len(rce.ExpiredRefreshedContracts) == 0 {
 			fmt.Println("  No expired refreshed contracts.")
 		} else {
-			writeContracts(rce.ExpiredRefreshedContracts)
+			writeContracts(rce.ExpiredRefreshedContracts, groupsByHost)
 		}
 	}
 }
@@ -1779,7 +2060,12 @@ func renterfileslistcmd(cmd *cobra.Command, args []string) {
 
 	// Print totals for both verbose and not verbose output.
 	totalStoredStr := modules.FilesizeUnits(totalStored)
-	fmt.Printf("\nListing %v files/dirs:\t%9s\n\n", numFilesDirs, totalStoredStr)
+	fmt.Printf("\nListing %v files/dirs:\t%9s\n", numFilesDirs, totalStoredStr)
+	if root.dir.Quota > 0 || root.dir.RedundantQuota > 0 {
+		fmt.Printf("Quota:\t%9s of %s (%s of %s after redundancy)\n", totalStoredStr, quotaString(root.dir.Quota),
+			modules.FilesizeUnits(root.dir.AggregateRedundantSize), quotaString(root.dir.RedundantQuota))
+	}
+	fmt.Println()
 
 	// Handle the non verbose output.
 	if !verbose {
@@ -1868,6 +2154,742 @@ func renterfilesrenamecmd(path, newpath string) {
 	fmt.Printf("Renamed %s to %s\n", path, newpath)
 }
 
+// renterversionscmd is the handler for the command `ttdxc renter versions
+// [path]`. It lists the prior versions of a file.
+func renterversionscmd(path string) {
+	siaPath, err := modules.NewTurtleDexPath(path)
+	if err != nil {
+		die("Couldn't parse TurtleDexPath:", err)
+	}
+	rfv, err := httpClient.RenterFileVersionsGet(siaPath)
+	if err != nil {
+		die("Could not get file versions:", err)
+	}
+	if len(rfv.Versions) == 0 {
+		fmt.Printf("No prior versions of %s\n", path)
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  Version ID\tArchived\tSize\tHealth\tTurtleDexPath\n")
+	for _, v := range rfv.Versions {
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%.2f%%\t%s\n", v.VersionID, v.ArchiveTime.Format(time.RFC3339), modules.FilesizeUnits(v.Filesize), v.MaxHealthPercent, v.TurtleDexPath.String())
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// renterversionsrestorecmd is the handler for the command `ttdxc renter
+// versions restore [path] [versionid]`.
+func renterversionsrestorecmd(path, versionID string) {
+	siaPath, err := modules.NewTurtleDexPath(path)
+	if err != nil {
+		die("Couldn't parse TurtleDexPath:", err)
+	}
+	err = httpClient.RenterFileVersionRestorePost(siaPath, versionID)
+	if err != nil {
+		die("Could not restore file version:", err)
+	}
+	fmt.Printf("Restored version %s of %s\n", versionID, path)
+}
+
+// renterversionsretentioncmd is the handler for the command `ttdxc renter
+// versions retention [dirpath] [versions]`.
+func renterversionsretentioncmd(path, versions string) {
+	siaPath, err := modules.NewTurtleDexPath(path)
+	if err != nil {
+		die("Couldn't parse TurtleDexPath:", err)
+	}
+	retention, err := strconv.ParseInt(versions, 10, 64)
+	if err != nil {
+		die("Couldn't parse number of versions:", err)
+	}
+	err = httpClient.RenterDirSetVersionRetentionPost(siaPath, retention)
+	if err != nil {
+		die("Could not set version retention:", err)
+	}
+	fmt.Printf("Set version retention of %s to %v\n", path, retention)
+}
+
+// renterdirsnapshotscmd is the handler for the command `ttdxc renter
+// dirsnapshots`.
+func renterdirsnapshotscmd() {
+	rds, err := httpClient.RenterDirSnapshotsGet()
+	if err != nil {
+		die("Could not get directory snapshots:", err)
+	}
+	if len(rds.Snapshots) == 0 {
+		fmt.Println("No directory snapshots.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  Name\tCreated\tFiles\tSize\tTurtleDexPath")
+	for _, s := range rds.Snapshots {
+		fmt.Fprintf(w, "  %s\t%s\t%v\t%s\t%s\n", s.Name, s.CreateTime.Format(time.RFC3339), s.NumFiles, modules.FilesizeUnits(s.Size), s.TurtleDexPath.String())
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// renterdirsnapshotscreatecmd is the handler for the command `ttdxc renter
+// dirsnapshots create [dirpath] [name]`.
+func renterdirsnapshotscreatecmd(path, name string) {
+	siaPath, err := modules.NewTurtleDexPath(path)
+	if err != nil {
+		die("Couldn't parse TurtleDexPath:", err)
+	}
+	snapshot, err := httpClient.RenterDirSnapshotCreatePost(siaPath, name, false)
+	if err != nil {
+		die("Could not create directory snapshot:", err)
+	}
+	fmt.Printf("Created snapshot %s of %s with %v files (%s)\n", name, path, snapshot.NumFiles, modules.FilesizeUnits(snapshot.Size))
+}
+
+// renterdirsnapshotsdeletecmd is the handler for the command `ttdxc renter
+// dirsnapshots delete [name]`.
+func renterdirsnapshotsdeletecmd(name string) {
+	if err := httpClient.RenterDirSnapshotDeletePost(name); err != nil {
+		die("Could not delete directory snapshot:", err)
+	}
+	fmt.Printf("Deleted snapshot %s\n", name)
+}
+
+// renterdirsnapshotsdiffcmd is the handler for the command `ttdxc renter
+// dirsnapshots diff [name]`.
+func renterdirsnapshotsdiffcmd(name string) {
+	diff, err := httpClient.RenterDirSnapshotDiffGet(name)
+	if err != nil {
+		die("Could not diff directory snapshot:", err)
+	}
+	if len(diff.Added)+len(diff.Modified)+len(diff.Removed) == 0 {
+		fmt.Println("No changes since the snapshot was created.")
+		return
+	}
+	for _, sp := range diff.Added {
+		fmt.Printf("A %s\n", sp)
+	}
+	for _, sp := range diff.Modified {
+		fmt.Printf("M %s\n", sp)
+	}
+	for _, sp := range diff.Removed {
+		fmt.Printf("D %s\n", sp)
+	}
+}
+
+// renterdirsnapshotsrestorecmd is the handler for the command `ttdxc renter
+// dirsnapshots restore [name] [dirpath]`.
+func renterdirsnapshotsrestorecmd(name, path string) {
+	siaPath, err := modules.NewTurtleDexPath(path)
+	if err != nil {
+		die("Couldn't parse TurtleDexPath:", err)
+	}
+	if err := httpClient.RenterDirSnapshotRestorePost(name, siaPath, false); err != nil {
+		die("Could not restore directory snapshot:", err)
+	}
+	fmt.Printf("Restored snapshot %s to %s\n", name, path)
+}
+
+// renterdirsnapshotsuploadcmd is the handler for the command `ttdxc renter
+// dirsnapshots upload [name]`.
+func renterdirsnapshotsuploadcmd(name string) {
+	if err := httpClient.RenterDirSnapshotUploadPost(name); err != nil {
+		die("Could not upload directory snapshot:", err)
+	}
+	fmt.Println("Upload initiated. Monitor progress with the 'listbackups' command.")
+}
+
+// rentermkdircmd is the handler for the command `ttdxc renter mkdir [path]`.
+// It creates a directory and sets its quota.
+func rentermkdircmd(path string) {
+	siaPath, err := modules.NewTurtleDexPath(path)
+	if err != nil {
+		die("Couldn't parse TurtleDexPath:", err)
+	}
+	err = httpClient.RenterDirCreatePost(siaPath)
+	exists := err != nil && strings.Contains(err.Error(), filesystem.ErrExists.Error())
+	if err != nil && !exists {
+		die("Could not create directory:", err)
+	}
+	if !exists {
+		fmt.Printf("Created directory %s\n", path)
+	}
+	if renterMkdirQuota == "" && renterMkdirRedundantQuota == "" {
+		if exists {
+			die("Directory already exists")
+		}
+		return
+	}
+
+	// Set the quota. Quotas that aren't specified are kept.
+	rd, err := httpClient.RenterDirGet(siaPath)
+	if err != nil {
+		die("Could not fetch directory:", err)
+	}
+	quota, redundantQuota := rd.Directories[0].Quota, rd.Directories[0].RedundantQuota
+	if renterMkdirQuota != "" {
+		quota = parseQuota(renterMkdirQuota)
+	}
+	if renterMkdirRedundantQuota != "" {
+		redundantQuota = parseQuota(renterMkdirRedundantQuota)
+	}
+	err = httpClient.RenterDirSetQuotaPost(siaPath, quota, redundantQuota)
+	if err != nil {
+		die("Could not set quota:", err)
+	}
+	fmt.Printf("Set quota of %s to %s (%s after redundancy)\n", path, quotaString(quota), quotaString(redundantQuota))
+}
+
+// parseQuota parses a quota provided by the user.
+func parseQuota(str string) uint64 {
+	if str == "0" {
+		return 0
+	}
+	sizeStr, err := parseFilesize(str)
+	if err != nil {
+		die("Could not parse quota:", err)
+	}
+	size, err := strconv.ParseUint(sizeStr, 10, 64)
+	if err != nil {
+		die("Could not parse quota:", err)
+	}
+	return size
+}
+
+// quotaString returns a human readable representation of a quota.
+func quotaString(quota uint64) string {
+	if quota == 0 {
+		return "unlimited"
+	}
+	return modules.FilesizeUnits(quota)
+}
+
+// rentermetadatacmd is the handler for the command `ttdxc renter metadata
+// [path]`. It displays the user metadata of a file or directory.
+func rentermetadatacmd(path string) {
+	siaPath, err := modules.NewTurtleDexPath(path)
+	if err != nil {
+		die("Couldn't parse TurtleDexPath:", err)
+	}
+	md, _ := fetchUserMetadata(siaPath)
+	if len(md) == 0 {
+		fmt.Printf("%s has no metadata.\n", path)
+		return
+	}
+	keys := make([]string, 0, len(md))
+	for k := range md {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Key\tValue")
+	for _, k := range keys {
+		fmt.Fprintf(w, "%v\t%v\n", k, md[k])
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// rentermetadatafindcmd is the handler for the command `ttdxc renter metadata
+// find [key=value]...`. It lists the files with matching user metadata.
+func rentermetadatafindcmd(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		_ = cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	filter, err := modules.ParseUserMetadata(args)
+	if err != nil {
+		die("Could not parse metadata:", err)
+	}
+	rf, err := httpClient.RenterFilesUserMetadataGet(true, filter)
+	if err != nil {
+		die("Could not fetch files:", err)
+	}
+	if len(rf.Files) == 0 {
+		fmt.Println("No matching files.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, file := range rf.Files {
+		fmt.Fprintf(w, "%v\t%9v\n", file.TurtleDexPath, modules.FilesizeUnits(file.Filesize))
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// rentermetadataremovecmd is the handler for the command `ttdxc renter
+// metadata remove [path] [key]...`.
+func rentermetadataremovecmd(cmd *cobra.Command, args []string) {
+	if len(args) < 2 {
+		_ = cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	siaPath, err := modules.NewTurtleDexPath(args[0])
+	if err != nil {
+		die("Couldn't parse TurtleDexPath:", err)
+	}
+	setUserMetadata(siaPath, nil, args[1:])
+	fmt.Printf("Removed metadata from %s\n", args[0])
+}
+
+// rentermetadatasetcmd is the handler for the command `ttdxc renter metadata
+// set [path] [key=value]...`.
+func rentermetadatasetcmd(cmd *cobra.Command, args []string) {
+	if len(args) < 2 {
+		_ = cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	siaPath, err := modules.NewTurtleDexPath(args[0])
+	if err != nil {
+		die("Couldn't parse TurtleDexPath:", err)
+	}
+	md, err := modules.ParseUserMetadata(args[1:])
+	if err != nil {
+		die("Could not parse metadata:", err)
+	}
+	setUserMetadata(siaPath, md, nil)
+	fmt.Printf("Set metadata of %s\n", args[0])
+}
+
+// renterredundancycmd is the handler for the command `ttdxc renter redundancy
+// [dirpath]`.
+func renterredundancycmd(path string) {
+	siaPath, err := modules.NewTurtleDexPath(path)
+	if err != nil {
+		die("Couldn't parse TurtleDexPath:", err)
+	}
+	rd, err := httpClient.RenterDirGet(siaPath)
+	if err != nil {
+		die("Could not fetch directory:", err)
+	}
+	rg, err := httpClient.RenterGet()
+	if err != nil {
+		die("Could not fetch renter settings:", err)
+	}
+	di := rd.Directories[0]
+	if di.RedundancyPolicy.IsSet() {
+		fmt.Printf("Redundancy policy of %s:\n", path)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  After\tData Pieces\tParity Pieces")
+		for _, tier := range di.RedundancyPolicy.Tiers {
+			fmt.Fprintf(w, "  %v\t%v\t%v\n", tier.After, tier.DataPieces, tier.ParityPieces)
+		}
+		if err := w.Flush(); err != nil {
+			die("failed to flush writer:", err)
+		}
+	} else {
+		fmt.Printf("%s has no redundancy policy and inherits the policy of its parent.\n", path)
+	}
+
+	fmt.Println()
+	fmt.Printf("Pending Migrations: %v files, %s\n", di.AggregateNumPendingMigrations, modules.FilesizeUnits(di.AggregatePendingMigrationSize))
+	status := rg.Settings.RedundancyMigrationStatus
+	if status.Paused {
+		fmt.Printf("Migration Status:   paused until %v\n", status.PauseEndTime.Format(time.RFC822))
+	} else if !status.ActiveFile.IsEmpty() {
+		fmt.Printf("Migration Status:   migrating %v\n", status.ActiveFile)
+	} else {
+		fmt.Println("Migration Status:   idle")
+	}
+	fmt.Printf("Migrated:           %v files, %s\n", status.MigratedFiles, modules.FilesizeUnits(status.MigratedSize))
+}
+
+// renterredundancypausecmd is the handler for the command `ttdxc renter
+// redundancy pause [duration]`.
+func renterredundancypausecmd(dur string) {
+	pauseDuration, err := time.ParseDuration(dur)
+	if err != nil {
+		die("Couldn't parse duration:", err)
+	}
+	err = httpClient.RenterRedundancyMigrationPausePost(pauseDuration)
+	if err != nil {
+		die("Could not pause redundancy migration:", err)
+	}
+	fmt.Println("Redundancy migration has been paused for", dur)
+}
+
+// renterredundancyresumecmd is the handler for the command `ttdxc renter
+// redundancy resume`.
+func renterredundancyresumecmd() {
+	err := httpClient.RenterRedundancyMigrationResumePost()
+	if err != nil {
+		die("Could not resume redundancy migration:", err)
+	}
+	fmt.Println("Redundancy migration has been resumed")
+}
+
+// renterredundancysetcmd is the handler for the command `ttdxc renter
+// redundancy set [dirpath] [tier]...`.
+func renterredundancysetcmd(cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		_ = cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	siaPath, err := modules.NewTurtleDexPath(args[0])
+	if err != nil {
+		die("Couldn't parse TurtleDexPath:", err)
+	}
+	var policy modules.RedundancyPolicy
+	for _, arg := range args[1:] {
+		tier, err := modules.ParseRedundancyTier(arg)
+		if err != nil {
+			die("Could not parse tier:", err)
+		}
+		policy.Tiers = append(policy.Tiers, tier)
+	}
+	err = httpClient.RenterDirSetRedundancyPolicyPost(siaPath, policy)
+	if err != nil {
+		die("Could not set redundancy policy:", err)
+	}
+	if !policy.IsSet() {
+		fmt.Printf("Unset redundancy policy of %s\n", args[0])
+		return
+	}
+	fmt.Printf("Set redundancy policy of %s\n", args[0])
+}
+
+// renterfindcmd is the handler for the command `ttdxc renter find [pattern]`.
+// It searches for files using the renter's search index.
+func renterfindcmd(cmd *cobra.Command, args []string) {
+	if len(args) > 1 {
+		_ = cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	params := modules.FileSearchParams{
+		Prefix:    renterFindPrefix,
+		Substring: renterFindSubstring,
+		Limit:     renterFindLimit,
+	}
+	if len(args) == 1 {
+		params.Glob = args[0]
+	}
+	if renterFindMinSize != "" {
+		params.MinSize = parseSearchSize(renterFindMinSize)
+	}
+	if renterFindMaxSize != "" {
+		params.MaxSize = parseSearchSize(renterFindMaxSize)
+	}
+	if renterFindMinHealth != "" {
+		minHealth, err := strconv.ParseFloat(renterFindMinHealth, 64)
+		if err != nil {
+			die("Could not parse min health:", err)
+		}
+		params.MinHealth = &minHealth
+	}
+	if renterFindMaxHealth != "" {
+		maxHealth, err := strconv.ParseFloat(renterFindMaxHealth, 64)
+		if err != nil {
+			die("Could not parse max health:", err)
+		}
+		params.MaxHealth = &maxHealth
+	}
+	if renterFindHasSkylink != "" {
+		hasSkylink, err := strconv.ParseBool(renterFindHasSkylink)
+		if err != nil {
+			die("Could not parse has-skylink:", err)
+		}
+		params.HasSkylink = &hasSkylink
+	}
+	siaPath := modules.RootTurtleDexPath()
+	if renterFindDir != "" {
+		var err error
+		siaPath, err = modules.NewTurtleDexPath(renterFindDir)
+		if err != nil {
+			die("Couldn't parse TurtleDexPath:", err)
+		}
+	}
+
+	rs, err := httpClient.RenterSearchGet(siaPath, renterFindRoot, params)
+	if err != nil {
+		die("Could not search files:", err)
+	}
+	if len(rs.Files) == 0 {
+		fmt.Println("No matching files.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  Size\tHealth\tModified\tSkylinks\tPath")
+	for _, file := range rs.Files {
+		fmt.Fprintf(w, "  %v\t%.2f%%\t%v\t%v\t%v\n", modules.FilesizeUnits(file.Filesize), modules.HealthPercentage(file.Health),
+			file.ModTime.Format(time.RFC3339), len(file.Skylinks), file.TurtleDexPath)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// renterbudgetscmd is the handler for the command `ttdxc renter budgets`. It
+// shows the budgets of the API callers and their spending.
+func renterbudgetscmd() {
+	rbg, err := httpClient.RenterBudgetsGet()
+	if err != nil {
+		die("Could not get budgets:", err)
+	}
+	if len(rbg.Budgets) == 0 {
+		fmt.Println("No budgets set.")
+		return
+	}
+	// formatUsage formats the spending of a category and its limit.
+	formatUsage := func(spending, limit types.Currency) string {
+		if limit.IsZero() {
+			return fmt.Sprintf("%v / unlimited", currencyUnits(spending))
+		}
+		return fmt.Sprintf("%v / %v", currencyUnits(spending), currencyUnits(limit))
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  Identity\tUpload\tDownload\tRegistry")
+	for _, b := range rbg.Budgets {
+		fmt.Fprintf(w, "  %v\t%v\t%v\t%v\n", b.Identity, formatUsage(b.UploadSpending, b.Upload),
+			formatUsage(b.DownloadSpending, b.Download), formatUsage(b.RegistrySpending, b.Registry))
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// renterbudgetsremovecmd is the handler for the command `ttdxc renter budgets
+// remove [identity]`.
+func renterbudgetsremovecmd(identity string) {
+	if err := httpClient.RenterBudgetRemovePost(identity); err != nil {
+		die("Could not remove budget:", err)
+	}
+	fmt.Printf("Removed the budget of '%v'\n", identity)
+}
+
+// renterbudgetssetcmd is the handler for the command `ttdxc renter budgets set
+// [identity]`.
+func renterbudgetssetcmd(identity string) {
+	// parseLimit parses the limit of a category.
+	parseLimit := func(limitStr string) types.Currency {
+		if limitStr == "" {
+			return types.ZeroCurrency
+		}
+		hastings, err := types.ParseCurrency(limitStr)
+		if err != nil {
+			die("Could not parse limit:", err)
+		}
+		var limit types.Currency
+		if _, err := fmt.Sscan(hastings, &limit); err != nil {
+			die("Could not parse limit:", err)
+		}
+		return limit
+	}
+	budget := modules.RenterBudget{
+		Identity: identity,
+		Upload:   parseLimit(renterBudgetUpload),
+		Download: parseLimit(renterBudgetDownload),
+		Registry: parseLimit(renterBudgetRegistry),
+	}
+	if err := httpClient.RenterBudgetPost(budget); err != nil {
+		die("Could not set budget:", err)
+	}
+	fmt.Printf("Set the budget of '%v'\n", identity)
+}
+
+// renterrepaircmd is the handler for the command `ttdxc renter repair`. It
+// shows the repair settings and the repair progress.
+func renterrepaircmd() {
+	rrg, err := httpClient.RenterRepairGet()
+	if err != nil {
+		die("Could not get repair status:", err)
+	}
+	maxSpeed, maxChunks := "unlimited", "unlimited"
+	if rrg.MaxBytesPerSecond > 0 {
+		maxSpeed = ratelimitUnits(int64(rrg.MaxBytesPerSecond))
+	}
+	if rrg.MaxConcurrentChunks > 0 {
+		maxChunks = fmt.Sprint(rrg.MaxConcurrentChunks)
+	}
+	eta := rrg.ETA.Round(time.Second).String()
+	if rrg.ETAUnknown {
+		eta = "unknown"
+	}
+	fmt.Printf(`Repair Settings:
+  Max Speed:             %v
+  Max Concurrent Chunks: %v
+  Repair Only:           %v
+
+Repair Progress:
+  Active Chunks:         %v
+  Repair Size:           %v
+  Stuck Size:            %v
+  Stuck Chunks:          %v (%v queued, %v recently repaired files)
+  Throughput:            %v
+  ETA:                   %v
+`, maxSpeed, maxChunks, yesNo(rrg.RepairOnly), rrg.ActiveChunks, modules.FilesizeUnits(rrg.RepairBytes),
+		modules.FilesizeUnits(rrg.StuckBytes), rrg.NumStuckChunks, rrg.StuckChunksInHeap, rrg.RecentlySuccessfulStuckFiles,
+		ratelimitUnits(int64(rrg.Throughput)), eta)
+}
+
+// renterrepairsetcmd is the handler for the command `ttdxc renter repair set`.
+func renterrepairsetcmd() {
+	rrg, err := httpClient.RenterRepairGet()
+	if err != nil {
+		die("Could not get repair settings:", err)
+	}
+	settings := rrg.RepairSettings
+	if renterRepairMaxSpeed != "" {
+		speed, err := parseRatelimit(renterRepairMaxSpeed)
+		if err != nil {
+			die("Could not parse max speed:", err)
+		}
+		settings.MaxBytesPerSecond = uint64(speed)
+	}
+	if renterRepairMaxChunks != "" {
+		settings.MaxConcurrentChunks, err = strconv.ParseUint(renterRepairMaxChunks, 10, 64)
+		if err != nil {
+			die("Could not parse max chunks:", err)
+		}
+	}
+	if renterRepairOnly != "" {
+		settings.RepairOnly, err = strconv.ParseBool(renterRepairOnly)
+		if err != nil {
+			die("Could not parse repair-only:", err)
+		}
+	}
+	if err := httpClient.RenterRepairPost(settings); err != nil {
+		die("Could not set repair settings:", err)
+	}
+	fmt.Println("Repair settings updated")
+}
+
+// renterspendingcmd is the handler for the command `ttdxc renter spending`. It
+// shows the records of the spending ledger.
+func renterspendingcmd() {
+	var filter modules.SpendingHistoryFilter
+	var err error
+	filter.Types, err = modules.ParseSpendingTypes(renterSpendingTypes)
+	if err != nil {
+		die("Could not parse types:", err)
+	}
+	if renterSpendingHost != "" {
+		var hpk types.TurtleDexPublicKey
+		if err := hpk.LoadString(renterSpendingHost); err != nil {
+			die("Could not parse host public key:", err)
+		}
+		filter.HostPublicKey = &hpk
+	}
+	if renterSpendingContract != "" {
+		var fcid types.FileContractID
+		if err := fcid.LoadString(renterSpendingContract); err != nil {
+			die("Could not parse contract id:", err)
+		}
+		filter.ContractID = &fcid
+	}
+	if renterSpendingSince != "" {
+		since, err := time.ParseDuration(renterSpendingSince)
+		if err != nil {
+			die("Could not parse duration:", err)
+		}
+		filter.Start = time.Now().Add(-since)
+	}
+	filter.MinHeight = types.BlockHeight(renterSpendingMinHeight)
+	filter.MaxHeight = types.BlockHeight(renterSpendingMaxHeight)
+	filter.Limit = renterSpendingLimit
+
+	switch renterSpendingFormat {
+	case "csv":
+		csv, err := httpClient.RenterSpendingHistoryCSVGet(filter)
+		if err != nil {
+			die("Could not get spending history:", err)
+		}
+		fmt.Print(string(csv))
+		return
+	case "json", "table":
+	default:
+		die("Unknown format, must be 'table', 'json' or 'csv':", renterSpendingFormat)
+	}
+	rshg, err := httpClient.RenterSpendingHistoryGet(filter)
+	if err != nil {
+		die("Could not get spending history:", err)
+	}
+	if renterSpendingFormat == "json" {
+		b, err := json.MarshalIndent(rshg.Records, "", "  ")
+		if err != nil {
+			die("Could not marshal spending history:", err)
+		}
+		fmt.Println(string(b))
+		return
+	}
+	if len(rshg.Records) == 0 {
+		fmt.Println("No matching spending records.")
+		return
+	}
+
+	totals := make(map[modules.SpendingType]types.Currency)
+	var total types.Currency
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  Time\tHeight\tType\tAmount\tFees\tHost PubKey\tContract ID")
+	for _, r := range rshg.Records {
+		fmt.Fprintf(w, "  %v\t%v\t%v\t%v\t%v\t%v\t%v\n", r.Timestamp.Format(time.RFC3339), r.BlockHeight, r.Type,
+			currencyUnits(r.Amount), currencyUnits(r.Fees), r.HostPublicKey, r.ContractID)
+		totals[r.Type] = totals[r.Type].Add(r.Amount)
+		total = total.Add(r.Amount)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+
+	fmt.Println("\nTotals:")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, t := range modules.SpendingTypes {
+		if amount, ok := totals[t]; ok {
+			fmt.Fprintf(w, "  %v:\t%v\n", t, currencyUnits(amount))
+		}
+	}
+	fmt.Fprintf(w, "  total:\t%v\n", currencyUnits(total))
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// parseSearchSize parses a file size filter provided by the user.
+func parseSearchSize(str string) uint64 {
+	sizeStr, err := parseFilesize(str)
+	if err != nil {
+		die("Could not parse size:", err)
+	}
+	size, err := strconv.ParseUint(sizeStr, 10, 64)
+	if err != nil {
+		die("Could not parse size:", err)
+	}
+	return size
+}
+
+// setUserMetadata updates the user metadata of the file or directory at
+// siaPath.
+func setUserMetadata(siaPath modules.TurtleDexPath, set map[string]string, remove []string) {
+	_, isDir := fetchUserMetadata(siaPath)
+	var err error
+	if isDir {
+		err = httpClient.RenterDirSetUserMetadataPost(siaPath, set, remove)
+	} else {
+		err = httpClient.RenterSetFileUserMetadataPost(siaPath, set, remove)
+	}
+	if err != nil {
+		die("Could not set metadata:", err)
+	}
+}
+
+// fetchUserMetadata fetches the user metadata of the file or directory at
+// siaPath and returns whether it is a directory.
+func fetchUserMetadata(siaPath modules.TurtleDexPath) (map[string]string, bool) {
+	rf, err := httpClient.RenterFileGet(siaPath)
+	if err == nil {
+		return rf.File.UserMetadata, false
+	}
+	if !strings.Contains(err.Error(), filesystem.ErrNotExist.Error()) {
+		die("Could not fetch file:", err)
+	}
+	rd, err := httpClient.RenterDirGet(siaPath)
+	if err != nil {
+		die("Could not fetch directory:", err)
+	}
+	return rd.Directories[0].UserMetadata, true
+}
+
 // renterfusecmd displays the list of directories that are currently mounted via
 // fuse.
 func renterfusecmd() {
@@ -1909,10 +2931,6 @@ func renterfusecmd() {
 
 // renterfusemountcmd is the handler for the command `ttdxc renter fuse mount [path] [siapath]`.
 func renterfusemountcmd(path, siaPathStr string) {
-	// TODO: Once read-write is supported on the backend, the 'true' flag can be
-	// set to 'false' - ttdxc will support mounting read-write by default. Need
-	// to update the help string of the command to indicate that mounting will
-	// mount in read-write mode.
 	path = abs(path)
 	var siaPath modules.TurtleDexPath
 	var err error
@@ -1925,7 +2943,7 @@ func renterfusemountcmd(path, siaPathStr string) {
 		}
 	}
 	opts := modules.MountOptions{
-		ReadOnly:   true,
+		ReadOnly:   renterFuseMountReadOnly,
 		AllowOther: renterFuseMountAllowOther,
 	}
 	err = httpClient.RenterFuseMount(path, siaPath, opts)
@@ -2032,6 +3050,10 @@ func renterfilesuploadcmd(source, path string) {
 	if err != nil {
 		die("Could not parse data and parity pieces:", err)
 	}
+	userMetadata, err := modules.ParseUserMetadata(renterUploadMetadata)
+	if err != nil {
+		die("Could not parse metadata:", err)
+	}
 
 	if stat.IsDir() {
 		// folder
@@ -2062,7 +3084,7 @@ func renterfilesuploadcmd(source, path string) {
 			if err != nil {
 				die("Couldn't parse TurtleDexPath:", err)
 			}
-			err = httpClient.RenterUploadPost(abs(file), fTurtleDexPath, uint64(numDataPieces), uint64(numParityPieces))
+			err = httpClient.RenterUploadUserMetadataPost(abs(file), fTurtleDexPath, uint64(numDataPieces), uint64(numParityPieces), false, userMetadata)
 			if err != nil {
 				failed++
 				fmt.Printf("Could not upload file %s :%v\n", file, err)
@@ -2076,7 +3098,7 @@ func renterfilesuploadcmd(source, path string) {
 		if err != nil {
 			die("Couldn't parse TurtleDexPath:", err)
 		}
-		err = httpClient.RenterUploadPost(abs(source), siaPath, uint64(numDataPieces), uint64(numParityPieces))
+		err = httpClient.RenterUploadUserMetadataPost(abs(source), siaPath, uint64(numDataPieces), uint64(numParityPieces), false, userMetadata)
 		if err != nil {
 			die("Could not upload file:", err)
 		}
@@ -2199,12 +3221,27 @@ func renterratelimitcmd(downloadSpeedStr, uploadSpeedStr string) {
 	if err != nil {
 		die(errors.AddContext(err, "unable to parse upload speed"))
 	}
+	var schedule modules.BandwidthSchedule
+	if renterRatelimitSchedule != "" {
+		schedule, err = parseBandwidthSchedule(renterRatelimitSchedule)
+		if err != nil {
+			die(errors.AddContext(err, "unable to parse bandwidth schedule"))
+		}
+	}
 
 	err = httpClient.RenterRateLimitPost(downloadSpeedInt, uploadSpeedInt)
 	if err != nil {
 		die(errors.AddContext(err, "Could not set renter ratelimit speed"))
 	}
 	fmt.Println("Set renter maxdownloadspeed to ", downloadSpeedInt, " and maxuploadspeed to ", uploadSpeedInt)
+	if renterRatelimitSchedule == "" {
+		return
+	}
+	err = httpClient.RenterBandwidthSchedulePost(schedule)
+	if err != nil {
+		die(errors.AddContext(err, "Could not set renter bandwidth schedule"))
+	}
+	fmt.Printf("Set renter bandwidth schedule to %v window(s)\n", len(schedule))
 }
 
 // renterworkerscmd is the handler for the command `ttdxc renter workers`.
@@ -2227,6 +3264,8 @@ func renterworkerscmd() {
 	fmt.Fprintf(w, "  Workers On Download Cooldown:\t%v\n", rw.TotalDownloadCoolDown)
 	fmt.Fprintf(w, "  Workers On Upload Cooldown:\t%v\n", rw.TotalUploadCoolDown)
 	fmt.Fprintf(w, "  Workers On Maintenance Cooldown:\t%v\n", rw.TotalMaintenanceCoolDown)
+	fmt.Fprintf(w, "  Download Heap (interactive/normal/bulk):\t%v/%v/%v\n", rw.DownloadHeapDepths.Interactive, rw.DownloadHeapDepths.Normal, rw.DownloadHeapDepths.Bulk)
+	fmt.Fprintf(w, "  Read Jobs (interactive/normal/bulk):\t%v/%v/%v\n", rw.ReadJobQueueDepths.Interactive, rw.ReadJobQueueDepths.Normal, rw.ReadJobQueueDepths.Bulk)
 	if err := w.Flush(); err != nil {
 		die("failed to flush writer:", err)
 	}
@@ -96,6 +96,19 @@ func contractStats(contracts []api.RenterContract) (size uint64, spent, remainin
 	return
 }
 
+// downloadPriority parses the priority flag of the download command. If the
+// flag isn't set, the renter's default priority is used.
+func downloadPriority() modules.DownloadPriority {
+	if renterDownloadPriority == "" {
+		return 0
+	}
+	priority, err := modules.ParseDownloadPriority(renterDownloadPriority)
+	if err != nil {
+		die("Couldn't parse download priority:", err)
+	}
+	return priority
+}
+
 // downloadDir downloads the dir at the specified siaPath to the specified
 // location. It returns all the files for which a download was initialized as
 // tracked files and the ones which were ignored as skipped. Errors are composed
@@ -125,7 +138,7 @@ func downloadDir(siaPath modules.TurtleDexPath, destination string) (tfs []track
 		}
 		// Download file.
 		totalSize += file.Filesize
-		_, err = httpClient.RenterDownloadFullGet(file.TurtleDexPath, dst, true, true)
+		_, err = httpClient.RenterDownloadFullWithPriorityGet(file.TurtleDexPath, dst, true, true, downloadPriority())
 		if err != nil {
 			err = errors.AddContext(err, "Failed to start download")
 			return
@@ -530,7 +543,7 @@ func renterFilesDownload(path, destination string) {
 	// the call will return before the download has completed. The call is made
 	// as an async call.
 	start := time.Now()
-	cancelID, err := httpClient.RenterDownloadFullGet(siaPath, destination, true, true)
+	cancelID, err := httpClient.RenterDownloadFullWithPriorityGet(siaPath, destination, true, true, downloadPriority())
 	if err != nil {
 		die("Download could not be started:", err)
 	}
@@ -581,11 +594,11 @@ func renterFileHealthSummary(dirs []directoryInfo) {
 }
 
 // writeContracts is a helper function to display contracts
-func writeContracts(contracts []api.RenterContract) {
+func writeContracts(contracts []api.RenterContract, groupsByHost map[string][]string) {
 	fmt.Println("  Number of Contracts:", len(contracts))
 	sort.Sort(byValue(contracts))
 	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "  \nHost\tHost PubKey\tHost Version\tRemaining Funds\tSpent Funds\tSpent Fees\tData\tEnd Height\tContract ID\tGoodForUpload\tGoodForRenew\tBadContract")
+	fmt.Fprintln(w, "  \nHost\tHost PubKey\tHost Version\tRemaining Funds\tSpent Funds\tSpent Fees\tData\tEnd Height\tContract ID\tGoodForUpload\tGoodForRenew\tBadContract\tGroups")
 	for _, c := range contracts {
 		address := c.NetAddress
 		hostVersion := c.HostVersion
@@ -600,7 +613,7 @@ func writeContracts(contracts []api.RenterContract) {
 		} else {
 			contractTotalSpent = c.TotalCost.Sub(c.RenterFunds).Sub(c.Fees)
 		}
-		fmt.Fprintf(w, "  %v\t%v\t%v\t%8s\t%8s\t%8s\t%v\t%v\t%v\t%v\t%v\t%v\n",
+		fmt.Fprintf(w, "  %v\t%v\t%v\t%8s\t%8s\t%8s\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
 			address,
 			c.HostPublicKey.String(),
 			hostVersion,
@@ -612,13 +625,88 @@ func writeContracts(contracts []api.RenterContract) {
 			c.ID,
 			c.GoodForUpload,
 			c.GoodForRenew,
-			c.BadContract)
+			c.BadContract,
+			formatHostGroups(groupsByHost[c.HostPublicKey.String()]))
 	}
 	if err := w.Flush(); err != nil {
 		die("failed to flush writer:", err)
 	}
 }
 
+// writeContractMaintenancePlan is a helper function to display what contract
+// maintenance would do according to the plan.
+func writeContractMaintenancePlan(plan modules.ContractMaintenancePlan) {
+	formatUtility := func(u modules.ContractUtility) string {
+		return fmt.Sprintf("GFU: %v, GFR: %v, Locked: %v", u.GoodForUpload, u.GoodForRenew, u.Locked)
+	}
+	writeRenewals := func(title string, renewals []modules.PlannedRenewal) {
+		fmt.Printf("\n%v: %v\n", title, len(renewals))
+		if len(renewals) == 0 {
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  Contract ID\tHost PubKey\tFunding\tSkipped")
+		for _, r := range renewals {
+			fmt.Fprintf(w, "  %v\t%v\t%v\t%v\n", r.ID, r.HostPublicKey, currencyUnits(r.Funding), r.Skipped)
+		}
+		if err := w.Flush(); err != nil {
+			die("failed to flush writer:", err)
+		}
+	}
+
+	fmt.Printf(`Contract Maintenance Plan:
+  Block Height:       %v
+  Period End Height:  %v
+  Funds Available:    %v
+  Churned Contracts:  %v
+  Churn Avoided:      %v
+`, plan.BlockHeight, plan.EndHeight, currencyUnits(plan.FundsAvailable), plan.ChurnedContracts, plan.ChurnAvoided)
+
+	fmt.Printf("\nUtility Updates: %v\n", len(plan.UtilityUpdates))
+	if len(plan.UtilityUpdates) > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  Contract ID\tHost PubKey\tOld Utility\tNew Utility\tReason")
+		for _, u := range plan.UtilityUpdates {
+			fmt.Fprintf(w, "  %v\t%v\t%v\t%v\t%v\n", u.ID, u.HostPublicKey, formatUtility(u.OldUtility), formatUtility(u.NewUtility), u.Reason)
+		}
+		if err := w.Flush(); err != nil {
+			die("failed to flush writer:", err)
+		}
+	}
+	writeRenewals("Renewals", plan.Renewals)
+	writeRenewals("Refreshes", plan.Refreshes)
+
+	fmt.Printf("\nNeeded Contracts: %v\n", plan.NeededContracts)
+	fmt.Printf("Formations: %v\n", len(plan.Formations))
+	if len(plan.Formations) > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  Host\tHost PubKey\tFunding\tPayment Contract\tGroups")
+		for _, f := range plan.Formations {
+			fmt.Fprintf(w, "  %v\t%v\t%v\t%v\t%v\n", f.NetAddress, f.HostPublicKey, currencyUnits(f.Funding), f.PaymentContract, formatHostGroups(f.Groups))
+		}
+		if err := w.Flush(); err != nil {
+			die("failed to flush writer:", err)
+		}
+	}
+
+	fmt.Printf(`
+Estimated Spending:
+  Renewals:    %v
+  Refreshes:   %v
+  Formations:  %v
+  Total:       %v
+`, currencyUnits(plan.EstimatedRenewSpending), currencyUnits(plan.EstimatedRefreshSpending),
+		currencyUnits(plan.EstimatedFormationSpending), currencyUnits(plan.EstimatedTotalSpending))
+
+	if len(plan.Warnings) > 0 {
+		fmt.Println("\nWarnings:")
+		for _, warning := range plan.Warnings {
+			fmt.Println("  " + warning)
+		}
+	}
+	fmt.Println("\nHosts of new contracts are a random sample and may differ when maintenance runs.")
+}
+
 // writeWorkerDownloadUploadInfo is a helper function for writing the download
 // or upload information to the tabwriter.
 func writeWorkerDownloadUploadInfo(download bool, w *tabwriter.Writer, rw modules.WorkerPoolStatus) {
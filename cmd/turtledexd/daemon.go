@@ -16,6 +16,7 @@ import (
 
 	"github.com/turtledex/TurtleDexCore/build"
 	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/node/api/s3gateway"
 	"github.com/turtledex/TurtleDexCore/node/api/server"
 	"github.com/turtledex/TurtleDexCore/profile"
 )
@@ -83,6 +84,9 @@ func processModules(modules string) (string, error) {
 func processConfig(config Config) (Config, error) {
 	var err1, err2 error
 	config.TurtleDexd.APIaddr = processNetAddr(config.TurtleDexd.APIaddr)
+	if config.TurtleDexd.S3Addr != "" {
+		config.TurtleDexd.S3Addr = processNetAddr(config.TurtleDexd.S3Addr)
+	}
 	config.TurtleDexd.RPCaddr = processNetAddr(config.TurtleDexd.RPCaddr)
 	config.TurtleDexd.HostAddr = processNetAddr(config.TurtleDexd.HostAddr)
 	config.TurtleDexd.Modules, err1 = processModules(config.TurtleDexd.Modules)
@@ -201,6 +205,15 @@ func startDaemon(config Config) (err error) {
 	// Attempt to auto-unlock the wallet using the SIA_WALLET_PASSWORD env variable
 	tryAutoUnlock(srv)
 
+	// Start the S3 gateway if an address was provided.
+	if config.TurtleDexd.S3Addr != "" {
+		if err := srv.StartS3Gateway(config.TurtleDexd.S3Addr); err != nil {
+			return errors.Compose(errors.AddContext(err, "failed to start S3 gateway"), srv.Close())
+		}
+		fmt.Println("S3 gateway listening on", srv.S3GatewayAddress())
+		fmt.Println("S3 gateway credentials are stored in", s3gateway.CredentialsPath(filepath.Join(srv.Dir, modules.RenterDir, s3gateway.PersistDir)))
+	}
+
 	// listen for kill signals
 	sigChan := installKillSignalHandler()
 
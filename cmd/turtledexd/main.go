@@ -38,6 +38,7 @@ type Config struct {
 		TurtleDexMuxTCPAddr string
 		TurtleDexMuxWSAddr  string
 		AllowAPIBind  bool
+		S3Addr        string
 
 		Modules           string
 		NoBootstrap       bool
@@ -183,6 +184,7 @@ func main() {
 	root.Flags().BoolVarP(&globalConfig.TurtleDexd.NoBootstrap, "no-bootstrap", "", false, "disable bootstrapping on this run")
 	root.Flags().StringVarP(&globalConfig.TurtleDexd.Profile, "profile", "", "", "enable profiling with flags 'cmt' for CPU, memory, trace")
 	root.Flags().StringVarP(&globalConfig.TurtleDexd.RPCaddr, "rpc-addr", "", ":9981", "which port the gateway listens on")
+	root.Flags().StringVarP(&globalConfig.TurtleDexd.S3Addr, "s3-addr", "", "", "which host:port the S3 gateway listens on, the gateway is disabled if empty")
 	root.Flags().StringVarP(&globalConfig.TurtleDexd.TurtleDexMuxTCPAddr, "siamux-addr", "", ":9983", "which port the TurtleDexMux listens on")
 	root.Flags().StringVarP(&globalConfig.TurtleDexd.TurtleDexMuxWSAddr, "siamux-addr-ws", "", ":9984", "which port the TurtleDexMux websocket listens on")
 	root.Flags().StringVarP(&globalConfig.TurtleDexd.Modules, "modules", "M", "cghrtwf", "enabled modules, see 'ttdxd modules' for more info")
@@ -53,6 +53,21 @@ const (
 	// registered if the host has insufficient collateral budget left to form or
 	// renew a contract
 	AlertIDHostInsufficientCollateral = "host-insufficient-collateral"
+	// AlertIDHostPeersBanned is the id of the alert that is registered while
+	// the host has temporarily banned peers for sending invalid payments or
+	// programs
+	AlertIDHostPeersBanned = "host-peers-banned"
+	// AlertIDHostCorruptSectors is the id of the alert that is registered if
+	// the host's scrubber found corrupt or missing sectors
+	AlertIDHostCorruptSectors = "host-corrupt-sectors"
+	// AlertIDHostObligationsAtRisk is the id of the alert that is registered
+	// if the host has unresolved storage obligations with corrupt or missing
+	// sectors
+	AlertIDHostObligationsAtRisk = "host-obligations-at-risk"
+	// AlertIDRenterFuseStagedWrites is the id of the alert that is registered
+	// if the renter found staging files of fuse mounts of a previous session
+	// on startup
+	AlertIDRenterFuseStagedWrites = "renter-fuse-staged-writes"
 )
 
 // AlertIDTurtleDexfileLowRedundancy uses a TurtleDexfile's UID to create a unique AlertID
@@ -61,6 +76,12 @@ func AlertIDTurtleDexfileLowRedundancy(uid string) AlertID {
 	return AlertID(fmt.Sprintf("low-redundancy:%v", uid))
 }
 
+// AlertIDRenterDirQuota uses a dir's siapath to create a unique AlertID for a
+// dir that is close to its quota.
+func AlertIDRenterDirQuota(siaPath string) AlertID {
+	return AlertID(fmt.Sprintf("dir-quota:%v", siaPath))
+}
+
 type (
 	// Alerter is the interface implemented by all top-level modules. It's an
 	// interface that allows for asking a module about potential issues.
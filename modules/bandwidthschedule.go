@@ -0,0 +1,361 @@
+package modules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turtledex/errors"
+	"github.com/turtledex/ratelimit"
+
+	"github.com/turtledex/TurtleDexCore/build"
+)
+
+const (
+	// minutesPerDay is the number of minutes in a day.
+	minutesPerDay = 24 * 60
+
+	// bandwidthSchedulePacketSize is the packet size used when ratelimiting
+	// connections according to a bandwidth schedule.
+	bandwidthSchedulePacketSize = 4 * 4096
+)
+
+var (
+	// BandwidthScheduleInterval is the interval at which a bandwidth
+	// scheduler checks whether a different window became active.
+	BandwidthScheduleInterval = build.Select(build.Var{
+		Standard: time.Minute,
+		Dev:      10 * time.Second,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// ErrInvalidBandwidthSchedule is returned when a bandwidth schedule can't
+	// be parsed or contains invalid windows.
+	ErrInvalidBandwidthSchedule = errors.New("invalid bandwidth schedule")
+)
+
+// weekdayNames are the abbreviations used for the days of a bandwidth window.
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+type (
+	// BandwidthWindow is a recurring window of time during which different
+	// bandwidth limits apply. Start and End are the minutes since midnight in
+	// the local time of the daemon. A window with an End before its Start
+	// wraps past midnight and a window with an End equal to its Start covers
+	// the whole day. A window without Days is active on every day.
+	BandwidthWindow struct {
+		Days  []time.Weekday `json:"days"`
+		Start uint64         `json:"start"`
+		End   uint64         `json:"end"`
+
+		MaxDownloadSpeed int64 `json:"maxdownloadspeed"`
+		MaxUploadSpeed   int64 `json:"maxuploadspeed"`
+	}
+
+	// BandwidthSchedule is a list of bandwidth windows. If multiple windows
+	// are active at the same time, the first one wins. Outside of all windows
+	// the default limits apply.
+	BandwidthSchedule []BandwidthWindow
+
+	// BandwidthScheduler applies the limits of a bandwidth schedule to a
+	// ratelimit and keeps them up to date as windows start and end.
+	BandwidthScheduler struct {
+		defaultDownload int64
+		defaultUpload   int64
+		schedule        BandwidthSchedule
+
+		activeDownload int64
+		activeUpload   int64
+
+		staticRL *ratelimit.RateLimit
+		mu       sync.Mutex
+	}
+)
+
+// ParseBandwidthSchedule parses a bandwidth schedule from its string
+// representation. Windows are separated by semicolons and each window consists
+// of the days, the time of day, the download speed and the upload speed in
+// bytes per second, e.g. "mon-fri 09:00-17:00 1000000 500000". The days are
+// either '*' for every day or a comma separated list of days and day ranges.
+// An empty string is an empty schedule.
+func ParseBandwidthSchedule(s string) (BandwidthSchedule, error) {
+	var schedule BandwidthSchedule
+	for _, ws := range strings.Split(s, ";") {
+		if strings.TrimSpace(ws) == "" {
+			continue
+		}
+		w, err := parseBandwidthWindow(ws)
+		if err != nil {
+			return nil, errors.Compose(ErrInvalidBandwidthSchedule, errors.AddContext(err, fmt.Sprintf("failed to parse window '%v'", strings.TrimSpace(ws))))
+		}
+		schedule = append(schedule, w)
+	}
+	return schedule, schedule.Validate()
+}
+
+// parseBandwidthWindow parses a single window of a bandwidth schedule.
+func parseBandwidthWindow(s string) (w BandwidthWindow, err error) {
+	fields := strings.Fields(s)
+	if len(fields) != 4 {
+		return BandwidthWindow{}, errors.New("expected days, time of day, download speed and upload speed")
+	}
+	w.Days, err = parseWeekdays(fields[0])
+	if err != nil {
+		return BandwidthWindow{}, err
+	}
+	times := strings.Split(fields[1], "-")
+	if len(times) != 2 {
+		return BandwidthWindow{}, errors.New("time of day must be of the form hh:mm-hh:mm")
+	}
+	w.Start, err = parseTimeOfDay(times[0])
+	if err != nil {
+		return BandwidthWindow{}, err
+	}
+	w.End, err = parseTimeOfDay(times[1])
+	if err != nil {
+		return BandwidthWindow{}, err
+	}
+	w.MaxDownloadSpeed, err = strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return BandwidthWindow{}, errors.AddContext(err, "failed to parse download speed")
+	}
+	w.MaxUploadSpeed, err = strconv.ParseInt(fields[3], 10, 64)
+	if err != nil {
+		return BandwidthWindow{}, errors.AddContext(err, "failed to parse upload speed")
+	}
+	return w, nil
+}
+
+// parseTimeOfDay parses a time of day of the form hh:mm into the minutes since
+// midnight. 24:00 is accepted as the end of the day.
+func parseTimeOfDay(s string) (uint64, error) {
+	var hours, minutes uint64
+	if _, err := fmt.Sscanf(s, "%d:%d", &hours, &minutes); err != nil {
+		return 0, errors.AddContext(err, fmt.Sprintf("failed to parse time of day '%v'", s))
+	}
+	if minutes >= 60 || hours*60+minutes > minutesPerDay {
+		return 0, fmt.Errorf("time of day '%v' is out of range", s)
+	}
+	return (hours*60 + minutes) % minutesPerDay, nil
+}
+
+// parseWeekday parses the abbreviation of a day of the week.
+func parseWeekday(s string) (time.Weekday, error) {
+	for i, name := range weekdayNames {
+		if strings.ToLower(s) == name {
+			return time.Weekday(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown day '%v'", s)
+}
+
+// parseWeekdays parses a list of days of the week.
+func parseWeekdays(s string) ([]time.Weekday, error) {
+	if s == "*" {
+		return nil, nil
+	}
+	var days []time.Weekday
+	for _, ds := range strings.Split(s, ",") {
+		bounds := strings.Split(ds, "-")
+		if len(bounds) > 2 {
+			return nil, fmt.Errorf("invalid day range '%v'", ds)
+		}
+		first, err := parseWeekday(bounds[0])
+		if err != nil {
+			return nil, err
+		}
+		last := first
+		if len(bounds) == 2 {
+			last, err = parseWeekday(bounds[1])
+			if err != nil {
+				return nil, err
+			}
+		}
+		// Ranges may wrap around the end of the week.
+		for d := first; ; d = (d + 1) % 7 {
+			days = append(days, d)
+			if d == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// Active returns whether the window is active at the given time.
+func (w BandwidthWindow) Active(t time.Time) bool {
+	minute := uint64(t.Hour()*60 + t.Minute())
+	today := t.Weekday()
+	yesterday := (today + 6) % 7
+	switch {
+	case w.Start == w.End:
+		return w.activeOn(today)
+	case w.Start < w.End:
+		return w.activeOn(today) && minute >= w.Start && minute < w.End
+	default:
+		// The window wraps past midnight and belongs to the day it started.
+		return (w.activeOn(today) && minute >= w.Start) || (w.activeOn(yesterday) && minute < w.End)
+	}
+}
+
+// activeOn returns whether the window applies to the given day.
+func (w BandwidthWindow) activeOn(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// Period returns the days and the time of day of the window, e.g.
+// "mon,tue 09:00-17:00".
+func (w BandwidthWindow) Period() string {
+	days := "*"
+	if len(w.Days) > 0 {
+		names := make([]string, 0, len(w.Days))
+		for _, d := range w.Days {
+			names = append(names, weekdayNames[d%7])
+		}
+		days = strings.Join(names, ",")
+	}
+	return fmt.Sprintf("%v %02d:%02d-%02d:%02d", days, w.Start/60, w.Start%60, w.End/60, w.End%60)
+}
+
+// String returns the string representation of the window that can be parsed
+// by ParseBandwidthSchedule.
+func (w BandwidthWindow) String() string {
+	return fmt.Sprintf("%v %v %v", w.Period(), w.MaxDownloadSpeed, w.MaxUploadSpeed)
+}
+
+// Validate checks that the window is valid.
+func (w BandwidthWindow) Validate() error {
+	if w.Start >= minutesPerDay || w.End >= minutesPerDay {
+		return errors.New("start and end of a window must be less than a day")
+	}
+	for _, d := range w.Days {
+		if d < time.Sunday || d > time.Saturday {
+			return fmt.Errorf("invalid day %v", int(d))
+		}
+	}
+	if w.MaxDownloadSpeed < 0 || w.MaxUploadSpeed < 0 {
+		return errors.New("download/upload rate limit can't be below 0")
+	}
+	return nil
+}
+
+// Limits returns the download and upload limits that apply at the given time.
+// If no window is active the default limits are returned.
+func (bs BandwidthSchedule) Limits(t time.Time, download, upload int64) (int64, int64) {
+	for _, w := range bs {
+		if w.Active(t) {
+			return w.MaxDownloadSpeed, w.MaxUploadSpeed
+		}
+	}
+	return download, upload
+}
+
+// String returns the string representation of the schedule that can be parsed
+// by ParseBandwidthSchedule.
+func (bs BandwidthSchedule) String() string {
+	windows := make([]string, 0, len(bs))
+	for _, w := range bs {
+		windows = append(windows, w.String())
+	}
+	return strings.Join(windows, "; ")
+}
+
+// Validate checks that all windows of the schedule are valid.
+func (bs BandwidthSchedule) Validate() error {
+	for i, w := range bs {
+		if err := w.Validate(); err != nil {
+			return errors.Compose(ErrInvalidBandwidthSchedule, errors.AddContext(err, fmt.Sprintf("window %v", i)))
+		}
+	}
+	return nil
+}
+
+// NewBandwidthScheduler creates a new scheduler for the given ratelimit. The
+// ratelimit is not limited until a schedule is set.
+func NewBandwidthScheduler(rl *ratelimit.RateLimit) *BandwidthScheduler {
+	return &BandwidthScheduler{
+		staticRL: rl,
+	}
+}
+
+// ActiveLimits returns the limits that are currently applied to the
+// ratelimit.
+func (bs *BandwidthScheduler) ActiveLimits() (int64, int64) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	return bs.activeDownload, bs.activeUpload
+}
+
+// Schedule returns the default limits and the schedule of the scheduler.
+func (bs *BandwidthScheduler) Schedule() (int64, int64, BandwidthSchedule) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	return bs.defaultDownload, bs.defaultUpload, append(BandwidthSchedule(nil), bs.schedule...)
+}
+
+// SetSchedule sets the default limits and the schedule of the scheduler and
+// immediately applies the limits that are active right now.
+func (bs *BandwidthScheduler) SetSchedule(download, upload int64, schedule BandwidthSchedule) error {
+	if download < 0 || upload < 0 {
+		return errors.New("download/upload rate limit can't be below 0")
+	}
+	if err := schedule.Validate(); err != nil {
+		return err
+	}
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	bs.defaultDownload = download
+	bs.defaultUpload = upload
+	bs.schedule = append(BandwidthSchedule(nil), schedule...)
+	bs.update(time.Now(), true)
+	return nil
+}
+
+// Start launches a background thread that applies the active limits of the
+// schedule until the stop channel is closed.
+func (bs *BandwidthScheduler) Start(stop <-chan struct{}) {
+	go bs.threadedApplySchedule(stop)
+}
+
+// threadedApplySchedule periodically updates the limits of the ratelimit.
+func (bs *BandwidthScheduler) threadedApplySchedule(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(BandwidthScheduleInterval):
+		}
+		bs.mu.Lock()
+		bs.update(time.Now(), false)
+		bs.mu.Unlock()
+	}
+}
+
+// update applies the limits that are active at the given time to the
+// ratelimit. Unless force is specified, the ratelimit is only updated if the
+// limits changed.
+func (bs *BandwidthScheduler) update(t time.Time, force bool) {
+	download, upload := bs.schedule.Limits(t, bs.defaultDownload, bs.defaultUpload)
+	if !force && download == bs.activeDownload && upload == bs.activeUpload {
+		return
+	}
+	bs.activeDownload = download
+	bs.activeUpload = upload
+
+	// Check for sentinel "no limits" value.
+	if download == 0 && upload == 0 {
+		bs.staticRL.SetLimits(0, 0, 0)
+	} else {
+		bs.staticRL.SetLimits(download, upload, bandwidthSchedulePacketSize)
+	}
+}
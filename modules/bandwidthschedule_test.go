@@ -0,0 +1,146 @@
+package modules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/turtledex/errors"
+	"github.com/turtledex/ratelimit"
+)
+
+// TestParseBandwidthSchedule tests parsing bandwidth schedules.
+func TestParseBandwidthSchedule(t *testing.T) {
+	schedule, err := ParseBandwidthSchedule("mon-fri 09:00-17:00 1000 500; sat,sun 22:00-06:00 0 0;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schedule) != 2 {
+		t.Fatal("wrong number of windows", len(schedule))
+	}
+	if len(schedule[0].Days) != 5 || schedule[0].Start != 9*60 || schedule[0].End != 17*60 {
+		t.Fatal("wrong first window", schedule[0])
+	}
+	if schedule[0].MaxDownloadSpeed != 1000 || schedule[0].MaxUploadSpeed != 500 {
+		t.Fatal("wrong speeds", schedule[0])
+	}
+
+	// The string representation can be parsed again.
+	expected := "mon,tue,wed,thu,fri 09:00-17:00 1000 500; sat,sun 22:00-06:00 0 0"
+	if schedule.String() != expected {
+		t.Fatalf("expected '%v' but got '%v'", expected, schedule.String())
+	}
+	reparsed, err := ParseBandwidthSchedule(schedule.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reparsed.String() != expected {
+		t.Fatal("schedule changed after parsing", reparsed)
+	}
+
+	// Day ranges wrap around the end of the week and 24:00 is the end of the
+	// day.
+	schedule, err = ParseBandwidthSchedule("fri-mon 12:00-24:00 1 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schedule[0].Days) != 4 || schedule[0].End != 0 {
+		t.Fatal("wrong window", schedule[0])
+	}
+
+	// An empty schedule is valid.
+	schedule, err = ParseBandwidthSchedule("")
+	if err != nil || len(schedule) != 0 {
+		t.Fatal("expected empty schedule", schedule, err)
+	}
+
+	// Invalid schedules.
+	for _, s := range []string{
+		"* 09:00-17:00 1000",
+		"someday 09:00-17:00 1000 500",
+		"* 09:00 1000 500",
+		"* 25:00-17:00 1000 500",
+		"* 09:60-17:00 1000 500",
+		"* 09:00-17:00 -1 500",
+		"* 09:00-17:00 1000 fast",
+	} {
+		if _, err := ParseBandwidthSchedule(s); !errors.Contains(err, ErrInvalidBandwidthSchedule) {
+			t.Fatalf("expected ErrInvalidBandwidthSchedule for '%v' but got %v", s, err)
+		}
+	}
+}
+
+// TestBandwidthScheduleLimits tests which limits apply at a given time.
+func TestBandwidthScheduleLimits(t *testing.T) {
+	schedule, err := ParseBandwidthSchedule("mon-fri 09:00-17:00 100 50; fri 22:00-06:00 10 5; * 00:00-00:00 1 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2021-03-05 is a friday.
+	date := func(day, hour, minute int) time.Time {
+		return time.Date(2021, time.March, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		t        time.Time
+		download int64
+		upload   int64
+	}{
+		{date(5, 9, 0), 100, 50},
+		{date(5, 16, 59), 100, 50},
+		{date(5, 17, 0), 1, 1},
+		{date(5, 23, 0), 10, 5},
+		{date(6, 5, 59), 10, 5},
+		{date(6, 6, 0), 1, 1},
+		{date(6, 23, 0), 1, 1},
+	}
+	for _, test := range tests {
+		download, upload := schedule.Limits(test.t, 1000, 1000)
+		if download != test.download || upload != test.upload {
+			t.Errorf("%v: expected %v/%v but got %v/%v", test.t, test.download, test.upload, download, upload)
+		}
+	}
+
+	// Without a matching window the defaults apply.
+	download, upload := schedule[:2].Limits(date(6, 12, 0), 1000, 2000)
+	if download != 1000 || upload != 2000 {
+		t.Fatal("expected default limits", download, upload)
+	}
+}
+
+// TestBandwidthScheduler tests applying a schedule to a ratelimit.
+func TestBandwidthScheduler(t *testing.T) {
+	rl := ratelimit.NewRateLimit(0, 0, 0)
+	bs := NewBandwidthScheduler(rl)
+
+	// Without a schedule, the defaults are applied.
+	if err := bs.SetSchedule(1000, 2000, nil); err != nil {
+		t.Fatal(err)
+	}
+	if download, upload, _ := rl.Limits(); download != 1000 || upload != 2000 {
+		t.Fatal("wrong limits", download, upload)
+	}
+
+	// A window covering the whole day is applied immediately.
+	schedule := BandwidthSchedule{{MaxDownloadSpeed: 10, MaxUploadSpeed: 20}}
+	if err := bs.SetSchedule(1000, 2000, schedule); err != nil {
+		t.Fatal(err)
+	}
+	if download, upload, _ := rl.Limits(); download != 10 || upload != 20 {
+		t.Fatal("wrong limits", download, upload)
+	}
+	if download, upload := bs.ActiveLimits(); download != 10 || upload != 20 {
+		t.Fatal("wrong active limits", download, upload)
+	}
+	download, upload, s := bs.Schedule()
+	if download != 1000 || upload != 2000 || len(s) != 1 {
+		t.Fatal("wrong schedule", download, upload, s)
+	}
+
+	// Invalid schedules are rejected.
+	if err := bs.SetSchedule(-1, 0, nil); err == nil {
+		t.Fatal("negative limits should be rejected")
+	}
+	schedule = BandwidthSchedule{{Start: minutesPerDay}}
+	if err := bs.SetSchedule(0, 0, schedule); !errors.Contains(err, ErrInvalidBandwidthSchedule) {
+		t.Fatal("expected ErrInvalidBandwidthSchedule", err)
+	}
+}
@@ -0,0 +1,127 @@
+package modules
+
+import (
+	"fmt"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+const (
+	// BudgetCategoryUpload is the budget category for uploads.
+	BudgetCategoryUpload BudgetCategory = "upload"
+
+	// BudgetCategoryDownload is the budget category for downloads.
+	BudgetCategoryDownload BudgetCategory = "download"
+
+	// BudgetCategoryRegistry is the budget category for registry reads and
+	// updates.
+	BudgetCategoryRegistry BudgetCategory = "registry"
+
+	// DefaultBudgetIdentity is the identity of the budget that is shared by
+	// all callers which don't have a budget of their own. Without it, such
+	// callers are not limited.
+	DefaultBudgetIdentity = "default"
+
+	// FuseBudgetIdentity is the identity that is charged for the data read
+	// from and written to the renter's fuse mounts.
+	FuseBudgetIdentity = "fuse"
+)
+
+var (
+	// ErrBudgetExhausted is returned when an operation would exceed the
+	// budget of the caller.
+	ErrBudgetExhausted = errors.New("budget exhausted")
+
+	// ErrInvalidBudgetCategory is returned when a budget category is unknown.
+	ErrInvalidBudgetCategory = errors.New("invalid budget category")
+
+	// ErrEmptyBudgetIdentity is returned when a budget is set without an
+	// identity.
+	ErrEmptyBudgetIdentity = errors.New("budget identity can't be empty")
+)
+
+type (
+	// BudgetCategory is a category of spending that is limited by a budget.
+	BudgetCategory string
+
+	// RenterBudget limits the spending of a single caller of the renter's API
+	// per period. The caller is identified by its API token or its user agent.
+	// Callers without a budget of their own are charged to the budget of
+	// DefaultBudgetIdentity. A limit of 0 means that the category is
+	// unlimited.
+	RenterBudget struct {
+		Identity string         `json:"identity"`
+		Upload   types.Currency `json:"upload"`
+		Download types.Currency `json:"download"`
+		Registry types.Currency `json:"registry"`
+	}
+
+	// RenterBudgetUsage is a budget together with the amount that was spent
+	// from it in the current period.
+	RenterBudgetUsage struct {
+		RenterBudget
+
+		UploadSpending   types.Currency `json:"uploadspending"`
+		DownloadSpending types.Currency `json:"downloadspending"`
+		RegistrySpending types.Currency `json:"registryspending"`
+
+		// PeriodStart is the start height of the period the spending was
+		// tracked in.
+		PeriodStart types.BlockHeight `json:"periodstart"`
+	}
+)
+
+// Limit returns the limit of the budget for the category.
+func (b RenterBudget) Limit(category BudgetCategory) (types.Currency, error) {
+	switch category {
+	case BudgetCategoryUpload:
+		return b.Upload, nil
+	case BudgetCategoryDownload:
+		return b.Download, nil
+	case BudgetCategoryRegistry:
+		return b.Registry, nil
+	}
+	return types.ZeroCurrency, errors.AddContext(ErrInvalidBudgetCategory, string(category))
+}
+
+// Spending returns the amount spent from the budget for the category.
+func (u RenterBudgetUsage) Spending(category BudgetCategory) (types.Currency, error) {
+	switch category {
+	case BudgetCategoryUpload:
+		return u.UploadSpending, nil
+	case BudgetCategoryDownload:
+		return u.DownloadSpending, nil
+	case BudgetCategoryRegistry:
+		return u.RegistrySpending, nil
+	}
+	return types.ZeroCurrency, errors.AddContext(ErrInvalidBudgetCategory, string(category))
+}
+
+// Charge adds cost to the spending of the category. If that would exceed the
+// limit of the category, ErrBudgetExhausted is returned and the spending is
+// left unchanged.
+func (u *RenterBudgetUsage) Charge(category BudgetCategory, cost types.Currency) error {
+	limit, err := u.Limit(category)
+	if err != nil {
+		return err
+	}
+	spent, err := u.Spending(category)
+	if err != nil {
+		return err
+	}
+	spending := spent.Add(cost)
+	if !limit.IsZero() && (spent.Cmp(limit) >= 0 || spending.Cmp(limit) > 0) {
+		return errors.AddContext(ErrBudgetExhausted, fmt.Sprintf("%v budget of '%v' is %v and %v were spent already", category, u.Identity, limit.HumanString(), spent.HumanString()))
+	}
+	switch category {
+	case BudgetCategoryUpload:
+		u.UploadSpending = spending
+	case BudgetCategoryDownload:
+		u.DownloadSpending = spending
+	case BudgetCategoryRegistry:
+		u.RegistrySpending = spending
+	}
+	return nil
+}
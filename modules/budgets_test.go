@@ -0,0 +1,57 @@
+package modules
+
+import (
+	"testing"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+// TestRenterBudgetUsageCharge tests charging budgets.
+func TestRenterBudgetUsageCharge(t *testing.T) {
+	usage := RenterBudgetUsage{
+		RenterBudget: RenterBudget{
+			Identity: "app",
+			Download: types.NewCurrency64(100),
+		},
+	}
+
+	// Charges within the limit succeed.
+	if err := usage.Charge(BudgetCategoryDownload, types.NewCurrency64(60)); err != nil {
+		t.Fatal(err)
+	}
+	if !usage.DownloadSpending.Equals64(60) {
+		t.Fatal("wrong spending", usage.DownloadSpending)
+	}
+
+	// A charge exceeding the limit fails and doesn't change the spending.
+	err := usage.Charge(BudgetCategoryDownload, types.NewCurrency64(50))
+	if !errors.Contains(err, ErrBudgetExhausted) {
+		t.Fatal("expected ErrBudgetExhausted", err)
+	}
+	if !usage.DownloadSpending.Equals64(60) {
+		t.Fatal("spending changed", usage.DownloadSpending)
+	}
+
+	// Once the limit is reached, even free operations fail.
+	if err := usage.Charge(BudgetCategoryDownload, types.NewCurrency64(40)); err != nil {
+		t.Fatal(err)
+	}
+	if err := usage.Charge(BudgetCategoryDownload, types.ZeroCurrency); !errors.Contains(err, ErrBudgetExhausted) {
+		t.Fatal("expected ErrBudgetExhausted", err)
+	}
+
+	// Categories without a limit are unlimited.
+	if err := usage.Charge(BudgetCategoryUpload, types.TurtleDexcoinPrecision); err != nil {
+		t.Fatal(err)
+	}
+	if !usage.UploadSpending.Equals(types.TurtleDexcoinPrecision) || !usage.RegistrySpending.IsZero() {
+		t.Fatal("wrong spending", usage.UploadSpending, usage.RegistrySpending)
+	}
+
+	// Unknown categories are rejected.
+	if err := usage.Charge("storage", types.ZeroCurrency); !errors.Contains(err, ErrInvalidBudgetCategory) {
+		t.Fatal("expected ErrInvalidBudgetCategory", err)
+	}
+}
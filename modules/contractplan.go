@@ -0,0 +1,90 @@
+package modules
+
+import (
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+type (
+	// ContractMaintenancePlan describes what contract maintenance would do in
+	// its next iteration with a given allowance. Creating a plan has no side
+	// effects. Hosts for new contracts are picked at random, so the planned
+	// formations are a sample of the hosts maintenance might choose.
+	ContractMaintenancePlan struct {
+		Allowance   Allowance         `json:"allowance"`
+		BlockHeight types.BlockHeight `json:"blockheight"`
+		EndHeight   types.BlockHeight `json:"endheight"`
+
+		// FundsAvailable are the funds of the allowance that aren't allocated
+		// to contracts of the current period yet.
+		FundsAvailable types.Currency `json:"fundsavailable"`
+
+		// UtilityUpdates contains the contracts whose utility would change.
+		UtilityUpdates []PlannedUtilityUpdate `json:"utilityupdates"`
+
+		// Renewals contains the contracts which would be renewed because they
+		// are about to expire, Refreshes the contracts which would be renewed
+		// because they are running out of funds.
+		Renewals  []PlannedRenewal `json:"renewals"`
+		Refreshes []PlannedRenewal `json:"refreshes"`
+
+		// NeededContracts is the number of contracts that need to be formed to
+		// reach the allowance's hosts after the renewals. Formations contains
+		// the hosts new contracts would be formed with.
+		NeededContracts uint64             `json:"neededcontracts"`
+		Formations      []PlannedFormation `json:"formations"`
+
+		// ChurnedContracts is the number of contracts that would be marked
+		// !GFR for their host's score within the churn budget. ChurnAvoided is
+		// the number of contracts that would be kept because the churn budget
+		// is exhausted.
+		ChurnedContracts uint64 `json:"churnedcontracts"`
+		ChurnAvoided     uint64 `json:"churnavoided"`
+
+		// The estimated spending of the plan. The renewal and formation
+		// estimates only include the contracts that fit into the allowance.
+		EstimatedRenewSpending     types.Currency `json:"estimatedrenewspending"`
+		EstimatedRefreshSpending   types.Currency `json:"estimatedrefreshspending"`
+		EstimatedFormationSpending types.Currency `json:"estimatedformationspending"`
+		EstimatedTotalSpending     types.Currency `json:"estimatedtotalspending"`
+
+		// Warnings contains conditions which would prevent maintenance from
+		// carrying out the plan, e.g. a locked wallet.
+		Warnings []string `json:"warnings"`
+	}
+
+	// PlannedUtilityUpdate is a change of a contract's utility planned by
+	// contract maintenance.
+	PlannedUtilityUpdate struct {
+		ID            types.FileContractID     `json:"id"`
+		HostPublicKey types.TurtleDexPublicKey `json:"hostpublickey"`
+		OldUtility    ContractUtility          `json:"oldutility"`
+		NewUtility    ContractUtility          `json:"newutility"`
+		Reason        string                   `json:"reason"`
+	}
+
+	// PlannedRenewal is a renewal planned by contract maintenance.
+	PlannedRenewal struct {
+		ID            types.FileContractID     `json:"id"`
+		HostPublicKey types.TurtleDexPublicKey `json:"hostpublickey"`
+		Funding       types.Currency           `json:"funding"`
+
+		// Skipped is true if the remaining funds of the allowance aren't
+		// sufficient for the renewal.
+		Skipped bool `json:"skipped"`
+	}
+
+	// PlannedFormation is a contract formation planned by contract
+	// maintenance.
+	PlannedFormation struct {
+		HostPublicKey types.TurtleDexPublicKey `json:"hostpublickey"`
+		NetAddress    NetAddress               `json:"netaddress"`
+		Funding       types.Currency           `json:"funding"`
+
+		// Groups contains the host groups the host belongs to.
+		Groups []string `json:"groups"`
+
+		// PaymentContract is true for the contracts portals form with all
+		// hosts.
+		PaymentContract bool `json:"paymentcontract"`
+	}
+)
@@ -0,0 +1,93 @@
+package modules
+
+import (
+	"strings"
+
+	"github.com/turtledex/errors"
+)
+
+const (
+	// DownloadPriorityBulk is the priority class for background and bulk
+	// downloads like backups and migrations. Queued bulk work is preempted by
+	// downloads of a higher priority and bulk downloads don't overdrive until
+	// the launched workers are considerably late.
+	DownloadPriorityBulk DownloadPriority = iota + 1
+
+	// DownloadPriorityNormal is the priority class for regular downloads.
+	DownloadPriorityNormal
+
+	// DownloadPriorityInteractive is the priority class for downloads that a
+	// user is actively waiting for, like streams.
+	DownloadPriorityInteractive
+)
+
+var (
+	// ErrInvalidDownloadPriority is returned when a download priority is
+	// unknown.
+	ErrInvalidDownloadPriority = errors.New("invalid download priority, must be one of 'interactive', 'normal' or 'bulk'")
+)
+
+type (
+	// DownloadPriority is the priority class of a download. Downloads of a
+	// higher class are scheduled before downloads of a lower class, both in
+	// the download heap and in the read queues of the workers. The zero value
+	// is used for repairs, which are scheduled after all other downloads.
+	DownloadPriority uint8
+
+	// DownloadPriorityCounts contains a count per download priority class.
+	// Repairs are counted as bulk work.
+	DownloadPriorityCounts struct {
+		Interactive uint64 `json:"interactive"`
+		Normal      uint64 `json:"normal"`
+		Bulk        uint64 `json:"bulk"`
+	}
+)
+
+// ParseDownloadPriority parses a download priority class from its string
+// representation.
+func ParseDownloadPriority(s string) (DownloadPriority, error) {
+	switch strings.ToLower(s) {
+	case "interactive":
+		return DownloadPriorityInteractive, nil
+	case "normal":
+		return DownloadPriorityNormal, nil
+	case "bulk":
+		return DownloadPriorityBulk, nil
+	}
+	return 0, errors.AddContext(ErrInvalidDownloadPriority, s)
+}
+
+// String implements the fmt.Stringer interface.
+func (p DownloadPriority) String() string {
+	switch p {
+	case DownloadPriorityInteractive:
+		return "interactive"
+	case DownloadPriorityNormal:
+		return "normal"
+	case DownloadPriorityBulk:
+		return "bulk"
+	}
+	return "repair"
+}
+
+// Add increments the count of the priority class. Priorities below
+// DownloadPriorityBulk are counted as bulk work.
+func (c *DownloadPriorityCounts) Add(p DownloadPriority) {
+	switch {
+	case p >= DownloadPriorityInteractive:
+		c.Interactive++
+	case p == DownloadPriorityNormal:
+		c.Normal++
+	default:
+		c.Bulk++
+	}
+}
+
+// Sum adds up two sets of counts.
+func (c DownloadPriorityCounts) Sum(other DownloadPriorityCounts) DownloadPriorityCounts {
+	return DownloadPriorityCounts{
+		Interactive: c.Interactive + other.Interactive,
+		Normal:      c.Normal + other.Normal,
+		Bulk:        c.Bulk + other.Bulk,
+	}
+}
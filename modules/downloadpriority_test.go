@@ -0,0 +1,46 @@
+package modules
+
+import (
+	"testing"
+
+	"github.com/turtledex/errors"
+)
+
+// TestParseDownloadPriority tests parsing download priorities.
+func TestParseDownloadPriority(t *testing.T) {
+	for _, p := range []DownloadPriority{DownloadPriorityBulk, DownloadPriorityNormal, DownloadPriorityInteractive} {
+		parsed, err := ParseDownloadPriority(p.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed != p {
+			t.Fatalf("expected %v but got %v", p, parsed)
+		}
+	}
+	if p, err := ParseDownloadPriority("Interactive"); err != nil || p != DownloadPriorityInteractive {
+		t.Fatal("parsing should be case insensitive", p, err)
+	}
+	if _, err := ParseDownloadPriority("urgent"); !errors.Contains(err, ErrInvalidDownloadPriority) {
+		t.Fatal("expected ErrInvalidDownloadPriority", err)
+	}
+	if _, err := ParseDownloadPriority(""); !errors.Contains(err, ErrInvalidDownloadPriority) {
+		t.Fatal("expected ErrInvalidDownloadPriority", err)
+	}
+}
+
+// TestDownloadPriorityCounts tests counting downloads per priority class.
+func TestDownloadPriorityCounts(t *testing.T) {
+	var counts DownloadPriorityCounts
+	counts.Add(DownloadPriorityInteractive)
+	counts.Add(DownloadPriorityNormal)
+	counts.Add(DownloadPriorityNormal)
+	counts.Add(DownloadPriorityBulk)
+	counts.Add(0) // repair
+	if counts.Interactive != 1 || counts.Normal != 2 || counts.Bulk != 2 {
+		t.Fatal("unexpected counts", counts)
+	}
+	sum := counts.Sum(counts)
+	if sum.Interactive != 2 || sum.Normal != 4 || sum.Bulk != 4 {
+		t.Fatal("unexpected sum", sum)
+	}
+}
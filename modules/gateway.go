@@ -190,6 +190,13 @@ type (
 		// gateway.
 		SetRateLimits(downloadSpeed, uploadSpeed int64) error
 
+		// BandwidthSchedule returns the bandwidth schedule of the gateway.
+		BandwidthSchedule() BandwidthSchedule
+
+		// SetBandwidthSchedule changes the windows during which different rate
+		// limits apply to the peer-connections of the gateway.
+		SetBandwidthSchedule(schedule BandwidthSchedule) error
+
 		// UnregisterRPC unregisters an RPC and removes all references to the
 		// RPCFunc supplied in the corresponding RegisterRPC call. References to
 		// RPCFuncs registered with RegisterConnectCall are not removed and
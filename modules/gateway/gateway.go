@@ -120,6 +120,9 @@ type Gateway struct {
 	port     string
 	rl       *ratelimit.RateLimit
 
+	// staticBandwidthScheduler adjusts rl according to the bandwidth schedule.
+	staticBandwidthScheduler *modules.BandwidthScheduler
+
 	// handlers are the RPCs that the Gateway can handle.
 	//
 	// initRPCs are the RPCs that the Gateway calls upon connecting to a peer.
@@ -205,20 +208,10 @@ func (g *Gateway) managedSleep(t time.Duration) (completed bool) {
 	}
 }
 
-// setRateLimits sets the specified ratelimit after performing input
-// validation without persisting them.
-func setRateLimits(rl *ratelimit.RateLimit, downloadSpeed, uploadSpeed int64) error {
-	// Input validation.
-	if downloadSpeed < 0 || uploadSpeed < 0 {
-		return errors.New("download/upload rate can't be below 0")
-	}
-	// Check for sentinel "no limits" value.
-	if downloadSpeed == 0 && uploadSpeed == 0 {
-		rl.SetLimits(0, 0, 0)
-	} else {
-		rl.SetLimits(downloadSpeed, uploadSpeed, 4*4096)
-	}
-	return nil
+// setRateLimits sets the specified ratelimit and bandwidth schedule after
+// performing input validation without persisting them.
+func (g *Gateway) setRateLimits(downloadSpeed, uploadSpeed int64, schedule modules.BandwidthSchedule) error {
+	return g.staticBandwidthScheduler.SetSchedule(downloadSpeed, uploadSpeed, schedule)
 }
 
 // Address returns the NetAddress of the Gateway.
@@ -294,6 +287,13 @@ func (g *Gateway) ForwardPort(port string) error {
 	return g.managedForwardPort(port)
 }
 
+// BandwidthSchedule returns the bandwidth schedule of the gateway.
+func (g *Gateway) BandwidthSchedule() modules.BandwidthSchedule {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return append(modules.BandwidthSchedule(nil), g.persist.BandwidthSchedule...)
+}
+
 // RateLimits returns the currently set bandwidth limits of the gateway.
 func (g *Gateway) RateLimits() (int64, int64) {
 	g.mu.RLock()
@@ -345,7 +345,7 @@ func (g *Gateway) SetRateLimits(downloadSpeed, uploadSpeed int64) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 	// Set the limit in memory.
-	if err := setRateLimits(g.rl, downloadSpeed, uploadSpeed); err != nil {
+	if err := g.setRateLimits(downloadSpeed, uploadSpeed, g.persist.BandwidthSchedule); err != nil {
 		return err
 	}
 	// Update the persistence struct.
@@ -354,6 +354,20 @@ func (g *Gateway) SetRateLimits(downloadSpeed, uploadSpeed int64) error {
 	return g.saveSync()
 }
 
+// SetBandwidthSchedule changes the windows during which different rate limits
+// apply to the peer-connections of the gateway.
+func (g *Gateway) SetBandwidthSchedule(schedule modules.BandwidthSchedule) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	// Set the schedule in memory.
+	if err := g.setRateLimits(g.persist.MaxDownloadSpeed, g.persist.MaxUploadSpeed, schedule); err != nil {
+		return err
+	}
+	// Update the persistence struct.
+	g.persist.BandwidthSchedule = schedule
+	return g.saveSync()
+}
+
 // New returns an initialized Gateway.
 func New(addr string, bootstrap bool, persistDir string) (*Gateway, error) {
 	return NewCustomGateway(addr, bootstrap, persistDir, modules.ProdDependencies)
@@ -431,9 +445,11 @@ func NewCustomGateway(addr string, bootstrap bool, persistDir string, deps modul
 	}
 	// Create the ratelimiter and set it to the persisted limits.
 	g.rl = ratelimit.NewRateLimit(0, 0, 0)
-	if err := setRateLimits(g.rl, g.persist.MaxDownloadSpeed, g.persist.MaxUploadSpeed); err != nil {
+	g.staticBandwidthScheduler = modules.NewBandwidthScheduler(g.rl)
+	if err := g.setRateLimits(g.persist.MaxDownloadSpeed, g.persist.MaxUploadSpeed, g.persist.BandwidthSchedule); err != nil {
 		return nil, errors.AddContext(err, "unable to set rate limits for the gateway")
 	}
+	g.staticBandwidthScheduler.Start(g.threads.StopChan())
 	// Create a Bandwidth monitor
 	g.m = connmonitor.NewMonitor()
 	// Spawn the thread to periodically save the gateway.
@@ -32,8 +32,9 @@ type (
 		RouterURL string
 
 		// rate limit settings
-		MaxDownloadSpeed int64
-		MaxUploadSpeed   int64
+		MaxDownloadSpeed  int64
+		MaxUploadSpeed    int64
+		BandwidthSchedule modules.BandwidthSchedule
 
 		// blocklisted IPs
 		Blocklist []string
@@ -139,6 +139,15 @@ var (
 	// deleted.
 	DefaultEphemeralAccountExpiry = time.Minute * 60 * 24 * 7 // 1 week
 
+	// DefaultPeerBanDuration defines the default amount of time a peer is
+	// banned for and within which its invalid payments and programs are
+	// counted.
+	DefaultPeerBanDuration = time.Hour
+
+	// DefaultPeerBanThreshold defines the default number of invalid payments
+	// or programs after which a peer is banned.
+	DefaultPeerBanThreshold = uint64(20)
+
 	// DefaultMaxEphemeralAccountBalance defines the default maximum amount of
 	// money that the host will allow to deposit into a single ephemeral account
 	DefaultMaxEphemeralAccountBalance = types.TurtleDexcoinPrecision
@@ -263,6 +272,40 @@ type (
 		UploadBandwidthRevenue            types.Currency `json:"uploadbandwidthrevenue"`
 	}
 
+	// HostLedgerEntry is an entry of the host's revenue ledger. It contains
+	// the revenue, the collateral and the expenses of a single contract on a
+	// single UTC day. Payments which don't belong to a contract, such as RPCs
+	// paid from ephemeral accounts, are booked with a zero contract id. The
+	// revenue of a contract is booked on the day its storage obligation
+	// succeeds.
+	HostLedgerEntry struct {
+		Day        time.Time            `json:"day"`
+		ContractID types.FileContractID `json:"contractid"`
+
+		// Revenue of successful storage obligations.
+		AccountFunding       types.Currency `json:"accountfunding"`
+		ContractCompensation types.Currency `json:"contractcompensation"`
+		DownloadRevenue      types.Currency `json:"downloadrevenue"`
+		StorageRevenue       types.Currency `json:"storagerevenue"`
+		UploadRevenue        types.Currency `json:"uploadrevenue"`
+
+		// Payments by contract for RPCs and registry operations.
+		RegistryRevenue types.Currency `json:"registryrevenue"`
+		RPCRevenue      types.Currency `json:"rpcrevenue"`
+
+		// Money spent from ephemeral accounts on RPCs and registry
+		// operations. This isn't revenue since it is already part of the
+		// account funding of the contracts which funded the accounts.
+		AccountRegistrySpending types.Currency `json:"accountregistryspending"`
+		AccountRPCSpending      types.Currency `json:"accountrpcspending"`
+
+		// Collateral and expenses.
+		LockedCollateral   types.Currency `json:"lockedcollateral"`
+		LostCollateral     types.Currency `json:"lostcollateral"`
+		UnlockedCollateral types.Currency `json:"unlockedcollateral"`
+		TransactionFees    types.Currency `json:"transactionfees"`
+	}
+
 	// HostInternalSettings contains a list of settings that can be changed.
 	HostInternalSettings struct {
 		AcceptingContracts   bool              `json:"acceptingcontracts"`
@@ -289,6 +332,37 @@ type (
 
 		CustomRegistryPath string `json:"customregistrypath"`
 		RegistrySize       uint64 `json:"registrysize"`
+
+		// MaxDownloadSpeed and MaxUploadSpeed limit the speed at which the
+		// host receives data from and sends data to renters. The limits of
+		// an active window of the BandwidthSchedule take precedence.
+		MaxDownloadSpeed  int64             `json:"maxdownloadspeed"`
+		MaxUploadSpeed    int64             `json:"maxuploadspeed"`
+		BandwidthSchedule BandwidthSchedule `json:"bandwidthschedule"`
+
+		// PeerMaxConcurrentStreams, PeerMaxDownloadSpeed,
+		// PeerMaxUploadSpeed and PeerMaxProgramsPerMinute limit the
+		// resources a single renter key or ephemeral account can use. A
+		// limit of 0 means that the resource is unlimited.
+		PeerMaxConcurrentStreams uint64 `json:"peermaxconcurrentstreams"`
+		PeerMaxDownloadSpeed     int64  `json:"peermaxdownloadspeed"`
+		PeerMaxUploadSpeed       int64  `json:"peermaxuploadspeed"`
+		PeerMaxProgramsPerMinute uint64 `json:"peermaxprogramsperminute"`
+
+		// PeerBanThreshold is the number of invalid payments or programs a
+		// peer can send within PeerBanDuration before it is banned for
+		// PeerBanDuration. A threshold of 0 disables bans.
+		PeerBanThreshold uint64        `json:"peerbanthreshold"`
+		PeerBanDuration  time.Duration `json:"peerbanduration"`
+	}
+
+	// HostBannedPeer is a peer which the host temporarily refuses to serve
+	// because it repeatedly sent invalid payments or programs.
+	HostBannedPeer struct {
+		Address string    `json:"address"`
+		Reason  string    `json:"reason"`
+		Strikes uint64    `json:"strikes"`
+		Expiry  time.Time `json:"expiry"`
 	}
 
 	// HostNetworkMetrics reports the quantity of each type of RPC call that
@@ -412,9 +486,17 @@ type (
 		// BandwidthCounters returns the Hosts's upload and download bandwidth
 		BandwidthCounters() (uint64, uint64, time.Time, error)
 
+		// BannedPeers returns the peers which are temporarily banned by the
+		// host.
+		BannedPeers() []HostBannedPeer
+
 		// FinancialMetrics returns the financial statistics of the host.
 		FinancialMetrics() HostFinancialMetrics
 
+		// FinancialLedger returns the entries of the host's revenue ledger
+		// for the days between from and to, inclusive.
+		FinancialLedger(from, to time.Time) ([]HostLedgerEntry, error)
+
 		// InternalSettings returns the host's internal settings, including
 		// potentially private or sensitive information.
 		InternalSettings() HostInternalSettings
@@ -428,6 +510,10 @@ type (
 		// PriceTable returns the host's current price table.
 		PriceTable() RPCPriceTable
 
+		// PricingStatus returns the settings and the current state of the
+		// host's dynamic pricing engine.
+		PricingStatus() HostPricingStatus
+
 		// PruneStaleStorageObligations will delete storage obligations from the
 		// host that, for whatever reason, did not make it on the block chain.
 		// As these stale storage obligations have an impact on the host
@@ -479,9 +565,25 @@ type (
 		// and the resize operation completed, meaning that data will be lost.
 		ResizeStorageFolder(index uint16, newSize uint64, force bool) error
 
+		// ScrubStatus returns the progress and the findings of the scrubber
+		// together with the storage obligations which are at risk.
+		ScrubStatus() StorageScrubStatus
+
 		// SetInternalSettings sets the hosting parameters of the host.
 		SetInternalSettings(HostInternalSettings) error
 
+		// SetPricingSettings configures the host's dynamic pricing engine.
+		SetPricingSettings(HostPricingSettings) error
+
+		// SetScrubSettings configures the scrubber.
+		SetScrubSettings(StorageScrubSettings) error
+
+		// SetStorageFolderTier sets the tier of a storage folder on the host.
+		SetStorageFolderTier(index uint16, tier StorageTier) error
+
+		// StartScrub starts a scrub of the host's storage immediately.
+		StartScrub() error
+
 		// StorageObligations returns the set of storage obligations held by
 		// the host.
 		StorageObligations() []StorageObligation
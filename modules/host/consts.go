@@ -12,6 +12,14 @@ const (
 	// AlertMSGHostInsufficientCollateral indicates that a host has insufficient
 	// collateral budget remaining
 	AlertMSGHostInsufficientCollateral = "host has insufficient collateral budget"
+
+	// AlertMSGHostPeersBanned indicates that the host has temporarily banned
+	// peers for sending invalid payments or programs
+	AlertMSGHostPeersBanned = "host has banned peers for sending invalid payments or programs"
+
+	// AlertMSGHostObligationsAtRisk indicates that some of the host's storage
+	// obligations contain corrupt or missing sectors
+	AlertMSGHostObligationsAtRisk = "storage obligations at risk due to corrupt or missing sectors"
 )
 
 const (
@@ -157,6 +165,11 @@ var (
 	// using the id.
 	bucketActionItems = []byte("BucketActionItems")
 
+	// bucketLedger contains the entries of the host's revenue ledger. The
+	// entries are keyed by the day as a big endian unix timestamp followed by
+	// the file contract id.
+	bucketLedger = []byte("BucketLedger")
+
 	// bucketStorageObligations contains a set of serialized
 	// 'storageObligations' sorted by their file contract id.
 	bucketStorageObligations = []byte("BucketStorageObligations")
@@ -12,6 +12,10 @@ const (
 	// AlertMSGHostDiskTrouble indicates that one or multiple of a host's disks
 	// are encountering problems
 	AlertMSGHostDiskTrouble = "disk problem detected"
+
+	// AlertMSGHostCorruptSectors indicates that the scrubber found sectors
+	// which are corrupt or can't be read
+	AlertMSGHostCorruptSectors = "corrupt or missing sectors detected"
 )
 
 const (
@@ -49,6 +53,13 @@ const (
 	// sectorOverflowFile is the path to the file used if a virtual sector's
 	// counter becomes greater than the max value of a uint16.
 	sectorOverflowFile = "sector_overflow.dat"
+
+	// scrubFile is the name of the file that is used to save the settings and
+	// the findings of the scrubber.
+	scrubFile = "scrub.json"
+
+	// scrubVersion is the version of the scrubber's persist file.
+	scrubVersion = "1.5.5"
 )
 
 const (
@@ -91,6 +102,13 @@ var (
 		Header:  "TurtleDex Contract Manager WAL",
 		Version: "1.2.0",
 	}
+
+	// scrubMetadata is the header that is used when writing the settings and
+	// the findings of the scrubber to disk.
+	scrubMetadata = persist.Metadata{
+		Header:  "TurtleDex Contract Manager Scrub",
+		Version: scrubVersion,
+	}
 )
 
 var (
@@ -139,4 +157,39 @@ var (
 		Standard: time.Second * 60 * 5,
 		Testing:  time.Second * 8,
 	}).(time.Duration)
+
+	// scrubCheckInterval specifies how often the contract manager checks
+	// whether a scrub is due.
+	scrubCheckInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Minute * 10,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// tierRebalanceInterval specifies how often the contract manager moves
+	// sectors between the storage tiers.
+	tierRebalanceInterval = build.Select(build.Var{
+		Dev:      time.Minute * 5,
+		Standard: time.Hour,
+		Testing:  time.Second * 5,
+	}).(time.Duration)
+)
+
+var (
+	// tierHotThreshold is the number of reads after which a sector is
+	// considered hot. The read counts are halved after every rebalance, so
+	// the threshold needs to be reached within a few rebalance intervals.
+	tierHotThreshold = build.Select(build.Var{
+		Dev:      uint64(4),
+		Standard: uint64(16),
+		Testing:  uint64(2),
+	}).(uint64)
+
+	// tierMaxMoves is the maximum number of sectors which are promoted to the
+	// fast tier during a single rebalance.
+	tierMaxMoves = build.Select(build.Var{
+		Dev:      256,
+		Standard: 4096,
+		Testing:  16,
+	}).(int)
 )
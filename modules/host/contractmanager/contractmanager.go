@@ -93,6 +93,14 @@ type ContractManager struct {
 	// or modified.
 	lockedSectors map[sectorID]*sectorLock
 
+	// staticScrubber keeps track of the periodic verification of all
+	// sectors.
+	staticScrubber *scrubber
+
+	// staticTiering counts the reads of the sectors to find the sectors which
+	// should be stored in the fast storage folders.
+	staticTiering *sectorTiering
+
 	// Utilities.
 	dependencies  modules.Dependencies
 	staticAlerter *modules.GenericAlerter
@@ -116,6 +124,8 @@ func newContractManager(dependencies modules.Dependencies, persistDir string) (_
 
 		lockedSectors: make(map[sectorID]*sectorLock),
 
+		staticTiering: newSectorTiering(),
+
 		dependencies: dependencies,
 		persistDir:   persistDir,
 
@@ -212,6 +222,14 @@ func newContractManager(dependencies modules.Dependencies, persistDir string) (_
 		cm.loadSectorLocations(sf)
 	}
 
+	// Load the settings and the findings of the scrubber.
+	cm.staticScrubber, err = newScrubber(cm.persistDir)
+	if err != nil {
+		cm.log.Println("ERROR: Unable to load the scrubber:", err)
+		return nil, errors.AddContext(err, "error while loading the scrubber")
+	}
+	cm.updateScrubAlert(cm.staticScrubber.counts())
+
 	// Launch the sync loop that periodically flushes changes from the WAL to
 	// disk.
 	err = cm.wal.spawnSyncLoop()
@@ -224,6 +242,13 @@ func newContractManager(dependencies modules.Dependencies, persistDir string) (_
 	// and adds them if they are discovered.
 	go cm.threadedFolderRecheck()
 
+	// Spin up the thread that periodically scrubs the stored sectors.
+	go cm.threadedScrub()
+
+	// Spin up the thread that periodically moves sectors between the storage
+	// tiers.
+	go cm.threadedRebalanceTiers()
+
 	// Simulate an error to make sure the cleanup code is triggered correctly.
 	if cm.dependencies.Disrupt("erroredStartup") {
 		err = errors.New("startup disrupted")
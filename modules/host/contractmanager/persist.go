@@ -11,6 +11,7 @@ import (
 
 	"github.com/turtledex/TurtleDexCore/build"
 	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
 	"github.com/turtledex/TurtleDexCore/persist"
 )
 
@@ -20,6 +21,7 @@ type (
 	savedStorageFolder struct {
 		Index uint16
 		Path  string
+		Tier  modules.StorageTier
 		Usage []uint64
 	}
 
@@ -36,6 +38,7 @@ func (sf *storageFolder) savedStorageFolder() savedStorageFolder {
 	ssf := savedStorageFolder{
 		Index: sf.index,
 		Path:  sf.path,
+		Tier:  sf.tier,
 		Usage: make([]uint64, len(sf.usage)),
 	}
 	copy(ssf.Usage, sf.usage)
@@ -77,6 +80,7 @@ func (cm *ContractManager) loadSettings() error {
 		sf := new(storageFolder)
 		sf.index = ss.StorageFolders[i].Index
 		sf.path = ss.StorageFolders[i].Path
+		sf.tier = ss.StorageFolders[i].storageTier()
 		sf.usage = ss.StorageFolders[i].Usage
 		sf.metadataFile, err = cm.dependencies.OpenFile(filepath.Join(ss.StorageFolders[i].Path, metadataFile), os.O_RDWR, 0700)
 		if err != nil {
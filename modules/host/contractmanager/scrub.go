@@ -0,0 +1,462 @@
+package contractmanager
+
+// The scrubber periodically reads every sector stored by the contract manager,
+// recomputes its Merkle root and compares it to the id of the sector in the
+// sector location metadata. Sectors which can't be read are recorded as
+// missing, sectors whose data doesn't match their id as corrupt. Every sector
+// is locked while it is verified and the reads are throttled to leave enough
+// I/O for the renters.
+//
+// The findings are persisted together with the settings of the scrubber. A
+// finding is cleared once its sector passes verification again or is removed
+// from the contract manager.
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/persist"
+)
+
+var (
+	// errInvalidScrubSettings is returned if the settings of the scrubber are
+	// invalid.
+	errInvalidScrubSettings = errors.New("scrub interval needs to be positive if scrubbing is enabled")
+
+	// errScrubInProgress is returned if a scrub is started while another one
+	// is in progress.
+	errScrubInProgress = errors.New("a scrub is already in progress")
+)
+
+type (
+	// scrubFinding is a sector which failed verification.
+	scrubFinding struct {
+		ID            sectorID  `json:"id"`
+		StorageFolder uint16    `json:"storagefolder"`
+		SectorIndex   uint32    `json:"sectorindex"`
+		DetectedAt    time.Time `json:"detectedat"`
+		Missing       bool      `json:"missing"`
+	}
+
+	// scrubPersist is the persisted state of the scrubber.
+	scrubPersist struct {
+		Settings   modules.StorageScrubSettings `json:"settings"`
+		LastStart  time.Time                    `json:"laststart"`
+		LastFinish time.Time                    `json:"lastfinish"`
+		Findings   []scrubFinding               `json:"findings"`
+	}
+
+	// scrubber keeps track of the settings, the progress and the findings of
+	// the scrubs.
+	scrubber struct {
+		findings   map[sectorID]scrubFinding
+		lastFinish time.Time
+		lastStart  time.Time
+		running    bool
+		scrubbed   uint64
+		settings   modules.StorageScrubSettings
+		total      uint64
+
+		// startChan is used to start a scrub immediately.
+		startChan chan struct{}
+
+		staticPath string
+		mu         sync.Mutex
+	}
+)
+
+// newScrubber loads the state of the scrubber from disk or creates a new
+// scrubber with the default settings.
+func newScrubber(persistDir string) (*scrubber, error) {
+	s := &scrubber{
+		findings: make(map[sectorID]scrubFinding),
+		settings: modules.StorageScrubSettings{
+			Enabled:           true,
+			Interval:          modules.DefaultStorageScrubInterval,
+			MaxBytesPerSecond: modules.DefaultStorageScrubMaxBytesPerSecond,
+		},
+		startChan:  make(chan struct{}, 1),
+		staticPath: filepath.Join(persistDir, scrubFile),
+	}
+	// The first periodic scrub of a new scrubber starts one interval after it
+	// was created.
+	sp := scrubPersist{
+		Settings:  s.settings,
+		LastStart: time.Now(),
+	}
+	err := persist.LoadJSON(scrubMetadata, &sp, s.staticPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.AddContext(err, "failed to load scrub state")
+	}
+	s.settings = sp.Settings
+	s.lastStart = sp.LastStart
+	s.lastFinish = sp.LastFinish
+	for _, f := range sp.Findings {
+		s.findings[f.ID] = f
+	}
+	if os.IsNotExist(err) {
+		if err := s.save(); err != nil {
+			return nil, errors.AddContext(err, "failed to save scrub state")
+		}
+	}
+	return s, nil
+}
+
+// save persists the state of the scrubber.
+func (s *scrubber) save() error {
+	sp := scrubPersist{
+		Settings:   s.settings,
+		LastStart:  s.lastStart,
+		LastFinish: s.lastFinish,
+		Findings:   make([]scrubFinding, 0, len(s.findings)),
+	}
+	for _, f := range s.findings {
+		sp.Findings = append(sp.Findings, f)
+	}
+	return persist.SaveJSON(scrubMetadata, sp, s.staticPath)
+}
+
+// counts returns the number of corrupt and missing sectors.
+func (s *scrubber) counts() (corrupt, missing uint64) {
+	for _, f := range s.findings {
+		if f.Missing {
+			missing++
+		} else {
+			corrupt++
+		}
+	}
+	return corrupt, missing
+}
+
+// managedDue returns whether a periodic scrub is due.
+func (s *scrubber) managedDue() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.settings.Enabled && time.Since(s.lastStart) >= s.settings.Interval
+}
+
+// managedStart marks the start of a scrub of the given number of sectors. It
+// returns false if a scrub is already in progress.
+func (s *scrubber) managedStart(total uint64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return false
+	}
+	s.running = true
+	s.scrubbed = 0
+	s.total = total
+	s.lastStart = time.Now()
+	return true
+}
+
+// managedRecord records the outcome of verifying a sector. A nil finding
+// means that the sector passed verification.
+func (s *scrubber) managedRecord(id sectorID, finding *scrubFinding) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scrubbed++
+	if finding == nil {
+		delete(s.findings, id)
+		return
+	}
+	// Keep the time the sector was first found to be bad.
+	if old, exists := s.findings[id]; exists {
+		finding.DetectedAt = old.DetectedAt
+	}
+	s.findings[id] = *finding
+}
+
+// managedMaxBytesPerSecond returns the current I/O limit of the scrubber.
+func (s *scrubber) managedMaxBytesPerSecond() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.settings.MaxBytesPerSecond
+}
+
+// managedFindingIDs returns the ids of all sectors with findings.
+func (s *scrubber) managedFindingIDs() []sectorID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]sectorID, 0, len(s.findings))
+	for id := range s.findings {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// managedFinish marks the end of a scrub and removes the findings of the
+// sectors which no longer exist. It returns the number of corrupt and missing
+// sectors.
+func (s *scrubber) managedFinish(removed []sectorID, completed bool) (uint64, uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = false
+	for _, id := range removed {
+		delete(s.findings, id)
+	}
+	if completed {
+		s.lastFinish = time.Now()
+	}
+	corrupt, missing := s.counts()
+	return corrupt, missing, s.save()
+}
+
+// managedSetSettings validates and persists the settings.
+func (s *scrubber) managedSetSettings(settings modules.StorageScrubSettings) error {
+	if settings.Enabled && settings.Interval <= 0 {
+		return errInvalidScrubSettings
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings = settings
+	return s.save()
+}
+
+// managedStatus returns the progress and the findings of the scrubber. The
+// paths of the storage folders are used to describe the findings.
+func (s *scrubber) managedStatus(paths map[uint16]string) modules.StorageScrubStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	corrupt, missing := s.counts()
+	status := modules.StorageScrubStatus{
+		StorageScrubSettings: s.settings,
+
+		Running:         s.running,
+		SectorsScrubbed: s.scrubbed,
+		TotalSectors:    s.total,
+
+		LastStart:  s.lastStart,
+		LastFinish: s.lastFinish,
+
+		CorruptSectors: corrupt,
+		MissingSectors: missing,
+		Findings:       make([]modules.StorageScrubFinding, 0, len(s.findings)),
+	}
+	for _, f := range s.findings {
+		status.Findings = append(status.Findings, modules.StorageScrubFinding{
+			Path:          paths[f.StorageFolder],
+			StorageFolder: f.StorageFolder,
+			SectorIndex:   f.SectorIndex,
+			DetectedAt:    f.DetectedAt,
+			Missing:       f.Missing,
+		})
+	}
+	sort.Slice(status.Findings, func(i, j int) bool {
+		fi, fj := status.Findings[i], status.Findings[j]
+		if fi.StorageFolder != fj.StorageFolder {
+			return fi.StorageFolder < fj.StorageFolder
+		}
+		return fi.SectorIndex < fj.SectorIndex
+	})
+	return status
+}
+
+// updateScrubAlert registers or unregisters the corrupt sectors alert
+// depending on the findings of the scrubber.
+func (cm *ContractManager) updateScrubAlert(corrupt, missing uint64) {
+	if corrupt == 0 && missing == 0 {
+		cm.staticAlerter.UnregisterAlert(modules.AlertIDHostCorruptSectors)
+		return
+	}
+	cause := fmt.Sprintf("%v corrupt and %v missing sectors", corrupt, missing)
+	cm.staticAlerter.RegisterAlert(modules.AlertIDHostCorruptSectors, AlertMSGHostCorruptSectors, cause, modules.SeverityError)
+}
+
+// managedScrubSector verifies a single sector. It returns the finding if the
+// sector failed verification and false if the sector no longer exists.
+func (cm *ContractManager) managedScrubSector(id sectorID) (*scrubFinding, bool) {
+	cm.wal.managedLockSector(id)
+	defer cm.wal.managedUnlockSector(id)
+
+	// Fetch the sector metadata.
+	cm.wal.mu.Lock()
+	sl, exists1 := cm.sectorLocations[id]
+	sf, exists2 := cm.storageFolders[sl.storageFolder]
+	cm.wal.mu.Unlock()
+	if !exists1 {
+		return nil, false
+	}
+	finding := &scrubFinding{
+		ID:            id,
+		StorageFolder: sl.storageFolder,
+		SectorIndex:   sl.index,
+		DetectedAt:    time.Now(),
+	}
+	if !exists2 || atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
+		finding.Missing = true
+		return finding, true
+	}
+
+	// Read the sector and compare its root to the id.
+	sectorData, err := readSector(sf.sectorFile, sl.index)
+	if err != nil {
+		atomic.AddUint64(&sf.atomicFailedReads, 1)
+		finding.Missing = true
+		return finding, true
+	}
+	atomic.AddUint64(&sf.atomicSuccessfulReads, 1)
+	if cm.managedSectorID(crypto.MerkleRoot(sectorData)) != id {
+		return finding, true
+	}
+	return nil, true
+}
+
+// managedScrub verifies all sectors of the contract manager.
+func (cm *ContractManager) managedScrub() {
+	err := cm.tg.Add()
+	if err != nil {
+		return
+	}
+	defer cm.tg.Done()
+
+	// Get the ids of all sectors. Sectors which are added during the scrub
+	// will be verified by the next one.
+	cm.wal.mu.Lock()
+	ids := make([]sectorID, 0, len(cm.sectorLocations))
+	for id := range cm.sectorLocations {
+		ids = append(ids, id)
+	}
+	cm.wal.mu.Unlock()
+
+	s := cm.staticScrubber
+	if !s.managedStart(uint64(len(ids))) {
+		return
+	}
+	cm.log.Printf("Starting scrub of %v sectors", len(ids))
+
+	completed := true
+	next := time.Now()
+	for _, id := range ids {
+		// Throttle the reads.
+		select {
+		case <-cm.tg.StopChan():
+			completed = false
+		case <-time.After(time.Until(next)):
+		}
+		if !completed {
+			break
+		}
+		finding, exists := cm.managedScrubSector(id)
+		if !exists {
+			continue
+		}
+		s.managedRecord(id, finding)
+		if finding != nil {
+			cm.log.Printf("WARN: scrub found bad sector %v in storage folder %v, missing: %v", finding.SectorIndex, finding.StorageFolder, finding.Missing)
+		}
+		if maxBPS := s.managedMaxBytesPerSecond(); maxBPS > 0 {
+			next = time.Now().Add(time.Duration(float64(modules.SectorSize) / float64(maxBPS) * float64(time.Second)))
+		}
+	}
+
+	// Remove the findings of sectors which were removed in the meantime.
+	var removed []sectorID
+	findingIDs := s.managedFindingIDs()
+	cm.wal.mu.Lock()
+	for _, id := range findingIDs {
+		if _, exists := cm.sectorLocations[id]; !exists {
+			removed = append(removed, id)
+		}
+	}
+	cm.wal.mu.Unlock()
+
+	corrupt, missing, err := s.managedFinish(removed, completed)
+	if err != nil {
+		cm.log.Println("ERROR: unable to save scrub state:", err)
+	}
+	cm.updateScrubAlert(corrupt, missing)
+	if completed {
+		cm.log.Printf("Finished scrub, %v corrupt and %v missing sectors", corrupt, missing)
+	}
+}
+
+// threadedScrub periodically scrubs the storage of the contract manager.
+func (cm *ContractManager) threadedScrub() {
+	// Don't spawn the loop if 'noScrub' disruption is set.
+	if cm.dependencies.Disrupt("noScrub") {
+		return
+	}
+
+	for {
+		select {
+		case <-cm.tg.StopChan():
+			return
+		case <-cm.staticScrubber.startChan:
+		case <-time.After(scrubCheckInterval):
+			if !cm.staticScrubber.managedDue() {
+				continue
+			}
+		}
+		cm.managedScrub()
+	}
+}
+
+// CorruptSectors returns the sectors among the provided ones which failed
+// verification during a scrub.
+func (cm *ContractManager) CorruptSectors(sectorRoots []crypto.Hash) []crypto.Hash {
+	s := cm.staticScrubber
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.findings) == 0 {
+		return nil
+	}
+	var corrupt []crypto.Hash
+	for _, root := range sectorRoots {
+		if _, exists := s.findings[cm.managedSectorID(root)]; exists {
+			corrupt = append(corrupt, root)
+		}
+	}
+	return corrupt
+}
+
+// ScrubStatus returns the progress and the findings of the scrubber.
+func (cm *ContractManager) ScrubStatus() modules.StorageScrubStatus {
+	cm.wal.mu.Lock()
+	paths := make(map[uint16]string, len(cm.storageFolders))
+	for index, sf := range cm.storageFolders {
+		paths[index] = sf.path
+	}
+	cm.wal.mu.Unlock()
+	return cm.staticScrubber.managedStatus(paths)
+}
+
+// SetScrubSettings configures the scrubber.
+func (cm *ContractManager) SetScrubSettings(settings modules.StorageScrubSettings) error {
+	err := cm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cm.tg.Done()
+	return cm.staticScrubber.managedSetSettings(settings)
+}
+
+// StartScrub starts a scrub immediately unless one is already in progress.
+func (cm *ContractManager) StartScrub() error {
+	err := cm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cm.tg.Done()
+	s := cm.staticScrubber
+	s.mu.Lock()
+	running := s.running
+	s.mu.Unlock()
+	if running {
+		return errScrubInProgress
+	}
+	select {
+	case s.startChan <- struct{}{}:
+	default:
+	}
+	return nil
+}
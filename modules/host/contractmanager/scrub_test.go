@@ -0,0 +1,175 @@
+package contractmanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/turtledex/errors"
+	"github.com/turtledex/fastrand"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+)
+
+// TestScrub checks that a scrub detects corrupt sectors and that the findings
+// are persisted.
+func TestScrub(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	// Add a storage folder to the contract manager tester.
+	storageFolderDir := filepath.Join(cmt.persistDir, "storageFolderOne")
+	err = os.MkdirAll(storageFolderDir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderDir, modules.SectorSize*64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add a few sectors.
+	var roots []crypto.Hash
+	for i := 0; i < 3; i++ {
+		root, data := randSector()
+		err = cmt.cm.AddSector(root, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+	}
+
+	// Disable the I/O limit to speed up the test.
+	err = cmt.cm.SetScrubSettings(modules.StorageScrubSettings{
+		Enabled:  true,
+		Interval: time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// scrub starts a scrub and waits for it to finish.
+	scrub := func() modules.StorageScrubStatus {
+		lastFinish := cmt.cm.ScrubStatus().LastFinish
+		err := cmt.cm.StartScrub()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var status modules.StorageScrubStatus
+		err = build.Retry(100, 100*time.Millisecond, func() error {
+			status = cmt.cm.ScrubStatus()
+			if status.Running || status.LastFinish.Equal(lastFinish) {
+				return errors.New("scrub hasn't finished")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return status
+	}
+
+	// A scrub of intact sectors doesn't find anything.
+	status := scrub()
+	if status.TotalSectors != 3 || status.SectorsScrubbed != 3 {
+		t.Fatal("unexpected progress", status.SectorsScrubbed, status.TotalSectors)
+	}
+	if len(status.Findings) != 0 || len(cmt.cm.CorruptSectors(roots)) != 0 {
+		t.Fatal("scrub shouldn't find anything", status.Findings)
+	}
+
+	// Corrupt the second sector on disk.
+	cmt.cm.wal.mu.Lock()
+	sl := cmt.cm.sectorLocations[cmt.cm.managedSectorID(roots[1])]
+	sf := cmt.cm.storageFolders[sl.storageFolder]
+	_, err = sf.sectorFile.WriteAt(fastrand.Bytes(64), int64(sl.index)*int64(modules.SectorSize))
+	cmt.cm.wal.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The scrub should find the corrupt sector.
+	status = scrub()
+	if status.CorruptSectors != 1 || status.MissingSectors != 0 || len(status.Findings) != 1 {
+		t.Fatal("expected one corrupt sector", status.CorruptSectors, status.MissingSectors, status.Findings)
+	}
+	finding := status.Findings[0]
+	if finding.Path != storageFolderDir || finding.SectorIndex != sl.index || finding.Missing {
+		t.Fatal("unexpected finding", finding)
+	}
+	corrupt := cmt.cm.CorruptSectors(roots)
+	if len(corrupt) != 1 || corrupt[0] != roots[1] {
+		t.Fatal("expected the second sector to be corrupt", corrupt)
+	}
+
+	// The findings are persisted.
+	s, err := newScrubber(cmt.cm.persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if corrupt, missing := s.counts(); corrupt != 1 || missing != 0 {
+		t.Fatal("findings weren't persisted", corrupt, missing)
+	}
+
+	// Once the sector is removed, the finding is cleared by the next scrub.
+	err = cmt.cm.RemoveSector(roots[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	status = scrub()
+	if len(status.Findings) != 0 || len(cmt.cm.CorruptSectors(roots)) != 0 {
+		t.Fatal("finding should have been cleared", status.Findings)
+	}
+}
+
+// TestScrubSettings checks the validation of the scrub settings.
+func TestScrubSettings(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	// New contract managers scrub with the default settings.
+	status := cmt.cm.ScrubStatus()
+	if !status.Enabled || status.Interval != modules.DefaultStorageScrubInterval || status.MaxBytesPerSecond != modules.DefaultStorageScrubMaxBytesPerSecond {
+		t.Fatal("unexpected default settings", status.StorageScrubSettings)
+	}
+
+	// The interval needs to be positive if scrubbing is enabled.
+	err = cmt.cm.SetScrubSettings(modules.StorageScrubSettings{Enabled: true})
+	if !errors.Contains(err, errInvalidScrubSettings) {
+		t.Fatal("expected errInvalidScrubSettings", err)
+	}
+	err = cmt.cm.SetScrubSettings(modules.StorageScrubSettings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The settings are persisted.
+	err = cmt.cm.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmt.cm, err = New(cmt.cm.persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := cmt.cm.ScrubStatus(); status.Enabled {
+		t.Fatal("settings weren't persisted", status.StorageScrubSettings)
+	}
+}
@@ -94,9 +94,10 @@ type storageFolder struct {
 	// an error if it is queried.
 	atomicUnavailable uint64 // uint64 for alignment
 
-	// The index, path, and usage are all saved directly to disk.
+	// The index, path, tier and usage are all saved directly to disk.
 	index uint16
 	path  string
+	tier  modules.StorageTier
 	usage []uint64
 
 	// availableSectors indicates sectors which are marked as consumed in the
@@ -409,9 +410,18 @@ func (cm *ContractManager) StorageFolders() []modules.StorageFolderMetadata {
 		return nil
 	}
 	defer cm.tg.Done()
+	hotIDs := cm.staticTiering.managedHotSectors()
 	cm.wal.mu.Lock()
 	defer cm.wal.mu.Unlock()
 
+	// Count the hot sectors of each storage folder.
+	hotSectors := make(map[uint16]uint64)
+	for _, id := range hotIDs {
+		if sl, exists := cm.sectorLocations[id]; exists {
+			hotSectors[sl.storageFolder]++
+		}
+	}
+
 	// Iterate over the storage folders that are in memory first, and then
 	// suppliment them with the storage folders that are not in memory.
 	var smfs []modules.StorageFolderMetadata
@@ -430,6 +440,8 @@ func (cm *ContractManager) StorageFolders() []modules.StorageFolderMetadata {
 			CapacityRemaining: ((64 * uint64(len(sf.usage))) - sf.sectors) * modules.SectorSize,
 			Index:             sf.index,
 			Path:              sf.path,
+			Tier:              sf.tier,
+			HotSectors:        hotSectors[sf.index],
 		}
 
 		// Set some of the values to extreme numbers if the storage folder is
@@ -14,11 +14,23 @@ var (
 	// out the sectors in a storage folder if errors prevented one or more of
 	// the sectors from being properly migrated to a new storage folder.
 	ErrPartialRelocation = errors.New("unable to migrate all sectors")
+
+	// errNoVacantStorageFolder is returned when moving a sector fails because
+	// none of the accepted storage folders has room for it. It uses the same
+	// message as the out of storage error of the RPCs.
+	errNoVacantStorageFolder = errors.New(modules.V1420HostOutOfStorageErrString)
 )
 
 // managedMoveSector will move a sector from its current storage folder to
 // another.
 func (wal *writeAheadLog) managedMoveSector(id sectorID) error {
+	return wal.managedMoveSectorTo(id, nil)
+}
+
+// managedMoveSectorTo will move a sector from its current storage folder to
+// another one which is accepted by the provided function. A nil function
+// accepts any storage folder. The function is called while the WAL is locked.
+func (wal *writeAheadLog) managedMoveSectorTo(id sectorID, accept func(*storageFolder) bool) error {
 	wal.managedLockSector(id)
 	defer wal.managedUnlockSector(id)
 
@@ -51,6 +63,15 @@ func (wal *writeAheadLog) managedMoveSector(id sectorID) error {
 	// Place the sector into its new folder and add the atomic move to the WAL.
 	wal.mu.Lock()
 	storageFolders := wal.cm.availableStorageFolders()
+	if accept != nil {
+		accepted := storageFolders[:0]
+		for _, sf := range storageFolders {
+			if accept(sf) {
+				accepted = append(accepted, sf)
+			}
+		}
+		storageFolders = accepted
+	}
 	wal.mu.Unlock()
 	for len(storageFolders) >= 1 {
 		var storageFolderIndex int
@@ -68,7 +89,7 @@ func (wal *writeAheadLog) managedMoveSector(id sectorID) error {
 				// None of the storage folders have enough room to house the
 				// sector.
 				wal.mu.Unlock()
-				return errors.New(modules.V1420HostOutOfStorageErrString)
+				return errNoVacantStorageFolder
 			}
 			defer sf.mu.RUnlock()
 
@@ -137,7 +158,7 @@ func (wal *writeAheadLog) managedMoveSector(id sectorID) error {
 			wal.mu.Unlock()
 			return nil
 		}()
-		if err != nil && err.Error() == modules.V1420HostOutOfStorageErrString {
+		if errors.Contains(err, errNoVacantStorageFolder) {
 			return err
 		} else if err != nil {
 			// Try the next storage folder.
@@ -148,7 +169,7 @@ func (wal *writeAheadLog) managedMoveSector(id sectorID) error {
 		break
 	}
 	if len(storageFolders) < 1 {
-		return errors.New(modules.V1420HostOutOfStorageErrString)
+		return errNoVacantStorageFolder
 	}
 	return nil
 }
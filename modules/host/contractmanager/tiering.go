@@ -0,0 +1,271 @@
+package contractmanager
+
+// Storage folders belong to either the fast tier, e.g. folders on SSDs, or the
+// slow tier, e.g. folders on HDDs. New sectors are placed in any storage folder
+// with vacancy. The contract manager counts the reads of every sector and
+// periodically promotes the most read sectors of the slow folders to the fast
+// ones. If the fast folders are full, their coldest sectors are demoted to the
+// slow folders to make room. Sectors are moved with the same WAL machinery
+// that is used to empty storage folders.
+//
+// The read counts are only kept in memory and are halved after every
+// rebalance, so that sectors which are no longer read cool down over time.
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+)
+
+var (
+	// errInvalidStorageTier is returned if a storage folder is assigned to an
+	// unknown tier.
+	errInvalidStorageTier = errors.New("storage tier needs to be either 'fast' or 'slow'")
+)
+
+// sectorTiering counts the reads of the sectors since the last rebalance.
+type sectorTiering struct {
+	reads map[sectorID]uint64
+	mu    sync.Mutex
+}
+
+// newSectorTiering creates a new sectorTiering object.
+func newSectorTiering() *sectorTiering {
+	return &sectorTiering{
+		reads: make(map[sectorID]uint64),
+	}
+}
+
+// storageTier returns the tier of a saved storage folder. Folders which were
+// saved before tiers were introduced belong to the slow tier.
+func (ssf savedStorageFolder) storageTier() modules.StorageTier {
+	if ssf.Tier == "" {
+		return modules.StorageTierSlow
+	}
+	return ssf.Tier
+}
+
+// managedRecordRead counts a read of the sector with the given id.
+func (st *sectorTiering) managedRecordRead(id sectorID) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.reads[id]++
+}
+
+// managedReads returns a copy of the read counts.
+func (st *sectorTiering) managedReads() map[sectorID]uint64 {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	reads := make(map[sectorID]uint64, len(st.reads))
+	for id, n := range st.reads {
+		reads[id] = n
+	}
+	return reads
+}
+
+// managedHotSectors returns the ids of the sectors which reached the hot
+// threshold.
+func (st *sectorTiering) managedHotSectors() []sectorID {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	var ids []sectorID
+	for id, n := range st.reads {
+		if n >= tierHotThreshold {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// managedDecay halves the read counts and forgets about the sectors which
+// haven't been read in a while.
+func (st *sectorTiering) managedDecay() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for id, n := range st.reads {
+		if n/2 == 0 {
+			delete(st.reads, id)
+			continue
+		}
+		st.reads[id] = n / 2
+	}
+}
+
+// hasTiers returns whether there are available storage folders in both the
+// fast and the slow tier.
+func (cm *ContractManager) hasTiers() bool {
+	var fast, slow bool
+	for _, sf := range cm.availableStorageFolders() {
+		fast = fast || sf.tier == modules.StorageTierFast
+		slow = slow || sf.tier == modules.StorageTierSlow
+	}
+	return fast && slow
+}
+
+// tierCandidates returns the hot sectors of the slow tier, sorted from the
+// most to the least read one.
+func (cm *ContractManager) tierCandidates(reads map[sectorID]uint64) []sectorID {
+	var hot []sectorID
+	for id, n := range reads {
+		if n < tierHotThreshold {
+			continue
+		}
+		sl, exists := cm.sectorLocations[id]
+		if !exists {
+			continue
+		}
+		sf, exists := cm.storageFolders[sl.storageFolder]
+		if exists && sf.tier == modules.StorageTierSlow {
+			hot = append(hot, id)
+		}
+	}
+	sort.Slice(hot, func(i, j int) bool {
+		return reads[hot[i]] > reads[hot[j]]
+	})
+	if len(hot) > tierMaxMoves {
+		hot = hot[:tierMaxMoves]
+	}
+	return hot
+}
+
+// coldSectors returns up to n sectors of the fast tier which aren't hot,
+// sorted from the least to the most read one.
+func (cm *ContractManager) coldSectors(reads map[sectorID]uint64, n int) []sectorID {
+	var cold []sectorID
+	for id, sl := range cm.sectorLocations {
+		if reads[id] >= tierHotThreshold {
+			continue
+		}
+		sf, exists := cm.storageFolders[sl.storageFolder]
+		if exists && sf.tier == modules.StorageTierFast {
+			cold = append(cold, id)
+		}
+	}
+	sort.Slice(cold, func(i, j int) bool {
+		return reads[cold[i]] < reads[cold[j]]
+	})
+	if len(cold) > n {
+		cold = cold[:n]
+	}
+	return cold
+}
+
+// managedRebalanceTiers promotes the hot sectors of the slow tier to the fast
+// tier, demoting cold sectors of the fast tier if it is full. It returns the
+// number of promoted and demoted sectors.
+func (cm *ContractManager) managedRebalanceTiers() (promoted, demoted int) {
+	err := cm.tg.Add()
+	if err != nil {
+		return 0, 0
+	}
+	defer cm.tg.Done()
+
+	reads := cm.staticTiering.managedReads()
+	cm.wal.mu.Lock()
+	if !cm.hasTiers() {
+		cm.wal.mu.Unlock()
+		return 0, 0
+	}
+	hot := cm.tierCandidates(reads)
+	cm.wal.mu.Unlock()
+
+	toTier := func(tier modules.StorageTier) func(*storageFolder) bool {
+		return func(sf *storageFolder) bool {
+			return sf.tier == tier
+		}
+	}
+	var cold []sectorID
+	coldLoaded := false
+	for _, id := range hot {
+		select {
+		case <-cm.tg.StopChan():
+			return promoted, demoted
+		default:
+		}
+
+		err := cm.wal.managedMoveSectorTo(id, toTier(modules.StorageTierFast))
+		if errors.Contains(err, errNoVacantStorageFolder) {
+			// Make room by demoting the coldest sector of the fast tier.
+			if !coldLoaded {
+				cm.wal.mu.Lock()
+				cold = cm.coldSectors(reads, len(hot))
+				cm.wal.mu.Unlock()
+				coldLoaded = true
+			}
+			if len(cold) == 0 {
+				break
+			}
+			err = cm.wal.managedMoveSectorTo(cold[0], toTier(modules.StorageTierSlow))
+			cold = cold[1:]
+			if err != nil {
+				cm.log.Println("WARN: unable to demote sector to the slow tier:", err)
+				break
+			}
+			demoted++
+			err = cm.wal.managedMoveSectorTo(id, toTier(modules.StorageTierFast))
+		}
+		if err != nil {
+			cm.log.Println("WARN: unable to promote sector to the fast tier:", err)
+			continue
+		}
+		promoted++
+	}
+	return promoted, demoted
+}
+
+// threadedRebalanceTiers periodically moves sectors between the storage tiers
+// and decays the read counts.
+func (cm *ContractManager) threadedRebalanceTiers() {
+	// Don't spawn the loop if 'noTiering' disruption is set.
+	if cm.dependencies.Disrupt("noTiering") {
+		return
+	}
+
+	for {
+		select {
+		case <-cm.tg.StopChan():
+			return
+		case <-time.After(tierRebalanceInterval):
+		}
+		promoted, demoted := cm.managedRebalanceTiers()
+		if promoted > 0 || demoted > 0 {
+			cm.log.Printf("Promoted %v sectors to the fast tier and demoted %v sectors to the slow tier", promoted, demoted)
+		}
+		cm.staticTiering.managedDecay()
+	}
+}
+
+// SetStorageFolderTier sets the tier of a storage folder.
+func (cm *ContractManager) SetStorageFolderTier(index uint16, tier modules.StorageTier) error {
+	err := cm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cm.tg.Done()
+	if tier != modules.StorageTierFast && tier != modules.StorageTierSlow {
+		return errInvalidStorageTier
+	}
+
+	cm.wal.mu.Lock()
+	sf, exists := cm.storageFolders[index]
+	if !exists {
+		cm.wal.mu.Unlock()
+		return errStorageFolderNotFound
+	}
+	sf.tier = tier
+	syncChan := cm.wal.syncChan
+	cm.wal.mu.Unlock()
+
+	// Wait until the tier has been saved to disk. The settings file is
+	// written during one sync and atomically renamed during the next one.
+	<-syncChan
+	cm.wal.mu.Lock()
+	syncChan = cm.wal.syncChan
+	cm.wal.mu.Unlock()
+	<-syncChan
+	return nil
+}
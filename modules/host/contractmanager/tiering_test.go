@@ -0,0 +1,183 @@
+package contractmanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+)
+
+// dependencyNoTiering is a dependency that prevents the contract manager from
+// rebalancing the storage tiers in the background.
+type dependencyNoTiering struct {
+	modules.ProductionDependencies
+}
+
+// Disrupt prevents the tiering loop from running in the contract manager.
+func (*dependencyNoTiering) Disrupt(s string) bool {
+	return s == "noTiering"
+}
+
+// TestSectorTieringDecay checks that the read counts are halved and that cold
+// sectors are forgotten.
+func TestSectorTieringDecay(t *testing.T) {
+	t.Parallel()
+
+	st := newSectorTiering()
+	var hot, cold sectorID
+	hot[0], cold[0] = 1, 2
+	for i := uint64(0); i < 2*tierHotThreshold; i++ {
+		st.managedRecordRead(hot)
+	}
+	st.managedRecordRead(cold)
+	if ids := st.managedHotSectors(); len(ids) != 1 || ids[0] != hot {
+		t.Fatal("expected one hot sector", ids)
+	}
+
+	st.managedDecay()
+	reads := st.managedReads()
+	if len(reads) != 1 || reads[hot] != tierHotThreshold {
+		t.Fatal("unexpected read counts after decay", reads)
+	}
+	st.managedDecay()
+	if len(st.managedHotSectors()) != 0 {
+		t.Fatal("sector should have cooled down")
+	}
+}
+
+// TestTiering checks that hot sectors are promoted to the fast tier and that
+// cold sectors are demoted to make room for them.
+func TestTiering(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	d := new(dependencyNoTiering)
+	cmt, err := newMockedContractManagerTester(d, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	// addFolder adds a storage folder with the minimum size and returns its
+	// index.
+	addFolder := func(name string) uint16 {
+		dir := filepath.Join(cmt.persistDir, name)
+		err := os.MkdirAll(dir, 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = cmt.cm.AddStorageFolder(dir, modules.SectorSize*MinimumSectorsPerStorageFolder)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, sf := range cmt.cm.StorageFolders() {
+			if sf.Path == dir {
+				return sf.Index
+			}
+		}
+		t.Fatal("storage folder not found")
+		return 0
+	}
+	// folderOf returns the index of the storage folder containing a sector.
+	folderOf := func(root crypto.Hash) uint16 {
+		cmt.cm.wal.mu.Lock()
+		defer cmt.cm.wal.mu.Unlock()
+		return cmt.cm.sectorLocations[cmt.cm.managedSectorID(root)].storageFolder
+	}
+
+	// Fill a fast storage folder.
+	fast := addFolder("fast")
+	err = cmt.cm.SetStorageFolderTier(fast, modules.StorageTierFast)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := uint64(0); i < MinimumSectorsPerStorageFolder; i++ {
+		root, data := randSector()
+		err = cmt.cm.AddSector(root, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Add a slow storage folder. New sectors end up in the slow folder since
+	// the fast one is full.
+	slow := addFolder("slow")
+	root, data := randSector()
+	err = cmt.cm.AddSector(root, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if folderOf(root) != slow {
+		t.Fatal("sector should be stored in the slow folder")
+	}
+
+	// Without reads, nothing is moved.
+	if promoted, demoted := cmt.cm.managedRebalanceTiers(); promoted != 0 || demoted != 0 {
+		t.Fatal("no sectors should have been moved", promoted, demoted)
+	}
+
+	// Read the sector until it is hot.
+	for i := uint64(0); i < tierHotThreshold; i++ {
+		readData, err := cmt.cm.ReadSector(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if crypto.MerkleRoot(readData) != root {
+			t.Fatal("read returned the wrong data")
+		}
+	}
+	for _, sf := range cmt.cm.StorageFolders() {
+		if (sf.Index == slow) != (sf.HotSectors == 1) {
+			t.Fatal("the slow folder should store the hot sector", sf.Index, sf.HotSectors)
+		}
+	}
+
+	// The hot sector is promoted and a cold one demoted to make room.
+	if promoted, demoted := cmt.cm.managedRebalanceTiers(); promoted != 1 || demoted != 1 {
+		t.Fatal("expected one sector to be promoted and one to be demoted", promoted, demoted)
+	}
+	if folderOf(root) != fast {
+		t.Fatal("sector should have been promoted to the fast folder")
+	}
+	readData, err := cmt.cm.ReadSector(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crypto.MerkleRoot(readData) != root {
+		t.Fatal("promoted sector returned the wrong data")
+	}
+	for _, sf := range cmt.cm.StorageFolders() {
+		if sf.Index == fast && (sf.Tier != modules.StorageTierFast || sf.HotSectors != 1) {
+			t.Fatal("unexpected fast folder", sf.Tier, sf.HotSectors)
+		}
+		if sf.Index == slow && (sf.Tier != modules.StorageTierSlow || sf.CapacityRemaining != sf.Capacity-modules.SectorSize) {
+			t.Fatal("unexpected slow folder", sf.Tier, sf.CapacityRemaining)
+		}
+	}
+
+	// Invalid tiers are rejected.
+	err = cmt.cm.SetStorageFolderTier(slow, "medium")
+	if !errors.Contains(err, errInvalidStorageTier) {
+		t.Fatal("expected errInvalidStorageTier", err)
+	}
+
+	// The tiers are persisted.
+	err = cmt.cm.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmt.cm, err = newContractManager(d, cmt.cm.persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sf := range cmt.cm.StorageFolders() {
+		if (sf.Index == fast) != (sf.Tier == modules.StorageTierFast) {
+			t.Fatal("tier wasn't persisted", sf.Index, sf.Tier)
+		}
+	}
+}
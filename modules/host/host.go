@@ -83,6 +83,7 @@ import (
 	"github.com/turtledex/TurtleDexCore/types"
 	"github.com/turtledex/errors"
 	connmonitor "github.com/turtledex/monitor"
+	"github.com/turtledex/ratelimit"
 	"github.com/turtledex/siamux"
 )
 
@@ -194,10 +195,28 @@ type Host struct {
 	// of such conditions are congestion, load, liquidity, etc.
 	staticPriceTables *hostPrices
 
+	// The pricing engine which adjusts the host's prices.
+	staticPricing *pricingEngine
+
 	// Fields related to RHP3 bandwidhth.
 	atomicStreamUpload   uint64
 	atomicStreamDownload uint64
 
+	// The host's bandwidth ratelimit and the scheduler which adjusts it
+	// according to the bandwidth schedule.
+	staticRL                 *ratelimit.RateLimit
+	staticBandwidthScheduler *modules.BandwidthScheduler
+
+	// The limits of the resources used by single peers and the peers which
+	// are banned.
+	staticPeerLimits *peerLimits
+
+	// The storage obligations with sectors that failed verification.
+	staticAtRisk *atRiskObligations
+
+	// The revenue ledger of the host.
+	staticLedger *ledger
+
 	// Misc state.
 	db            *persist.BoltDatabase
 	listener      net.Listener
@@ -456,8 +475,13 @@ func newHost(dependencies modules.Dependencies, smDeps modules.Dependencies, cs
 			},
 		},
 		staticRegistrySubscriptions: newRegistrySubscriptions(),
+		staticAtRisk:                new(atRiskObligations),
+		staticLedger:                newLedger(),
+		staticRL:                    ratelimit.NewRateLimit(0, 0, 0),
 		persistDir:                  persistDir,
 	}
+	h.staticBandwidthScheduler = modules.NewBandwidthScheduler(h.staticRL)
+	h.staticPeerLimits = newPeerLimits(h.staticAlerter)
 
 	// Create MDM.
 	h.staticMDM = mdm.New(h)
@@ -518,6 +542,20 @@ func newHost(dependencies modules.Dependencies, smDeps modules.Dependencies, cs
 		}
 	})
 
+	// Apply the persisted bandwidth limits and keep them in sync with the
+	// bandwidth schedule.
+	err = h.staticBandwidthScheduler.SetSchedule(h.settings.MaxDownloadSpeed, h.settings.MaxUploadSpeed, h.settings.BandwidthSchedule)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to set bandwidth limits")
+	}
+	h.staticBandwidthScheduler.Start(h.tg.StopChan())
+
+	// Load the settings of the pricing engine.
+	h.staticPricing, err = newPricingEngine(h.persistDir)
+	if err != nil {
+		return nil, err
+	}
+
 	// Load the registry.
 	err = h.managedInitRegistry()
 	if err != nil {
@@ -561,6 +599,12 @@ func newHost(dependencies modules.Dependencies, smDeps modules.Dependencies, cs
 	// Ensure the expired RPC tables get pruned as to not leak memory
 	go h.threadedPruneExpiredPriceTables()
 
+	// Keep the prices up-to-date with the pricing engine.
+	go h.threadedUpdatePricing()
+
+	// Mark the storage obligations with corrupt sectors after each scrub.
+	go h.threadedCheckScrub()
+
 	return h, nil
 }
 
@@ -697,6 +741,19 @@ func (h *Host) SetInternalSettings(settings modules.HostInternalSettings) error
 		}
 	}
 
+	if settings.MaxDownloadSpeed < 0 || settings.MaxUploadSpeed < 0 {
+		return errors.New("internal settings not updated, bandwidth limits cannot be negative")
+	}
+	if err := settings.BandwidthSchedule.Validate(); err != nil {
+		return errors.AddContext(err, "internal settings not updated, invalid bandwidth schedule")
+	}
+	if settings.PeerMaxDownloadSpeed < 0 || settings.PeerMaxUploadSpeed < 0 {
+		return errors.New("internal settings not updated, peer bandwidth limits cannot be negative")
+	}
+	if settings.PeerBanThreshold > 0 && settings.PeerBanDuration <= 0 {
+		return errors.New("internal settings not updated, peer ban duration needs to be positive")
+	}
+
 	// Check if the net address for the host has changed. If it has, and it's
 	// not equal to the auto address, then the host is going to need to make
 	// another blockchain announcement.
@@ -728,6 +785,12 @@ func (h *Host) SetInternalSettings(settings modules.HostInternalSettings) error
 		}
 	}
 
+	// Apply the bandwidth limits.
+	err = h.staticBandwidthScheduler.SetSchedule(settings.MaxDownloadSpeed, settings.MaxUploadSpeed, settings.BandwidthSchedule)
+	if err != nil {
+		return errors.AddContext(err, "internal settings not updated, invalid bandwidth limits")
+	}
+
 	h.settings = settings
 	h.revisionNumber++
 
@@ -0,0 +1,256 @@
+package host
+
+// The ledger books the host's revenue, collateral and expenses per contract and
+// per UTC day, which allows hosts to export their financials for accounting and
+// tax reporting. The revenue of a storage obligation is booked on the day the
+// obligation succeeds, collateral is booked as locked whenever a contract is
+// formed or revised and as unlocked or lost once the obligation is resolved.
+//
+// Deposits into ephemeral accounts are booked as account funding revenue of the
+// contract which paid for them. To avoid counting the same money twice, RPCs
+// paid from an ephemeral account are booked as account spending, which isn't
+// revenue. Only RPCs paid by contract are booked as RPC or registry revenue.
+//
+// Payments are aggregated in memory and written to the ledger bucket of the
+// host's database whenever the host saves its settings, i.e. after every block
+// and on shutdown. This avoids a database transaction for every single payment.
+// The entries are keyed by the day followed by the contract id, which means a
+// range of days can be read with a single cursor seek.
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/turtledex/bolt"
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/persist"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+var (
+	// errInvalidLedgerRange is returned if the ledger is queried for a range of
+	// days that ends before it starts.
+	errInvalidLedgerRange = errors.New("the end of the range needs to be after its start")
+)
+
+type (
+	// ledger aggregates the ledger entries which haven't been written to the
+	// database yet.
+	ledger struct {
+		pending map[ledgerKey]modules.HostLedgerEntry
+		mu      sync.Mutex
+	}
+
+	// ledgerKey identifies an entry of the ledger.
+	ledgerKey struct {
+		day  int64
+		fcid types.FileContractID
+	}
+)
+
+// newLedger creates a new ledger.
+func newLedger() *ledger {
+	return &ledger{
+		pending: make(map[ledgerKey]modules.HostLedgerEntry),
+	}
+}
+
+// ledgerDay returns the start of the UTC day of the given time.
+func ledgerDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// newLedgerKey returns the key of the entry for the given contract on the day
+// of the given time.
+func newLedgerKey(t time.Time, fcid types.FileContractID) ledgerKey {
+	return ledgerKey{
+		day:  ledgerDay(t).Unix(),
+		fcid: fcid,
+	}
+}
+
+// bytes returns the database key of the entry. The day comes first and is
+// stored as a big endian integer, which means bolt sorts the entries by day.
+func (k ledgerKey) bytes() []byte {
+	b := make([]byte, 8+len(k.fcid))
+	binary.BigEndian.PutUint64(b, uint64(k.day))
+	copy(b[8:], k.fcid[:])
+	return b
+}
+
+// addLedgerEntries adds the amounts of entry b to the amounts of entry a.
+func addLedgerEntries(a, b modules.HostLedgerEntry) modules.HostLedgerEntry {
+	a.AccountFunding = a.AccountFunding.Add(b.AccountFunding)
+	a.ContractCompensation = a.ContractCompensation.Add(b.ContractCompensation)
+	a.DownloadRevenue = a.DownloadRevenue.Add(b.DownloadRevenue)
+	a.StorageRevenue = a.StorageRevenue.Add(b.StorageRevenue)
+	a.UploadRevenue = a.UploadRevenue.Add(b.UploadRevenue)
+	a.RegistryRevenue = a.RegistryRevenue.Add(b.RegistryRevenue)
+	a.RPCRevenue = a.RPCRevenue.Add(b.RPCRevenue)
+	a.AccountRegistrySpending = a.AccountRegistrySpending.Add(b.AccountRegistrySpending)
+	a.AccountRPCSpending = a.AccountRPCSpending.Add(b.AccountRPCSpending)
+	a.LockedCollateral = a.LockedCollateral.Add(b.LockedCollateral)
+	a.LostCollateral = a.LostCollateral.Add(b.LostCollateral)
+	a.UnlockedCollateral = a.UnlockedCollateral.Add(b.UnlockedCollateral)
+	a.TransactionFees = a.TransactionFees.Add(b.TransactionFees)
+	return a
+}
+
+// subOrZero returns a-b or zero if b is greater than a.
+func subOrZero(a, b types.Currency) types.Currency {
+	if a.Cmp(b) <= 0 {
+		return types.ZeroCurrency
+	}
+	return a.Sub(b)
+}
+
+// programUsesRegistry returns whether a program reads or updates the registry.
+func programUsesRegistry(program modules.Program) bool {
+	for _, instruction := range program {
+		if instruction.Specifier == modules.SpecifierReadRegistry || instruction.Specifier == modules.SpecifierUpdateRegistry {
+			return true
+		}
+	}
+	return false
+}
+
+// addPending adds an entry to the pending entries.
+func (l *ledger) addPending(key ledgerKey, entry modules.HostLedgerEntry) {
+	entry = addLedgerEntries(l.pending[key], entry)
+	entry.Day = time.Unix(key.day, 0).UTC()
+	entry.ContractID = key.fcid
+	l.pending[key] = entry
+}
+
+// managedRecord books the amounts of the entry for the given contract on the
+// current day.
+func (l *ledger) managedRecord(fcid types.FileContractID, entry modules.HostLedgerEntry) {
+	l.managedRecordAt(time.Now(), fcid, entry)
+}
+
+// paymentLedgerEntry returns the ledger entry for an amount which was spent on
+// an RPC. Amounts paid by contract are revenue while amounts paid from an
+// ephemeral account are account spending.
+func paymentLedgerEntry(method types.Specifier, spent types.Currency, registry bool) modules.HostLedgerEntry {
+	byAccount := method == modules.PayByEphemeralAccount
+	switch {
+	case byAccount && registry:
+		return modules.HostLedgerEntry{AccountRegistrySpending: spent}
+	case byAccount:
+		return modules.HostLedgerEntry{AccountRPCSpending: spent}
+	case registry:
+		return modules.HostLedgerEntry{RegistryRevenue: spent}
+	default:
+		return modules.HostLedgerEntry{RPCRevenue: spent}
+	}
+}
+
+// managedRecordPayment books an amount which was spent on an RPC paid with the
+// given payment method on the current day. The amount doesn't belong to a
+// contract.
+func (l *ledger) managedRecordPayment(method types.Specifier, spent types.Currency, registry bool) {
+	if spent.IsZero() {
+		return
+	}
+	l.managedRecord(types.FileContractID{}, paymentLedgerEntry(method, spent, registry))
+}
+
+// managedRecordAt books the amounts of the entry for the given contract on the
+// day of the given time.
+func (l *ledger) managedRecordAt(t time.Time, fcid types.FileContractID, entry modules.HostLedgerEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.addPending(newLedgerKey(t, fcid), entry)
+}
+
+// managedFlush writes the pending entries to the database.
+func (l *ledger) managedFlush(db *persist.BoltDatabase) error {
+	l.mu.Lock()
+	pending := l.pending
+	l.pending = make(map[ledgerKey]modules.HostLedgerEntry)
+	l.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketLedger)
+		for key, entry := range pending {
+			k := key.bytes()
+			if v := b.Get(k); v != nil {
+				var stored modules.HostLedgerEntry
+				err := json.Unmarshal(v, &stored)
+				if err != nil {
+					return errors.AddContext(err, "unable to unmarshal ledger entry")
+				}
+				entry = addLedgerEntries(stored, entry)
+			}
+			v, err := json.Marshal(entry)
+			if err != nil {
+				return errors.AddContext(err, "unable to marshal ledger entry")
+			}
+			err = b.Put(k, v)
+			if err != nil {
+				return errors.AddContext(err, "unable to store ledger entry")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// Keep the entries around to write them during the next flush.
+		l.mu.Lock()
+		for key, entry := range pending {
+			l.addPending(key, entry)
+		}
+		l.mu.Unlock()
+	}
+	return err
+}
+
+// managedEntries flushes the pending entries and returns the entries for the
+// days between from and to, inclusive. The entries are sorted by day.
+func (l *ledger) managedEntries(db *persist.BoltDatabase, from, to time.Time) ([]modules.HostLedgerEntry, error) {
+	if ledgerDay(to).Before(ledgerDay(from)) {
+		return nil, errInvalidLedgerRange
+	}
+	// There are no entries before the unix epoch.
+	if from.Before(time.Unix(0, 0)) {
+		from = time.Unix(0, 0)
+	}
+	err := l.managedFlush(db)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to flush ledger")
+	}
+
+	start := newLedgerKey(from, types.FileContractID{}).bytes()
+	end := uint64(ledgerDay(to).Unix())
+	entries := make([]modules.HostLedgerEntry, 0)
+	err = db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketLedger).Cursor()
+		for k, v := c.Seek(start); k != nil && binary.BigEndian.Uint64(k[:8]) <= end; k, v = c.Next() {
+			var entry modules.HostLedgerEntry
+			err := json.Unmarshal(v, &entry)
+			if err != nil {
+				return errors.AddContext(err, "unable to unmarshal ledger entry")
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// FinancialLedger returns the entries of the host's revenue ledger for the days
+// between from and to, inclusive.
+func (h *Host) FinancialLedger(from, to time.Time) ([]modules.HostLedgerEntry, error) {
+	err := h.tg.Add()
+	if err != nil {
+		return nil, err
+	}
+	defer h.tg.Done()
+	return h.staticLedger.managedEntries(h.db, from, to)
+}
@@ -0,0 +1,212 @@
+package host
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/turtledex/errors"
+	"github.com/turtledex/fastrand"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+// TestLedger checks that the ledger aggregates the entries per contract and
+// day and that they can be queried by day.
+func TestLedger(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	ht, err := blankHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	l := ht.host.staticLedger
+
+	day1 := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+	day3 := day2.Add(24 * time.Hour)
+	fcid := types.FileContractID{1}
+
+	// Book a few entries, two of them on the same day for the same contract.
+	l.managedRecordAt(day1, fcid, modules.HostLedgerEntry{LockedCollateral: types.NewCurrency64(10)})
+	l.managedRecordAt(day1.Add(time.Hour), fcid, modules.HostLedgerEntry{TransactionFees: types.NewCurrency64(2)})
+	l.managedRecordAt(day1, types.FileContractID{}, modules.HostLedgerEntry{RPCRevenue: types.NewCurrency64(3)})
+	l.managedRecordAt(day3, fcid, modules.HostLedgerEntry{StorageRevenue: types.NewCurrency64(5)})
+
+	// The first day contains two entries.
+	entries, err := ht.host.FinancialLedger(day1, day1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatal("expected two entries", len(entries))
+	}
+	for _, e := range entries {
+		if !e.Day.Equal(ledgerDay(day1)) {
+			t.Fatal("wrong day", e.Day)
+		}
+		if e.ContractID == fcid && (!e.LockedCollateral.Equals64(10) || !e.TransactionFees.Equals64(2)) {
+			t.Fatal("entries weren't aggregated", e)
+		}
+		if e.ContractID != fcid && !e.RPCRevenue.Equals64(3) {
+			t.Fatal("wrong rpc revenue", e.RPCRevenue)
+		}
+	}
+
+	// Entries which are booked after a flush are added to the stored ones.
+	l.managedRecordAt(day3, fcid, modules.HostLedgerEntry{StorageRevenue: types.NewCurrency64(5)})
+	entries, err = ht.host.FinancialLedger(day2, day3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || !entries[0].Day.Equal(ledgerDay(day3)) || !entries[0].StorageRevenue.Equals64(10) {
+		t.Fatal("unexpected entries", entries)
+	}
+
+	// The whole ledger contains three entries.
+	entries, err = ht.host.FinancialLedger(time.Time{}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatal("expected three entries", len(entries))
+	}
+
+	// Ranges which end before they start are rejected.
+	_, err = ht.host.FinancialLedger(day2, day1)
+	if !errors.Contains(err, errInvalidLedgerRange) {
+		t.Fatal("expected errInvalidLedgerRange", err)
+	}
+}
+
+// TestLedgerAccountSpending checks that money which is deposited into an
+// ephemeral account and then spent on a program is only booked as revenue once.
+func TestLedgerAccountSpending(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rhp, err := newRenterHostPair(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rhp.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h := rhp.staticHT.host
+
+	// totals returns the sum of all entries of the ledger.
+	totals := func() modules.HostLedgerEntry {
+		entries, err := h.FinancialLedger(time.Time{}, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var total modules.HostLedgerEntry
+		for _, e := range entries {
+			total = addLedgerEntries(total, e)
+		}
+		return total
+	}
+	before := totals()
+
+	// Fund the account by contract.
+	pt := rhp.managedPriceTable()
+	deposit := h.managedInternalSettings().MaxEphemeralAccountBalance
+	_, err = rhp.managedFundEphemeralAccount(deposit.Add(pt.FundAccountCost), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Run a program which is paid from the account.
+	sectorData := fastrand.Bytes(int(modules.SectorSize))
+	sectorRoot := crypto.MerkleRoot(sectorData)
+	err = h.AddSector(sectorRoot, sectorData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pb := modules.NewProgramBuilder(pt, 0)
+	pb.AddHasSectorInstruction(sectorRoot)
+	program, data := pb.Program()
+	epr := modules.RPCExecuteProgramRequest{
+		FileContractID:    rhp.staticFCID,
+		Program:           program,
+		ProgramDataLength: uint64(len(data)),
+	}
+	programCost, _, _ := pb.Cost(true)
+	bandwidthCost := pt.DownloadBandwidthCost.Add(pt.UploadBandwidthCost).Mul64(modules.SectorSize)
+	_, _, err = rhp.managedExecuteProgram(epr, data, programCost.Add(bandwidthCost), false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The money spent from the account is booked as account spending. The
+	// refund and the booking happen after the RPC returns.
+	var spent types.Currency
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		total := totals()
+		balance := h.staticAccountManager.callAccountBalance(rhp.staticAccountID)
+		spent = deposit.Sub(balance)
+		if spent.IsZero() {
+			return errors.New("the program wasn't paid from the account")
+		}
+		if booked := total.AccountRPCSpending.Sub(before.AccountRPCSpending); !booked.Equals(spent) {
+			return fmt.Errorf("expected account spending %v but was %v", spent, booked)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the cost of funding the account is revenue so far.
+	total := totals()
+	if !total.RPCRevenue.Sub(before.RPCRevenue).Equals(pt.FundAccountCost) {
+		t.Fatal("unexpected rpc revenue", total.RPCRevenue.Sub(before.RPCRevenue), pt.FundAccountCost)
+	}
+	if !total.AccountFunding.Equals(before.AccountFunding) {
+		t.Fatal("account funding shouldn't be booked before the contract succeeds")
+	}
+
+	// Once the contract succeeds, the deposit is booked as revenue.
+	h.managedLockStorageObligation(rhp.staticFCID)
+	so, err := h.managedGetStorageObligation(rhp.staticFCID)
+	if err != nil {
+		h.managedUnlockStorageObligation(rhp.staticFCID)
+		t.Fatal(err)
+	}
+	err = h.removeStorageObligation(so, obligationSucceeded)
+	h.managedUnlockStorageObligation(rhp.staticFCID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := totals()
+	if !after.AccountFunding.Sub(before.AccountFunding).Equals(deposit) {
+		t.Fatal("unexpected account funding", after.AccountFunding.Sub(before.AccountFunding), deposit)
+	}
+
+	// The revenue contains the deposit and the cost of funding the account
+	// but not the money spent from the account.
+	revenue := func(e modules.HostLedgerEntry) types.Currency {
+		return e.AccountFunding.Add(e.RPCRevenue).Add(e.RegistryRevenue)
+	}
+	if !revenue(after).Sub(revenue(before)).Equals(deposit.Add(pt.FundAccountCost)) {
+		t.Fatal("money spent from the account was booked as revenue", revenue(after).Sub(revenue(before)))
+	}
+	if !after.AccountRPCSpending.Sub(before.AccountRPCSpending).Equals(spent) {
+		t.Fatal("unexpected account spending", after.AccountRPCSpending.Sub(before.AccountRPCSpending), spent)
+	}
+}
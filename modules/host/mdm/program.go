@@ -21,6 +21,10 @@ var (
 	// ErrInterrupted indicates that the program was interrupted during
 	// execution and couldn't finish.
 	ErrInterrupted = errors.New("execution of program was interrupted")
+
+	// ErrInvalidInstruction is returned if one of the program's instructions
+	// can't be decoded.
+	ErrInvalidInstruction = errors.New("program contains an invalid instruction")
 )
 
 // FnFinalize is the type of a function returned by ExecuteProgram to finalize
@@ -144,7 +148,7 @@ func (mdm *MDM) ExecuteProgram(ctx context.Context, pt *modules.RPCPriceTable, p
 	for _, i := range p {
 		instruction, err := decodeInstruction(program, i)
 		if err != nil {
-			return nil, nil, errors.Compose(err, program.staticData.Close())
+			return nil, nil, errors.Compose(ErrInvalidInstruction, err, program.staticData.Close())
 		}
 		program.instructions = append(program.instructions, instruction)
 	}
@@ -64,6 +64,9 @@ func (h *Host) externalSettings(maxFeeEstimation types.Currency) modules.HostExt
 		maxCollateral = h.settings.CollateralBudget.Sub(h.financialMetrics.LockedStorageCollateral)
 	}
 
+	// Apply the factor of the pricing engine to the minimum prices.
+	prices := h.settings.MinPrices().Apply(h.staticPricing.managedFactor())
+
 	// Extract the port from the TurtleDexMux's address
 	_, port, err := net.SplitHostPort(h.staticMux.Address().String())
 	if err != nil {
@@ -85,12 +88,12 @@ func (h *Host) externalSettings(maxFeeEstimation types.Currency) modules.HostExt
 		Collateral:    h.settings.Collateral,
 		MaxCollateral: maxCollateral,
 
-		BaseRPCPrice:           h.settings.MinBaseRPCPrice,
+		BaseRPCPrice:           prices.BaseRPCPrice,
 		ContractPrice:          contractPrice,
-		DownloadBandwidthPrice: h.settings.MinDownloadBandwidthPrice,
-		SectorAccessPrice:      h.settings.MinSectorAccessPrice,
-		StoragePrice:           h.settings.MinStoragePrice,
-		UploadBandwidthPrice:   h.settings.MinUploadBandwidthPrice,
+		DownloadBandwidthPrice: prices.DownloadBandwidthPrice,
+		SectorAccessPrice:      prices.SectorAccessPrice,
+		StoragePrice:           prices.StoragePrice,
+		UploadBandwidthPrice:   prices.UploadBandwidthPrice,
 
 		EphemeralAccountExpiry:     h.settings.EphemeralAccountExpiry,
 		MaxEphemeralAccountBalance: h.settings.MaxEphemeralAccountBalance,
@@ -27,6 +27,7 @@ import (
 	"github.com/turtledex/encoding"
 	"github.com/turtledex/errors"
 	connmonitor "github.com/turtledex/monitor"
+	"github.com/turtledex/ratelimit"
 	"github.com/turtledex/siamux"
 )
 
@@ -247,7 +248,10 @@ func (h *Host) initNetworking(address string) (err error) {
 
 	// Create a listener for the TurtleDexMux.
 	if !h.dependencies.Disrupt("DisableHostTurtleDexmux") {
-		err = h.staticMux.NewListener(modules.HostTurtleDexMuxSubscriberName, h.threadedHandleStream)
+		err = h.staticMux.NewListener(modules.HostTurtleDexMuxSubscriberName, func(stream siamux.Stream) {
+			// Apply the host's ratelimit.
+			h.threadedHandleStream(ratelimit.NewRLStream(stream, h.staticRL, h.tg.StopChan()))
+		})
 		if err != nil {
 			return errors.AddContext(err, "Failed to subscribe to the TurtleDexMux")
 		}
@@ -289,6 +293,12 @@ func (h *Host) threadedHandleConn(conn net.Conn) {
 		return
 	}
 
+	// Refuse the connections of banned peers.
+	if h.staticPeerLimits.managedIsBanned(peerAddress(conn.RemoteAddr())) {
+		h.log.Debugf("WARN: refused connection of banned peer %v", conn.RemoteAddr())
+		return
+	}
+
 	// Read the first 16 bytes. If those bytes are RPCLoopEnter, then the
 	// renter is attempting to use the new protocol; otherweise, assume the
 	// renter is using the old protocol, and that the following 8 bytes
@@ -326,9 +336,11 @@ func (h *Host) threadedHandleConn(conn net.Conn) {
 	case modules.RPCRenewContractRHP2:
 		atomic.AddUint64(&h.atomicRenewCalls, 1)
 		err = extendErr("incoming RPCRenewContract failed: ", h.managedRPCRenewContractRHP2(conn))
+		h.staticPricing.managedRecordNegotiation(err == nil)
 	case modules.RPCFormContract:
 		atomic.AddUint64(&h.atomicFormContractCalls, 1)
 		err = extendErr("incoming RPCFormContract failed: ", h.managedRPCFormContract(conn))
+		h.staticPricing.managedRecordNegotiation(err == nil)
 	case modules.RPCReviseContract:
 		atomic.AddUint64(&h.atomicReviseCalls, 1)
 		err = extendErr("incoming RPCReviseContract failed: ", h.managedRPCReviseContract(conn))
@@ -397,6 +409,14 @@ func (h *Host) threadedHandleStream(stream siamux.Stream) {
 		return
 	}
 
+	// refuse the streams of banned peers
+	if h.staticPeerLimits.managedIsBanned(peerAddress(stream.RemoteAddr())) {
+		if wErr := modules.RPCWriteError(stream, errPeerBanned); wErr != nil {
+			h.managedLogError(wErr)
+		}
+		return
+	}
+
 	// read the RPC id
 	var rpcID types.Specifier
 	err = modules.RPCRead(stream, &rpcID)
@@ -424,6 +444,7 @@ func (h *Host) threadedHandleStream(stream siamux.Stream) {
 		cleanup, err = h.managedRPCRegistrySubscribe(stream)
 	case modules.RPCRenewContract:
 		err = h.managedRPCRenewContract(stream)
+		h.staticPricing.managedRecordNegotiation(err == nil)
 	default:
 		h.log.Debugf("WARN: incoming stream %v requested unknown RPC \"%v\"", stream.RemoteAddr().String(), rpcID)
 		err = errors.New(fmt.Sprintf("Unrecognized RPC id %v", rpcID))
@@ -431,6 +452,9 @@ func (h *Host) threadedHandleStream(stream siamux.Stream) {
 	}
 
 	if err != nil {
+		if reason, offense := peerOffense(err); offense {
+			h.managedStrikePeer(stream.RemoteAddr(), reason)
+		}
 		err = errors.Compose(err, modules.RPCWriteError(stream, err))
 		atomic.AddUint64(&h.atomicErroredCalls, 1)
 		h.managedLogError(err)
@@ -451,6 +475,8 @@ func (h *Host) threadedListen(closeChan chan struct{}) {
 		}
 
 		conn = connmonitor.NewMonitoredConn(conn, h.staticMonitor)
+		// Apply the host's ratelimit.
+		conn = ratelimit.NewRLConn(conn, h.staticRL, h.tg.StopChan())
 
 		go h.threadedHandleConn(conn)
 
@@ -11,6 +11,7 @@ import (
 	"github.com/turtledex/bolt"
 	"github.com/turtledex/errors"
 	"github.com/turtledex/fastrand"
+	"github.com/turtledex/ratelimit"
 
 	"github.com/turtledex/TurtleDexCore/crypto"
 	"github.com/turtledex/TurtleDexCore/modules"
@@ -116,6 +117,22 @@ func (h *Host) managedRPCLoopLock(s *rpcSession) error {
 			return extendErr("could not get storage obligation "+req.ContractID.String()+": ", err)
 		}
 		s.so = so
+
+		// Apply the limits of the renter's key for as long as the contract
+		// is locked.
+		rl, release, err := h.staticPeerLimits.managedAcquire(types.Ed25519PublicKey(renterPK).String(), h.managedInternalSettings(), false)
+		if err != nil {
+			h.managedUnlockStorageObligation(req.ContractID)
+			s.so = storageObligation{}
+			err = errors.Compose(err, s.writeError(err))
+			return err
+		}
+		conn := s.conn
+		s.conn = ratelimit.NewRLConn(conn, rl, nil)
+		s.peerRelease = func() {
+			release()
+			s.conn = conn
+		}
 	}
 
 	// get the revision and signatures
@@ -150,6 +167,7 @@ func (h *Host) managedRPCLoopUnlock(s *rpcSession) error {
 		h.managedUnlockStorageObligation(s.so.id())
 		s.so = storageObligation{}
 	}
+	s.releasePeer()
 	return nil
 }
 
@@ -49,7 +49,7 @@ func (h *Host) staticPayByEphemeralAccount(stream siamux.Stream, bh types.BlockH
 	}
 
 	// Payment done through EAs don't move collateral
-	return newPaymentDetails(req.Message.Account, req.Message.Amount), nil
+	return newPaymentDetails(req.Message.Account, req.Message.Amount, modules.PayByEphemeralAccount), nil
 }
 
 // managedPayByContract processes a PayByContractRequest coming in over the
@@ -131,7 +131,7 @@ func (h *Host) managedPayByContract(stream siamux.Stream, bh types.BlockHeight)
 		return nil, errors.AddContext(err, "Could not send PayByContractResponse")
 	}
 
-	return newPaymentDetails(accountID, amount), nil
+	return newPaymentDetails(accountID, amount, modules.PayByContract), nil
 }
 
 // managedFundAccount processes a PayByContractRequest coming in over the given
@@ -401,13 +401,15 @@ func verifyPayByContractRevision(current, payment types.FileContractRevision, bl
 type paymentDetails struct {
 	account modules.AccountID
 	amount  types.Currency
+	method  types.Specifier
 }
 
 // newPaymentDetails returns a new paymentDetails object using the given values
-func newPaymentDetails(account modules.AccountID, amountPaid types.Currency) *paymentDetails {
+func newPaymentDetails(account modules.AccountID, amountPaid types.Currency, method types.Specifier) *paymentDetails {
 	return &paymentDetails{
 		account: account,
 		amount:  amountPaid,
+		method:  method,
 	}
 }
 
@@ -417,3 +419,7 @@ func (pd *paymentDetails) AccountID() modules.AccountID { return pd.account }
 
 // Amount returns how much money the host received.
 func (pd *paymentDetails) Amount() types.Currency { return pd.amount }
+
+// PaymentMethod returns whether the payment was made by contract or from an
+// ephemeral account.
+func (pd *paymentDetails) PaymentMethod() types.Specifier { return pd.method }
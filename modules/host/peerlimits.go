@@ -0,0 +1,307 @@
+package host
+
+// The peer limits protect the host from single renters monopolizing its
+// resources. Streams are attributed to the renter key of the locked contract
+// in the RPC loop and to the ephemeral account which paid for an ExecuteProgram
+// RPC. For each of these peers, the number of concurrent streams, their
+// bandwidth and the number of program executions per minute are limited
+// according to the host's internal settings.
+//
+// Peers that repeatedly send invalid payments or programs collect strikes.
+// Since keys and accounts are free to create, strikes and bans apply to the IP
+// address of the peer. Once a peer reaches the ban threshold, its streams and
+// connections are refused until the ban expires. Bans are only kept in memory
+// and an alert is registered for as long as any peer is banned.
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/turtledex/errors"
+	"github.com/turtledex/ratelimit"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+)
+
+const (
+	// peerLimitsPacketSize is the packet size used when ratelimiting the
+	// bandwidth of a single peer.
+	peerLimitsPacketSize = 4 * 4096
+
+	// peerProgramWindow is the amount of time over which the program
+	// executions of a peer are limited.
+	peerProgramWindow = time.Minute
+)
+
+var (
+	// errInvalidProgram is returned if the host refuses to execute a program.
+	errInvalidProgram = errors.New("invalid program")
+
+	// errPeerBanned is returned to peers which are temporarily banned.
+	errPeerBanned = errors.New("peer is temporarily banned")
+
+	// errPeerTooManyPrograms is returned if a peer exceeds its limit of
+	// program executions per minute.
+	errPeerTooManyPrograms = errors.New("peer exceeded its limit of program executions")
+
+	// errPeerTooManyStreams is returned if a peer exceeds its limit of
+	// concurrent streams.
+	errPeerTooManyStreams = errors.New("peer exceeded its limit of concurrent streams")
+)
+
+type (
+	// peerUsage is the usage of the host's resources by a single peer.
+	peerUsage struct {
+		activeStreams uint64
+		programs      []time.Time
+		staticRL      *ratelimit.RateLimit
+	}
+
+	// peerLimits keeps track of the resources used by the host's peers, of
+	// their strikes and of the peers that are banned.
+	peerLimits struct {
+		bans      map[string]modules.HostBannedPeer
+		lastPrune time.Time
+		strikes   map[string][]time.Time
+		usage     map[string]*peerUsage
+
+		staticAlerter *modules.GenericAlerter
+		mu            sync.Mutex
+	}
+)
+
+// newPeerLimits creates a new peerLimits object which registers its alerts
+// with the given alerter.
+func newPeerLimits(alerter *modules.GenericAlerter) *peerLimits {
+	return &peerLimits{
+		bans:          make(map[string]modules.HostBannedPeer),
+		strikes:       make(map[string][]time.Time),
+		usage:         make(map[string]*peerUsage),
+		staticAlerter: alerter,
+	}
+}
+
+// peerAddress returns the address used to identify a peer for strikes and
+// bans, which is the IP address of the remote end of a connection.
+func peerAddress(addr net.Addr) string {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
+
+// peerOffense returns whether an error returned by an RPC was caused by the
+// peer sending an invalid payment or program and a description of the
+// offense.
+func peerOffense(err error) (string, bool) {
+	switch {
+	case errors.Contains(err, errInvalidProgram):
+		return "invalid program", true
+	case errors.Contains(err, modules.ErrWithdrawalInvalidSignature),
+		errors.Contains(err, modules.ErrWithdrawalExpired),
+		errors.Contains(err, modules.ErrWithdrawalExtremeFuture),
+		errors.Contains(err, modules.ErrUnknownPaymentMethod),
+		errors.Contains(err, ErrWithdrawalSpent):
+		return "invalid payment", true
+	}
+	return "", false
+}
+
+// prunePrograms removes the program executions which are older than the
+// program window.
+func (pu *peerUsage) prunePrograms(now time.Time) {
+	i := 0
+	for i < len(pu.programs) && now.Sub(pu.programs[i]) > peerProgramWindow {
+		i++
+	}
+	pu.programs = pu.programs[i:]
+}
+
+// pruneStrikes removes the strikes of a peer which are older than the window.
+func (pl *peerLimits) pruneStrikes(address string, now time.Time, window time.Duration) {
+	strikes := pl.strikes[address]
+	i := 0
+	for i < len(strikes) && now.Sub(strikes[i]) > window {
+		i++
+	}
+	if i == len(strikes) {
+		delete(pl.strikes, address)
+		return
+	}
+	pl.strikes[address] = strikes[i:]
+}
+
+// prune removes idle peers, old strikes and expired bans. To keep the cost of
+// acquiring a stream low, the peers are only pruned once per program window.
+func (pl *peerLimits) prune(now time.Time, banDuration time.Duration) {
+	if now.Sub(pl.lastPrune) < peerProgramWindow {
+		return
+	}
+	pl.lastPrune = now
+	for peer, pu := range pl.usage {
+		pu.prunePrograms(now)
+		if pu.activeStreams == 0 && len(pu.programs) == 0 {
+			delete(pl.usage, peer)
+		}
+	}
+	for address := range pl.strikes {
+		pl.pruneStrikes(address, now, banDuration)
+	}
+	for address, ban := range pl.bans {
+		if !now.Before(ban.Expiry) {
+			delete(pl.bans, address)
+		}
+	}
+	pl.updateAlert()
+}
+
+// updateAlert registers or unregisters the banned peers alert depending on
+// whether any peers are banned.
+func (pl *peerLimits) updateAlert() {
+	if len(pl.bans) == 0 {
+		pl.staticAlerter.UnregisterAlert(modules.AlertIDHostPeersBanned)
+		return
+	}
+	cause := fmt.Sprintf("%v peers are banned", len(pl.bans))
+	pl.staticAlerter.RegisterAlert(modules.AlertIDHostPeersBanned, AlertMSGHostPeersBanned, cause, modules.SeverityWarning)
+}
+
+// managedAcquire acquires a stream for the peer with the given key. If the
+// stream executes a program, it counts towards the peer's program executions.
+// It returns the ratelimit that applies to the peer's streams and a function
+// that needs to be called once the stream is done.
+func (pl *peerLimits) managedAcquire(peer string, settings modules.HostInternalSettings, program bool) (*ratelimit.RateLimit, func(), error) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	now := time.Now()
+	pl.prune(now, settings.PeerBanDuration)
+
+	pu, exists := pl.usage[peer]
+	if !exists {
+		pu = &peerUsage{
+			staticRL: ratelimit.NewRateLimit(0, 0, 0),
+		}
+	}
+	pu.prunePrograms(now)
+	if settings.PeerMaxConcurrentStreams > 0 && pu.activeStreams >= settings.PeerMaxConcurrentStreams {
+		return nil, nil, errPeerTooManyStreams
+	}
+	if program && settings.PeerMaxProgramsPerMinute > 0 && uint64(len(pu.programs)) >= settings.PeerMaxProgramsPerMinute {
+		return nil, nil, errPeerTooManyPrograms
+	}
+	if program {
+		pu.programs = append(pu.programs, now)
+	}
+	pu.activeStreams++
+	pl.usage[peer] = pu
+
+	// Apply the current bandwidth limits to all of the peer's streams.
+	if settings.PeerMaxDownloadSpeed == 0 && settings.PeerMaxUploadSpeed == 0 {
+		pu.staticRL.SetLimits(0, 0, 0)
+	} else {
+		pu.staticRL.SetLimits(settings.PeerMaxDownloadSpeed, settings.PeerMaxUploadSpeed, peerLimitsPacketSize)
+	}
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			pl.managedRelease(peer)
+		})
+	}
+	return pu.staticRL, release, nil
+}
+
+// managedRelease releases a stream of the peer with the given key.
+func (pl *peerLimits) managedRelease(peer string) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	pu, exists := pl.usage[peer]
+	if !exists || pu.activeStreams == 0 {
+		return
+	}
+	pu.activeStreams--
+	pu.prunePrograms(time.Now())
+	if pu.activeStreams == 0 && len(pu.programs) == 0 {
+		delete(pl.usage, peer)
+	}
+}
+
+// managedBannedPeers returns the peers which are currently banned, sorted by
+// the expiry of their bans.
+func (pl *peerLimits) managedBannedPeers() []modules.HostBannedPeer {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	now := time.Now()
+	bans := make([]modules.HostBannedPeer, 0, len(pl.bans))
+	for _, ban := range pl.bans {
+		if now.Before(ban.Expiry) {
+			bans = append(bans, ban)
+		}
+	}
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].Expiry.Before(bans[j].Expiry)
+	})
+	return bans
+}
+
+// managedIsBanned returns whether the peer with the given address is banned.
+func (pl *peerLimits) managedIsBanned(address string) bool {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	ban, exists := pl.bans[address]
+	if !exists {
+		return false
+	}
+	if time.Now().Before(ban.Expiry) {
+		return true
+	}
+	delete(pl.bans, address)
+	pl.updateAlert()
+	return false
+}
+
+// managedStrike records an offense of the peer with the given address. The
+// peer is banned once it reaches the ban threshold. The return value
+// indicates whether the peer was banned.
+func (pl *peerLimits) managedStrike(address, reason string, settings modules.HostInternalSettings) bool {
+	if settings.PeerBanThreshold == 0 {
+		return false
+	}
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	now := time.Now()
+	pl.pruneStrikes(address, now, settings.PeerBanDuration)
+	pl.strikes[address] = append(pl.strikes[address], now)
+	strikes := uint64(len(pl.strikes[address]))
+	if strikes < settings.PeerBanThreshold {
+		return false
+	}
+	delete(pl.strikes, address)
+	pl.bans[address] = modules.HostBannedPeer{
+		Address: address,
+		Reason:  reason,
+		Strikes: strikes,
+		Expiry:  now.Add(settings.PeerBanDuration),
+	}
+	pl.updateAlert()
+	return true
+}
+
+// managedStrikePeer records an offense of the peer at the given address and
+// logs if the peer gets banned.
+func (h *Host) managedStrikePeer(addr net.Addr, reason string) {
+	settings := h.managedInternalSettings()
+	address := peerAddress(addr)
+	if h.staticPeerLimits.managedStrike(address, reason, settings) {
+		h.log.Printf("Banned peer %v for %v after repeatedly sending an %v", address, settings.PeerBanDuration, reason)
+	}
+}
+
+// BannedPeers returns the peers which are temporarily banned by the host.
+func (h *Host) BannedPeers() []modules.HostBannedPeer {
+	return h.staticPeerLimits.managedBannedPeers()
+}
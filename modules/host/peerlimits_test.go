@@ -0,0 +1,156 @@
+package host
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/host/mdm"
+)
+
+// TestPeerLimits tests limiting the streams and programs of peers.
+func TestPeerLimits(t *testing.T) {
+	t.Parallel()
+
+	pl := newPeerLimits(modules.NewAlerter("host"))
+	settings := modules.HostInternalSettings{
+		PeerMaxConcurrentStreams: 2,
+		PeerMaxProgramsPerMinute: 3,
+	}
+
+	// The number of concurrent streams is limited.
+	_, release1, err := pl.managedAcquire("peer", settings, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, release2, err := pl.managedAcquire("peer", settings, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := pl.managedAcquire("peer", settings, false); !errors.Contains(err, errPeerTooManyStreams) {
+		t.Fatal("expected errPeerTooManyStreams", err)
+	}
+
+	// Other peers are not affected.
+	_, release3, err := pl.managedAcquire("other", settings, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release3()
+
+	// Releasing a stream twice only frees one slot.
+	release1()
+	release1()
+	_, release1, err = pl.managedAcquire("peer", settings, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := pl.managedAcquire("peer", settings, false); !errors.Contains(err, errPeerTooManyStreams) {
+		t.Fatal("expected errPeerTooManyStreams", err)
+	}
+	release1()
+	release2()
+
+	// The number of programs per minute is limited.
+	_, release, err := pl.managedAcquire("peer", settings, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if _, _, err := pl.managedAcquire("peer", settings, true); !errors.Contains(err, errPeerTooManyPrograms) {
+		t.Fatal("expected errPeerTooManyPrograms", err)
+	}
+
+	// Without limits, the peer can use the host again.
+	_, release, err = pl.managedAcquire("peer", modules.HostInternalSettings{}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+}
+
+// TestPeerBans tests banning peers after repeated offenses.
+func TestPeerBans(t *testing.T) {
+	t.Parallel()
+
+	alerter := modules.NewAlerter("host")
+	pl := newPeerLimits(alerter)
+	settings := modules.HostInternalSettings{
+		PeerBanThreshold: 3,
+		PeerBanDuration:  time.Second,
+	}
+
+	// Without a threshold, peers are never banned.
+	for i := 0; i < 10; i++ {
+		if pl.managedStrike("1.2.3.4", "invalid payment", modules.HostInternalSettings{}) {
+			t.Fatal("peer shouldn't be banned")
+		}
+	}
+
+	// The peer is banned once it reaches the threshold.
+	for i := 0; i < 2; i++ {
+		if pl.managedStrike("1.2.3.4", "invalid payment", settings) {
+			t.Fatal("peer shouldn't be banned yet")
+		}
+	}
+	if pl.managedIsBanned("1.2.3.4") {
+		t.Fatal("peer shouldn't be banned yet")
+	}
+	if !pl.managedStrike("1.2.3.4", "invalid program", settings) {
+		t.Fatal("peer should be banned")
+	}
+	if !pl.managedIsBanned("1.2.3.4") || pl.managedIsBanned("5.6.7.8") {
+		t.Fatal("wrong peer is banned")
+	}
+	bans := pl.managedBannedPeers()
+	if len(bans) != 1 || bans[0].Address != "1.2.3.4" || bans[0].Reason != "invalid program" || bans[0].Strikes != 3 {
+		t.Fatal("unexpected bans", bans)
+	}
+	_, _, warn := alerter.Alerts()
+	if len(warn) != 1 || warn[0].Msg != AlertMSGHostPeersBanned {
+		t.Fatal("expected banned peers alert", warn)
+	}
+
+	// The ban expires.
+	time.Sleep(settings.PeerBanDuration)
+	if pl.managedIsBanned("1.2.3.4") {
+		t.Fatal("ban should have expired")
+	}
+	if len(pl.managedBannedPeers()) != 0 {
+		t.Fatal("ban should have expired")
+	}
+	if _, _, warn := alerter.Alerts(); len(warn) != 0 {
+		t.Fatal("alert should have been unregistered", warn)
+	}
+}
+
+// TestPeerOffense tests classifying the errors returned by RPCs.
+func TestPeerOffense(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err     error
+		offense bool
+	}{
+		{errors.AddContext(modules.ErrWithdrawalInvalidSignature, "Withdraw failed"), true},
+		{errors.AddContext(ErrWithdrawalSpent, "Withdraw failed"), true},
+		{errors.Compose(mdm.ErrInvalidInstruction, errInvalidProgram), true},
+		{errors.AddContext(modules.ErrMDMInsufficientBudget, "Failed to start execution of the program"), false},
+		{errors.AddContext(ErrBalanceInsufficient, "Withdraw failed"), false},
+		{errors.New("connection reset"), false},
+	}
+	for _, test := range tests {
+		if _, offense := peerOffense(test.err); offense != test.offense {
+			t.Fatalf("%v: expected %v but was %v", test.err, test.offense, offense)
+		}
+	}
+
+	// Peers are identified by their IP address.
+	addr := &net.TCPAddr{IP: net.ParseIP("1.2.3.4"), Port: 9982}
+	if address := peerAddress(addr); address != "1.2.3.4" {
+		t.Fatal("unexpected address", address)
+	}
+}
@@ -75,6 +75,9 @@ func (h *Host) establishDefaults() error {
 		EphemeralAccountExpiry:     modules.DefaultEphemeralAccountExpiry,
 		MaxEphemeralAccountBalance: modules.DefaultMaxEphemeralAccountBalance,
 		MaxEphemeralAccountRisk:    defaultMaxEphemeralAccountRisk,
+
+		PeerBanThreshold: modules.DefaultPeerBanThreshold,
+		PeerBanDuration:  modules.DefaultPeerBanDuration,
 	}
 
 	// Load the host's key pair, use the same keys as the TurtleDexMux.
@@ -143,6 +146,7 @@ func (h *Host) initDB() (err error) {
 		// database needs to be initialized. Create the database buckets.
 		buckets := [][]byte{
 			bucketActionItems,
+			bucketLedger,
 			bucketStorageObligations,
 		}
 		for _, bucket := range buckets {
@@ -257,7 +261,9 @@ func (h *Host) load() error {
 	return nil
 }
 
-// saveSync stores all of the persist data to disk and then syncs to disk.
+// saveSync stores all of the persist data to disk and then syncs to disk. The
+// pending entries of the ledger are written to the database as well.
 func (h *Host) saveSync() error {
-	return persist.SaveJSON(modules.Hostv151PersistMetadata, h.persistData(), filepath.Join(h.persistDir, settingsFile))
+	err := h.staticLedger.managedFlush(h.db)
+	return errors.Compose(err, persist.SaveJSON(modules.Hostv151PersistMetadata, h.persistData(), filepath.Join(h.persistDir, settingsFile)))
 }
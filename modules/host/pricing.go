@@ -0,0 +1,228 @@
+package host
+
+// The pricing engine adjusts the host's prices to the storage utilization, a
+// fiat target and the demand for contracts. The demand is measured as the
+// fraction of recent contract formations and renewals which completed
+// successfully. At a regular interval, the engine combines these inputs into a
+// single factor which is applied to the minimum prices of the host's internal
+// settings when the external settings and the price table are built. Every
+// change of the factor is logged.
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/persist"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+const (
+	// pricingPersistFile is the name of the file the settings of the pricing
+	// engine are persisted to.
+	pricingPersistFile = "pricing.json"
+
+	// pricingPersistVersion is the version of the pricing engine's persist
+	// file.
+	pricingPersistVersion = "1.5.5"
+)
+
+var (
+	// pricingMetadata is the metadata of the pricing engine's persist file.
+	pricingMetadata = persist.Metadata{
+		Header:  "Host Pricing",
+		Version: pricingPersistVersion,
+	}
+
+	// pricingAcceptanceWindow is the amount of time for which contract
+	// negotiations are considered when computing the acceptance rate.
+	pricingAcceptanceWindow = build.Select(build.Var{
+		Standard: 24 * time.Hour,
+		Dev:      time.Hour,
+		Testing:  time.Minute,
+	}).(time.Duration)
+)
+
+type (
+	// negotiationOutcome is the outcome of a contract negotiation.
+	negotiationOutcome struct {
+		accepted  bool
+		timestamp time.Time
+	}
+
+	// pricingEngine computes the factor which is applied to the host's
+	// minimum prices.
+	pricingEngine struct {
+		acceptanceRate     float64
+		factor             float64
+		lastUpdate         time.Time
+		negotiations       []negotiationOutcome
+		settings           modules.HostPricingSettings
+		storageUtilization float64
+
+		staticPath string
+		mu         sync.Mutex
+	}
+)
+
+// newPricingEngine loads the settings of the pricing engine from disk or
+// creates a new, disabled pricing engine.
+func newPricingEngine(persistDir string) (*pricingEngine, error) {
+	pe := &pricingEngine{
+		acceptanceRate: -1,
+		factor:         1,
+		staticPath:     filepath.Join(persistDir, pricingPersistFile),
+	}
+	err := persist.LoadJSON(pricingMetadata, &pe.settings, pe.staticPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.AddContext(err, "failed to load pricing settings")
+	}
+	return pe, nil
+}
+
+// managedFactor returns the factor which is applied to the host's minimum
+// prices.
+func (pe *pricingEngine) managedFactor() float64 {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+	return pe.factor
+}
+
+// managedRecordNegotiation records the outcome of a contract negotiation.
+func (pe *pricingEngine) managedRecordNegotiation(accepted bool) {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+	pe.negotiations = append(pe.negotiations, negotiationOutcome{
+		accepted:  accepted,
+		timestamp: time.Now(),
+	})
+	pe.pruneNegotiations()
+}
+
+// pruneNegotiations removes the negotiations which are older than the
+// acceptance window.
+func (pe *pricingEngine) pruneNegotiations() {
+	i := 0
+	for i < len(pe.negotiations) && time.Since(pe.negotiations[i].timestamp) > pricingAcceptanceWindow {
+		i++
+	}
+	pe.negotiations = pe.negotiations[i:]
+}
+
+// currentAcceptanceRate returns the fraction of recent negotiations which
+// were accepted or -1 if there were none.
+func (pe *pricingEngine) currentAcceptanceRate() float64 {
+	pe.pruneNegotiations()
+	if len(pe.negotiations) == 0 {
+		return -1
+	}
+	var accepted int
+	for _, n := range pe.negotiations {
+		if n.accepted {
+			accepted++
+		}
+	}
+	return float64(accepted) / float64(len(pe.negotiations))
+}
+
+// managedSetSettings validates and persists the settings.
+func (pe *pricingEngine) managedSetSettings(settings modules.HostPricingSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+	pe.settings = settings
+	return persist.SaveJSON(pricingMetadata, pe.settings, pe.staticPath)
+}
+
+// managedStatus returns the settings and the outcome of the last update.
+func (pe *pricingEngine) managedStatus() modules.HostPricingStatus {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+	return modules.HostPricingStatus{
+		HostPricingSettings: pe.settings,
+
+		Factor:             pe.factor,
+		StorageUtilization: pe.storageUtilization,
+		AcceptanceRate:     pe.acceptanceRate,
+		LastUpdate:         pe.lastUpdate,
+	}
+}
+
+// managedUpdate recomputes the factor and returns the old and the new one.
+func (pe *pricingEngine) managedUpdate(minStoragePrice types.Currency, utilization float64) (float64, float64) {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+	oldFactor := pe.factor
+	pe.acceptanceRate = pe.currentAcceptanceRate()
+	pe.storageUtilization = utilization
+	pe.factor = pe.settings.Factor(minStoragePrice, utilization, pe.acceptanceRate)
+	pe.lastUpdate = time.Now()
+	return oldFactor, pe.factor
+}
+
+// managedUpdatePricing recomputes the prices of the host and updates the price
+// table if they changed.
+func (h *Host) managedUpdatePricing() {
+	var utilization float64
+	total, remaining := h.capacity()
+	if total > 0 {
+		utilization = float64(total-remaining) / float64(total)
+	}
+	settings := h.managedInternalSettings()
+	oldFactor, newFactor := h.staticPricing.managedUpdate(settings.MinStoragePrice, utilization)
+	if oldFactor == newFactor {
+		return
+	}
+	prices := settings.MinPrices().Apply(newFactor)
+	h.log.Printf("Pricing engine changed the price factor from %.4f to %.4f, storage price is now %v, upload bandwidth price %v, download bandwidth price %v",
+		oldFactor, newFactor, prices.StoragePrice.HumanString(), prices.UploadBandwidthPrice.HumanString(), prices.DownloadBandwidthPrice.HumanString())
+	h.managedUpdatePriceTable()
+}
+
+// threadedUpdatePricing periodically recomputes the prices of the host.
+func (h *Host) threadedUpdatePricing() {
+	for {
+		func() {
+			if err := h.tg.Add(); err != nil {
+				return
+			}
+			defer h.tg.Done()
+			h.managedUpdatePricing()
+		}()
+
+		select {
+		case <-h.tg.StopChan():
+			return
+		case <-time.After(modules.HostPricingUpdateInterval):
+		}
+	}
+}
+
+// PricingStatus returns the settings and the current state of the host's
+// dynamic pricing engine.
+func (h *Host) PricingStatus() modules.HostPricingStatus {
+	status := h.staticPricing.managedStatus()
+	status.Prices = h.managedInternalSettings().MinPrices().Apply(status.Factor)
+	return status
+}
+
+// SetPricingSettings configures the host's dynamic pricing engine and applies
+// the new settings immediately.
+func (h *Host) SetPricingSettings(settings modules.HostPricingSettings) error {
+	if err := h.tg.Add(); err != nil {
+		return err
+	}
+	defer h.tg.Done()
+	if err := h.staticPricing.managedSetSettings(settings); err != nil {
+		return errors.AddContext(err, "unable to set pricing settings")
+	}
+	h.managedUpdatePricing()
+	return nil
+}
@@ -0,0 +1,131 @@
+package host
+
+import (
+	"os"
+	"testing"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+// TestPricingEngine tests the acceptance rate and the persistence of the
+// pricing engine.
+func TestPricingEngine(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir(modules.HostDir, t.Name())
+	if err := os.MkdirAll(dir, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	pe, err := newPricingEngine(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A new engine is disabled and doesn't change the prices.
+	if old, factor := pe.managedUpdate(types.NewCurrency64(1), 0.5); old != 1 || factor != 1 {
+		t.Fatal("disabled engine shouldn't change the prices", old, factor)
+	}
+	if status := pe.managedStatus(); status.AcceptanceRate != -1 || status.StorageUtilization != 0.5 {
+		t.Fatal("unexpected status", status)
+	}
+
+	// Invalid settings are rejected.
+	err = pe.managedSetSettings(modules.HostPricingSettings{TargetAcceptanceRate: 2})
+	if !errors.Contains(err, modules.ErrInvalidHostPricingSettings) {
+		t.Fatal("expected ErrInvalidHostPricingSettings", err)
+	}
+
+	// Raise prices when more negotiations succeed than targeted.
+	settings := modules.HostPricingSettings{
+		Enabled:              true,
+		TargetAcceptanceRate: 0.5,
+		AcceptanceAdjustment: 0.5,
+	}
+	if err := pe.managedSetSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		pe.managedRecordNegotiation(true)
+	}
+	pe.managedRecordNegotiation(false)
+	if _, factor := pe.managedUpdate(types.NewCurrency64(1), 0); factor != 1.125 {
+		t.Fatal("wrong factor", factor)
+	}
+	if pe.managedFactor() != 1.125 || pe.managedStatus().AcceptanceRate != 0.75 {
+		t.Fatal("unexpected status", pe.managedStatus())
+	}
+
+	// The settings are persisted.
+	pe, err = newPricingEngine(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pe.managedStatus().HostPricingSettings != settings {
+		t.Fatal("settings weren't persisted", pe.managedStatus())
+	}
+}
+
+// TestHostPricing tests that the factor of the pricing engine is applied to
+// the host's prices.
+func TestHostPricing(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Without pricing settings, the minimum prices are used.
+	is := ht.host.InternalSettings()
+	if !ht.host.PriceTable().WriteStoreCost.Equals(is.MinStoragePrice) {
+		t.Fatal("price table should use the minimum storage price")
+	}
+
+	// Double the prices of the empty host.
+	err = ht.host.SetPricingSettings(modules.HostPricingSettings{
+		Enabled:            true,
+		EmptyStorageFactor: 2,
+		FullStorageFactor:  4,
+		MaxFactor:          2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := ht.host.PricingStatus()
+	if status.Factor != 2 {
+		t.Fatal("wrong factor", status.Factor)
+	}
+	if !status.Prices.StoragePrice.Equals(is.MinStoragePrice.Mul64(2)) {
+		t.Fatal("wrong storage price", status.Prices.StoragePrice)
+	}
+	pt := ht.host.PriceTable()
+	if !pt.WriteStoreCost.Equals(is.MinStoragePrice.Mul64(2)) {
+		t.Fatal("price table wasn't updated", pt.WriteStoreCost)
+	}
+	if !pt.DownloadBandwidthCost.Equals(is.MinDownloadBandwidthPrice.Mul64(2)) {
+		t.Fatal("price table wasn't updated", pt.DownloadBandwidthCost)
+	}
+
+	// Disabling the engine restores the minimum prices.
+	if err := ht.host.SetPricingSettings(modules.HostPricingSettings{}); err != nil {
+		t.Fatal(err)
+	}
+	if !ht.host.PriceTable().WriteStoreCost.Equals(is.MinStoragePrice) {
+		t.Fatal("price table should use the minimum storage price")
+	}
+}
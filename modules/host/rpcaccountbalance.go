@@ -33,6 +33,7 @@ func (h *Host) managedRPCAccountBalance(stream siamux.Stream) error {
 	if err != nil {
 		return errors.AddContext(err, "failed to refund client")
 	}
+	h.staticLedger.managedRecordPayment(pd.PaymentMethod(), pt.AccountBalanceCost, false)
 
 	// Read request
 	var abr modules.AccountBalanceRequest
@@ -14,6 +14,7 @@ import (
 	"github.com/turtledex/TurtleDexCore/types"
 	"github.com/turtledex/errors"
 	"github.com/turtledex/fastrand"
+	"github.com/turtledex/ratelimit"
 	"github.com/turtledex/siamux"
 )
 
@@ -44,6 +45,7 @@ func (h *Host) managedRPCExecuteProgram(stream siamux.Stream) error {
 	// Refund all the money we didn't use at the end of the RPC.
 	refundAccount := pd.AccountID()
 	programRefund := pd.Amount()
+	usesRegistry := false
 	err = h.tg.Add()
 	if err != nil {
 		return err
@@ -53,13 +55,25 @@ func (h *Host) managedRPCExecuteProgram(stream siamux.Stream) error {
 			defer h.tg.Done()
 			// The total refund is the remaining value of the budget + the
 			// potential program refund.
-			depositErr := h.staticAccountManager.callRefund(refundAccount, programRefund.Add(budget.Remaining()))
+			refund := programRefund.Add(budget.Remaining())
+			depositErr := h.staticAccountManager.callRefund(refundAccount, refund)
 			if depositErr != nil {
 				h.log.Print("ERROR: failed to refund renter", depositErr)
 			}
+			// Book the money that wasn't refunded in the ledger.
+			h.staticLedger.managedRecordPayment(pd.PaymentMethod(), subOrZero(pd.Amount(), refund), usesRegistry)
 		}()
 	}()
 
+	// Apply the limits of the paying account. The stream's bandwidth counts
+	// towards the bandwidth of all of the account's streams.
+	rl, release, err := h.staticPeerLimits.managedAcquire(refundAccount.SPK().String(), h.managedInternalSettings(), true)
+	if err != nil {
+		return errors.AddContext(err, "failed to acquire stream for account")
+	}
+	defer release()
+	stream = ratelimit.NewRLStream(stream, rl, h.tg.StopChan())
+
 	// Read request
 	var epr modules.RPCExecuteProgramRequest
 	err = modules.RPCRead(stream, &epr)
@@ -70,6 +84,7 @@ func (h *Host) managedRPCExecuteProgram(stream siamux.Stream) error {
 	// Extract the arguments.
 	fcid, instructions, dataLength := epr.FileContractID, epr.Program, epr.ProgramDataLength
 	program := modules.Program(instructions)
+	usesRegistry = programUsesRegistry(program)
 
 	// If the program isn't readonly we need to acquire a lock on the storage
 	// obligation.
@@ -113,7 +128,13 @@ func (h *Host) managedRPCExecuteProgram(stream siamux.Stream) error {
 	// Execute the program.
 	finalize, outputs, err := h.staticMDM.ExecuteProgram(ctx, pt, program, budget, collateralBudget, sos, duration, dataLength, stream)
 	if err != nil {
-		return errors.AddContext(err, "Failed to start execution of the program")
+		err = errors.AddContext(err, "Failed to start execution of the program")
+		// Only programs that can't be decoded are the peer's fault. An
+		// insufficient budget or a shutting down host are not.
+		if errors.Contains(err, mdm.ErrEmptyProgram) || errors.Contains(err, mdm.ErrInvalidInstruction) {
+			err = errors.Compose(err, errInvalidProgram)
+		}
+		return err
 	}
 
 	// Create a buffer
@@ -45,7 +45,10 @@ func (h *Host) managedRPCFundEphemeralAccount(stream siamux.Stream) error {
 
 	// There's no need to verify payment here. The account gets funded by the
 	// amount paid minus the cost of the RPC. If the amount paid did not cover
-	// the cost of the RPC, an error will have been returned.
+	// the cost of the RPC, an error will have been returned. The funded amount
+	// is booked as account funding once the contract succeeds, only the cost
+	// of the RPC is booked right away.
+	h.staticLedger.managedRecordPayment(modules.PayByContract, pt.FundAccountCost, false)
 
 	// create the receipt and sign it
 	receipt := modules.Receipt{
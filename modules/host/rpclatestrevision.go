@@ -57,5 +57,6 @@ func (h *Host) managedRPCLatestRevision(stream siamux.Stream) (err error) {
 			return errors.AddContext(err, "failed to refund excessive payment")
 		}
 	}
+	h.staticLedger.managedRecordPayment(pd.PaymentMethod(), pt.LatestRevisionCost, false)
 	return nil
 }
@@ -22,6 +22,19 @@ type rpcSession struct {
 	aead      cipher.AEAD
 	so        storageObligation
 	challenge [16]byte
+
+	// peerRelease releases the stream acquired for the renter key of the
+	// locked contract.
+	peerRelease func()
+}
+
+// releasePeer releases the stream acquired for the renter key of the locked
+// contract, if any.
+func (s *rpcSession) releasePeer() {
+	if s.peerRelease != nil {
+		s.peerRelease()
+		s.peerRelease = nil
+	}
 }
 
 // extendDeadline extends the read/write deadline on the underlying connection
@@ -117,6 +130,7 @@ func (h *Host) managedRPCLoop(conn net.Conn) error {
 			h.managedUnlockStorageObligation(s.so.id())
 			s.so = storageObligation{}
 		}
+		s.releasePeer()
 	}()
 
 	// enter RPC loop
@@ -141,9 +155,15 @@ func (h *Host) managedRPCLoop(conn net.Conn) error {
 		} else if id == modules.RPCLoopExit {
 			return nil
 		}
-		if rpcFn, ok := rpcs[id]; !ok {
+		rpcFn, ok := rpcs[id]
+		if !ok {
 			return errors.New("invalid or unknown RPC ID: " + id.String())
-		} else if err := rpcFn(s); err != nil {
+		}
+		err = rpcFn(s)
+		if id == modules.RPCLoopFormContract || id == modules.RPCLoopRenewContract || id == modules.RPCLoopRenewClearContract {
+			h.staticPricing.managedRecordNegotiation(err == nil)
+		}
+		if err != nil {
 			return extendErr("incoming RPC"+id.String()+" failed: ", err)
 		}
 	}
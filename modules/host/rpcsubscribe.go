@@ -251,17 +251,17 @@ func (h *Host) managedHandleExtendSubscriptionRequest(stream siamux.Stream, oldD
 // this session with the host. Due to the complicated concurrency of how we
 // track bandwidth and updating the price table, we lock the subscriptionInfo
 // during the whole operation and notify the renter when setting the new limit
-// is done.
-func (h *Host) managedHandlePrepayBandwidth(stream siamux.Stream, info *subscriptionInfo, pt *modules.RPCPriceTable) error {
+// is done. It returns the amount that was paid.
+func (h *Host) managedHandlePrepayBandwidth(stream siamux.Stream, info *subscriptionInfo, pt *modules.RPCPriceTable) (types.Currency, error) {
 	// Process payment.
 	pd, err := h.ProcessPayment(stream, pt.HostBlockHeight)
 	if err != nil {
-		return errors.AddContext(err, "managedHandlePrepaybandwidth: failed to process payment")
+		return types.ZeroCurrency, errors.AddContext(err, "managedHandlePrepaybandwidth: failed to process payment")
 	}
 
 	// Add to budget.
 	info.staticBudget.Deposit(pd.Amount())
-	return nil
+	return pd.Amount(), nil
 }
 
 // managedPriceTableValidFor returns true if a price table is still valid for
@@ -384,11 +384,16 @@ func (h *Host) managedRPCRegistrySubscribe(stream siamux.Stream) (_ afterCloseFn
 	// makes the writeCost the DownloadBandwidthCost.
 	budget := modules.NewBudget(pd.Amount())
 	bandwidthLimit := modules.NewBudgetLimit(budget, pt.UploadBandwidthCost, pt.DownloadBandwidthCost)
+	// Keep track of the total payment to book the money that wasn't refunded
+	// in the ledger.
+	paid := pd.Amount()
 	// Prepare a refund method which is called at the end of the rpc.
 	refund := func() {
+		remaining := budget.Remaining()
+		h.staticLedger.managedRecordPayment(pd.PaymentMethod(), subOrZero(paid, remaining), true)
 		// Refund the unused budget
-		if !budget.Remaining().IsZero() {
-			err = errors.Compose(err, h.staticAccountManager.callRefund(pd.AccountID(), budget.Remaining()))
+		if !remaining.IsZero() {
+			err = errors.Compose(err, h.staticAccountManager.callRefund(pd.AccountID(), remaining))
 		}
 	}
 	err = stream.SetLimit(bandwidthLimit)
@@ -436,7 +441,9 @@ func (h *Host) managedRPCRegistrySubscribe(stream siamux.Stream) (_ afterCloseFn
 		case modules.SubscriptionRequestExtend:
 			pt, deadline, err = h.managedHandleExtendSubscriptionRequest(stream, deadline, info, bandwidthLimit)
 		case modules.SubscriptionRequestPrepay:
-			err = h.managedHandlePrepayBandwidth(stream, info, pt)
+			var prepaid types.Currency
+			prepaid, err = h.managedHandlePrepayBandwidth(stream, info, pt)
+			paid = paid.Add(prepaid)
 		case modules.SubscriptionRequestStop:
 			err = h.managedHandleStopSubscription(info)
 			return refund, err
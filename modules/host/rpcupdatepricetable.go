@@ -157,6 +157,7 @@ func (h *Host) managedRPCUpdatePriceTable(stream siamux.Stream) (err error) {
 		refund := payment.Amount().Sub(pt.UpdatePriceTableCost)
 		err = errors.Compose(err, h.staticAccountManager.callRefund(payment.AccountID(), refund))
 	}()
+	h.staticLedger.managedRecordPayment(payment.PaymentMethod(), pt.UpdatePriceTableCost, false)
 
 	// after payment has been received, track the price table in the host's list
 	// of price tables and signal the renter we consider the price table valid
@@ -0,0 +1,152 @@
+package host
+
+// Whenever a scrub of the storage manager finishes, the host checks which of
+// its unresolved storage obligations contain corrupt or missing sectors and
+// marks them as at risk. A storage proof for such an obligation fails if one of
+// the bad sectors is challenged.
+//
+// The at-risk obligations are only kept in memory. Since the storage manager
+// persists the findings of its last scrub, the host rebuilds them from these
+// findings when it starts.
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/turtledex/bolt"
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+var (
+	// scrubCheckInterval is the interval at which the host checks whether a
+	// scrub of the storage manager finished.
+	scrubCheckInterval = build.Select(build.Var{
+		Standard: 10 * time.Minute,
+		Dev:      time.Minute,
+		Testing:  time.Second,
+	}).(time.Duration)
+)
+
+// atRiskObligations are the storage obligations with corrupt or missing
+// sectors.
+type atRiskObligations struct {
+	contracts  []types.FileContractID
+	lastFinish time.Time
+	mu         sync.Mutex
+}
+
+// managedFindAtRiskObligations returns the unresolved storage obligations which
+// contain sectors that failed verification. The sectors of each obligation are
+// checked outside of the database transaction to avoid blocking the host
+// while the storage manager is queried.
+func (h *Host) managedFindAtRiskObligations() ([]types.FileContractID, error) {
+	var unresolved []types.FileContractID
+	err := h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketStorageObligations).ForEach(func(_, soBytes []byte) error {
+			var so storageObligation
+			if err := json.Unmarshal(soBytes, &so); err != nil {
+				return build.ExtendErr("unable to unmarshal storage obligation:", err)
+			}
+			if so.ObligationStatus == obligationUnresolved {
+				unresolved = append(unresolved, so.id())
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var contracts []types.FileContractID
+	for _, fcid := range unresolved {
+		var roots []crypto.Hash
+		err := h.db.View(func(tx *bolt.Tx) error {
+			so, err := h.getStorageObligation(tx, fcid)
+			roots = so.SectorRoots
+			return err
+		})
+		if errors.Contains(err, errNoStorageObligation) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if len(h.StorageManager.CorruptSectors(roots)) > 0 {
+			contracts = append(contracts, fcid)
+		}
+	}
+	return contracts, nil
+}
+
+// managedCheckScrub marks the storage obligations with corrupt or missing
+// sectors as at risk if a scrub finished since the last check.
+func (h *Host) managedCheckScrub() {
+	status := h.StorageManager.ScrubStatus()
+	h.staticAtRisk.mu.Lock()
+	checked := status.LastFinish.Equal(h.staticAtRisk.lastFinish)
+	h.staticAtRisk.mu.Unlock()
+	if checked {
+		return
+	}
+
+	var contracts []types.FileContractID
+	if len(status.Findings) > 0 {
+		var err error
+		contracts, err = h.managedFindAtRiskObligations()
+		if err != nil {
+			h.log.Println("ERROR: unable to check storage obligations for corrupt sectors:", err)
+			return
+		}
+	}
+	h.staticAtRisk.mu.Lock()
+	h.staticAtRisk.contracts = contracts
+	h.staticAtRisk.lastFinish = status.LastFinish
+	h.staticAtRisk.mu.Unlock()
+
+	if len(contracts) == 0 {
+		h.staticAlerter.UnregisterAlert(modules.AlertIDHostObligationsAtRisk)
+		return
+	}
+	for _, fcid := range contracts {
+		h.log.Println("WARN: storage obligation has corrupt or missing sectors:", fcid)
+	}
+	cause := fmt.Sprintf("%v storage obligations have corrupt or missing sectors", len(contracts))
+	h.staticAlerter.RegisterAlert(modules.AlertIDHostObligationsAtRisk, AlertMSGHostObligationsAtRisk, cause, modules.SeverityCritical)
+}
+
+// threadedCheckScrub periodically checks for finished scrubs. The first check
+// runs right away, which rebuilds the at-risk obligations from the findings of
+// the last scrub after a restart.
+func (h *Host) threadedCheckScrub() {
+	for {
+		func() {
+			if err := h.tg.Add(); err != nil {
+				return
+			}
+			defer h.tg.Done()
+			h.managedCheckScrub()
+		}()
+
+		select {
+		case <-h.tg.StopChan():
+			return
+		case <-time.After(scrubCheckInterval):
+		}
+	}
+}
+
+// ScrubStatus returns the progress and the findings of the storage manager's
+// scrubber together with the storage obligations which are at risk.
+func (h *Host) ScrubStatus() modules.StorageScrubStatus {
+	status := h.StorageManager.ScrubStatus()
+	h.staticAtRisk.mu.Lock()
+	status.AtRiskContracts = append([]types.FileContractID(nil), h.staticAtRisk.contracts...)
+	h.staticAtRisk.mu.Unlock()
+	return status
+}
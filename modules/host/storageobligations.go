@@ -763,6 +763,11 @@ func (h *Host) updateFinancialMetricsAddSO(so storageObligation) {
 	h.financialMetrics.PotentialUploadBandwidthRevenue = h.financialMetrics.PotentialUploadBandwidthRevenue.Add(so.PotentialUploadRevenue)
 	h.financialMetrics.RiskedStorageCollateral = h.financialMetrics.RiskedStorageCollateral.Add(so.RiskedCollateral)
 	h.financialMetrics.TransactionFeeExpenses = h.financialMetrics.TransactionFeeExpenses.Add(so.TransactionFeesAdded)
+
+	// Book the locked collateral in the ledger.
+	h.staticLedger.managedRecord(so.id(), modules.HostLedgerEntry{
+		LockedCollateral: so.LockedCollateral,
+	})
 }
 
 // updateFinancialMetricsAddSO updates the host's financial metrics for a
@@ -790,6 +795,17 @@ func (h *Host) updateFinancialMetricsUpdateSO(oldSO, newSO storageObligation) {
 	h.financialMetrics.RiskedStorageCollateral = h.financialMetrics.RiskedStorageCollateral.Sub(oldSO.RiskedCollateral)
 	h.financialMetrics.TransactionFeeExpenses = h.financialMetrics.TransactionFeeExpenses.Sub(oldSO.TransactionFeesAdded)
 
+	// Book the change of the locked collateral in the ledger.
+	if newSO.LockedCollateral.Cmp(oldSO.LockedCollateral) > 0 {
+		h.staticLedger.managedRecord(newSO.id(), modules.HostLedgerEntry{
+			LockedCollateral: newSO.LockedCollateral.Sub(oldSO.LockedCollateral),
+		})
+	} else if newSO.LockedCollateral.Cmp(oldSO.LockedCollateral) < 0 {
+		h.staticLedger.managedRecord(newSO.id(), modules.HostLedgerEntry{
+			UnlockedCollateral: oldSO.LockedCollateral.Sub(newSO.LockedCollateral),
+		})
+	}
+
 	// The locked storage collateral was altered, we potentially want to
 	// unregister the insufficient collateral budget alert
 	h.tryUnregisterInsufficientCollateralBudgetAlert()
@@ -964,6 +980,11 @@ func (h *Host) removeStorageObligation(so storageObligation, sos storageObligati
 			h.financialMetrics.PotentialUploadBandwidthRevenue = h.financialMetrics.PotentialUploadBandwidthRevenue.Sub(so.PotentialUploadRevenue)
 			h.financialMetrics.RiskedStorageCollateral = h.financialMetrics.RiskedStorageCollateral.Sub(so.RiskedCollateral)
 
+			// Book the unlocked collateral in the ledger.
+			h.staticLedger.managedRecord(so.id(), modules.HostLedgerEntry{
+				UnlockedCollateral: so.LockedCollateral,
+			})
+
 			// The locked storage collateral was altered, we potentially want to
 			// unregister the insufficient collateral budget alert
 			h.tryUnregisterInsufficientCollateralBudgetAlert()
@@ -995,6 +1016,16 @@ func (h *Host) removeStorageObligation(so storageObligation, sos storageObligati
 		h.financialMetrics.DownloadBandwidthRevenue = h.financialMetrics.DownloadBandwidthRevenue.Add(so.PotentialDownloadRevenue)
 		h.financialMetrics.UploadBandwidthRevenue = h.financialMetrics.UploadBandwidthRevenue.Add(so.PotentialUploadRevenue)
 
+		// Book the revenue and the unlocked collateral in the ledger.
+		h.staticLedger.managedRecord(so.id(), modules.HostLedgerEntry{
+			AccountFunding:       so.PotentialAccountFunding,
+			ContractCompensation: so.ContractCost,
+			DownloadRevenue:      so.PotentialDownloadRevenue,
+			StorageRevenue:       so.PotentialStorageRevenue,
+			UploadRevenue:        so.PotentialUploadRevenue,
+			UnlockedCollateral:   so.LockedCollateral,
+		})
+
 		// The locked storage collateral was altered, we potentially want to
 		// unregister the insufficient collateral budget alert
 		h.tryUnregisterInsufficientCollateralBudgetAlert()
@@ -1014,6 +1045,13 @@ func (h *Host) removeStorageObligation(so storageObligation, sos storageObligati
 		h.financialMetrics.LostStorageCollateral = h.financialMetrics.LostStorageCollateral.Add(so.RiskedCollateral)
 		h.financialMetrics.LostRevenue = h.financialMetrics.LostRevenue.Add(so.ContractCost).Add(so.PotentialStorageRevenue).Add(so.PotentialDownloadRevenue).Add(so.PotentialUploadRevenue).Add(so.PotentialAccountFunding)
 
+		// Book the lost collateral and the remaining unlocked collateral in
+		// the ledger.
+		h.staticLedger.managedRecord(so.id(), modules.HostLedgerEntry{
+			LostCollateral:     so.RiskedCollateral,
+			UnlockedCollateral: subOrZero(so.LockedCollateral, so.RiskedCollateral),
+		})
+
 		// The locked storage collateral was altered, we potentially want to
 		// unregister the insufficient collateral budget alert
 		h.tryUnregisterInsufficientCollateralBudgetAlert()
@@ -1238,6 +1276,7 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 			builder.Drop()
 		}
 		so.TransactionFeesAdded = so.TransactionFeesAdded.Add(requiredFee)
+		h.staticLedger.managedRecord(so.id(), modules.HostLedgerEntry{TransactionFees: requiredFee})
 		// return
 	}
 
@@ -1324,6 +1363,7 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 			return
 		}
 		so.TransactionFeesAdded = so.TransactionFeesAdded.Add(requiredFee)
+		h.staticLedger.managedRecord(so.id(), modules.HostLedgerEntry{TransactionFees: requiredFee})
 
 		// Queue another action item to check whether the storage proof
 		// got confirmed.
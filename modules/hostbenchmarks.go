@@ -0,0 +1,124 @@
+package modules
+
+import (
+	"sort"
+	"time"
+
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+const (
+	// HostBenchmarkHistoryLen is the number of measurements of each kind that
+	// are kept in the benchmark history of a host. Older measurements are
+	// dropped when new ones are added.
+	HostBenchmarkHistoryLen = 20
+)
+
+type (
+	// HostBenchmarks contains rolling histories of the performance
+	// measurements the hostdb took while scanning a host. The most recent
+	// measurement is always the last element of a history.
+	HostBenchmarks struct {
+		// RTT is the round trip time of dialing the host.
+		RTT []time.Duration `json:"rtt"`
+
+		// PriceTableLatency is the time it took to fetch a price table from
+		// the host over the siamux.
+		PriceTableLatency []time.Duration `json:"pricetablelatency"`
+
+		// ReadThroughput and WriteThroughput are measured in bytes per
+		// second using small paid samples.
+		ReadThroughput  []uint64 `json:"readthroughput"`
+		WriteThroughput []uint64 `json:"writethroughput"`
+	}
+
+	// HostThroughputSampler measures the throughput of a host by reading data
+	// from and writing data to it. The hostdb can't pay for the samples
+	// itself, so the sampler is provided by the renter.
+	HostThroughputSampler interface {
+		// SampleThroughput returns the read and write throughput of the host
+		// in bytes per second. A throughput of 0 means that it couldn't be
+		// measured.
+		SampleThroughput(hostKey types.TurtleDexPublicKey) (readBPS, writeBPS uint64, err error)
+	}
+)
+
+// AddRTT adds a round trip time to the history.
+func (hb *HostBenchmarks) AddRTT(rtt time.Duration) {
+	hb.RTT = append(hb.RTT, rtt)
+	if len(hb.RTT) > HostBenchmarkHistoryLen {
+		hb.RTT = hb.RTT[len(hb.RTT)-HostBenchmarkHistoryLen:]
+	}
+}
+
+// AddPriceTableLatency adds a price table latency to the history.
+func (hb *HostBenchmarks) AddPriceTableLatency(latency time.Duration) {
+	hb.PriceTableLatency = append(hb.PriceTableLatency, latency)
+	if len(hb.PriceTableLatency) > HostBenchmarkHistoryLen {
+		hb.PriceTableLatency = hb.PriceTableLatency[len(hb.PriceTableLatency)-HostBenchmarkHistoryLen:]
+	}
+}
+
+// AddReadThroughput adds a read throughput to the history.
+func (hb *HostBenchmarks) AddReadThroughput(bps uint64) {
+	hb.ReadThroughput = append(hb.ReadThroughput, bps)
+	if len(hb.ReadThroughput) > HostBenchmarkHistoryLen {
+		hb.ReadThroughput = hb.ReadThroughput[len(hb.ReadThroughput)-HostBenchmarkHistoryLen:]
+	}
+}
+
+// AddWriteThroughput adds a write throughput to the history.
+func (hb *HostBenchmarks) AddWriteThroughput(bps uint64) {
+	hb.WriteThroughput = append(hb.WriteThroughput, bps)
+	if len(hb.WriteThroughput) > HostBenchmarkHistoryLen {
+		hb.WriteThroughput = hb.WriteThroughput[len(hb.WriteThroughput)-HostBenchmarkHistoryLen:]
+	}
+}
+
+// MedianRTT returns the median of the round trip time history or 0 if the
+// history is empty.
+func (hb HostBenchmarks) MedianRTT() time.Duration {
+	return medianDuration(hb.RTT)
+}
+
+// MedianPriceTableLatency returns the median of the price table latency
+// history or 0 if the history is empty.
+func (hb HostBenchmarks) MedianPriceTableLatency() time.Duration {
+	return medianDuration(hb.PriceTableLatency)
+}
+
+// MedianReadThroughput returns the median of the read throughput history or 0
+// if the history is empty.
+func (hb HostBenchmarks) MedianReadThroughput() uint64 {
+	return medianUint64(hb.ReadThroughput)
+}
+
+// MedianWriteThroughput returns the median of the write throughput history or
+// 0 if the history is empty.
+func (hb HostBenchmarks) MedianWriteThroughput() uint64 {
+	return medianUint64(hb.WriteThroughput)
+}
+
+// medianDuration returns the median of the durations without modifying them.
+func medianDuration(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	return sorted[len(sorted)/2]
+}
+
+// medianUint64 returns the median of the values without modifying them.
+func medianUint64(values []uint64) uint64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]uint64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	return sorted[len(sorted)/2]
+}
@@ -0,0 +1,52 @@
+package modules
+
+import (
+	"testing"
+	"time"
+)
+
+// TestHostBenchmarks tests adding measurements to the benchmark histories and
+// computing their medians.
+func TestHostBenchmarks(t *testing.T) {
+	var hb HostBenchmarks
+	if hb.MedianRTT() != 0 || hb.MedianPriceTableLatency() != 0 || hb.MedianReadThroughput() != 0 || hb.MedianWriteThroughput() != 0 {
+		t.Fatal("medians of empty histories should be 0")
+	}
+
+	// Add some unsorted measurements.
+	for _, i := range []int{3, 1, 2} {
+		hb.AddRTT(time.Duration(i) * time.Millisecond)
+		hb.AddPriceTableLatency(time.Duration(i) * time.Second)
+		hb.AddReadThroughput(uint64(i))
+		hb.AddWriteThroughput(uint64(10 * i))
+	}
+	if hb.MedianRTT() != 2*time.Millisecond {
+		t.Fatal("wrong median rtt", hb.MedianRTT())
+	}
+	if hb.MedianPriceTableLatency() != 2*time.Second {
+		t.Fatal("wrong median price table latency", hb.MedianPriceTableLatency())
+	}
+	if hb.MedianReadThroughput() != 2 || hb.MedianWriteThroughput() != 20 {
+		t.Fatal("wrong median throughput", hb.MedianReadThroughput(), hb.MedianWriteThroughput())
+	}
+	// Computing the median shouldn't reorder the history.
+	if hb.RTT[0] != 3*time.Millisecond {
+		t.Fatal("history was modified")
+	}
+
+	// The histories should be capped and only contain the most recent
+	// measurements.
+	for i := 0; i < 2*HostBenchmarkHistoryLen; i++ {
+		hb.AddRTT(time.Duration(i))
+		hb.AddPriceTableLatency(time.Duration(i))
+		hb.AddReadThroughput(uint64(i))
+		hb.AddWriteThroughput(uint64(i))
+	}
+	if len(hb.RTT) != HostBenchmarkHistoryLen || len(hb.PriceTableLatency) != HostBenchmarkHistoryLen ||
+		len(hb.ReadThroughput) != HostBenchmarkHistoryLen || len(hb.WriteThroughput) != HostBenchmarkHistoryLen {
+		t.Fatal("histories weren't capped")
+	}
+	if hb.ReadThroughput[HostBenchmarkHistoryLen-1] != 2*HostBenchmarkHistoryLen-1 {
+		t.Fatal("last measurement should be the most recent one")
+	}
+}
@@ -0,0 +1,56 @@
+package modules
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// HostDiversityConstraints limit the hosts the renter forms contracts with to
+// spread its contracts across networks and locations. The network and location
+// of a host are looked up in the IP database of the hostdb. Hosts which can't
+// be found in the database are only selected if no region is required.
+type HostDiversityConstraints struct {
+	// MaxASNPercentage is the maximum percentage of the allowance's hosts
+	// which may belong to the same autonomous system. 0 disables the limit.
+	MaxASNPercentage float64 `json:"maxasnpercentage"`
+
+	// RequiredRegions are the regions hosts need to be located in. If empty,
+	// hosts may be located in any region.
+	RequiredRegions []string `json:"requiredregions"`
+
+	// ExcludedCountries are the countries hosts may not be located in.
+	ExcludedCountries []string `json:"excludedcountries"`
+}
+
+// Enabled returns true if any of the constraints limit the selectable hosts.
+func (c HostDiversityConstraints) Enabled() bool {
+	return c.MaxASNPercentage > 0 || len(c.RequiredRegions) > 0 || len(c.ExcludedCountries) > 0
+}
+
+// Normalized returns a copy of the constraints with upper case region and
+// country codes and without empty codes.
+func (c HostDiversityConstraints) Normalized() HostDiversityConstraints {
+	normalize := func(codes []string) []string {
+		var normalized []string
+		for _, code := range codes {
+			if code = strings.ToUpper(strings.TrimSpace(code)); code != "" {
+				normalized = append(normalized, code)
+			}
+		}
+		return normalized
+	}
+	return HostDiversityConstraints{
+		MaxASNPercentage:  c.MaxASNPercentage,
+		RequiredRegions:   normalize(c.RequiredRegions),
+		ExcludedCountries: normalize(c.ExcludedCountries),
+	}
+}
+
+// Validate checks that the max ASN percentage is a valid percentage.
+func (c HostDiversityConstraints) Validate() error {
+	if math.IsNaN(c.MaxASNPercentage) || c.MaxASNPercentage < 0 || c.MaxASNPercentage > 100 {
+		return fmt.Errorf("invalid max ASN percentage %v, needs to be between 0 and 100", c.MaxASNPercentage)
+	}
+	return nil
+}
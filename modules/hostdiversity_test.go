@@ -0,0 +1,42 @@
+package modules
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// TestHostDiversityConstraints tests validating and normalizing host
+// diversity constraints.
+func TestHostDiversityConstraints(t *testing.T) {
+	var c HostDiversityConstraints
+	if c.Enabled() {
+		t.Fatal("empty constraints shouldn't be enabled")
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []float64{-1, 101, math.NaN()} {
+		c := HostDiversityConstraints{MaxASNPercentage: p}
+		if err := c.Validate(); err == nil {
+			t.Fatalf("percentage %v should be invalid", p)
+		}
+	}
+
+	c = HostDiversityConstraints{
+		MaxASNPercentage:  10,
+		RequiredRegions:   []string{" eu", ""},
+		ExcludedCountries: []string{"fr", "De "},
+	}
+	if !c.Enabled() {
+		t.Fatal("constraints should be enabled")
+	}
+	expected := HostDiversityConstraints{
+		MaxASNPercentage:  10,
+		RequiredRegions:   []string{"EU"},
+		ExcludedCountries: []string{"FR", "DE"},
+	}
+	if n := c.Normalized(); !reflect.DeepEqual(n, expected) {
+		t.Fatal("unexpected normalized constraints", n)
+	}
+}
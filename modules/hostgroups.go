@@ -0,0 +1,118 @@
+package modules
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/turtledex/TurtleDexCore/types"
+	"github.com/turtledex/errors"
+)
+
+const (
+	// HostGroupNameMaxLen is the maximum length of the name of a host group.
+	HostGroupNameMaxLen = 64
+)
+
+var (
+	// ErrInvalidHostGroupName is returned when a host group name is empty,
+	// too long or contains invalid characters.
+	ErrInvalidHostGroupName = fmt.Errorf("host group names need to consist of 1 to %v lowercase letters, digits, '-' or '_'", HostGroupNameMaxLen)
+)
+
+// ValidateHostGroupName checks that the name of a host group is valid. Names
+// are restricted to lowercase letters, digits, '-' and '_' so that they can
+// be used in comma separated lists on the command line.
+func ValidateHostGroupName(name string) error {
+	if name == "" || len(name) > HostGroupNameMaxLen {
+		return ErrInvalidHostGroupName
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return errors.AddContext(ErrInvalidHostGroupName, name)
+		}
+	}
+	return nil
+}
+
+// HostGroupsByHost inverts a map of host groups to their members. The
+// returned map maps the string representation of a host's public key to the
+// sorted names of the groups the host belongs to.
+func HostGroupsByHost(groups map[string][]types.TurtleDexPublicKey) map[string][]string {
+	byHost := make(map[string][]string)
+	for name, hosts := range groups {
+		for _, pk := range hosts {
+			byHost[pk.String()] = append(byHost[pk.String()], name)
+		}
+	}
+	for _, names := range byHost {
+		sort.Strings(names)
+	}
+	return byHost
+}
+
+// ParseHostGroupTargets parses host group targets of the form
+// "group:n,group:n". An empty string results in no targets.
+func ParseHostGroupTargets(str string) (map[string]uint64, error) {
+	if str == "" {
+		return nil, nil
+	}
+	targets := make(map[string]uint64)
+	for _, target := range strings.Split(str, ",") {
+		split := strings.Split(target, ":")
+		if len(split) != 2 {
+			return nil, fmt.Errorf("invalid host group target '%v', expected 'group:n'", target)
+		}
+		if err := ValidateHostGroupName(split[0]); err != nil {
+			return nil, err
+		}
+		n, err := strconv.ParseUint(split[1], 10, 64)
+		if err != nil {
+			return nil, errors.AddContext(err, fmt.Sprintf("invalid target for host group '%v'", split[0]))
+		}
+		targets[split[0]] = n
+	}
+	return targets, nil
+}
+
+// FormatHostGroupTargets formats host group targets in the form accepted by
+// ParseHostGroupTargets. The groups are sorted by name.
+func FormatHostGroupTargets(targets map[string]uint64) string {
+	names := make([]string, 0, len(targets))
+	for name := range targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	formatted := make([]string, 0, len(names))
+	for _, name := range names {
+		formatted = append(formatted, fmt.Sprintf("%v:%v", name, targets[name]))
+	}
+	return strings.Join(formatted, ",")
+}
+
+// HostGroupTargetsTotal returns the number of contracts the allowance wants to
+// hold with hosts of host groups. Hosts can be in multiple groups, so this is
+// an upper bound of the contracts needed to satisfy the targets.
+func (a Allowance) HostGroupTargetsTotal() uint64 {
+	var total uint64
+	for _, target := range a.HostGroupTargets {
+		total += target
+	}
+	return total
+}
+
+// ValidateHostGroupTargets checks that the host group targets of the
+// allowance have valid names and don't require more contracts than the
+// allowance's hosts.
+func (a Allowance) ValidateHostGroupTargets() error {
+	for name := range a.HostGroupTargets {
+		if err := ValidateHostGroupName(name); err != nil {
+			return err
+		}
+	}
+	if total := a.HostGroupTargetsTotal(); total > a.Hosts {
+		return fmt.Errorf("host group targets require %v contracts but the allowance only has %v hosts", total, a.Hosts)
+	}
+	return nil
+}
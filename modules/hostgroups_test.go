@@ -0,0 +1,87 @@
+package modules
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+// TestValidateHostGroupName tests validating host group names.
+func TestValidateHostGroupName(t *testing.T) {
+	valid := []string{"trusted-partners", "cheap_archival", "eu1"}
+	for _, name := range valid {
+		if err := ValidateHostGroupName(name); err != nil {
+			t.Errorf("'%v' should be valid: %v", name, err)
+		}
+	}
+	invalid := []string{"", "Trusted", "a,b", "with space", string(make([]byte, HostGroupNameMaxLen+1))}
+	for _, name := range invalid {
+		if err := ValidateHostGroupName(name); err == nil {
+			t.Errorf("'%v' should be invalid", name)
+		}
+	}
+}
+
+// TestHostGroupsByHost tests inverting a map of host groups.
+func TestHostGroupsByHost(t *testing.T) {
+	pk1 := types.TurtleDexPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{1}}
+	pk2 := types.TurtleDexPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{2}}
+	byHost := HostGroupsByHost(map[string][]types.TurtleDexPublicKey{
+		"b": {pk1},
+		"a": {pk1, pk2},
+	})
+	if !reflect.DeepEqual(byHost[pk1.String()], []string{"a", "b"}) {
+		t.Fatal("wrong groups for host 1", byHost[pk1.String()])
+	}
+	if !reflect.DeepEqual(byHost[pk2.String()], []string{"a"}) {
+		t.Fatal("wrong groups for host 2", byHost[pk2.String()])
+	}
+}
+
+// TestAllowanceHostGroupTargets tests validating the host group targets of an
+// allowance.
+func TestAllowanceHostGroupTargets(t *testing.T) {
+	a := Allowance{Hosts: 10}
+	if err := a.ValidateHostGroupTargets(); err != nil {
+		t.Fatal(err)
+	}
+	a.HostGroupTargets = map[string]uint64{"trusted": 4, "archival": 6}
+	if a.HostGroupTargetsTotal() != 10 {
+		t.Fatal("wrong total", a.HostGroupTargetsTotal())
+	}
+	if err := a.ValidateHostGroupTargets(); err != nil {
+		t.Fatal(err)
+	}
+	a.HostGroupTargets["more"] = 1
+	if err := a.ValidateHostGroupTargets(); err == nil {
+		t.Fatal("targets exceeding the hosts should be invalid")
+	}
+	a.HostGroupTargets = map[string]uint64{"Invalid": 1}
+	if err := a.ValidateHostGroupTargets(); err == nil {
+		t.Fatal("invalid group name should be rejected")
+	}
+}
+
+// TestParseHostGroupTargets tests parsing and formatting host group targets.
+func TestParseHostGroupTargets(t *testing.T) {
+	targets, err := ParseHostGroupTargets("trusted:5,archival:2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]uint64{"trusted": 5, "archival": 2}
+	if !reflect.DeepEqual(targets, expected) {
+		t.Fatal("unexpected targets", targets)
+	}
+	if str := FormatHostGroupTargets(targets); str != "archival:2,trusted:5" {
+		t.Fatal("unexpected formatting", str)
+	}
+	if targets, err := ParseHostGroupTargets(""); err != nil || len(targets) != 0 {
+		t.Fatal("empty string should result in no targets", targets, err)
+	}
+	for _, str := range []string{"trusted", "trusted:x", "Trusted:1", "trusted:1:2", "trusted:-1"} {
+		if _, err := ParseHostGroupTargets(str); err == nil {
+			t.Errorf("'%v' should be invalid", str)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package modules
+
+import (
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+var (
+	// ErrInvalidHostPricingSettings is returned if the settings of the host's
+	// pricing engine are invalid.
+	ErrInvalidHostPricingSettings = errors.New("invalid host pricing settings")
+
+	// HostPricingUpdateInterval is the interval at which the host's pricing
+	// engine recalculates the prices.
+	HostPricingUpdateInterval = build.Select(build.Var{
+		Standard: 10 * time.Minute,
+		Dev:      time.Minute,
+		Testing:  3 * time.Second,
+	}).(time.Duration)
+)
+
+type (
+	// HostPricingSettings configure the host's dynamic pricing engine. The
+	// engine combines a set of rules into a single factor which is applied to
+	// the minimum prices of the host's internal settings. A rule with zero
+	// values is disabled.
+	HostPricingSettings struct {
+		Enabled bool `json:"enabled"`
+
+		// EmptyStorageFactor and FullStorageFactor are the factors applied
+		// at 0% and at 100% storage utilization. The factor is interpolated
+		// linearly in between.
+		EmptyStorageFactor float64 `json:"emptystoragefactor"`
+		FullStorageFactor  float64 `json:"fullstoragefactor"`
+
+		// TargetAcceptanceRate is the fraction of recent contract
+		// negotiations which the host aims to complete successfully. If more
+		// negotiations succeed, prices are raised, otherwise they are
+		// lowered. AcceptanceAdjustment is the maximum relative change.
+		TargetAcceptanceRate float64 `json:"targetacceptancerate"`
+		AcceptanceAdjustment float64 `json:"acceptanceadjustment"`
+
+		// ExchangeRate is the value of one coin in a fiat currency, e.g.
+		// "0.004 USD". TargetStoragePrice is the price per TB per month in
+		// that currency. The prices are scaled to match the target storage
+		// price while keeping the ratio between the host's minimum prices.
+		ExchangeRate       string  `json:"exchangerate"`
+		TargetStoragePrice float64 `json:"targetstorageprice"`
+
+		// MinFactor and MaxFactor bound the combined factor.
+		MinFactor float64 `json:"minfactor"`
+		MaxFactor float64 `json:"maxfactor"`
+	}
+
+	// HostPricingStatus contains the settings of the host's pricing engine
+	// together with the inputs and the outcome of its last update.
+	HostPricingStatus struct {
+		HostPricingSettings
+
+		Factor             float64   `json:"factor"`
+		StorageUtilization float64   `json:"storageutilization"`
+		AcceptanceRate     float64   `json:"acceptancerate"`
+		LastUpdate         time.Time `json:"lastupdate"`
+
+		// Prices are the prices the host currently advertises.
+		Prices HostPrices `json:"prices"`
+	}
+
+	// HostPrices are the prices of the host which are adjusted by the pricing
+	// engine.
+	HostPrices struct {
+		BaseRPCPrice           types.Currency `json:"baserpcprice"`
+		DownloadBandwidthPrice types.Currency `json:"downloadbandwidthprice"`
+		SectorAccessPrice      types.Currency `json:"sectoraccessprice"`
+		StoragePrice           types.Currency `json:"storageprice"`
+		UploadBandwidthPrice   types.Currency `json:"uploadbandwidthprice"`
+	}
+)
+
+// MinPrices returns the minimum prices of the host's internal settings.
+func (his HostInternalSettings) MinPrices() HostPrices {
+	return HostPrices{
+		BaseRPCPrice:           his.MinBaseRPCPrice,
+		DownloadBandwidthPrice: his.MinDownloadBandwidthPrice,
+		SectorAccessPrice:      his.MinSectorAccessPrice,
+		StoragePrice:           his.MinStoragePrice,
+		UploadBandwidthPrice:   his.MinUploadBandwidthPrice,
+	}
+}
+
+// Apply multiplies all prices with the factor.
+func (hp HostPrices) Apply(factor float64) HostPrices {
+	if factor == 1 {
+		return hp
+	}
+	return HostPrices{
+		BaseRPCPrice:           hp.BaseRPCPrice.MulFloat(factor),
+		DownloadBandwidthPrice: hp.DownloadBandwidthPrice.MulFloat(factor),
+		SectorAccessPrice:      hp.SectorAccessPrice.MulFloat(factor),
+		StoragePrice:           hp.StoragePrice.MulFloat(factor),
+		UploadBandwidthPrice:   hp.UploadBandwidthPrice.MulFloat(factor),
+	}
+}
+
+// Validate checks the settings for errors.
+func (s HostPricingSettings) Validate() error {
+	invalid := func(reason string) error {
+		return errors.AddContext(ErrInvalidHostPricingSettings, reason)
+	}
+	for _, f := range []float64{s.EmptyStorageFactor, s.FullStorageFactor, s.TargetAcceptanceRate, s.AcceptanceAdjustment, s.TargetStoragePrice, s.MinFactor, s.MaxFactor} {
+		if math.IsNaN(f) || math.IsInf(f, 0) || f < 0 {
+			return invalid("values must be finite and not negative")
+		}
+	}
+	if (s.EmptyStorageFactor == 0) != (s.FullStorageFactor == 0) {
+		return invalid("both or none of the storage factors need to be set")
+	}
+	if s.TargetAcceptanceRate > 1 {
+		return invalid("target acceptance rate can't be greater than 1")
+	}
+	if s.AcceptanceAdjustment >= 1 {
+		return invalid("acceptance adjustment needs to be less than 1")
+	}
+	rate, err := types.ParseExchangeRate(s.ExchangeRate)
+	if err != nil {
+		return errors.Compose(invalid("unable to parse exchange rate"), err)
+	}
+	if (rate == nil) != (s.TargetStoragePrice == 0) {
+		return invalid("exchange rate and target storage price need to be set together")
+	}
+	if s.MaxFactor > 0 && s.MinFactor > s.MaxFactor {
+		return invalid("min factor can't be greater than max factor")
+	}
+	return nil
+}
+
+// Factor computes the factor which is applied to the host's minimum prices.
+// utilization is the fraction of the host's storage which is used and
+// acceptanceRate the fraction of recent contract negotiations which succeeded
+// or a negative number if there were none. The settings are expected to be
+// valid.
+func (s HostPricingSettings) Factor(minStoragePrice types.Currency, utilization, acceptanceRate float64) float64 {
+	if !s.Enabled {
+		return 1
+	}
+	factor := 1.0
+
+	// Scale the prices to the fiat target.
+	rate, _ := types.ParseExchangeRate(s.ExchangeRate)
+	if rate != nil && s.TargetStoragePrice > 0 && !minStoragePrice.IsZero() {
+		target := rate.Convert(s.TargetStoragePrice)
+		current := minStoragePrice.Mul64(1e12).Mul64(uint64(types.BlocksPerMonth))
+		factor, _ = new(big.Rat).SetFrac(target.Big(), current.Big()).Float64()
+	}
+
+	// Adjust the prices to the storage utilization.
+	if s.EmptyStorageFactor > 0 && s.FullStorageFactor > 0 {
+		utilization = math.Max(0, math.Min(1, utilization))
+		factor *= s.EmptyStorageFactor + (s.FullStorageFactor-s.EmptyStorageFactor)*utilization
+	}
+
+	// Adjust the prices to the demand.
+	if s.AcceptanceAdjustment > 0 && acceptanceRate >= 0 {
+		factor *= 1 + s.AcceptanceAdjustment*(acceptanceRate-s.TargetAcceptanceRate)
+	}
+
+	// Apply the bounds.
+	if s.MinFactor > 0 && factor < s.MinFactor {
+		factor = s.MinFactor
+	}
+	if s.MaxFactor > 0 && factor > s.MaxFactor {
+		factor = s.MaxFactor
+	}
+	return factor
+}
@@ -0,0 +1,87 @@
+package modules
+
+import (
+	"math"
+	"testing"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+// TestHostPricingSettingsValidate tests validating the settings of the host's
+// pricing engine.
+func TestHostPricingSettingsValidate(t *testing.T) {
+	valid := []HostPricingSettings{
+		{},
+		{Enabled: true, EmptyStorageFactor: 0.5, FullStorageFactor: 2},
+		{Enabled: true, TargetAcceptanceRate: 0.8, AcceptanceAdjustment: 0.2},
+		{Enabled: true, ExchangeRate: "0.004 USD", TargetStoragePrice: 2},
+		{Enabled: true, MinFactor: 0.5, MaxFactor: 2},
+	}
+	for _, s := range valid {
+		if err := s.Validate(); err != nil {
+			t.Errorf("%+v should be valid: %v", s, err)
+		}
+	}
+	invalid := []HostPricingSettings{
+		{EmptyStorageFactor: -1, FullStorageFactor: 1},
+		{EmptyStorageFactor: 1},
+		{TargetAcceptanceRate: 1.5},
+		{AcceptanceAdjustment: 1},
+		{ExchangeRate: "USD", TargetStoragePrice: 2},
+		{ExchangeRate: "0.004 USD"},
+		{TargetStoragePrice: 2},
+		{MinFactor: 2, MaxFactor: 1},
+		{MaxFactor: math.Inf(1)},
+	}
+	for _, s := range invalid {
+		if err := s.Validate(); !errors.Contains(err, ErrInvalidHostPricingSettings) {
+			t.Errorf("%+v should be invalid: %v", s, err)
+		}
+	}
+}
+
+// TestHostPricingFactor tests combining the pricing rules into a factor.
+func TestHostPricingFactor(t *testing.T) {
+	minStoragePrice := types.NewCurrency64(1)
+	tests := []struct {
+		settings       HostPricingSettings
+		utilization    float64
+		acceptanceRate float64
+		factor         float64
+	}{
+		// Disabled engines don't change the prices.
+		{HostPricingSettings{EmptyStorageFactor: 1, FullStorageFactor: 2}, 1, -1, 1},
+		// Storage utilization.
+		{HostPricingSettings{Enabled: true, EmptyStorageFactor: 1, FullStorageFactor: 2}, 0, -1, 1},
+		{HostPricingSettings{Enabled: true, EmptyStorageFactor: 1, FullStorageFactor: 2}, 0.5, -1, 1.5},
+		{HostPricingSettings{Enabled: true, EmptyStorageFactor: 1, FullStorageFactor: 2}, 1, -1, 2},
+		// Acceptance rate.
+		{HostPricingSettings{Enabled: true, TargetAcceptanceRate: 0.5, AcceptanceAdjustment: 0.2}, 0, 1, 1.1},
+		{HostPricingSettings{Enabled: true, TargetAcceptanceRate: 0.5, AcceptanceAdjustment: 0.2}, 0, 0, 0.9},
+		{HostPricingSettings{Enabled: true, TargetAcceptanceRate: 0.5, AcceptanceAdjustment: 0.2}, 0, -1, 1},
+		// Bounds.
+		{HostPricingSettings{Enabled: true, EmptyStorageFactor: 1, FullStorageFactor: 4, MaxFactor: 3}, 1, -1, 3},
+		{HostPricingSettings{Enabled: true, EmptyStorageFactor: 0.1, FullStorageFactor: 1, MinFactor: 0.5}, 0, -1, 0.5},
+		// Fiat target. One hasting per byte per block is 4.32e15 hastings
+		// per TB per month, so 1.296e-8 coins per TB per month at 1 USD
+		// per coin triple the price.
+		{HostPricingSettings{Enabled: true, ExchangeRate: "1 USD", TargetStoragePrice: 1.296e-8}, 0, -1, 3},
+	}
+	for _, test := range tests {
+		factor := test.settings.Factor(minStoragePrice, test.utilization, test.acceptanceRate)
+		if math.Abs(factor-test.factor) > 1e-6 {
+			t.Errorf("%+v: expected factor %v but got %v", test.settings, test.factor, factor)
+		}
+	}
+
+	// Prices are multiplied with the factor.
+	prices := HostPrices{
+		StoragePrice:         types.NewCurrency64(10),
+		UploadBandwidthPrice: types.NewCurrency64(20),
+	}.Apply(1.5)
+	if !prices.StoragePrice.Equals64(15) || !prices.UploadBandwidthPrice.Equals64(30) || !prices.BaseRPCPrice.IsZero() {
+		t.Fatal("wrong prices", prices)
+	}
+}
@@ -0,0 +1,170 @@
+package modules
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/turtledex/errors"
+)
+
+const (
+	// HostScoringProfileCheapest is the name of the scoring profile that
+	// favors hosts with low prices over everything else.
+	HostScoringProfileCheapest = "cheapest"
+
+	// HostScoringProfileDefault is the name of the scoring profile that
+	// weighs all adjustments equally. It is the profile used by the hostdb
+	// unless a different policy is set.
+	HostScoringProfileDefault = "default"
+
+	// HostScoringProfileLatencyFirst is the name of the scoring profile that
+	// favors hosts with low latencies and high throughput in their benchmarks
+	// over cheap ones. Without host benchmarking it mostly favors hosts which
+	// are reliably online.
+	HostScoringProfileLatencyFirst = "latency-first"
+
+	// HostScoringProfileMaxCollateral is the name of the scoring profile that
+	// favors hosts which put up a lot of collateral.
+	HostScoringProfileMaxCollateral = "max-collateral"
+
+	// hostScoringProfileSeparator separates the names of the profiles a
+	// policy is composed of.
+	hostScoringProfileSeparator = "+"
+)
+
+var (
+	// ErrUnknownHostScoringProfile is returned when a scoring policy is
+	// composed of a profile that doesn't exist.
+	ErrUnknownHostScoringProfile = errors.New("unknown host scoring profile")
+
+	// DefaultHostScoringPolicy is the policy used by the hostdb unless a
+	// different policy is set.
+	DefaultHostScoringPolicy = HostScoringProfiles[HostScoringProfileDefault]
+
+	// HostScoringProfiles are the predefined scoring policies that can be
+	// selected or composed by name.
+	HostScoringProfiles = map[string]HostScoringPolicy{
+		HostScoringProfileCheapest: {
+			Name:                   HostScoringProfileCheapest,
+			AgeWeight:              1,
+			CollateralWeight:       0.5,
+			InteractionWeight:      1,
+			PerformanceWeight:      1,
+			PriceWeight:            2,
+			StorageRemainingWeight: 1,
+			UptimeWeight:           1,
+		},
+		HostScoringProfileDefault: {
+			Name:                   HostScoringProfileDefault,
+			AgeWeight:              1,
+			CollateralWeight:       1,
+			InteractionWeight:      1,
+			PerformanceWeight:      1,
+			PriceWeight:            1,
+			StorageRemainingWeight: 1,
+			UptimeWeight:           1,
+		},
+		HostScoringProfileLatencyFirst: {
+			Name:                   HostScoringProfileLatencyFirst,
+			AgeWeight:              1,
+			CollateralWeight:       1,
+			InteractionWeight:      1,
+			PerformanceWeight:      3,
+			PriceWeight:            0.5,
+			StorageRemainingWeight: 1,
+			UptimeWeight:           2,
+		},
+		HostScoringProfileMaxCollateral: {
+			Name:                   HostScoringProfileMaxCollateral,
+			AgeWeight:              1,
+			CollateralWeight:       2,
+			InteractionWeight:      1,
+			PerformanceWeight:      1,
+			PriceWeight:            0.5,
+			StorageRemainingWeight: 1,
+			UptimeWeight:           1,
+		},
+	}
+)
+
+// HostScoringPolicy determines how the hostdb combines the adjustments of a
+// host's score. Every tunable adjustment is raised to the power of its weight
+// before the adjustments are multiplied into the score. A weight of 1 leaves
+// the adjustment unchanged, a weight of 0 ignores it and larger weights make
+// the hostdb more selective about it. An adjustment of 0 disqualifies a host
+// regardless of its weight. Adjustments that disqualify a host, like not
+// accepting contracts, are not tunable.
+type HostScoringPolicy struct {
+	// Name identifies the policy in score breakdowns. Policies composed of
+	// multiple profiles are named after all of them.
+	Name string `json:"name"`
+
+	AgeWeight              float64 `json:"ageweight"`
+	CollateralWeight       float64 `json:"collateralweight"`
+	InteractionWeight      float64 `json:"interactionweight"`
+	PerformanceWeight      float64 `json:"performanceweight"`
+	PriceWeight            float64 `json:"priceweight"`
+	StorageRemainingWeight float64 `json:"storageremainingweight"`
+	UptimeWeight           float64 `json:"uptimeweight"`
+}
+
+// ComposeHostScoringPolicy creates a policy from the predefined profiles with
+// the provided names. The weights of the profiles are multiplied, so composing
+// "cheapest" with "latency-first" favors cheap hosts which are also reliable.
+func ComposeHostScoringPolicy(names ...string) (HostScoringPolicy, error) {
+	if len(names) == 0 {
+		return DefaultHostScoringPolicy, nil
+	}
+	policy := HostScoringProfiles[HostScoringProfileDefault]
+	for _, name := range names {
+		profile, exists := HostScoringProfiles[name]
+		if !exists {
+			return HostScoringPolicy{}, errors.AddContext(ErrUnknownHostScoringProfile, name)
+		}
+		policy.AgeWeight *= profile.AgeWeight
+		policy.CollateralWeight *= profile.CollateralWeight
+		policy.InteractionWeight *= profile.InteractionWeight
+		policy.PerformanceWeight *= profile.PerformanceWeight
+		policy.PriceWeight *= profile.PriceWeight
+		policy.StorageRemainingWeight *= profile.StorageRemainingWeight
+		policy.UptimeWeight *= profile.UptimeWeight
+	}
+	policy.Name = strings.Join(names, hostScoringProfileSeparator)
+	return policy, nil
+}
+
+// HostScoringProfileNames returns the sorted names of the predefined scoring
+// profiles.
+func HostScoringProfileNames() []string {
+	names := make([]string, 0, len(HostScoringProfiles))
+	for name := range HostScoringProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks that the policy has a name and that all of its weights are
+// finite and not negative.
+func (p HostScoringPolicy) Validate() error {
+	if p.Name == "" {
+		return errors.New("scoring policy needs a name")
+	}
+	weights := map[string]float64{
+		"age":              p.AgeWeight,
+		"collateral":       p.CollateralWeight,
+		"interaction":      p.InteractionWeight,
+		"performance":      p.PerformanceWeight,
+		"price":            p.PriceWeight,
+		"storageremaining": p.StorageRemainingWeight,
+		"uptime":           p.UptimeWeight,
+	}
+	for name, weight := range weights {
+		if weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return fmt.Errorf("invalid %v weight %v, weights need to be finite and not negative", name, weight)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package modules
+
+import (
+	"math"
+	"testing"
+
+	"github.com/turtledex/errors"
+)
+
+// TestComposeHostScoringPolicy tests composing scoring policies from the
+// predefined profiles.
+func TestComposeHostScoringPolicy(t *testing.T) {
+	// Without profiles, the default policy is returned.
+	policy, err := ComposeHostScoringPolicy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy != DefaultHostScoringPolicy {
+		t.Fatal("expected default policy but got", policy)
+	}
+
+	// A single profile is returned as is.
+	policy, err = ComposeHostScoringPolicy(HostScoringProfileCheapest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy != HostScoringProfiles[HostScoringProfileCheapest] {
+		t.Fatal("expected cheapest policy but got", policy)
+	}
+
+	// The weights of multiple profiles are multiplied.
+	policy, err = ComposeHostScoringPolicy(HostScoringProfileCheapest, HostScoringProfileLatencyFirst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.Name != "cheapest+latency-first" {
+		t.Fatal("wrong name", policy.Name)
+	}
+	if policy.PriceWeight != 1 || policy.CollateralWeight != 0.5 || policy.UptimeWeight != 2 || policy.PerformanceWeight != 3 {
+		t.Fatal("wrong weights", policy)
+	}
+
+	// Unknown profiles are rejected.
+	if _, err := ComposeHostScoringPolicy(HostScoringProfileDefault, "fastest"); !errors.Contains(err, ErrUnknownHostScoringProfile) {
+		t.Fatal("expected ErrUnknownHostScoringProfile but got", err)
+	}
+
+	// All profiles should be valid.
+	for _, name := range HostScoringProfileNames() {
+		if err := HostScoringProfiles[name].Validate(); err != nil {
+			t.Fatal(name, err)
+		}
+	}
+}
+
+// TestHostScoringPolicyValidate tests validating scoring policies.
+func TestHostScoringPolicyValidate(t *testing.T) {
+	policy := DefaultHostScoringPolicy
+	policy.PriceWeight = 0
+	if err := policy.Validate(); err != nil {
+		t.Fatal("a weight of 0 should be valid", err)
+	}
+	policy.Name = ""
+	if err := policy.Validate(); err == nil {
+		t.Fatal("policy without a name should be invalid")
+	}
+	for _, weight := range []float64{-1, math.NaN(), math.Inf(1)} {
+		policy := DefaultHostScoringPolicy
+		policy.UptimeWeight = weight
+		if err := policy.Validate(); err == nil {
+			t.Fatalf("weight %v should be invalid", weight)
+		}
+	}
+}
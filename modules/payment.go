@@ -73,6 +73,7 @@ type PaymentProvider interface {
 type PaymentDetails interface {
 	AccountID() AccountID
 	Amount() types.Currency
+	PaymentMethod() types.Specifier
 }
 
 // Payment identifiers
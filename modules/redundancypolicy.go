@@ -0,0 +1,155 @@
+package modules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/errors"
+)
+
+const (
+	// MaxRedundancyTiers is the maximum number of tiers a redundancy policy
+	// can have.
+	MaxRedundancyTiers = 8
+)
+
+var (
+	// ErrInvalidRedundancyTier is returned if a tier of a redundancy policy
+	// has invalid erasure coding settings.
+	ErrInvalidRedundancyTier = errors.New("invalid redundancy tier")
+
+	// ErrRedundancyTierOrder is returned if the tiers of a redundancy policy
+	// are not sorted by their age or if the first tier doesn't apply to new
+	// files.
+	ErrRedundancyTierOrder = errors.New("the first redundancy tier needs to apply after 0s and the following tiers need to apply after increasing durations")
+
+	// ErrTooManyRedundancyTiers is returned if a redundancy policy has more
+	// than MaxRedundancyTiers tiers.
+	ErrTooManyRedundancyTiers = fmt.Errorf("a redundancy policy can't have more than %v tiers", MaxRedundancyTiers)
+)
+
+type (
+	// RedundancyPolicy describes the erasure coding settings of the files
+	// within a directory. Files move to the next tier once they haven't been
+	// accessed for the duration of that tier. A policy without tiers is not
+	// set and inherits the policy of the parent directory.
+	RedundancyPolicy struct {
+		Tiers []RedundancyTier `json:"tiers"`
+	}
+
+	// RedundancyTier is a single tier of a RedundancyPolicy.
+	RedundancyTier struct {
+		// After is the duration since the last access of a file after which
+		// the tier applies to the file.
+		After        time.Duration `json:"after"`
+		DataPieces   int           `json:"datapieces"`
+		ParityPieces int           `json:"paritypieces"`
+	}
+
+	// RedundancyMigrationStatus contains information about the job that
+	// migrates files to the erasure coding settings of their redundancy
+	// policy.
+	RedundancyMigrationStatus struct {
+		Paused       bool      `json:"paused"`
+		PauseEndTime time.Time `json:"pauseendtime"`
+
+		// ActiveFile is the file that is currently being migrated. It is
+		// empty if no migration is in progress.
+		ActiveFile TurtleDexPath `json:"activefile"`
+
+		// MigratedFiles and MigratedSize are the number of files and the
+		// number of bytes that were migrated since the renter started.
+		MigratedFiles uint64 `json:"migratedfiles"`
+		MigratedSize  uint64 `json:"migratedsize"`
+	}
+)
+
+// ParseRedundancyTier parses a tier in the format 'data+parity@after', e.g.
+// '10+10@720h'. The '@after' part is optional and defaults to 0.
+func ParseRedundancyTier(str string) (RedundancyTier, error) {
+	var tier RedundancyTier
+	pieces := str
+	if i := strings.Index(str, "@"); i >= 0 {
+		after, err := time.ParseDuration(str[i+1:])
+		if err != nil {
+			return RedundancyTier{}, errors.AddContext(err, "unable to parse tier duration")
+		}
+		tier.After = after
+		pieces = str[:i]
+	}
+	split := strings.Split(pieces, "+")
+	if len(split) != 2 {
+		return RedundancyTier{}, fmt.Errorf("tier '%v' is not in the format 'data+parity@after'", str)
+	}
+	var err error
+	tier.DataPieces, err = strconv.Atoi(split[0])
+	if err != nil {
+		return RedundancyTier{}, errors.AddContext(err, "unable to parse data pieces")
+	}
+	tier.ParityPieces, err = strconv.Atoi(split[1])
+	if err != nil {
+		return RedundancyTier{}, errors.AddContext(err, "unable to parse parity pieces")
+	}
+	return tier, nil
+}
+
+// IsSet returns whether the policy has any tiers. Policies that are not set
+// inherit the policy of the parent directory.
+func (rp RedundancyPolicy) IsSet() bool {
+	return len(rp.Tiers) > 0
+}
+
+// Tier returns the tier that applies to a file that was last accessed at
+// accessTime.
+func (rp RedundancyPolicy) Tier(accessTime, now time.Time) (RedundancyTier, bool) {
+	if !rp.IsSet() {
+		return RedundancyTier{}, false
+	}
+	idle := now.Sub(accessTime)
+	tier := rp.Tiers[0]
+	for _, t := range rp.Tiers[1:] {
+		if idle < t.After {
+			break
+		}
+		tier = t
+	}
+	return tier, true
+}
+
+// Validate checks the tiers of the policy. An unset policy is valid.
+func (rp RedundancyPolicy) Validate() error {
+	if len(rp.Tiers) > MaxRedundancyTiers {
+		return ErrTooManyRedundancyTiers
+	}
+	for i, tier := range rp.Tiers {
+		if i == 0 && tier.After != 0 {
+			return ErrRedundancyTierOrder
+		}
+		if i > 0 && tier.After <= rp.Tiers[i-1].After {
+			return ErrRedundancyTierOrder
+		}
+		if _, err := tier.ErasureCode(); err != nil {
+			return errors.Compose(ErrInvalidRedundancyTier, err)
+		}
+	}
+	return nil
+}
+
+// ErasureCode returns the erasure coder files of the tier are encoded with.
+func (rt RedundancyTier) ErasureCode() (ErasureCoder, error) {
+	return NewRSSubCode(rt.DataPieces, rt.ParityPieces, crypto.SegmentSize)
+}
+
+// Matches returns whether the provided erasure coder uses the settings of the
+// tier.
+func (rt RedundancyTier) Matches(ec ErasureCoder) bool {
+	return ec.MinPieces() == rt.DataPieces && ec.NumPieces() == rt.DataPieces+rt.ParityPieces
+}
+
+// String returns the tier in the format accepted by ParseRedundancyTier.
+func (rt RedundancyTier) String() string {
+	return fmt.Sprintf("%v+%v@%v", rt.DataPieces, rt.ParityPieces, rt.After)
+}
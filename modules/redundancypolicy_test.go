@@ -0,0 +1,129 @@
+package modules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/turtledex/errors"
+)
+
+// TestParseRedundancyTier is a unit test for ParseRedundancyTier.
+func TestParseRedundancyTier(t *testing.T) {
+	tests := []struct {
+		str   string
+		tier  RedundancyTier
+		valid bool
+	}{
+		{"10+20", RedundancyTier{DataPieces: 10, ParityPieces: 20}, true},
+		{"10+10@720h", RedundancyTier{After: 720 * time.Hour, DataPieces: 10, ParityPieces: 10}, true},
+		{"1+2@1m30s", RedundancyTier{After: 90 * time.Second, DataPieces: 1, ParityPieces: 2}, true},
+		{"10", RedundancyTier{}, false},
+		{"10+", RedundancyTier{}, false},
+		{"a+10", RedundancyTier{}, false},
+		{"10+10@", RedundancyTier{}, false},
+		{"10+10@30d", RedundancyTier{}, false},
+	}
+	for _, test := range tests {
+		tier, err := ParseRedundancyTier(test.str)
+		if (err == nil) != test.valid {
+			t.Fatalf("%v: expected valid to be %v but got %v", test.str, test.valid, err)
+		}
+		if tier != test.tier {
+			t.Fatalf("%v: expected %v but got %v", test.str, test.tier, tier)
+		}
+		if !test.valid {
+			continue
+		}
+		// The string representation should parse to the same tier.
+		tier, err = ParseRedundancyTier(tier.String())
+		if err != nil || tier != test.tier {
+			t.Fatalf("%v: tier didn't survive a round trip: %v %v", test.str, tier, err)
+		}
+	}
+}
+
+// TestRedundancyPolicyTier checks that the right tier is chosen for files
+// depending on their last access.
+func TestRedundancyPolicyTier(t *testing.T) {
+	hot := RedundancyTier{DataPieces: 10, ParityPieces: 20}
+	warm := RedundancyTier{After: time.Hour, DataPieces: 10, ParityPieces: 10}
+	cold := RedundancyTier{After: 24 * time.Hour, DataPieces: 10, ParityPieces: 5}
+	rp := RedundancyPolicy{Tiers: []RedundancyTier{hot, warm, cold}}
+
+	now := time.Now()
+	tests := []struct {
+		accessTime time.Time
+		tier       RedundancyTier
+	}{
+		{now, hot},
+		{now.Add(time.Minute), hot},
+		{now.Add(-time.Minute), hot},
+		{now.Add(-time.Hour), warm},
+		{now.Add(-23 * time.Hour), warm},
+		{now.Add(-24 * time.Hour), cold},
+		{now.Add(-1000 * time.Hour), cold},
+	}
+	for _, test := range tests {
+		tier, ok := rp.Tier(test.accessTime, now)
+		if !ok || tier != test.tier {
+			t.Errorf("%v: expected %v but got %v", now.Sub(test.accessTime), test.tier, tier)
+		}
+	}
+
+	// An unset policy doesn't have a tier.
+	if _, ok := (RedundancyPolicy{}).Tier(now, now); ok {
+		t.Fatal("unset policy shouldn't return a tier")
+	}
+}
+
+// TestRedundancyPolicyValidate is a unit test for RedundancyPolicy.Validate.
+func TestRedundancyPolicyValidate(t *testing.T) {
+	hot := RedundancyTier{DataPieces: 10, ParityPieces: 20}
+	cold := RedundancyTier{After: time.Hour, DataPieces: 10, ParityPieces: 10}
+	tests := []struct {
+		tiers []RedundancyTier
+		err   error
+	}{
+		{nil, nil},
+		{[]RedundancyTier{hot}, nil},
+		{[]RedundancyTier{hot, cold}, nil},
+		{[]RedundancyTier{cold}, ErrRedundancyTierOrder},
+		{[]RedundancyTier{hot, hot}, ErrRedundancyTierOrder},
+		{[]RedundancyTier{hot, cold, cold}, ErrRedundancyTierOrder},
+		{[]RedundancyTier{{DataPieces: 0, ParityPieces: 10}}, ErrInvalidRedundancyTier},
+		{[]RedundancyTier{{DataPieces: 200, ParityPieces: 200}}, ErrInvalidRedundancyTier},
+		{make([]RedundancyTier, MaxRedundancyTiers+1), ErrTooManyRedundancyTiers},
+	}
+	for i, test := range tests {
+		err := RedundancyPolicy{Tiers: test.tiers}.Validate()
+		if (err == nil) != (test.err == nil) || (err != nil && !errors.Contains(err, test.err)) {
+			t.Fatalf("%v: expected error %v but got %v", i, test.err, err)
+		}
+	}
+}
+
+// TestRedundancyTierMatches is a unit test for RedundancyTier.Matches.
+func TestRedundancyTierMatches(t *testing.T) {
+	tier := RedundancyTier{DataPieces: 10, ParityPieces: 20}
+	ec, err := tier.ErasureCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tier.Matches(ec) {
+		t.Fatal("tier should match its own erasure coder")
+	}
+	rsc, err := NewRSCode(10, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tier.Matches(rsc) {
+		t.Fatal("tier should match erasure coders with the same number of pieces")
+	}
+	rsc, err = NewRSCode(10, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tier.Matches(rsc) {
+		t.Fatal("tier shouldn't match erasure coder with less parity pieces")
+	}
+}
@@ -167,6 +167,10 @@ const (
 	// permissions are supplied. Changing this value is a compatibility issue
 	// since users expect files to have these permissions.
 	DefaultFilePerm = 0644
+
+	// VersionRetentionDisabled is the version retention of a directory that
+	// disables versioning for its files even if a parent directory enables it.
+	VersionRetentionDisabled = -1
 )
 
 // String returns the string value for the FilterMode
@@ -296,6 +300,11 @@ type Allowance struct {
 	// period.
 	MaxPeriodChurn uint64 `json:"maxperiodchurn"`
 
+	// HostGroupTargets maps the names of host groups to the number of
+	// contracts the renter wants to hold with hosts of that group. The
+	// contracts count towards the allowance's hosts.
+	HostGroupTargets map[string]uint64 `json:"hostgrouptargets,omitempty"`
+
 	// The following fields provide price gouging protection for the user. By
 	// setting a particular maximum price for each mechanism that a host can use
 	// to charge users, the workers know to avoid hosts that go outside of the
@@ -376,6 +385,7 @@ type DirectoryInfo struct {
 	AggregateNumFiles            uint64    `json:"aggregatenumfiles"`
 	AggregateNumStuckChunks      uint64    `json:"aggregatenumstuckchunks"`
 	AggregateNumSubDirs          uint64    `json:"aggregatenumsubdirs"`
+	AggregateRedundantSize       uint64    `json:"aggregateredundantsize"`
 	AggregateRepairSize          uint64    `json:"aggregaterepairsize"`
 	AggregateSize                uint64    `json:"aggregatesize"`
 	AggregateStuckHealth         float64   `json:"aggregatestuckhealth"`
@@ -385,6 +395,10 @@ type DirectoryInfo struct {
 	AggregateSkynetFiles uint64 `json:"aggregateskynetfiles"`
 	AggregateSkynetSize  uint64 `json:"aggregateskynetsize"`
 
+	// Redundancy Migration Fields
+	AggregateNumPendingMigrations uint64 `json:"aggregatenumpendingmigrations"`
+	AggregatePendingMigrationSize uint64 `json:"aggregatependingmigrationsize"`
+
 	// The following fields are information specific to the ttdxdir that is not
 	// an aggregate of the entire sub directory tree
 	Health              float64     `json:"health"`
@@ -397,6 +411,7 @@ type DirectoryInfo struct {
 	NumFiles            uint64      `json:"numfiles"`
 	NumStuckChunks      uint64      `json:"numstuckchunks"`
 	NumSubDirs          uint64      `json:"numsubdirs"`
+	RedundantSize       uint64      `json:"redundantsize"`
 	RepairSize          uint64      `json:"repairsize"`
 	TurtleDexPath             TurtleDexPath     `json:"siapath"`
 	DirSize             uint64      `json:"size,siamismatch"` // Stays as 'size' in json for compatibility
@@ -407,6 +422,20 @@ type DirectoryInfo struct {
 	// Skynet Fields
 	SkynetFiles uint64 `json:"skynetfiles"`
 	SkynetSize  uint64 `json:"skynetsize"`
+
+	// Redundancy Migration Fields
+	NumPendingMigrations uint64 `json:"numpendingmigrations"`
+	PendingMigrationSize uint64 `json:"pendingmigrationsize"`
+
+	// Settings
+	Quota            uint64           `json:"quota"`
+	RedundancyPolicy RedundancyPolicy `json:"redundancypolicy"`
+	RedundantQuota   uint64           `json:"redundantquota"`
+	VersionRetention int64            `json:"versionretention"`
+
+	// UserMetadata is the key/value metadata attached to the directory by
+	// the user.
+	UserMetadata map[string]string `json:"usermetadata"`
 }
 
 // Name implements os.FileInfo.
@@ -463,6 +492,14 @@ type FileUploadParams struct {
 	// to create a CipherKey with the given CipherType. This value override
 	// CipherType if it is set.
 	CipherKey crypto.CipherKey
+
+	// UserMetadata is arbitrary key/value metadata that is attached to the
+	// file when it is created.
+	UserMetadata map[string]string
+
+	// BudgetIdentity is the identity whose budget is charged for the upload.
+	// Uploads without an identity are internal and aren't charged.
+	BudgetIdentity string
 }
 
 // FileInfo provides information about a file.
@@ -492,6 +529,7 @@ type FileInfo struct {
 	UID              uint64            `json:"uid"`
 	UploadedBytes    uint64            `json:"uploadedbytes"`
 	UploadProgress   float64           `json:"uploadprogress"`
+	UserMetadata     map[string]string `json:"usermetadata"`
 }
 
 // Name implements os.FileInfo.
@@ -512,6 +550,46 @@ func (f FileInfo) IsDir() bool { return false }
 // Sys implements os.FileInfo.
 func (f FileInfo) Sys() interface{} { return nil }
 
+// FileVersionInfo provides information about a prior version of a file which
+// was retained when the file was overwritten. The embedded FileInfo describes
+// the siafile of the version, which can be downloaded using its siapath.
+type FileVersionInfo struct {
+	FileInfo
+	ArchiveTime time.Time `json:"archivetime"`
+	VersionID   string    `json:"versionid"`
+}
+
+// DirectorySnapshot provides information about a named, read-only snapshot
+// of a directory tree. TurtleDexPath is the directory the snapshot was created
+// from.
+type DirectorySnapshot struct {
+	CreateTime    time.Time     `json:"createtime"`
+	Name          string        `json:"name"`
+	NumFiles      uint64        `json:"numfiles"`
+	Size          uint64        `json:"size"`
+	TurtleDexPath TurtleDexPath `json:"siapath"`
+}
+
+// DirectorySnapshotDiff lists the files of a directory which changed since a
+// snapshot of the directory was created. The files are identified by their
+// siapaths within the directory.
+type DirectorySnapshotDiff struct {
+	Added    []TurtleDexPath `json:"added"`
+	Modified []TurtleDexPath `json:"modified"`
+	Removed  []TurtleDexPath `json:"removed"`
+}
+
+// UploadSessionInfo provides information about a resumable upload session.
+// Offset is the number of bytes that were committed to the session so far and
+// is the offset at which the next write needs to start.
+type UploadSessionInfo struct {
+	CreateTime    time.Time     `json:"createtime"`
+	ExpiryTime    time.Time     `json:"expirytime"`
+	ID            string        `json:"id"`
+	Offset        uint64        `json:"offset"`
+	TurtleDexPath TurtleDexPath `json:"siapath"`
+}
+
 // A HostDBEntry represents one host entry in the Renter's host DB. It
 // aggregates the host's external settings and metrics with its public key.
 type HostDBEntry struct {
@@ -539,6 +617,17 @@ type HostDBEntry struct {
 	IPNets          []string  `json:"ipnets"`
 	LastIPNetChange time.Time `json:"lastipnetchange"`
 
+	// The network and location of the host according to the IP database of
+	// the hostdb. The fields are empty if the host wasn't found in the
+	// database.
+	ASN     uint32 `json:"asn"`
+	Country string `json:"country"`
+	Region  string `json:"region"`
+
+	// Benchmarks are the performance measurements taken while scanning the
+	// host if benchmarking is enabled in the hostdb.
+	Benchmarks HostBenchmarks `json:"benchmarks"`
+
 	// The public key of the host, stored separately to minimize risk of certain
 	// MitM based vulnerabilities.
 	PublicKey types.TurtleDexPublicKey `json:"publickey"`
@@ -564,6 +653,7 @@ type HostDBScan struct {
 type HostScoreBreakdown struct {
 	Score          types.Currency `json:"score"`
 	ConversionRate float64        `json:"conversionrate"`
+	ScoringPolicy  string         `json:"scoringpolicy"`
 
 	AcceptContractAdjustment   float64 `json:"acceptcontractadjustment"`
 	AgeAdjustment              float64 `json:"ageadjustment"`
@@ -572,12 +662,28 @@ type HostScoreBreakdown struct {
 	CollateralAdjustment       float64 `json:"collateraladjustment"`
 	DurationAdjustment         float64 `json:"durationadjustment"`
 	InteractionAdjustment      float64 `json:"interactionadjustment"`
+	PerformanceAdjustment      float64 `json:"performanceadjustment"`
 	PriceAdjustment            float64 `json:"pricesmultiplier,siamismatch"`
 	StorageRemainingAdjustment float64 `json:"storageremainingadjustment"`
 	UptimeAdjustment           float64 `json:"uptimeadjustment"`
 	VersionAdjustment          float64 `json:"versionadjustment"`
 }
 
+// DedupStatus contains information about the chunks that were deduplicated by
+// the renter.
+type DedupStatus struct {
+	// UniqueChunks is the number of distinct chunks in the dedup index.
+	UniqueChunks uint64 `json:"uniquechunks"`
+
+	// ChunkReferences is the number of chunks of files that reference the
+	// chunks in the dedup index.
+	ChunkReferences uint64 `json:"chunkreferences"`
+
+	// StorageSaved is the amount of storage in bytes that didn't need to be
+	// uploaded thanks to deduplication.
+	StorageSaved uint64 `json:"storagesaved"`
+}
+
 // MemoryStatus contains information about the status of the memory managers in
 // the renter.
 type MemoryStatus struct {
@@ -646,6 +752,14 @@ type RenterSettings struct {
 	MaxUploadSpeed   int64         `json:"maxuploadspeed"`
 	MaxDownloadSpeed int64         `json:"maxdownloadspeed"`
 	UploadsStatus    UploadsStatus `json:"uploadsstatus"`
+
+	// BandwidthSchedule contains windows during which different bandwidth
+	// limits than MaxDownloadSpeed and MaxUploadSpeed apply.
+	BandwidthSchedule BandwidthSchedule `json:"bandwidthschedule"`
+
+	HostDiversity HostDiversityConstraints `json:"hostdiversity"`
+
+	RedundancyMigrationStatus RedundancyMigrationStatus `json:"redundancymigrationstatus"`
 }
 
 // UploadsStatus contains information about the Renter's Uploads
@@ -839,6 +953,12 @@ type (
 		TotalMaintenanceCoolDown int            `json:"totalmaintenancecooldown"`
 		TotalUploadCoolDown      int            `json:"totaluploadcooldown"`
 		Workers                  []WorkerStatus `json:"workers"`
+
+		// DownloadHeapDepths is the number of chunks per priority class that
+		// are waiting in the download heap and ReadJobQueueDepths is the
+		// number of read jobs per priority class queued in all workers.
+		DownloadHeapDepths DownloadPriorityCounts `json:"downloadheapdepths"`
+		ReadJobQueueDepths DownloadPriorityCounts `json:"readjobqueuedepths"`
 	}
 
 	// WorkerStatus contains information about the status of a worker
@@ -931,7 +1051,8 @@ type (
 
 		ConsecutiveFailures uint64 `json:"consecutivefailures"`
 
-		JobQueueSize uint64 `json:"jobqueuesize"`
+		JobQueueSize   uint64                 `json:"jobqueuesize"`
+		JobQueueDepths DownloadPriorityCounts `json:"jobqueuedepths"`
 
 		RecentErr     string    `json:"recenterr"`
 		RecentErrTime time.Time `json:"recenterrtime"`
@@ -1011,6 +1132,38 @@ type Renter interface {
 	// ContractorChurnStatus returns contract churn stats for the current period.
 	ContractorChurnStatus() ContractorChurnStatus
 
+	// ContractMaintenancePlan returns what contract maintenance would do in
+	// its next iteration with the provided allowance without doing it.
+	ContractMaintenancePlan(Allowance) (ContractMaintenancePlan, error)
+
+	// SpendingHistory returns the records of the renter's spending ledger
+	// which match the filter.
+	SpendingHistory(SpendingHistoryFilter) ([]SpendingRecord, error)
+
+	// Budgets returns the budgets of the renter's API callers together with
+	// their spending in the current period.
+	Budgets() ([]RenterBudgetUsage, error)
+
+	// ChargeBudget estimates the cost of an operation transferring size bytes
+	// and charges it to the budget of the identity. ErrBudgetExhausted is
+	// returned if the budget doesn't cover the cost.
+	ChargeBudget(identity string, category BudgetCategory, size uint64) error
+
+	// RemoveBudget removes the budget of the identity.
+	RemoveBudget(identity string) error
+
+	// SetBudget sets the budget of an API caller.
+	SetBudget(RenterBudget) error
+
+	// RepairStatus returns the repair settings of the renter together with
+	// the amount of data that needs to be repaired and an estimate of how
+	// long the repairs take.
+	RepairStatus() (RepairStatus, error)
+
+	// SetRepairSettings sets the limits for repairs and whether new uploads
+	// are paused in favor of repairs.
+	SetRepairSettings(RepairSettings) error
+
 	// ContractUtility provides the contract utility for a given host key.
 	ContractUtility(pk types.TurtleDexPublicKey) (ContractUtility, bool)
 
@@ -1018,6 +1171,34 @@ type Renter interface {
 	// began.
 	CurrentPeriod() types.BlockHeight
 
+	// DedupStatus returns information about the chunks that were
+	// deduplicated by the renter.
+	DedupStatus() (DedupStatus, error)
+
+	// CreateDirSnapshot creates a read-only snapshot with the provided name
+	// of the directory at siaPath. The files of the snapshot are kept
+	// independently of the files within the directory.
+	CreateDirSnapshot(siaPath TurtleDexPath, name string) (DirectorySnapshot, error)
+
+	// DeleteDirSnapshot deletes the directory snapshot with the provided
+	// name.
+	DeleteDirSnapshot(name string) error
+
+	// DiffDirSnapshot returns the files of the snapshotted directory which
+	// changed since the snapshot was created.
+	DiffDirSnapshot(name string) (DirectorySnapshotDiff, error)
+
+	// DirSnapshots returns the renter's directory snapshots.
+	DirSnapshots() ([]DirectorySnapshot, error)
+
+	// RestoreDirSnapshot copies the files of the directory snapshot with the
+	// provided name into the new directory at siaPath.
+	RestoreDirSnapshot(name string, siaPath TurtleDexPath) error
+
+	// UploadDirSnapshot uploads the directory snapshot with the provided name
+	// to hosts as a backup of the same name.
+	UploadDirSnapshot(name string) error
+
 	// MemoryStatus returns the current status of the memory manager
 	MemoryStatus() (MemoryStatus, error)
 
@@ -1093,17 +1274,48 @@ type Renter interface {
 	// File returns information on specific file queried by user
 	File(siaPath TurtleDexPath) (FileInfo, error)
 
+	// FileVersions returns the prior versions of the file at siaPath, sorted
+	// from oldest to newest.
+	FileVersions(siaPath TurtleDexPath) ([]FileVersionInfo, error)
+
 	// FileList returns information on all of the files stored by the renter at the
 	// specified folder. The 'cached' argument specifies whether cached values
 	// should be returned or not.
 	FileList(siaPath TurtleDexPath, recursive, cached bool, flf FileListFunc) error
 
+	// SearchFiles returns the files within the directory at siaPath and its
+	// subdirectories that match the params, using the renter's search index.
+	SearchFiles(siaPath TurtleDexPath, params FileSearchParams) ([]IndexedFile, error)
+
 	// Filter returns the renter's hostdb's filterMode and filteredHosts
 	Filter() (FilterMode, map[string]types.TurtleDexPublicKey, error)
 
 	// SetFilterMode sets the renter's hostdb filter mode
 	SetFilterMode(fm FilterMode, hosts []types.TurtleDexPublicKey) error
 
+	// HostScoringPolicy returns the policy the renter's hostdb uses to score
+	// hosts.
+	HostScoringPolicy() (HostScoringPolicy, error)
+
+	// SetHostScoringPolicy sets the policy the renter's hostdb uses to score
+	// hosts.
+	SetHostScoringPolicy(policy HostScoringPolicy) error
+
+	// HostBenchmarking returns whether the renter's hostdb benchmarks hosts
+	// while scanning them.
+	HostBenchmarking() (bool, error)
+
+	// HostGroups returns the host groups of the renter's hostdb.
+	HostGroups() (map[string][]types.TurtleDexPublicKey, error)
+
+	// SetHostGroup sets the hosts of a host group of the renter's hostdb. An
+	// empty list of hosts removes the group.
+	SetHostGroup(name string, hosts []types.TurtleDexPublicKey) error
+
+	// SetHostBenchmarking enables or disables benchmarking hosts while
+	// scanning them.
+	SetHostBenchmarking(enabled bool) error
+
 	// Host provides the DB entry and score breakdown for the requested host.
 	Host(pk types.TurtleDexPublicKey) (HostDBEntry, bool, error)
 
@@ -1121,6 +1333,38 @@ type Renter interface {
 	// RenameDir changes the path of a dir.
 	RenameDir(oldPath, newPath TurtleDexPath) error
 
+	// ReplaceFile moves the file at siaPath to newTurtleDexPath, replacing any
+	// existing file there. Depending on the version retention of its
+	// directory, the replaced file is kept as a prior version.
+	ReplaceFile(siaPath, newTurtleDexPath TurtleDexPath) error
+
+	// RestoreFileVersion replaces the file at siaPath with one of its prior
+	// versions. The replaced file is retained as a version itself.
+	RestoreFileVersion(siaPath TurtleDexPath, versionID string) error
+
+	// SetDirQuota sets the maximum number of bytes that can be stored within
+	// the directory before and after redundancy. 0 means no limit.
+	SetDirQuota(siaPath TurtleDexPath, quota, redundantQuota uint64) error
+
+	// SetDirRedundancyPolicy sets the redundancy policy of the directory.
+	// Files within the directory are migrated to the erasure coding settings
+	// of the policy in the background.
+	SetDirRedundancyPolicy(siaPath TurtleDexPath, policy RedundancyPolicy) error
+
+	// SetDirVersionRetention sets the number of prior versions that are kept
+	// for files within the directory when they are overwritten. A retention
+	// of 0 inherits the setting of the parent directory and
+	// VersionRetentionDisabled disables versioning.
+	SetDirVersionRetention(siaPath TurtleDexPath, retention int64) error
+
+	// SetDirUserMetadata sets the keys of the user metadata of a directory to
+	// the values in set and removes the keys in remove.
+	SetDirUserMetadata(siaPath TurtleDexPath, set map[string]string, remove []string) error
+
+	// SetFileUserMetadata sets the keys of the user metadata of a file to the
+	// values in set and removes the keys in remove.
+	SetFileUserMetadata(siaPath TurtleDexPath, set map[string]string, remove []string) error
+
 	// EstimateHostScore will return the score for a host with the provided
 	// settings, assuming perfect age and uptime adjustments
 	EstimateHostScore(entry HostDBEntry, allowance Allowance) (HostScoreBreakdown, error)
@@ -1156,10 +1400,20 @@ type Renter interface {
 	// ResumeRepairsAndUploads resumes the renter's repairs and uploads
 	ResumeRepairsAndUploads() error
 
+	// PauseRedundancyMigration pauses the migration of files to the erasure
+	// coding settings of their redundancy policy for a time duration
+	PauseRedundancyMigration(duration time.Duration) error
+
+	// ResumeRedundancyMigration resumes the migration of files to the erasure
+	// coding settings of their redundancy policy
+	ResumeRedundancyMigration() error
+
 	// Streamer creates a io.ReadSeeker that can be used to stream downloads
 	// from the TurtleDex network and also returns the fileName of the streamed
-	// resource.
-	Streamer(siapath TurtleDexPath, disableLocalFetch bool) (string, Streamer, error)
+	// resource. The priority determines how the stream's downloads are
+	// scheduled relative to other downloads. The data that is fetched for the
+	// stream is charged to the budget of the identity.
+	Streamer(siapath TurtleDexPath, disableLocalFetch bool, priority DownloadPriority, budgetIdentity string) (string, Streamer, error)
 
 	// Upload uploads a file using the input parameters.
 	Upload(FileUploadParams) error
@@ -1168,6 +1422,24 @@ type Renter interface {
 	// reached and upload the data to the TurtleDex network.
 	UploadStreamFromReader(up FileUploadParams, reader io.Reader) error
 
+	// AbortUploadSession cancels a resumable upload session and deletes the
+	// partially uploaded file.
+	AbortUploadSession(id string) error
+
+	// CreateUploadSession creates a resumable upload session for a new file.
+	CreateUploadSession(up FileUploadParams) (UploadSessionInfo, error)
+
+	// FinalizeUploadSession uploads the remaining data of a resumable upload
+	// session and closes the session.
+	FinalizeUploadSession(id string) error
+
+	// UploadSession returns information about a resumable upload session.
+	UploadSession(id string) (UploadSessionInfo, error)
+
+	// UploadSessionWrite appends the data read from reader to a resumable
+	// upload session. The offset needs to match the session's offset.
+	UploadSessionWrite(id string, offset uint64, reader io.Reader) (UploadSessionInfo, error)
+
 	// CreateDir creates a directory for the renter
 	CreateDir(siaPath TurtleDexPath, mode os.FileMode) error
 
@@ -1216,23 +1488,26 @@ type Renter interface {
 	// given timeout will make sure this call won't block for a time that
 	// exceeds the given timeout value. Passing a timeout of 0 is considered as
 	// no timeout. The pricePerMS acts as a budget to spend on faster, and thus
-	// potentially more expensive, hosts.
-	DownloadByRoot(root crypto.Hash, offset, length uint64, timeout time.Duration, pricePerMS types.Currency) ([]byte, error)
+	// potentially more expensive, hosts. The priority determines how the
+	// download is scheduled relative to other downloads.
+	DownloadByRoot(root crypto.Hash, offset, length uint64, timeout time.Duration, pricePerMS types.Currency, priority DownloadPriority) ([]byte, error)
 
 	// DownloadSkylink will fetch a file from the TurtleDex network using the given
 	// skylink. The given timeout will make sure this call won't block for a
 	// time that exceeds the given timeout value. Passing a timeout of 0 is
 	// considered as no timeout. The pricePerMS acts as a budget to spend on
-	// faster, and thus potentially more expensive, hosts.
-	DownloadSkylink(link Skylink, timeout time.Duration, pricePerMS types.Currency) (SkyfileLayout, SkyfileMetadata, Streamer, error)
+	// faster, and thus potentially more expensive, hosts. The priority
+	// determines how the download is scheduled relative to other downloads.
+	DownloadSkylink(link Skylink, timeout time.Duration, pricePerMS types.Currency, priority DownloadPriority) (SkyfileLayout, SkyfileMetadata, Streamer, error)
 
 	// DownloadSkylinkBaseSector will take a link and turn it into the data of a
 	// download without any decoding of the metadata, fanout, or decryption. The
 	// given timeout will make sure this call won't block for a time that
 	// exceeds the given timeout value. Passing a timeout of 0 is considered as
 	// no timeout. The pricePerMS acts as a budget to spend on faster, and thus
-	// potentially more expensive, hosts.
-	DownloadSkylinkBaseSector(link Skylink, timeout time.Duration, pricePerMS types.Currency) (Streamer, error)
+	// potentially more expensive, hosts. The priority determines how the
+	// download is scheduled relative to other downloads.
+	DownloadSkylinkBaseSector(link Skylink, timeout time.Duration, pricePerMS types.Currency, priority DownloadPriority) (Streamer, error)
 
 	// UploadSkyfile will upload data to the TurtleDex network from a reader and
 	// create a skyfile, returning the skylink that can be used to access the
@@ -1299,6 +1574,14 @@ type RenterDownloadParameters struct {
 	TurtleDexPath          TurtleDexPath
 	Destination      string
 	DisableDiskFetch bool
+
+	// Priority is the priority class of the download. If it is not set, the
+	// download has normal priority.
+	Priority DownloadPriority
+
+	// BudgetIdentity is the identity whose budget is charged for the
+	// download. Downloads without an identity aren't charged.
+	BudgetIdentity string
 }
 
 // HealthPercentage returns the health in a more human understandable format out
@@ -1353,9 +1636,17 @@ type HostDB interface {
 	// ones that violate the rules of the addressFilter.
 	CheckForIPViolations([]types.TurtleDexPublicKey) ([]types.TurtleDexPublicKey, error)
 
+	// Benchmarking returns whether the hostdb benchmarks hosts while scanning
+	// them.
+	Benchmarking() (bool, error)
+
 	// Close closes the hostdb.
 	Close() error
 
+	// DiversityConstraints returns the constraints RandomHosts enforces to
+	// spread the selected hosts across networks and locations.
+	DiversityConstraints() (HostDiversityConstraints, error)
+
 	// EstimateHostScore returns the estimated score breakdown of a host with the
 	// provided settings.
 	EstimateHostScore(HostDBEntry, Allowance) (HostScoreBreakdown, error)
@@ -1369,6 +1660,10 @@ type HostDB interface {
 	// Host returns the HostDBEntry for a given host.
 	Host(pk types.TurtleDexPublicKey) (HostDBEntry, bool, error)
 
+	// HostGroups returns the names of the host groups mapped to the public
+	// keys of their hosts.
+	HostGroups() (map[string][]types.TurtleDexPublicKey, error)
+
 	// IncrementSuccessfulInteractions increments the number of successful
 	// interactions with a host for a given key
 	IncrementSuccessfulInteractions(types.TurtleDexPublicKey) error
@@ -1395,6 +1690,10 @@ type HostDB interface {
 	// renter.
 	RandomHostsWithAllowance(int, []types.TurtleDexPublicKey, []types.TurtleDexPublicKey, Allowance) ([]HostDBEntry, error)
 
+	// RandomHostsInGroup is the same as RandomHosts but only returns hosts of
+	// the host group with the provided name.
+	RandomHostsInGroup(string, int, []types.TurtleDexPublicKey, []types.TurtleDexPublicKey) ([]HostDBEntry, error)
+
 	// ScoreBreakdown returns a detailed explanation of the various properties
 	// of the host.
 	ScoreBreakdown(HostDBEntry) (HostScoreBreakdown, error)
@@ -1404,10 +1703,33 @@ type HostDB interface {
 	// it should be used with care.
 	SetAllowance(Allowance) error
 
+	// SetBenchmarking enables or disables benchmarking hosts while scanning
+	// them. Throughput is only sampled if a HostThroughputSampler is set.
+	SetBenchmarking(enabled bool) error
+
+	// SetDiversityConstraints sets the constraints RandomHosts enforces to
+	// spread the selected hosts across networks and locations.
+	SetDiversityConstraints(HostDiversityConstraints) error
+
+	// SetHostGroup sets the hosts of the host group with the provided name.
+	// An empty list of hosts removes the group.
+	SetHostGroup(string, []types.TurtleDexPublicKey) error
+
 	// SetIPViolationCheck enables/disables the IP violation check within the
 	// hostdb.
 	SetIPViolationCheck(enabled bool) error
 
+	// ScoringPolicy returns the policy the hostdb uses to score hosts.
+	ScoringPolicy() (HostScoringPolicy, error)
+
+	// SetScoringPolicy sets the policy the hostdb uses to score hosts and
+	// rebuilds the hosttree with it.
+	SetScoringPolicy(HostScoringPolicy) error
+
+	// SetThroughputSampler sets the sampler used to measure the throughput of
+	// hosts the renter has a contract with.
+	SetThroughputSampler(HostThroughputSampler)
+
 	// UpdateContracts rebuilds the knownContracts of the HostBD using the provided
 	// contracts.
 	UpdateContracts([]RenterContract) error
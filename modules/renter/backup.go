@@ -45,12 +45,13 @@ func (r *Renter) CreateBackup(dst string, secret []byte) error {
 		return err
 	}
 	defer r.tg.Done()
-	return r.managedCreateBackup(dst, secret)
+	return r.managedCreateBackup(dst, modules.UserFolder, secret)
 }
 
-// managedCreateBackup creates a backup of the renter's siafiles. If a secret is
-// not nil, the backup will be encrypted using the provided secret.
-func (r *Renter) managedCreateBackup(dst string, secret []byte) (err error) {
+// managedCreateBackup creates a backup of the siafiles within the dir at root.
+// If a secret is not nil, the backup will be encrypted using the provided
+// secret.
+func (r *Renter) managedCreateBackup(dst string, root modules.TurtleDexPath, secret []byte) (err error) {
 	// Create the gzip file.
 	f, err := os.Create(dst)
 	if err != nil {
@@ -101,7 +102,7 @@ func (r *Renter) managedCreateBackup(dst string, secret []byte) (err error) {
 	// Wrap the gzip writer into a tar writer.
 	tw := tar.NewWriter(gzw)
 	// Add the files to the archive.
-	if err := r.managedTarTurtleDexFiles(tw); err != nil {
+	if err := r.managedTarTurtleDexFiles(tw, root); err != nil {
 		twErr := tw.Close()
 		gzwErr := gzw.Close()
 		return errors.Compose(err, twErr, gzwErr)
@@ -234,12 +235,11 @@ func (r *Renter) LoadBackup(src string, secret []byte) (err error) {
 	return nil
 }
 
-// managedTarTurtleDexFiles creates a tarball from the renter's siafiles and writes
-// it to dst.
-func (r *Renter) managedTarTurtleDexFiles(tw *tar.Writer) error {
-	// Walk over all the siafiles in in the user's home and add them to the
-	// tarball.
-	return r.staticFileSystem.Walk(modules.UserFolder, func(path string, info os.FileInfo, statErr error) (err error) {
+// managedTarTurtleDexFiles creates a tarball from the siafiles within the dir
+// at root and writes it to dst.
+func (r *Renter) managedTarTurtleDexFiles(tw *tar.Writer, root modules.TurtleDexPath) error {
+	// Walk over all the siafiles in root and add them to the tarball.
+	return r.staticFileSystem.Walk(root, func(path string, info os.FileInfo, statErr error) (err error) {
 		// This error is non-nil if filepath.Walk couldn't stat a file or
 		// folder.
 		if statErr != nil {
@@ -255,7 +255,7 @@ func (r *Renter) managedTarTurtleDexFiles(tw *tar.Writer) error {
 		if err != nil {
 			return err
 		}
-		relPath := strings.TrimPrefix(path, r.staticFileSystem.DirPath(modules.UserFolder))
+		relPath := strings.TrimPrefix(path, r.staticFileSystem.DirPath(root))
 		header.Name = relPath
 		// If the info is a dir there is nothing more to do besides writing the
 		// header.
@@ -266,7 +266,7 @@ func (r *Renter) managedTarTurtleDexFiles(tw *tar.Writer) error {
 		var file io.Reader
 		if filepath.Ext(path) == modules.TurtleDexFileExtension {
 			// Get the siafile.
-			siaPath, err := modules.UserFolder.Join(strings.TrimSuffix(relPath, modules.TurtleDexFileExtension))
+			siaPath, err := root.Join(strings.TrimSuffix(relPath, modules.TurtleDexFileExtension))
 			if err != nil {
 				return err
 			}
@@ -298,9 +298,9 @@ func (r *Renter) managedTarTurtleDexFiles(tw *tar.Writer) error {
 			var siaPath modules.TurtleDexPath
 			siaPathStr := strings.TrimSuffix(relPath, modules.TurtleDexDirExtension)
 			if siaPathStr == string(filepath.Separator) {
-				siaPath = modules.UserFolder
+				siaPath = root
 			} else {
-				siaPath, err = modules.UserFolder.Join(siaPathStr)
+				siaPath, err = root.Join(siaPathStr)
 				if err != nil {
 					return err
 				}
@@ -0,0 +1,292 @@
+package renter
+
+// Budgets limit the spending of the individual callers of the renter's API. A
+// caller is identified by its API token or its user agent. Callers without a
+// budget of their own share the default budget. Before the data of an upload
+// or download is handed to the workers, its cost is estimated from the price
+// tables of the workers and charged to the caller's budget. Uploads of unknown
+// size, like streams, are charged one chunk at a time. Registry operations are
+// charged before they are started. If the budget doesn't cover the cost, the
+// operation is refused before any jobs are queued in the worker pool. The
+// spending of a budget is reset at the start of every period.
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/persist"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+const (
+	// budgetsPersistFile is the name of the file the budgets are persisted
+	// to.
+	budgetsPersistFile = "budgets.json"
+
+	// budgetsPersistVersion is the version of the budgets' persist file.
+	budgetsPersistVersion = "1.5.5"
+)
+
+var (
+	// budgetsMetadata is the metadata of the budgets' persist file.
+	budgetsMetadata = persist.Metadata{
+		Header:  "Renter Budgets",
+		Version: budgetsPersistVersion,
+	}
+
+	// budgetsSaveInterval is the minimum amount of time between saving the
+	// budgets after charging them. Saving them for every charge would slow
+	// down operations which are cheap, like registry lookups. The budgets are
+	// always saved when the renter shuts down.
+	budgetsSaveInterval = build.Select(build.Var{
+		Standard: time.Minute,
+		Dev:      10 * time.Second,
+		Testing:  time.Second,
+	}).(time.Duration)
+)
+
+type (
+	// budgetsPersist is the persisted state of the budgets.
+	budgetsPersist struct {
+		Budgets map[string]modules.RenterBudgetUsage `json:"budgets"`
+	}
+
+	// budgetTracker keeps track of the budgets of the renter's API callers
+	// and what they spent in the current period.
+	budgetTracker struct {
+		lastSave   time.Time
+		persist    budgetsPersist
+		staticPath string
+		mu         sync.Mutex
+	}
+)
+
+// newBudgetTracker loads the budgets from disk or creates a new budget
+// tracker.
+func newBudgetTracker(persistDir string) (*budgetTracker, error) {
+	bt := &budgetTracker{
+		lastSave: time.Now(),
+		persist: budgetsPersist{
+			Budgets: make(map[string]modules.RenterBudgetUsage),
+		},
+		staticPath: filepath.Join(persistDir, budgetsPersistFile),
+	}
+	err := persist.LoadJSON(budgetsMetadata, &bt.persist, bt.staticPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.AddContext(err, "failed to load budgets")
+	}
+	if bt.persist.Budgets == nil {
+		bt.persist.Budgets = make(map[string]modules.RenterBudgetUsage)
+	}
+	return bt, nil
+}
+
+// resetSpendingForPeriod resets the spending of the usage if it was tracked in
+// a previous period.
+func resetSpendingForPeriod(usage modules.RenterBudgetUsage, periodStart types.BlockHeight) modules.RenterBudgetUsage {
+	if usage.PeriodStart == periodStart {
+		return usage
+	}
+	return modules.RenterBudgetUsage{
+		RenterBudget: usage.RenterBudget,
+		PeriodStart:  periodStart,
+	}
+}
+
+// save saves the budgets to disk.
+func (bt *budgetTracker) save() error {
+	bt.lastSave = time.Now()
+	return persist.SaveJSON(budgetsMetadata, bt.persist, bt.staticPath)
+}
+
+// managedSave saves the budgets to disk.
+func (bt *budgetTracker) managedSave() error {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+	return bt.save()
+}
+
+// managedBudgets returns the budgets sorted by identity.
+func (bt *budgetTracker) managedBudgets(periodStart types.BlockHeight) []modules.RenterBudgetUsage {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+	budgets := make([]modules.RenterBudgetUsage, 0, len(bt.persist.Budgets))
+	for _, usage := range bt.persist.Budgets {
+		budgets = append(budgets, resetSpendingForPeriod(usage, periodStart))
+	}
+	sort.Slice(budgets, func(i, j int) bool {
+		return budgets[i].Identity < budgets[j].Identity
+	})
+	return budgets
+}
+
+// managedCharge charges cost to the budget of the identity. Identities without
+// a budget of their own are charged to the default budget and are only
+// unlimited if there is no default budget either. If the budgets can't be
+// saved after charging them, the charge is rolled back.
+func (bt *budgetTracker) managedCharge(identity string, category modules.BudgetCategory, cost types.Currency, periodStart types.BlockHeight) error {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+	if _, exists := bt.persist.Budgets[identity]; !exists {
+		identity = modules.DefaultBudgetIdentity
+	}
+	prevUsage, exists := bt.persist.Budgets[identity]
+	if !exists {
+		return nil
+	}
+	usage := resetSpendingForPeriod(prevUsage, periodStart)
+	if err := usage.Charge(category, cost); err != nil {
+		return err
+	}
+	bt.persist.Budgets[identity] = usage
+	if cost.IsZero() || time.Since(bt.lastSave) < budgetsSaveInterval {
+		return nil
+	}
+	if err := bt.save(); err != nil {
+		bt.persist.Budgets[identity] = prevUsage
+		return errors.AddContext(err, "unable to save budgets")
+	}
+	return nil
+}
+
+// managedRemoveBudget removes the budget of the identity.
+func (bt *budgetTracker) managedRemoveBudget(identity string) error {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+	if _, exists := bt.persist.Budgets[identity]; !exists {
+		return errors.New("no budget found for identity " + identity)
+	}
+	delete(bt.persist.Budgets, identity)
+	return bt.save()
+}
+
+// managedSetBudget adds a budget or updates the limits of an existing one.
+// Updating the limits doesn't reset the spending.
+func (bt *budgetTracker) managedSetBudget(budget modules.RenterBudget, periodStart types.BlockHeight) error {
+	if budget.Identity == "" {
+		return modules.ErrEmptyBudgetIdentity
+	}
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+	usage := bt.persist.Budgets[budget.Identity]
+	usage = resetSpendingForPeriod(usage, periodStart)
+	usage.RenterBudget = budget
+	bt.persist.Budgets[budget.Identity] = usage
+	return bt.save()
+}
+
+// Budgets returns the budgets of the renter's API callers together with their
+// spending in the current period.
+func (r *Renter) Budgets() ([]modules.RenterBudgetUsage, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+	return r.staticBudgets.managedBudgets(r.hostContractor.CurrentPeriod()), nil
+}
+
+// ChargeBudget estimates the cost of an operation and charges it to the budget
+// of the identity. For uploads and downloads, size is the number of bytes that
+// are transferred. For registry operations it is ignored. If the budget doesn't
+// cover the cost, modules.ErrBudgetExhausted is returned.
+func (r *Renter) ChargeBudget(identity string, category modules.BudgetCategory, size uint64) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.managedChargeBudget(identity, category, size)
+}
+
+// RemoveBudget removes the budget of the identity.
+func (r *Renter) RemoveBudget(identity string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.staticBudgets.managedRemoveBudget(identity)
+}
+
+// SetBudget sets the budget of an API caller.
+func (r *Renter) SetBudget(budget modules.RenterBudget) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.staticBudgets.managedSetBudget(budget, r.hostContractor.CurrentPeriod())
+}
+
+// managedChargeBudget estimates the cost of an operation transferring size
+// bytes and charges it to the budget of the identity. Operations without an
+// identity are started by the renter itself and aren't charged.
+func (r *Renter) managedChargeBudget(identity string, category modules.BudgetCategory, size uint64) error {
+	if identity == "" {
+		return nil
+	}
+	cost, err := r.managedEstimateBudgetCost(category, size)
+	if err != nil {
+		return err
+	}
+	return r.staticBudgets.managedCharge(identity, category, cost, r.hostContractor.CurrentPeriod())
+}
+
+// managedCheckBudget returns modules.ErrBudgetExhausted if the budget of the
+// identity for the category is used up already. It allows for refusing
+// operations of unknown size early.
+func (r *Renter) managedCheckBudget(identity string, category modules.BudgetCategory) error {
+	if identity == "" {
+		return nil
+	}
+	return r.staticBudgets.managedCharge(identity, category, types.ZeroCurrency, r.hostContractor.CurrentPeriod())
+}
+
+// managedEstimateBudgetCost estimates the cost of an operation using the price
+// tables of the workers. Uploads and downloads are estimated using the average
+// prices of the workers and uploads assume the default redundancy. Registry
+// operations are sent to all workers, so their cost is the sum over all
+// workers.
+func (r *Renter) managedEstimateBudgetCost(category modules.BudgetCategory, size uint64) (types.Currency, error) {
+	if _, err := (modules.RenterBudget{}).Limit(category); err != nil {
+		return types.ZeroCurrency, err
+	}
+	ec := modules.NewRSSubCodeDefault()
+	numSectors := numChunksForSize(size, ec) * uint64(ec.NumPieces())
+	period := r.hostContractor.Allowance().Period
+
+	var total types.Currency
+	var numWorkers uint64
+	for _, w := range r.staticWorkerPool.callWorkers() {
+		wpt := w.staticPriceTable()
+		if !wpt.staticValid() {
+			continue
+		}
+		pt := wpt.staticPriceTable
+		numWorkers++
+		switch category {
+		case modules.BudgetCategoryDownload:
+			total = total.Add(modules.MDMReadCost(&pt, size)).Add(modules.MDMBandwidthCost(pt, 0, size))
+		case modules.BudgetCategoryUpload:
+			appendCost, _ := modules.MDMAppendCost(&pt, period)
+			bandwidthCost := modules.MDMBandwidthCost(pt, modules.SectorSize, 0)
+			total = total.Add(appendCost.Add(bandwidthCost).Mul64(numSectors))
+		case modules.BudgetCategoryRegistry:
+			readCost, _ := modules.MDMReadRegistryCost(&pt)
+			updateCost, _ := modules.MDMUpdateRegistryCost(&pt)
+			if readCost.Cmp(updateCost) > 0 {
+				total = total.Add(readCost)
+			} else {
+				total = total.Add(updateCost)
+			}
+		}
+	}
+	if numWorkers == 0 || category == modules.BudgetCategoryRegistry {
+		return total, nil
+	}
+	return total.Div64(numWorkers), nil
+}
@@ -0,0 +1,135 @@
+package renter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+// TestBudgetTracker tests setting, charging, resetting and persisting budgets.
+func TestBudgetTracker(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	bt, err := newBudgetTracker(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a default budget, identities without a budget are not
+	// limited.
+	if err := bt.managedCharge("app", modules.BudgetCategoryUpload, types.NewCurrency64(1000), 0); err != nil {
+		t.Fatal(err)
+	}
+	if len(bt.managedBudgets(0)) != 0 {
+		t.Fatal("charge shouldn't create a budget")
+	}
+
+	// Budgets need an identity.
+	if err := bt.managedSetBudget(modules.RenterBudget{}, 0); !errors.Contains(err, modules.ErrEmptyBudgetIdentity) {
+		t.Fatal("expected ErrEmptyBudgetIdentity", err)
+	}
+
+	// Set a budget and exhaust it.
+	budget := modules.RenterBudget{
+		Identity: "app",
+		Registry: types.NewCurrency64(10),
+	}
+	if err := bt.managedSetBudget(budget, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := bt.managedCharge("app", modules.BudgetCategoryRegistry, types.NewCurrency64(10), 0); err != nil {
+		t.Fatal(err)
+	}
+	err = bt.managedCharge("app", modules.BudgetCategoryRegistry, types.NewCurrency64(1), 0)
+	if !errors.Contains(err, modules.ErrBudgetExhausted) {
+		t.Fatal("expected ErrBudgetExhausted", err)
+	}
+
+	// Raising the limit keeps the spending.
+	budget.Registry = types.NewCurrency64(20)
+	if err := bt.managedSetBudget(budget, 0); err != nil {
+		t.Fatal(err)
+	}
+	budgets := bt.managedBudgets(0)
+	if len(budgets) != 1 || !budgets[0].RegistrySpending.Equals64(10) || !budgets[0].Registry.Equals64(20) {
+		t.Fatal("unexpected budgets", budgets)
+	}
+
+	// Reload the budgets.
+	if err := bt.managedSave(); err != nil {
+		t.Fatal(err)
+	}
+	bt, err = newBudgetTracker(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	budgets = bt.managedBudgets(0)
+	if len(budgets) != 1 || budgets[0].Identity != "app" || !budgets[0].RegistrySpending.Equals64(10) {
+		t.Fatal("unexpected budgets after reload", budgets)
+	}
+
+	// The spending is reset in a new period.
+	budgets = bt.managedBudgets(100)
+	if !budgets[0].RegistrySpending.IsZero() || budgets[0].PeriodStart != 100 {
+		t.Fatal("spending wasn't reset", budgets)
+	}
+	if err := bt.managedCharge("app", modules.BudgetCategoryRegistry, types.NewCurrency64(20), 100); err != nil {
+		t.Fatal(err)
+	}
+
+	// Remove the budget.
+	if err := bt.managedRemoveBudget("app"); err != nil {
+		t.Fatal(err)
+	}
+	if err := bt.managedRemoveBudget("app"); err == nil {
+		t.Fatal("removing a missing budget should fail")
+	}
+	if len(bt.managedBudgets(100)) != 0 {
+		t.Fatal("budget wasn't removed")
+	}
+
+	// Identities without a budget of their own share the default budget.
+	budget = modules.RenterBudget{
+		Identity: modules.DefaultBudgetIdentity,
+		Upload:   types.NewCurrency64(10),
+	}
+	if err := bt.managedSetBudget(budget, 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := bt.managedCharge("app", modules.BudgetCategoryUpload, types.NewCurrency64(6), 100); err != nil {
+		t.Fatal(err)
+	}
+	err = bt.managedCharge("other app", modules.BudgetCategoryUpload, types.NewCurrency64(6), 100)
+	if !errors.Contains(err, modules.ErrBudgetExhausted) {
+		t.Fatal("expected ErrBudgetExhausted", err)
+	}
+	budgets = bt.managedBudgets(100)
+	if len(budgets) != 1 || !budgets[0].UploadSpending.Equals64(6) {
+		t.Fatal("unexpected budgets", budgets)
+	}
+
+	// A charge is rolled back if the budgets can't be saved.
+	bt.staticPath = filepath.Join(dir, "missing", budgetsPersistFile)
+	bt.lastSave = time.Time{}
+	if err := bt.managedCharge("app", modules.BudgetCategoryUpload, types.NewCurrency64(1), 100); err == nil {
+		t.Fatal("charge should fail if the budgets can't be saved")
+	}
+	budgets = bt.managedBudgets(100)
+	if !budgets[0].UploadSpending.Equals64(6) {
+		t.Fatal("charge wasn't rolled back", budgets)
+	}
+}
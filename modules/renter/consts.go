@@ -21,6 +21,17 @@ const (
 	// AlertTurtleDexfileLowRedundancyThreshold is the health threshold at which we start
 	// registering the LowRedundancy alert for a TurtleDexfile.
 	AlertTurtleDexfileLowRedundancyThreshold = 0.75
+
+	// AlertMSGDirQuota indicates that a dir is using more than 90% of its
+	// quota.
+	AlertMSGDirQuota = "The directory mentioned in the 'Cause' is using more than 90% of its quota"
+	// AlertDirQuotaThreshold is the fraction of a dir's quota at which we
+	// start registering the DirQuota alert.
+	AlertDirQuotaThreshold = 0.9
+
+	// AlertMSGFuseStagedWrites indicates that staged writes of a fuse mount
+	// from a previous session were found which might not have been committed.
+	AlertMSGFuseStagedWrites = "Writes to a fuse mount of a previous session might not have been uploaded"
 )
 
 // AlertCauseTurtleDexfileLowRedundancy creates a customized "cause" for a siafile
@@ -29,6 +40,21 @@ func AlertCauseTurtleDexfileLowRedundancy(siaPath modules.TurtleDexPath, health,
 	return fmt.Sprintf("TurtleDexfile '%v' has a health of %v and redundancy of %v", siaPath.String(), health, redundancy)
 }
 
+// AlertCauseDirQuota creates a customized "cause" for a dir with a certain
+// path that uses usage bytes of its quota.
+func AlertCauseDirQuota(siaPath modules.TurtleDexPath, usage, quota uint64, redundant bool) string {
+	if redundant {
+		return fmt.Sprintf("Directory '%v' uses %v of its redundant quota of %v bytes", siaPath.String(), usage, quota)
+	}
+	return fmt.Sprintf("Directory '%v' uses %v of its quota of %v bytes", siaPath.String(), usage, quota)
+}
+
+// AlertCauseFuseStagedWrites creates a customized "cause" for the staging
+// files of fuse mounts that were moved to dir.
+func AlertCauseFuseStagedWrites(files int, dir string) string {
+	return fmt.Sprintf("%v staging files were left behind and moved to '%v'", files, dir)
+}
+
 // Default redundancy parameters.
 var (
 	// syncCheckInterval is how often the repair heap checks the consensus code
@@ -272,6 +298,24 @@ var (
 		Testing:  250 * time.Millisecond,
 	}).(time.Duration)
 
+	// redundancyMigrationErrorSleepDuration indicates how long the redundancy
+	// migration loop should sleep before retrying if there is an error
+	// preventing progress.
+	redundancyMigrationErrorSleepDuration = build.Select(build.Var{
+		Dev:      10 * time.Second,
+		Standard: 5 * time.Minute,
+		Testing:  3 * time.Second,
+	}).(time.Duration)
+
+	// redundancyMigrationInterval is how long the redundancy migration loop
+	// sleeps before checking for files that need to be migrated again after it
+	// found no work or yielded to the repair loop.
+	redundancyMigrationInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: 30 * time.Minute,
+		Testing:  2 * time.Second,
+	}).(time.Duration)
+
 	// repairLoopResetFrequency is the frequency with which the repair loop will
 	// reset entirely, pushing the root directory back on top. This is a
 	// temporary measure to ensure that even if a user is continuously
@@ -361,6 +405,24 @@ const (
 	// PriceEstimationSafetyFactor is the factor of safety used in the price
 	// estimation to account for any missed costs
 	PriceEstimationSafetyFactor = 1.2
+
+	// throughputSampleSize is the number of bytes read from a host to sample
+	// its read throughput for the hostdb's benchmarks.
+	throughputSampleSize = 1 << 16 // 64 KiB
+
+	// throughputSampleTimeout is the amount of time a throughput sample may
+	// take before it is considered failed.
+	throughputSampleTimeout = 30 * time.Second
+)
+
+var (
+	// throughputSampleInterval is the minimum amount of time between two paid
+	// throughput samples of the same host.
+	throughputSampleInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: 6 * time.Hour,
+		Testing:  time.Second,
+	}).(time.Duration)
 )
 
 // Deprecated consts.
@@ -66,24 +66,8 @@ func (c *Contractor) SetAllowance(a modules.Allowance) error {
 	}
 
 	// sanity checks
-	if a.Funds.Cmp(types.ZeroCurrency) <= 0 {
-		return ErrAllowanceZeroFunds
-	} else if a.Hosts == 0 {
-		return ErrAllowanceNoHosts
-	} else if a.Period == 0 {
-		return ErrAllowanceZeroPeriod
-	} else if a.RenewWindow == 0 {
-		return ErrAllowanceZeroWindow
-	} else if a.ExpectedStorage == 0 {
-		return ErrAllowanceZeroExpectedStorage
-	} else if a.ExpectedUpload == 0 {
-		return ErrAllowanceZeroExpectedUpload
-	} else if a.ExpectedDownload == 0 {
-		return ErrAllowanceZeroExpectedDownload
-	} else if a.ExpectedRedundancy == 0 {
-		return ErrAllowanceZeroExpectedRedundancy
-	} else if a.MaxPeriodChurn == 0 {
-		return ErrAllowanceZeroMaxPeriodChurn
+	if err := checkAllowance(a); err != nil {
+		return err
 	} else if !c.cs.Synced() {
 		return errAllowanceNotSynced
 	}
@@ -164,6 +148,31 @@ func (c *Contractor) SetAllowance(a modules.Allowance) error {
 	return nil
 }
 
+// checkAllowance checks that all fields of a non-empty allowance are set to
+// valid values.
+func checkAllowance(a modules.Allowance) error {
+	if a.Funds.Cmp(types.ZeroCurrency) <= 0 {
+		return ErrAllowanceZeroFunds
+	} else if a.Hosts == 0 {
+		return ErrAllowanceNoHosts
+	} else if a.Period == 0 {
+		return ErrAllowanceZeroPeriod
+	} else if a.RenewWindow == 0 {
+		return ErrAllowanceZeroWindow
+	} else if a.ExpectedStorage == 0 {
+		return ErrAllowanceZeroExpectedStorage
+	} else if a.ExpectedUpload == 0 {
+		return ErrAllowanceZeroExpectedUpload
+	} else if a.ExpectedDownload == 0 {
+		return ErrAllowanceZeroExpectedDownload
+	} else if a.ExpectedRedundancy == 0 {
+		return ErrAllowanceZeroExpectedRedundancy
+	} else if a.MaxPeriodChurn == 0 {
+		return ErrAllowanceZeroMaxPeriodChurn
+	}
+	return a.ValidateHostGroupTargets()
+}
+
 // managedCancelAllowance handles the special case where the allowance is empty.
 func (c *Contractor) managedCancelAllowance() error {
 	c.log.Println("INFO: canceling allowance")
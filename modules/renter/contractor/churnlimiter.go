@@ -139,27 +139,20 @@ func (cl *churnLimiter) managedMaxChurnBudget() int {
 // inputs are assumed to be contracts that have passed all critical utility
 // checks.
 func (cl *churnLimiter) managedProcessSuggestedUpdates(queue []contractScoreAndUtil) error {
-	sort.Slice(queue, func(i, j int) bool {
-		return queue[i].score.Cmp(queue[j].score) < 0
-	})
-
-	var queuedContract contractScoreAndUtil
-	for len(queue) > 0 {
-		queuedContract, queue = queue[0], queue[1:]
+	maxPeriodChurn := cl.managedMaxPeriodChurn()
+	maxChurnBudget := cl.managedMaxChurnBudget()
+	cl.mu.Lock()
+	remainingChurnBudget, aggregateChurn := cl.remainingChurnBudget, cl.aggregateCurrentPeriodChurn
+	cl.mu.Unlock()
+	churned, avoided := churnSuggestedUpdates(queue, remainingChurnBudget, maxChurnBudget, aggregateChurn, maxPeriodChurn)
 
-		// Churn a contract if it went from GFR in the previous util
-		// (queuedContract.contract.Utility) to !GFR in the suggested util
-		// (queuedContract.util) and the churnLimit has not been reached.
-		turnedNotGFR := queuedContract.contract.Utility.GoodForRenew && !queuedContract.util.GoodForRenew
-		churningThisContract := turnedNotGFR && cl.managedCanChurnContract(queuedContract.contract)
-		if turnedNotGFR && !churningThisContract {
+	for i, queuedContract := range queue {
+		if avoided[i] {
 			cl.contractor.log.Debugln("Avoiding churn on contract: ", queuedContract.contract.ID)
 			currentBudget, periodBudget := cl.managedChurnBudget()
 			cl.contractor.log.Debugf("Remaining Churn Budget: %d. Remaining Period Budget: %d", currentBudget, periodBudget)
-			queuedContract.util.GoodForRenew = true
 		}
-
-		if churningThisContract {
+		if churned[i] {
 			cl.contractor.log.Println("Churning contract for bad score: ", queuedContract.contract.ID, queuedContract.score)
 		}
 
@@ -172,6 +165,45 @@ func (cl *churnLimiter) managedProcessSuggestedUpdates(queue []contractScoreAndU
 	return nil
 }
 
+// churnSuggestedUpdates sorts the queue of suggested utility updates by score
+// and decides which contracts are churned with the provided churn budget. A
+// contract is churned if it went from GFR in its previous utility to !GFR in
+// the suggested utility and the churn limit has not been reached. Otherwise the
+// suggested utility is changed to keep the contract GFR. The returned slices
+// indicate which contracts of the sorted queue are churned and for which
+// contracts churn was avoided.
+func churnSuggestedUpdates(queue []contractScoreAndUtil, remainingChurnBudget, maxChurnBudget int, aggregateCurrentPeriodChurn, maxPeriodChurn uint64) (churned, avoided []bool) {
+	sort.Slice(queue, func(i, j int) bool {
+		return queue[i].score.Cmp(queue[j].score) < 0
+	})
+	churned = make([]bool, len(queue))
+	avoided = make([]bool, len(queue))
+	for i := range queue {
+		if !queue[i].contract.Utility.GoodForRenew || queue[i].util.GoodForRenew {
+			continue
+		}
+		size := contractSize(queue[i].contract)
+		if !canChurn(size, remainingChurnBudget, maxChurnBudget, aggregateCurrentPeriodChurn, maxPeriodChurn) {
+			queue[i].util.GoodForRenew = true
+			avoided[i] = true
+			continue
+		}
+		// Churning the contract uses up the budget.
+		remainingChurnBudget -= int(size)
+		aggregateCurrentPeriodChurn += size
+		churned[i] = true
+	}
+	return churned, avoided
+}
+
+// contractSize returns the size of the data stored in the contract.
+func contractSize(contract modules.RenterContract) uint64 {
+	if len(contract.Transaction.FileContractRevisions) == 0 {
+		return 0
+	}
+	return contract.Transaction.FileContractRevisions[0].NewFileSize
+}
+
 // managedChurnBudget returns the current remaining churn budget, and the remaining
 // budget for the period.
 func (cl *churnLimiter) managedChurnBudget() (int, int) {
@@ -198,17 +230,22 @@ func (cl *churnLimiter) managedCanChurnContract(contract modules.RenterContract)
 	maxChurnBudget := cl.managedMaxChurnBudget()
 	cl.mu.Lock()
 	defer cl.mu.Unlock()
+	return canChurn(size, cl.remainingChurnBudget, maxChurnBudget, cl.aggregateCurrentPeriodChurn, maxPeriodChurn)
+}
 
+// canChurn returns true if and only if a contract of the given size can be
+// churned with the provided budgets.
+func canChurn(size uint64, remainingChurnBudget, maxChurnBudget int, aggregateCurrentPeriodChurn, maxPeriodChurn uint64) bool {
 	// Allow any size contract to be churned if the current budget is the max
 	// budget. This allows large contracts to be churned if there is enough budget
 	// remaining for the period, even if the contract is larger than the
 	// maxChurnBudget.
-	fitsInCurrentBudget := (cl.remainingChurnBudget-int(size) >= 0) || (cl.remainingChurnBudget == maxChurnBudget)
-	fitsInPeriodBudget := (int(maxPeriodChurn) - int(cl.aggregateCurrentPeriodChurn) - int(size)) >= 0
+	fitsInCurrentBudget := (remainingChurnBudget-int(size) >= 0) || (remainingChurnBudget == maxChurnBudget)
+	fitsInPeriodBudget := (int(maxPeriodChurn) - int(aggregateCurrentPeriodChurn) - int(size)) >= 0
 
 	// If there has been no churn in this period, allow any size contract to be
 	// churned.
-	fitsInPeriodBudget = fitsInPeriodBudget || (cl.aggregateCurrentPeriodChurn == 0)
+	fitsInPeriodBudget = fitsInPeriodBudget || (aggregateCurrentPeriodChurn == 0)
 
 	return fitsInPeriodBudget && fitsInCurrentBudget
 }
@@ -7,7 +7,6 @@ package contractor
 import (
 	"fmt"
 	"math"
-	"math/big"
 	"reflect"
 	"sort"
 	"time"
@@ -408,6 +407,7 @@ func (c *Contractor) managedNewContract(host modules.HostDBEntry, contractFundin
 		txnBuilder.Drop()
 		return types.ZeroCurrency, modules.RenterContract{}, err
 	}
+	c.managedRecordContractSpending(modules.SpendingTypeFormation, contract)
 
 	monitorContractArgs := monitorContractArgs{
 		false,
@@ -485,17 +485,15 @@ func (c *Contractor) managedPrunedRedundantAddressRange() {
 }
 
 // managedLimitGFUHosts caps the number of GFU hosts for non-portals to
-// allowance.Hosts.
+// allowance.Hosts. Contracts which are needed to reach the target of a host
+// group are kept.
 func (c *Contractor) managedLimitGFUHosts() {
 	c.mu.Lock()
 	wantedHosts := c.allowance.Hosts
+	groupTargets := c.allowance.HostGroupTargets
 	c.mu.Unlock()
 	// Get all GFU contracts and their score.
-	type gfuContract struct {
-		c     modules.RenterContract
-		score types.Currency
-	}
-	var gfuContracts []gfuContract
+	var gfuContracts []contractScoreAndUtil
 	for _, contract := range c.Contracts() {
 		if !contract.Utility.GoodForUpload {
 			continue
@@ -510,20 +508,17 @@ func (c *Contractor) managedLimitGFUHosts() {
 			c.log.Print("managedLimitGFUHosts: failed to get score breakdown for GFU host")
 			continue
 		}
-		gfuContracts = append(gfuContracts, gfuContract{
-			c:     contract,
-			score: score.Score,
+		gfuContracts = append(gfuContracts, contractScoreAndUtil{
+			contract: contract,
+			score:    score.Score,
+			util:     contract.Utility,
 		})
 	}
-	// Sort gfuContracts by score.
-	sort.Slice(gfuContracts, func(i, j int) bool {
-		return gfuContracts[i].score.Cmp(gfuContracts[j].score) < 0
-	})
-	// Mark them bad for upload until we are below the expected number of hosts.
-	var contract gfuContract
-	for uint64(len(gfuContracts)) > wantedHosts {
-		contract, gfuContracts = gfuContracts[0], gfuContracts[1:]
-		sc, ok := c.staticContracts.Acquire(contract.c.ID)
+	// Mark the lowest scoring contracts bad for upload until we are below the
+	// expected number of hosts.
+	groupsByHost := c.managedHostGroupsByHost()
+	for _, i := range limitGFUContracts(gfuContracts, wantedHosts, groupTargets, groupsByHost) {
+		sc, ok := c.staticContracts.Acquire(gfuContracts[i].contract.ID)
 		if !ok {
 			c.log.Print("managedLimitGFUHosts: failed to acquire GFU contract")
 			continue
@@ -539,6 +534,53 @@ func (c *Contractor) managedLimitGFUHosts() {
 	}
 }
 
+// limitGFUContracts returns the indices of the GFU contracts that need to be
+// marked !GFU to cap their number at wantedHosts. The contracts with the lowest
+// scores are picked first. Contracts which are needed to reach the target of a
+// host group are kept.
+func limitGFUContracts(gfuContracts []contractScoreAndUtil, wantedHosts uint64, groupTargets map[string]uint64, groupsByHost map[string][]string) []int {
+	// Sort the contracts by score.
+	order := make([]int, len(gfuContracts))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return gfuContracts[order[i]].score.Cmp(gfuContracts[order[j]].score) < 0
+	})
+	// Count the GFU contracts of each host group.
+	groupCounts := make(map[string]uint64)
+	for _, contract := range gfuContracts {
+		for _, name := range groupsByHost[contract.contract.HostPublicKey.String()] {
+			groupCounts[name]++
+		}
+	}
+	// Pick contracts until we are below the expected number of hosts.
+	var limited []int
+	remaining := uint64(len(gfuContracts))
+	for _, i := range order {
+		if remaining <= wantedHosts {
+			break
+		}
+		// Skip contracts that are needed for the target of a host group.
+		groups := groupsByHost[gfuContracts[i].contract.HostPublicKey.String()]
+		needed := false
+		for _, name := range groups {
+			if groupCounts[name] <= groupTargets[name] {
+				needed = true
+			}
+		}
+		if needed {
+			continue
+		}
+		remaining--
+		for _, name := range groups {
+			groupCounts[name]--
+		}
+		limited = append(limited, i)
+	}
+	return limited
+}
+
 // staticCheckFormPaymentContractGouging will check whether the pricing from the
 // host for forming a payment contract is too high to justify forming a contract
 // with this host.
@@ -714,6 +756,7 @@ func (c *Contractor) managedRenew(id types.FileContractID, hpk types.TurtleDexPu
 		txnBuilder.Drop() // return unused outputs to wallet
 		return modules.RenterContract{}, err
 	}
+	c.managedRecordContractSpending(modules.SpendingTypeRenewal, newContract)
 
 	monitorContractArgs := monitorContractArgs{
 		false,
@@ -1133,15 +1176,9 @@ func (c *Contractor) threadedContractMaintenance() {
 		// if less than 'minContractFundRenewalThreshold' funds are remaining
 		// (3% at time of writing), or if there is less than 3 sectors worth of
 		// storage+upload+download remaining.
-		blockBytes := types.NewCurrency64(modules.SectorSize * uint64(allowance.Period))
-		sectorStoragePrice := host.StoragePrice.Mul(blockBytes)
-		sectorUploadBandwidthPrice := host.UploadBandwidthPrice.Mul64(modules.SectorSize)
-		sectorDownloadBandwidthPrice := host.DownloadBandwidthPrice.Mul64(modules.SectorSize)
-		sectorBandwidthPrice := sectorUploadBandwidthPrice.Add(sectorDownloadBandwidthPrice)
-		sectorPrice := sectorStoragePrice.Add(sectorBandwidthPrice)
-		percentRemaining, _ := big.NewRat(0, 1).SetFrac(contract.RenterFunds.Big(), contract.TotalCost.Big()).Float64()
+		sectorPrice, percentRemaining := sectorPriceAndFundsRemaining(contract, host, allowance.Period)
 		lowFundsRefresh := c.staticDeps.Disrupt("LowFundsRefresh")
-		if lowFundsRefresh || ((contract.RenterFunds.Cmp(sectorPrice.Mul64(3)) < 0 || percentRemaining < MinContractFundRenewalThreshold) && !c.staticDeps.Disrupt("disableRenew")) {
+		if lowFundsRefresh || (needsRefresh(contract, host, allowance.Period) && !c.staticDeps.Disrupt("disableRenew")) {
 			// Renew the contract with double the amount of funds that the
 			// contract had previously. The reason that we double the funding
 			// instead of doing anything more clever is that we don't know what
@@ -1337,13 +1374,23 @@ func (c *Contractor) threadedContractMaintenance() {
 	c.mu.RLock()
 	neededContracts := int(c.allowance.Hosts) - uploadContracts
 	c.mu.RUnlock()
-	if neededContracts <= 0 && allowance.PaymentContractInitialFunding.IsZero() {
+
+	// Determine how many contracts are needed to reach the targets of the
+	// host groups. These contracts count towards the allowance's hosts, but
+	// they are formed even if there are enough contracts in total. The
+	// contracts in excess are limited by managedLimitGFUHosts.
+	groupsByHost := c.managedHostGroupsByHost()
+	groupNeeds := hostGroupNeeds(allowance.HostGroupTargets, c.staticContracts.ViewAll(), groupsByHost)
+	if neededContracts <= 0 && len(groupNeeds) == 0 && allowance.PaymentContractInitialFunding.IsZero() {
 		c.log.Debugln("do not seem to need more contracts")
 		return
 	}
 	if neededContracts > 0 {
 		c.log.Println("need more contracts:", neededContracts)
 	}
+	for _, name := range sortedHostGroupNames(groupNeeds) {
+		c.log.Printf("need %v more contracts with hosts of group '%v'\n", groupNeeds[name], name)
+	}
 
 	// Assemble two exclusion lists. The first one includes all hosts that we
 	// already have contracts with and the second one includes all hosts we
@@ -1371,12 +1418,23 @@ func (c *Contractor) threadedContractMaintenance() {
 	minInitialContractFunds := c.allowance.Funds.Div64(c.allowance.Hosts).Div64(MinInitialContractFundingDivFactor)
 	c.mu.RUnlock()
 
-	// Get Hosts
-	hosts, err := c.hdb.RandomHosts(neededContracts*4+randomHostsBufferForScore, blacklist, addressBlacklist)
+	// Get Hosts. The hosts of the groups which are below their targets come
+	// first, followed by random hosts for the remaining contracts.
+	var hosts []modules.HostDBEntry
+	for _, name := range sortedHostGroupNames(groupNeeds) {
+		groupHosts, err := c.hdb.RandomHostsInGroup(name, groupNeeds[name]*4+randomHostsBufferForScore, blacklist, addressBlacklist)
+		if err != nil {
+			c.log.Printf("WARN: unable to get hosts of group '%v': %v\n", name, err)
+			continue
+		}
+		hosts = append(hosts, groupHosts...)
+	}
+	randomHosts, err := c.hdb.RandomHosts(neededContracts*4+randomHostsBufferForScore, blacklist, addressBlacklist)
 	if err != nil {
 		c.log.Println("WARN: not forming new contracts:", err)
 		return
 	}
+	hosts = append(hosts, randomHosts...)
 	c.log.Debugln("trying to form contracts with hosts, pulled this many hosts from hostdb:", len(hosts))
 
 	// Calculate the anticipated transaction fee.
@@ -1385,6 +1443,7 @@ func (c *Contractor) threadedContractMaintenance() {
 
 	// Form contracts with the hosts one at a time, until we have enough
 	// contracts.
+	triedHosts := make(map[string]struct{})
 	for _, host := range hosts {
 		// Return here if an interrupt or kill signal has been sent.
 		select {
@@ -1398,9 +1457,19 @@ func (c *Contractor) threadedContractMaintenance() {
 		}
 
 		// If no more contracts are needed, break.
-		if neededContracts <= 0 {
+		if neededContracts <= 0 && len(groupNeeds) == 0 {
 			break
 		}
+		// Skip hosts which were tried before and hosts which don't help to
+		// reach the target of a host group once enough contracts were formed.
+		if _, tried := triedHosts[host.PublicKey.String()]; tried {
+			continue
+		}
+		hostGroups := groupsByHost[host.PublicKey.String()]
+		if neededContracts <= 0 && !helpsHostGroups(groupNeeds, hostGroups) {
+			continue
+		}
+		triedHosts[host.PublicKey.String()] = struct{}{}
 
 		// Calculate the contract funding with host
 		contractFunds := host.ContractPrice.Add(txnFee).Mul64(ContractFeeFundingMulFactor)
@@ -1448,6 +1517,7 @@ func (c *Contractor) threadedContractMaintenance() {
 		}
 		fundsRemaining = fundsRemaining.Sub(fundsSpent)
 		neededContracts--
+		reduceHostGroupNeeds(groupNeeds, hostGroups)
 
 		sb, err := c.hdb.ScoreBreakdown(host)
 		if err == nil {
@@ -106,8 +106,9 @@ type Contractor struct {
 	renewedFrom          map[types.FileContractID]types.FileContractID
 	renewedTo            map[types.FileContractID]types.FileContractID
 
-	staticChurnLimiter *churnLimiter
-	staticWatchdog     *watchdog
+	staticChurnLimiter   *churnLimiter
+	staticSpendingLedger *spendingLedger
+	staticWatchdog       *watchdog
 }
 
 // Allowance returns the current allowance.
@@ -325,6 +326,18 @@ func (c *Contractor) ProvidePayment(stream io.ReadWriter, host types.TurtleDexPu
 			return errors.AddContext(err, "Failed to commit unknown spending intent")
 		}
 	}
+
+	// record the payment in the spending ledger
+	spendingType := modules.SpendingTypeRPC
+	if rpc == modules.RPCFundAccount {
+		spendingType = modules.SpendingTypeAccountFunding
+	}
+	c.managedRecordSpending(modules.SpendingRecord{
+		Type:          spendingType,
+		Amount:        amount,
+		HostPublicKey: host,
+		ContractID:    contract.ID,
+	})
 	return nil
 }
 
@@ -478,11 +491,21 @@ func contractorBlockingStartup(cs modules.ConsensusSet, w modules.Wallet, tp mod
 	c.staticChurnLimiter = newChurnLimiter(c)
 	c.staticWatchdog = newWatchdog(c)
 
-	// Close the contract set and logger upon shutdown.
-	err := c.tg.AfterStop(func() error {
+	// Load the spending ledger.
+	sl, err := newSpendingLedger(persistDir)
+	if err != nil {
+		return nil, err
+	}
+	c.staticSpendingLedger = sl
+
+	// Close the contract set, spending ledger and logger upon shutdown.
+	err = c.tg.AfterStop(func() error {
 		if err := c.staticContracts.Close(); err != nil {
 			return errors.AddContext(err, "failed to close contract set")
 		}
+		if err := c.staticSpendingLedger.Close(); err != nil {
+			return errors.AddContext(err, "failed to close the spending ledger")
+		}
 		if err := c.log.Close(); err != nil {
 			return errors.AddContext(err, "failed to close the contractor logger")
 		}
@@ -660,6 +660,27 @@ func TestPayment(t *testing.T) {
 	if !receipt.Host.Equals(hpk) {
 		t.Fatalf("Unexpected host pubkey in the receipt, expected %v but received %v", hpk, receipt.Host)
 	}
+
+	// verify the payments were recorded in the spending ledger
+	records, err := c.SpendingHistory(modules.SpendingHistoryFilter{
+		Types:         []modules.SpendingType{modules.SpendingTypeRPC, modules.SpendingTypeAccountFunding},
+		HostPublicKey: &hpk,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 spending records but got %v", len(records))
+	}
+	if records[0].Type != modules.SpendingTypeRPC || !records[0].Amount.Equals(pt.UpdatePriceTableCost) {
+		t.Fatal("Unexpected record for the price table payment", records[0])
+	}
+	if records[1].Type != modules.SpendingTypeAccountFunding || !records[1].Amount.Equals(funding.Add(pt.FundAccountCost)) {
+		t.Fatal("Unexpected record for the account funding", records[1])
+	}
+	if records[1].ContractID != contract.ID {
+		t.Fatal("Unexpected contract id", records[1].ContractID)
+	}
 }
 
 // TestLinkedContracts tests that the contractors maps are updated correctly
@@ -51,13 +51,17 @@ type (
 		AllHosts() ([]modules.HostDBEntry, error)
 		ActiveHosts() ([]modules.HostDBEntry, error)
 		CheckForIPViolations([]types.TurtleDexPublicKey) ([]types.TurtleDexPublicKey, error)
+		EstimateHostScore(modules.HostDBEntry, modules.Allowance) (modules.HostScoreBreakdown, error)
 		Filter() (modules.FilterMode, map[string]types.TurtleDexPublicKey, error)
 		SetFilterMode(fm modules.FilterMode, hosts []types.TurtleDexPublicKey) error
 		Host(types.TurtleDexPublicKey) (modules.HostDBEntry, bool, error)
+		HostGroups() (map[string][]types.TurtleDexPublicKey, error)
 		IncrementSuccessfulInteractions(key types.TurtleDexPublicKey) error
 		IncrementFailedInteractions(key types.TurtleDexPublicKey) error
 		InitialScanComplete() (complete bool, err error)
 		RandomHosts(n int, blacklist, addressBlacklist []types.TurtleDexPublicKey) ([]modules.HostDBEntry, error)
+		RandomHostsInGroup(group string, n int, blacklist, addressBlacklist []types.TurtleDexPublicKey) ([]modules.HostDBEntry, error)
+		RandomHostsWithAllowance(n int, blacklist, addressBlacklist []types.TurtleDexPublicKey, allowance modules.Allowance) ([]modules.HostDBEntry, error)
 		UpdateContracts([]modules.RenterContract) error
 		ScoreBreakdown(modules.HostDBEntry) (modules.HostScoreBreakdown, error)
 		SetAllowance(allowance modules.Allowance) error
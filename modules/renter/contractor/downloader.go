@@ -96,10 +96,12 @@ func (hd *hostDownloader) Download(root crypto.Hash, offset, length uint32) ([]b
 	if hd.invalid {
 		return nil, errInvalidDownloader
 	}
-	_, data, err := hd.downloader.Download(root, offset, length)
+	before, _ := hd.contractor.staticContracts.View(hd.contractID)
+	after, data, err := hd.downloader.Download(root, offset, length)
 	if err != nil {
 		return nil, err
 	}
+	hd.contractor.managedRecordRevisionSpending(before, after)
 	return data, nil
 }
 
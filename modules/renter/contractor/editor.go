@@ -113,10 +113,12 @@ func (he *hostEditor) Upload(data []byte) (_ crypto.Hash, err error) {
 	}
 
 	// Perform the upload.
-	_, sectorRoot, err := he.editor.Upload(data)
+	before, _ := he.contractor.staticContracts.View(he.id)
+	after, sectorRoot, err := he.editor.Upload(data)
 	if err != nil {
 		return crypto.Hash{}, err
 	}
+	he.contractor.managedRecordRevisionSpending(before, after)
 	return sectorRoot, nil
 }
 
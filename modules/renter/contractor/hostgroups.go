@@ -0,0 +1,76 @@
+package contractor
+
+import (
+	"sort"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+)
+
+// managedHostGroupsByHost returns the names of the host groups of the hostdb
+// each host belongs to, keyed by the string representation of the host's
+// public key.
+func (c *Contractor) managedHostGroupsByHost() map[string][]string {
+	groups, err := c.hdb.HostGroups()
+	if err != nil {
+		c.log.Println("WARN: unable to get host groups:", err)
+		return nil
+	}
+	return modules.HostGroupsByHost(groups)
+}
+
+// hostGroupNeeds returns the number of contracts which still need to be formed
+// with hosts of each group to reach the group targets. Only contracts which
+// are good for upload count towards the targets. Groups which reached their
+// target are omitted.
+func hostGroupNeeds(targets map[string]uint64, contracts []modules.RenterContract, groupsByHost map[string][]string) map[string]int {
+	counts := make(map[string]uint64)
+	for _, contract := range contracts {
+		if !contract.Utility.GoodForUpload {
+			continue
+		}
+		for _, name := range groupsByHost[contract.HostPublicKey.String()] {
+			counts[name]++
+		}
+	}
+	needs := make(map[string]int)
+	for name, target := range targets {
+		if counts[name] < target {
+			needs[name] = int(target - counts[name])
+		}
+	}
+	return needs
+}
+
+// helpsHostGroups returns true if a host in the provided groups helps to reach
+// the target of at least one of them.
+func helpsHostGroups(needs map[string]int, groups []string) bool {
+	for _, name := range groups {
+		if needs[name] > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// reduceHostGroupNeeds updates the needs of the groups after a contract was
+// formed with a host in the provided groups.
+func reduceHostGroupNeeds(needs map[string]int, groups []string) {
+	for _, name := range groups {
+		if needs[name] > 1 {
+			needs[name]--
+		} else {
+			delete(needs, name)
+		}
+	}
+}
+
+// sortedHostGroupNames returns the names of the groups in needs in sorted
+// order, to make contract formation deterministic.
+func sortedHostGroupNames(needs map[string]int) []string {
+	names := make([]string, 0, len(needs))
+	for name := range needs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
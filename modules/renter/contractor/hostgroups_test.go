@@ -0,0 +1,57 @@
+package contractor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+// TestHostGroupNeeds tests computing and reducing the number of contracts
+// needed to reach the targets of host groups.
+func TestHostGroupNeeds(t *testing.T) {
+	var pk1, pk2, pk3 types.TurtleDexPublicKey
+	pk1.Key = []byte{1}
+	pk2.Key = []byte{2}
+	pk3.Key = []byte{3}
+	groupsByHost := modules.HostGroupsByHost(map[string][]types.TurtleDexPublicKey{
+		"archival": {pk1, pk2},
+		"trusted":  {pk2, pk3},
+	})
+	contract := func(pk types.TurtleDexPublicKey, gfu bool) modules.RenterContract {
+		return modules.RenterContract{
+			HostPublicKey: pk,
+			Utility:       modules.ContractUtility{GoodForUpload: gfu},
+		}
+	}
+
+	// pk1 counts towards "archival", the contract with pk3 isn't GFU.
+	targets := map[string]uint64{"archival": 2, "trusted": 2, "unused": 0}
+	contracts := []modules.RenterContract{contract(pk1, true), contract(pk3, false)}
+	needs := hostGroupNeeds(targets, contracts, groupsByHost)
+	expected := map[string]int{"archival": 1, "trusted": 2}
+	if !reflect.DeepEqual(needs, expected) {
+		t.Fatal("unexpected needs", needs)
+	}
+	if names := sortedHostGroupNames(needs); !reflect.DeepEqual(names, []string{"archival", "trusted"}) {
+		t.Fatal("unexpected names", names)
+	}
+	if helpsHostGroups(needs, nil) || !helpsHostGroups(needs, groupsByHost[pk3.String()]) {
+		t.Fatal("wrong helpsHostGroups result")
+	}
+
+	// A contract with pk2 counts towards both groups.
+	reduceHostGroupNeeds(needs, groupsByHost[pk2.String()])
+	expected = map[string]int{"trusted": 1}
+	if !reflect.DeepEqual(needs, expected) {
+		t.Fatal("unexpected needs", needs)
+	}
+	if helpsHostGroups(needs, groupsByHost[pk1.String()]) {
+		t.Fatal("archival group already reached its target")
+	}
+	reduceHostGroupNeeds(needs, groupsByHost[pk3.String()])
+	if len(needs) != 0 {
+		t.Fatal("all targets should be reached", needs)
+	}
+}
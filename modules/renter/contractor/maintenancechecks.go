@@ -18,39 +18,43 @@ const (
 	necessaryUtilityUpdate
 )
 
-// badContractCheck checks whether the contract has been marked as bad. If the
-// contract has been marked as bad, GoodForUpload and GoodForRenew need to be
-// set to false to prevent the renter from using this contract.
-func (c *Contractor) badContractCheck(u modules.ContractUtility) (modules.ContractUtility, bool) {
-	if u.BadContract {
-		u.GoodForUpload = false
-		u.GoodForRenew = false
-		return u, true
+// checkHostScore checks the score of a contract's host against the minimum
+// accepted scores and returns the resulting utility of the contract.
+func checkHostScore(contract modules.RenterContract, sb modules.HostScoreBreakdown, minScoreGFR, minScoreGFU types.Currency, allowance modules.Allowance) (modules.ContractUtility, utilityUpdateStatus) {
+	u := contract.Utility
+
+	// Check whether the contract is a payment contract. Payment contracts
+	// cannot be marked !GFR for poor score.
+	var size uint64
+	if len(contract.Transaction.FileContractRevisions) > 0 {
+		size = contract.Transaction.FileContractRevisions[0].NewFileSize
 	}
-	return u, false
-}
+	paymentContract := !allowance.PaymentContractInitialFunding.IsZero() && size == 0
 
-// maxRevisionCheck will return a locked utility if the contract has reached its
-// max revision.
-func (c *Contractor) maxRevisionCheck(u modules.ContractUtility, revisionNumber uint64) (modules.ContractUtility, bool) {
-	if revisionNumber == math.MaxUint64 {
+	// Contract has no utility if the score is poor. Cannot be marked as bad if
+	// the contract is a payment contract.
+	deadScore := sb.Score.Cmp(types.NewCurrency64(1)) <= 0
+	badScore := !minScoreGFR.IsZero() && sb.Score.Cmp(minScoreGFR) < 0
+	if deadScore || (badScore && !paymentContract) {
 		u.GoodForUpload = false
 		u.GoodForRenew = false
-		u.Locked = true
-		return u, true
+
+		// Only force utility updates if the score is the min possible score.
+		// Otherwise defer update decision for low-score contracts to the
+		// churnLimiter.
+		if deadScore {
+			return u, necessaryUtilityUpdate
+		}
+		return u, suggestedUtilityUpdate
 	}
-	return u, false
-}
 
-// renewedCheck will return a contract with no utility and a required update if
-// the contract has been renewed, no changes otherwise.
-func (c *Contractor) renewedCheck(u modules.ContractUtility, renewed bool) (modules.ContractUtility, bool) {
-	if renewed {
+	// Contract should not be used for uplodaing if the score is poor.
+	if !minScoreGFU.IsZero() && sb.Score.Cmp(minScoreGFU) < 0 {
 		u.GoodForUpload = false
-		u.GoodForRenew = false
-		return u, true
+		u.GoodForRenew = true
+		return u, necessaryUtilityUpdate
 	}
-	return u, false
+	return u, noUpdate
 }
 
 // managedCheckHostScore checks host scorebreakdown against minimum accepted
@@ -60,20 +64,10 @@ func (c *Contractor) managedCheckHostScore(contract modules.RenterContract, sb m
 	defer c.mu.Unlock()
 
 	u := contract.Utility
+	newUtility, status := checkHostScore(contract, sb, minScoreGFR, minScoreGFU, c.allowance)
 
-	// Check whether the contract is a payment contract. Payment contracts
-	// cannot be marked !GFR for poor score.
-	var size uint64
-	if len(contract.Transaction.FileContractRevisions) > 0 {
-		size = contract.Transaction.FileContractRevisions[0].NewFileSize
-	}
-	paymentContract := !c.allowance.PaymentContractInitialFunding.IsZero() && size == 0
-
-	// Contract has no utility if the score is poor. Cannot be marked as bad if
-	// the contract is a payment contract.
-	deadScore := sb.Score.Cmp(types.NewCurrency64(1)) <= 0
-	badScore := !minScoreGFR.IsZero() && sb.Score.Cmp(minScoreGFR) < 0
-	if deadScore || (badScore && !paymentContract) {
+	// Contract has no utility if the score is poor.
+	if status != noUpdate && !newUtility.GoodForRenew {
 		// Log if the utility has changed.
 		if u.GoodForUpload || u.GoodForRenew {
 			c.log.Printf("Marking contract as having no utility because of host score: %v", contract.ID)
@@ -90,21 +84,14 @@ func (c *Contractor) managedCheckHostScore(contract modules.RenterContract, sb m
 			c.log.Println("Uptime Adjustment:     ", sb.UptimeAdjustment)
 			c.log.Println("Version Adjustment:    ", sb.VersionAdjustment)
 		}
-		u.GoodForUpload = false
-		u.GoodForRenew = false
-
-		// Only force utility updates if the score is the min possible score.
-		// Otherwise defer update decision for low-score contracts to the
-		// churnLimiter.
-		if deadScore {
-			return u, necessaryUtilityUpdate
+		if status == suggestedUtilityUpdate {
+			c.log.Println("Adding contract utility update to churnLimiter queue")
 		}
-		c.log.Println("Adding contract utility update to churnLimiter queue")
-		return u, suggestedUtilityUpdate
+		return newUtility, status
 	}
 
 	// Contract should not be used for uplodaing if the score is poor.
-	if !minScoreGFU.IsZero() && sb.Score.Cmp(minScoreGFU) < 0 {
+	if status != noUpdate {
 		if u.GoodForUpload {
 			c.log.Printf("Marking contract as not good for upload because of a poor score: %v", contract.ID)
 			c.log.Println("Min Score:", minScoreGFU)
@@ -123,12 +110,53 @@ func (c *Contractor) managedCheckHostScore(contract modules.RenterContract, sb m
 		if !u.GoodForRenew {
 			c.log.Println("Marking contract as being good for renew", contract.ID)
 		}
+	}
+	return newUtility, status
+}
+
+// criticalUtilityChecks performs critical checks on a contract that would
+// require, with no exceptions, marking the contract as !GFR and/or !GFU. It
+// returns the new utility, true if any of the checks failed and a description
+// of the failed check. It is used by both contract maintenance and contract
+// maintenance plans.
+func criticalUtilityChecks(contract modules.RenterContract, host modules.HostDBEntry, revisionNumber uint64, renewed bool, renewWindow, period, blockHeight types.BlockHeight) (modules.ContractUtility, bool, string) {
+	u := contract.Utility
+	switch {
+	case renewed:
+		// A contract that has been renewed should be set to !GFU and !GFR.
+		u.GoodForUpload = false
+		u.GoodForRenew = false
+		return u, true, "contract was renewed"
+	case revisionNumber == math.MaxUint64:
+		// A contract that reached its max revision is locked.
+		u.GoodForUpload = false
+		u.GoodForRenew = false
+		u.Locked = true
+		return u, true, "contract reached its max revision"
+	case u.BadContract:
+		u.GoodForUpload = false
+		u.GoodForRenew = false
+		return u, true, "contract is bad"
+	case isOffline(host):
+		u.GoodForUpload = false
+		u.GoodForRenew = false
+		return u, true, "host is offline"
+	case upForRenewal(contract, renewWindow, blockHeight):
 		u.GoodForUpload = false
 		u.GoodForRenew = true
-		return u, necessaryUtilityUpdate
+		return u, true, "contract is up for renewal"
 	}
-
-	return u, noUpdate
+	if lowFunds, _ := insufficientFunds(contract, host, period); lowFunds {
+		u.GoodForUpload = false
+		u.GoodForRenew = true
+		return u, true, "contract has insufficient funds"
+	}
+	if outOfStorage(u, blockHeight) {
+		u.GoodForUpload = false
+		u.GoodForRenew = true
+		return u, true, "host is out of storage"
+	}
+	return u, false, ""
 }
 
 // managedCriticalUtilityChecks performs critical checks on a contract that
@@ -150,43 +178,15 @@ func (c *Contractor) managedCriticalUtilityChecks(sc *proto.SafeContract, host m
 	_, renewed := c.renewedTo[contract.ID]
 	c.mu.RUnlock()
 
-	// A contract that has been renewed should be set to !GFU and !GFR.
-	u, needsUpdate := c.renewedCheck(contract.Utility, renewed)
-	if needsUpdate {
-		return u, needsUpdate
+	u, needsUpdate, reason := criticalUtilityChecks(contract, host, sc.LastRevision().NewRevisionNumber, renewed, renewWindow, period, blockHeight)
+	if !needsUpdate {
+		return contract.Utility, false
 	}
-
-	u, needsUpdate = c.maxRevisionCheck(contract.Utility, sc.LastRevision().NewRevisionNumber)
-	if needsUpdate {
-		return u, needsUpdate
-	}
-
-	u, needsUpdate = c.badContractCheck(contract.Utility)
-	if needsUpdate {
-		return u, needsUpdate
-	}
-
-	u, needsUpdate = c.offlineCheck(contract, host)
-	if needsUpdate {
-		return u, needsUpdate
-	}
-
-	u, needsUpdate = c.upForRenewalCheck(contract, renewWindow, blockHeight)
-	if needsUpdate {
-		return u, needsUpdate
+	// Log if the utility has changed.
+	if u.GoodForUpload != contract.Utility.GoodForUpload || u.GoodForRenew != contract.Utility.GoodForRenew {
+		c.log.Printf("Marking contract %v as GFU: %v, GFR: %v because the %v", contract.ID, u.GoodForUpload, u.GoodForRenew, reason)
 	}
-
-	u, needsUpdate = c.sufficientFundsCheck(contract, host, period)
-	if needsUpdate {
-		return u, needsUpdate
-	}
-
-	u, needsUpdate = c.outOfStorageCheck(contract, blockHeight)
-	if needsUpdate {
-		return u, needsUpdate
-	}
-
-	return contract.Utility, false
+	return u, true
 }
 
 // managedHostInHostDBCheck checks if the host is in the hostdb and not
@@ -215,54 +215,30 @@ func (c *Contractor) managedHostInHostDBCheck(contract modules.RenterContract) (
 	return host, u, false
 }
 
-// offLineCheck checks if the host for this contract is offline.
-// Returns true if a check fails and the utility returned must be used to update
-// the contract state.
-func (c *Contractor) offlineCheck(contract modules.RenterContract, host modules.HostDBEntry) (modules.ContractUtility, bool) {
-	u := contract.Utility
-	// Contract has no utility if the host is offline.
-	if isOffline(host) {
-		// Log if the utility has changed.
-		if u.GoodForUpload || u.GoodForRenew {
-			c.log.Println("Marking contract as having no utility because of host being offline", contract.ID)
-		}
-		u.GoodForUpload = false
-		u.GoodForRenew = false
-		return u, true
-	}
-	return u, false
+// upForRenewal returns true if the renew window of the contract was reached.
+func upForRenewal(contract modules.RenterContract, renewWindow, blockHeight types.BlockHeight) bool {
+	return blockHeight+renewWindow >= contract.EndHeight
 }
 
-// upForRenewalCheck checks if this contract is up for renewal.
-// Returns true if a check fails and the utility returned must be used to update
-// the contract state.
-func (c *Contractor) upForRenewalCheck(contract modules.RenterContract, renewWindow, blockHeight types.BlockHeight) (modules.ContractUtility, bool) {
-	u := contract.Utility
-	// Contract should not be used for uploading if the time has come to
-	// renew the contract.
-	if blockHeight+renewWindow >= contract.EndHeight {
-		if u.GoodForUpload {
-			c.log.Println("Marking contract as not good for upload because it is time to renew the contract", contract.ID)
-		}
-		if !u.GoodForRenew {
-			c.log.Println("Marking contract as being good for renew:", contract.ID)
-		}
-		u.GoodForUpload = false
-		u.GoodForRenew = true
-		return u, true
-	}
-	return u, false
+// insufficientFunds returns true if the contract doesn't have enough money
+// remaining to be used for uploads. The percentage of the funds remaining in
+// the contract is returned as well.
+func insufficientFunds(contract modules.RenterContract, host modules.HostDBEntry, period types.BlockHeight) (bool, float64) {
+	sectorPrice, percentRemaining := sectorPriceAndFundsRemaining(contract, host, period)
+	return contract.RenterFunds.Cmp(sectorPrice.Mul64(3)) < 0 || percentRemaining < MinContractFundUploadThreshold, percentRemaining
 }
 
-// sufficientFundsCheck checks if there are enough funds left in the contract
-// for uploads.
-// Returns true if a check fails and the utility returned must be used to update
-// the contract state.
-func (c *Contractor) sufficientFundsCheck(contract modules.RenterContract, host modules.HostDBEntry, period types.BlockHeight) (modules.ContractUtility, bool) {
-	u := contract.Utility
+// needsRefresh returns true if the contract is running out of funds and needs
+// to be refreshed.
+func needsRefresh(contract modules.RenterContract, host modules.HostDBEntry, period types.BlockHeight) bool {
+	sectorPrice, percentRemaining := sectorPriceAndFundsRemaining(contract, host, period)
+	return contract.RenterFunds.Cmp(sectorPrice.Mul64(3)) < 0 || percentRemaining < MinContractFundRenewalThreshold
+}
 
-	// Contract should not be used for uploading if the contract does
-	// not have enough money remaining to perform the upload.
+// sectorPriceAndFundsRemaining returns the price of storing, uploading and
+// downloading a sector with the host for the period and the percentage of the
+// contract's funds remaining.
+func sectorPriceAndFundsRemaining(contract modules.RenterContract, host modules.HostDBEntry, period types.BlockHeight) (types.Currency, float64) {
 	blockBytes := types.NewCurrency64(modules.SectorSize * uint64(period))
 	sectorStoragePrice := host.StoragePrice.Mul(blockBytes)
 	sectorUploadBandwidthPrice := host.UploadBandwidthPrice.Mul64(modules.SectorSize)
@@ -270,40 +246,15 @@ func (c *Contractor) sufficientFundsCheck(contract modules.RenterContract, host
 	sectorBandwidthPrice := sectorUploadBandwidthPrice.Add(sectorDownloadBandwidthPrice)
 	sectorPrice := sectorStoragePrice.Add(sectorBandwidthPrice)
 	percentRemaining, _ := big.NewRat(0, 1).SetFrac(contract.RenterFunds.Big(), contract.TotalCost.Big()).Float64()
-	if contract.RenterFunds.Cmp(sectorPrice.Mul64(3)) < 0 || percentRemaining < MinContractFundUploadThreshold {
-		if u.GoodForUpload {
-			c.log.Printf("Marking contract as not good for upload because of insufficient funds: %v vs. %v - %v", contract.RenterFunds.Cmp(sectorPrice.Mul64(3)) < 0, percentRemaining, contract.ID)
-		}
-		if !u.GoodForRenew {
-			c.log.Println("Marking contract as being good for renew:", contract.ID)
-		}
-		u.GoodForUpload = false
-		u.GoodForRenew = true
-		return u, true
-	}
-	return u, false
+	return sectorPrice, percentRemaining
 }
 
-// outOfStorageCheck checks if the host is running out of storage.
-// Returns true if a check fails and the utility returned must be used to update
-// the contract state.
-func (c *Contractor) outOfStorageCheck(contract modules.RenterContract, blockHeight types.BlockHeight) (modules.ContractUtility, bool) {
-	u := contract.Utility
-	// If LastOOSErr has never been set, return false.
+// outOfStorage returns true if the host of the contract ran out of storage
+// recently.
+func outOfStorage(u modules.ContractUtility, blockHeight types.BlockHeight) bool {
+	// If LastOOSErr has never been set, the host never ran out of storage.
 	if u.LastOOSErr == 0 {
-		return u, false
-	}
-	// Contract should not be used for uploading if the host is out of storage.
-	if blockHeight-u.LastOOSErr <= oosRetryInterval {
-		if u.GoodForUpload {
-			c.log.Println("Marking contract as not being good for upload due to the host running out of storage:", contract.ID)
-		}
-		if !u.GoodForRenew {
-			c.log.Println("Marking contract as being good for renew:", contract.ID)
-		}
-		u.GoodForUpload = false
-		u.GoodForRenew = true
-		return u, true
+		return false
 	}
-	return u, false
+	return blockHeight-u.LastOOSErr <= oosRetryInterval
 }
@@ -0,0 +1,458 @@
+package contractor
+
+import (
+	"reflect"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+// plannedContract is a contract together with the utility contract
+// maintenance would assign to it.
+type plannedContract struct {
+	contract modules.RenterContract
+	host     modules.HostDBEntry
+	score    types.Currency
+	utility  modules.ContractUtility
+	status   utilityUpdateStatus
+	reason   string
+}
+
+// ContractMaintenancePlan returns the plan of what contract maintenance would
+// do in its next iteration if the allowance was set to a. The plan mirrors the
+// decisions of threadedContractMaintenance, but it doesn't update any
+// contracts, form any contracts or spend any money. The scores of the hosts are
+// estimated using the provided allowance.
+func (c *Contractor) ContractMaintenancePlan(a modules.Allowance) (modules.ContractMaintenancePlan, error) {
+	if err := c.tg.Add(); err != nil {
+		return modules.ContractMaintenancePlan{}, err
+	}
+	defer c.tg.Done()
+
+	// An empty allowance cancels all contracts.
+	contracts := c.staticContracts.ViewAll()
+	plan := modules.ContractMaintenancePlan{Allowance: a}
+	if reflect.DeepEqual(a, modules.Allowance{}) {
+		for _, contract := range contracts {
+			plan.UtilityUpdates = append(plan.UtilityUpdates, modules.PlannedUtilityUpdate{
+				ID:            contract.ID,
+				HostPublicKey: contract.HostPublicKey,
+				OldUtility:    contract.Utility,
+				NewUtility:    modules.ContractUtility{Locked: true},
+				Reason:        "allowance is cancelled",
+			})
+		}
+		return plan, nil
+	}
+	if err := checkAllowance(a); err != nil {
+		return modules.ContractMaintenancePlan{}, err
+	}
+
+	// Collect the state of the contractor. The current period is set the same
+	// way SetAllowance would set it.
+	c.mu.RLock()
+	plan.BlockHeight = c.blockHeight
+	currentPeriod := c.currentPeriod
+	if reflect.DeepEqual(c.allowance, modules.Allowance{}) {
+		currentPeriod = c.blockHeight
+		if a.Period > a.RenewWindow {
+			currentPeriod -= a.RenewWindow
+		}
+	}
+	renewed := make(map[types.FileContractID]bool)
+	for _, contract := range contracts {
+		_, renewed[contract.ID] = c.renewedTo[contract.ID]
+	}
+	var recoverableHosts []types.TurtleDexPublicKey
+	for _, contract := range c.recoverableContracts {
+		recoverableHosts = append(recoverableHosts, contract.HostPublicKey)
+	}
+	allowanceCancelled := reflect.DeepEqual(c.allowance, modules.Allowance{})
+	c.mu.RUnlock()
+	plan.EndHeight = currentPeriod + a.Period + a.RenewWindow
+
+	if !c.managedSynced() {
+		plan.Warnings = append(plan.Warnings, "consensus is not synced, maintenance won't run until it is")
+	}
+	if unlocked, err := c.wallet.Unlocked(); !unlocked || err != nil {
+		plan.Warnings = append(plan.Warnings, "wallet is locked, maintenance won't renew or form contracts")
+	}
+
+	// Plan the utilities of the contracts.
+	planned, err := c.managedPlanContractUtilities(contracts, renewed, a, plan.BlockHeight, allowanceCancelled)
+	if err != nil {
+		return modules.ContractMaintenancePlan{}, errors.AddContext(err, "unable to plan contract utilities")
+	}
+	plan.ChurnedContracts, plan.ChurnAvoided = c.planChurn(planned, a)
+	groupsByHost := c.managedHostGroupsByHost()
+	planLimitGFUHosts(planned, a, groupsByHost)
+	for _, pc := range planned {
+		if pc.utility != pc.contract.Utility {
+			plan.UtilityUpdates = append(plan.UtilityUpdates, modules.PlannedUtilityUpdate{
+				ID:            pc.contract.ID,
+				HostPublicKey: pc.contract.HostPublicKey,
+				OldUtility:    pc.contract.Utility,
+				NewUtility:    pc.utility,
+				Reason:        pc.reason,
+			})
+		}
+	}
+
+	// Plan the renewals and refreshes.
+	spending, err := c.PeriodSpending()
+	if err != nil {
+		return modules.ContractMaintenancePlan{}, errors.AddContext(err, "unable to get period spending")
+	}
+	if spending.TotalAllocated.Cmp(a.Funds) < 0 {
+		plan.FundsAvailable = a.Funds.Sub(spending.TotalAllocated)
+	}
+	fundsRemaining := plan.FundsAvailable
+	renewSet, refreshSet := c.managedPlanRenewals(planned, a, plan.BlockHeight)
+	uploadContracts := 0
+	for _, pc := range planned {
+		if pc.utility.GoodForUpload {
+			uploadContracts++
+		}
+	}
+	for i := range renewSet {
+		if renewSet[i].Funding.Cmp(fundsRemaining) > 0 {
+			renewSet[i].Skipped = true
+			continue
+		}
+		fundsRemaining = fundsRemaining.Sub(renewSet[i].Funding)
+		plan.EstimatedRenewSpending = plan.EstimatedRenewSpending.Add(renewSet[i].Funding)
+		uploadContracts++
+	}
+	for i := range refreshSet {
+		if refreshSet[i].Funding.Cmp(fundsRemaining) > 0 {
+			refreshSet[i].Skipped = true
+			continue
+		}
+		fundsRemaining = fundsRemaining.Sub(refreshSet[i].Funding)
+		plan.EstimatedRefreshSpending = plan.EstimatedRefreshSpending.Add(refreshSet[i].Funding)
+		uploadContracts++
+	}
+	plan.Renewals, plan.Refreshes = renewSet, refreshSet
+
+	// Plan the formation of new contracts.
+	neededContracts := int(a.Hosts) - uploadContracts
+	if neededContracts > 0 {
+		plan.NeededContracts = uint64(neededContracts)
+	}
+	plannedContracts := make([]modules.RenterContract, 0, len(planned))
+	for _, pc := range planned {
+		contract := pc.contract
+		contract.Utility = pc.utility
+		plannedContracts = append(plannedContracts, contract)
+	}
+	groupNeeds := hostGroupNeeds(a.HostGroupTargets, plannedContracts, groupsByHost)
+	if neededContracts > 0 || len(groupNeeds) > 0 || a.PortalMode() {
+		plan.Formations = c.managedPlanFormations(plannedContracts, recoverableHosts, a, neededContracts, groupNeeds, groupsByHost, fundsRemaining)
+	}
+	for _, formation := range plan.Formations {
+		plan.EstimatedFormationSpending = plan.EstimatedFormationSpending.Add(formation.Funding)
+	}
+	if uint64(len(plan.Formations)) < plan.NeededContracts {
+		plan.Warnings = append(plan.Warnings, "not enough funds or hosts to form the needed contracts")
+	}
+	plan.EstimatedTotalSpending = plan.EstimatedRenewSpending.Add(plan.EstimatedRefreshSpending).Add(plan.EstimatedFormationSpending)
+	return plan, nil
+}
+
+// managedPlanContractUtilities determines the utility of the contracts the
+// same way managedMarkContractUtility does, using the provided allowance and
+// without updating the contracts.
+func (c *Contractor) managedPlanContractUtilities(contracts []modules.RenterContract, renewed map[types.FileContractID]bool, a modules.Allowance, blockHeight types.BlockHeight, allowanceCancelled bool) ([]plannedContract, error) {
+	// Find the minimum scores using the provided allowance.
+	hosts, err := c.hdb.RandomHostsWithAllowance(int(a.Hosts)+randomHostsBufferForScore, nil, nil, a)
+	if err != nil {
+		return nil, err
+	}
+	if len(hosts) == 0 {
+		return nil, errors.New("No hosts returned in RandomHostsWithAllowance")
+	}
+	var lowestScore types.Currency
+	for i, host := range hosts {
+		sb, err := c.hdb.EstimateHostScore(host, a)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 || sb.Score.Cmp(lowestScore) < 0 {
+			lowestScore = sb.Score
+		}
+	}
+	minScoreGFR := lowestScore.Div(scoreLeewayGoodForRenew)
+	minScoreGFU := lowestScore.Div(scoreLeewayGoodForUpload)
+
+	planned := make([]plannedContract, 0, len(contracts))
+	for _, contract := range contracts {
+		pc := plannedContract{
+			contract: contract,
+			utility:  contract.Utility,
+			status:   noUpdate,
+		}
+		// Setting an allowance after it was cancelled unlocks the contracts.
+		// Otherwise locked contracts keep their utility.
+		if pc.utility.Locked && !allowanceCancelled {
+			planned = append(planned, pc)
+			continue
+		}
+		pc.utility.Locked = false
+		pc.utility, pc.status, pc.reason = c.managedPlanContractUtility(&pc, renewed[contract.ID], a, blockHeight, minScoreGFR, minScoreGFU)
+		planned = append(planned, pc)
+	}
+	return planned, nil
+}
+
+// managedPlanContractUtility applies the utility checks of contract
+// maintenance to a single contract and returns the resulting utility together
+// with the reason for a change.
+func (c *Contractor) managedPlanContractUtility(pc *plannedContract, renewed bool, a modules.Allowance, blockHeight types.BlockHeight, minScoreGFR, minScoreGFU types.Currency) (modules.ContractUtility, utilityUpdateStatus, string) {
+	contract := pc.contract
+	contract.Utility = pc.utility
+	u := contract.Utility
+
+	// Check that the host is in the hostdb and not filtered.
+	host, exists, err := c.hdb.Host(contract.HostPublicKey)
+	if !exists || host.Filtered || err != nil {
+		u.GoodForUpload = false
+		u.GoodForRenew = false
+		return u, necessaryUtilityUpdate, "host is not in the hostdb or filtered"
+	}
+	pc.host = host
+
+	// Critical checks.
+	sc, ok := c.staticContracts.Acquire(contract.ID)
+	if !ok {
+		return u, noUpdate, "contract is not in the contract set"
+	}
+	revisionNumber := sc.LastRevision().NewRevisionNumber
+	c.staticContracts.Return(sc)
+	if newUtility, needsUpdate, reason := criticalUtilityChecks(contract, host, revisionNumber, renewed, a.RenewWindow, a.Period, blockHeight); needsUpdate {
+		return newUtility, necessaryUtilityUpdate, reason
+	}
+
+	// Check the score of the host.
+	sb, err := c.hdb.EstimateHostScore(host, a)
+	if err != nil {
+		return u, noUpdate, ""
+	}
+	pc.score = sb.Score
+	newUtility, status := checkHostScore(contract, sb, minScoreGFR, minScoreGFU, a)
+	switch {
+	case status == suggestedUtilityUpdate:
+		return newUtility, status, "host score is too low"
+	case status != noUpdate && !newUtility.GoodForRenew:
+		return newUtility, status, "host score is dead"
+	case status != noUpdate:
+		return newUtility, status, "host score is too low for uploads"
+	}
+
+	// All checks passed.
+	u.GoodForUpload = true
+	u.GoodForRenew = true
+	return u, noUpdate, "all checks passed"
+}
+
+// planChurn applies the churn limiter to the planned contracts whose utility
+// update was only suggested. It returns the number of contracts that would be
+// churned and the number of contracts that would be kept to respect the churn
+// budget.
+func (c *Contractor) planChurn(planned []plannedContract, a modules.Allowance) (churned, avoided uint64) {
+	cl := c.staticChurnLimiter
+	cl.mu.Lock()
+	remainingChurnBudget := cl.remainingChurnBudget
+	aggregateChurn := cl.aggregateCurrentPeriodChurn
+	cl.mu.Unlock()
+	maxChurnBudget := int(a.MaxPeriodChurn / 2)
+
+	// Process the suggested updates like managedProcessSuggestedUpdates does.
+	var queue []contractScoreAndUtil
+	indices := make(map[types.FileContractID]int)
+	for i, pc := range planned {
+		if pc.status == suggestedUtilityUpdate {
+			queue = append(queue, contractScoreAndUtil{pc.contract, pc.score, pc.utility})
+			indices[pc.contract.ID] = i
+		}
+	}
+	churnedContracts, avoidedContracts := churnSuggestedUpdates(queue, remainingChurnBudget, maxChurnBudget, aggregateChurn, a.MaxPeriodChurn)
+	for i, q := range queue {
+		switch {
+		case churnedContracts[i]:
+			churned++
+		case avoidedContracts[i]:
+			pc := &planned[indices[q.contract.ID]]
+			pc.utility = q.util
+			pc.reason = "host score is too low but the churn budget is exhausted"
+			avoided++
+		}
+	}
+	return churned, avoided
+}
+
+// planLimitGFUHosts marks the lowest scoring planned contracts as !GFU until
+// only the allowance's hosts are left, like managedLimitGFUHosts does.
+func planLimitGFUHosts(planned []plannedContract, a modules.Allowance, groupsByHost map[string][]string) {
+	var gfu []contractScoreAndUtil
+	var indices []int
+	for i, pc := range planned {
+		if pc.utility.GoodForUpload {
+			gfu = append(gfu, contractScoreAndUtil{pc.contract, pc.score, pc.utility})
+			indices = append(indices, i)
+		}
+	}
+	for _, i := range limitGFUContracts(gfu, a.Hosts, a.HostGroupTargets, groupsByHost) {
+		pc := &planned[indices[i]]
+		pc.utility.GoodForUpload = false
+		pc.reason = "more contracts are good for upload than the allowance's hosts"
+	}
+}
+
+// managedPlanRenewals returns the renew set and refresh set of the planned
+// contracts, like threadedContractMaintenance builds them.
+func (c *Contractor) managedPlanRenewals(planned []plannedContract, a modules.Allowance, blockHeight types.BlockHeight) (renewSet, refreshSet []modules.PlannedRenewal) {
+	for _, pc := range planned {
+		contract := pc.contract
+		host := pc.host
+		if !pc.utility.GoodForRenew || host.Filtered || build.VersionCmp(host.Version, modules.MinimumSupportedRenterHostProtocolVersion) < 0 {
+			continue
+		}
+		if upForRenewal(contract, a.RenewWindow, blockHeight) {
+			amount, err := c.managedEstimateRenewFundingRequirements(contract, blockHeight, a)
+			if err != nil {
+				continue
+			}
+			renewSet = append(renewSet, modules.PlannedRenewal{
+				ID:            contract.ID,
+				HostPublicKey: contract.HostPublicKey,
+				Funding:       amount,
+			})
+			continue
+		}
+		if !needsRefresh(contract, host, a.Period) {
+			continue
+		}
+		amount := contract.TotalCost.Mul64(2)
+		minimum := a.Funds.MulFloat(fileContractMinimumFunding).Div64(a.Hosts)
+		if amount.Cmp(minimum) < 0 {
+			amount = minimum
+		}
+		refreshSet = append(refreshSet, modules.PlannedRenewal{
+			ID:            contract.ID,
+			HostPublicKey: contract.HostPublicKey,
+			Funding:       amount,
+		})
+	}
+	return renewSet, refreshSet
+}
+
+// managedPlanFormations picks the hosts new contracts would be formed with,
+// like threadedContractMaintenance does.
+func (c *Contractor) managedPlanFormations(contracts []modules.RenterContract, recoverableHosts []types.TurtleDexPublicKey, a modules.Allowance, neededContracts int, groupNeeds map[string]int, groupsByHost map[string][]string, fundsRemaining types.Currency) []modules.PlannedFormation {
+	var blacklist, addressBlacklist []types.TurtleDexPublicKey
+	for _, contract := range contracts {
+		blacklist = append(blacklist, contract.HostPublicKey)
+		if !contract.Utility.Locked || contract.Utility.GoodForRenew || contract.Utility.GoodForUpload {
+			addressBlacklist = append(addressBlacklist, contract.HostPublicKey)
+		}
+	}
+	blacklist = append(blacklist, recoverableHosts...)
+	maxInitialContractFunds := a.Funds.Div64(a.Hosts).Mul64(MaxInitialContractFundingMulFactor).Div64(MaxInitialContractFundingDivFactor)
+	minInitialContractFunds := a.Funds.Div64(a.Hosts).Div64(MinInitialContractFundingDivFactor)
+	_, maxFee := c.tpool.FeeEstimation()
+	txnFee := maxFee.Mul64(modules.EstimatedFileContractTransactionSetSize)
+
+	// Get the candidates, the hosts of groups below their targets first.
+	var hosts []modules.HostDBEntry
+	for _, name := range sortedHostGroupNames(groupNeeds) {
+		groupHosts, err := c.hdb.RandomHostsInGroup(name, groupNeeds[name]*4+randomHostsBufferForScore, blacklist, addressBlacklist)
+		if err == nil {
+			hosts = append(hosts, groupHosts...)
+		}
+	}
+	if neededContracts > 0 {
+		randomHosts, err := c.hdb.RandomHostsWithAllowance(neededContracts*4+randomHostsBufferForScore, blacklist, addressBlacklist, a)
+		if err == nil {
+			hosts = append(hosts, randomHosts...)
+		}
+	}
+
+	var formations []modules.PlannedFormation
+	planned := make(map[string]struct{})
+	for _, host := range hosts {
+		if neededContracts <= 0 && len(groupNeeds) == 0 {
+			break
+		}
+		hostGroups := groupsByHost[host.PublicKey.String()]
+		if _, exists := planned[host.PublicKey.String()]; exists {
+			continue
+		}
+		if neededContracts <= 0 && !helpsHostGroups(groupNeeds, hostGroups) {
+			continue
+		}
+		// Skip hosts managedNewContract would reject.
+		if host.StoragePrice.Cmp(maxStoragePrice) > 0 || host.MaxDuration < a.Period || checkFormContractGouging(a, host.HostExternalSettings) != nil {
+			continue
+		}
+		contractFunds := host.ContractPrice.Add(txnFee).Mul64(ContractFeeFundingMulFactor)
+		if contractFunds.Cmp(maxInitialContractFunds) > 0 {
+			contractFunds = maxInitialContractFunds
+		}
+		if contractFunds.Cmp(minInitialContractFunds) < 0 {
+			contractFunds = minInitialContractFunds
+		}
+		if fundsRemaining.Cmp(contractFunds) < 0 {
+			break
+		}
+		fundsRemaining = fundsRemaining.Sub(contractFunds)
+		neededContracts--
+		reduceHostGroupNeeds(groupNeeds, hostGroups)
+		planned[host.PublicKey.String()] = struct{}{}
+		formations = append(formations, modules.PlannedFormation{
+			HostPublicKey: host.PublicKey,
+			NetAddress:    host.NetAddress,
+			Funding:       contractFunds,
+			Groups:        hostGroups,
+		})
+	}
+
+	// Portals form payment contracts with all active hosts they don't have a
+	// contract with.
+	if !a.PortalMode() {
+		return formations
+	}
+	activeHosts, err := c.hdb.ActiveHosts()
+	if err != nil {
+		return formations
+	}
+	for _, contract := range contracts {
+		planned[contract.HostPublicKey.String()] = struct{}{}
+	}
+	for _, host := range activeHosts {
+		if _, exists := planned[host.PublicKey.String()]; exists {
+			continue
+		}
+		sb, err := c.hdb.EstimateHostScore(host, a)
+		if err != nil || sb.Score.Equals(types.NewCurrency64(1)) {
+			continue
+		}
+		if staticCheckFormPaymentContractGouging(a, host.HostExternalSettings) != nil {
+			continue
+		}
+		if fundsRemaining.Cmp(a.PaymentContractInitialFunding) < 0 {
+			break
+		}
+		fundsRemaining = fundsRemaining.Sub(a.PaymentContractInitialFunding)
+		formations = append(formations, modules.PlannedFormation{
+			HostPublicKey:   host.PublicKey,
+			NetAddress:      host.NetAddress,
+			Funding:         a.PaymentContractInitialFunding,
+			Groups:          groupsByHost[host.PublicKey.String()],
+			PaymentContract: true,
+		})
+	}
+	return formations
+}
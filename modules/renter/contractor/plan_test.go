@@ -0,0 +1,214 @@
+package contractor
+
+import (
+	"math"
+	"testing"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+// TestCheckHostScore tests the utility checkHostScore assigns to contracts for
+// different host scores.
+func TestCheckHostScore(t *testing.T) {
+	minScoreGFR := types.NewCurrency64(100)
+	minScoreGFU := types.NewCurrency64(200)
+	contract := modules.RenterContract{
+		Utility: modules.ContractUtility{GoodForUpload: true, GoodForRenew: true},
+	}
+	score := func(s uint64) modules.HostScoreBreakdown {
+		return modules.HostScoreBreakdown{Score: types.NewCurrency64(s)}
+	}
+
+	tests := []struct {
+		score  uint64
+		gfu    bool
+		gfr    bool
+		status utilityUpdateStatus
+	}{
+		{1, false, false, necessaryUtilityUpdate},
+		{50, false, false, suggestedUtilityUpdate},
+		{150, false, true, necessaryUtilityUpdate},
+		{250, true, true, noUpdate},
+	}
+	for _, test := range tests {
+		u, status := checkHostScore(contract, score(test.score), minScoreGFR, minScoreGFU, modules.Allowance{})
+		if u.GoodForUpload != test.gfu || u.GoodForRenew != test.gfr || status != test.status {
+			t.Errorf("score %v: got %v %v %v, expected %v %v %v", test.score, u.GoodForUpload, u.GoodForRenew, status, test.gfu, test.gfr, test.status)
+		}
+	}
+
+	// Empty payment contracts can't be marked !GFR for a low score but they
+	// can for a dead score.
+	a := modules.Allowance{PaymentContractInitialFunding: types.NewCurrency64(1)}
+	u, status := checkHostScore(contract, score(50), minScoreGFR, minScoreGFU, a)
+	if u.GoodForUpload || !u.GoodForRenew || status != necessaryUtilityUpdate {
+		t.Fatal("payment contract was marked !GFR", u, status)
+	}
+	u, status = checkHostScore(contract, score(1), minScoreGFR, minScoreGFU, a)
+	if u.GoodForRenew || status != necessaryUtilityUpdate {
+		t.Fatal("payment contract with dead score should be marked !GFR", u, status)
+	}
+}
+
+// TestCriticalUtilityChecks tests the utility criticalUtilityChecks assigns to
+// contracts failing the different critical checks.
+func TestCriticalUtilityChecks(t *testing.T) {
+	online := modules.HostDBEntry{ScanHistory: modules.HostDBScans{{Success: true}}}
+	offline := modules.HostDBEntry{ScanHistory: modules.HostDBScans{{Success: false}}}
+	newContract := func() modules.RenterContract {
+		return modules.RenterContract{
+			EndHeight:   1000,
+			RenterFunds: types.NewCurrency64(100),
+			TotalCost:   types.NewCurrency64(100),
+			Utility:     modules.ContractUtility{GoodForUpload: true, GoodForRenew: true},
+		}
+	}
+	renewWindow, period, blockHeight := types.BlockHeight(100), types.BlockHeight(500), types.BlockHeight(10)
+
+	// A healthy contract passes all checks.
+	if u, needsUpdate, _ := criticalUtilityChecks(newContract(), online, 1, false, renewWindow, period, blockHeight); needsUpdate || !u.GoodForUpload || !u.GoodForRenew {
+		t.Fatal("healthy contract failed the critical checks", u)
+	}
+
+	badContract := newContract()
+	badContract.Utility.BadContract = true
+	lowFunds := newContract()
+	lowFunds.RenterFunds = types.ZeroCurrency
+	oos := newContract()
+	oos.Utility.LastOOSErr = blockHeight
+
+	tests := []struct {
+		name           string
+		contract       modules.RenterContract
+		host           modules.HostDBEntry
+		revisionNumber uint64
+		renewed        bool
+		blockHeight    types.BlockHeight
+		gfu            bool
+		gfr            bool
+		locked         bool
+	}{
+		{"renewed", newContract(), online, 1, true, blockHeight, false, false, false},
+		{"max revision", newContract(), online, math.MaxUint64, false, blockHeight, false, false, true},
+		{"bad contract", badContract, online, 1, false, blockHeight, false, false, false},
+		{"offline", newContract(), offline, 1, false, blockHeight, false, false, false},
+		{"up for renewal", newContract(), online, 1, false, 950, false, true, false},
+		{"insufficient funds", lowFunds, online, 1, false, blockHeight, false, true, false},
+		{"out of storage", oos, online, 1, false, blockHeight, false, true, false},
+	}
+	for _, test := range tests {
+		u, needsUpdate, reason := criticalUtilityChecks(test.contract, test.host, test.revisionNumber, test.renewed, renewWindow, period, test.blockHeight)
+		if !needsUpdate || reason == "" {
+			t.Errorf("%v: expected the checks to fail", test.name)
+		}
+		if u.GoodForUpload != test.gfu || u.GoodForRenew != test.gfr || u.Locked != test.locked {
+			t.Errorf("%v: got %v %v %v, expected %v %v %v", test.name, u.GoodForUpload, u.GoodForRenew, u.Locked, test.gfu, test.gfr, test.locked)
+		}
+	}
+}
+
+// TestCanChurn tests the budget checks of the churn limiter.
+func TestCanChurn(t *testing.T) {
+	tests := []struct {
+		size      uint64
+		remaining int
+		maxBudget int
+		aggregate uint64
+		maxPeriod uint64
+		canChurn  bool
+	}{
+		// Fits into both budgets.
+		{10, 20, 50, 10, 100, true},
+		// Doesn't fit into the remaining budget.
+		{30, 20, 50, 10, 100, false},
+		// Larger than the max budget but the budget is untouched.
+		{60, 50, 50, 10, 100, true},
+		// Doesn't fit into the period budget.
+		{10, 20, 50, 95, 100, false},
+		// Larger than the period budget but nothing was churned yet.
+		{200, 50, 50, 0, 100, true},
+	}
+	for i, test := range tests {
+		if canChurn(test.size, test.remaining, test.maxBudget, test.aggregate, test.maxPeriod) != test.canChurn {
+			t.Errorf("test %v: expected canChurn to be %v", i, test.canChurn)
+		}
+	}
+}
+
+// TestPlanLimitGFUHosts tests that planLimitGFUHosts marks the lowest scoring
+// contracts !GFU while keeping the contracts needed for host group targets.
+func TestPlanLimitGFUHosts(t *testing.T) {
+	planned := make([]plannedContract, 4)
+	for i := range planned {
+		planned[i].contract.HostPublicKey.Key = []byte{byte(i)}
+		planned[i].score = types.NewCurrency64(uint64(i + 1))
+		planned[i].utility.GoodForUpload = true
+	}
+	gfu := func() (n int) {
+		for _, pc := range planned {
+			if pc.utility.GoodForUpload {
+				n++
+			}
+		}
+		return
+	}
+
+	// The lowest scoring host belongs to a group with a target.
+	groupsByHost := modules.HostGroupsByHost(map[string][]types.TurtleDexPublicKey{
+		"archival": {planned[0].contract.HostPublicKey},
+	})
+	a := modules.Allowance{
+		Hosts:            2,
+		HostGroupTargets: map[string]uint64{"archival": 1},
+	}
+	planLimitGFUHosts(planned, a, groupsByHost)
+	if gfu() != 2 {
+		t.Fatal("expected 2 gfu contracts, got", gfu())
+	}
+	if !planned[0].utility.GoodForUpload || planned[1].utility.GoodForUpload || planned[2].utility.GoodForUpload || !planned[3].utility.GoodForUpload {
+		t.Fatal("wrong contracts were marked !GFU")
+	}
+	if planned[1].reason == "" {
+		t.Fatal("reason wasn't set")
+	}
+
+	// Without the target the lowest scoring contract is marked !GFU.
+	planLimitGFUHosts(planned, modules.Allowance{Hosts: 1}, groupsByHost)
+	if gfu() != 1 || !planned[3].utility.GoodForUpload {
+		t.Fatal("highest scoring contract should be the only gfu contract")
+	}
+}
+
+// TestChurnSuggestedUpdates tests that churnSuggestedUpdates churns the lowest
+// scoring contracts until the churn budget is used up.
+func TestChurnSuggestedUpdates(t *testing.T) {
+	contract := func(size, score uint64) contractScoreAndUtil {
+		var c modules.RenterContract
+		c.ID[0] = byte(score)
+		c.Utility.GoodForRenew = true
+		c.Transaction.FileContractRevisions = []types.FileContractRevision{{NewFileSize: size}}
+		return contractScoreAndUtil{contract: c, score: types.NewCurrency64(score)}
+	}
+	queue := []contractScoreAndUtil{contract(10, 3), contract(10, 1), contract(10, 2)}
+	// Keeping the contract GFR doesn't count as churn.
+	gfr := contract(10, 4)
+	gfr.util.GoodForRenew = true
+	queue = append(queue, gfr)
+
+	churned, avoided := churnSuggestedUpdates(queue, 20, 50, 10, 100)
+	for i := range queue {
+		if queue[i].score.Cmp64(uint64(i+1)) != 0 {
+			t.Fatal("queue wasn't sorted by score")
+		}
+	}
+	if !churned[0] || !churned[1] || churned[2] || churned[3] {
+		t.Fatal("wrong contracts were churned", churned)
+	}
+	if avoided[0] || avoided[1] || !avoided[2] || avoided[3] {
+		t.Fatal("wrong contracts avoided churn", avoided)
+	}
+	if queue[0].util.GoodForRenew || queue[1].util.GoodForRenew || !queue[2].util.GoodForRenew {
+		t.Fatal("utility of the contract that avoided churn wasn't updated")
+	}
+}
@@ -53,6 +53,10 @@ type Session interface {
 	// Upload revises the underlying contract to store the new data. It
 	// returns the Merkle root of the data.
 	Upload(data []byte) (crypto.Hash, error)
+
+	// UploadSample uploads data to the host without storing it. Only the
+	// bandwidth is paid for.
+	UploadSample(data []byte) error
 }
 
 // A hostSession modifies a Contract via the renter-host RPC loop. It
@@ -123,10 +127,12 @@ func (hs *hostSession) Download(root crypto.Hash, offset, length uint32) ([]byte
 	}
 
 	// Download the data.
-	_, data, err := hs.session.ReadSection(root, offset, length)
+	before, _ := hs.contractor.staticContracts.View(hs.id)
+	after, data, err := hs.session.ReadSection(root, offset, length)
 	if err != nil {
 		return nil, err
 	}
+	hs.contractor.managedRecordRevisionSpending(before, after)
 	return data, nil
 }
 
@@ -139,6 +145,7 @@ func (hs *hostSession) DownloadIndex(index uint64, offset, length uint32) ([]byt
 	}
 
 	// Retrieve the Merkle root for the index.
+	before, _ := hs.contractor.staticContracts.View(hs.id)
 	_, roots, err := hs.session.SectorRoots(modules.LoopSectorRootsRequest{
 		RootOffset: index,
 		NumRoots:   1,
@@ -148,10 +155,11 @@ func (hs *hostSession) DownloadIndex(index uint64, offset, length uint32) ([]byt
 	}
 
 	// Download the data.
-	_, data, err := hs.session.ReadSection(roots[0], offset, length)
+	after, data, err := hs.session.ReadSection(roots[0], offset, length)
 	if err != nil {
 		return nil, err
 	}
+	hs.contractor.managedRecordRevisionSpending(before, after)
 	return data, nil
 }
 
@@ -168,15 +176,35 @@ func (hs *hostSession) Upload(data []byte) (crypto.Hash, error) {
 	}
 
 	// Perform the upload.
-	_, sectorRoot, err := hs.session.Append(data)
+	before, _ := hs.contractor.staticContracts.View(hs.id)
+	after, sectorRoot, err := hs.session.Append(data)
 	if err != nil {
 		// Return the sector root so that it can be logged and used for
 		// debugging in the event of an error.
 		return sectorRoot, err
 	}
+	hs.contractor.managedRecordRevisionSpending(before, after)
 	return sectorRoot, nil
 }
 
+// UploadSample negotiates a revision that adds a sector to a file contract and
+// removes it again.
+func (hs *hostSession) UploadSample(data []byte) error {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	if hs.invalid {
+		return errInvalidSession
+	}
+
+	before, _ := hs.contractor.staticContracts.View(hs.id)
+	after, err := hs.session.AppendAndTrim(data)
+	if err != nil {
+		return err
+	}
+	hs.contractor.managedRecordRevisionSpending(before, after)
+	return nil
+}
+
 // Replace replaces the sector at the specified index with data.
 func (hs *hostSession) Replace(data []byte, sectorIndex uint64, trim bool) (crypto.Hash, error) {
 	hs.mu.Lock()
@@ -185,10 +213,12 @@ func (hs *hostSession) Replace(data []byte, sectorIndex uint64, trim bool) (cryp
 		return crypto.Hash{}, errInvalidSession
 	}
 
-	_, sectorRoot, err := hs.session.Replace(data, sectorIndex, trim)
+	before, _ := hs.contractor.staticContracts.View(hs.id)
+	after, sectorRoot, err := hs.session.Replace(data, sectorIndex, trim)
 	if err != nil {
 		return crypto.Hash{}, errors.AddContext(err, "unable to perform replace operation in session")
 	}
+	hs.contractor.managedRecordRevisionSpending(before, after)
 	return sectorRoot, nil
 }
 
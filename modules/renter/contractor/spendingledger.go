@@ -0,0 +1,217 @@
+package contractor
+
+// The spending ledger records every payment the contractor makes. Records are
+// appended to a persist file and never modified. Since the ledger can grow
+// large, it isn't kept in memory. Queries read the records from disk.
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/turtledex/encoding"
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/persist"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+const (
+	// spendingLedgerFile is the name of the file the spending ledger is
+	// persisted to.
+	spendingLedgerFile = "spendingledger"
+)
+
+var (
+	// spendingLedgerMetadataHeader is the header of the metadata for the
+	// persist file of the spending ledger.
+	spendingLedgerMetadataHeader = types.NewSpecifier("SpendingLedger\n")
+
+	// spendingLedgerMetadataVersion is the version of the persist file of the
+	// spending ledger.
+	spendingLedgerMetadataVersion = types.NewSpecifier("v1.5.5\n")
+)
+
+type (
+	// spendingLedger is the append-only ledger of the contractor's spending.
+	spendingLedger struct {
+		aop *persist.AppendOnlyPersist
+	}
+
+	// spendingRecord is the persisted version of a record of the spending
+	// ledger.
+	spendingRecord struct {
+		Type          string
+		Amount        types.Currency
+		Fees          types.Currency
+		HostPublicKey types.TurtleDexPublicKey
+		ContractID    types.FileContractID
+		BlockHeight   types.BlockHeight
+		Timestamp     int64
+	}
+)
+
+// newSpendingLedger loads the spending ledger from disk or creates a new one.
+func newSpendingLedger(persistDir string) (*spendingLedger, error) {
+	aop, _, err := persist.NewAppendOnlyPersist(persistDir, spendingLedgerFile, spendingLedgerMetadataHeader, spendingLedgerMetadataVersion)
+	if err != nil {
+		return nil, errors.AddContext(err, fmt.Sprintf("unable to initialize the spending ledger at '%v'", filepath.Join(persistDir, spendingLedgerFile)))
+	}
+	return &spendingLedger{aop: aop}, nil
+}
+
+// newSpendingRecord creates the persisted version of a record.
+func newSpendingRecord(r modules.SpendingRecord) spendingRecord {
+	return spendingRecord{
+		Type:          string(r.Type),
+		Amount:        r.Amount,
+		Fees:          r.Fees,
+		HostPublicKey: r.HostPublicKey,
+		ContractID:    r.ContractID,
+		BlockHeight:   r.BlockHeight,
+		Timestamp:     r.Timestamp.UnixNano(),
+	}
+}
+
+// spendingRecord returns the record of the ledger for the persisted record.
+func (r spendingRecord) spendingRecord() modules.SpendingRecord {
+	return modules.SpendingRecord{
+		Type:          modules.SpendingType(r.Type),
+		Amount:        r.Amount,
+		Fees:          r.Fees,
+		HostPublicKey: r.HostPublicKey,
+		ContractID:    r.ContractID,
+		BlockHeight:   r.BlockHeight,
+		Timestamp:     time.Unix(0, r.Timestamp),
+	}
+}
+
+// Close closes the persist file of the ledger.
+func (sl *spendingLedger) Close() error {
+	return sl.aop.Close()
+}
+
+// Append appends the records to the ledger.
+func (sl *spendingLedger) Append(records ...modules.SpendingRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	enc := encoding.NewEncoder(&buf)
+	for _, r := range records {
+		if err := enc.Encode(newSpendingRecord(r)); err != nil {
+			return errors.AddContext(err, "unable to encode spending record")
+		}
+	}
+	_, err := sl.aop.Write(buf.Bytes())
+	if err != nil {
+		return errors.AddContext(err, fmt.Sprintf("unable to update spending ledger at '%v'", sl.aop.FilePath()))
+	}
+	return nil
+}
+
+// History returns the records of the ledger which match the filter in the
+// order they were appended.
+func (sl *spendingLedger) History(filter modules.SpendingHistoryFilter) (_ []modules.SpendingRecord, err error) {
+	// Only read the records that were written when the history was requested.
+	length := sl.aop.PersistLength()
+	f, err := os.Open(sl.aop.FilePath())
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to open spending ledger")
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+	r := io.NewSectionReader(f, int64(persist.MetadataPageSize), int64(length-persist.MetadataPageSize))
+
+	var records []modules.SpendingRecord
+	d := encoding.NewDecoder(r, encoding.DefaultAllocLimit)
+	for {
+		var sr spendingRecord
+		err := d.Decode(&sr)
+		if errors.Contains(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, errors.AddContext(err, "unable to decode spending record")
+		}
+		record := sr.spendingRecord()
+		if !filter.Matches(record) {
+			continue
+		}
+		records = append(records, record)
+		// Only keep the most recent records if there is a limit.
+		if filter.Limit > 0 && uint64(len(records)) > 2*filter.Limit {
+			records = append(records[:0], records[uint64(len(records))-filter.Limit:]...)
+		}
+	}
+	if filter.Limit > 0 && uint64(len(records)) > filter.Limit {
+		records = records[uint64(len(records))-filter.Limit:]
+	}
+	return records, nil
+}
+
+// managedRecordSpending appends a record to the spending ledger. Failing to
+// record spending doesn't fail the payment, so errors are only logged.
+func (c *Contractor) managedRecordSpending(records ...modules.SpendingRecord) {
+	c.mu.RLock()
+	blockHeight := c.blockHeight
+	c.mu.RUnlock()
+	now := time.Now()
+	for i := range records {
+		records[i].BlockHeight = blockHeight
+		records[i].Timestamp = now
+	}
+	if err := c.staticSpendingLedger.Append(records...); err != nil {
+		c.log.Println("WARN: unable to record spending:", err)
+	}
+}
+
+// managedRecordRevisionSpending records the upload, download and storage
+// spending of a revision of a contract.
+func (c *Contractor) managedRecordRevisionSpending(before, after modules.RenterContract) {
+	var records []modules.SpendingRecord
+	add := func(t modules.SpendingType, spentBefore, spentAfter types.Currency) {
+		if spentAfter.Cmp(spentBefore) <= 0 {
+			return
+		}
+		records = append(records, modules.SpendingRecord{
+			Type:          t,
+			Amount:        spentAfter.Sub(spentBefore),
+			HostPublicKey: after.HostPublicKey,
+			ContractID:    after.ID,
+		})
+	}
+	add(modules.SpendingTypeUpload, before.UploadSpending, after.UploadSpending)
+	add(modules.SpendingTypeDownload, before.DownloadSpending, after.DownloadSpending)
+	add(modules.SpendingTypeStorage, before.StorageSpending, after.StorageSpending)
+	if len(records) > 0 {
+		c.managedRecordSpending(records...)
+	}
+}
+
+// managedRecordContractSpending records the funds allocated to a newly formed
+// or renewed contract.
+func (c *Contractor) managedRecordContractSpending(t modules.SpendingType, contract modules.RenterContract) {
+	c.managedRecordSpending(modules.SpendingRecord{
+		Type:          t,
+		Amount:        contract.TotalCost,
+		Fees:          contract.ContractFee.Add(contract.TxnFee).Add(contract.TurtleDexfundFee),
+		HostPublicKey: contract.HostPublicKey,
+		ContractID:    contract.ID,
+	})
+}
+
+// SpendingHistory returns the records of the spending ledger which match the
+// filter.
+func (c *Contractor) SpendingHistory(filter modules.SpendingHistoryFilter) ([]modules.SpendingRecord, error) {
+	if err := c.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer c.tg.Done()
+	return c.staticSpendingLedger.History(filter)
+}
@@ -0,0 +1,108 @@
+package contractor
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+// TestSpendingLedger tests appending records to the spending ledger, querying
+// them and reloading the ledger from disk.
+func TestSpendingLedger(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("contractor", t.Name())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	sl, err := newSpendingLedger(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An empty ledger has no history.
+	records, err := sl.History(modules.SpendingHistoryFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 0 {
+		t.Fatal("expected empty history", records)
+	}
+
+	// Append some records.
+	var hpk types.TurtleDexPublicKey
+	hpk.Key = []byte{1}
+	now := time.Now()
+	var appended []modules.SpendingRecord
+	for i := 0; i < 10; i++ {
+		spendingType := modules.SpendingTypeUpload
+		if i%2 == 0 {
+			spendingType = modules.SpendingTypeDownload
+		}
+		appended = append(appended, modules.SpendingRecord{
+			Type:          spendingType,
+			Amount:        types.NewCurrency64(uint64(i + 1)),
+			HostPublicKey: hpk,
+			ContractID:    types.FileContractID{byte(i)},
+			BlockHeight:   types.BlockHeight(i),
+			Timestamp:     now.Add(time.Duration(i) * time.Second),
+		})
+	}
+	if err := sl.Append(appended[:5]...); err != nil {
+		t.Fatal(err)
+	}
+	if err := sl.Append(appended[5:]...); err != nil {
+		t.Fatal(err)
+	}
+
+	// checkHistory checks that the history for the filter contains the
+	// records with the expected block heights.
+	checkHistory := func(sl *spendingLedger, filter modules.SpendingHistoryFilter, heights ...types.BlockHeight) {
+		t.Helper()
+		records, err := sl.History(filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != len(heights) {
+			t.Fatalf("expected %v records but got %v", len(heights), len(records))
+		}
+		for i, r := range records {
+			expected := appended[heights[i]]
+			if r.BlockHeight != heights[i] || r.Type != expected.Type || !r.Amount.Equals(expected.Amount) ||
+				r.ContractID != expected.ContractID || !r.HostPublicKey.Equals(hpk) || !r.Timestamp.Equal(expected.Timestamp) {
+				t.Fatalf("unexpected record %v, expected %v", r, expected)
+			}
+		}
+	}
+	checkHistory(sl, modules.SpendingHistoryFilter{}, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+	uploads := modules.SpendingHistoryFilter{Types: []modules.SpendingType{modules.SpendingTypeUpload}}
+	checkHistory(sl, uploads, 1, 3, 5, 7, 9)
+
+	// The limit returns the most recent records.
+	checkHistory(sl, modules.SpendingHistoryFilter{Limit: 3}, 7, 8, 9)
+	uploads.Limit = 1
+	checkHistory(sl, uploads, 9)
+	checkHistory(sl, modules.SpendingHistoryFilter{MinHeight: 2, MaxHeight: 4, Limit: 10}, 2, 3, 4)
+
+	// Reload the ledger.
+	if err := sl.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sl, err = newSpendingLedger(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := sl.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	checkHistory(sl, modules.SpendingHistoryFilter{Start: now.Add(8 * time.Second)}, 8, 9)
+}
@@ -0,0 +1,699 @@
+package renter
+
+// Chunks that are uploaded for the first time are deduplicated by their
+// content. Before a chunk is encrypted, its data is hashed using a convergent
+// key which is derived from the renter's seed. The resulting id is stored in
+// the extension info of the chunk and the pieces of the chunk are encrypted
+// with a key derived from the id instead of the file's master key. That way
+// identical chunks result in identical pieces, no matter which file they
+// belong to.
+//
+// The dedup index maps the ids to the pieces that were uploaded for them and
+// the chunks that reference them. The convergent key is never persisted, it is
+// derived from the renter seed again after every restart. If a chunk with a
+// known id is uploaded and the existing pieces are still healthy, the pieces
+// are added to the new file instead of uploading the data again. The pieces are
+// only dropped from the index once the last file referencing them is deleted.
+//
+// The index is kept in memory and persisted to an append-only file. Every
+// change to the references or pieces of an entry appends a record to the file.
+// Once the file contains too many outdated records, it is compacted by writing
+// the current entries to a new file which replaces the old one.
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem/siafile"
+	"github.com/turtledex/TurtleDexCore/persist"
+	"github.com/turtledex/TurtleDexCore/types"
+	"github.com/turtledex/encoding"
+	"github.com/turtledex/errors"
+	"github.com/turtledex/fastrand"
+)
+
+const (
+	// dedupPersistFile is the name of the file the dedup index is persisted
+	// to.
+	dedupPersistFile = "dedup"
+
+	// dedupPersistTempFile is the name of the file the dedup index is written
+	// to while it is compacted.
+	dedupPersistTempFile = dedupPersistFile + "_temp"
+
+	// dedupLegacyPersistFile is the name of the JSON file the dedup index was
+	// persisted to before it was made append-only.
+	dedupLegacyPersistFile = "dedup.json"
+
+	// dedupLegacyPersistVersion is the version of the JSON file the dedup
+	// index was persisted to before it was made append-only.
+	dedupLegacyPersistVersion = "1.5.5"
+
+	// chunkExtensionDedup is the first byte of the extension info of a chunk
+	// which was deduplicated. The remaining bytes contain the dedupID of the
+	// chunk.
+	chunkExtensionDedup = 1
+)
+
+var (
+	// dedupCompactionThreshold is the minimum number of outdated records in
+	// the persist file of the dedup index before it is compacted. The file is
+	// only compacted if it also contains more outdated records than current
+	// ones.
+	dedupCompactionThreshold = build.Select(build.Var{
+		Dev:      uint64(1000),
+		Standard: uint64(100000),
+		Testing:  uint64(10),
+	}).(uint64)
+
+	// dedupMetadataHeader is the header of the metadata for the persist file
+	// of the dedup index.
+	dedupMetadataHeader = types.NewSpecifier("DedupIndex\n")
+
+	// dedupMetadataVersion is the version of the persist file of the dedup
+	// index.
+	dedupMetadataVersion = types.NewSpecifier("v1.5.5\n")
+
+	// dedupLegacyMetadata is the metadata of the JSON file the dedup index was
+	// persisted to before it was made append-only.
+	dedupLegacyMetadata = persist.Metadata{
+		Header:  "Renter Dedup Index",
+		Version: dedupLegacyPersistVersion,
+	}
+
+	// dedupKeySpecifier is the specifier used to derive the convergent key
+	// from the renter seed.
+	dedupKeySpecifier = types.NewSpecifier("dedup")
+)
+
+type (
+	// dedupID identifies the content of a deduplicated chunk.
+	dedupID [15]byte
+
+	// dedupReference is a reference of a chunk of a file to a deduplicated
+	// chunk.
+	dedupReference struct {
+		id  dedupID
+		ref string
+	}
+
+	// dedupEntry is an entry of the dedup index.
+	dedupEntry struct {
+		// Pieces are the pieces of the chunk that were uploaded most recently.
+		Pieces [][]siafile.Piece `json:"pieces"`
+
+		// References contains the chunks that reference the entry, identified
+		// by the UID of their file and their index.
+		References map[string]struct{} `json:"references"`
+	}
+
+	// dedupLegacyPersist is the state of the dedup index as it was persisted
+	// to the legacy JSON file.
+	dedupLegacyPersist struct {
+		Entries map[string]dedupEntry `json:"entries"`
+	}
+
+	// dedupRecord is a change of an entry of the dedup index. The references
+	// are either added to or removed from the entry. If the pieces are
+	// updated, the entry is created if it doesn't exist yet. Otherwise
+	// references are only added to existing entries.
+	dedupRecord struct {
+		ID            dedupID
+		References    []string
+		Removed       bool
+		UpdatesPieces bool
+		Pieces        [][]siafile.Piece
+	}
+
+	// dedupIndex keeps track of the chunks that were uploaded by the renter to
+	// allow for deduplicating chunks with the same content.
+	dedupIndex struct {
+		convergentKey crypto.Hash
+		entries       map[dedupID]dedupEntry
+
+		// numRecords is the number of records in the persist file.
+		numRecords uint64
+
+		aop              *persist.AppendOnlyPersist
+		staticPersistDir string
+		mu               sync.Mutex
+	}
+)
+
+// newDedupIndex loads the dedup index from disk or creates a new one.
+func newDedupIndex(persistDir string) (*dedupIndex, error) {
+	aop, reader, err := persist.NewAppendOnlyPersist(persistDir, dedupPersistFile, dedupMetadataHeader, dedupMetadataVersion)
+	if err != nil {
+		return nil, errors.AddContext(err, fmt.Sprintf("unable to initialize the dedup index persistence at '%v'", filepath.Join(persistDir, dedupPersistFile)))
+	}
+	di := &dedupIndex{
+		entries:          make(map[dedupID]dedupEntry),
+		aop:              aop,
+		staticPersistDir: persistDir,
+	}
+	di.numRecords, err = unmarshalDedupRecords(reader, di.entries)
+	if err != nil {
+		return nil, errors.Compose(errors.AddContext(err, "unable to unmarshal dedup index"), aop.Close())
+	}
+	if err := di.managedConvertLegacyPersist(); err != nil {
+		return nil, errors.Compose(errors.AddContext(err, "unable to convert legacy dedup index"), di.managedClose())
+	}
+	if err := di.managedMaybeCompact(); err != nil {
+		return nil, errors.Compose(err, di.managedClose())
+	}
+	return di, nil
+}
+
+// applyDedupRecord applies a record to the entries of the dedup index.
+func applyDedupRecord(entries map[dedupID]dedupEntry, record dedupRecord) {
+	entry, exists := entries[record.ID]
+	if record.Removed {
+		if !exists {
+			return
+		}
+		for _, ref := range record.References {
+			delete(entry.References, ref)
+		}
+		if len(entry.References) == 0 {
+			delete(entries, record.ID)
+		}
+		return
+	}
+	if !exists && !record.UpdatesPieces {
+		return
+	}
+	if !exists {
+		entry.References = make(map[string]struct{})
+	}
+	if record.UpdatesPieces {
+		entry.Pieces = record.Pieces
+	}
+	for _, ref := range record.References {
+		entry.References[ref] = struct{}{}
+	}
+	entries[record.ID] = entry
+}
+
+// unmarshalDedupRecords reads the records from the reader and applies them to
+// the entries. The number of read records is returned.
+func unmarshalDedupRecords(reader io.Reader, entries map[dedupID]dedupEntry) (uint64, error) {
+	var numRecords uint64
+	d := encoding.NewDecoder(reader, encoding.DefaultAllocLimit)
+	for {
+		var record dedupRecord
+		err := d.Decode(&record)
+		if errors.Contains(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		numRecords++
+		applyDedupRecord(entries, record)
+	}
+	return numRecords, nil
+}
+
+// String returns the hex representation of the id.
+func (id dedupID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// extensionInfo returns the chunk extension info for a chunk that was
+// deduplicated using the id.
+func (id dedupID) extensionInfo() (ei [16]byte) {
+	ei[0] = chunkExtensionDedup
+	copy(ei[1:], id[:])
+	return
+}
+
+// dedupIDFromExtensionInfo returns the dedupID stored in a chunk's extension
+// info and whether the chunk was deduplicated.
+func dedupIDFromExtensionInfo(ei [16]byte) (id dedupID, ok bool) {
+	if ei[0] != chunkExtensionDedup {
+		return dedupID{}, false
+	}
+	copy(id[:], ei[1:])
+	return id, true
+}
+
+// dedupChunkID computes the dedupID of a chunk from its data pieces. Apart
+// from the data, the id also depends on all the parameters that affect the
+// pieces of the chunk.
+func dedupChunkID(convergentKey crypto.Hash, ct crypto.CipherType, ec modules.ErasureCoder, pieceSize, length uint64, dataPieces [][]byte) (id dedupID) {
+	h := crypto.NewHash()
+	_, _ = h.Write(convergentKey[:])
+	_, _ = h.Write(encoding.MarshalAll(ct, ec.Identifier(), pieceSize, length))
+	for _, piece := range dataPieces {
+		_, _ = h.Write(piece)
+	}
+	copy(id[:], h.Sum(nil))
+	return
+}
+
+// dedupChunkKey derives the key that is used to encrypt the pieces of a
+// deduplicated chunk. The pieces are derived from the key using the chunk
+// index 0.
+func dedupChunkKey(convergentKey crypto.Hash, id dedupID) (crypto.CipherKey, error) {
+	h0 := crypto.HashAll(convergentKey, id, uint64(0))
+	h1 := crypto.HashAll(convergentKey, id, uint64(1))
+	defer fastrand.Read(h0[:])
+	defer fastrand.Read(h1[:])
+	return crypto.NewTurtleDexKey(crypto.TypeThreefish, append(h0[:], h1[:]...))
+}
+
+// dedupChunkRef returns the reference of the chunk with the given index of the
+// file with the given UID.
+func dedupChunkRef(uid siafile.TurtleDexfileUID, chunkIndex uint64) string {
+	return fmt.Sprintf("%v/%v", uid, chunkIndex)
+}
+
+// managedConvergentKey returns the convergent key of the renter. The key is
+// derived from the wallet seed the first time it is needed after startup which
+// requires the wallet to be unlocked. It is only kept in memory.
+func (di *dedupIndex) managedConvergentKey(w modules.Wallet) (crypto.Hash, error) {
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	if di.convergentKey != (crypto.Hash{}) {
+		return di.convergentKey, nil
+	}
+	ws, _, err := w.PrimarySeed()
+	if err != nil {
+		return crypto.Hash{}, errors.AddContext(err, "failed to get wallet's primary seed")
+	}
+	rs := modules.DeriveRenterSeed(ws)
+	defer fastrand.Read(rs[:])
+	di.convergentKey = crypto.HashAll(rs, dedupKeySpecifier)
+	return di.convergentKey, nil
+}
+
+// managedAddReference adds a reference to the entry with the given id and
+// updates its pieces.
+func (di *dedupIndex) managedAddReference(id dedupID, ref string, pieces [][]siafile.Piece) error {
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	return di.applyRecords([]dedupRecord{{
+		ID:            id,
+		References:    []string{ref},
+		UpdatesPieces: true,
+		Pieces:        pieces,
+	}})
+}
+
+// managedAddReferences adds the provided references to the index without
+// updating the pieces of the entries. References to entries which no longer
+// exist are ignored.
+func (di *dedupIndex) managedAddReferences(refs []dedupReference) error {
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	var records []dedupRecord
+	for _, ref := range refs {
+		if _, exists := di.entries[ref.id]; !exists {
+			continue
+		}
+		records = append(records, dedupRecord{
+			ID:         ref.id,
+			References: []string{ref.ref},
+		})
+	}
+	return di.applyRecords(records)
+}
+
+// managedClose closes the persist file of the dedup index.
+func (di *dedupIndex) managedClose() error {
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	return di.aop.Close()
+}
+
+// managedConvertLegacyPersist moves the entries of the legacy JSON file to the
+// append-only file and removes the JSON file. Older versions of the JSON file
+// contained the convergent key in plaintext, which is dropped from disk that
+// way.
+func (di *dedupIndex) managedConvertLegacyPersist() error {
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	legacyPath := filepath.Join(di.staticPersistDir, dedupLegacyPersistFile)
+	var legacy dedupLegacyPersist
+	err := persist.LoadJSON(dedupLegacyMetadata, &legacy, legacyPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.AddContext(err, "failed to load legacy dedup index")
+	}
+	for idStr, entry := range legacy.Entries {
+		var id dedupID
+		b, err := hex.DecodeString(idStr)
+		if err != nil || len(b) != len(id) {
+			return fmt.Errorf("invalid dedup id '%v' in legacy dedup index", idStr)
+		}
+		copy(id[:], b)
+		if entry.References == nil {
+			entry.References = make(map[string]struct{})
+		}
+		di.entries[id] = entry
+	}
+	err = di.compact()
+	if err != nil {
+		return err
+	}
+	return os.Remove(legacyPath)
+}
+
+// managedMaybeCompact compacts the persist file if it contains too many
+// outdated records.
+func (di *dedupIndex) managedMaybeCompact() error {
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	return di.maybeCompact()
+}
+
+// managedPieces returns the pieces stored for the entry with the given id.
+func (di *dedupIndex) managedPieces(id dedupID) ([][]siafile.Piece, bool) {
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	entry, exists := di.entries[id]
+	return entry.Pieces, exists
+}
+
+// managedRemoveReferences removes the provided references from the index.
+// Entries without references are removed from the index.
+func (di *dedupIndex) managedRemoveReferences(refs []dedupReference) error {
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	var records []dedupRecord
+	for _, ref := range refs {
+		if _, exists := di.entries[ref.id]; !exists {
+			continue
+		}
+		records = append(records, dedupRecord{
+			ID:         ref.id,
+			References: []string{ref.ref},
+			Removed:    true,
+		})
+	}
+	return di.applyRecords(records)
+}
+
+// managedStatus returns the status of the dedup index.
+func (di *dedupIndex) managedStatus() (ds modules.DedupStatus) {
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	for _, entry := range di.entries {
+		refs := uint64(len(entry.References))
+		ds.UniqueChunks++
+		ds.ChunkReferences += refs
+		if refs > 1 {
+			ds.StorageSaved += (refs - 1) * uint64(len(entry.Pieces)) * modules.SectorSize
+		}
+	}
+	return
+}
+
+// applyRecords applies the records to the entries of the index and appends
+// them to the persist file.
+func (di *dedupIndex) applyRecords(records []dedupRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	e := encoding.NewEncoder(&buf)
+	for _, record := range records {
+		if err := e.Encode(record); err != nil {
+			return errors.AddContext(err, "unable to encode dedup record")
+		}
+	}
+	for _, record := range records {
+		applyDedupRecord(di.entries, record)
+	}
+	_, err := di.aop.Write(buf.Bytes())
+	if err != nil {
+		return errors.AddContext(err, fmt.Sprintf("unable to update dedup index persistence at '%v'", di.aop.FilePath()))
+	}
+	di.numRecords += uint64(len(records))
+	return di.maybeCompact()
+}
+
+// maybeCompact compacts the persist file if it contains too many outdated
+// records.
+func (di *dedupIndex) maybeCompact() error {
+	numEntries := uint64(len(di.entries))
+	if di.numRecords < numEntries {
+		return nil
+	}
+	outdated := di.numRecords - numEntries
+	if outdated < dedupCompactionThreshold || outdated < numEntries {
+		return nil
+	}
+	return di.compact()
+}
+
+// compact writes the current entries to a new persist file which replaces the
+// existing one. Every entry is written as a single record.
+func (di *dedupIndex) compact() error {
+	// Remove leftovers of a previous compaction.
+	tempPath := filepath.Join(di.staticPersistDir, dedupPersistTempFile)
+	if err := os.Remove(tempPath); err != nil && !os.IsNotExist(err) {
+		return errors.AddContext(err, "unable to remove temporary dedup index file")
+	}
+
+	// Write the entries to the temporary file.
+	aop, _, err := persist.NewAppendOnlyPersist(di.staticPersistDir, dedupPersistTempFile, dedupMetadataHeader, dedupMetadataVersion)
+	if err != nil {
+		return errors.AddContext(err, "unable to create temporary dedup index file")
+	}
+	var buf bytes.Buffer
+	e := encoding.NewEncoder(&buf)
+	for id, entry := range di.entries {
+		record := dedupRecord{
+			ID:            id,
+			References:    make([]string, 0, len(entry.References)),
+			UpdatesPieces: true,
+			Pieces:        entry.Pieces,
+		}
+		for ref := range entry.References {
+			record.References = append(record.References, ref)
+		}
+		if err := e.Encode(record); err != nil {
+			return errors.Compose(errors.AddContext(err, "unable to encode dedup index"), aop.Close())
+		}
+	}
+	_, err = aop.Write(buf.Bytes())
+	err = errors.Compose(err, aop.Close())
+	if err != nil {
+		return errors.AddContext(err, "unable to write temporary dedup index file")
+	}
+
+	// Replace the persist file.
+	if err := di.aop.Close(); err != nil {
+		return errors.AddContext(err, "unable to close dedup index file")
+	}
+	err = os.Rename(tempPath, filepath.Join(di.staticPersistDir, dedupPersistFile))
+	if err != nil {
+		return errors.AddContext(err, "unable to replace dedup index file")
+	}
+	di.aop, _, err = persist.NewAppendOnlyPersist(di.staticPersistDir, dedupPersistFile, dedupMetadataHeader, dedupMetadataVersion)
+	if err != nil {
+		return errors.AddContext(err, "unable to reopen dedup index file")
+	}
+	di.numRecords = uint64(len(di.entries))
+	return nil
+}
+
+// DedupStatus returns information about the chunks deduplicated by the
+// renter.
+func (r *Renter) DedupStatus() (modules.DedupStatus, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.DedupStatus{}, err
+	}
+	defer r.tg.Done()
+	return r.staticDedupIndex.managedStatus(), nil
+}
+
+// managedChunkKey returns the key that the pieces of a chunk are derived from
+// and the chunk index to use for the derivation.
+func (r *Renter) managedChunkKey(masterKey crypto.CipherKey, ei [16]byte, chunkIndex uint64) (crypto.CipherKey, uint64, error) {
+	id, ok := dedupIDFromExtensionInfo(ei)
+	if !ok {
+		return masterKey, chunkIndex, nil
+	}
+	convergentKey, err := r.staticDedupIndex.managedConvergentKey(r.w)
+	if err != nil {
+		return nil, 0, errors.AddContext(err, "unable to get convergent key for deduplicated chunk")
+	}
+	key, err := dedupChunkKey(convergentKey, id)
+	if err != nil {
+		return nil, 0, errors.AddContext(err, "unable to derive key for deduplicated chunk")
+	}
+	return key, 0, nil
+}
+
+// managedDeduplicateChunk is called after the logical data of a chunk was
+// read for the first time. It assigns the chunk a dedupID and switches the
+// chunk to the convergent encryption. If the pieces of a chunk with the same
+// id are still healthy, they are added to the file and true is returned to
+// indicate that the chunk doesn't need to be uploaded anymore.
+func (r *Renter) managedDeduplicateChunk(uc *unfinishedUploadChunk, length uint64) bool {
+	// Only fresh chunks of regular files are deduplicated. Skyfiles need to
+	// be decryptable using only their master key.
+	if !r.managedDedupEligible(uc) {
+		return false
+	}
+	convergentKey, err := r.staticDedupIndex.managedConvergentKey(r.w)
+	if err != nil {
+		r.repairLog.Printf("Skipping deduplication of chunk %v of %s: %v", uc.staticIndex, uc.staticTurtleDexPath, err)
+		return false
+	}
+	ec := uc.fileEntry.ErasureCode()
+	id := dedupChunkID(convergentKey, uc.fileEntry.MasterKey().Type(), ec, uc.fileEntry.PieceSize(), length, uc.logicalChunkData[:ec.MinPieces()])
+	key, err := dedupChunkKey(convergentKey, id)
+	if err != nil {
+		r.repairLog.Printf("Skipping deduplication of chunk %v of %s: %v", uc.staticIndex, uc.staticTurtleDexPath, err)
+		return false
+	}
+	err = uc.fileEntry.SetChunkExtensionInfo(uc.staticIndex, id.extensionInfo())
+	if err != nil {
+		r.repairLog.Printf("Skipping deduplication of chunk %v of %s: %v", uc.staticIndex, uc.staticTurtleDexPath, err)
+		return false
+	}
+	uc.chunkKey, uc.chunkKeyIndex = key, 0
+
+	// Check if there are healthy pieces for the chunk already.
+	pieces, exists := r.staticDedupIndex.managedPieces(id)
+	if !exists {
+		return false
+	}
+	offline, goodForRenew, _ := r.managedContractUtilityMaps()
+	goodPieces := healthyDedupPieces(pieces, ec.NumPieces(), offline, goodForRenew)
+	if goodPieces == nil {
+		return false
+	}
+	for pieceIndex, piece := range goodPieces {
+		err = uc.fileEntry.AddPiece(piece.HostPubKey, uc.staticIndex, uint64(pieceIndex), piece.MerkleRoot)
+		if err != nil {
+			// The pieces that were added so far are still valid, the rest of
+			// them will be uploaded.
+			r.repairLog.Printf("Failed to add deduplicated piece to chunk %v of %s: %v", uc.staticIndex, uc.staticTurtleDexPath, err)
+			return false
+		}
+	}
+	return true
+}
+
+// managedRegisterDedupChunk adds a completed chunk to the dedup index if it
+// was deduplicated.
+func (r *Renter) managedRegisterDedupChunk(uc *unfinishedUploadChunk) {
+	uc.mu.Lock()
+	available := uc.piecesCompleted >= uc.staticMinimumPieces
+	uc.mu.Unlock()
+	if !available {
+		return
+	}
+	ei, err := uc.fileEntry.ChunkExtensionInfo(uc.staticIndex)
+	if err != nil {
+		r.log.Printf("Unable to fetch extension info of chunk %v of %s: %v", uc.staticIndex, uc.staticTurtleDexPath, err)
+		return
+	}
+	id, ok := dedupIDFromExtensionInfo(ei)
+	if !ok {
+		return
+	}
+	pieces, err := uc.fileEntry.Pieces(uc.staticIndex)
+	if err != nil {
+		r.log.Printf("Unable to fetch pieces of chunk %v of %s: %v", uc.staticIndex, uc.staticTurtleDexPath, err)
+		return
+	}
+	err = r.staticDedupIndex.managedAddReference(id, dedupChunkRef(uc.fileEntry.UID(), uc.staticIndex), pieces)
+	if err != nil {
+		r.log.Printf("Unable to add chunk %v of %s to dedup index: %v", uc.staticIndex, uc.staticTurtleDexPath, err)
+	}
+}
+
+// managedFileDedupReferences returns the references of the file at siaPath to
+// deduplicated chunks.
+func (r *Renter) managedFileDedupReferences(siaPath modules.TurtleDexPath) ([]dedupReference, error) {
+	node, err := r.staticFileSystem.OpenTurtleDexFile(siaPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := node.Close(); err != nil {
+			r.log.Printf("Unable to close file %v: %v", siaPath, err)
+		}
+	}()
+	uid := node.UID()
+	var refs []dedupReference
+	for chunkIndex := uint64(0); chunkIndex < node.NumChunks(); chunkIndex++ {
+		ei, err := node.ChunkExtensionInfo(chunkIndex)
+		if err != nil {
+			return nil, err
+		}
+		id, ok := dedupIDFromExtensionInfo(ei)
+		if !ok {
+			continue
+		}
+		refs = append(refs, dedupReference{
+			id:  id,
+			ref: dedupChunkRef(uid, chunkIndex),
+		})
+	}
+	return refs, nil
+}
+
+// managedDedupEligible returns whether the chunk may be deduplicated. Only
+// chunks without any pieces that belong to regular files are deduplicated.
+func (r *Renter) managedDedupEligible(uc *unfinishedUploadChunk) bool {
+	if uc.fileEntry.MasterKey().Type() != crypto.TypeThreefish {
+		return false
+	}
+	siaPath := r.staticFileSystem.FileTurtleDexPath(uc.fileEntry)
+	if siaPath.IsWithin(modules.SkynetFolder) {
+		return false
+	}
+	var zeroHash crypto.Hash
+	for _, root := range uc.staticExpectedPieceRoots {
+		if root != zeroHash {
+			return false
+		}
+	}
+	return true
+}
+
+// healthyDedupPieces returns one piece for every piece index of a chunk. If
+// there isn't a piece on a good host for every index, nil is returned.
+func healthyDedupPieces(pieces [][]siafile.Piece, numPieces int, offline, goodForRenew map[string]bool) []siafile.Piece {
+	if len(pieces) != numPieces {
+		return nil
+	}
+	goodPieces := make([]siafile.Piece, numPieces)
+	usedHosts := make(map[string]struct{})
+	for pieceIndex, pieceSet := range pieces {
+		found := false
+		for _, piece := range pieceSet {
+			hpk := piece.HostPubKey.String()
+			gfr, exists := goodForRenew[hpk]
+			off, exists2 := offline[hpk]
+			_, used := usedHosts[hpk]
+			if !exists || !gfr || !exists2 || off || used {
+				continue
+			}
+			goodPieces[pieceIndex] = piece
+			usedHosts[hpk] = struct{}{}
+			found = true
+			break
+		}
+		if !found {
+			return nil
+		}
+	}
+	return goodPieces
+}
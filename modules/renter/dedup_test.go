@@ -0,0 +1,337 @@
+package renter
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem/siafile"
+	"github.com/turtledex/TurtleDexCore/persist"
+	"github.com/turtledex/TurtleDexCore/types"
+	"github.com/turtledex/fastrand"
+)
+
+// TestDedupChunkID tests the computation of dedup ids and keys.
+func TestDedupChunkID(t *testing.T) {
+	var key crypto.Hash
+	fastrand.Read(key[:])
+	ec := modules.NewRSSubCodeDefault()
+	pieceSize := uint64(crypto.SegmentSize)
+	data := make([][]byte, ec.MinPieces())
+	for i := range data {
+		data[i] = fastrand.Bytes(int(pieceSize))
+	}
+
+	// The same data should result in the same id.
+	id := dedupChunkID(key, crypto.TypeThreefish, ec, pieceSize, 100, data)
+	if id2 := dedupChunkID(key, crypto.TypeThreefish, ec, pieceSize, 100, data); id != id2 {
+		t.Fatal("ids don't match", id, id2)
+	}
+	// Changing the length, the key or the data should change the id.
+	if id2 := dedupChunkID(key, crypto.TypeThreefish, ec, pieceSize, 101, data); id == id2 {
+		t.Fatal("ids shouldn't match")
+	}
+	var key2 crypto.Hash
+	fastrand.Read(key2[:])
+	if id2 := dedupChunkID(key2, crypto.TypeThreefish, ec, pieceSize, 100, data); id == id2 {
+		t.Fatal("ids shouldn't match")
+	}
+	data[0][0]++
+	if id2 := dedupChunkID(key, crypto.TypeThreefish, ec, pieceSize, 100, data); id == id2 {
+		t.Fatal("ids shouldn't match")
+	}
+
+	// The id should survive the round trip through the extension info.
+	if _, ok := dedupIDFromExtensionInfo([16]byte{}); ok {
+		t.Fatal("empty extension info shouldn't contain a dedup id")
+	}
+	id2, ok := dedupIDFromExtensionInfo(id.extensionInfo())
+	if !ok || id2 != id {
+		t.Fatal("dedup id doesn't survive round trip", ok, id, id2)
+	}
+
+	// The same id should result in the same ciphertext.
+	ck1, err1 := dedupChunkKey(key, id)
+	ck2, err2 := dedupChunkKey(key, id)
+	if err1 != nil || err2 != nil {
+		t.Fatal(err1, err2)
+	}
+	piece := fastrand.Bytes(int(modules.SectorSize))
+	c1 := ck1.Derive(0, 1).EncryptBytes(append([]byte{}, piece...))
+	c2 := ck2.Derive(0, 1).EncryptBytes(append([]byte{}, piece...))
+	if !bytes.Equal(c1, c2) {
+		t.Fatal("ciphertexts don't match")
+	}
+}
+
+// TestDedupIndex tests adding and removing references to the dedup index.
+func TestDedupIndex(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	di, err := newDedupIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add an entry with 2 references and one with a single reference.
+	_, pk := crypto.GenerateKeyPair()
+	pieces := [][]siafile.Piece{{{HostPubKey: types.Ed25519PublicKey(pk)}}, {}}
+	var id1, id2 dedupID
+	fastrand.Read(id1[:])
+	fastrand.Read(id2[:])
+	uid := siafile.TurtleDexfileUID("foo")
+	if err := di.managedAddReference(id1, dedupChunkRef(uid, 0), pieces); err != nil {
+		t.Fatal(err)
+	}
+	if err := di.managedAddReference(id1, dedupChunkRef(uid, 1), pieces); err != nil {
+		t.Fatal(err)
+	}
+	if err := di.managedAddReference(id2, dedupChunkRef(uid, 2), pieces); err != nil {
+		t.Fatal(err)
+	}
+	// Adding the same reference twice shouldn't count twice.
+	if err := di.managedAddReference(id2, dedupChunkRef(uid, 2), pieces); err != nil {
+		t.Fatal(err)
+	}
+	expected := modules.DedupStatus{
+		UniqueChunks:    2,
+		ChunkReferences: 3,
+		StorageSaved:    uint64(len(pieces)) * modules.SectorSize,
+	}
+	if ds := di.managedStatus(); ds != expected {
+		t.Fatal("wrong status", ds, expected)
+	}
+
+	// Reload the index.
+	if err := di.managedClose(); err != nil {
+		t.Fatal(err)
+	}
+	di, err = newDedupIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds := di.managedStatus(); ds != expected {
+		t.Fatal("wrong status after reload", ds, expected)
+	}
+	loadedPieces, exists := di.managedPieces(id1)
+	if !exists || len(loadedPieces) != len(pieces) || !loadedPieces[0][0].HostPubKey.Equals(pieces[0][0].HostPubKey) {
+		t.Fatal("wrong pieces", loadedPieces)
+	}
+
+	// Remove the references of the first entry one by one. The entry should
+	// only be gone after the last one is removed.
+	err = di.managedRemoveReferences([]dedupReference{{id: id1, ref: dedupChunkRef(uid, 0)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := di.managedPieces(id1); !exists {
+		t.Fatal("entry shouldn't be removed yet")
+	}
+	err = di.managedRemoveReferences([]dedupReference{{id: id1, ref: dedupChunkRef(uid, 1)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := di.managedPieces(id1); exists {
+		t.Fatal("entry should be removed")
+	}
+	expected = modules.DedupStatus{
+		UniqueChunks:    1,
+		ChunkReferences: 1,
+	}
+	if ds := di.managedStatus(); ds != expected {
+		t.Fatal("wrong status", ds, expected)
+	}
+
+	// Removing the references is persisted as well.
+	if err := di.managedClose(); err != nil {
+		t.Fatal(err)
+	}
+	di, err = newDedupIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds := di.managedStatus(); ds != expected {
+		t.Fatal("wrong status after reload", ds, expected)
+	}
+	if err := di.managedClose(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestDedupIndexCompaction tests that the persist file of the dedup index is
+// compacted once it contains too many outdated records.
+func TestDedupIndexCompaction(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	di, err := newDedupIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Keep adding and removing a reference to an entry which also has a
+	// permanent reference.
+	var id dedupID
+	fastrand.Read(id[:])
+	uid := siafile.TurtleDexfileUID("foo")
+	pieces := [][]siafile.Piece{{}}
+	if err := di.managedAddReference(id, dedupChunkRef(uid, 0), pieces); err != nil {
+		t.Fatal(err)
+	}
+	for i := uint64(0); i < 2*dedupCompactionThreshold; i++ {
+		ref := dedupReference{id: id, ref: dedupChunkRef(uid, 1)}
+		if err := di.managedAddReferences([]dedupReference{ref}); err != nil {
+			t.Fatal(err)
+		}
+		if err := di.managedRemoveReferences([]dedupReference{ref}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The file was compacted and never contained more outdated records than
+	// the threshold.
+	di.mu.Lock()
+	numRecords := di.numRecords
+	di.mu.Unlock()
+	if numRecords > dedupCompactionThreshold+1 {
+		t.Fatal("persist file wasn't compacted", numRecords)
+	}
+
+	// The compacted index contains the same entries.
+	if err := di.managedClose(); err != nil {
+		t.Fatal(err)
+	}
+	di, err = newDedupIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := modules.DedupStatus{
+		UniqueChunks:    1,
+		ChunkReferences: 1,
+	}
+	if ds := di.managedStatus(); ds != expected {
+		t.Fatal("wrong status after reload", ds, expected)
+	}
+	if err := di.managedClose(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestDedupIndexLegacy tests converting the legacy JSON file of the dedup
+// index.
+func TestDedupIndexLegacy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	var id dedupID
+	fastrand.Read(id[:])
+	legacy := dedupLegacyPersist{
+		Entries: map[string]dedupEntry{
+			id.String(): {
+				Pieces:     [][]siafile.Piece{{}},
+				References: map[string]struct{}{"foo/0": {}, "foo/1": {}},
+			},
+		},
+	}
+	legacyPath := filepath.Join(dir, dedupLegacyPersistFile)
+	if err := persist.SaveJSON(dedupLegacyMetadata, legacy, legacyPath); err != nil {
+		t.Fatal(err)
+	}
+
+	// The entries are converted and the JSON file is removed.
+	di, err := newDedupIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(legacyPath); !os.IsNotExist(err) {
+		t.Fatal("legacy file wasn't removed", err)
+	}
+	if err := di.managedClose(); err != nil {
+		t.Fatal(err)
+	}
+	di, err = newDedupIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := modules.DedupStatus{
+		UniqueChunks:    1,
+		ChunkReferences: 2,
+		StorageSaved:    modules.SectorSize,
+	}
+	if ds := di.managedStatus(); ds != expected {
+		t.Fatal("wrong status", ds, expected)
+	}
+	if err := di.managedClose(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestHealthyDedupPieces is a unit test for healthyDedupPieces.
+func TestHealthyDedupPieces(t *testing.T) {
+	hosts := make([]types.TurtleDexPublicKey, 3)
+	offline := make(map[string]bool)
+	goodForRenew := make(map[string]bool)
+	for i := range hosts {
+		_, pk := crypto.GenerateKeyPair()
+		hosts[i] = types.Ed25519PublicKey(pk)
+		offline[hosts[i].String()] = false
+		goodForRenew[hosts[i].String()] = true
+	}
+	pieces := [][]siafile.Piece{
+		{{HostPubKey: hosts[0]}},
+		{{HostPubKey: hosts[0]}, {HostPubKey: hosts[1]}},
+		{{HostPubKey: hosts[2]}},
+	}
+
+	// All pieces are healthy. The second piece is on a different host than
+	// the first one.
+	good := healthyDedupPieces(pieces, len(pieces), offline, goodForRenew)
+	if len(good) != len(pieces) {
+		t.Fatal("expected all pieces to be healthy")
+	}
+	if !good[1].HostPubKey.Equals(hosts[1]) {
+		t.Fatal("pieces shouldn't share a host")
+	}
+
+	// If a host goes offline, not all pieces are healthy anymore.
+	offline[hosts[2].String()] = true
+	if good := healthyDedupPieces(pieces, len(pieces), offline, goodForRenew); good != nil {
+		t.Fatal("expected pieces to be unhealthy")
+	}
+	offline[hosts[2].String()] = false
+
+	// Same for a host that isn't good for renew.
+	goodForRenew[hosts[1].String()] = false
+	if good := healthyDedupPieces(pieces, len(pieces), offline, goodForRenew); good != nil {
+		t.Fatal("expected pieces to be unhealthy")
+	}
+	goodForRenew[hosts[1].String()] = true
+
+	// A mismatch in the number of pieces is never healthy.
+	if good := healthyDedupPieces(pieces, len(pieces)+1, offline, goodForRenew); good != nil {
+		t.Fatal("expected pieces to be unhealthy")
+	}
+}
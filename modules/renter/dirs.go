@@ -16,6 +16,9 @@ func (r *Renter) CreateDir(siaPath modules.TurtleDexPath, mode os.FileMode) erro
 		return err
 	}
 	defer r.tg.Done()
+	if err := checkDirSnapshotWritable(siaPath); err != nil {
+		return err
+	}
 	return r.staticFileSystem.NewTurtleDexDir(siaPath, mode)
 }
 
@@ -26,6 +29,9 @@ func (r *Renter) DeleteDir(siaPath modules.TurtleDexPath) error {
 		return err
 	}
 	defer r.tg.Done()
+	if err := checkDirSnapshotWritable(siaPath); err != nil {
+		return err
+	}
 	return r.staticFileSystem.DeleteDir(siaPath)
 }
 
@@ -69,5 +75,8 @@ func (r *Renter) RenameDir(oldPath, newPath modules.TurtleDexPath) error {
 	if newPath.IsRoot() {
 		return errors.New("cannot rename a file to the root directory")
 	}
+	if err := checkDirSnapshotWritable(oldPath, newPath); err != nil {
+		return err
+	}
 	return r.staticFileSystem.RenameDir(oldPath, newPath)
 }
@@ -380,6 +380,9 @@ func compareDirectoryInfoAndMetadataCustom(di modules.DirectoryInfo, siaDir *fil
 	if md.AggregateNumSubDirs != di.AggregateNumSubDirs {
 		return fmt.Errorf("AggregateNumSubDirs not equal, %v and %v", md.AggregateNumSubDirs, di.AggregateNumSubDirs)
 	}
+	if md.AggregateRedundantSize != di.AggregateRedundantSize {
+		return fmt.Errorf("AggregateRedundantSize not equal, %v and %v", md.AggregateRedundantSize, di.AggregateRedundantSize)
+	}
 	if md.AggregateSize != di.AggregateSize {
 		return fmt.Errorf("AggregateSizes not equal, %v and %v", md.AggregateSize, di.AggregateSize)
 	}
@@ -0,0 +1,371 @@
+package renter
+
+// Directory snapshots are named, read-only copies of a directory tree. The
+// siafiles of a snapshot are copied into the snapshot's folder within
+// modules.DirectorySnapshotFolder. Since the copies get their own UIDs, they
+// are repaired like any other file and overwriting or deleting the original
+// files doesn't affect them. The copies also hold references to the
+// deduplicated chunks they share with the original files, which keeps the
+// chunks in the dedup index until the snapshot is deleted.
+//
+// A snapshot can be diffed against the live directory, restored into a new
+// directory and uploaded to hosts as a backup, which is done the same way as
+// for backups of the whole renter.
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
+	"github.com/turtledex/TurtleDexCore/persist"
+	"github.com/turtledex/errors"
+	"github.com/turtledex/fastrand"
+)
+
+const (
+	// dirSnapshotsPersistFile is the name of the file the metadata of the
+	// directory snapshots is persisted to.
+	dirSnapshotsPersistFile = "dirsnapshots.json"
+
+	// dirSnapshotsPersistVersion is the version of the directory snapshots'
+	// persist file.
+	dirSnapshotsPersistVersion = "1.5.5"
+)
+
+var (
+	// ErrDirSnapshotReadOnly is returned when a directory snapshot is about
+	// to be modified.
+	ErrDirSnapshotReadOnly = errors.New("directory snapshots are read-only")
+
+	// ErrUnknownDirSnapshot is returned when a directory snapshot can't be
+	// found.
+	ErrUnknownDirSnapshot = errors.New("directory snapshot does not exist")
+
+	// dirSnapshotsMetadata is the metadata of the directory snapshots' persist
+	// file.
+	dirSnapshotsMetadata = persist.Metadata{
+		Header:  "Renter Directory Snapshots",
+		Version: dirSnapshotsPersistVersion,
+	}
+)
+
+type (
+	// dirSnapshots keeps track of the metadata of the renter's directory
+	// snapshots.
+	dirSnapshots struct {
+		snapshots  map[string]modules.DirectorySnapshot
+		staticPath string
+		mu         sync.Mutex
+	}
+)
+
+// newDirSnapshots loads the metadata of the directory snapshots from disk.
+func newDirSnapshots(persistDir string) (*dirSnapshots, error) {
+	ds := &dirSnapshots{
+		snapshots:  make(map[string]modules.DirectorySnapshot),
+		staticPath: filepath.Join(persistDir, dirSnapshotsPersistFile),
+	}
+	var snapshots []modules.DirectorySnapshot
+	err := persist.LoadJSON(dirSnapshotsMetadata, &snapshots, ds.staticPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.AddContext(err, "failed to load directory snapshots")
+	}
+	for _, snapshot := range snapshots {
+		ds.snapshots[snapshot.Name] = snapshot
+	}
+	return ds, nil
+}
+
+// managedAdd adds a snapshot.
+func (ds *dirSnapshots) managedAdd(snapshot modules.DirectorySnapshot) error {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if _, exists := ds.snapshots[snapshot.Name]; exists {
+		return filesystem.ErrExists
+	}
+	ds.snapshots[snapshot.Name] = snapshot
+	err := ds.save()
+	if err != nil {
+		delete(ds.snapshots, snapshot.Name)
+	}
+	return err
+}
+
+// managedRemove removes the snapshot with the provided name.
+func (ds *dirSnapshots) managedRemove(name string) error {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	delete(ds.snapshots, name)
+	return ds.save()
+}
+
+// managedSnapshot returns the snapshot with the provided name.
+func (ds *dirSnapshots) managedSnapshot(name string) (modules.DirectorySnapshot, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	snapshot, exists := ds.snapshots[name]
+	if !exists {
+		return modules.DirectorySnapshot{}, ErrUnknownDirSnapshot
+	}
+	return snapshot, nil
+}
+
+// managedSnapshots returns all snapshots sorted by their creation time.
+func (ds *dirSnapshots) managedSnapshots() []modules.DirectorySnapshot {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	snapshots := make([]modules.DirectorySnapshot, 0, len(ds.snapshots))
+	for _, snapshot := range ds.snapshots {
+		snapshots = append(snapshots, snapshot)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		if snapshots[i].CreateTime.Equal(snapshots[j].CreateTime) {
+			return snapshots[i].Name < snapshots[j].Name
+		}
+		return snapshots[i].CreateTime.Before(snapshots[j].CreateTime)
+	})
+	return snapshots
+}
+
+// save saves the snapshots to disk.
+func (ds *dirSnapshots) save() error {
+	snapshots := make([]modules.DirectorySnapshot, 0, len(ds.snapshots))
+	for _, snapshot := range ds.snapshots {
+		snapshots = append(snapshots, snapshot)
+	}
+	return persist.SaveJSON(dirSnapshotsMetadata, snapshots, ds.staticPath)
+}
+
+// isDirSnapshotPath returns whether siaPath is a directory snapshot or within
+// one.
+func isDirSnapshotPath(siaPath modules.TurtleDexPath) bool {
+	return siaPath.Equals(modules.DirectorySnapshotFolder) || siaPath.IsWithin(modules.DirectorySnapshotFolder)
+}
+
+// checkDirSnapshotWritable returns ErrDirSnapshotReadOnly if any of the
+// provided siapaths belongs to a directory snapshot.
+func checkDirSnapshotWritable(siaPaths ...modules.TurtleDexPath) error {
+	for _, siaPath := range siaPaths {
+		if isDirSnapshotPath(siaPath) {
+			return errors.AddContext(ErrDirSnapshotReadOnly, fmt.Sprintf("'%v' belongs to a directory snapshot", siaPath))
+		}
+	}
+	return nil
+}
+
+// CreateDirSnapshot creates a read-only snapshot with the provided name of the
+// directory at siaPath.
+func (r *Renter) CreateDirSnapshot(siaPath modules.TurtleDexPath, name string) (modules.DirectorySnapshot, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.DirectorySnapshot{}, err
+	}
+	defer r.tg.Done()
+
+	// Snapshots can't contain other snapshots.
+	if isDirSnapshotPath(siaPath) || modules.DirectorySnapshotFolder.IsWithin(siaPath) {
+		return modules.DirectorySnapshot{}, errors.New("can't create a snapshot of a directory which contains directory snapshots")
+	}
+	if _, err := r.staticDirSnapshots.managedSnapshot(name); err == nil {
+		return modules.DirectorySnapshot{}, errors.AddContext(filesystem.ErrExists, fmt.Sprintf("directory snapshot '%v' already exists", name))
+	}
+	snapshotDir, err := filesystem.DirSnapshotTurtleDexPath(name)
+	if err != nil {
+		return modules.DirectorySnapshot{}, err
+	}
+	copies, size, err := r.managedCopyDir(siaPath, snapshotDir)
+	if err != nil {
+		return modules.DirectorySnapshot{}, errors.AddContext(err, "unable to copy files into snapshot")
+	}
+	snapshot := modules.DirectorySnapshot{
+		CreateTime:    time.Now(),
+		Name:          name,
+		NumFiles:      uint64(len(copies)),
+		Size:          size,
+		TurtleDexPath: siaPath,
+	}
+	if err := r.staticDirSnapshots.managedAdd(snapshot); err != nil {
+		return modules.DirectorySnapshot{}, errors.Compose(errors.AddContext(err, "unable to save snapshot"), r.managedDeleteDirSnapshotFiles(snapshotDir))
+	}
+	return snapshot, nil
+}
+
+// DeleteDirSnapshot deletes the directory snapshot with the provided name.
+func (r *Renter) DeleteDirSnapshot(name string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if _, err := r.staticDirSnapshots.managedSnapshot(name); err != nil {
+		return err
+	}
+	snapshotDir, err := filesystem.DirSnapshotTurtleDexPath(name)
+	if err != nil {
+		return err
+	}
+	err = r.managedDeleteDirSnapshotFiles(snapshotDir)
+	if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+		return errors.AddContext(err, "unable to delete snapshot files")
+	}
+	return r.staticDirSnapshots.managedRemove(name)
+}
+
+// DiffDirSnapshot returns the files of the snapshotted directory which changed
+// since the snapshot was created.
+func (r *Renter) DiffDirSnapshot(name string) (modules.DirectorySnapshotDiff, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.DirectorySnapshotDiff{}, err
+	}
+	defer r.tg.Done()
+	snapshot, err := r.staticDirSnapshots.managedSnapshot(name)
+	if err != nil {
+		return modules.DirectorySnapshotDiff{}, err
+	}
+	snapshotDir, err := filesystem.DirSnapshotTurtleDexPath(name)
+	if err != nil {
+		return modules.DirectorySnapshotDiff{}, err
+	}
+	return r.staticFileSystem.DiffDir(snapshotDir, snapshot.TurtleDexPath)
+}
+
+// DirSnapshots returns the renter's directory snapshots sorted by their
+// creation time.
+func (r *Renter) DirSnapshots() ([]modules.DirectorySnapshot, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+	return r.staticDirSnapshots.managedSnapshots(), nil
+}
+
+// RestoreDirSnapshot copies the files of the directory snapshot with the
+// provided name into the new directory at siaPath.
+func (r *Renter) RestoreDirSnapshot(name string, siaPath modules.TurtleDexPath) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if err := checkDirSnapshotWritable(siaPath); err != nil {
+		return err
+	}
+	if _, err := r.staticDirSnapshots.managedSnapshot(name); err != nil {
+		return err
+	}
+	snapshotDir, err := filesystem.DirSnapshotTurtleDexPath(name)
+	if err != nil {
+		return err
+	}
+	_, _, err = r.managedCopyDir(snapshotDir, siaPath)
+	return err
+}
+
+// UploadDirSnapshot uploads the directory snapshot with the provided name to
+// hosts as a backup of the same name. The backup is encrypted the same way as
+// backups of the whole renter and restoring it adds the files of the snapshot
+// to the user's home directory.
+func (r *Renter) UploadDirSnapshot(name string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if _, err := r.staticDirSnapshots.managedSnapshot(name); err != nil {
+		return err
+	}
+	snapshotDir, err := filesystem.DirSnapshotTurtleDexPath(name)
+	if err != nil {
+		return err
+	}
+
+	// Write the backup to a temporary file and delete it after uploading.
+	tmpDir, err := ioutil.TempDir("", "sia-dirsnapshot")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			r.log.Printf("Unable to remove temporary backup of directory snapshot %v: %v", name, err)
+		}
+	}()
+	backupPath := filepath.Join(tmpDir, name+".bak")
+
+	// Derive the backup secret from the wallet seed and wipe it afterwards.
+	ws, _, err := r.w.PrimarySeed()
+	if err != nil {
+		return errors.AddContext(err, "failed to get wallet's primary seed")
+	}
+	rs := modules.DeriveRenterSeed(ws)
+	defer fastrand.Read(rs[:])
+	secret := crypto.HashAll(rs, modules.BackupKeySpecifier)
+	defer fastrand.Read(secret[:])
+
+	if err := r.managedCreateBackup(backupPath, snapshotDir, secret[:32]); err != nil {
+		return errors.AddContext(err, "failed to create backup")
+	}
+	return r.managedUploadBackup(backupPath, name)
+}
+
+// managedCopyDir copies the files within the dir at src to the new dir at dst
+// and adds the references of the copies to deduplicated chunks to the dedup
+// index. The siapaths of the copies and their total size are returned.
+func (r *Renter) managedCopyDir(src, dst modules.TurtleDexPath) ([]modules.TurtleDexPath, uint64, error) {
+	copies, size, err := r.staticFileSystem.CopyDir(src, dst)
+	if err != nil {
+		return nil, 0, err
+	}
+	var refs []dedupReference
+	bubblePaths := r.newUniqueRefreshPaths()
+	if err := bubblePaths.callAdd(dst); err != nil {
+		r.log.Printf("failed to add directory '%v' to bubble paths: %v", dst, err)
+	}
+	for _, siaPath := range copies {
+		fileRefs, err := r.managedFileDedupReferences(siaPath)
+		if err != nil {
+			r.log.Printf("Unable to fetch dedup references of %v: %v", siaPath, err)
+		}
+		refs = append(refs, fileRefs...)
+		dir, err := siaPath.Dir()
+		if err != nil {
+			r.log.Printf("Unable to fetch the directory from a siaPath %v for copied siafile: %v", siaPath, err)
+			continue
+		}
+		if err := bubblePaths.callAdd(dir); err != nil {
+			r.log.Printf("failed to add directory '%v' to bubble paths: %v", dir, err)
+		}
+	}
+	if err := r.staticDedupIndex.managedAddReferences(refs); err != nil {
+		r.log.Printf("Unable to add dedup references of the files copied to %v: %v", dst, err)
+	}
+	bubblePaths.callRefreshAll()
+	return copies, size, nil
+}
+
+// managedDeleteDirSnapshotFiles deletes the files of a directory snapshot and
+// releases their references to deduplicated chunks.
+func (r *Renter) managedDeleteDirSnapshotFiles(snapshotDir modules.TurtleDexPath) error {
+	files, err := r.staticFileSystem.TurtleDexFiles(snapshotDir)
+	if err != nil {
+		return err
+	}
+	var refs []dedupReference
+	for _, siaPath := range files {
+		fileRefs, err := r.managedFileDedupReferences(siaPath)
+		if err != nil {
+			r.log.Printf("Unable to fetch dedup references of %v: %v", siaPath, err)
+		}
+		refs = append(refs, fileRefs...)
+	}
+	if err := r.staticFileSystem.DeleteDir(snapshotDir); err != nil {
+		return err
+	}
+	if err := r.staticDedupIndex.managedRemoveReferences(refs); err != nil {
+		r.log.Printf("Unable to release dedup references of %v: %v", snapshotDir, err)
+	}
+	go r.callThreadedBubbleMetadata(modules.DirectorySnapshotFolder)
+	return nil
+}
@@ -0,0 +1,164 @@
+package renter
+
+import (
+	"testing"
+
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
+	"github.com/turtledex/errors"
+)
+
+// TestCheckDirSnapshotWritable is a unit test for checkDirSnapshotWritable.
+func TestCheckDirSnapshotWritable(t *testing.T) {
+	snapshotFile, err := modules.DirectorySnapshotFolder.Join("snap/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkDirSnapshotWritable(modules.DirectorySnapshotFolder); !errors.Contains(err, ErrDirSnapshotReadOnly) {
+		t.Fatal("snapshot folder should be read-only", err)
+	}
+	if err := checkDirSnapshotWritable(modules.RandomTurtleDexPath(), snapshotFile); !errors.Contains(err, ErrDirSnapshotReadOnly) {
+		t.Fatal("snapshot file should be read-only", err)
+	}
+	if err := checkDirSnapshotWritable(modules.RandomTurtleDexPath(), modules.VarFolder); err != nil {
+		t.Fatal("paths outside of snapshots should be writable", err)
+	}
+}
+
+// TestDirSnapshots tests creating, diffing, restoring and deleting directory
+// snapshots.
+func TestDirSnapshots(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// Create a dir with 2 files.
+	dir, err := modules.NewTurtleDexPath("dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []modules.TurtleDexPath
+	for _, name := range []string{"foo", "bar"} {
+		siaPath, err := dir.Join(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, rsc := testingFileParams()
+		entry, err := r.createRenterTestFileWithParams(siaPath, rsc, crypto.RandomCipherType())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := entry.Close(); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, siaPath)
+	}
+
+	// Snapshots of dirs containing the snapshots should fail.
+	if _, err := r.CreateDirSnapshot(modules.RootTurtleDexPath(), "root"); err == nil {
+		t.Fatal("snapshot of root should fail")
+	}
+
+	// Create a snapshot.
+	snapshot, err := r.CreateDirSnapshot(dir, "snap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.NumFiles != 2 || snapshot.Size != 2000 || !snapshot.TurtleDexPath.Equals(dir) {
+		t.Fatal("unexpected snapshot", snapshot)
+	}
+	if _, err := r.CreateDirSnapshot(dir, "snap"); !errors.Contains(err, filesystem.ErrExists) {
+		t.Fatal("expected ErrExists but got", err)
+	}
+	snapshots, err := r.DirSnapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 || snapshots[0].Name != "snap" {
+		t.Fatal("unexpected snapshots", snapshots)
+	}
+
+	// The snapshot should be read-only.
+	snapshotDir, err := filesystem.DirSnapshotTurtleDexPath("snap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshotFile, err := snapshotDir.Join("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.DeleteFile(snapshotFile); !errors.Contains(err, ErrDirSnapshotReadOnly) {
+		t.Fatal("expected ErrDirSnapshotReadOnly but got", err)
+	}
+	if err := r.DeleteDir(snapshotDir); !errors.Contains(err, ErrDirSnapshotReadOnly) {
+		t.Fatal("expected ErrDirSnapshotReadOnly but got", err)
+	}
+
+	// Delete a live file. The snapshot should still contain it.
+	if err := r.DeleteFile(files[0]); err != nil {
+		t.Fatal(err)
+	}
+	if exists, err := r.staticFileSystem.FileExists(snapshotFile); err != nil || !exists {
+		t.Fatal("snapshot file should still exist", err)
+	}
+	diff, err := r.DiffDirSnapshot("snap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Added) != 0 || len(diff.Modified) != 0 || len(diff.Removed) != 1 || !diff.Removed[0].Equals(files[0]) {
+		t.Fatal("unexpected diff", diff)
+	}
+
+	// Restore the snapshot into a new dir.
+	restored, err := modules.NewTurtleDexPath("restored")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RestoreDirSnapshot("snap", restored); err != nil {
+		t.Fatal(err)
+	}
+	restoredFiles, err := r.staticFileSystem.TurtleDexFiles(restored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restoredFiles) != 2 {
+		t.Fatal("expected 2 restored files but got", len(restoredFiles))
+	}
+	if err := r.RestoreDirSnapshot("snap", restored); !errors.Contains(err, filesystem.ErrExists) {
+		t.Fatal("expected ErrExists but got", err)
+	}
+
+	// The snapshots should be persisted.
+	ds, err := newDirSnapshots(r.persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ds.managedSnapshot("snap"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Delete the snapshot.
+	if err := r.DeleteDirSnapshot("snap"); err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ := r.staticFileSystem.DirExists(snapshotDir); exists {
+		t.Fatal("snapshot dir should have been deleted")
+	}
+	if err := r.DeleteDirSnapshot("snap"); !errors.Contains(err, ErrUnknownDirSnapshot) {
+		t.Fatal("expected ErrUnknownDirSnapshot but got", err)
+	}
+	if _, err := r.DiffDirSnapshot("snap"); !errors.Contains(err, ErrUnknownDirSnapshot) {
+		t.Fatal("expected ErrUnknownDirSnapshot but got", err)
+	}
+}
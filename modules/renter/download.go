@@ -17,6 +17,7 @@ import (
 	"github.com/turtledex/errors"
 
 	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/crypto"
 	"github.com/turtledex/TurtleDexCore/modules"
 	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem/siafile"
 	"github.com/turtledex/TurtleDexCore/types"
@@ -54,9 +55,9 @@ type (
 		staticParams downloadParams
 
 		// Retrieval settings for the file.
-		staticLatencyTarget time.Duration // In milliseconds. Lower latency results in lower total system throughput.
-		staticOverdrive     int           // How many extra pieces to download to prevent slow hosts from being a bottleneck.
-		staticPriority      uint64        // Downloads with higher priority will complete first.
+		staticLatencyTarget time.Duration            // In milliseconds. Lower latency results in lower total system throughput.
+		staticOverdrive     int                      // How many extra pieces to download to prevent slow hosts from being a bottleneck.
+		staticPriority      modules.DownloadPriority // Downloads with higher priority will complete first.
 
 		// Utilities.
 		r  *Renter    // The renter that was used to create the download.
@@ -71,13 +72,17 @@ type (
 		disableLocalFetch bool                // Whether or not the file can be fetched from disk if available.
 		file              *siafile.Snapshot   // The file to download.
 
-		latencyTarget       time.Duration // Workers above this latency will be automatically put on standby initially.
-		length              uint64        // Length of download. Cannot be 0.
-		needsMemory         bool          // Whether new memory needs to be allocated to perform the download.
-		offset              uint64        // Offset within the file to start the download. Must be less than the total filesize.
-		overdrive           int           // How many extra pieces to download to prevent slow hosts from being a bottleneck.
-		priority            uint64        // Files with a higher priority will be downloaded first.
+		latencyTarget       time.Duration            // Workers above this latency will be automatically put on standby initially.
+		length              uint64                   // Length of download. Cannot be 0.
+		needsMemory         bool                     // Whether new memory needs to be allocated to perform the download.
+		offset              uint64                   // Offset within the file to start the download. Must be less than the total filesize.
+		overdrive           int                      // How many extra pieces to download to prevent slow hosts from being a bottleneck.
+		priority            modules.DownloadPriority // Files with a higher priority will be downloaded first.
 		staticMemoryManager *memoryManager
+
+		// budgetIdentity is the identity whose budget is charged for the
+		// download. Downloads without an identity aren't charged.
+		budgetIdentity string
 	}
 )
 
@@ -299,21 +304,14 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 		destinationType = "file"
 	}
 
-	// If the destination is a httpWriter, we set the Content-Length in the
-	// header.
-	if isHTTPResp {
-		w, ok := p.Httpwriter.(http.ResponseWriter)
-		if ok {
-			w.Header().Set("Content-Length", fmt.Sprint(p.Length))
-		}
-	}
-
 	// Prepare snapshot.
 	snap, err := entry.SnapshotRange(p.TurtleDexPath, p.Offset, p.Length)
 	if err != nil {
 		return nil, err
 	}
 	// Create the download object.
+	priority := downloadPriorityOrDefault(p.Priority, modules.DownloadPriorityNormal)
+	latencyTarget, overdrive := downloadPrioritySettings(priority)
 	d, err := r.managedNewDownload(downloadParams{
 		destination:       dw,
 		destinationType:   destinationType,
@@ -321,14 +319,16 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 		disableLocalFetch: p.DisableDiskFetch,
 		file:              snap,
 
-		latencyTarget: 25e3 * time.Millisecond, // TODO: high default until full latency support is added.
+		latencyTarget: latencyTarget,
 		length:        p.Length,
 		needsMemory:   true,
 		offset:        p.Offset,
-		overdrive:     3, // TODO: moderate default until full overdrive support is added.
-		priority:      5, // TODO: moderate default until full priority support is added.
+		overdrive:     overdrive,
+		priority:      priority,
 
 		staticMemoryManager: r.userDownloadMemoryManager, // user initiated download
+
+		budgetIdentity: p.BudgetIdentity,
 	})
 	if closer, ok := dw.(io.Closer); err != nil && ok {
 		// If the destination can be closed we do so.
@@ -337,6 +337,16 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 		return nil, err
 	}
 
+	// If the destination is a httpWriter, we set the Content-Length in the
+	// header. This happens after the download was created to not set it for
+	// responses that return an error instead.
+	if isHTTPResp {
+		w, ok := p.Httpwriter.(http.ResponseWriter)
+		if ok {
+			w.Header().Set("Content-Length", fmt.Sprint(p.Length))
+		}
+	}
+
 	// Register some cleanup for when the download is done.
 	d.OnComplete(func(_ error) error {
 		// close the destination if possible.
@@ -378,6 +388,13 @@ func (r *Renter) managedNewDownload(params downloadParams) (*download, error) {
 		return nil, errors.New("download is requesting data past the boundary of the file")
 	}
 
+	// Charge the download to the budget of the downloader before any chunks
+	// are queued.
+	err := r.managedChargeBudget(params.budgetIdentity, modules.BudgetCategoryDownload, params.length)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to download file")
+	}
+
 	// Create the download object.
 	d := &download{
 		completeChan: make(chan struct{}),
@@ -442,7 +459,17 @@ func (d *download) Start() error {
 	// For each chunk, assemble a mapping from the contract id to the index of
 	// the piece within the chunk that the contract is responsible for.
 	chunkMaps := make([]map[string]downloadPieceInfo, maxChunk-minChunk+1)
+	chunkKeys := make([]crypto.CipherKey, maxChunk-minChunk+1)
+	chunkKeyIndices := make([]uint64, maxChunk-minChunk+1)
 	for chunkIndex := minChunk; chunkIndex <= maxChunk; chunkIndex++ {
+		// Get the key the piece keys of the chunk are derived from.
+		key, keyIndex, err := d.r.managedChunkKey(params.file.MasterKey(), params.file.ChunkExtensionInfo(chunkIndex), chunkIndex)
+		if err != nil {
+			return errors.AddContext(err, "unable to get the chunk's encryption key")
+		}
+		chunkKeys[chunkIndex-minChunk] = key
+		chunkKeyIndices[chunkIndex-minChunk] = keyIndex
+
 		// Create the map.
 		chunkMaps[chunkIndex-minChunk] = make(map[string]downloadPieceInfo)
 		// Get the pieces for the chunk.
@@ -470,9 +497,10 @@ func (d *download) Start() error {
 		udc := &unfinishedDownloadChunk{
 			destination: params.destination,
 			erasureCode: params.file.ErasureCode(),
-			masterKey:   params.file.MasterKey(),
+			masterKey:   chunkKeys[i-minChunk],
 
 			staticChunkIndex: i,
+			staticKeyIndex:   chunkKeyIndices[i-minChunk],
 			staticCacheID:    fmt.Sprintf("%v:%v", d.staticTurtleDexPath, i),
 			staticChunkMap:   chunkMaps[i-minChunk],
 			staticChunkSize:  params.file.ChunkSize(),
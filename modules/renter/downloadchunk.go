@@ -42,6 +42,7 @@ type unfinishedDownloadChunk struct {
 
 	// Fetch + Write instructions - read only or otherwise thread safe.
 	staticChunkIndex  uint64                       // Required for deriving the encryption keys for each piece.
+	staticKeyIndex    uint64                       // Chunk index used to derive the piece keys from the masterKey. Differs from staticChunkIndex for deduplicated chunks.
 	staticCacheID     string                       // Used to uniquely identify a chunk in the chunk cache.
 	staticChunkMap    map[string]downloadPieceInfo // Maps from host PubKey to the info for the piece associated with that host
 	staticChunkSize   uint64
@@ -56,7 +57,7 @@ type unfinishedDownloadChunk struct {
 	staticNeedsMemory      bool // Set to true if memory was not pre-allocated for this chunk.
 	staticMemoryManager    *memoryManager
 	staticOverdrive        int
-	staticPriority         uint64
+	staticPriority         modules.DownloadPriority
 
 	// Download chunk state - need mutex to access.
 	completedPieces   []bool    // Which pieces were downloaded successfully.
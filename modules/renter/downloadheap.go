@@ -18,6 +18,8 @@ import (
 	"os"
 	"sync/atomic"
 	"time"
+
+	"github.com/turtledex/TurtleDexCore/modules"
 )
 
 // downloadChunkHeap is a heap that is sorted first by file priority, then by
@@ -107,6 +109,18 @@ func (r *Renter) managedAddChunkToDownloadHeap(udc *unfinishedDownloadChunk) {
 	r.downloadHeapMu.Unlock()
 }
 
+// managedDownloadHeapDepths returns the number of chunks per priority class
+// that are waiting in the download heap.
+func (r *Renter) managedDownloadHeapDepths() modules.DownloadPriorityCounts {
+	r.downloadHeapMu.Lock()
+	defer r.downloadHeapMu.Unlock()
+	var depths modules.DownloadPriorityCounts
+	for _, udc := range *r.downloadHeap {
+		depths.Add(udc.staticPriority)
+	}
+	return depths
+}
+
 // managedBlockUntilOnline will block until the renter is online. The renter
 // will appropriately handle incoming download requests and stop signals while
 // waiting.
@@ -0,0 +1,67 @@
+package renter
+
+// Downloads are assigned one of three priority classes: interactive, normal and
+// bulk. Repairs have the lowest priority of all. The priority class of a
+// download determines its position in the download heap and in the read job
+// queues of the workers, as well as how aggressively it overdrives.
+//
+// Preemption happens at the queue level. Jobs that are already running are
+// never interrupted, but a job with a higher priority that is added to a read
+// job queue skips over all queued jobs of a lower priority. This way bulk work
+// never delays an interactive request by more than the jobs that are currently
+// in flight.
+
+import (
+	"time"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+)
+
+const (
+	// bulkOverdriveGracePeriod is the amount of time a launched worker of a
+	// bulk download may be late before an overdrive worker is launched.
+	bulkOverdriveGracePeriod = 2 * time.Second
+
+	// interactiveOverdriveHeadStart is the amount of time before the slowest
+	// launched worker of an interactive download is expected to return, at
+	// which an overdrive worker is launched already.
+	interactiveOverdriveHeadStart = 20 * time.Millisecond
+)
+
+// downloadPriorityOrDefault returns the priority or the fallback if the
+// priority is not set.
+func downloadPriorityOrDefault(priority, fallback modules.DownloadPriority) modules.DownloadPriority {
+	if priority == 0 {
+		return fallback
+	}
+	return priority
+}
+
+// downloadPrioritySettings returns the latency target and the number of
+// overdrive pieces for a download of the legacy download code with the given
+// priority.
+func downloadPrioritySettings(priority modules.DownloadPriority) (time.Duration, int) {
+	switch priority {
+	case modules.DownloadPriorityInteractive:
+		return 50 * time.Millisecond, 5
+	case modules.DownloadPriorityBulk:
+		return 25e3 * time.Millisecond, 0
+	default:
+		return 25e3 * time.Millisecond, 3
+	}
+}
+
+// overdriveDelay returns the amount of time that has to pass after the slowest
+// launched worker of a project download is expected to return before an
+// overdrive worker is launched. Interactive downloads overdrive slightly
+// before the workers are late and bulk downloads only after a grace period.
+func overdriveDelay(priority modules.DownloadPriority) time.Duration {
+	switch priority {
+	case modules.DownloadPriorityInteractive:
+		return -interactiveOverdriveHeadStart
+	case modules.DownloadPriorityBulk:
+		return bulkOverdriveGracePeriod
+	default:
+		return 0
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"io"
 	"sync"
-	"time"
 
 	"github.com/turtledex/errors"
 
@@ -60,6 +59,8 @@ type (
 		cacheOffset             int64
 		cacheReady              chan struct{}
 		staticDisableLocalFetch bool
+		staticPriority          modules.DownloadPriority
+		staticBudgetIdentity    string
 		readErr                 error
 		targetCacheSize         int64
 
@@ -169,6 +170,7 @@ func (s *streamer) managedFillCache() bool {
 	// Perform the actual download.
 	buffer := bytes.NewBuffer([]byte{})
 	ddw := newDownloadDestinationWriter(buffer)
+	latencyTarget, overdrive := downloadPrioritySettings(s.staticPriority)
 	d, err := s.r.managedNewDownload(downloadParams{
 		destination:       ddw,
 		destinationType:   destinationTypeSeekStream,
@@ -176,14 +178,16 @@ func (s *streamer) managedFillCache() bool {
 		disableLocalFetch: s.staticDisableLocalFetch,
 		file:              s.staticFile,
 
-		latencyTarget: 50 * time.Millisecond, // TODO: low default until full latency support is added.
+		latencyTarget: latencyTarget,
 		length:        uint64(fetchLen),
 		needsMemory:   true,
 		offset:        uint64(fetchOffset),
-		overdrive:     5,    // TODO: high default until full overdrive support is added.
-		priority:      1000, // TODO: high default until full priority support is added.
+		overdrive:     overdrive,
+		priority:      s.staticPriority,
 
 		staticMemoryManager: s.r.userDownloadMemoryManager, // user initiated download
+
+		budgetIdentity: s.staticBudgetIdentity,
 	})
 	if err != nil {
 		closeErr := ddw.Close()
@@ -470,8 +474,9 @@ func (s *streamer) Seek(offset int64, whence int) (int64, error) {
 }
 
 // Streamer creates a modules.Streamer that can be used to stream downloads from
-// the sia network.
-func (r *Renter) Streamer(siaPath modules.TurtleDexPath, disableLocalFetch bool) (_ string, _ modules.Streamer, err error) {
+// the sia network. If the priority is not set, the stream is interactive. The
+// data fetched for the stream is charged to the budget of the identity.
+func (r *Renter) Streamer(siaPath modules.TurtleDexPath, disableLocalFetch bool, priority modules.DownloadPriority, budgetIdentity string) (_ string, _ modules.Streamer, err error) {
 	if err := r.tg.Add(); err != nil {
 		return "", nil, err
 	}
@@ -491,14 +496,14 @@ func (r *Renter) Streamer(siaPath modules.TurtleDexPath, disableLocalFetch bool)
 	if err != nil {
 		return "", nil, err
 	}
-	s := r.managedStreamer(snap, disableLocalFetch)
+	s := r.managedStreamer(snap, disableLocalFetch, priority, budgetIdentity)
 	return siaPath.String(), s, nil
 }
 
 // StreamerByNode will open a streamer for the renter, taking a FileNode as
 // input instead of a siapath. This is important for fuse, which has filenodes
 // that could be getting renamed before the streams are opened.
-func (r *Renter) StreamerByNode(node *filesystem.FileNode, disableLocalFetch bool) (modules.Streamer, error) {
+func (r *Renter) StreamerByNode(node *filesystem.FileNode, disableLocalFetch bool, priority modules.DownloadPriority, budgetIdentity string) (modules.Streamer, error) {
 	if err := r.tg.Add(); err != nil {
 		return nil, err
 	}
@@ -510,13 +515,13 @@ func (r *Renter) StreamerByNode(node *filesystem.FileNode, disableLocalFetch boo
 	if err != nil {
 		return nil, err
 	}
-	s := r.managedStreamer(snap, disableLocalFetch)
+	s := r.managedStreamer(snap, disableLocalFetch, priority, budgetIdentity)
 	return s, nil
 }
 
 // managedStreamer creates a streamer from a siafile snapshot and starts filling
-// its cache.
-func (r *Renter) managedStreamer(snapshot *siafile.Snapshot, disableLocalFetch bool) modules.Streamer {
+// its cache. Streamers without a budget identity aren't charged.
+func (r *Renter) managedStreamer(snapshot *siafile.Snapshot, disableLocalFetch bool, priority modules.DownloadPriority, budgetIdentity string) modules.Streamer {
 	s := &streamer{
 		staticFile: snapshot,
 		r:          r,
@@ -524,6 +529,8 @@ func (r *Renter) managedStreamer(snapshot *siafile.Snapshot, disableLocalFetch b
 		activateCache:           make(chan struct{}),
 		cacheReady:              make(chan struct{}),
 		staticDisableLocalFetch: disableLocalFetch,
+		staticPriority:          downloadPriorityOrDefault(priority, modules.DownloadPriorityInteractive),
+		staticBudgetIdentity:    budgetIdentity,
 		targetCacheSize:         initialStreamerCacheSize,
 	}
 	go s.threadedFillCache()
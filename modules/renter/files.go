@@ -2,6 +2,7 @@ package renter
 
 import (
 	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
 
 	"github.com/turtledex/errors"
 )
@@ -14,6 +15,15 @@ func (r *Renter) DeleteFile(siaPath modules.TurtleDexPath) error {
 		return err
 	}
 	defer r.tg.Done()
+	if err := checkDirSnapshotWritable(siaPath); err != nil {
+		return err
+	}
+
+	// Grab the file's references to deduplicated chunks before deleting it.
+	dedupRefs, err := r.managedFileDedupReferences(siaPath)
+	if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+		r.log.Printf("Unable to fetch dedup references of %v: %v", siaPath, err)
+	}
 
 	// Perform the delete operation.
 	err = r.staticFileSystem.DeleteFile(siaPath)
@@ -21,6 +31,13 @@ func (r *Renter) DeleteFile(siaPath modules.TurtleDexPath) error {
 		return errors.AddContext(err, "unable to delete siafile from filesystem")
 	}
 
+	// Release the references. The pieces of deduplicated chunks are only
+	// dropped once the last file referencing them is gone.
+	err = r.staticDedupIndex.managedRemoveReferences(dedupRefs)
+	if err != nil {
+		r.log.Printf("Unable to release dedup references of %v: %v", siaPath, err)
+	}
+
 	// Update the filesystem metadata.
 	//
 	// TODO: This is incorrect, should be running the metadata update call on a
@@ -57,6 +74,17 @@ func (r *Renter) FileList(siaPath modules.TurtleDexPath, recursive, cached bool,
 	return err
 }
 
+// SearchFiles returns the files within the directory at siaPath and its
+// subdirectories that match the params. The search uses the filesystem's
+// search index instead of walking the directory tree.
+func (r *Renter) SearchFiles(siaPath modules.TurtleDexPath, params modules.FileSearchParams) ([]modules.IndexedFile, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+	return r.staticFileSystem.Search(siaPath, params)
+}
+
 // File returns file from siaPath queried by user.
 // Update based on FileList
 func (r *Renter) File(siaPath modules.TurtleDexPath) (modules.FileInfo, error) {
@@ -90,6 +118,9 @@ func (r *Renter) RenameFile(currentName, newName modules.TurtleDexPath) error {
 		return err
 	}
 	defer r.tg.Done()
+	if err := checkDirSnapshotWritable(currentName, newName); err != nil {
+		return err
+	}
 
 	// Rename file.
 	err := r.staticFileSystem.RenameFile(currentName, newName)
@@ -120,6 +151,24 @@ func (r *Renter) RenameFile(currentName, newName modules.TurtleDexPath) error {
 	return nil
 }
 
+// ReplaceFile moves the file at currentName to newName. Unlike RenameFile, an
+// existing file at newName is replaced. Depending on the directory's version
+// retention, the replaced file is either deleted or kept as a prior version.
+func (r *Renter) ReplaceFile(currentName, newName modules.TurtleDexPath) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if err := checkDirSnapshotWritable(currentName, newName); err != nil {
+		return err
+	}
+	err := r.managedReplaceFile(newName)
+	if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+		return errors.AddContext(err, "unable to replace existing file")
+	}
+	return r.RenameFile(currentName, newName)
+}
+
 // SetFileStuck sets the Stuck field of the whole siafile to stuck.
 func (r *Renter) SetFileStuck(siaPath modules.TurtleDexPath, stuck bool) (err error) {
 	if err := r.tg.Add(); err != nil {
@@ -585,6 +585,7 @@ func (n *DirNode) managedInfo(siaPath modules.TurtleDexPath) (modules.DirectoryI
 		AggregateNumFiles:            metadata.AggregateNumFiles,
 		AggregateNumStuckChunks:      metadata.AggregateNumStuckChunks,
 		AggregateNumSubDirs:          metadata.AggregateNumSubDirs,
+		AggregateRedundantSize:       metadata.AggregateRedundantSize,
 		AggregateRepairSize:          metadata.AggregateRepairSize,
 		AggregateSize:                metadata.AggregateSize,
 		AggregateStuckHealth:         metadata.AggregateStuckHealth,
@@ -594,6 +595,10 @@ func (n *DirNode) managedInfo(siaPath modules.TurtleDexPath) (modules.DirectoryI
 		AggregateSkynetFiles: metadata.AggregateSkynetFiles,
 		AggregateSkynetSize:  metadata.AggregateSkynetSize,
 
+		// Redundancy Migration Fields
+		AggregateNumPendingMigrations: metadata.AggregateNumPendingMigrations,
+		AggregatePendingMigrationSize: metadata.AggregatePendingMigrationSize,
+
 		// TurtleDexDir Fields
 		Health:              metadata.Health,
 		LastHealthCheckTime: metadata.LastHealthCheckTime,
@@ -605,6 +610,7 @@ func (n *DirNode) managedInfo(siaPath modules.TurtleDexPath) (modules.DirectoryI
 		NumFiles:            metadata.NumFiles,
 		NumStuckChunks:      metadata.NumStuckChunks,
 		NumSubDirs:          metadata.NumSubDirs,
+		RedundantSize:       metadata.RedundantSize,
 		RepairSize:          metadata.RepairSize,
 		DirSize:             metadata.Size,
 		StuckHealth:         metadata.StuckHealth,
@@ -615,6 +621,17 @@ func (n *DirNode) managedInfo(siaPath modules.TurtleDexPath) (modules.DirectoryI
 		// Skynet Fields
 		SkynetFiles: metadata.SkynetFiles,
 		SkynetSize:  metadata.SkynetSize,
+
+		// Redundancy Migration Fields
+		NumPendingMigrations: metadata.NumPendingMigrations,
+		PendingMigrationSize: metadata.PendingMigrationSize,
+
+		// Settings
+		Quota:            metadata.Quota,
+		RedundancyPolicy: metadata.RedundancyPolicy,
+		RedundantQuota:   metadata.RedundantQuota,
+		VersionRetention: metadata.VersionRetention,
+		UserMetadata:     modules.UpdateUserMetadata(metadata.UserMetadata, nil, nil),
 	}, nil
 }
 
@@ -0,0 +1,225 @@
+package filesystem
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/errors"
+)
+
+var (
+	// ErrInvalidDirSnapshotName is returned when the name of a directory
+	// snapshot can't be used as a directory name.
+	ErrInvalidDirSnapshotName = errors.New("invalid directory snapshot name")
+)
+
+type (
+	// fileFingerprint identifies the contents of a siafile. Files that are
+	// overwritten get a new master key and creation time, while renames and
+	// repairs don't change either of them.
+	fileFingerprint struct {
+		createTime int64
+		masterKey  string
+		size       uint64
+	}
+)
+
+// DirSnapshotTurtleDexPath returns the siapath of the directory that holds
+// the files of the directory snapshot with the provided name.
+func DirSnapshotTurtleDexPath(name string) (modules.TurtleDexPath, error) {
+	if name == "" || strings.Contains(name, "/") || name == "." || name == ".." {
+		return modules.TurtleDexPath{}, ErrInvalidDirSnapshotName
+	}
+	return modules.DirectorySnapshotFolder.Join(name)
+}
+
+// CopyDir copies the siafiles within the dir at src and its subdirs to the new
+// dir at dst. The copies get new UIDs which makes them independent of the
+// original files. The siapaths of the copies and their total size are
+// returned. If copying a file fails, dst is deleted again.
+func (fs *FileSystem) CopyDir(src, dst modules.TurtleDexPath) (_ []modules.TurtleDexPath, _ uint64, err error) {
+	if dst.IsWithin(src) {
+		return nil, 0, errors.New("can't copy a dir into itself")
+	}
+	files, err := fs.TurtleDexFiles(src)
+	if err != nil {
+		return nil, 0, err
+	}
+	exists, err := fs.DirExists(dst)
+	if err != nil {
+		return nil, 0, err
+	}
+	if exists {
+		return nil, 0, ErrExists
+	}
+	if err := fs.NewTurtleDexDir(dst, modules.DefaultDirPerm); err != nil {
+		return nil, 0, errors.AddContext(err, "unable to create destination dir")
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Compose(err, fs.DeleteDir(dst))
+		}
+	}()
+
+	copies := make([]modules.TurtleDexPath, 0, len(files))
+	var size uint64
+	for _, srcPath := range files {
+		dstPath, err := srcPath.Rebase(src, dst)
+		if err != nil {
+			return nil, 0, err
+		}
+		fileSize, err := fs.managedCopyFile(srcPath, dstPath)
+		if err != nil {
+			return nil, 0, errors.AddContext(err, "unable to copy "+srcPath.String())
+		}
+		copies = append(copies, dstPath)
+		size += fileSize
+	}
+	return copies, size, nil
+}
+
+// DiffDir compares the siafiles within the dir at snapshot with the ones
+// within the dir at live. The files of the diff are identified by their
+// siapaths within live.
+func (fs *FileSystem) DiffDir(snapshot, live modules.TurtleDexPath) (diff modules.DirectorySnapshotDiff, err error) {
+	snapshotFiles, err := fs.managedDirFingerprints(snapshot)
+	if err != nil {
+		return modules.DirectorySnapshotDiff{}, errors.AddContext(err, "unable to read snapshot")
+	}
+	// The live dir might have been deleted since the snapshot was created.
+	liveFiles := make(map[string]fileFingerprint)
+	exists, err := fs.DirExists(live)
+	if err != nil {
+		return modules.DirectorySnapshotDiff{}, err
+	}
+	if exists {
+		liveFiles, err = fs.managedDirFingerprints(live)
+		if err != nil {
+			return modules.DirectorySnapshotDiff{}, errors.AddContext(err, "unable to read live dir")
+		}
+	}
+
+	for relPath, fp := range liveFiles {
+		snapshotFP, exists := snapshotFiles[relPath]
+		if exists && snapshotFP == fp {
+			continue
+		}
+		sp, err := live.Join(relPath)
+		if err != nil {
+			return modules.DirectorySnapshotDiff{}, err
+		}
+		if exists {
+			diff.Modified = append(diff.Modified, sp)
+		} else {
+			diff.Added = append(diff.Added, sp)
+		}
+	}
+	for relPath := range snapshotFiles {
+		if _, exists := liveFiles[relPath]; exists {
+			continue
+		}
+		sp, err := live.Join(relPath)
+		if err != nil {
+			return modules.DirectorySnapshotDiff{}, err
+		}
+		diff.Removed = append(diff.Removed, sp)
+	}
+	for _, sps := range [][]modules.TurtleDexPath{diff.Added, diff.Modified, diff.Removed} {
+		sort.Slice(sps, func(i, j int) bool {
+			return sps[i].String() < sps[j].String()
+		})
+	}
+	return diff, nil
+}
+
+// TurtleDexFiles returns the siapaths of the siafiles within the dir at
+// siaPath and its subdirs.
+func (fs *FileSystem) TurtleDexFiles(siaPath modules.TurtleDexPath) ([]modules.TurtleDexPath, error) {
+	exists, err := fs.DirExists(siaPath)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotExist
+	}
+	root := fs.managedAbsPath()
+	var files []modules.TurtleDexPath
+	err = fs.Walk(siaPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != modules.TurtleDexFileExtension {
+			return nil
+		}
+		var sp modules.TurtleDexPath
+		if err := sp.FromSysPath(path, root); err != nil {
+			return err
+		}
+		files = append(files, sp)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to walk dir")
+	}
+	return files, nil
+}
+
+// managedCopyFile copies the siafile at src to dst and returns the size of
+// the file.
+func (fs *FileSystem) managedCopyFile(src, dst modules.TurtleDexPath) (_ uint64, err error) {
+	n, err := fs.OpenTurtleDexFile(src)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		err = errors.Compose(err, n.Close())
+	}()
+	size := n.Size()
+	// The snapshot reader holds a lock on the file, so it is closed before
+	// the copy is added.
+	sr, err := n.SnapshotReader()
+	if err != nil {
+		return 0, err
+	}
+	b, err := ioutil.ReadAll(sr)
+	if err := errors.Compose(err, sr.Close()); err != nil {
+		return 0, errors.AddContext(err, "unable to read siafile")
+	}
+	return size, fs.AddTurtleDexFileFromReader(bytes.NewReader(b), dst)
+}
+
+// managedDirFingerprints returns the fingerprints of the siafiles within the
+// dir at siaPath and its subdirs by their paths relative to the dir.
+func (fs *FileSystem) managedDirFingerprints(siaPath modules.TurtleDexPath) (map[string]fileFingerprint, error) {
+	files, err := fs.TurtleDexFiles(siaPath)
+	if err != nil {
+		return nil, err
+	}
+	fps := make(map[string]fileFingerprint, len(files))
+	for _, sp := range files {
+		n, err := fs.OpenTurtleDexFile(sp)
+		if err != nil {
+			return nil, err
+		}
+		md := n.Metadata()
+		fp := fileFingerprint{
+			createTime: md.CreateTime.UnixNano(),
+			masterKey:  string(n.MasterKey().Key()),
+			size:       uint64(md.FileSize),
+		}
+		if err := n.Close(); err != nil {
+			return nil, err
+		}
+		relPath := sp.String()
+		if !siaPath.IsRoot() {
+			relPath = strings.TrimPrefix(relPath, siaPath.String()+"/")
+		}
+		fps[relPath] = fp
+	}
+	return fps, nil
+}
@@ -0,0 +1,166 @@
+package filesystem
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/errors"
+)
+
+// checkTurtleDexPaths checks that the siapaths match the expected paths.
+func checkTurtleDexPaths(t *testing.T, sps []modules.TurtleDexPath, expected ...string) {
+	t.Helper()
+	if len(sps) != len(expected) {
+		t.Fatalf("expected %v but got %v", expected, sps)
+	}
+	for i := range sps {
+		if sps[i].String() != expected[i] {
+			t.Fatalf("expected %v but got %v", expected, sps)
+		}
+	}
+}
+
+// TestCopyDir tests copying the siafiles of a dir.
+func TestCopyDir(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// Create filesystem.
+	root := filepath.Join(testDir(t.Name()), "fs-root")
+	fs := newTestFileSystem(root)
+
+	src := newTurtleDexPath("dir")
+	fs.addTestTurtleDexFile(newTurtleDexPath("dir/foo"))
+	fs.addTestTurtleDexFile(newTurtleDexPath("dir/sub/bar"))
+	fs.addTestTurtleDexFile(newTurtleDexPath("other"))
+
+	dst, err := DirSnapshotTurtleDexPath("snap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	copies, size, err := fs.CopyDir(src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTurtleDexPaths(t, copies, "var/dirsnapshots/snap/foo", "var/dirsnapshots/snap/sub/bar")
+
+	// The copies should have the same size and key but a different UID.
+	var expectedSize uint64
+	for _, sp := range []string{"foo", "sub/bar"} {
+		orig, err := fs.OpenTurtleDexFile(newTurtleDexPath("dir/" + sp))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cp, err := fs.OpenTurtleDexFile(newTurtleDexPath("var/dirsnapshots/snap/" + sp))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if orig.UID() == cp.UID() {
+			t.Fatal("copy should have a new UID")
+		}
+		if orig.Size() != cp.Size() || string(orig.MasterKey().Key()) != string(cp.MasterKey().Key()) {
+			t.Fatal("copy doesn't match original")
+		}
+		expectedSize += orig.Size()
+		if err := errors.Compose(orig.Close(), cp.Close()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if size != expectedSize {
+		t.Fatalf("expected size %v but got %v", expectedSize, size)
+	}
+
+	// Copying into an existing dir or into the source dir should fail.
+	if _, _, err := fs.CopyDir(src, dst); !errors.Contains(err, ErrExists) {
+		t.Fatal("expected ErrExists but got", err)
+	}
+	if _, _, err := fs.CopyDir(src, newTurtleDexPath("dir/sub/copy")); err == nil {
+		t.Fatal("copying a dir into itself should fail")
+	}
+	if _, _, err := fs.CopyDir(newTurtleDexPath("missing"), newTurtleDexPath("copy")); !errors.Contains(err, ErrNotExist) {
+		t.Fatal("expected ErrNotExist but got", err)
+	}
+
+	// Invalid snapshot names should be rejected.
+	for _, name := range []string{"", "a/b", ".", ".."} {
+		if _, err := DirSnapshotTurtleDexPath(name); !errors.Contains(err, ErrInvalidDirSnapshotName) {
+			t.Fatalf("expected name '%v' to be invalid but got %v", name, err)
+		}
+	}
+}
+
+// TestDiffDir tests diffing a copy of a dir against the dir.
+func TestDiffDir(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// Create filesystem.
+	root := filepath.Join(testDir(t.Name()), "fs-root")
+	fs := newTestFileSystem(root)
+
+	live := newTurtleDexPath("dir")
+	fs.addTestTurtleDexFile(newTurtleDexPath("dir/unchanged"))
+	fs.addTestTurtleDexFile(newTurtleDexPath("dir/renamed"))
+	fs.addTestTurtleDexFile(newTurtleDexPath("dir/overwritten"))
+	fs.addTestTurtleDexFile(newTurtleDexPath("dir/sub/deleted"))
+	snapshot, err := DirSnapshotTurtleDexPath("snap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := fs.CopyDir(live, snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without changes, the diff should be empty.
+	diff, err := fs.DiffDir(snapshot, live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Added)+len(diff.Modified)+len(diff.Removed) != 0 {
+		t.Fatal("diff should be empty", diff)
+	}
+
+	// Change the live dir.
+	if err := fs.RenameFile(newTurtleDexPath("dir/renamed"), newTurtleDexPath("dir/sub/renamed")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.DeleteFile(newTurtleDexPath("dir/overwritten")); err != nil {
+		t.Fatal(err)
+	}
+	fs.addTestTurtleDexFile(newTurtleDexPath("dir/overwritten"))
+	if err := fs.DeleteFile(newTurtleDexPath("dir/sub/deleted")); err != nil {
+		t.Fatal(err)
+	}
+	fs.addTestTurtleDexFile(newTurtleDexPath("dir/added"))
+	diff, err = fs.DiffDir(snapshot, live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTurtleDexPaths(t, diff.Added, "dir/added", "dir/sub/renamed")
+	checkTurtleDexPaths(t, diff.Modified, "dir/overwritten")
+	checkTurtleDexPaths(t, diff.Removed, "dir/renamed", "dir/sub/deleted")
+
+	// The files of the snapshot should be unaffected.
+	files, err := fs.TurtleDexFiles(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 4 {
+		t.Fatal("expected 4 files in snapshot but got", len(files))
+	}
+
+	// If the live dir is deleted, all files are removed.
+	if err := fs.DeleteDir(live); err != nil {
+		t.Fatal(err)
+	}
+	diff, err = fs.DiffDir(snapshot, live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Added) != 0 || len(diff.Modified) != 0 || len(diff.Removed) != 4 {
+		t.Fatal("unexpected diff", diff)
+	}
+}
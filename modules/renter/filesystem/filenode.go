@@ -142,6 +142,7 @@ func (n *FileNode) managedFileInfo(siaPath modules.TurtleDexPath, offline map[st
 		UID:              n.staticUID,
 		UploadedBytes:    uploadedBytes,
 		UploadProgress:   uploadProgress,
+		UserMetadata:     n.UserMetadata(),
 	}
 	return fileInfo, nil
 }
@@ -165,6 +166,47 @@ func (n *FileNode) managedRename(newName string, oldParent, newParent *DirNode)
 	if exists := newParent.childExists(newName); exists {
 		return ErrExists
 	}
+	return n.rename(newName, oldParent, newParent)
+}
+
+// managedReplace renames the fNode's underlying file to newName, replacing the
+// file with the provided uid at that location. The check, the deletion and the
+// rename happen while the parents are locked.
+func (n *FileNode) managedReplace(newName string, uid siafile.TurtleDexfileUID, oldParent, newParent *DirNode) error {
+	// Lock the parents. If they are the same, only lock one.
+	if oldParent.staticUID == newParent.staticUID {
+		oldParent.node.mu.Lock()
+		defer oldParent.node.mu.Unlock()
+	} else {
+		oldParent.node.mu.Lock()
+		defer oldParent.node.mu.Unlock()
+		newParent.node.mu.Lock()
+		defer newParent.node.mu.Unlock()
+	}
+	// Check that the file at the new location is the expected one.
+	replaced, err := newParent.readonlyOpenFile(newName)
+	if err != nil {
+		return err
+	}
+	if replaced.UID() != uid {
+		return ErrFileReplaced
+	}
+	// Delete it.
+	err = replaced.managedDelete()
+	if err != nil {
+		return errors.AddContext(err, "failed to delete replaced file")
+	}
+	newParent.removeFile(replaced)
+
+	n.node.mu.Lock()
+	defer n.node.mu.Unlock()
+	return n.rename(newName, oldParent, newParent)
+}
+
+// rename renames the fNode's underlying file. The caller needs to hold the
+// locks of the node and both parents and make sure that there is no file or
+// folder with the new name.
+func (n *FileNode) rename(newName string, oldParent, newParent *DirNode) error {
 	newPath := filepath.Join(newParent.absPath(), newName) + modules.TurtleDexFileExtension
 	// Rename the file.
 	err := n.TurtleDexFile.Rename(newPath)
@@ -225,6 +267,20 @@ func (n *FileNode) staticCachedInfo(siaPath modules.TurtleDexPath) (modules.File
 		UID:              n.staticUID,
 		UploadedBytes:    md.CachedUploadedBytes,
 		UploadProgress:   md.CachedUploadProgress,
+		UserMetadata:     modules.UpdateUserMetadata(md.UserMetadata, nil, nil),
 	}
 	return fileInfo, nil
 }
+
+// IndexedFile returns the entry of the file in the search index.
+func (n *FileNode) IndexedFile(siaPath modules.TurtleDexPath) modules.IndexedFile {
+	md := n.Metadata()
+	return modules.IndexedFile{
+		TurtleDexPath: siaPath,
+		Filesize:      uint64(md.FileSize),
+		CreateTime:    md.CreateTime,
+		ModTime:       md.ModTime,
+		Health:        md.CachedHealth,
+		Skylinks:      md.Skylinks,
+	}
+}
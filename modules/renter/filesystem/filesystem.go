@@ -32,6 +32,10 @@ var (
 	// ErrDeleteFileIsDir is returned when the file delete method is used but
 	// the filename corresponds to a directory
 	ErrDeleteFileIsDir = errors.New("cannot delete file, file is a directory")
+
+	// ErrFileReplaced is returned by ReplaceFile if the file that should be
+	// replaced isn't the expected file anymore.
+	ErrFileReplaced = errors.New("file was replaced by a different file")
 )
 
 type (
@@ -40,6 +44,9 @@ type (
 	// future.
 	FileSystem struct {
 		DirNode
+
+		// staticSearchIndex is the index of the filesystem's siafiles.
+		staticSearchIndex *searchIndex
 	}
 
 	// node is a struct that contains the common fields of every node.
@@ -147,8 +154,9 @@ func (n *node) managedAbsPath() string {
 }
 
 // New creates a new FileSystem at the specified root path. The folder will be
-// created if it doesn't exist already.
-func New(root string, log *persist.Logger, wal *writeaheadlog.WAL) (*FileSystem, error) {
+// created if it doesn't exist already. The search index of the filesystem is
+// persisted within persistDir.
+func New(root, persistDir string, log *persist.Logger, wal *writeaheadlog.WAL) (*FileSystem, error) {
 	fs := &FileSystem{
 		DirNode: DirNode{
 			// The root doesn't require a parent, a name or uid.
@@ -163,6 +171,17 @@ func New(root string, log *persist.Logger, wal *writeaheadlog.WAL) (*FileSystem,
 	if err != nil && !errors.Contains(err, ErrExists) {
 		return nil, err
 	}
+	// Load the search index. An empty index is built from the existing files
+	// in case the index didn't exist before.
+	fs.staticSearchIndex, err = newSearchIndex(persistDir)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to load search index")
+	}
+	if fs.staticSearchIndex.managedIsEmpty() {
+		if err := fs.managedBuildSearchIndex(); err != nil {
+			return nil, errors.Compose(errors.AddContext(err, "unable to build search index"), fs.Close())
+		}
+	}
 	return fs, nil
 }
 
@@ -194,8 +213,17 @@ func (fs *FileSystem) AddTurtleDexFileFromReader(rs io.ReadSeeker, siaPath modul
 	defer func() {
 		err = errors.Compose(err, dir.Close())
 	}()
-	// Add the file to the dir.
-	return dir.managedNewTurtleDexFileFromExisting(sf, chunks)
+	// Add the file to the dir. Its path might have changed to make it unique.
+	err = dir.managedNewTurtleDexFileFromExisting(sf, chunks)
+	if err != nil {
+		return err
+	}
+	var sp modules.TurtleDexPath
+	if err := sp.FromSysPath(sf.TurtleDexFilePath(), fs.managedAbsPath()); err != nil {
+		return err
+	}
+	fs.managedIndexFile(sp)
+	return nil
 }
 
 // CachedFileInfo returns the cached File Information of the siafile
@@ -234,6 +262,11 @@ func (fs *FileSystem) CachedListOnNode(d *DirNode) (fis []modules.FileInfo, dis
 	return
 }
 
+// Close closes the filesystem's search index.
+func (fs *FileSystem) Close() error {
+	return fs.staticSearchIndex.managedClose()
+}
+
 // DeleteDir deletes a dir from the filesystem. The dir will be marked as
 // 'deleted' which should cause all remaining instances of the dir to be close
 // shortly. Only when all instances of the dir are closed it will be removed
@@ -241,7 +274,14 @@ func (fs *FileSystem) CachedListOnNode(d *DirNode) (fis []modules.FileInfo, dis
 // file of the same path can be created and the existing file can't be opened
 // until all instances of it are closed.
 func (fs *FileSystem) DeleteDir(siaPath modules.TurtleDexPath) error {
-	return fs.managedDeleteDir(siaPath.String())
+	err := fs.managedDeleteDir(siaPath.String())
+	if err != nil {
+		return err
+	}
+	if err := fs.staticSearchIndex.managedRemoveDir(siaPath); err != nil {
+		fs.staticLog.Printf("WARN: unable to remove dir %v from search index: %v", siaPath, err)
+	}
+	return nil
 }
 
 // DeleteFile deletes a file from the filesystem. The file will be marked as
@@ -251,7 +291,14 @@ func (fs *FileSystem) DeleteDir(siaPath modules.TurtleDexPath) error {
 // file of the same path can be created and the existing file can't be opened
 // until all instances of it are closed.
 func (fs *FileSystem) DeleteFile(siaPath modules.TurtleDexPath) error {
-	return fs.managedDeleteFile(siaPath.String())
+	err := fs.managedDeleteFile(siaPath.String())
+	if err != nil {
+		return err
+	}
+	if err := fs.staticSearchIndex.managedRemove(siaPath); err != nil {
+		fs.staticLog.Printf("WARN: unable to remove file %v from search index: %v", siaPath, err)
+	}
+	return nil
 }
 
 // DirInfo returns the Directory Information of the ttdxdir
@@ -326,7 +373,12 @@ func (fs *FileSystem) NewTurtleDexFile(siaPath modules.TurtleDexPath, source str
 	if err = fs.NewTurtleDexDir(dirTurtleDexPath, fileMode); err != nil {
 		return errors.AddContext(err, fmt.Sprintf("failed to create TurtleDexDir %v for TurtleDexFile %v", dirTurtleDexPath.String(), siaPath.String()))
 	}
-	return fs.managedNewTurtleDexFile(siaPath.String(), source, ec, mk, fileSize, fileMode, disablePartialUpload)
+	err = fs.managedNewTurtleDexFile(siaPath.String(), source, ec, mk, fileSize, fileMode, disablePartialUpload)
+	if err != nil {
+		return err
+	}
+	fs.managedIndexFile(siaPath)
+	return nil
 }
 
 // ReadDir reads all the fileinfos of the specified dir.
@@ -439,7 +491,14 @@ func (fs *FileSystem) NewTurtleDexFileFromLegacyData(fd siafile.FileData) (_ *Fi
 		err = errors.Compose(err, dir.Close())
 	}()
 	// Add the file to the dir.
-	return dir.managedNewTurtleDexFileFromLegacyData(sp.Name(), fd)
+	n, err := dir.managedNewTurtleDexFileFromLegacyData(sp.Name(), fd)
+	if err != nil {
+		return nil, err
+	}
+	if err := fs.staticSearchIndex.managedUpdate(n.IndexedFile(sp)); err != nil {
+		fs.staticLog.Printf("WARN: unable to add file %v to search index: %v", sp, err)
+	}
+	return n, nil
 }
 
 // OpenTurtleDexDir opens a TurtleDexDir and adds it and all of its parents to the
@@ -516,7 +575,79 @@ func (fs *FileSystem) RenameFile(oldTurtleDexPath, newTurtleDexPath modules.Turt
 		err = errors.Compose(err, newDir.Close())
 	}()
 	// Rename the file.
-	return sf.managedRename(newTurtleDexPath.Name(), oldDir, newDir)
+	err = sf.managedRename(newTurtleDexPath.Name(), oldDir, newDir)
+	if err != nil {
+		return err
+	}
+	indexed, err := fs.staticSearchIndex.managedRenameFile(oldTurtleDexPath, newTurtleDexPath)
+	if err != nil {
+		fs.staticLog.Printf("WARN: unable to rename file %v in search index: %v", oldTurtleDexPath, err)
+	}
+	if !indexed {
+		fs.managedIndexFile(newTurtleDexPath)
+	}
+	return nil
+}
+
+// ReplaceFile moves the file at srcTurtleDexPath to dstTurtleDexPath, deleting
+// the file at dstTurtleDexPath. Checking that the file at dstTurtleDexPath is
+// the file with the provided uid, deleting it and moving the file at
+// srcTurtleDexPath into its place happens while holding the locks of both
+// parent dirs. If the file at dstTurtleDexPath was replaced by a different
+// file, ErrFileReplaced is returned and neither file is modified.
+func (fs *FileSystem) ReplaceFile(srcTurtleDexPath, dstTurtleDexPath modules.TurtleDexPath, uid siafile.TurtleDexfileUID) (err error) {
+	// Open TurtleDexDir for file at old location.
+	srcDirTurtleDexPath, err := srcTurtleDexPath.Dir()
+	if err != nil {
+		return err
+	}
+	srcDir, err := fs.managedOpenTurtleDexDir(srcDirTurtleDexPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, srcDir.Close())
+	}()
+	// Open the file.
+	sf, err := srcDir.managedOpenFile(srcTurtleDexPath.Name())
+	if errors.Contains(err, ErrNotExist) {
+		return ErrNotExist
+	}
+	if err != nil {
+		return errors.AddContext(err, "failed to open file for renaming")
+	}
+	defer func() {
+		err = errors.Compose(err, sf.Close())
+	}()
+
+	// Open TurtleDexDir of the file that is replaced.
+	dstDirTurtleDexPath, err := dstTurtleDexPath.Dir()
+	if err != nil {
+		return err
+	}
+	dstDir, err := fs.managedOpenTurtleDexDir(dstDirTurtleDexPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, dstDir.Close())
+	}()
+	// Replace the file.
+	err = sf.managedReplace(dstTurtleDexPath.Name(), uid, srcDir, dstDir)
+	if err != nil {
+		return err
+	}
+	if err := fs.staticSearchIndex.managedRemove(dstTurtleDexPath); err != nil {
+		fs.staticLog.Printf("WARN: unable to remove file %v from search index: %v", dstTurtleDexPath, err)
+	}
+	indexed, err := fs.staticSearchIndex.managedRenameFile(srcTurtleDexPath, dstTurtleDexPath)
+	if err != nil {
+		fs.staticLog.Printf("WARN: unable to rename file %v in search index: %v", srcTurtleDexPath, err)
+	}
+	if !indexed {
+		fs.managedIndexFile(dstTurtleDexPath)
+	}
+	return nil
 }
 
 // RenameDir takes an existing directory and changes the path. The original
@@ -568,7 +699,13 @@ func (fs *FileSystem) RenameDir(oldTurtleDexPath, newTurtleDexPath modules.Turtl
 	}()
 	// Rename the dir.
 	err = sd.managedRename(newTurtleDexPath.Name(), oldDir, newDir)
-	return err
+	if err != nil {
+		return err
+	}
+	if err := fs.staticSearchIndex.managedRenameDir(oldTurtleDexPath, newTurtleDexPath); err != nil {
+		fs.staticLog.Printf("WARN: unable to rename dir %v in search index: %v", oldTurtleDexPath, err)
+	}
+	return nil
 }
 
 // managedDeleteFile opens the parent folder of the file to delete and calls
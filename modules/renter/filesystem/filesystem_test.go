@@ -103,7 +103,7 @@ func newTestFileSystem(root string) *FileSystem {
 	if err != nil {
 		panic(err.Error())
 	}
-	fs, err := New(root, logger, wal)
+	fs, err := New(root, root+"-persist", logger, wal)
 	if err != nil {
 		panic(err.Error())
 	}
@@ -757,6 +757,64 @@ func TestRenameFile(t *testing.T) {
 	sf.Close()
 }
 
+// TestReplaceFile tests replacing a file with another file if it wasn't
+// replaced in the meantime.
+func TestReplaceFile(t *testing.T) {
+	if testing.Short() && !build.VLONG {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// Create filesystem.
+	root := filepath.Join(testDir(t.Name()), "fs-root")
+	fs := newTestFileSystem(root)
+	// Add the file to replace and the replacement in a different dir.
+	foo := newTurtleDexPath("foo")
+	barfoo := newTurtleDexPath("bar/foo")
+	fs.addTestTurtleDexFile(foo)
+	fs.addTestTurtleDexFile(barfoo)
+	sf, err := fs.OpenTurtleDexFile(foo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uid := sf.UID()
+	sf.Close()
+	sf, err = fs.OpenTurtleDexFile(barfoo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newUID := sf.UID()
+	sf.Close()
+
+	// Replacing the file with the wrong uid should fail without modifying
+	// either file.
+	if err := fs.ReplaceFile(barfoo, foo, newUID); !errors.Contains(err, ErrFileReplaced) {
+		t.Fatal("expected ErrFileReplaced but got:", err)
+	}
+	for _, sp := range []modules.TurtleDexPath{foo, barfoo} {
+		sf, err := fs.OpenTurtleDexFile(sp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sf.Close()
+	}
+
+	// Replace the file.
+	if err := fs.ReplaceFile(barfoo, foo, uid); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.OpenTurtleDexFile(barfoo); !errors.Contains(err, ErrNotExist) {
+		t.Fatal("expected ErrNotExist but got:", err)
+	}
+	sf, err = fs.OpenTurtleDexFile(foo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sf.UID() != newUID {
+		t.Fatal("file wasn't replaced")
+	}
+	sf.Close()
+}
+
 // TestThreadedAccess tests rapidly opening and closing files and directories
 // from multiple threads to check the locking conventions.
 func TestThreadedAccess(t *testing.T) {
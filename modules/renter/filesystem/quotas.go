@@ -0,0 +1,76 @@
+package filesystem
+
+import (
+	"fmt"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/errors"
+)
+
+var (
+	// ErrQuotaExceeded is returned when adding data to a dir would exceed the
+	// quota of the dir or one of its parents.
+	ErrQuotaExceeded = errors.New("directory quota exceeded")
+)
+
+// CheckDirQuotas checks whether size bytes, or redundantSize bytes after
+// redundancy, can be added to the dir at siaPath without exceeding the quota
+// of the dir or any of its parents. Dirs that don't exist yet are skipped
+// since they will be created without a quota.
+func (fs *FileSystem) CheckDirQuotas(siaPath modules.TurtleDexPath, size, redundantSize uint64) error {
+	dirPath := siaPath
+	for {
+		err := fs.managedCheckDirQuota(dirPath, size, redundantSize)
+		if err != nil && !errors.Contains(err, ErrNotExist) {
+			return err
+		}
+		if dirPath.IsRoot() {
+			return nil
+		}
+		dirPath, err = dirPath.Dir()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// SetDirQuota sets the quota of the dir at siaPath. A quota of 0 means that
+// there is no limit.
+func (fs *FileSystem) SetDirQuota(siaPath modules.TurtleDexPath, quota, redundantQuota uint64) (err error) {
+	dir, err := fs.OpenTurtleDexDir(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+	}()
+	md, err := dir.Metadata()
+	if err != nil {
+		return err
+	}
+	md.Quota = quota
+	md.RedundantQuota = redundantQuota
+	return dir.UpdateMetadata(md)
+}
+
+// managedCheckDirQuota checks the quota of a single dir.
+func (fs *FileSystem) managedCheckDirQuota(siaPath modules.TurtleDexPath, size, redundantSize uint64) (err error) {
+	dir, err := fs.OpenTurtleDexDir(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+	}()
+	md, err := dir.Metadata()
+	if err != nil {
+		return err
+	}
+	if md.Quota > 0 && md.AggregateSize+size > md.Quota {
+		return errors.AddContext(ErrQuotaExceeded, fmt.Sprintf("'%v' uses %v of %v bytes", siaPath, md.AggregateSize, md.Quota))
+	}
+	if md.RedundantQuota > 0 && md.AggregateRedundantSize+redundantSize > md.RedundantQuota {
+		return errors.AddContext(ErrQuotaExceeded, fmt.Sprintf("'%v' uses %v of %v redundant bytes", siaPath, md.AggregateRedundantSize, md.RedundantQuota))
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package filesystem
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/errors"
+)
+
+// TestDirQuotas tests setting and enforcing dir quotas.
+func TestDirQuotas(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// Create filesystem.
+	root := filepath.Join(testDir(t.Name()), "fs-root")
+	fs := newTestFileSystem(root)
+
+	// Create a dir and set a quota on it.
+	dirPath := newTurtleDexPath("dir")
+	if err := fs.NewTurtleDexDir(dirPath, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.SetDirQuota(dirPath, 100, 1000); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a bubble of the dir. The quota shouldn't be overwritten.
+	dir, err := fs.OpenTurtleDexDir(dirPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md, err := dir.Metadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+	md.AggregateSize = 50
+	md.AggregateRedundantSize = 500
+	md.Quota = 0
+	md.RedundantQuota = 0
+	if err := dir.UpdateBubbledMetadata(md); err != nil {
+		t.Fatal(err)
+	}
+	md, err = dir.Metadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dir.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if md.Quota != 100 || md.RedundantQuota != 1000 {
+		t.Fatal("quota was overwritten by bubble", md.Quota, md.RedundantQuota)
+	}
+
+	// Adding data that fits should work, also for sub dirs that don't exist
+	// yet.
+	subPath := newTurtleDexPath("dir/sub")
+	if err := fs.CheckDirQuotas(subPath, 50, 500); err != nil {
+		t.Fatal(err)
+	}
+	// Exceeding either quota should fail.
+	if err := fs.CheckDirQuotas(subPath, 51, 500); !errors.Contains(err, ErrQuotaExceeded) {
+		t.Fatal("expected ErrQuotaExceeded but got", err)
+	}
+	if err := fs.CheckDirQuotas(subPath, 50, 501); !errors.Contains(err, ErrQuotaExceeded) {
+		t.Fatal("expected ErrQuotaExceeded but got", err)
+	}
+	// Dirs outside of the quota aren't affected.
+	if err := fs.CheckDirQuotas(newTurtleDexPath("other"), 51, 501); err != nil {
+		t.Fatal(err)
+	}
+
+	// Removing the quota should lift the limit.
+	if err := fs.SetDirQuota(dirPath, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.CheckDirQuotas(subPath, 51, 501); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,65 @@
+package filesystem
+
+import (
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/errors"
+)
+
+// RedundancyPolicy returns the redundancy policy that applies to the files
+// within the dir at siaPath. The policy is inherited from the closest dir,
+// starting at the dir itself, that has a policy set. An unset policy is
+// returned if neither the dir nor its parents have one.
+func (fs *FileSystem) RedundancyPolicy(siaPath modules.TurtleDexPath) (modules.RedundancyPolicy, error) {
+	dirPath := siaPath
+	for {
+		policy, err := fs.managedDirRedundancyPolicy(dirPath)
+		if err != nil && !errors.Contains(err, ErrNotExist) {
+			return modules.RedundancyPolicy{}, err
+		}
+		if policy.IsSet() || dirPath.IsRoot() {
+			return policy, nil
+		}
+		dirPath, err = dirPath.Dir()
+		if err != nil {
+			return modules.RedundancyPolicy{}, err
+		}
+	}
+}
+
+// SetRedundancyPolicy sets the redundancy policy of the dir at siaPath. An
+// unset policy inherits the policy of the parent dir.
+func (fs *FileSystem) SetRedundancyPolicy(siaPath modules.TurtleDexPath, policy modules.RedundancyPolicy) (err error) {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	dir, err := fs.OpenTurtleDexDir(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+	}()
+	md, err := dir.Metadata()
+	if err != nil {
+		return err
+	}
+	md.RedundancyPolicy = policy
+	return dir.UpdateMetadata(md)
+}
+
+// managedDirRedundancyPolicy returns the redundancy policy that is set in the
+// metadata of the dir at siaPath.
+func (fs *FileSystem) managedDirRedundancyPolicy(siaPath modules.TurtleDexPath) (_ modules.RedundancyPolicy, err error) {
+	dir, err := fs.OpenTurtleDexDir(siaPath)
+	if err != nil {
+		return modules.RedundancyPolicy{}, err
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+	}()
+	md, err := dir.Metadata()
+	if err != nil {
+		return modules.RedundancyPolicy{}, err
+	}
+	return md.RedundancyPolicy, nil
+}
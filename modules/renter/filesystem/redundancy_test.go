@@ -0,0 +1,126 @@
+package filesystem
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/errors"
+)
+
+// TestRedundancyPolicy tests setting redundancy policies on dirs and that
+// they are inherited by sub dirs.
+func TestRedundancyPolicy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// Create filesystem.
+	root := filepath.Join(testDir(t.Name()), "fs-root")
+	fs := newTestFileSystem(root)
+
+	// Create a dir with a sub dir.
+	dirPath := newTurtleDexPath("dir")
+	subDirPath := newTurtleDexPath("dir/subdir")
+	if err := fs.NewTurtleDexDir(subDirPath, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a policy, none should be returned.
+	policy, err := fs.RedundancyPolicy(subDirPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.IsSet() {
+		t.Fatal("policy shouldn't be set", policy)
+	}
+
+	// Set a policy on the dir. The sub dir should inherit it.
+	dirPolicy := modules.RedundancyPolicy{
+		Tiers: []modules.RedundancyTier{
+			{DataPieces: 10, ParityPieces: 20},
+			{After: time.Hour, DataPieces: 10, ParityPieces: 10},
+		},
+	}
+	if err := fs.SetRedundancyPolicy(dirPath, dirPolicy); err != nil {
+		t.Fatal(err)
+	}
+	policy, err = fs.RedundancyPolicy(subDirPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(policy, dirPolicy) {
+		t.Fatalf("expected %v but got %v", dirPolicy, policy)
+	}
+
+	// Set a policy on the sub dir. It should take precedence.
+	subDirPolicy := modules.RedundancyPolicy{
+		Tiers: []modules.RedundancyTier{{DataPieces: 1, ParityPieces: 2}},
+	}
+	if err := fs.SetRedundancyPolicy(subDirPath, subDirPolicy); err != nil {
+		t.Fatal(err)
+	}
+	policy, err = fs.RedundancyPolicy(subDirPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(policy, subDirPolicy) {
+		t.Fatalf("expected %v but got %v", subDirPolicy, policy)
+	}
+
+	// The policy should be reported in the dir info and survive a bubble.
+	dir, err := fs.OpenTurtleDexDir(subDirPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md, err := dir.Metadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+	md.RedundancyPolicy = modules.RedundancyPolicy{}
+	md.NumPendingMigrations = 1
+	if err := dir.UpdateBubbledMetadata(md); err != nil {
+		t.Fatal(err)
+	}
+	if err := dir.Close(); err != nil {
+		t.Fatal(err)
+	}
+	di, err := fs.DirInfo(subDirPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(di.RedundancyPolicy, subDirPolicy) {
+		t.Fatalf("expected %v but got %v", subDirPolicy, di.RedundancyPolicy)
+	}
+	if di.NumPendingMigrations != 1 {
+		t.Fatal("wrong number of pending migrations", di.NumPendingMigrations)
+	}
+
+	// Unsetting the policy of the sub dir should fall back to the dir's.
+	if err := fs.SetRedundancyPolicy(subDirPath, modules.RedundancyPolicy{}); err != nil {
+		t.Fatal(err)
+	}
+	policy, err = fs.RedundancyPolicy(subDirPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(policy, dirPolicy) {
+		t.Fatalf("expected %v but got %v", dirPolicy, policy)
+	}
+
+	// Invalid policies should be rejected.
+	invalid := modules.RedundancyPolicy{
+		Tiers: []modules.RedundancyTier{{After: time.Hour, DataPieces: 1, ParityPieces: 2}},
+	}
+	err = fs.SetRedundancyPolicy(dirPath, invalid)
+	if !errors.Contains(err, modules.ErrRedundancyTierOrder) {
+		t.Fatal("expected ErrRedundancyTierOrder but got", err)
+	}
+	// Setting the policy of a dir that doesn't exist should fail.
+	err = fs.SetRedundancyPolicy(newTurtleDexPath("nodir"), dirPolicy)
+	if !errors.Contains(err, ErrNotExist) {
+		t.Fatal("expected ErrNotExist but got", err)
+	}
+}
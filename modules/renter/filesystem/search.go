@@ -0,0 +1,80 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/errors"
+)
+
+// Search returns the files within the dir at siaPath and its subdirs that
+// match the params, sorted by their siapaths.
+func (fs *FileSystem) Search(siaPath modules.TurtleDexPath, params modules.FileSearchParams) ([]modules.IndexedFile, error) {
+	if err := modules.ValidateFileSearchParams(params); err != nil {
+		return nil, errors.AddContext(err, "invalid search params")
+	}
+	exists, err := fs.DirExists(siaPath)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotExist
+	}
+	return fs.staticSearchIndex.managedSearch(siaPath, params), nil
+}
+
+// UpdateSearchIndex updates the entries of the provided files in the search
+// index. It is used to update the fields of the files which change over time,
+// like their health.
+func (fs *FileSystem) UpdateSearchIndex(files ...modules.IndexedFile) error {
+	return fs.staticSearchIndex.managedUpdate(files...)
+}
+
+// managedBuildSearchIndex adds all of the filesystem's siafiles to the search
+// index.
+func (fs *FileSystem) managedBuildSearchIndex() error {
+	root := fs.managedAbsPath()
+	var files []modules.IndexedFile
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			fs.staticLog.Printf("WARN: unable to walk %v while building search index: %v", path, err)
+			return nil
+		}
+		if info.IsDir() || filepath.Ext(path) != modules.TurtleDexFileExtension {
+			return nil
+		}
+		var siaPath modules.TurtleDexPath
+		if err := siaPath.FromSysPath(path, root); err != nil {
+			return err
+		}
+		n, err := fs.OpenTurtleDexFile(siaPath)
+		if err != nil {
+			fs.staticLog.Printf("WARN: unable to open %v while building search index: %v", siaPath, err)
+			return nil
+		}
+		files = append(files, n.IndexedFile(siaPath))
+		return n.Close()
+	})
+	if err != nil {
+		return err
+	}
+	return fs.staticSearchIndex.managedUpdate(files...)
+}
+
+// managedIndexFile adds the file at siaPath to the search index. Since the
+// index is not essential to the filesystem, failures are only logged.
+func (fs *FileSystem) managedIndexFile(siaPath modules.TurtleDexPath) {
+	n, err := fs.OpenTurtleDexFile(siaPath)
+	if err != nil {
+		fs.staticLog.Printf("WARN: unable to open file %v to add it to the search index: %v", siaPath, err)
+		return
+	}
+	file := n.IndexedFile(siaPath)
+	if err := n.Close(); err != nil {
+		fs.staticLog.Printf("WARN: unable to close file %v: %v", siaPath, err)
+	}
+	if err := fs.staticSearchIndex.managedUpdate(file); err != nil {
+		fs.staticLog.Printf("WARN: unable to add file %v to the search index: %v", siaPath, err)
+	}
+}
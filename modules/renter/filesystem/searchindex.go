@@ -0,0 +1,413 @@
+package filesystem
+
+// The search index contains an entry for every siafile of the filesystem. It
+// is kept in memory and persisted to an append-only file. Every change to an
+// entry appends a new record for the entry to the file. Once the file contains
+// too many outdated records, it is compacted by writing the current entries to
+// a new file which replaces the old one.
+//
+// The filesystem updates the index whenever files are created, renamed or
+// deleted. Fields which change while a file exists, like its health, are
+// updated by the renter whenever it calculates them.
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/persist"
+	"github.com/turtledex/TurtleDexCore/types"
+	"github.com/turtledex/encoding"
+	"github.com/turtledex/errors"
+)
+
+const (
+	// searchIndexFile is the name of the file the search index is persisted
+	// to.
+	searchIndexFile = "searchindex"
+
+	// searchIndexTempFile is the name of the file the search index is written
+	// to while it is compacted.
+	searchIndexTempFile = searchIndexFile + "_temp"
+)
+
+var (
+	// searchIndexCompactionThreshold is the minimum number of outdated records
+	// in the persist file of the search index before it is compacted. The file
+	// is only compacted if it also contains more outdated records than
+	// current ones.
+	searchIndexCompactionThreshold = build.Select(build.Var{
+		Dev:      uint64(1000),
+		Standard: uint64(100000),
+		Testing:  uint64(10),
+	}).(uint64)
+
+	// searchIndexMetadataHeader is the header of the metadata for the persist
+	// file of the search index.
+	searchIndexMetadataHeader = types.NewSpecifier("SearchIndex\n")
+
+	// searchIndexMetadataVersion is the version of the persist file of the
+	// search index.
+	searchIndexMetadataVersion = types.NewSpecifier("v1.5.5\n")
+)
+
+type (
+	// searchIndex is the persisted index of the filesystem's siafiles.
+	searchIndex struct {
+		// files maps the siapaths of the indexed files to their entries.
+		files map[string]modules.IndexedFile
+
+		// numRecords is the number of records in the persist file.
+		numRecords uint64
+
+		aop              *persist.AppendOnlyPersist
+		staticPersistDir string
+		mu               sync.Mutex
+	}
+
+	// searchIndexRecord is the persisted version of an entry of the search
+	// index. A removed entry has only its siapath set.
+	searchIndexRecord struct {
+		TurtleDexPath string
+		Removed       bool
+		Filesize      uint64
+		CreateTime    int64
+		ModTime       int64
+		Health        uint64
+		Skylinks      []string
+	}
+)
+
+// newSearchIndex loads the search index from disk or creates a new one.
+func newSearchIndex(persistDir string) (*searchIndex, error) {
+	aop, reader, err := persist.NewAppendOnlyPersist(persistDir, searchIndexFile, searchIndexMetadataHeader, searchIndexMetadataVersion)
+	if err != nil {
+		return nil, errors.AddContext(err, fmt.Sprintf("unable to initialize the search index persistence at '%v'", filepath.Join(persistDir, searchIndexFile)))
+	}
+	si := &searchIndex{
+		files:            make(map[string]modules.IndexedFile),
+		aop:              aop,
+		staticPersistDir: persistDir,
+	}
+	si.numRecords, err = unmarshalSearchIndexRecords(reader, si.files)
+	if err != nil {
+		return nil, errors.Compose(errors.AddContext(err, "unable to unmarshal search index"), aop.Close())
+	}
+	if err := si.managedMaybeCompact(); err != nil {
+		return nil, errors.Compose(err, si.managedClose())
+	}
+	return si, nil
+}
+
+// newSearchIndexRecord creates the record for an entry of the search index.
+func newSearchIndexRecord(file modules.IndexedFile) searchIndexRecord {
+	return searchIndexRecord{
+		TurtleDexPath: file.TurtleDexPath.String(),
+		Filesize:      file.Filesize,
+		CreateTime:    timeToUnixNano(file.CreateTime),
+		ModTime:       timeToUnixNano(file.ModTime),
+		Health:        math.Float64bits(file.Health),
+		Skylinks:      file.Skylinks,
+	}
+}
+
+// indexedFile returns the entry of the search index for the record.
+func (r searchIndexRecord) indexedFile() (modules.IndexedFile, error) {
+	var siaPath modules.TurtleDexPath
+	if err := siaPath.LoadString(r.TurtleDexPath); err != nil {
+		return modules.IndexedFile{}, err
+	}
+	return modules.IndexedFile{
+		TurtleDexPath: siaPath,
+		Filesize:      r.Filesize,
+		CreateTime:    unixNanoToTime(r.CreateTime),
+		ModTime:       unixNanoToTime(r.ModTime),
+		Health:        math.Float64frombits(r.Health),
+		Skylinks:      r.Skylinks,
+	}, nil
+}
+
+// timeToUnixNano converts t to nanoseconds since the unix epoch. The zero time
+// is converted to 0.
+func timeToUnixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// unixNanoToTime is the inverse of timeToUnixNano.
+func unixNanoToTime(ns int64) time.Time {
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+// indexedFilesEqual returns whether the entries are equal.
+func indexedFilesEqual(a, b modules.IndexedFile) bool {
+	if !a.TurtleDexPath.Equals(b.TurtleDexPath) || a.Filesize != b.Filesize || !a.CreateTime.Equal(b.CreateTime) ||
+		!a.ModTime.Equal(b.ModTime) || a.Health != b.Health || len(a.Skylinks) != len(b.Skylinks) {
+		return false
+	}
+	for i := range a.Skylinks {
+		if a.Skylinks[i] != b.Skylinks[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// unmarshalSearchIndexRecords reads the records from the reader and applies
+// them to files. The number of read records is returned.
+func unmarshalSearchIndexRecords(reader io.Reader, files map[string]modules.IndexedFile) (uint64, error) {
+	var numRecords uint64
+	d := encoding.NewDecoder(reader, encoding.DefaultAllocLimit)
+	for {
+		var record searchIndexRecord
+		err := d.Decode(&record)
+		if errors.Contains(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		numRecords++
+		if record.Removed {
+			delete(files, record.TurtleDexPath)
+			continue
+		}
+		file, err := record.indexedFile()
+		if err != nil {
+			return 0, errors.AddContext(err, "invalid siapath in record")
+		}
+		files[record.TurtleDexPath] = file
+	}
+	return numRecords, nil
+}
+
+// managedClose closes the persist file of the search index.
+func (si *searchIndex) managedClose() error {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	return si.aop.Close()
+}
+
+// managedIsEmpty returns whether the index doesn't contain any files.
+func (si *searchIndex) managedIsEmpty() bool {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	return len(si.files) == 0
+}
+
+// managedMaybeCompact compacts the persist file if it contains too many
+// outdated records.
+func (si *searchIndex) managedMaybeCompact() error {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	return si.maybeCompact()
+}
+
+// managedRemove removes the files with the provided siapaths from the index.
+func (si *searchIndex) managedRemove(siaPaths ...modules.TurtleDexPath) error {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	var records []searchIndexRecord
+	for _, siaPath := range siaPaths {
+		if _, exists := si.files[siaPath.String()]; !exists {
+			continue
+		}
+		delete(si.files, siaPath.String())
+		records = append(records, searchIndexRecord{TurtleDexPath: siaPath.String(), Removed: true})
+	}
+	return si.writeRecords(records)
+}
+
+// managedRemoveDir removes all files within the dir from the index.
+func (si *searchIndex) managedRemoveDir(dir modules.TurtleDexPath) error {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	var records []searchIndexRecord
+	for sp, file := range si.files {
+		if !file.TurtleDexPath.IsWithin(dir) {
+			continue
+		}
+		delete(si.files, sp)
+		records = append(records, searchIndexRecord{TurtleDexPath: sp, Removed: true})
+	}
+	return si.writeRecords(records)
+}
+
+// managedRenameDir moves the entries of all files within oldDir to newDir.
+func (si *searchIndex) managedRenameDir(oldDir, newDir modules.TurtleDexPath) error {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	var records []searchIndexRecord
+	for sp, file := range si.files {
+		if !file.TurtleDexPath.IsWithin(oldDir) {
+			continue
+		}
+		newTurtleDexPath, err := file.TurtleDexPath.Rebase(oldDir, newDir)
+		if err != nil {
+			return errors.Compose(err, si.writeRecords(records))
+		}
+		delete(si.files, sp)
+		records = append(records, searchIndexRecord{TurtleDexPath: sp, Removed: true})
+		file.TurtleDexPath = newTurtleDexPath
+		si.files[newTurtleDexPath.String()] = file
+		records = append(records, newSearchIndexRecord(file))
+	}
+	return si.writeRecords(records)
+}
+
+// managedRenameFile moves the entry of the file at oldTurtleDexPath to
+// newTurtleDexPath. false is returned if the file isn't indexed.
+func (si *searchIndex) managedRenameFile(oldTurtleDexPath, newTurtleDexPath modules.TurtleDexPath) (bool, error) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	file, exists := si.files[oldTurtleDexPath.String()]
+	if !exists {
+		return false, nil
+	}
+	delete(si.files, oldTurtleDexPath.String())
+	file.TurtleDexPath = newTurtleDexPath
+	si.files[newTurtleDexPath.String()] = file
+	records := []searchIndexRecord{
+		{TurtleDexPath: oldTurtleDexPath.String(), Removed: true},
+		newSearchIndexRecord(file),
+	}
+	return true, si.writeRecords(records)
+}
+
+// managedSearch returns the files within dir that match the params sorted by
+// their siapaths.
+func (si *searchIndex) managedSearch(dir modules.TurtleDexPath, params modules.FileSearchParams) []modules.IndexedFile {
+	si.mu.Lock()
+	var files []modules.IndexedFile
+	for _, file := range si.files {
+		if !file.TurtleDexPath.IsWithin(dir) {
+			continue
+		}
+		relPath := file.TurtleDexPath.String()
+		if !dir.IsRoot() {
+			relPath = strings.TrimPrefix(relPath, dir.String()+"/")
+		}
+		if params.Matches(relPath, file) {
+			files = append(files, file)
+		}
+	}
+	si.mu.Unlock()
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].TurtleDexPath.String() < files[j].TurtleDexPath.String()
+	})
+	if params.Limit > 0 && uint64(len(files)) > params.Limit {
+		files = files[:params.Limit]
+	}
+	// Copy the skylinks to prevent the caller from modifying the index.
+	for i := range files {
+		files[i].Skylinks = append([]string(nil), files[i].Skylinks...)
+	}
+	return files
+}
+
+// managedUpdate adds the files to the index or updates their entries. Only
+// entries that changed are persisted.
+func (si *searchIndex) managedUpdate(files ...modules.IndexedFile) error {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	var records []searchIndexRecord
+	for _, file := range files {
+		sp := file.TurtleDexPath.String()
+		if existing, exists := si.files[sp]; exists && indexedFilesEqual(existing, file) {
+			continue
+		}
+		file.Skylinks = append([]string(nil), file.Skylinks...)
+		si.files[sp] = file
+		records = append(records, newSearchIndexRecord(file))
+	}
+	return si.writeRecords(records)
+}
+
+// maybeCompact compacts the persist file if it contains too many outdated
+// records.
+func (si *searchIndex) maybeCompact() error {
+	numFiles := uint64(len(si.files))
+	outdated := si.numRecords - numFiles
+	if outdated < searchIndexCompactionThreshold || outdated < numFiles {
+		return nil
+	}
+	return si.compact()
+}
+
+// compact writes the current entries to a new persist file which replaces the
+// existing one.
+func (si *searchIndex) compact() error {
+	// Remove leftovers of a previous compaction.
+	tempPath := filepath.Join(si.staticPersistDir, searchIndexTempFile)
+	if err := os.Remove(tempPath); err != nil && !os.IsNotExist(err) {
+		return errors.AddContext(err, "unable to remove temporary search index file")
+	}
+
+	// Write the entries to the temporary file.
+	aop, _, err := persist.NewAppendOnlyPersist(si.staticPersistDir, searchIndexTempFile, searchIndexMetadataHeader, searchIndexMetadataVersion)
+	if err != nil {
+		return errors.AddContext(err, "unable to create temporary search index file")
+	}
+	var buf bytes.Buffer
+	e := encoding.NewEncoder(&buf)
+	for _, file := range si.files {
+		if err := e.Encode(newSearchIndexRecord(file)); err != nil {
+			return errors.Compose(errors.AddContext(err, "unable to encode search index"), aop.Close())
+		}
+	}
+	_, err = aop.Write(buf.Bytes())
+	err = errors.Compose(err, aop.Close())
+	if err != nil {
+		return errors.AddContext(err, "unable to write temporary search index file")
+	}
+
+	// Replace the persist file.
+	if err := si.aop.Close(); err != nil {
+		return errors.AddContext(err, "unable to close search index file")
+	}
+	err = os.Rename(tempPath, filepath.Join(si.staticPersistDir, searchIndexFile))
+	if err != nil {
+		return errors.AddContext(err, "unable to replace search index file")
+	}
+	si.aop, _, err = persist.NewAppendOnlyPersist(si.staticPersistDir, searchIndexFile, searchIndexMetadataHeader, searchIndexMetadataVersion)
+	if err != nil {
+		return errors.AddContext(err, "unable to reopen search index file")
+	}
+	si.numRecords = uint64(len(si.files))
+	return nil
+}
+
+// writeRecords appends the records to the persist file.
+func (si *searchIndex) writeRecords(records []searchIndexRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	e := encoding.NewEncoder(&buf)
+	for _, record := range records {
+		if err := e.Encode(record); err != nil {
+			return errors.AddContext(err, "unable to encode search index record")
+		}
+	}
+	_, err := si.aop.Write(buf.Bytes())
+	if err != nil {
+		return errors.AddContext(err, fmt.Sprintf("unable to update search index persistence at '%v'", si.aop.FilePath()))
+	}
+	si.numRecords += uint64(len(records))
+	return si.maybeCompact()
+}
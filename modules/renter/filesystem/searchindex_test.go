@@ -0,0 +1,334 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/persist"
+	"github.com/turtledex/errors"
+)
+
+// newTestSearchIndexFile creates an IndexedFile for testing.
+func newTestSearchIndexFile(t *testing.T, path string, size uint64, health float64, skylinks ...string) modules.IndexedFile {
+	siaPath, err := modules.NewTurtleDexPath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	return modules.IndexedFile{
+		TurtleDexPath: siaPath,
+		Filesize:      size,
+		CreateTime:    now,
+		ModTime:       now,
+		Health:        health,
+		Skylinks:      skylinks,
+	}
+}
+
+// searchIndexPaths returns the siapaths of the files of a search.
+func searchIndexPaths(files []modules.IndexedFile) []string {
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		paths = append(paths, file.TurtleDexPath.String())
+	}
+	return paths
+}
+
+// checkSearchIndexPaths checks that the search for params within dir returns
+// the expected paths.
+func checkSearchIndexPaths(t *testing.T, si *searchIndex, dir modules.TurtleDexPath, params modules.FileSearchParams, expected ...string) {
+	t.Helper()
+	paths := searchIndexPaths(si.managedSearch(dir, params))
+	if len(paths) != len(expected) {
+		t.Fatalf("expected %v but got %v", expected, paths)
+	}
+	for i := range paths {
+		if paths[i] != expected[i] {
+			t.Fatalf("expected %v but got %v", expected, paths)
+		}
+	}
+}
+
+// TestSearchIndex tests the basic operations of the searchIndex and its
+// persistence.
+func TestSearchIndex(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := testDir(t.Name())
+	if err := os.MkdirAll(dir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	si, err := newSearchIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !si.managedIsEmpty() {
+		t.Fatal("new index should be empty")
+	}
+
+	// Add some files.
+	err = si.managedUpdate(
+		newTestSearchIndexFile(t, "a/photo.jpg", 100, 0, "skylink"),
+		newTestSearchIndexFile(t, "a/b/doc.txt", 1000, 0.5),
+		newTestSearchIndexFile(t, "c/PHOTO2.jpg", 10000, 1.5),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := modules.RootTurtleDexPath()
+	checkSearchIndexPaths(t, si, root, modules.FileSearchParams{}, "a/b/doc.txt", "a/photo.jpg", "c/PHOTO2.jpg")
+
+	// Rename a file and a dir.
+	renamed, err := si.managedRenameFile(newTestSearchIndexFile(t, "a/photo.jpg", 0, 0).TurtleDexPath, newTestSearchIndexFile(t, "d/photo.jpg", 0, 0).TurtleDexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !renamed {
+		t.Fatal("file should have been renamed")
+	}
+	oldDir, err := modules.NewTurtleDexPath("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newDir, err := modules.NewTurtleDexPath("e")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := si.managedRenameDir(oldDir, newDir); err != nil {
+		t.Fatal(err)
+	}
+	checkSearchIndexPaths(t, si, root, modules.FileSearchParams{}, "c/PHOTO2.jpg", "d/photo.jpg", "e/b/doc.txt")
+
+	// Renaming an unknown file shouldn't do anything.
+	renamed, err = si.managedRenameFile(oldDir, newDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamed {
+		t.Fatal("unknown file shouldn't have been renamed")
+	}
+
+	// Remove a file.
+	if err := si.managedRemove(newTestSearchIndexFile(t, "c/PHOTO2.jpg", 0, 0).TurtleDexPath); err != nil {
+		t.Fatal(err)
+	}
+	checkSearchIndexPaths(t, si, root, modules.FileSearchParams{}, "d/photo.jpg", "e/b/doc.txt")
+
+	// Reload the index. The files and their fields should be the same.
+	before := si.managedSearch(root, modules.FileSearchParams{})
+	if err := si.managedClose(); err != nil {
+		t.Fatal(err)
+	}
+	si, err = newSearchIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := si.managedSearch(root, modules.FileSearchParams{})
+	if len(before) != len(after) {
+		t.Fatalf("expected %v files but got %v", len(before), len(after))
+	}
+	for i := range before {
+		if !indexedFilesEqual(before[i], after[i]) {
+			t.Fatalf("file changed after reload: %v != %v", before[i], after[i])
+		}
+	}
+
+	// Remove a dir.
+	if err := si.managedRemoveDir(newDir); err != nil {
+		t.Fatal(err)
+	}
+	checkSearchIndexPaths(t, si, root, modules.FileSearchParams{}, "d/photo.jpg")
+	if err := si.managedClose(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestSearchIndexCompaction tests that the searchIndex compacts its
+// persistence once enough records were written.
+func TestSearchIndexCompaction(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := testDir(t.Name())
+	if err := os.MkdirAll(dir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	si, err := newSearchIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Update the same file more often than the compaction threshold.
+	for i := uint64(0); i < 2*searchIndexCompactionThreshold; i++ {
+		if err := si.managedUpdate(newTestSearchIndexFile(t, "file", i, 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	si.mu.Lock()
+	numRecords := si.numRecords
+	si.mu.Unlock()
+	if numRecords > searchIndexCompactionThreshold {
+		t.Fatalf("index wasn't compacted, %v records", numRecords)
+	}
+	if _, err := os.Stat(filepath.Join(dir, searchIndexTempFile)); !os.IsNotExist(err) {
+		t.Fatal("temp file should have been removed", err)
+	}
+
+	// The latest update should survive a reload.
+	if err := si.managedClose(); err != nil {
+		t.Fatal(err)
+	}
+	si, err = newSearchIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := si.managedSearch(modules.RootTurtleDexPath(), modules.FileSearchParams{})
+	if len(files) != 1 || files[0].Filesize != 2*searchIndexCompactionThreshold-1 {
+		t.Fatal("unexpected files after reload", files)
+	}
+	if err := si.managedClose(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestSearchIndexSearch tests the filters of a search within the searchIndex.
+func TestSearchIndexSearch(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := testDir(t.Name())
+	if err := os.MkdirAll(dir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	si, err := newSearchIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := si.managedClose(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	err = si.managedUpdate(
+		newTestSearchIndexFile(t, "a/photo.jpg", 100, 0, "skylink"),
+		newTestSearchIndexFile(t, "a/b/doc.txt", 1000, 0.5),
+		newTestSearchIndexFile(t, "c/PHOTO2.jpg", 10000, 1.5),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := modules.RootTurtleDexPath()
+	aDir, err := modules.NewTurtleDexPath("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	low, high := 0.25, 1.0
+	hasSkylink, noSkylink := true, false
+
+	checkSearchIndexPaths(t, si, root, modules.FileSearchParams{Glob: "*.jpg"}, "a/photo.jpg", "c/PHOTO2.jpg")
+	checkSearchIndexPaths(t, si, root, modules.FileSearchParams{Glob: "a/*"}, "a/photo.jpg")
+	checkSearchIndexPaths(t, si, aDir, modules.FileSearchParams{Glob: "b/*"}, "a/b/doc.txt")
+	checkSearchIndexPaths(t, si, aDir, modules.FileSearchParams{}, "a/b/doc.txt", "a/photo.jpg")
+	checkSearchIndexPaths(t, si, root, modules.FileSearchParams{Prefix: "a/b"}, "a/b/doc.txt")
+	checkSearchIndexPaths(t, si, root, modules.FileSearchParams{Substring: "photo"}, "a/photo.jpg", "c/PHOTO2.jpg")
+	checkSearchIndexPaths(t, si, root, modules.FileSearchParams{MinSize: 1000}, "a/b/doc.txt", "c/PHOTO2.jpg")
+	checkSearchIndexPaths(t, si, root, modules.FileSearchParams{MaxSize: 1000}, "a/b/doc.txt", "a/photo.jpg")
+	checkSearchIndexPaths(t, si, root, modules.FileSearchParams{MinHealth: &low}, "a/b/doc.txt", "c/PHOTO2.jpg")
+	checkSearchIndexPaths(t, si, root, modules.FileSearchParams{MaxHealth: &high}, "a/b/doc.txt", "a/photo.jpg")
+	checkSearchIndexPaths(t, si, root, modules.FileSearchParams{HasSkylink: &hasSkylink}, "a/photo.jpg")
+	checkSearchIndexPaths(t, si, root, modules.FileSearchParams{HasSkylink: &noSkylink}, "a/b/doc.txt", "c/PHOTO2.jpg")
+	checkSearchIndexPaths(t, si, root, modules.FileSearchParams{Limit: 1}, "a/b/doc.txt")
+	checkSearchIndexPaths(t, si, root, modules.FileSearchParams{Substring: "photo", MinSize: 1000}, "c/PHOTO2.jpg")
+}
+
+// TestFileSystemSearch tests that the FileSystem keeps its search index up to
+// date when files are created, renamed and deleted.
+func TestFileSystemSearch(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	root := filepath.Join(testDir(t.Name()), "fs-root")
+	fs := newTestFileSystem(root)
+	sp1 := newTestSearchIndexFile(t, "dir/file1", 0, 0).TurtleDexPath
+	sp2 := newTestSearchIndexFile(t, "dir/sub/file2", 0, 0).TurtleDexPath
+	fs.addTestTurtleDexFile(sp1)
+	fs.addTestTurtleDexFile(sp2)
+
+	search := func(dir modules.TurtleDexPath, expected ...string) {
+		t.Helper()
+		files, err := fs.Search(dir, modules.FileSearchParams{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths := searchIndexPaths(files)
+		if len(paths) != len(expected) {
+			t.Fatalf("expected %v but got %v", expected, paths)
+		}
+		for i := range paths {
+			if paths[i] != expected[i] {
+				t.Fatalf("expected %v but got %v", expected, paths)
+			}
+		}
+	}
+	search(modules.RootTurtleDexPath(), "dir/file1", "dir/sub/file2")
+
+	// Rename a file.
+	sp3 := newTestSearchIndexFile(t, "dir/file3", 0, 0).TurtleDexPath
+	if err := fs.RenameFile(sp1, sp3); err != nil {
+		t.Fatal(err)
+	}
+	search(modules.RootTurtleDexPath(), "dir/file3", "dir/sub/file2")
+
+	// Rename a dir.
+	oldDir := newTestSearchIndexFile(t, "dir/sub", 0, 0).TurtleDexPath
+	newDir := newTestSearchIndexFile(t, "dir/sub2", 0, 0).TurtleDexPath
+	if err := fs.RenameDir(oldDir, newDir); err != nil {
+		t.Fatal(err)
+	}
+	search(modules.RootTurtleDexPath(), "dir/file3", "dir/sub2/file2")
+	search(newDir, "dir/sub2/file2")
+
+	// Searching a dir that doesn't exist should fail.
+	if _, err := fs.Search(oldDir, modules.FileSearchParams{}); !errors.Contains(err, ErrNotExist) {
+		t.Fatal("expected ErrNotExist but got", err)
+	}
+	// Searching with an invalid pattern should fail.
+	if _, err := fs.Search(modules.RootTurtleDexPath(), modules.FileSearchParams{Glob: "["}); err == nil {
+		t.Fatal("search with invalid pattern should fail")
+	}
+
+	// Delete a file and a dir.
+	if err := fs.DeleteFile(sp3); err != nil {
+		t.Fatal(err)
+	}
+	search(modules.RootTurtleDexPath(), "dir/sub2/file2")
+	if err := fs.DeleteDir(newDir); err != nil {
+		t.Fatal(err)
+	}
+	search(modules.RootTurtleDexPath())
+
+	// Add a file and rebuild the index from disk after removing it.
+	fs.addTestTurtleDexFile(sp1)
+	if err := fs.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(root+"-persist", searchIndexFile)); err != nil {
+		t.Fatal(err)
+	}
+	fs = newTestFileSystem(root)
+	search(modules.RootTurtleDexPath(), "dir/file1")
+	if err := fs.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -318,7 +318,12 @@ func (sd *TurtleDexDir) UpdateBubbledMetadata(metadata Metadata) error {
 	sd.mu.Lock()
 	defer sd.mu.Unlock()
 	metadata.Mode = sd.metadata.Mode
+	metadata.Quota = sd.metadata.Quota
+	metadata.RedundancyPolicy = sd.metadata.RedundancyPolicy
+	metadata.RedundantQuota = sd.metadata.RedundantQuota
+	metadata.UserMetadata = sd.metadata.UserMetadata
 	metadata.Version = sd.metadata.Version
+	metadata.VersionRetention = sd.metadata.VersionRetention
 	return sd.updateMetadata(metadata)
 }
 
@@ -355,6 +360,7 @@ func (sd *TurtleDexDir) updateMetadata(metadata Metadata) error {
 	sd.metadata.AggregateNumFiles = metadata.AggregateNumFiles
 	sd.metadata.AggregateNumStuckChunks = metadata.AggregateNumStuckChunks
 	sd.metadata.AggregateNumSubDirs = metadata.AggregateNumSubDirs
+	sd.metadata.AggregateRedundantSize = metadata.AggregateRedundantSize
 	sd.metadata.AggregateRemoteHealth = metadata.AggregateRemoteHealth
 	sd.metadata.AggregateRepairSize = metadata.AggregateRepairSize
 	sd.metadata.AggregateSize = metadata.AggregateSize
@@ -364,6 +370,9 @@ func (sd *TurtleDexDir) updateMetadata(metadata Metadata) error {
 	sd.metadata.AggregateSkynetFiles = metadata.AggregateSkynetFiles
 	sd.metadata.AggregateSkynetSize = metadata.AggregateSkynetSize
 
+	sd.metadata.AggregateNumPendingMigrations = metadata.AggregateNumPendingMigrations
+	sd.metadata.AggregatePendingMigrationSize = metadata.AggregatePendingMigrationSize
+
 	sd.metadata.Health = metadata.Health
 	sd.metadata.LastHealthCheckTime = metadata.LastHealthCheckTime
 	sd.metadata.MinRedundancy = metadata.MinRedundancy
@@ -372,6 +381,7 @@ func (sd *TurtleDexDir) updateMetadata(metadata Metadata) error {
 	sd.metadata.NumFiles = metadata.NumFiles
 	sd.metadata.NumStuckChunks = metadata.NumStuckChunks
 	sd.metadata.NumSubDirs = metadata.NumSubDirs
+	sd.metadata.RedundantSize = metadata.RedundantSize
 	sd.metadata.RemoteHealth = metadata.RemoteHealth
 	sd.metadata.RepairSize = metadata.RepairSize
 	sd.metadata.Size = metadata.Size
@@ -381,6 +391,15 @@ func (sd *TurtleDexDir) updateMetadata(metadata Metadata) error {
 	sd.metadata.SkynetFiles = metadata.SkynetFiles
 	sd.metadata.SkynetSize = metadata.SkynetSize
 
+	sd.metadata.NumPendingMigrations = metadata.NumPendingMigrations
+	sd.metadata.PendingMigrationSize = metadata.PendingMigrationSize
+
+	sd.metadata.VersionRetention = metadata.VersionRetention
+	sd.metadata.Quota = metadata.Quota
+	sd.metadata.RedundantQuota = metadata.RedundantQuota
+	sd.metadata.RedundancyPolicy = metadata.RedundancyPolicy
+	sd.metadata.UserMetadata = metadata.UserMetadata
+
 	sd.metadata.Version = metadata.Version
 
 	// Testing check to ensure new fields aren't missed
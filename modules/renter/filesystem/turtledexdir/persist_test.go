@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/turtledex/fastrand"
@@ -65,6 +66,14 @@ func equalMetadatas(md, md2 Metadata) error {
 		return fmt.Errorf("AggregateSkynetSize not equal, %v and %v", md.AggregateSkynetSize, md2.AggregateSkynetSize)
 	}
 
+	// Aggregate Redundancy Migration Fields
+	if md.AggregateNumPendingMigrations != md2.AggregateNumPendingMigrations {
+		return fmt.Errorf("AggregateNumPendingMigrations not equal, %v and %v", md.AggregateNumPendingMigrations, md2.AggregateNumPendingMigrations)
+	}
+	if md.AggregatePendingMigrationSize != md2.AggregatePendingMigrationSize {
+		return fmt.Errorf("AggregatePendingMigrationSize not equal, %v and %v", md.AggregatePendingMigrationSize, md2.AggregatePendingMigrationSize)
+	}
+
 	// Check TurtleDexDir Fields
 	if md.Health != md2.Health {
 		return fmt.Errorf("Healths not equal, %v and %v", md.Health, md2.Health)
@@ -111,6 +120,29 @@ func equalMetadatas(md, md2 Metadata) error {
 		return fmt.Errorf("SkynetSize not equal, %v and %v", md.SkynetSize, md2.SkynetSize)
 	}
 
+	// Redundancy Migration Fields
+	if md.NumPendingMigrations != md2.NumPendingMigrations {
+		return fmt.Errorf("NumPendingMigrations not equal, %v and %v", md.NumPendingMigrations, md2.NumPendingMigrations)
+	}
+	if md.PendingMigrationSize != md2.PendingMigrationSize {
+		return fmt.Errorf("PendingMigrationSize not equal, %v and %v", md.PendingMigrationSize, md2.PendingMigrationSize)
+	}
+
+	// Redundancy Policy
+	if !reflect.DeepEqual(md.RedundancyPolicy, md2.RedundancyPolicy) {
+		return fmt.Errorf("RedundancyPolicy not equal, %v and %v", md.RedundancyPolicy, md2.RedundancyPolicy)
+	}
+
+	// User Metadata
+	if len(md.UserMetadata) != len(md2.UserMetadata) {
+		return fmt.Errorf("UserMetadata not equal, %v and %v", md.UserMetadata, md2.UserMetadata)
+	}
+	for k, v := range md.UserMetadata {
+		if v2, exists := md2.UserMetadata[k]; !exists || v != v2 {
+			return fmt.Errorf("UserMetadata not equal, %v and %v", md.UserMetadata, md2.UserMetadata)
+		}
+	}
+
 	return nil
 }
 
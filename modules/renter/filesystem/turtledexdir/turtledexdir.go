@@ -53,6 +53,9 @@ type (
 		//
 		// NumSubDirs is the number of sub-ttdxdirs in a ttdxdir
 		//
+		// RedundantSize is the total amount of data stored on hosts for the
+		// siafiles of the ttdxdir, including redundancy
+		//
 		// Size is the total amount of data stored in the siafiles of the ttdxdir
 		//
 		// StuckHealth is the health of the most in need siafile in the ttdxdir,
@@ -68,6 +71,7 @@ type (
 		AggregateNumFiles            uint64    `json:"aggregatenumfiles"`
 		AggregateNumStuckChunks      uint64    `json:"aggregatenumstuckchunks"`
 		AggregateNumSubDirs          uint64    `json:"aggregatenumsubdirs"`
+		AggregateRedundantSize       uint64    `json:"aggregateredundantsize"`
 		AggregateRemoteHealth        float64   `json:"aggregateremotehealth"`
 		AggregateRepairSize          uint64    `json:"aggregaterepairsize"`
 		AggregateSize                uint64    `json:"aggregatesize"`
@@ -78,6 +82,10 @@ type (
 		AggregateSkynetFiles uint64 `json:"aggregateskynetfiles"`
 		AggregateSkynetSize  uint64 `json:"aggregateskynetsize"`
 
+		// Aggregate Redundancy Migration Stats
+		AggregateNumPendingMigrations uint64 `json:"aggregatenumpendingmigrations"`
+		AggregatePendingMigrationSize uint64 `json:"aggregatependingmigrationsize"`
+
 		// The following fields are information specific to the ttdxdir that is not
 		// an aggregate of the entire sub directory tree
 		Health              float64     `json:"health"`
@@ -88,6 +96,7 @@ type (
 		NumFiles            uint64      `json:"numfiles"`
 		NumStuckChunks      uint64      `json:"numstuckchunks"`
 		NumSubDirs          uint64      `json:"numsubdirs"`
+		RedundantSize       uint64      `json:"redundantsize"`
 		RemoteHealth        float64     `json:"remotehealth"`
 		RepairSize          uint64      `json:"repairsize"`
 		Size                uint64      `json:"size"`
@@ -98,6 +107,39 @@ type (
 		SkynetFiles uint64 `json:"skynetfiles"`
 		SkynetSize  uint64 `json:"skynetsize"`
 
+		// Redundancy Migration Stats
+		//
+		// NumPendingMigrations is the number of siafiles in the ttdxdir that
+		// don't use the erasure coding settings of their redundancy policy
+		// and PendingMigrationSize is their total size.
+		NumPendingMigrations uint64 `json:"numpendingmigrations"`
+		PendingMigrationSize uint64 `json:"pendingmigrationsize"`
+
+		// The following fields are settings of the ttdxdir which are set by the
+		// user and are not touched by the bubble.
+		//
+		// VersionRetention is the number of prior versions that are kept for
+		// files within the ttdxdir when they are overwritten. A value of 0
+		// means that the setting is inherited from the parent ttdxdir and
+		// modules.VersionRetentionDisabled disables versioning.
+		VersionRetention int64 `json:"versionretention"`
+		//
+		// Quota is the maximum number of bytes that can be stored in the
+		// ttdxdir and its sub ttdxdirs before redundancy. RedundantQuota is the
+		// same limit after redundancy. A value of 0 means no limit.
+		Quota          uint64 `json:"quota"`
+		RedundantQuota uint64 `json:"redundantquota"`
+		//
+		// RedundancyPolicy describes the erasure coding settings of the
+		// siafiles within the ttdxdir and its sub ttdxdirs. A policy without
+		// tiers means that the policy is inherited from the parent ttdxdir.
+		RedundancyPolicy modules.RedundancyPolicy `json:"redundancypolicy"`
+		//
+		// UserMetadata is arbitrary key/value metadata attached to the ttdxdir
+		// by the user. Dirs created before it was introduced don't have the
+		// field and decode to a nil map.
+		UserMetadata map[string]string `json:"usermetadata,omitempty"`
+
 		// Version is the used version of the header file.
 		Version string `json:"version"`
 	}
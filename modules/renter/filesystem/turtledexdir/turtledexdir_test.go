@@ -1,6 +1,7 @@
 package ttdxdir
 
 import (
+	"encoding/hex"
 	"os"
 	"path/filepath"
 	"testing"
@@ -70,6 +71,9 @@ func randomMetadata() Metadata {
 		AggregateSkynetFiles: fastrand.Uint64n(100),
 		AggregateSkynetSize:  fastrand.Uint64n(100),
 
+		AggregateNumPendingMigrations: fastrand.Uint64n(100),
+		AggregatePendingMigrationSize: fastrand.Uint64n(100),
+
 		Health:              float64(fastrand.Intn(100)),
 		LastHealthCheckTime: time.Now(),
 		MinRedundancy:       float64(fastrand.Intn(100)),
@@ -85,6 +89,16 @@ func randomMetadata() Metadata {
 
 		SkynetFiles: fastrand.Uint64n(100),
 		SkynetSize:  fastrand.Uint64n(100),
+
+		NumPendingMigrations: fastrand.Uint64n(100),
+		PendingMigrationSize: fastrand.Uint64n(100),
+
+		RedundancyPolicy: modules.RedundancyPolicy{
+			Tiers: []modules.RedundancyTier{{DataPieces: fastrand.Intn(10) + 1, ParityPieces: fastrand.Intn(10) + 1}},
+		},
+		UserMetadata: map[string]string{
+			"key": hex.EncodeToString(fastrand.Bytes(8)),
+		},
 	}
 	return md
 }
@@ -315,3 +329,61 @@ func TestTurtleDexDirDelete(t *testing.T) {
 	}
 	siaDir.mu.Unlock()
 }
+
+// TestUserMetadataBubble verifies that the user metadata of a TurtleDexDir
+// survives updates to the bubbled metadata and is persisted.
+func TestUserMetadataBubble(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create new siaDir
+	rootDir, err := newRootDir(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	siaPath, err := modules.NewTurtleDexPath("TestDir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	siaDirSysPath := siaPath.TurtleDexDirSysPath(rootDir)
+	wal, _ := newTestWAL()
+	siaDir, err := New(siaDirSysPath, rootDir, modules.DefaultDirPerm, wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A new dir shouldn't have any user metadata.
+	if len(siaDir.Metadata().UserMetadata) != 0 {
+		t.Fatal("new dir shouldn't have user metadata", siaDir.Metadata().UserMetadata)
+	}
+
+	// Set the user metadata.
+	md := siaDir.Metadata()
+	md.UserMetadata = map[string]string{"project": "x"}
+	err = siaDir.UpdateMetadata(md)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Bubbling metadata without user metadata shouldn't remove it.
+	bubbled := randomMetadata()
+	bubbled.UserMetadata = nil
+	err = siaDir.UpdateBubbledMetadata(bubbled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if siaDir.Metadata().UserMetadata["project"] != "x" {
+		t.Fatal("user metadata was lost", siaDir.Metadata().UserMetadata)
+	}
+
+	// The user metadata should be persisted.
+	siaDir, err = LoadTurtleDexDir(siaDirSysPath, modules.ProdDependencies, wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if siaDir.Metadata().UserMetadata["project"] != "x" {
+		t.Fatal("user metadata wasn't persisted", siaDir.Metadata().UserMetadata)
+	}
+}
@@ -168,10 +168,19 @@ type (
 		// skyfiles, those skyfiles will be listed here. It should be noted that
 		// a single siafile can be responsible for tracking many skyfiles.
 		Skylinks []string `json:"skylinks"`
+
+		// UserMetadata is arbitrary key/value metadata attached to the file by
+		// the user. Files created before it was introduced don't have the
+		// field and decode to a nil map. The map is never modified in place
+		// but replaced on every update, which allows for sharing it with
+		// shallow copies of the metadata.
+		UserMetadata map[string]string `json:"usermetadata,omitempty"`
 	}
 
 	// BubbledMetadata is the metadata of a siafile that gets bubbled
 	BubbledMetadata struct {
+		AccessTime          time.Time
+		ErasureCode         modules.ErasureCoder
 		Health              float64
 		LastHealthCheckTime time.Time
 		ModTime             time.Time
@@ -179,6 +188,7 @@ type (
 		NumStuckChunks      uint64
 		OnDisk              bool
 		Redundancy          float64
+		RedundantSize       uint64
 		RepairBytes         uint64
 		Size                uint64
 		StuckBytes          uint64
@@ -314,6 +324,13 @@ func (sf *TurtleDexFile) Rename(newTurtleDexFilePath string) error {
 	return sf.rename(newTurtleDexFilePath)
 }
 
+// UserMetadata returns a copy of the user metadata of the TurtleDexFile.
+func (sf *TurtleDexFile) UserMetadata() map[string]string {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return modules.UpdateUserMetadata(sf.staticMetadata.UserMetadata, nil, nil)
+}
+
 // backup creates a deep-copy of a Metadata.
 func (md Metadata) backup() (b Metadata) {
 	// Copy the static fields first. They are shallow copies since they are not
@@ -374,6 +391,15 @@ func (md Metadata) backup() (b Metadata) {
 		b.Skylinks = make([]string, len(md.Skylinks), cap(md.Skylinks))
 		copy(b.Skylinks, md.Skylinks)
 	}
+	// Special handling for the map for the same reason.
+	if md.UserMetadata == nil {
+		b.UserMetadata = nil
+	} else {
+		b.UserMetadata = make(map[string]string, len(md.UserMetadata))
+		for k, v := range md.UserMetadata {
+			b.UserMetadata[k] = v
+		}
+	}
 	// If the backup was successful it should match the original.
 	if build.Release == "testing" && !md.equals(b) {
 		fmt.Println("md:\n", md)
@@ -413,6 +439,7 @@ func (md *Metadata) restore(b Metadata) {
 	md.ChunkOffset = b.ChunkOffset
 	md.PubKeyTableOffset = b.PubKeyTableOffset
 	md.Skylinks = b.Skylinks
+	md.UserMetadata = b.UserMetadata
 	// If the backup was successful it should match the backup.
 	if build.Release == "testing" && !md.equals(b) {
 		fmt.Println("md:\n", md)
@@ -520,6 +547,34 @@ func (sf *TurtleDexFile) SetMode(mode os.FileMode) (err error) {
 	return sf.createAndApplyTransaction(updates...)
 }
 
+// SetUserMetadata sets the keys of the TurtleDexFile's user metadata to the
+// values in set and removes the keys in remove. The update is rejected if the
+// resulting metadata exceeds the user metadata limits.
+func (sf *TurtleDexFile) SetUserMetadata(set map[string]string, remove []string) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+	userMetadata := modules.UpdateUserMetadata(sf.staticMetadata.UserMetadata, set, remove)
+	if err := modules.ValidateUserMetadata(userMetadata); err != nil {
+		return err
+	}
+	sf.staticMetadata.UserMetadata = userMetadata
+	sf.staticMetadata.ChangeTime = time.Now()
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
 // SetLastHealthCheckTime sets the LastHealthCheckTime in memory to the current
 // time but does not update and write to disk.
 //
@@ -567,7 +622,14 @@ func (sf *TurtleDexFile) UpdateUniqueID() {
 }
 
 // UpdateAccessTime updates the AccessTime timestamp to the current time.
-func (sf *TurtleDexFile) UpdateAccessTime() (err error) {
+func (sf *TurtleDexFile) UpdateAccessTime() error {
+	return sf.SetAccessTime(time.Now())
+}
+
+// SetAccessTime sets the AccessTime timestamp of the file. This is used to
+// carry the AccessTime over when a file's data is replaced without it being
+// accessed by the user.
+func (sf *TurtleDexFile) SetAccessTime(accessTime time.Time) (err error) {
 	sf.mu.Lock()
 	defer sf.mu.Unlock()
 	// backup the changed metadata before changing it. Revert the change on
@@ -577,7 +639,7 @@ func (sf *TurtleDexFile) UpdateAccessTime() (err error) {
 			sf.staticMetadata.restore(backup)
 		}
 	}(sf.staticMetadata.backup())
-	sf.staticMetadata.AccessTime = time.Now()
+	sf.staticMetadata.AccessTime = accessTime
 
 	// Save changes to metadata to disk.
 	updates, err := sf.saveMetadataUpdates()
@@ -1,6 +1,7 @@
 package siafile
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -92,6 +93,11 @@ func TestBackupRestoreMetadata(t *testing.T) {
 	} else {
 		sf.staticMetadata.PartialChunks = nil
 	}
+	if fastrand.Intn(2) == 0 {
+		sf.staticMetadata.UserMetadata = map[string]string{}
+	} else {
+		sf.staticMetadata.UserMetadata = nil
+	}
 
 	// Clone the metadata before modifying it.
 	mdBefore := sf.staticMetadata.backup()
@@ -146,6 +152,10 @@ func TestBackupRestoreMetadata(t *testing.T) {
 		if fastrand.Intn(2) == 0 { // 50% chance to be not nil
 			sf.staticMetadata.Skylinks = make([]string, fastrand.Intn(10))
 		}
+		sf.staticMetadata.UserMetadata = nil
+		if fastrand.Intn(2) == 0 { // 50% chance to be not nil
+			sf.staticMetadata.UserMetadata = map[string]string{"key": "value"}
+		}
 
 		// Error occurred after changing the fields.
 		return errors.New("")
@@ -155,3 +165,105 @@ func TestBackupRestoreMetadata(t *testing.T) {
 		t.Fatalf("metadata wasn't restored successfully %v %v", mdBefore, sf.staticMetadata)
 	}
 }
+
+// TestUserMetadata tests setting, removing and persisting the user metadata of
+// a TurtleDexFile.
+func TestUserMetadata(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	sf := newBlankTestFile()
+
+	// A new file shouldn't have any user metadata.
+	if md := sf.UserMetadata(); len(md) != 0 {
+		t.Fatal("new file shouldn't have user metadata", md)
+	}
+
+	// Set some metadata.
+	err := sf.SetUserMetadata(map[string]string{"project": "x", "owner": "bob"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Update a key and remove another one.
+	err = sf.SetUserMetadata(map[string]string{"project": "y"}, []string{"owner", "doesntexist"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"project": "y"}
+	if md := sf.UserMetadata(); !reflect.DeepEqual(md, expected) {
+		t.Fatal("wrong user metadata", md, expected)
+	}
+
+	// Modifying the returned map shouldn't change the file.
+	sf.UserMetadata()["project"] = "z"
+	if md := sf.UserMetadata(); !reflect.DeepEqual(md, expected) {
+		t.Fatal("user metadata was modified", md, expected)
+	}
+
+	// Invalid metadata should be rejected without changing the file.
+	err = sf.SetUserMetadata(map[string]string{"": "empty"}, nil)
+	if !errors.Contains(err, modules.ErrEmptyUserMetadataKey) {
+		t.Fatal("expected ErrEmptyUserMetadataKey but got", err)
+	}
+	if md := sf.UserMetadata(); !reflect.DeepEqual(md, expected) {
+		t.Fatal("user metadata was modified", md, expected)
+	}
+
+	// The metadata should be persisted.
+	sf2, err := LoadTurtleDexFile(sf.siaFilePath, sf.wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md := sf2.UserMetadata(); !reflect.DeepEqual(md, expected) {
+		t.Fatal("wrong user metadata after reload", md, expected)
+	}
+
+	// Metadata of files created before user metadata existed doesn't contain
+	// the field at all. It should decode to an empty set.
+	var md Metadata
+	if err := json.Unmarshal([]byte(`{"uniqueid":"foo"}`), &md); err != nil {
+		t.Fatal(err)
+	}
+	if md.UserMetadata != nil {
+		t.Fatal("user metadata should be nil", md.UserMetadata)
+	}
+}
+
+// TestSetAccessTime tests that the AccessTime of a file can be set and that it
+// is persisted.
+func TestSetAccessTime(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	sf := newBlankTestFile()
+
+	// Set the AccessTime to a point in the past.
+	accessTime := time.Now().Add(-time.Hour).Round(time.Second)
+	if err := sf.SetAccessTime(accessTime); err != nil {
+		t.Fatal(err)
+	}
+	if !sf.AccessTime().Equal(accessTime) {
+		t.Fatal("wrong access time", sf.AccessTime(), accessTime)
+	}
+
+	// The AccessTime should be persisted.
+	sf2, err := LoadTurtleDexFile(sf.siaFilePath, sf.wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sf2.AccessTime().Equal(accessTime) {
+		t.Fatal("wrong access time after reload", sf2.AccessTime(), accessTime)
+	}
+
+	// Updating the AccessTime should set it to the current time.
+	if err := sf.UpdateAccessTime(); err != nil {
+		t.Fatal(err)
+	}
+	if !sf.AccessTime().After(accessTime) {
+		t.Fatal("access time wasn't updated", sf.AccessTime())
+	}
+}
@@ -166,6 +166,12 @@ func (s *Snapshot) NumChunks() uint64 {
 	return uint64(len(s.staticChunks))
 }
 
+// ChunkExtensionInfo returns the extension info of the chunk with the given
+// index.
+func (s *Snapshot) ChunkExtensionInfo(chunkIndex uint64) [16]byte {
+	return s.staticChunks[chunkIndex].ExtensionInfo
+}
+
 // Pieces returns all the pieces for a chunk in a slice of slices that contains
 // all the pieces for a certain index.
 func (s *Snapshot) Pieces(chunkIndex uint64) [][]Piece {
@@ -270,7 +276,8 @@ func (sf *TurtleDexFile) readlockSnapshot(sp modules.TurtleDexPath, chunks []chu
 			}
 		}
 		exportedChunks = append(exportedChunks, Chunk{
-			Pieces: pieces,
+			ExtensionInfo: chunk.ExtensionInfo,
+			Pieces:        pieces,
 		})
 	}
 	// Get non-static metadata fields under lock.
@@ -93,7 +93,8 @@ type (
 
 	// Chunk is an exported chunk. It contains exported pieces.
 	Chunk struct {
-		Pieces [][]Piece
+		ExtensionInfo [16]byte
+		Pieces        [][]Piece
 	}
 
 	// piece represents a single piece of a chunk on disk
@@ -1013,6 +1014,44 @@ func (sf *TurtleDexFile) SetStuck(index uint64, stuck bool) (err error) {
 	return sf.setStuck(index, stuck)
 }
 
+// ChunkExtensionInfo returns the extension info of the chunk at the index.
+// Partial chunks don't have any extension info.
+func (sf *TurtleDexFile) ChunkExtensionInfo(index uint64) ([16]byte, error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if _, ok := sf.isIncludedPartialChunk(index); ok || sf.isIncompletePartialChunk(index) {
+		return [16]byte{}, nil
+	}
+	chunk, err := sf.chunk(int(index))
+	if err != nil {
+		return [16]byte{}, errors.AddContext(err, "failed to read chunk")
+	}
+	return chunk.ExtensionInfo, nil
+}
+
+// SetChunkExtensionInfo sets the extension info of the chunk at the index.
+func (sf *TurtleDexFile) SetChunkExtensionInfo(index uint64, ei [16]byte) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	// If the file has been deleted we can't update the chunk.
+	if sf.deleted {
+		return errors.AddContext(ErrDeleted, "can't call SetChunkExtensionInfo on deleted file")
+	}
+	if _, ok := sf.isIncludedPartialChunk(index); ok || sf.isIncompletePartialChunk(index) {
+		return errors.New("can't set extension info of partial chunk")
+	}
+	chunk, err := sf.chunk(int(index))
+	if err != nil {
+		return errors.AddContext(err, "failed to read chunk")
+	}
+	// Check for change
+	if chunk.ExtensionInfo == ei {
+		return nil
+	}
+	chunk.ExtensionInfo = ei
+	return sf.createAndApplyTransaction(sf.saveChunkUpdate(chunk))
+}
+
 // StuckChunkByIndex returns if the chunk at the index is marked as Stuck or not
 func (sf *TurtleDexFile) StuckChunkByIndex(index uint64) (bool, error) {
 	sf.mu.Lock()
@@ -1161,6 +1161,58 @@ func TestStuckChunks(t *testing.T) {
 	}
 }
 
+// TestChunkExtensionInfo tests setting and getting the extension info of a
+// chunk.
+func TestChunkExtensionInfo(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create siafile
+	sf := newTestFile()
+
+	// Set the extension info of every full chunk.
+	infos := make(map[uint64][16]byte)
+	for chunkIndex := uint64(0); chunkIndex < sf.NumChunks(); chunkIndex++ {
+		if sf.staticMetadata.HasPartialChunk && chunkIndex == sf.NumChunks()-1 {
+			// Partial chunks don't have any extension info.
+			if err := sf.SetChunkExtensionInfo(chunkIndex, [16]byte{1}); err == nil {
+				t.Fatal("shouldn't be able to set extension info of partial chunk")
+			}
+			continue
+		}
+		var ei [16]byte
+		fastrand.Read(ei[:])
+		if err := sf.SetChunkExtensionInfo(chunkIndex, ei); err != nil {
+			t.Fatal(err)
+		}
+		infos[chunkIndex] = ei
+	}
+
+	// Load siafile from disk and check the infos.
+	sf, err := LoadTurtleDexFile(sf.TurtleDexFilePath(), sf.wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap, err := sf.Snapshot(modules.RandomTurtleDexPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for chunkIndex, expected := range infos {
+		ei, err := sf.ChunkExtensionInfo(chunkIndex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ei != expected {
+			t.Fatal("wrong extension info", ei, expected)
+		}
+		if snap.ChunkExtensionInfo(chunkIndex) != expected {
+			t.Fatal("wrong extension info in snapshot", snap.ChunkExtensionInfo(chunkIndex), expected)
+		}
+	}
+}
+
 // TestUploadedBytes tests that uploadedBytes() returns the expected values for
 // total and unique uploaded bytes.
 func TestUploadedBytes(t *testing.T) {
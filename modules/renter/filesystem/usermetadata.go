@@ -0,0 +1,40 @@
+package filesystem
+
+import (
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/errors"
+)
+
+// SetDirUserMetadata sets the keys of the user metadata of the dir at siaPath
+// to the values in set and removes the keys in remove.
+func (fs *FileSystem) SetDirUserMetadata(siaPath modules.TurtleDexPath, set map[string]string, remove []string) (err error) {
+	dir, err := fs.OpenTurtleDexDir(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+	}()
+	md, err := dir.Metadata()
+	if err != nil {
+		return err
+	}
+	md.UserMetadata = modules.UpdateUserMetadata(md.UserMetadata, set, remove)
+	if err := modules.ValidateUserMetadata(md.UserMetadata); err != nil {
+		return err
+	}
+	return dir.UpdateMetadata(md)
+}
+
+// SetFileUserMetadata sets the keys of the user metadata of the file at
+// siaPath to the values in set and removes the keys in remove.
+func (fs *FileSystem) SetFileUserMetadata(siaPath modules.TurtleDexPath, set map[string]string, remove []string) (err error) {
+	file, err := fs.OpenTurtleDexFile(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, file.Close())
+	}()
+	return file.SetUserMetadata(set, remove)
+}
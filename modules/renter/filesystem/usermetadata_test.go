@@ -0,0 +1,73 @@
+package filesystem
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/errors"
+)
+
+// TestUserMetadata tests setting the user metadata of files and dirs and that
+// it is reported in their infos.
+func TestUserMetadata(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// Create filesystem.
+	root := filepath.Join(testDir(t.Name()), "fs-root")
+	fs := newTestFileSystem(root)
+
+	// Create a dir with a file.
+	dirPath := newTurtleDexPath("dir")
+	filePath := newTurtleDexPath("dir/file")
+	if err := fs.NewTurtleDexDir(dirPath, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	fs.addTestTurtleDexFile(filePath)
+
+	// Set the metadata of both.
+	if err := fs.SetDirUserMetadata(dirPath, map[string]string{"project": "x"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.SetFileUserMetadata(filePath, map[string]string{"project": "x", "owner": "bob"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.SetFileUserMetadata(filePath, nil, []string{"owner"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Check the infos.
+	expected := map[string]string{"project": "x"}
+	di, err := fs.DirInfo(dirPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(di.UserMetadata, expected) {
+		t.Fatal("wrong dir metadata", di.UserMetadata)
+	}
+	fi, err := fs.CachedFileInfo(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fi.UserMetadata, expected) {
+		t.Fatal("wrong file metadata", fi.UserMetadata)
+	}
+
+	// Invalid metadata should be rejected.
+	err = fs.SetDirUserMetadata(dirPath, map[string]string{"a=b": "c"}, nil)
+	if !errors.Contains(err, modules.ErrInvalidUserMetadataKey) {
+		t.Fatal("expected ErrInvalidUserMetadataKey but got", err)
+	}
+	// Setting the metadata of files and dirs that don't exist should fail.
+	err = fs.SetFileUserMetadata(newTurtleDexPath("dir/nofile"), expected, nil)
+	if !errors.Contains(err, ErrNotExist) {
+		t.Fatal("expected ErrNotExist but got", err)
+	}
+	err = fs.SetDirUserMetadata(newTurtleDexPath("nodir"), expected, nil)
+	if !errors.Contains(err, ErrNotExist) {
+		t.Fatal("expected ErrNotExist but got", err)
+	}
+}
@@ -0,0 +1,250 @@
+package filesystem
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/errors"
+)
+
+var (
+	// ErrInvalidVersionRetention is returned when a dir's version retention
+	// is set to an invalid value.
+	ErrInvalidVersionRetention = errors.New("version retention must not be smaller than -1")
+
+	// ErrUnknownVersion is returned when a file version can't be found.
+	ErrUnknownVersion = errors.New("file version does not exist")
+)
+
+// VersionsTurtleDexPath returns the siapath of the directory that holds the
+// prior versions of the file at siaPath.
+func VersionsTurtleDexPath(siaPath modules.TurtleDexPath) (modules.TurtleDexPath, error) {
+	return modules.VersionsFolder.Join(siaPath.String())
+}
+
+// VersionTime returns the time at which the version with the provided id was
+// archived. Version ids are the unix nano timestamp of their archival.
+func VersionTime(versionID string) (time.Time, error) {
+	nanos, err := strconv.ParseInt(versionID, 10, 64)
+	if err != nil {
+		return time.Time{}, errors.AddContext(err, "invalid version id")
+	}
+	return time.Unix(0, nanos), nil
+}
+
+// versionTurtleDexPath returns the siapath of the version of the file at
+// siaPath with the provided id.
+func versionTurtleDexPath(siaPath modules.TurtleDexPath, versionID string) (modules.TurtleDexPath, error) {
+	if _, err := VersionTime(versionID); err != nil {
+		return modules.TurtleDexPath{}, err
+	}
+	versionsDir, err := VersionsTurtleDexPath(siaPath)
+	if err != nil {
+		return modules.TurtleDexPath{}, err
+	}
+	return versionsDir.Join(versionID)
+}
+
+// ArchiveFile moves the file at siaPath into its versions directory and then
+// deletes the oldest versions until at most retention versions are left. A
+// retention of 0 disables versioning, in which case the file is deleted.
+func (fs *FileSystem) ArchiveFile(siaPath modules.TurtleDexPath, retention uint64) error {
+	if retention == 0 {
+		return fs.DeleteFile(siaPath)
+	}
+	if err := fs.managedArchiveFile(siaPath); err != nil {
+		return err
+	}
+	return fs.PruneFileVersions(siaPath, retention)
+}
+
+// FileVersions returns the ids of the prior versions of the file at siaPath
+// sorted from oldest to newest.
+func (fs *FileSystem) FileVersions(siaPath modules.TurtleDexPath) ([]string, error) {
+	versionsDir, err := VersionsTurtleDexPath(siaPath)
+	if err != nil {
+		return nil, err
+	}
+	fis, err := fs.ReadDir(versionsDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to read versions dir")
+	}
+	var ids []string
+	var times []int64
+	for _, fi := range fis {
+		if fi.IsDir() || !strings.HasSuffix(fi.Name(), modules.TurtleDexFileExtension) {
+			continue
+		}
+		id := strings.TrimSuffix(fi.Name(), modules.TurtleDexFileExtension)
+		t, err := VersionTime(id)
+		if err != nil {
+			continue // not a version
+		}
+		ids = append(ids, id)
+		times = append(times, t.UnixNano())
+	}
+	sort.Sort(versionsByTime{ids, times})
+	return ids, nil
+}
+
+// PruneFileVersions deletes the oldest versions of the file at siaPath until at
+// most retention versions are left.
+func (fs *FileSystem) PruneFileVersions(siaPath modules.TurtleDexPath, retention uint64) error {
+	ids, err := fs.FileVersions(siaPath)
+	if err != nil {
+		return err
+	}
+	for uint64(len(ids)) > retention {
+		versionPath, err := versionTurtleDexPath(siaPath, ids[0])
+		if err != nil {
+			return err
+		}
+		err = fs.DeleteFile(versionPath)
+		if err != nil && !errors.Contains(err, ErrNotExist) {
+			return errors.AddContext(err, "unable to delete old version")
+		}
+		ids = ids[1:]
+	}
+	return nil
+}
+
+// RestoreFileVersion moves the version with the provided id back to siaPath.
+// If a file exists at siaPath, it is archived first and the versions are
+// pruned according to retention afterwards. A retention of 0 means that
+// versioning is disabled for the file, in which case the file at siaPath is
+// deleted instead and the remaining versions are left untouched.
+func (fs *FileSystem) RestoreFileVersion(siaPath modules.TurtleDexPath, versionID string, retention uint64) error {
+	versionPath, err := versionTurtleDexPath(siaPath, versionID)
+	if err != nil {
+		return err
+	}
+	exists, err := fs.FileExists(versionPath)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrUnknownVersion
+	}
+
+	// Without versioning, the current file is deleted like ArchiveFile does.
+	if retention == 0 {
+		err = fs.DeleteFile(siaPath)
+		if err != nil && !errors.Contains(err, ErrNotExist) {
+			return errors.AddContext(err, "unable to delete current file")
+		}
+		return errors.AddContext(fs.RenameFile(versionPath, siaPath), "unable to move version into place")
+	}
+
+	// Archive the current file without pruning. Pruning first might delete
+	// the version that is about to be restored.
+	err = fs.managedArchiveFile(siaPath)
+	if err != nil && !errors.Contains(err, ErrNotExist) {
+		return errors.AddContext(err, "unable to archive current file")
+	}
+	err = fs.RenameFile(versionPath, siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to move version into place")
+	}
+	return fs.PruneFileVersions(siaPath, retention)
+}
+
+// SetVersionRetention sets the number of prior versions that are kept for
+// files within the dir at siaPath. A retention of 0 inherits the setting of the
+// parent dir and modules.VersionRetentionDisabled disables versioning.
+func (fs *FileSystem) SetVersionRetention(siaPath modules.TurtleDexPath, retention int64) (err error) {
+	if retention < modules.VersionRetentionDisabled {
+		return ErrInvalidVersionRetention
+	}
+	dir, err := fs.OpenTurtleDexDir(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+	}()
+	md, err := dir.Metadata()
+	if err != nil {
+		return err
+	}
+	md.VersionRetention = retention
+	return dir.UpdateMetadata(md)
+}
+
+// VersionRetention returns the number of prior versions that should be kept
+// for the file at siaPath. The setting is inherited from the closest parent
+// dir that has it set. A retention of 0 means that versioning is disabled.
+func (fs *FileSystem) VersionRetention(siaPath modules.TurtleDexPath) (uint64, error) {
+	dirPath := siaPath
+	for !dirPath.IsRoot() {
+		var err error
+		dirPath, err = dirPath.Dir()
+		if err != nil {
+			return 0, err
+		}
+		retention, err := fs.managedDirVersionRetention(dirPath)
+		if errors.Contains(err, ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		if retention == modules.VersionRetentionDisabled {
+			return 0, nil
+		}
+		if retention > 0 {
+			return uint64(retention), nil
+		}
+	}
+	return 0, nil
+}
+
+// managedArchiveFile moves the file at siaPath into its versions directory.
+func (fs *FileSystem) managedArchiveFile(siaPath modules.TurtleDexPath) error {
+	versionPath, err := versionTurtleDexPath(siaPath, strconv.FormatInt(time.Now().UnixNano(), 10))
+	if err != nil {
+		return err
+	}
+	return fs.RenameFile(siaPath, versionPath)
+}
+
+// managedDirVersionRetention returns the version retention that is set in the
+// metadata of the dir at siaPath.
+func (fs *FileSystem) managedDirVersionRetention(siaPath modules.TurtleDexPath) (_ int64, err error) {
+	dir, err := fs.OpenTurtleDexDir(siaPath)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+	}()
+	md, err := dir.Metadata()
+	if err != nil {
+		return 0, err
+	}
+	return md.VersionRetention, nil
+}
+
+// versionsByTime sorts version ids by their archival time.
+type versionsByTime struct {
+	ids   []string
+	times []int64
+}
+
+// Len implements sort.Interface.
+func (v versionsByTime) Len() int { return len(v.ids) }
+
+// Less implements sort.Interface.
+func (v versionsByTime) Less(i, j int) bool { return v.times[i] < v.times[j] }
+
+// Swap implements sort.Interface.
+func (v versionsByTime) Swap(i, j int) {
+	v.ids[i], v.ids[j] = v.ids[j], v.ids[i]
+	v.times[i], v.times[j] = v.times[j], v.times[i]
+}
@@ -0,0 +1,183 @@
+package filesystem
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem/siafile"
+	"github.com/turtledex/errors"
+)
+
+// TestFileVersions tests archiving, pruning and restoring file versions.
+func TestFileVersions(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// Create filesystem.
+	root := filepath.Join(testDir(t.Name()), "fs-root")
+	fs := newTestFileSystem(root)
+
+	// Without a retention set, versioning is disabled.
+	sp := newTurtleDexPath("dir/sub/foo")
+	fs.addTestTurtleDexFile(sp)
+	retention, err := fs.VersionRetention(sp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retention != 0 {
+		t.Fatal("expected retention to be 0 but was", retention)
+	}
+	if err := fs.ArchiveFile(sp, retention); err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ := fs.FileExists(sp); exists {
+		t.Fatal("file should have been deleted")
+	}
+	ids, err := fs.FileVersions(sp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 0 {
+		t.Fatal("there shouldn't be any versions", len(ids))
+	}
+
+	// Set a retention on the grandparent dir. It should be inherited.
+	if err := fs.SetVersionRetention(newTurtleDexPath("dir"), 2); err != nil {
+		t.Fatal(err)
+	}
+	retention, err = fs.VersionRetention(sp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retention != 2 {
+		t.Fatal("expected retention to be inherited but was", retention)
+	}
+
+	// Archive the file 3 times. Only 2 versions should be kept.
+	var uids []siafile.TurtleDexfileUID
+	for i := 0; i < 3; i++ {
+		fs.addTestTurtleDexFile(sp)
+		sf, err := fs.OpenTurtleDexFile(sp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		uids = append(uids, sf.UID())
+		if err := sf.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := fs.ArchiveFile(sp, retention); err != nil {
+			t.Fatal(err)
+		}
+		// Make sure the version ids differ.
+		time.Sleep(time.Millisecond)
+	}
+	ids, err = fs.FileVersions(sp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 {
+		t.Fatal("expected 2 versions but got", len(ids))
+	}
+	t1, err1 := VersionTime(ids[0])
+	t2, err2 := VersionTime(ids[1])
+	if err := errors.Compose(err1, err2); err != nil {
+		t.Fatal(err)
+	}
+	if !t1.Before(t2) {
+		t.Fatal("versions should be sorted from oldest to newest")
+	}
+
+	// Restore the oldest remaining version. The current file should be
+	// archived and the restored version should be gone from the versions.
+	fs.addTestTurtleDexFile(sp)
+	if err := fs.RestoreFileVersion(sp, ids[0], retention); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := fs.OpenTurtleDexFile(sp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.UID() != uids[1] {
+		t.Fatal("wrong version was restored")
+	}
+	if err := restored.Close(); err != nil {
+		t.Fatal(err)
+	}
+	newIDs, err := fs.FileVersions(sp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(newIDs) != 2 || newIDs[0] != ids[1] {
+		t.Fatal("unexpected versions after restore", newIDs, ids)
+	}
+
+	// Restoring an unknown version should fail.
+	if err := fs.RestoreFileVersion(sp, "1", retention); !errors.Contains(err, ErrUnknownVersion) {
+		t.Fatal("expected ErrUnknownVersion but got", err)
+	}
+
+	// The versions are regular siafiles in the versions folder.
+	versionsDir, err := VersionsTurtleDexPath(sp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fis, _, err := fs.CachedListCollect(versionsDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fis) != 2 {
+		t.Fatal("expected 2 siafiles in the versions dir but got", len(fis))
+	}
+	for _, fi := range fis {
+		dir, err := fi.TurtleDexPath.Dir()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !dir.Equals(versionsDir) {
+			t.Fatal("version stored in wrong dir", fi.TurtleDexPath)
+		}
+	}
+
+	// Values smaller than VersionRetentionDisabled are invalid.
+	err = fs.SetVersionRetention(newTurtleDexPath("dir/sub"), modules.VersionRetentionDisabled-1)
+	if !errors.Contains(err, ErrInvalidVersionRetention) {
+		t.Fatal("expected ErrInvalidVersionRetention but got", err)
+	}
+
+	// Disable versioning in the parent dir. It overrides the retention of the
+	// grandparent dir.
+	if err := fs.SetVersionRetention(newTurtleDexPath("dir/sub"), modules.VersionRetentionDisabled); err != nil {
+		t.Fatal(err)
+	}
+	retention, err = fs.VersionRetention(sp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retention != 0 {
+		t.Fatal("expected versioning to be disabled but retention was", retention)
+	}
+	other := newTurtleDexPath("dir/other")
+	retention, err = fs.VersionRetention(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retention != 2 {
+		t.Fatal("sibling dir should still inherit the retention but it was", retention)
+	}
+
+	// Restoring a version without versioning deletes the current file and
+	// leaves the other versions untouched.
+	if err := fs.RestoreFileVersion(sp, newIDs[1], 0); err != nil {
+		t.Fatal(err)
+	}
+	ids, err = fs.FileVersions(sp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0] != newIDs[0] {
+		t.Fatal("unexpected versions after restore without versioning", ids, newIDs)
+	}
+}
@@ -57,18 +57,52 @@ var _ = (fs.NodeLookuper)((*fuseDirnode)(nil))
 var _ = (fs.NodeReaddirer)((*fuseDirnode)(nil))
 var _ = (fs.NodeStatfser)((*fuseDirnode)(nil))
 
+// Ensure the dir nodes satisfy the interfaces required for read-write mounts.
+//
+// NodeCreater is necessary for creating new files.
+//
+// NodeMkdirer is necessary for creating new directories.
+//
+// NodeRenamer is necessary for moving files and directories.
+//
+// NodeRmdirer is necessary for deleting directories.
+//
+// NodeUnlinker is necessary for deleting files.
+var _ = (fs.NodeCreater)((*fuseDirnode)(nil))
+var _ = (fs.NodeMkdirer)((*fuseDirnode)(nil))
+var _ = (fs.NodeRenamer)((*fuseDirnode)(nil))
+var _ = (fs.NodeRmdirer)((*fuseDirnode)(nil))
+var _ = (fs.NodeUnlinker)((*fuseDirnode)(nil))
+
 // fuseFilenode is a fuse node for the fs package that covers a siafile.
 //
 // Data is fetched using a download streamer. This download streamer needs to be
 // closed when the filehandle is released.
+//
+// When the filesystem is mounted read-write, writes are buffered in a local
+// staging file which is uploaded to the network when the file is flushed. The
+// upload replaces the underlying siafile, which is why the fileNode is not
+// static and is protected by its own mutex.
 type fuseFilenode struct {
 	atomicClosed uint32
 
 	fs.Inode
 	staticFilesystem *fuseFS
-	staticFileNode   *filesystem.FileNode
 	stream           modules.Streamer
 	mu               sync.Mutex
+
+	// staged is the staging file of the fuse file and stagedPath is the
+	// siapath its data is committed to. The siapath follows renames of the
+	// file while it is staged.
+	staged     *fuseStagedFile
+	stagedPath modules.TurtleDexPath
+
+	// fileNode and stagedSize are protected by nodeMu rather than mu so that
+	// Getattr doesn't block on long running reads and writes. stagedSize is -1
+	// if there is no staged data for the file.
+	fileNode   *filesystem.FileNode
+	stagedSize int64
+	nodeMu     sync.Mutex
 }
 
 // Ensure the file nodes satisfy the required interfaces.
@@ -94,6 +128,20 @@ var _ = (fs.NodeOpener)((*fuseFilenode)(nil))
 var _ = (fs.NodeReader)((*fuseFilenode)(nil))
 var _ = (fs.NodeStatfser)((*fuseFilenode)(nil))
 
+// Ensure the file nodes satisfy the interfaces required for read-write mounts.
+//
+// NodeFsyncer is necessary for committing staged writes on demand.
+//
+// NodeReleaser is necessary for cleaning up the local staging file.
+//
+// NodeSetattrer is necessary for truncating files and changing their mode.
+//
+// NodeWriter is necessary for writing files.
+var _ = (fs.NodeFsyncer)((*fuseFilenode)(nil))
+var _ = (fs.NodeReleaser)((*fuseFilenode)(nil))
+var _ = (fs.NodeSetattrer)((*fuseFilenode)(nil))
+var _ = (fs.NodeWriter)((*fuseFilenode)(nil))
+
 // fuseRoot is the root directory for a mounted fuse filesystem.
 type fuseFS struct {
 	options modules.MountOptions
@@ -107,12 +155,24 @@ type fuseFS struct {
 func errToStatus(err error) syscall.Errno {
 	if err == nil {
 		return syscall.F_OK
-	} else if errors.IsOSNotExist(err) {
+	} else if errors.IsOSNotExist(err) || errors.Contains(err, filesystem.ErrNotExist) {
 		return syscall.ENOENT
+	} else if errors.Contains(err, filesystem.ErrExists) {
+		return syscall.EEXIST
+	} else if errors.Contains(err, errFuseReadOnly) {
+		return syscall.EROFS
 	}
 	return syscall.EIO
 }
 
+// managedFileNode returns the filenode that is currently backing the fuse
+// file.
+func (ffn *fuseFilenode) managedFileNode() *filesystem.FileNode {
+	ffn.nodeMu.Lock()
+	defer ffn.nodeMu.Unlock()
+	return ffn.fileNode
+}
+
 // Access reports whether a directory can be accessed by the caller.
 func (fdn *fuseDirnode) Access(ctx context.Context, mask uint32) syscall.Errno {
 	// TODO: parse the mask and return a more correct value instead of always
@@ -137,14 +197,22 @@ func (fdn *fuseDirnode) Flush(ctx context.Context, fh fs.FileHandle) syscall.Err
 	return errToStatus(err)
 }
 
-// Flush is called when a file is being closed.
+// Flush is called when a file is being closed. Any staged writes are committed
+// to the TurtleDex network before the file is closed.
 func (ffn *fuseFilenode) Flush(ctx context.Context, fh fs.FileHandle) syscall.Errno {
+	ffn.mu.Lock()
+	defer ffn.mu.Unlock()
+	err := ffn.commitStaged()
+	if err != nil {
+		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileTurtleDexPath(ffn.managedFileNode())
+		ffn.staticFilesystem.renter.log.Printf("error when committing writes to fuse file %v: %v", siaPath, err)
+		return errToStatus(err)
+	}
+
 	swapped := atomic.CompareAndSwapUint32(&ffn.atomicClosed, 0, 1)
 	if !swapped {
 		return errToStatus(nil)
 	}
-	ffn.mu.Lock()
-	defer ffn.mu.Unlock()
 
 	// If a stream was opened for the file, the stream must now be closed.
 	var streamErr error
@@ -152,13 +220,15 @@ func (ffn *fuseFilenode) Flush(ctx context.Context, fh fs.FileHandle) syscall.Er
 		// Need to 'nil' out the stream once 'Flush' has been called because it
 		// can be called multiple times.
 		streamErr = ffn.stream.Close()
+		ffn.stream = nil
 	}
 
 	// Check all of the errors.
-	closeErr := ffn.staticFileNode.Close()
-	err := errors.Compose(streamErr, closeErr)
+	fileNode := ffn.managedFileNode()
+	closeErr := fileNode.Close()
+	err = errors.Compose(streamErr, closeErr)
 	if err != nil {
-		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileTurtleDexPath(ffn.staticFileNode)
+		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileTurtleDexPath(fileNode)
 		ffn.staticFilesystem.renter.log.Printf("error when flushing fuse file %v: %v", siaPath, err)
 		return errToStatus(err)
 	}
@@ -181,7 +251,8 @@ func (fdn *fuseDirnode) Lookup(ctx context.Context, name string, out *fuse.Entry
 		// Convert the file to an inode.
 		filenode := &fuseFilenode{
 			staticFilesystem: fdn.staticFilesystem,
-			staticFileNode:   fileNode,
+			fileNode:         fileNode,
+			stagedSize:       -1,
 		}
 		attrs := fs.StableAttr{
 			Ino:  fileInfo.UID,
@@ -244,18 +315,26 @@ func (fdn *fuseDirnode) Getattr(ctx context.Context, fh fs.FileHandle, out *fuse
 // Getattr should try to minimize lock contention and should run very quickly if
 // possible.
 func (ffn *fuseFilenode) Getattr(ctx context.Context, fh fs.FileHandle, out *fuse.AttrOut) syscall.Errno {
-	fileInfo, err := ffn.staticFilesystem.renter.staticFileSystem.FileNodeInfo(ffn.staticFileNode)
+	ffn.nodeMu.Lock()
+	fileNode, stagedSize := ffn.fileNode, ffn.stagedSize
+	ffn.nodeMu.Unlock()
+	fileInfo, err := ffn.staticFilesystem.renter.staticFileSystem.FileNodeInfo(fileNode)
 	if err != nil {
 		ffn.staticFilesystem.renter.log.Printf("Unable to fetch info from file: %v", err)
 	}
 
+	// Report the size of the staged data if the file has pending writes.
 	out.Size = fileInfo.Filesize
+	if stagedSize >= 0 {
+		out.Size = uint64(stagedSize)
+	}
 	out.Mode = uint32(fileInfo.Mode()) | syscall.S_IFREG
 	out.Ino = fileInfo.UID
 	return errToStatus(nil)
 }
 
-// Open will open a streamer for the file.
+// Open will open a streamer for the file. If the file is opened for writing, a
+// local staging file is prepared as well.
 //
 // TODO: Currently 'Open' returns '0' for the fuseFlags. I was unable to figure
 // out from the documentation what the flags are supposed to represent. So far,
@@ -264,9 +343,26 @@ func (ffn *fuseFilenode) Open(ctx context.Context, flags uint32) (fs.FileHandle,
 	ffn.mu.Lock()
 	defer ffn.mu.Unlock()
 
-	stream, err := ffn.staticFilesystem.renter.StreamerByNode(ffn.staticFileNode, false)
+	// Prepare the staging file if the file is opened for writing.
+	if flags&(syscall.O_WRONLY|syscall.O_RDWR) != 0 {
+		if ffn.staticFilesystem.options.ReadOnly {
+			return nil, 0, syscall.EROFS
+		}
+		err := ffn.stageFile(flags&syscall.O_TRUNC != 0)
+		if err != nil {
+			siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileTurtleDexPath(ffn.managedFileNode())
+			ffn.staticFilesystem.renter.log.Printf("Unable to stage file %v for writing: %v", siaPath, err)
+			return nil, 0, errToStatus(err)
+		}
+	}
+	if ffn.stream != nil {
+		return ffn, 0, errToStatus(nil)
+	}
+
+	fileNode := ffn.managedFileNode()
+	stream, err := ffn.staticFilesystem.renter.StreamerByNode(fileNode, false, modules.DownloadPriorityInteractive, modules.FuseBudgetIdentity)
 	if err != nil {
-		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileTurtleDexPath(ffn.staticFileNode)
+		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileTurtleDexPath(fileNode)
 		ffn.staticFilesystem.renter.log.Printf("Unable to get stream for file %v: %v", siaPath, err)
 		return nil, 0, errToStatus(err)
 	}
@@ -284,9 +380,27 @@ func (ffn *fuseFilenode) Read(ctx context.Context, f fs.FileHandle, dest []byte,
 	ffn.mu.Lock()
 	defer ffn.mu.Unlock()
 
+	// If there are staged writes, serve the read from the staging file.
+	if ffn.staged != nil {
+		n, err := ffn.staged.ReadAt(dest, offset)
+		if err != nil && !errors.Contains(err, io.EOF) {
+			siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileTurtleDexPath(ffn.managedFileNode())
+			ffn.staticFilesystem.renter.log.Printf("Error reading staged data at offset %v in file %s: %v", offset, siaPath.String(), err)
+			return nil, errToStatus(err)
+		}
+		return fuse.ReadResultData(dest[:n]), errToStatus(nil)
+	}
+	if ffn.stream == nil {
+		stream, err := ffn.staticFilesystem.renter.StreamerByNode(ffn.managedFileNode(), false, modules.DownloadPriorityInteractive, modules.FuseBudgetIdentity)
+		if err != nil {
+			return nil, errToStatus(err)
+		}
+		ffn.stream = stream
+	}
+
 	_, err := ffn.stream.Seek(offset, io.SeekStart)
 	if err != nil {
-		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileTurtleDexPath(ffn.staticFileNode)
+		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileTurtleDexPath(ffn.managedFileNode())
 		ffn.staticFilesystem.renter.log.Printf("Error seeking to offset %v during call to Read in file %s: %v", offset, siaPath.String(), err)
 		return nil, errToStatus(err)
 	}
@@ -297,7 +411,7 @@ func (ffn *fuseFilenode) Read(ctx context.Context, f fs.FileHandle, dest []byte,
 	// often dropping parts of the tail of the file.
 	n, err := io.ReadFull(ffn.stream, dest)
 	if err != nil && !errors.Contains(err, io.EOF) && err != io.ErrUnexpectedEOF {
-		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileTurtleDexPath(ffn.staticFileNode)
+		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileTurtleDexPath(ffn.managedFileNode())
 		ffn.staticFilesystem.renter.log.Printf("Error reading from offset %v during call to Read in file %s: %v", offset, siaPath.String(), err)
 		return nil, errToStatus(err)
 	}
@@ -401,7 +515,7 @@ func (fdn *fuseDirnode) Statfs(ctx context.Context, out *fuse.StatfsOut) syscall
 func (ffn *fuseFilenode) Statfs(ctx context.Context, out *fuse.StatfsOut) syscall.Errno {
 	err := ffn.staticFilesystem.setStatfsOut(out)
 	if err != nil {
-		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileTurtleDexPath(ffn.staticFileNode)
+		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileTurtleDexPath(ffn.managedFileNode())
 		ffn.staticFilesystem.renter.log.Printf("Error fetching statfs for fuse file %v: %v", siaPath, err)
 		return errToStatus(err)
 	}
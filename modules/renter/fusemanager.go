@@ -4,7 +4,10 @@ package renter
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"sync"
+	"time"
 
 	"github.com/hanwen/go-fuse/v2/fs"
 	"github.com/hanwen/go-fuse/v2/fuse"
@@ -30,6 +33,11 @@ func newFuseManager(r *Renter) renterFuseManager {
 		renter:      r,
 	}
 
+	// Move any staging files that were left behind by read-write mounts of a
+	// previous session out of the way. They might contain writes that were
+	// never committed, so they are kept around for the user to recover.
+	r.managedRecoverFuseStagingDir()
+
 	// Close the fuse manager on shutdown.
 	r.tg.OnStop(func() error {
 		return fm.managedCloseFuseManager()
@@ -38,6 +46,31 @@ func newFuseManager(r *Renter) renterFuseManager {
 	return fm
 }
 
+// managedRecoverFuseStagingDir moves the fuse staging dir aside if it contains
+// any staging files and registers an alert pointing the user to them.
+func (r *Renter) managedRecoverFuseStagingDir() {
+	stagingDir := r.staticFuseStagingDir()
+	fis, err := ioutil.ReadDir(stagingDir)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		r.log.Printf("Unable to read the fuse staging dir: %v", err)
+		return
+	}
+	if len(fis) == 0 {
+		return
+	}
+	recoveryDir := fmt.Sprintf("%v-%v", stagingDir, time.Now().Unix())
+	err = os.Rename(stagingDir, recoveryDir)
+	if err != nil {
+		r.log.Printf("Unable to move the fuse staging dir aside: %v", err)
+		return
+	}
+	r.log.Printf("Moved %v leftover fuse staging files to %v", len(fis), recoveryDir)
+	r.staticAlerter.RegisterAlert(modules.AlertIDRenterFuseStagedWrites, AlertMSGFuseStagedWrites, AlertCauseFuseStagedWrites(len(fis), recoveryDir), modules.SeverityWarning)
+}
+
 // managedCloseFuseManager unmounts all currently-mounted filesystems.
 func (fm *fuseManager) managedCloseFuseManager() error {
 	// The concurreny here is a little bit annoying because the callto Unmount
@@ -98,11 +131,6 @@ func (fm *fuseManager) Mount(mountPoint string, sp modules.TurtleDexPath, opts m
 		}
 	}()
 
-	// Enforce read-only requirements for now.
-	if !opts.ReadOnly {
-		return errors.New("fuse must be mounted as read-only")
-	}
-
 	// Get the mountpoint's root from the filesystem.
 	rootDirNode, err := fm.renter.staticFileSystem.OpenTurtleDexDir(sp)
 	if err != nil {
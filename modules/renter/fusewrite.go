@@ -0,0 +1,578 @@
+// +build linux darwin
+
+package renter
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/errors"
+)
+
+const (
+	// fuseStagingDir is the name of the directory within the renter's persist
+	// directory that holds the local staging files of fuse files which are
+	// opened for writing.
+	fuseStagingDir = "fusestaging"
+)
+
+var (
+	// errFuseReadOnly is returned when a write operation is attempted on a
+	// fuse filesystem that was mounted read-only.
+	errFuseReadOnly = errors.New("fuse filesystem is mounted read-only")
+)
+
+// fuseStagedFile is a local buffer that holds the contents of a fuse file that
+// was opened for writing. The staged data is uploaded to the TurtleDex network
+// when the file is flushed.
+type fuseStagedFile struct {
+	// dirty indicates that the staged data differs from the data on the
+	// network and needs to be committed.
+	dirty bool
+	file  *os.File
+	size  int64
+}
+
+// newFuseStagedFile creates a new, empty staging file in the provided
+// directory.
+func newFuseStagedFile(dir string) (*fuseStagedFile, error) {
+	err := os.MkdirAll(dir, modules.DefaultDirPerm)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create fuse staging dir")
+	}
+	f, err := ioutil.TempFile(dir, "staged-")
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create fuse staging file")
+	}
+	return &fuseStagedFile{file: f}, nil
+}
+
+// Close closes and removes the staging file.
+func (sf *fuseStagedFile) Close() error {
+	return errors.Compose(sf.file.Close(), os.Remove(sf.file.Name()))
+}
+
+// ReadAt reads staged data at the provided offset.
+func (sf *fuseStagedFile) ReadAt(b []byte, off int64) (int, error) {
+	if off >= sf.size {
+		return 0, io.EOF
+	}
+	if remaining := sf.size - off; int64(len(b)) > remaining {
+		b = b[:remaining]
+	}
+	return sf.file.ReadAt(b, off)
+}
+
+// ReadFrom appends the data read from r to the staging file.
+func (sf *fuseStagedFile) ReadFrom(r io.Reader) (int64, error) {
+	_, err := sf.file.Seek(sf.size, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(sf.file, r)
+	sf.size += n
+	return n, err
+}
+
+// Reader returns a reader over all of the staged data.
+func (sf *fuseStagedFile) Reader() io.Reader {
+	return io.NewSectionReader(sf.file, 0, sf.size)
+}
+
+// Truncate changes the size of the staged data.
+func (sf *fuseStagedFile) Truncate(size int64) error {
+	err := sf.file.Truncate(size)
+	if err != nil {
+		return err
+	}
+	sf.size = size
+	sf.dirty = true
+	return nil
+}
+
+// WriteAt writes data to the staging file at the provided offset.
+func (sf *fuseStagedFile) WriteAt(b []byte, off int64) (int, error) {
+	n, err := sf.file.WriteAt(b, off)
+	if end := off + int64(n); end > sf.size {
+		sf.size = end
+	}
+	if n > 0 {
+		sf.dirty = true
+	}
+	return n, err
+}
+
+// staticFuseStagingDir returns the directory which holds the staging files of
+// fuse files that are opened for writing.
+func (r *Renter) staticFuseStagingDir() string {
+	return filepath.Join(r.persistDir, fuseStagingDir)
+}
+
+// childTurtleDexPath returns the siapath of the child with the provided name.
+func (fdn *fuseDirnode) childTurtleDexPath(name string) (modules.TurtleDexPath, error) {
+	siaPath := fdn.staticFilesystem.renter.staticFileSystem.DirTurtleDexPath(fdn.staticDirNode)
+	return siaPath.Join(name)
+}
+
+// forEachFuseFile calls fn for every fuse file within the tree of the inode
+// that the kernel knows about, including the inode itself.
+func forEachFuseFile(inode *fs.Inode, fn func(*fuseFilenode)) {
+	if ffn, ok := inode.Operations().(*fuseFilenode); ok {
+		fn(ffn)
+	}
+	for _, child := range inode.Children() {
+		forEachFuseFile(child, fn)
+	}
+}
+
+// managedDropStaged discards the staged data of the file without committing
+// it. It is called when the file is deleted while it is still open.
+func (ffn *fuseFilenode) managedDropStaged() error {
+	ffn.mu.Lock()
+	defer ffn.mu.Unlock()
+	if ffn.staged == nil {
+		return nil
+	}
+	err := ffn.staged.Close()
+	ffn.staged = nil
+	ffn.setStagedSize(-1)
+	return err
+}
+
+// managedRebaseStaged moves the siapath that the staged data of the file is
+// committed to from oldBase to newBase. It is called when the file or one of
+// its parent directories is renamed while the file is still open.
+func (ffn *fuseFilenode) managedRebaseStaged(oldBase, newBase modules.TurtleDexPath) error {
+	ffn.mu.Lock()
+	defer ffn.mu.Unlock()
+	if ffn.staged == nil {
+		return nil
+	}
+	newPath, err := ffn.stagedPath.Rebase(oldBase, newBase)
+	if err != nil {
+		return err
+	}
+	ffn.stagedPath = newPath
+	return nil
+}
+
+// setStagedSize updates the size that Getattr reports for the file. A size of
+// -1 indicates that there is no staged data.
+func (ffn *fuseFilenode) setStagedSize(size int64) {
+	ffn.nodeMu.Lock()
+	defer ffn.nodeMu.Unlock()
+	ffn.stagedSize = size
+}
+
+// stageFile prepares the local staging file for the fuse file. Unless truncate
+// is set, the current contents of the file are downloaded into the staging
+// file so that partial writes preserve the remaining data.
+func (ffn *fuseFilenode) stageFile(truncate bool) error {
+	// If the file is already staged, only the truncation needs to be
+	// handled.
+	if ffn.staged != nil {
+		if !truncate {
+			return nil
+		}
+		err := ffn.staged.Truncate(0)
+		if err != nil {
+			return err
+		}
+		ffn.setStagedSize(0)
+		return nil
+	}
+
+	r := ffn.staticFilesystem.renter
+	staged, err := newFuseStagedFile(r.staticFuseStagingDir())
+	if err != nil {
+		return err
+	}
+	if truncate {
+		staged.dirty = true
+	} else {
+		stream, err := r.StreamerByNode(ffn.managedFileNode(), false, modules.DownloadPriorityInteractive, modules.FuseBudgetIdentity)
+		if err != nil {
+			return errors.Compose(err, staged.Close())
+		}
+		_, readErr := staged.ReadFrom(stream)
+		err = errors.Compose(readErr, stream.Close())
+		if err != nil {
+			return errors.Compose(errors.AddContext(err, "unable to download file into staging file"), staged.Close())
+		}
+	}
+	ffn.staged = staged
+	ffn.stagedPath = r.staticFileSystem.FileTurtleDexPath(ffn.managedFileNode())
+	ffn.setStagedSize(staged.size)
+	return nil
+}
+
+// commitStaged uploads the staged data of the file to the TurtleDex network,
+// replacing the existing siafile. The staged data is kept around until the file
+// is released so that following reads and writes don't need to hit the
+// network.
+func (ffn *fuseFilenode) commitStaged() error {
+	if ffn.staged == nil || !ffn.staged.dirty {
+		return nil
+	}
+	r := ffn.staticFilesystem.renter
+	oldNode := ffn.managedFileNode()
+	siaPath := ffn.stagedPath
+
+	// The open stream points to the data that is about to be replaced.
+	var streamErr error
+	if ffn.stream != nil {
+		streamErr = ffn.stream.Close()
+		ffn.stream = nil
+	}
+
+	// Upload the staged data using the erasure coding settings and the user
+	// metadata of the file that is being replaced.
+	up := modules.FileUploadParams{
+		TurtleDexPath:  siaPath,
+		ErasureCode:    oldNode.ErasureCode(),
+		Force:          true,
+		CipherType:     crypto.TypeDefaultRenter,
+		UserMetadata:   oldNode.UserMetadata(),
+		BudgetIdentity: modules.FuseBudgetIdentity,
+	}
+	newNode, err := r.callUploadStreamFromReader(up, ffn.staged.Reader())
+	if err != nil {
+		return errors.Compose(errors.AddContext(err, "unable to upload staged data"), streamErr)
+	}
+	ffn.staged.dirty = false
+
+	// Swap the nodes. If the old node hasn't been closed by a call to Flush
+	// yet, it needs to be closed now. Either way the new node is open.
+	ffn.nodeMu.Lock()
+	ffn.fileNode = newNode
+	ffn.nodeMu.Unlock()
+	var closeErr error
+	if atomic.SwapUint32(&ffn.atomicClosed, 0) == 0 {
+		closeErr = oldNode.Close()
+	}
+	return errors.Compose(streamErr, closeErr)
+}
+
+// Create creates a new, empty file in the directory and opens it for writing.
+func (fdn *fuseDirnode) Create(ctx context.Context, name string, flags uint32, mode uint32, out *fuse.EntryOut) (*fs.Inode, fs.FileHandle, uint32, syscall.Errno) {
+	if fdn.staticFilesystem.options.ReadOnly {
+		return nil, nil, 0, errToStatus(errFuseReadOnly)
+	}
+	r := fdn.staticFilesystem.renter
+	siaPath, err := fdn.childTurtleDexPath(name)
+	if err != nil {
+		return nil, nil, 0, syscall.EINVAL
+	}
+
+	// Create an empty siafile which will be replaced once the file is
+	// flushed.
+	ec := modules.NewRSSubCodeDefault()
+	cipherKey := crypto.GenerateTurtleDexKey(crypto.TypeDefaultRenter)
+	err = r.staticFileSystem.NewTurtleDexFile(siaPath, "", ec, cipherKey, 0, os.FileMode(mode).Perm(), false)
+	if err != nil {
+		r.log.Printf("Unable to create fuse file %v: %v", siaPath, err)
+		return nil, nil, 0, errToStatus(err)
+	}
+	fileNode, err := r.staticFileSystem.OpenTurtleDexFile(siaPath)
+	if err != nil {
+		r.log.Printf("Unable to open newly created fuse file %v: %v", siaPath, err)
+		return nil, nil, 0, errToStatus(err)
+	}
+	fileInfo, err := r.staticFileSystem.FileNodeInfo(fileNode)
+	if err != nil {
+		r.log.Printf("Unable to fetch fileinfo on new fuse file %v: %v", siaPath, err)
+		return nil, nil, 0, errToStatus(errors.Compose(err, fileNode.Close()))
+	}
+
+	// Create the filenode and stage it for writing.
+	ffn := &fuseFilenode{
+		staticFilesystem: fdn.staticFilesystem,
+		fileNode:         fileNode,
+		stagedSize:       -1,
+	}
+	ffn.mu.Lock()
+	err = ffn.stageFile(true)
+	ffn.mu.Unlock()
+	if err != nil {
+		r.log.Printf("Unable to stage new fuse file %v: %v", siaPath, err)
+		return nil, nil, 0, errToStatus(errors.Compose(err, fileNode.Close()))
+	}
+
+	attrs := fs.StableAttr{
+		Ino:  fileInfo.UID,
+		Mode: fuse.S_IFREG,
+	}
+	out.Ino = fileInfo.UID
+	out.Mode = uint32(fileInfo.Mode()) | syscall.S_IFREG
+	inode := fdn.NewInode(ctx, ffn, attrs)
+	return inode, ffn, 0, errToStatus(nil)
+}
+
+// Mkdir creates a new directory within the directory.
+func (fdn *fuseDirnode) Mkdir(ctx context.Context, name string, mode uint32, out *fuse.EntryOut) (*fs.Inode, syscall.Errno) {
+	if fdn.staticFilesystem.options.ReadOnly {
+		return nil, errToStatus(errFuseReadOnly)
+	}
+	r := fdn.staticFilesystem.renter
+	siaPath, err := fdn.childTurtleDexPath(name)
+	if err != nil {
+		return nil, syscall.EINVAL
+	}
+	err = r.CreateDir(siaPath, os.FileMode(mode).Perm())
+	if err != nil {
+		r.log.Printf("Unable to create fuse dir %v: %v", siaPath, err)
+		return nil, errToStatus(err)
+	}
+	childDir, err := fdn.staticDirNode.Dir(name)
+	if err != nil {
+		r.log.Printf("Unable to open newly created fuse dir %v: %v", siaPath, err)
+		return nil, errToStatus(err)
+	}
+	dirInfo, err := r.staticFileSystem.DirNodeInfo(childDir)
+	if err != nil {
+		r.log.Printf("Unable to fetch info from new fuse dir %v: %v", siaPath, err)
+		return nil, errToStatus(errors.Compose(err, childDir.Close()))
+	}
+
+	dirnode := &fuseDirnode{
+		staticDirNode:    childDir,
+		staticFilesystem: fdn.staticFilesystem,
+	}
+	attrs := fs.StableAttr{
+		Ino:  dirInfo.UID,
+		Mode: fuse.S_IFDIR,
+	}
+	out.Ino = dirInfo.UID
+	out.Mode = uint32(dirInfo.Mode()) | syscall.S_IFDIR
+	inode := fdn.NewInode(ctx, dirnode, attrs)
+	return inode, errToStatus(nil)
+}
+
+// Rename moves a file or directory within the mounted filesystem.
+func (fdn *fuseDirnode) Rename(ctx context.Context, name string, newParent fs.InodeEmbedder, newName string, flags uint32) syscall.Errno {
+	if fdn.staticFilesystem.options.ReadOnly {
+		return errToStatus(errFuseReadOnly)
+	}
+	// Flags such as RENAME_EXCHANGE and RENAME_NOREPLACE are not supported.
+	if flags != 0 {
+		return syscall.ENOTSUP
+	}
+	newParentDir, ok := newParent.(*fuseDirnode)
+	if !ok {
+		return syscall.EXDEV
+	}
+	r := fdn.staticFilesystem.renter
+	oldPath, err := fdn.childTurtleDexPath(name)
+	if err != nil {
+		return syscall.EINVAL
+	}
+	newPath, err := newParentDir.childTurtleDexPath(newName)
+	if err != nil {
+		return syscall.EINVAL
+	}
+
+	// Figure out whether a file or a directory is being renamed.
+	child := fdn.GetChild(name)
+	isDir := child != nil && child.IsDir()
+	if child == nil {
+		isDir, err = r.staticFileSystem.DirExists(oldPath)
+		if err != nil {
+			return errToStatus(err)
+		}
+	}
+	if isDir {
+		err = r.RenameDir(oldPath, newPath)
+	} else {
+		err = r.RenameFile(oldPath, newPath)
+	}
+	if err != nil {
+		r.log.Printf("Unable to rename %v to %v: %v", oldPath, newPath, err)
+		return errToStatus(err)
+	}
+
+	// Open files with staged data need to commit it to the new path.
+	if child != nil {
+		forEachFuseFile(child, func(ffn *fuseFilenode) {
+			if err := ffn.managedRebaseStaged(oldPath, newPath); err != nil {
+				r.log.Printf("Unable to move staged data of renamed fuse file from %v to %v: %v", oldPath, newPath, err)
+			}
+		})
+	}
+	return errToStatus(nil)
+}
+
+// Rmdir deletes a directory and everything it contains.
+func (fdn *fuseDirnode) Rmdir(ctx context.Context, name string) syscall.Errno {
+	if fdn.staticFilesystem.options.ReadOnly {
+		return errToStatus(errFuseReadOnly)
+	}
+	r := fdn.staticFilesystem.renter
+	siaPath, err := fdn.childTurtleDexPath(name)
+	if err != nil {
+		return syscall.EINVAL
+	}
+	child := fdn.GetChild(name)
+	err = r.DeleteDir(siaPath)
+	if err != nil {
+		r.log.Printf("Unable to delete fuse dir %v: %v", siaPath, err)
+		return errToStatus(err)
+	}
+	fdn.dropStaged(child)
+	return errToStatus(nil)
+}
+
+// Unlink deletes a file.
+func (fdn *fuseDirnode) Unlink(ctx context.Context, name string) syscall.Errno {
+	if fdn.staticFilesystem.options.ReadOnly {
+		return errToStatus(errFuseReadOnly)
+	}
+	r := fdn.staticFilesystem.renter
+	siaPath, err := fdn.childTurtleDexPath(name)
+	if err != nil {
+		return syscall.EINVAL
+	}
+	child := fdn.GetChild(name)
+	err = r.DeleteFile(siaPath)
+	if err != nil {
+		r.log.Printf("Unable to delete fuse file %v: %v", siaPath, err)
+		return errToStatus(err)
+	}
+	fdn.dropStaged(child)
+	return errToStatus(nil)
+}
+
+// dropStaged discards the staged data of the open files within the tree of a
+// deleted child. Otherwise the data would be uploaded again once the files are
+// flushed, bringing the deleted files back.
+func (fdn *fuseDirnode) dropStaged(child *fs.Inode) {
+	if child == nil {
+		return
+	}
+	forEachFuseFile(child, func(ffn *fuseFilenode) {
+		if err := ffn.managedDropStaged(); err != nil {
+			fdn.staticFilesystem.renter.log.Printf("Unable to discard staged data of deleted fuse file: %v", err)
+		}
+	})
+}
+
+// Fsync commits the staged writes of the file to the TurtleDex network.
+func (ffn *fuseFilenode) Fsync(ctx context.Context, f fs.FileHandle, flags uint32) syscall.Errno {
+	ffn.mu.Lock()
+	defer ffn.mu.Unlock()
+	err := ffn.commitStaged()
+	if err != nil {
+		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileTurtleDexPath(ffn.managedFileNode())
+		ffn.staticFilesystem.renter.log.Printf("Unable to fsync fuse file %v: %v", siaPath, err)
+		return errToStatus(err)
+	}
+	return errToStatus(nil)
+}
+
+// Release is called when the last reference to an open file handle is dropped.
+// Any remaining staged writes are committed and the staging file is removed.
+func (ffn *fuseFilenode) Release(ctx context.Context, f fs.FileHandle) syscall.Errno {
+	ffn.mu.Lock()
+	defer ffn.mu.Unlock()
+	if ffn.staged == nil {
+		return errToStatus(nil)
+	}
+	commitErr := ffn.commitStaged()
+	if commitErr != nil {
+		// Keep the staged data around, the writes would be lost otherwise.
+		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileTurtleDexPath(ffn.managedFileNode())
+		ffn.staticFilesystem.renter.log.Printf("Unable to commit staged data of fuse file %v on release: %v", siaPath, commitErr)
+		return errToStatus(commitErr)
+	}
+	closeErr := ffn.staged.Close()
+	ffn.staged = nil
+	ffn.setStagedSize(-1)
+	return errToStatus(closeErr)
+}
+
+// Setattr changes the size or mode of a file.
+func (ffn *fuseFilenode) Setattr(ctx context.Context, f fs.FileHandle, in *fuse.SetAttrIn, out *fuse.AttrOut) syscall.Errno {
+	if ffn.staticFilesystem.options.ReadOnly {
+		return errToStatus(errFuseReadOnly)
+	}
+	r := ffn.staticFilesystem.renter
+	if size, ok := in.GetSize(); ok {
+		// Without a file handle the file isn't flushed or released
+		// afterwards, so the truncation needs to be committed right away.
+		err := ffn.managedTruncate(int64(size), f == nil)
+		if err != nil {
+			siaPath := r.staticFileSystem.FileTurtleDexPath(ffn.managedFileNode())
+			r.log.Printf("Unable to truncate fuse file %v to %v bytes: %v", siaPath, size, err)
+			return errToStatus(err)
+		}
+	}
+	if mode, ok := in.GetMode(); ok {
+		err := ffn.managedFileNode().SetMode(os.FileMode(mode).Perm())
+		if err != nil {
+			siaPath := r.staticFileSystem.FileTurtleDexPath(ffn.managedFileNode())
+			r.log.Printf("Unable to set mode of fuse file %v: %v", siaPath, err)
+			return errToStatus(err)
+		}
+	}
+	return ffn.Getattr(ctx, f, out)
+}
+
+// managedTruncate changes the size of the staged file, staging the file first
+// if necessary. If commit is set and the file wasn't staged by an open handle
+// already, the truncated file is committed and unstaged again immediately.
+func (ffn *fuseFilenode) managedTruncate(size int64, commit bool) error {
+	ffn.mu.Lock()
+	defer ffn.mu.Unlock()
+	commit = commit && ffn.staged == nil
+	err := ffn.stageFile(size == 0)
+	if err != nil {
+		return err
+	}
+	err = ffn.staged.Truncate(size)
+	if err == nil {
+		ffn.setStagedSize(size)
+	}
+	if !commit {
+		return err
+	}
+
+	// Nobody is going to release the staged file, so it is discarded even if
+	// the truncation couldn't be committed.
+	if err == nil {
+		err = ffn.commitStaged()
+	}
+	err = errors.Compose(err, ffn.staged.Close())
+	ffn.staged = nil
+	ffn.setStagedSize(-1)
+	return err
+}
+
+// Write writes data to the staging file of the fuse file.
+func (ffn *fuseFilenode) Write(ctx context.Context, f fs.FileHandle, data []byte, off int64) (uint32, syscall.Errno) {
+	if ffn.staticFilesystem.options.ReadOnly {
+		return 0, errToStatus(errFuseReadOnly)
+	}
+	ffn.mu.Lock()
+	defer ffn.mu.Unlock()
+
+	// The file should have been staged when it was opened for writing.
+	if ffn.staged == nil {
+		return 0, syscall.EBADF
+	}
+	n, err := ffn.staged.WriteAt(data, off)
+	ffn.setStagedSize(ffn.staged.size)
+	if err != nil {
+		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileTurtleDexPath(ffn.managedFileNode())
+		ffn.staticFilesystem.renter.log.Printf("Error writing to offset %v in fuse file %s: %v", off, siaPath.String(), err)
+		return uint32(n), errToStatus(err)
+	}
+	return uint32(n), errToStatus(nil)
+}
@@ -0,0 +1,239 @@
+// +build linux darwin
+
+package renter
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/errors"
+)
+
+const (
+	// fuseXattrPrefix is the prefix of the extended attributes that map to
+	// the user metadata of files and directories. Only the 'user' namespace
+	// is supported since the other namespaces have a special meaning to the
+	// kernel.
+	fuseXattrPrefix = "user."
+
+	// fuseXattrCreate and fuseXattrReplace are the flags that can be passed
+	// to setxattr.
+	fuseXattrCreate  = 0x1
+	fuseXattrReplace = 0x2
+)
+
+// Ensure the dir and file nodes support extended attributes.
+//
+// NodeGetxattrer is necessary for reading the user metadata of a node.
+//
+// NodeListxattrer is necessary for listing the user metadata of a node.
+//
+// NodeRemovexattrer is necessary for removing user metadata from a node.
+//
+// NodeSetxattrer is necessary for setting the user metadata of a node.
+var _ = (fs.NodeGetxattrer)((*fuseDirnode)(nil))
+var _ = (fs.NodeListxattrer)((*fuseDirnode)(nil))
+var _ = (fs.NodeRemovexattrer)((*fuseDirnode)(nil))
+var _ = (fs.NodeSetxattrer)((*fuseDirnode)(nil))
+var _ = (fs.NodeGetxattrer)((*fuseFilenode)(nil))
+var _ = (fs.NodeListxattrer)((*fuseFilenode)(nil))
+var _ = (fs.NodeRemovexattrer)((*fuseFilenode)(nil))
+var _ = (fs.NodeSetxattrer)((*fuseFilenode)(nil))
+
+// Getxattr returns the value of a user metadata key of the directory.
+func (fdn *fuseDirnode) Getxattr(ctx context.Context, attr string, dest []byte) (uint32, syscall.Errno) {
+	md, err := fdn.staticDirNode.Metadata()
+	if err != nil {
+		return 0, errToStatus(err)
+	}
+	return getXattr(md.UserMetadata, attr, dest)
+}
+
+// Getxattr returns the value of a user metadata key of the file.
+func (ffn *fuseFilenode) Getxattr(ctx context.Context, attr string, dest []byte) (uint32, syscall.Errno) {
+	return getXattr(ffn.managedFileNode().UserMetadata(), attr, dest)
+}
+
+// Listxattr lists the user metadata keys of the directory.
+func (fdn *fuseDirnode) Listxattr(ctx context.Context, dest []byte) (uint32, syscall.Errno) {
+	md, err := fdn.staticDirNode.Metadata()
+	if err != nil {
+		return 0, errToStatus(err)
+	}
+	return listXattr(md.UserMetadata, dest)
+}
+
+// Listxattr lists the user metadata keys of the file.
+func (ffn *fuseFilenode) Listxattr(ctx context.Context, dest []byte) (uint32, syscall.Errno) {
+	return listXattr(ffn.managedFileNode().UserMetadata(), dest)
+}
+
+// Removexattr removes a user metadata key from the directory.
+func (fdn *fuseDirnode) Removexattr(ctx context.Context, attr string) syscall.Errno {
+	md, err := fdn.staticDirNode.Metadata()
+	if err != nil {
+		return errToStatus(err)
+	}
+	key, errno := removeXattrKey(fdn.staticFilesystem, md.UserMetadata, attr)
+	if errno != 0 {
+		return errno
+	}
+	r := fdn.staticFilesystem.renter
+	siaPath := r.staticFileSystem.DirTurtleDexPath(fdn.staticDirNode)
+	err = r.staticFileSystem.SetDirUserMetadata(siaPath, nil, []string{key})
+	if err != nil {
+		r.log.Printf("Unable to remove xattr %v of fuse dir %v: %v", attr, siaPath, err)
+	}
+	return xattrErrToStatus(err)
+}
+
+// Removexattr removes a user metadata key from the file.
+func (ffn *fuseFilenode) Removexattr(ctx context.Context, attr string) syscall.Errno {
+	fileNode := ffn.managedFileNode()
+	key, errno := removeXattrKey(ffn.staticFilesystem, fileNode.UserMetadata(), attr)
+	if errno != 0 {
+		return errno
+	}
+	err := fileNode.SetUserMetadata(nil, []string{key})
+	if err != nil {
+		r := ffn.staticFilesystem.renter
+		r.log.Printf("Unable to remove xattr %v of fuse file %v: %v", attr, r.staticFileSystem.FileTurtleDexPath(fileNode), err)
+	}
+	return xattrErrToStatus(err)
+}
+
+// Setxattr sets a user metadata key of the directory.
+func (fdn *fuseDirnode) Setxattr(ctx context.Context, attr string, data []byte, flags uint32) syscall.Errno {
+	md, err := fdn.staticDirNode.Metadata()
+	if err != nil {
+		return errToStatus(err)
+	}
+	key, errno := setXattrKey(fdn.staticFilesystem, md.UserMetadata, attr, flags)
+	if errno != 0 {
+		return errno
+	}
+	r := fdn.staticFilesystem.renter
+	siaPath := r.staticFileSystem.DirTurtleDexPath(fdn.staticDirNode)
+	err = r.staticFileSystem.SetDirUserMetadata(siaPath, map[string]string{key: string(data)}, nil)
+	if err != nil {
+		r.log.Printf("Unable to set xattr %v of fuse dir %v: %v", attr, siaPath, err)
+	}
+	return xattrErrToStatus(err)
+}
+
+// Setxattr sets a user metadata key of the file.
+func (ffn *fuseFilenode) Setxattr(ctx context.Context, attr string, data []byte, flags uint32) syscall.Errno {
+	fileNode := ffn.managedFileNode()
+	key, errno := setXattrKey(ffn.staticFilesystem, fileNode.UserMetadata(), attr, flags)
+	if errno != 0 {
+		return errno
+	}
+	err := fileNode.SetUserMetadata(map[string]string{key: string(data)}, nil)
+	if err != nil {
+		r := ffn.staticFilesystem.renter
+		r.log.Printf("Unable to set xattr %v of fuse file %v: %v", attr, r.staticFileSystem.FileTurtleDexPath(fileNode), err)
+	}
+	return xattrErrToStatus(err)
+}
+
+// copyXattrData copies data into dest and returns the size of data. If dest
+// is too small, ERANGE is returned unless dest is empty which is how callers
+// query the required size.
+func copyXattrData(data, dest []byte) (uint32, syscall.Errno) {
+	if len(dest) == 0 {
+		return uint32(len(data)), 0
+	}
+	if len(dest) < len(data) {
+		return uint32(len(data)), syscall.ERANGE
+	}
+	return uint32(copy(dest, data)), 0
+}
+
+// getXattr looks up the value of an extended attribute in the provided user
+// metadata.
+func getXattr(md map[string]string, attr string, dest []byte) (uint32, syscall.Errno) {
+	if !strings.HasPrefix(attr, fuseXattrPrefix) {
+		return 0, syscall.Errno(fuse.ENOATTR)
+	}
+	value, exists := md[strings.TrimPrefix(attr, fuseXattrPrefix)]
+	if !exists {
+		return 0, syscall.Errno(fuse.ENOATTR)
+	}
+	return copyXattrData([]byte(value), dest)
+}
+
+// listXattr returns the names of the extended attributes for the provided
+// user metadata as a list of null terminated strings.
+func listXattr(md map[string]string, dest []byte) (uint32, syscall.Errno) {
+	keys := make([]string, 0, len(md))
+	for key := range md {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var data []byte
+	for _, key := range keys {
+		data = append(data, fuseXattrPrefix+key...)
+		data = append(data, 0)
+	}
+	return copyXattrData(data, dest)
+}
+
+// removeXattrKey returns the user metadata key of the extended attribute that
+// is about to be removed.
+func removeXattrKey(ffs *fuseFS, md map[string]string, attr string) (string, syscall.Errno) {
+	if ffs.options.ReadOnly {
+		return "", errToStatus(errFuseReadOnly)
+	}
+	if !strings.HasPrefix(attr, fuseXattrPrefix) {
+		return "", syscall.Errno(fuse.ENOATTR)
+	}
+	key := strings.TrimPrefix(attr, fuseXattrPrefix)
+	if _, exists := md[key]; !exists {
+		return "", syscall.Errno(fuse.ENOATTR)
+	}
+	return key, 0
+}
+
+// setXattrKey returns the user metadata key of the extended attribute that is
+// about to be set, taking the setxattr flags into account.
+func setXattrKey(ffs *fuseFS, md map[string]string, attr string, flags uint32) (string, syscall.Errno) {
+	if ffs.options.ReadOnly {
+		return "", errToStatus(errFuseReadOnly)
+	}
+	if !strings.HasPrefix(attr, fuseXattrPrefix) {
+		return "", syscall.ENOTSUP
+	}
+	key := strings.TrimPrefix(attr, fuseXattrPrefix)
+	_, exists := md[key]
+	if flags&fuseXattrCreate != 0 && exists {
+		return "", syscall.EEXIST
+	}
+	if flags&fuseXattrReplace != 0 && !exists {
+		return "", syscall.Errno(fuse.ENOATTR)
+	}
+	return key, 0
+}
+
+// xattrErrToStatus converts an error returned when updating the user metadata
+// to the errno expected by the callers of setxattr and removexattr.
+func xattrErrToStatus(err error) syscall.Errno {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Contains(err, modules.ErrEmptyUserMetadataKey),
+		errors.Contains(err, modules.ErrInvalidUserMetadataKey):
+		return syscall.EINVAL
+	case errors.Contains(err, modules.ErrUserMetadataKeyTooLong):
+		return syscall.ERANGE
+	case errors.Contains(err, modules.ErrUserMetadataValueTooLong):
+		return syscall.E2BIG
+	case errors.Contains(err, modules.ErrTooManyUserMetadataKeys):
+		return syscall.ENOSPC
+	}
+	return errToStatus(err)
+}
@@ -0,0 +1,111 @@
+package renter
+
+import (
+	"context"
+	"time"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/types"
+	"github.com/turtledex/errors"
+	"github.com/turtledex/fastrand"
+)
+
+// Enforce that the Renter satisfies the modules.HostThroughputSampler
+// interface.
+var _ modules.HostThroughputSampler = (*Renter)(nil)
+
+// SampleThroughput measures the throughput of a host the renter has a contract
+// with. The read throughput is sampled by reading a small amount of the data
+// stored on the host with the worker's RHP3 read job, which is paid for from
+// the worker's ephemeral account. The write throughput is sampled by uploading
+// a sector which is trimmed from the contract again in the same revision, so
+// only the bandwidth is paid for from the contract. To limit the cost, a host
+// is sampled at most once per throughputSampleInterval. Otherwise a throughput
+// of 0 is returned.
+func (r *Renter) SampleThroughput(hostKey types.TurtleDexPublicKey) (readBPS, writeBPS uint64, err error) {
+	if err := r.tg.Add(); err != nil {
+		return 0, 0, err
+	}
+	defer r.tg.Done()
+	w, err := r.staticWorkerPool.callWorker(hostKey)
+	if err != nil {
+		return 0, 0, errors.AddContext(err, "unable to get worker for host")
+	}
+	contract, ok := r.hostContractor.ContractByPublicKey(hostKey)
+	if !ok {
+		return 0, 0, nil
+	}
+
+	// Check whether the host was sampled recently.
+	w.mu.Lock()
+	if time.Since(w.throughputSampleTime) < throughputSampleInterval {
+		w.mu.Unlock()
+		return 0, 0, nil
+	}
+	w.throughputSampleTime = time.Now()
+	w.mu.Unlock()
+
+	// Only sample the read throughput if the contract contains enough data.
+	if contract.Size() >= throughputSampleSize {
+		readBPS, err = r.managedSampleReadThroughput(w, contract)
+	}
+	// Only sample the write throughput if the contract is good for upload.
+	if contract.Utility.GoodForUpload {
+		var writeErr error
+		writeBPS, writeErr = r.managedSampleWriteThroughput(w)
+		err = errors.Compose(err, writeErr)
+	}
+	return readBPS, writeBPS, err
+}
+
+// managedSampleReadThroughput reads throughputSampleSize bytes from the
+// worker's host and returns the read throughput.
+func (r *Renter) managedSampleReadThroughput(w *worker, contract modules.RenterContract) (uint64, error) {
+	// Read from a random sector aligned offset to avoid hitting the same
+	// cached data every time.
+	sectors := contract.Size() / modules.SectorSize
+	offset := uint64(0)
+	if sectors > 1 {
+		offset = fastrand.Uint64n(sectors) * modules.SectorSize
+	}
+	ctx, cancel := context.WithTimeout(r.tg.StopCtx(), throughputSampleTimeout)
+	defer cancel()
+	start := time.Now()
+	_, err := w.ReadOffset(ctx, offset, throughputSampleSize)
+	if err != nil {
+		return 0, errors.AddContext(err, "unable to sample read throughput")
+	}
+	return throughputBPS(throughputSampleSize, time.Since(start)), nil
+}
+
+// managedSampleWriteThroughput uploads a sector of random data to the worker's
+// host without storing it and returns the write throughput. Hosts only accept
+// appends of full sectors.
+func (r *Renter) managedSampleWriteThroughput(w *worker) (_ uint64, err error) {
+	sess, err := r.hostContractor.Session(w.staticHostPubKey, r.tg.StopChan())
+	if err != nil {
+		return 0, errors.AddContext(err, "unable to get host session to sample write throughput")
+	}
+	defer func() {
+		err = errors.Compose(err, sess.Close())
+	}()
+	err = checkUploadGouging(r.hostContractor.Allowance(), sess.HostSettings())
+	if err != nil {
+		return 0, errors.AddContext(err, "write throughput sample blocked because potential price gouging was detected")
+	}
+	start := time.Now()
+	err = sess.UploadSample(fastrand.Bytes(int(modules.SectorSize)))
+	if err != nil {
+		return 0, errors.AddContext(err, "unable to sample write throughput")
+	}
+	return throughputBPS(modules.SectorSize, time.Since(start)), nil
+}
+
+// throughputBPS converts the time it took to transfer n bytes into a
+// throughput in bytes per second.
+func throughputBPS(n uint64, d time.Duration) uint64 {
+	if d <= 0 {
+		return 0
+	}
+	return uint64(float64(n) / d.Seconds())
+}
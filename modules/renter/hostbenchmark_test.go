@@ -0,0 +1,25 @@
+package renter
+
+import (
+	"testing"
+	"time"
+)
+
+// TestThroughputBPS is a unit test for throughputBPS.
+func TestThroughputBPS(t *testing.T) {
+	tests := []struct {
+		n   uint64
+		d   time.Duration
+		bps uint64
+	}{
+		{1 << 20, time.Second, 1 << 20},
+		{1 << 20, 2 * time.Second, 1 << 19},
+		{1 << 16, 500 * time.Millisecond, 1 << 17},
+		{1 << 20, 0, 0},
+	}
+	for _, test := range tests {
+		if bps := throughputBPS(test.n, test.d); bps != test.bps {
+			t.Errorf("throughputBPS(%v, %v) should be %v but was %v", test.n, test.d, test.bps, bps)
+		}
+	}
+}
@@ -73,6 +73,10 @@ type HostDB struct {
 	allowance  modules.Allowance
 	weightFunc hosttree.WeightFunc
 
+	// scoringPolicy determines how the adjustments of a host's score are
+	// weighed against each other by the weightFunc.
+	scoringPolicy modules.HostScoringPolicy
+
 	// txnFees are the most recent fees used in the score estimation. It is
 	// used to determine if the transaction fees have changed enough to warrant
 	// rebuilding the hosttree with an updated weight function.
@@ -103,6 +107,22 @@ type HostDB struct {
 	filteredHosts      map[string]types.TurtleDexPublicKey
 	filterMode         modules.FilterMode
 
+	// diversityConstraints are enforced when selecting random hosts, using
+	// the networks and locations of the hosts from the staticIPDatabase.
+	diversityConstraints modules.HostDiversityConstraints
+	staticIPDatabase     *ipDatabase
+
+	// If benchmarking is enabled, scans measure the performance of hosts. The
+	// throughputSampler is used to take paid samples from hosts the renter
+	// has a contract with.
+	benchmarking      bool
+	throughputSampler modules.HostThroughputSampler
+
+	// hostGroups maps the names of host groups to the public keys of their
+	// hosts. Contract maintenance forms contracts with the hosts of a group
+	// until the group's target of the allowance is reached.
+	hostGroups map[string][]types.TurtleDexPublicKey
+
 	blockHeight types.BlockHeight
 	lastChange  modules.ConsensusChangeID
 }
@@ -230,14 +250,16 @@ func hostdbBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 		staticTpool: tpool,
 
 		filteredHosts:  make(map[string]types.TurtleDexPublicKey),
+		hostGroups:     make(map[string][]types.TurtleDexPublicKey),
 		knownContracts: make(map[string]contractInfo),
 		scanMap:        make(map[string]struct{}),
 		staticAlerter:  modules.NewAlerter("hostdb"),
 	}
 
-	// Set the allowance, txnFees and hostweight function.
+	// Set the allowance, txnFees, scoring policy and hostweight function.
 	hdb.allowance = modules.DefaultAllowance
 	_, hdb.txnFees = hdb.staticTpool.FeeEstimation()
+	hdb.scoringPolicy = modules.DefaultHostScoringPolicy
 	hdb.weightFunc = hdb.managedCalculateHostWeightFn(hdb.allowance)
 
 	// Create the persist directory if it does not yet exist.
@@ -264,6 +286,14 @@ func hostdbBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 		return nil, err
 	}
 
+	// Load the IP database. A broken database shouldn't prevent the hostdb
+	// from starting, the hosts just can't be located.
+	hdb.staticIPDatabase, err = loadIPDatabase(filepath.Join(persistDir, ipDatabaseFilename))
+	if err != nil {
+		hdb.staticLog.Println("WARN: unable to load the IP database:", err)
+		hdb.staticIPDatabase = newIPDatabase()
+	}
+
 	// The host tree is used to manage hosts and query them at random. The
 	// filteredTree is used when whitelist or blacklist is enabled
 	hdb.staticHostTree = hosttree.New(hdb.weightFunc, deps.Resolver())
@@ -638,6 +668,29 @@ func (hdb *HostDB) InitialScanComplete() (complete bool, err error) {
 	return
 }
 
+// Benchmarking returns whether the hostdb benchmarks hosts while scanning them.
+func (hdb *HostDB) Benchmarking() (bool, error) {
+	if err := hdb.tg.Add(); err != nil {
+		return false, errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+	hdb.mu.RLock()
+	defer hdb.mu.RUnlock()
+	return hdb.benchmarking, nil
+}
+
+// DiversityConstraints returns the constraints RandomHosts enforces to spread
+// the selected hosts across networks and locations.
+func (hdb *HostDB) DiversityConstraints() (modules.HostDiversityConstraints, error) {
+	if err := hdb.tg.Add(); err != nil {
+		return modules.HostDiversityConstraints{}, errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+	hdb.mu.RLock()
+	defer hdb.mu.RUnlock()
+	return hdb.diversityConstraints, nil
+}
+
 // IPViolationsCheck returns a boolean indicating if the IP violation check is
 // enabled or not.
 func (hdb *HostDB) IPViolationsCheck() (bool, error) {
@@ -675,6 +728,75 @@ func (hdb *HostDB) SetAllowance(allowance modules.Allowance) error {
 	return hdb.managedSetWeightFunction(wf)
 }
 
+// ScoringPolicy returns the policy the hostdb uses to score hosts.
+func (hdb *HostDB) ScoringPolicy() (modules.HostScoringPolicy, error) {
+	if err := hdb.tg.Add(); err != nil {
+		return modules.HostScoringPolicy{}, errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+	hdb.mu.RLock()
+	defer hdb.mu.RUnlock()
+	return hdb.scoringPolicy, nil
+}
+
+// SetScoringPolicy updates the policy used by the hostdb for weighing hosts by
+// updating the host weight function. Like SetAllowance, it will completely
+// rebuild the hosttree.
+func (hdb *HostDB) SetScoringPolicy(policy modules.HostScoringPolicy) error {
+	if err := hdb.tg.Add(); err != nil {
+		return errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+	if err := policy.Validate(); err != nil {
+		return errors.AddContext(err, "invalid scoring policy")
+	}
+
+	// Update the policy.
+	hdb.mu.Lock()
+	hdb.scoringPolicy = policy
+	allowance := hdb.allowance
+	hdb.mu.Unlock()
+
+	// Update the weight function and persist the new policy.
+	wf := hdb.managedCalculateHostWeightFn(allowance)
+	err := hdb.managedSetWeightFunction(wf)
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	return errors.Compose(err, hdb.saveSync())
+}
+
+// SetBenchmarking enables or disables benchmarking hosts while scanning them.
+// Benchmarks that were taken before are kept and still affect the score of
+// the hosts.
+func (hdb *HostDB) SetBenchmarking(enabled bool) error {
+	if err := hdb.tg.Add(); err != nil {
+		return errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	hdb.benchmarking = enabled
+	return hdb.saveSync()
+}
+
+// SetDiversityConstraints sets the constraints RandomHosts enforces to spread
+// the selected hosts across networks and locations.
+func (hdb *HostDB) SetDiversityConstraints(constraints modules.HostDiversityConstraints) error {
+	if err := hdb.tg.Add(); err != nil {
+		return errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+	if err := constraints.Validate(); err != nil {
+		return errors.AddContext(err, "invalid diversity constraints")
+	}
+
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	hdb.diversityConstraints = constraints.Normalized()
+	return hdb.saveSync()
+}
+
 // SetIPViolationCheck enables or disables the IP violation check. If disabled,
 // CheckForIPViolations won't return bad hosts and RandomHosts will return the
 // address blacklist.
@@ -690,6 +812,14 @@ func (hdb *HostDB) SetIPViolationCheck(enabled bool) error {
 	return nil
 }
 
+// SetThroughputSampler sets the sampler used to measure the throughput of hosts
+// the renter has a contract with.
+func (hdb *HostDB) SetThroughputSampler(sampler modules.HostThroughputSampler) {
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	hdb.throughputSampler = sampler
+}
+
 // UpdateContracts rebuilds the knownContracts of the HostBD using the provided
 // contracts.
 func (hdb *HostDB) UpdateContracts(contracts []modules.RenterContract) error {
@@ -50,7 +50,11 @@ func bareHostDB() *HostDB {
 	hdb := &HostDB{
 		allowance:      modules.DefaultAllowance,
 		staticLog:      logger,
+		hostGroups:     make(map[string][]types.TurtleDexPublicKey),
 		knownContracts: make(map[string]contractInfo),
+		scoringPolicy:  modules.DefaultHostScoringPolicy,
+
+		staticIPDatabase: newIPDatabase(),
 	}
 	hdb.weightFunc = hdb.managedCalculateHostWeightFn(hdb.allowance)
 	hdb.staticHostTree = hosttree.New(hdb.weightFunc, &modules.ProductionResolver{})
@@ -0,0 +1,89 @@
+package hostdb
+
+import (
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/hostdb/hosttree"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+// HostGroups returns the names of the host groups mapped to the public keys of
+// their hosts.
+func (hdb *HostDB) HostGroups() (map[string][]types.TurtleDexPublicKey, error) {
+	if err := hdb.tg.Add(); err != nil {
+		return nil, errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+	hdb.mu.RLock()
+	defer hdb.mu.RUnlock()
+	groups := make(map[string][]types.TurtleDexPublicKey, len(hdb.hostGroups))
+	for name, hosts := range hdb.hostGroups {
+		groups[name] = append([]types.TurtleDexPublicKey(nil), hosts...)
+	}
+	return groups, nil
+}
+
+// SetHostGroup sets the hosts of the host group with the provided name. An
+// empty list of hosts removes the group. Hosts don't need to be known to the
+// hostdb to be added to a group.
+func (hdb *HostDB) SetHostGroup(name string, hosts []types.TurtleDexPublicKey) error {
+	if err := hdb.tg.Add(); err != nil {
+		return errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+	if err := modules.ValidateHostGroupName(name); err != nil {
+		return err
+	}
+
+	// Remove duplicates.
+	var members []types.TurtleDexPublicKey
+	seen := make(map[string]struct{})
+	for _, pk := range hosts {
+		if _, exists := seen[pk.String()]; exists {
+			continue
+		}
+		seen[pk.String()] = struct{}{}
+		members = append(members, pk)
+	}
+
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	if len(members) == 0 {
+		delete(hdb.hostGroups, name)
+	} else {
+		hdb.hostGroups[name] = members
+	}
+	return hdb.saveSync()
+}
+
+// RandomHostsInGroup works as RandomHosts but only selects hosts of the host
+// group with the provided name. Hosts of the group which are filtered out by
+// the filter mode are not selected.
+func (hdb *HostDB) RandomHostsInGroup(name string, n int, blacklist, addressBlacklist []types.TurtleDexPublicKey) ([]modules.HostDBEntry, error) {
+	hdb.mu.RLock()
+	defer hdb.mu.RUnlock()
+	if !hdb.initialScanComplete {
+		return []modules.HostDBEntry{}, ErrInitialScanIncomplete
+	}
+
+	// Create a temporary hosttree from the hosts of the group.
+	ht := hosttree.New(hdb.weightFunc, hdb.staticDeps.Resolver())
+	var insertErrs error
+	for _, pk := range hdb.hostGroups[name] {
+		host, exists := hdb.staticFilteredTree.Select(pk)
+		if !exists {
+			continue
+		}
+		if err := ht.Insert(host); err != nil {
+			insertErrs = errors.Compose(insertErrs, err)
+		}
+	}
+
+	// Select hosts from the temporary hosttree.
+	df := hdb.diversityFilter(addressBlacklist, hdb.allowance.Hosts)
+	if hdb.disableIPViolationCheck {
+		return ht.SelectRandomDiverse(n, blacklist, nil, df), insertErrs
+	}
+	return ht.SelectRandomDiverse(n, blacklist, addressBlacklist, df), insertErrs
+}
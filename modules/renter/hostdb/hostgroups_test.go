@@ -0,0 +1,88 @@
+package hostdb
+
+import (
+	"testing"
+
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+// TestHostGroups tests setting host groups and selecting random hosts from
+// them.
+func TestHostGroups(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	hdbt, err := newHDBTesterDeps(t.Name(), &disableScanLoopDeps{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pks []types.TurtleDexPublicKey
+	for i := 0; i < 10; i++ {
+		entry := makeHostDBEntry()
+		if err := hdbt.hdb.staticFilteredTree.Insert(entry); err != nil {
+			t.Fatal(err)
+		}
+		pks = append(pks, entry.PublicKey)
+	}
+
+	// Invalid names should be rejected.
+	if err := hdbt.hdb.SetHostGroup("Trusted Partners", pks[:1]); err == nil {
+		t.Fatal("invalid group name should be rejected")
+	}
+
+	// Add 3 hosts to a group, duplicates should be ignored.
+	if err := hdbt.hdb.SetHostGroup("trusted", append(pks[:3:3], pks[0])); err != nil {
+		t.Fatal(err)
+	}
+	groups, err := hdbt.hdb.HostGroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 || len(groups["trusted"]) != 3 {
+		t.Fatal("unexpected groups", groups)
+	}
+
+	// Only hosts of the group should be selected.
+	members := map[string]struct{}{}
+	for _, pk := range pks[:3] {
+		members[pk.String()] = struct{}{}
+	}
+	hosts, err := hdbt.hdb.RandomHostsInGroup("trusted", 10, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hosts) != 3 {
+		t.Fatal("expected 3 hosts but got", len(hosts))
+	}
+	for _, host := range hosts {
+		if _, ok := members[host.PublicKey.String()]; !ok {
+			t.Fatal("host outside of group was selected")
+		}
+	}
+
+	// Blacklisted hosts shouldn't be selected.
+	hosts, err = hdbt.hdb.RandomHostsInGroup("trusted", 10, pks[:1], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hosts) != 2 {
+		t.Fatal("expected 2 hosts but got", len(hosts))
+	}
+
+	// Unknown groups don't contain any hosts.
+	hosts, err = hdbt.hdb.RandomHostsInGroup("unknown", 10, nil, nil)
+	if err != nil || len(hosts) != 0 {
+		t.Fatal("unknown group shouldn't contain hosts", len(hosts), err)
+	}
+
+	// Setting an empty group removes it.
+	if err := hdbt.hdb.SetHostGroup("trusted", nil); err != nil {
+		t.Fatal(err)
+	}
+	groups, err = hdbt.hdb.HostGroups()
+	if err != nil || len(groups) != 0 {
+		t.Fatal("group wasn't removed", groups, err)
+	}
+}
@@ -0,0 +1,70 @@
+package hosttree
+
+import (
+	"strings"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+)
+
+// DiversityFilter filters hosts which would violate the renter's host
+// diversity constraints if they were selected. A nil DiversityFilter doesn't
+// filter any hosts.
+type DiversityFilter struct {
+	asnCounts         map[uint32]int
+	maxHostsPerASN    int
+	excludedCountries map[string]struct{}
+	requiredRegions   map[string]struct{}
+}
+
+// NewDiversityFilter creates a filter that enforces the constraints for a
+// renter that wants to form contracts with totalHosts hosts.
+func NewDiversityFilter(constraints modules.HostDiversityConstraints, totalHosts uint64) *DiversityFilter {
+	constraints = constraints.Normalized()
+	df := &DiversityFilter{
+		asnCounts:         make(map[uint32]int),
+		excludedCountries: make(map[string]struct{}),
+		requiredRegions:   make(map[string]struct{}),
+	}
+	// Every ASN is allowed at least one host, even if the percentage of the
+	// total is less than that.
+	if constraints.MaxASNPercentage > 0 {
+		df.maxHostsPerASN = int(constraints.MaxASNPercentage / 100 * float64(totalHosts))
+		if df.maxHostsPerASN < 1 {
+			df.maxHostsPerASN = 1
+		}
+	}
+	for _, country := range constraints.ExcludedCountries {
+		df.excludedCountries[country] = struct{}{}
+	}
+	for _, region := range constraints.RequiredRegions {
+		df.requiredRegions[region] = struct{}{}
+	}
+	return df
+}
+
+// Add adds a host to the filter which counts towards the limit of hosts per
+// ASN. Hosts with an unknown ASN are not counted.
+func (df *DiversityFilter) Add(entry modules.HostDBEntry) {
+	if df == nil || entry.ASN == 0 {
+		return
+	}
+	df.asnCounts[entry.ASN]++
+}
+
+// Filtered checks if a host is located in an excluded country, outside of the
+// required regions or belongs to an ASN which already reached its limit of
+// hosts.
+func (df *DiversityFilter) Filtered(entry modules.HostDBEntry) bool {
+	if df == nil {
+		return false
+	}
+	if _, excluded := df.excludedCountries[strings.ToUpper(entry.Country)]; excluded {
+		return true
+	}
+	if len(df.requiredRegions) > 0 {
+		if _, required := df.requiredRegions[strings.ToUpper(entry.Region)]; !required {
+			return true
+		}
+	}
+	return df.maxHostsPerASN > 0 && entry.ASN != 0 && df.asnCounts[entry.ASN] >= df.maxHostsPerASN
+}
@@ -0,0 +1,127 @@
+package hosttree
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+// TestDiversityFilter is a unit test for the DiversityFilter.
+func TestDiversityFilter(t *testing.T) {
+	host := func(asn uint32, country, region string) modules.HostDBEntry {
+		return modules.HostDBEntry{ASN: asn, Country: country, Region: region}
+	}
+
+	// A nil filter doesn't filter anything.
+	var df *DiversityFilter
+	df.Add(host(1, "DE", "EU"))
+	if df.Filtered(host(1, "DE", "EU")) {
+		t.Fatal("nil filter shouldn't filter hosts")
+	}
+
+	// 25% of 8 hosts allows for 2 hosts per ASN.
+	df = NewDiversityFilter(modules.HostDiversityConstraints{
+		MaxASNPercentage:  25,
+		RequiredRegions:   []string{"eu", "NA"},
+		ExcludedCountries: []string{"fr"},
+	}, 8)
+	if df.Filtered(host(1, "DE", "EU")) || df.Filtered(host(2, "us", "na")) {
+		t.Fatal("hosts in required regions shouldn't be filtered")
+	}
+	if !df.Filtered(host(1, "FR", "EU")) {
+		t.Fatal("host in excluded country should be filtered")
+	}
+	if !df.Filtered(host(1, "JP", "AS")) || !df.Filtered(host(1, "", "")) {
+		t.Fatal("hosts outside of required regions should be filtered")
+	}
+	df.Add(host(1, "DE", "EU"))
+	if df.Filtered(host(1, "DE", "EU")) {
+		t.Fatal("ASN shouldn't have reached its limit yet")
+	}
+	df.Add(host(1, "DE", "EU"))
+	if !df.Filtered(host(1, "DE", "EU")) {
+		t.Fatal("ASN should have reached its limit")
+	}
+	if df.Filtered(host(0, "DE", "EU")) {
+		t.Fatal("hosts with unknown ASN shouldn't be limited")
+	}
+
+	// Every ASN is allowed at least one host.
+	df = NewDiversityFilter(modules.HostDiversityConstraints{MaxASNPercentage: 1}, 10)
+	if df.Filtered(host(1, "", "")) {
+		t.Fatal("first host of ASN shouldn't be filtered")
+	}
+	df.Add(host(1, "", ""))
+	if !df.Filtered(host(1, "", "")) {
+		t.Fatal("second host of ASN should be filtered")
+	}
+}
+
+// TestSelectRandomDiverse checks that SelectRandomDiverse enforces the
+// diversity constraints.
+func TestSelectRandomDiverse(t *testing.T) {
+	tree := New(func(dbe modules.HostDBEntry) ScoreBreakdown {
+		// All entries have the same weight.
+		return newCustomScoreBreakdown(types.NewCurrency64(uint64(10)))
+	}, modules.ProductionResolver{})
+
+	// Insert 10 hosts of ASN 1 in the EU and 10 hosts of ASN 2 in NA. Each
+	// host has its own subnet.
+	var asn1 []types.TurtleDexPublicKey
+	for i := 0; i < 20; i++ {
+		entry := makeHostDBEntry()
+		entry.NetAddress = modules.NetAddress(fmt.Sprintf("10.0.%v.1:1234", i))
+		entry.ASN, entry.Country, entry.Region = 1, "DE", "EU"
+		if i%2 == 1 {
+			entry.ASN, entry.Country, entry.Region = 2, "US", "NA"
+		} else {
+			asn1 = append(asn1, entry.PublicKey)
+		}
+		if err := tree.Insert(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Only hosts in the EU should be returned.
+	df := NewDiversityFilter(modules.HostDiversityConstraints{RequiredRegions: []string{"EU"}}, 20)
+	hosts := tree.SelectRandomDiverse(20, nil, nil, df)
+	if len(hosts) != 10 {
+		t.Fatal("expected 10 hosts but got", len(hosts))
+	}
+	for _, host := range hosts {
+		if host.Region != "EU" {
+			t.Fatal("host outside of required region was selected")
+		}
+	}
+
+	// With a limit of 20% of 10 hosts, only 2 hosts per ASN should be
+	// returned.
+	df = NewDiversityFilter(modules.HostDiversityConstraints{MaxASNPercentage: 20}, 10)
+	hosts = tree.SelectRandomDiverse(20, nil, nil, df)
+	if len(hosts) != 4 {
+		t.Fatal("expected 4 hosts but got", len(hosts))
+	}
+
+	// Hosts which were added to the filter before count towards the limit.
+	df = NewDiversityFilter(modules.HostDiversityConstraints{MaxASNPercentage: 20}, 10)
+	for _, pk := range asn1[:2] {
+		entry, _ := tree.Select(pk)
+		df.Add(entry)
+	}
+	hosts = tree.SelectRandomDiverse(20, asn1[:2], nil, df)
+	if len(hosts) != 2 {
+		t.Fatal("expected 2 hosts but got", len(hosts))
+	}
+	for _, host := range hosts {
+		if host.ASN != 2 {
+			t.Fatal("host of ASN at its limit was selected")
+		}
+	}
+
+	// The tree should still contain all hosts.
+	if len(tree.All()) != 20 {
+		t.Fatal("hosts missing from tree", len(tree.All()))
+	}
+}
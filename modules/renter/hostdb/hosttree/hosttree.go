@@ -306,6 +306,12 @@ func (ht *HostTree) Select(spk types.TurtleDexPublicKey) (modules.HostDBEntry, b
 // intentionally being given a low score to indicate that the host should not be
 // used.
 func (ht *HostTree) SelectRandom(n int, blacklist, addressBlacklist []types.TurtleDexPublicKey) []modules.HostDBEntry {
+	return ht.SelectRandomDiverse(n, blacklist, addressBlacklist, nil)
+}
+
+// SelectRandomDiverse works like SelectRandom but also ignores hosts which are
+// filtered by the DiversityFilter. Selected hosts are added to the filter.
+func (ht *HostTree) SelectRandomDiverse(n int, blacklist, addressBlacklist []types.TurtleDexPublicKey, df *DiversityFilter) []modules.HostDBEntry {
 	ht.mu.Lock()
 	defer ht.mu.Unlock()
 
@@ -349,14 +355,16 @@ func (ht *HostTree) SelectRandom(n int, blacklist, addressBlacklist []types.Turt
 			len(node.entry.ScanHistory) > 0 &&
 			node.entry.ScanHistory[len(node.entry.ScanHistory)-1].Success &&
 			!filter.Filtered(node.entry.NetAddress) &&
+			!df.Filtered(node.entry.HostDBEntry) &&
 			node.entry.weight.Cmp(weightOne) > 0 {
 			// The host must be online and accepting contracts to be returned
 			// by the random function. It also has to pass the addressFilter
-			// check.
+			// and diversity checks.
 			hosts = append(hosts, node.entry.HostDBEntry)
 
-			// If the host passed the filter, we add it to the filter.
+			// If the host passed the filters, we add it to the filters.
 			filter.Add(node.entry.NetAddress)
+			df.Add(node.entry.HostDBEntry)
 		}
 
 		removedEntries = append(removedEntries, node.entry)
@@ -24,10 +24,15 @@ type HostAdjustments struct {
 	CollateralAdjustment       float64
 	DurationAdjustment         float64
 	InteractionAdjustment      float64
+	PerformanceAdjustment      float64
 	PriceAdjustment            float64
 	StorageRemainingAdjustment float64
 	UptimeAdjustment           float64
 	VersionAdjustment          float64
+
+	// ScoringPolicy is the name of the policy that produced the
+	// adjustments.
+	ScoringPolicy string
 }
 
 var (
@@ -68,6 +73,7 @@ func (h HostAdjustments) HostScoreBreakdown(totalScore types.Currency, ignoreAge
 	return modules.HostScoreBreakdown{
 		Score:          score,
 		ConversionRate: conversionRate(score, totalScore),
+		ScoringPolicy:  h.ScoringPolicy,
 
 		AcceptContractAdjustment:   h.AcceptContractAdjustment,
 		AgeAdjustment:              h.AgeAdjustment,
@@ -76,6 +82,7 @@ func (h HostAdjustments) HostScoreBreakdown(totalScore types.Currency, ignoreAge
 		CollateralAdjustment:       h.CollateralAdjustment,
 		DurationAdjustment:         h.DurationAdjustment,
 		InteractionAdjustment:      h.InteractionAdjustment,
+		PerformanceAdjustment:      h.PerformanceAdjustment,
 		PriceAdjustment:            h.PriceAdjustment,
 		StorageRemainingAdjustment: h.StorageRemainingAdjustment,
 		UptimeAdjustment:           h.UptimeAdjustment,
@@ -94,6 +101,7 @@ func (h HostAdjustments) Score() types.Currency {
 		h.CollateralAdjustment *
 		h.DurationAdjustment *
 		h.InteractionAdjustment *
+		h.PerformanceAdjustment *
 		h.PriceAdjustment *
 		h.StorageRemainingAdjustment *
 		h.UptimeAdjustment *
@@ -57,6 +57,25 @@ const (
 	// the bad points do not rack up very quickly.
 	interactionExponentiation = 10
 
+	// performanceAdjustmentMax and performanceAdjustmentMin bound the
+	// adjustment of a single benchmark. This prevents a few exceptionally
+	// fast or slow measurements from dominating the score of a host.
+	performanceAdjustmentMax = 2
+	performanceAdjustmentMin = 0.25
+
+	// performanceExponentiation is the power to which the ratio between a
+	// host's benchmark and the reference value is raised. The number is
+	// sublinear since being twice as fast as the reference is nice but not
+	// twice as valuable.
+	performanceExponentiation = 0.5
+
+	// performanceReferencePriceTableLatency, performanceReferenceRTT and
+	// performanceReferenceThroughput are the benchmarks of a host that
+	// receives neither a reward nor a penalty for its performance.
+	performanceReferencePriceTableLatency = 500 * time.Millisecond
+	performanceReferenceRTT               = 100 * time.Millisecond
+	performanceReferenceThroughput        = 1 << 22 // 4 MiB/s
+
 	// priceExponentiationLarge is the number of times that the weight is
 	// divided by the price when the price is large relative to the allowance.
 	// The exponentiation is a lot higher because we care greatly about high
@@ -231,6 +250,42 @@ func (hdb *HostDB) interactionAdjustments(entry modules.HostDBEntry) float64 {
 	return math.Pow(ratio, interactionExponentiation)
 }
 
+// performanceAdjustments will adjust the weight of the entry according to the
+// benchmarks taken while scanning the host. Every benchmark is compared to its
+// reference value, hosts which are faster are rewarded and hosts which are
+// slower are penalized. Benchmarks without measurements don't affect the
+// weight.
+func performanceAdjustments(entry modules.HostDBEntry) float64 {
+	base := float64(1)
+	if rtt := entry.Benchmarks.MedianRTT(); rtt > 0 {
+		base *= performanceRatioAdjustment(float64(performanceReferenceRTT) / float64(rtt))
+	}
+	if latency := entry.Benchmarks.MedianPriceTableLatency(); latency > 0 {
+		base *= performanceRatioAdjustment(float64(performanceReferencePriceTableLatency) / float64(latency))
+	}
+	if bps := entry.Benchmarks.MedianReadThroughput(); bps > 0 {
+		base *= performanceRatioAdjustment(float64(bps) / performanceReferenceThroughput)
+	}
+	if bps := entry.Benchmarks.MedianWriteThroughput(); bps > 0 {
+		base *= performanceRatioAdjustment(float64(bps) / performanceReferenceThroughput)
+	}
+	return base
+}
+
+// performanceRatioAdjustment converts the ratio between a host's benchmark and
+// its reference value into a bounded adjustment. A ratio greater than 1 means
+// that the host performed better than the reference.
+func performanceRatioAdjustment(ratio float64) float64 {
+	adjustment := math.Pow(ratio, performanceExponentiation)
+	if adjustment > performanceAdjustmentMax {
+		return performanceAdjustmentMax
+	}
+	if adjustment < performanceAdjustmentMin {
+		return performanceAdjustmentMin
+	}
+	return adjustment
+}
+
 // priceAdjustments will adjust the weight of the entry according to the prices
 // that it has set.
 //
@@ -551,19 +606,41 @@ func (hdb *HostDB) uptimeAdjustments(entry modules.HostDBEntry) float64 {
 	return math.Pow(uptimeRatio, exp)
 }
 
-// managedCalculateHostWeightFn creates a hosttree.WeightFunc given an
-// Allowance.
+// applyScoringPolicy raises the tunable adjustments to the power of their
+// weights in the policy.
+func applyScoringPolicy(adjustments hosttree.HostAdjustments, policy modules.HostScoringPolicy) hosttree.HostAdjustments {
+	adjustments.AgeAdjustment = weightedAdjustment(adjustments.AgeAdjustment, policy.AgeWeight)
+	adjustments.CollateralAdjustment = weightedAdjustment(adjustments.CollateralAdjustment, policy.CollateralWeight)
+	adjustments.InteractionAdjustment = weightedAdjustment(adjustments.InteractionAdjustment, policy.InteractionWeight)
+	adjustments.PerformanceAdjustment = weightedAdjustment(adjustments.PerformanceAdjustment, policy.PerformanceWeight)
+	adjustments.PriceAdjustment = weightedAdjustment(adjustments.PriceAdjustment, policy.PriceWeight)
+	adjustments.StorageRemainingAdjustment = weightedAdjustment(adjustments.StorageRemainingAdjustment, policy.StorageRemainingWeight)
+	adjustments.UptimeAdjustment = weightedAdjustment(adjustments.UptimeAdjustment, policy.UptimeWeight)
+	adjustments.ScoringPolicy = policy.Name
+	return adjustments
+}
+
+// weightedAdjustment raises an adjustment to the power of its weight. An
+// adjustment of 0 disqualifies the host and stays 0 no matter the weight.
+// Otherwise a weight of 0 ignores the adjustment.
+func weightedAdjustment(adjustment, weight float64) float64 {
+	if adjustment == 0 {
+		return 0
+	}
+	if weight == 0 {
+		return 1
+	}
+	return math.Pow(adjustment, weight)
+}
+
+// calculateHostWeightFn creates a hosttree.WeightFunc given an Allowance, the
+// txnFees and the scoring policy.
 //
 // NOTE: the hosttree.WeightFunc that is returned accesses fields of the hostdb.
 // The hostdb lock must be held while utilizing the WeightFunc
-func (hdb *HostDB) managedCalculateHostWeightFn(allowance modules.Allowance) hosttree.WeightFunc {
-	// Get the txnFees.
-	hdb.mu.RLock()
-	txnFees := hdb.txnFees
-	hdb.mu.RUnlock()
-	// Create the weight function.
+func (hdb *HostDB) calculateHostWeightFn(allowance modules.Allowance, txnFees types.Currency, policy modules.HostScoringPolicy) hosttree.WeightFunc {
 	return func(entry modules.HostDBEntry) hosttree.ScoreBreakdown {
-		return hosttree.HostAdjustments{
+		adjustments := hosttree.HostAdjustments{
 			AcceptContractAdjustment:   hdb.acceptContractAdjustments(entry),
 			AgeAdjustment:              hdb.lifetimeAdjustments(entry),
 			BasePriceAdjustment:        hdb.basePriceAdjustments(entry),
@@ -571,14 +648,31 @@ func (hdb *HostDB) managedCalculateHostWeightFn(allowance modules.Allowance) hos
 			CollateralAdjustment:       hdb.collateralAdjustments(entry, allowance),
 			DurationAdjustment:         hdb.durationAdjustments(entry, allowance),
 			InteractionAdjustment:      hdb.interactionAdjustments(entry),
+			PerformanceAdjustment:      performanceAdjustments(entry),
 			PriceAdjustment:            hdb.priceAdjustments(entry, allowance, txnFees),
 			StorageRemainingAdjustment: hdb.storageRemainingAdjustments(entry, allowance),
 			UptimeAdjustment:           hdb.uptimeAdjustments(entry),
 			VersionAdjustment:          versionAdjustments(entry),
 		}
+		return applyScoringPolicy(adjustments, policy)
 	}
 }
 
+// managedCalculateHostWeightFn creates a hosttree.WeightFunc given an
+// Allowance using the current txnFees and scoring policy of the hostdb.
+//
+// NOTE: the hosttree.WeightFunc that is returned accesses fields of the hostdb.
+// The hostdb lock must be held while utilizing the WeightFunc
+func (hdb *HostDB) managedCalculateHostWeightFn(allowance modules.Allowance) hosttree.WeightFunc {
+	// Get the txnFees and scoring policy.
+	hdb.mu.RLock()
+	txnFees := hdb.txnFees
+	policy := hdb.scoringPolicy
+	hdb.mu.RUnlock()
+	// Create the weight function.
+	return hdb.calculateHostWeightFn(allowance, txnFees, policy)
+}
+
 // EstimateHostScore takes a HostExternalSettings and returns the estimated
 // score of that host in the hostdb, assuming no penalties for age or uptime.
 func (hdb *HostDB) EstimateHostScore(entry modules.HostDBEntry, allowance modules.Allowance) (modules.HostScoreBreakdown, error) {
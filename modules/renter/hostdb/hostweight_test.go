@@ -747,3 +747,122 @@ func TestHostWeightAcceptContract(t *testing.T) {
 		t.Error("Entry2 should have smallest weight")
 	}
 }
+
+// TestHostWeightScoringPolicy checks that the weights of the scoring policy are
+// applied to the adjustments of a host's score.
+func TestHostWeightScoringPolicy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	hdb := bareHostDB()
+	entry := DefaultHostDBEntry
+	entry.ScanHistory = modules.HostDBScans{{Timestamp: time.Now(), Success: true}}
+
+	breakdown := func(policy modules.HostScoringPolicy) modules.HostScoreBreakdown {
+		wf := hdb.calculateHostWeightFn(DefaultTestAllowance, types.ZeroCurrency, policy)
+		return wf(entry).HostScoreBreakdown(types.ZeroCurrency, false, false, false)
+	}
+	def := breakdown(modules.DefaultHostScoringPolicy)
+	if def.ScoringPolicy != modules.HostScoringProfileDefault {
+		t.Fatal("wrong scoring policy", def.ScoringPolicy)
+	}
+
+	// The cheapest profile should square the price adjustment and take the
+	// square root of the collateral adjustment.
+	cheapest := breakdown(modules.HostScoringProfiles[modules.HostScoringProfileCheapest])
+	if cheapest.ScoringPolicy != modules.HostScoringProfileCheapest {
+		t.Fatal("wrong scoring policy", cheapest.ScoringPolicy)
+	}
+	if !closeTo(cheapest.PriceAdjustment, math.Pow(def.PriceAdjustment, 2)) {
+		t.Fatal("price adjustment wasn't weighted", cheapest.PriceAdjustment, def.PriceAdjustment)
+	}
+	if !closeTo(cheapest.CollateralAdjustment, math.Sqrt(def.CollateralAdjustment)) {
+		t.Fatal("collateral adjustment wasn't weighted", cheapest.CollateralAdjustment, def.CollateralAdjustment)
+	}
+	if cheapest.AcceptContractAdjustment != def.AcceptContractAdjustment || cheapest.VersionAdjustment != def.VersionAdjustment {
+		t.Fatal("adjustments which aren't tunable shouldn't change")
+	}
+
+	// A weight of 0 should ignore the adjustment.
+	policy := modules.DefaultHostScoringPolicy
+	policy.Name = "no-uptime"
+	policy.UptimeWeight = 0
+	if b := breakdown(policy); b.UptimeAdjustment != 1 || def.UptimeAdjustment == 1 {
+		t.Fatal("uptime adjustment should be ignored", b.UptimeAdjustment, def.UptimeAdjustment)
+	}
+
+	// A weight of 0 shouldn't requalify a disqualified host.
+	for _, weight := range []float64{0, 0.5, 1, 2} {
+		if adjustment := weightedAdjustment(0, weight); adjustment != 0 {
+			t.Fatalf("adjustment of 0 with weight %v became %v", weight, adjustment)
+		}
+	}
+
+	// Favoring cheap hosts should rank the cheaper of two hosts higher by a
+	// larger margin.
+	expensive := entry
+	expensive.StoragePrice = expensive.StoragePrice.Mul64(2)
+	ratio := func(policy modules.HostScoringPolicy) float64 {
+		wf := hdb.calculateHostWeightFn(DefaultTestAllowance, types.ZeroCurrency, policy)
+		cheap, _ := wf(entry).Score().Float64()
+		exp, _ := wf(expensive).Score().Float64()
+		return cheap / exp
+	}
+	if ratio(modules.HostScoringProfiles[modules.HostScoringProfileCheapest]) <= ratio(modules.DefaultHostScoringPolicy) {
+		t.Fatal("cheapest profile should favor cheap hosts more than the default profile")
+	}
+}
+
+// TestHostWeightPerformance checks that the benchmarks of a host affect its
+// weight.
+func TestHostWeightPerformance(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	hdb := bareHostDB()
+	entry := DefaultHostDBEntry
+	entry.ScanHistory = modules.HostDBScans{{Timestamp: time.Now(), Success: true}}
+
+	// A host without benchmarks shouldn't be adjusted.
+	if adjustment := performanceAdjustments(entry); adjustment != 1 {
+		t.Fatal("host without benchmarks was adjusted", adjustment)
+	}
+
+	// A host which matches the reference values shouldn't be adjusted either.
+	reference := entry
+	reference.Benchmarks.AddRTT(performanceReferenceRTT)
+	reference.Benchmarks.AddPriceTableLatency(performanceReferencePriceTableLatency)
+	reference.Benchmarks.AddReadThroughput(performanceReferenceThroughput)
+	reference.Benchmarks.AddWriteThroughput(performanceReferenceThroughput)
+	if adjustment := performanceAdjustments(reference); !closeTo(adjustment, 1) {
+		t.Fatal("reference host was adjusted", adjustment)
+	}
+
+	// A host with a 4x lower latency should get twice the weight.
+	fast := entry
+	fast.Benchmarks.AddRTT(performanceReferenceRTT / 4)
+	if adjustment := performanceAdjustments(fast); !closeTo(adjustment, 2) {
+		t.Fatal("wrong adjustment for fast host", adjustment)
+	}
+
+	// The adjustment of a single benchmark is bounded.
+	slow := entry
+	slow.Benchmarks.AddReadThroughput(1)
+	if adjustment := performanceAdjustments(slow); adjustment != performanceAdjustmentMin {
+		t.Fatal("adjustment for slow host wasn't bounded", adjustment)
+	}
+
+	// The faster host should have a higher score than the slower one.
+	wf := hdb.calculateHostWeightFn(DefaultTestAllowance, types.ZeroCurrency, modules.DefaultHostScoringPolicy)
+	if wf(fast).Score().Cmp(wf(slow).Score()) <= 0 {
+		t.Fatal("fast host should have a higher score than the slow host")
+	}
+	if b := wf(fast).HostScoreBreakdown(types.ZeroCurrency, false, false, false); !closeTo(b.PerformanceAdjustment, 2) {
+		t.Fatal("wrong performance adjustment in breakdown", b.PerformanceAdjustment)
+	}
+}
+
+// closeTo returns true if a and b are within a relative margin of each other.
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(math.Abs(a), math.Abs(b))
+}
@@ -0,0 +1,148 @@
+package hostdb
+
+import (
+	"encoding/csv"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/turtledex/errors"
+)
+
+var (
+	// ipDatabaseFilename is the name of the file within the persist dir of
+	// the hostdb that contains the IP database. The file is optional and is
+	// provided by the user.
+	//
+	// Every line of the file is a record of the form
+	//
+	//   network,asn,country,region
+	//
+	// where network is an IPv4 or IPv6 network in CIDR notation, asn is the
+	// number of the autonomous system the network belongs to, optionally
+	// prefixed by 'AS', and country and region are the codes of the location
+	// of the network. Lines starting with '#' are ignored.
+	ipDatabaseFilename = "ipdb.csv"
+)
+
+type (
+	// ipDatabase is an offline database which maps IP networks to the
+	// autonomous system and the location they belong to.
+	ipDatabase struct {
+		// networks maps the prefix lengths of the networks to the locations
+		// of the networks with that prefix length.
+		networks map[int]map[string]ipLocation
+
+		// prefixLens are the prefix lengths of the networks sorted in
+		// descending order to find the most specific network first.
+		prefixLens []int
+	}
+
+	// ipLocation is the autonomous system and location of a network.
+	ipLocation struct {
+		asn     uint32
+		country string
+		region  string
+	}
+)
+
+// newIPDatabase creates an empty ipDatabase.
+func newIPDatabase() *ipDatabase {
+	return &ipDatabase{
+		networks: make(map[int]map[string]ipLocation),
+	}
+}
+
+// loadIPDatabase loads the ipDatabase from the file at path. If the file
+// doesn't exist an empty database is returned.
+func loadIPDatabase(path string) (*ipDatabase, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return newIPDatabase(), nil
+	}
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to open IP database")
+	}
+	defer f.Close()
+	return parseIPDatabase(f)
+}
+
+// parseIPDatabase parses the records of an ipDatabase.
+func parseIPDatabase(r io.Reader) (*ipDatabase, error) {
+	db := newIPDatabase()
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = 4
+	cr.TrimLeadingSpace = true
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.AddContext(err, "unable to read IP database record")
+		}
+		_, network, err := net.ParseCIDR(record[0])
+		if err != nil {
+			return nil, errors.AddContext(err, "invalid network in IP database")
+		}
+		asn, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(record[1]), "AS"), 10, 32)
+		if err != nil {
+			return nil, errors.AddContext(err, "invalid ASN in IP database")
+		}
+		db.add(network, ipLocation{
+			asn:     uint32(asn),
+			country: strings.ToUpper(record[2]),
+			region:  strings.ToUpper(record[3]),
+		})
+	}
+	return db, nil
+}
+
+// add adds a network and its location to the database.
+func (db *ipDatabase) add(network *net.IPNet, loc ipLocation) {
+	prefixLen, _ := network.Mask.Size()
+	networks, exists := db.networks[prefixLen]
+	if !exists {
+		networks = make(map[string]ipLocation)
+		db.networks[prefixLen] = networks
+		db.prefixLens = append(db.prefixLens, prefixLen)
+		sort.Sort(sort.Reverse(sort.IntSlice(db.prefixLens)))
+	}
+	networks[network.String()] = loc
+}
+
+// lookup returns the location of the most specific network that contains the
+// ip.
+func (db *ipDatabase) lookup(ip net.IP) (ipLocation, bool) {
+	bits := 8 * net.IPv6len
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		bits = 8 * net.IPv4len
+	}
+	for _, prefixLen := range db.prefixLens {
+		if prefixLen > bits {
+			continue
+		}
+		mask := net.CIDRMask(prefixLen, bits)
+		network := net.IPNet{IP: ip.Mask(mask), Mask: mask}
+		if loc, exists := db.networks[prefixLen][network.String()]; exists {
+			return loc, true
+		}
+	}
+	return ipLocation{}, false
+}
+
+// locate returns the location of the first of the host's IP addresses that
+// can be found in the database.
+func (db *ipDatabase) locate(ips []net.IP) (ipLocation, bool) {
+	for _, ip := range ips {
+		if loc, exists := db.lookup(ip); exists {
+			return loc, true
+		}
+	}
+	return ipLocation{}, false
+}
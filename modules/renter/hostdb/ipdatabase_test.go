@@ -0,0 +1,68 @@
+package hostdb
+
+import (
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/turtledex/TurtleDexCore/build"
+)
+
+// TestIPDatabase tests parsing an IP database and looking up IPs in it.
+func TestIPDatabase(t *testing.T) {
+	records := `# network,asn,country,region
+1.2.0.0/16,AS100,de,eu
+1.2.3.0/24,200,FR,EU
+2001:db8::/32,AS300,US,NA
+`
+	db, err := parseIPDatabase(strings.NewReader(records))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ip      string
+		exists  bool
+		asn     uint32
+		country string
+		region  string
+	}{
+		{"1.2.4.1", true, 100, "DE", "EU"},
+		{"1.2.3.4", true, 200, "FR", "EU"},
+		{"2001:db8::1", true, 300, "US", "NA"},
+		{"1.3.0.1", false, 0, "", ""},
+		{"2001:db9::1", false, 0, "", ""},
+	}
+	for _, test := range tests {
+		loc, exists := db.lookup(net.ParseIP(test.ip))
+		if exists != test.exists || loc.asn != test.asn || loc.country != test.country || loc.region != test.region {
+			t.Errorf("%v: unexpected location %v %v", test.ip, loc, exists)
+		}
+	}
+
+	// Hosts are located by the first address that can be found.
+	loc, exists := db.locate([]net.IP{net.ParseIP("5.5.5.5"), net.ParseIP("1.2.3.4")})
+	if !exists || loc.asn != 200 {
+		t.Fatal("unexpected location", loc, exists)
+	}
+	if _, exists := db.locate(nil); exists {
+		t.Fatal("host without addresses shouldn't be located")
+	}
+
+	// Invalid records should be rejected.
+	for _, record := range []string{"1.2.3.0/24,AS1,DE", "1.2.3.4,AS1,DE,EU", "1.2.3.0/24,ASX,DE,EU"} {
+		if _, err := parseIPDatabase(strings.NewReader(record)); err == nil {
+			t.Errorf("record '%v' should be invalid", record)
+		}
+	}
+
+	// A missing database should be empty.
+	db, err = loadIPDatabase(filepath.Join(build.TempDir("HostDB", t.Name()), ipDatabaseFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := db.lookup(net.ParseIP("1.2.3.4")); exists {
+		t.Fatal("empty database shouldn't contain any networks")
+	}
+}
@@ -32,6 +32,10 @@ type hdbPersist struct {
 	LastChange               modules.ConsensusChangeID
 	FilteredHosts            map[string]types.TurtleDexPublicKey
 	FilterMode               modules.FilterMode
+	ScoringPolicy            modules.HostScoringPolicy
+	DiversityConstraints     modules.HostDiversityConstraints
+	Benchmarking             bool
+	HostGroups               map[string][]types.TurtleDexPublicKey
 }
 
 // persistData returns the data in the hostdb that will be saved to disk.
@@ -43,6 +47,10 @@ func (hdb *HostDB) persistData() (data hdbPersist) {
 	data.LastChange = hdb.lastChange
 	data.FilteredHosts = hdb.filteredHosts
 	data.FilterMode = hdb.filterMode
+	data.ScoringPolicy = hdb.scoringPolicy
+	data.DiversityConstraints = hdb.diversityConstraints
+	data.Benchmarking = hdb.benchmarking
+	data.HostGroups = hdb.hostGroups
 	return data
 }
 
@@ -68,6 +76,21 @@ func (hdb *HostDB) load() error {
 	hdb.knownContracts = data.KnownContracts
 	hdb.filteredHosts = data.FilteredHosts
 	hdb.filterMode = data.FilterMode
+	hdb.diversityConstraints = data.DiversityConstraints
+	hdb.benchmarking = data.Benchmarking
+	if data.HostGroups != nil {
+		hdb.hostGroups = data.HostGroups
+	}
+
+	// Persist files created before scoring policies existed don't contain a
+	// policy, in which case the default policy is kept.
+	if data.ScoringPolicy.Name != "" {
+		hdb.scoringPolicy = data.ScoringPolicy
+		hdb.weightFunc = hdb.calculateHostWeightFn(hdb.allowance, hdb.txnFees, hdb.scoringPolicy)
+		if err := hdb.staticHostTree.SetWeightFunction(hdb.weightFunc); err != nil {
+			return err
+		}
+	}
 
 	if len(hdb.filteredHosts) > 0 {
 		hdb.staticFilteredTree = hosttree.New(hdb.weightFunc, modules.ProdDependencies.Resolver())
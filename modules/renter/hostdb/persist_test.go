@@ -79,6 +79,13 @@ func TestSaveLoad(t *testing.T) {
 	stashedLC := hdbt.hdb.lastChange
 	hdbt.hdb.filteredHosts = filteredHosts
 	hdbt.hdb.filterMode = filterMode
+	policy, err := modules.ComposeHostScoringPolicy(modules.HostScoringProfileCheapest, modules.HostScoringProfileLatencyFirst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hdbt.hdb.scoringPolicy = policy
+	hdbt.hdb.benchmarking = true
+	hdbt.hdb.hostGroups["trusted"] = []types.TurtleDexPublicKey{host1.PublicKey}
 	err = hdbt.hdb.saveSync()
 	hdbt.hdb.mu.Unlock()
 	if err != nil {
@@ -142,6 +149,21 @@ func TestSaveLoad(t *testing.T) {
 	if _, ok := hdbt.hdb.filteredHosts[host3.PublicKey.String()]; !ok {
 		t.Error("host3 not found in filteredHosts")
 	}
+
+	// Check that the scoring policy was saved and is used by the weightFunc.
+	if hdbt.hdb.scoringPolicy != policy {
+		t.Error("scoring policy wasn't loaded", hdbt.hdb.scoringPolicy)
+	}
+	breakdown := hdbt.hdb.weightFunc(h1).HostScoreBreakdown(types.ZeroCurrency, false, false, false)
+	if breakdown.ScoringPolicy != policy.Name {
+		t.Errorf("expected scoring policy %v but got %v", policy.Name, breakdown.ScoringPolicy)
+	}
+	if !hdbt.hdb.benchmarking {
+		t.Error("benchmarking wasn't loaded")
+	}
+	if hosts := hdbt.hdb.hostGroups["trusted"]; len(hosts) != 1 || !hosts[0].Equals(host1.PublicKey) {
+		t.Error("host groups weren't loaded", hdbt.hdb.hostGroups)
+	}
 }
 
 // TestRescan tests that the hostdb will rescan the blockchain properly, picking
@@ -11,7 +11,9 @@ import (
 // RandomHosts implements the HostDB interface's RandomHosts() method. It takes
 // a number of hosts to return, and a slice of netaddresses to ignore, and
 // returns a slice of entries. If the IP violation check was disabled, the
-// addressBlacklist is ignored.
+// addressBlacklist is ignored. The hosts of the addressBlacklist are the hosts
+// the renter already has contracts with, so they always count towards the ASN
+// limit of the diversity constraints.
 func (hdb *HostDB) RandomHosts(n int, blacklist, addressBlacklist []types.TurtleDexPublicKey) ([]modules.HostDBEntry, error) {
 	hdb.mu.RLock()
 	initialScanComplete := hdb.initialScanComplete
@@ -20,10 +22,11 @@ func (hdb *HostDB) RandomHosts(n int, blacklist, addressBlacklist []types.Turtle
 	if !initialScanComplete {
 		return []modules.HostDBEntry{}, ErrInitialScanIncomplete
 	}
+	df := hdb.managedDiversityFilter(addressBlacklist)
 	if ipCheckDisabled {
-		return hdb.staticFilteredTree.SelectRandom(n, blacklist, nil), nil
+		return hdb.staticFilteredTree.SelectRandomDiverse(n, blacklist, nil, df), nil
 	}
-	return hdb.staticFilteredTree.SelectRandom(n, blacklist, addressBlacklist), nil
+	return hdb.staticFilteredTree.SelectRandomDiverse(n, blacklist, addressBlacklist, df), nil
 }
 
 // RandomHostsWithAllowance works as RandomHosts but uses a temporary hosttree
@@ -59,5 +62,30 @@ func (hdb *HostDB) RandomHostsWithAllowance(n int, blacklist, addressBlacklist [
 	}
 
 	// Select hosts from the temporary hosttree.
-	return ht.SelectRandom(n, blacklist, addressBlacklist), insertErrs
+	df := hdb.diversityFilter(addressBlacklist, allowance.Hosts)
+	return ht.SelectRandomDiverse(n, blacklist, addressBlacklist, df), insertErrs
+}
+
+// diversityFilter creates a filter for the diversity constraints of the
+// hostdb. The hosts with the provided public keys are added to the filter. If
+// no constraints are set, nil is returned.
+func (hdb *HostDB) diversityFilter(hosts []types.TurtleDexPublicKey, totalHosts uint64) *hosttree.DiversityFilter {
+	if !hdb.diversityConstraints.Enabled() {
+		return nil
+	}
+	df := hosttree.NewDiversityFilter(hdb.diversityConstraints, totalHosts)
+	for _, pk := range hosts {
+		if entry, exists := hdb.staticHostTree.Select(pk); exists {
+			df.Add(entry)
+		}
+	}
+	return df
+}
+
+// managedDiversityFilter creates a filter for the diversity constraints of the
+// hostdb using the number of hosts of the hostdb's allowance.
+func (hdb *HostDB) managedDiversityFilter(hosts []types.TurtleDexPublicKey) *hosttree.DiversityFilter {
+	hdb.mu.RLock()
+	defer hdb.mu.RUnlock()
+	return hdb.diversityFilter(hosts, hdb.allowance.Hosts)
 }
@@ -228,6 +228,10 @@ func (hdb *HostDB) updateEntry(entry modules.HostDBEntry, netErr error) {
 		newEntry.HostExternalSettings = entry.HostExternalSettings
 		newEntry.IPNets = entry.IPNets
 		newEntry.LastIPNetChange = entry.LastIPNetChange
+		newEntry.ASN = entry.ASN
+		newEntry.Country = entry.Country
+		newEntry.Region = entry.Region
+		newEntry.Benchmarks = entry.Benchmarks
 	} else {
 		newEntry = entry
 	}
@@ -330,16 +334,27 @@ func (hdb *HostDB) updateEntry(entry modules.HostDBEntry, netErr error) {
 	}
 }
 
+// staticLookupIPs returns the IP addresses the host's address resolves to.
+func (hdb *HostDB) staticLookupIPs(address modules.NetAddress) ([]net.IP, error) {
+	return hdb.staticDeps.Resolver().LookupIP(address.Host())
+}
+
 // staticLookupIPNets returns string representations of the CIDR subnets used by
 // the host. In case of an error we return nil. We don't really care about the
 // error because we don't update host entries if we are offline anyway. So if we
 // fail to resolve a hostname, the problem is not related to us.
 func (hdb *HostDB) staticLookupIPNets(address modules.NetAddress) (ipNets []string, err error) {
 	// Lookup the IP addresses of the host.
-	addresses, err := hdb.staticDeps.Resolver().LookupIP(address.Host())
+	addresses, err := hdb.staticLookupIPs(address)
 	if err != nil {
 		return nil, err
 	}
+	return ipNetsFromIPs(addresses)
+}
+
+// ipNetsFromIPs returns string representations of the CIDR subnets of the
+// given IP addresses.
+func ipNetsFromIPs(addresses []net.IP) (ipNets []string, err error) {
 	// Get the subnets of the addresses.
 	for _, ip := range addresses {
 		// Set the filterRange according to the type of IP address.
@@ -379,7 +394,11 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 	// Resolve the host's used subnets and update the timestamp if they
 	// changed. We only update the timestamp if resolving the ipNets was
 	// successful.
-	ipNets, err := hdb.staticLookupIPNets(entry.NetAddress)
+	ips, err := hdb.staticLookupIPs(entry.NetAddress)
+	var ipNets []string
+	if err == nil {
+		ipNets, err = ipNetsFromIPs(ips)
+	}
 	if err == nil && !equalIPNets(ipNets, entry.IPNets) {
 		entry.IPNets = ipNets
 		entry.LastIPNetChange = time.Now()
@@ -388,13 +407,22 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 		hdb.staticLog.Debugln("mangedScanHost: failed to look up IP nets", err)
 	}
 
+	// Locate the host's addresses in the IP database. If the addresses
+	// couldn't be resolved, the host keeps its previous location.
+	if err == nil {
+		loc, _ := hdb.staticIPDatabase.locate(ips)
+		entry.ASN = loc.asn
+		entry.Country = loc.country
+		entry.Region = loc.region
+	}
+
 	// Update historic interactions of entry if necessary
 	hdb.mu.Lock()
 	updateHostHistoricInteractions(&entry, hdb.blockHeight)
 	hdb.mu.Unlock()
 
 	var settings modules.HostExternalSettings
-	var latency time.Duration
+	var latency, priceTableLatency time.Duration
 	err = func() error {
 		timeout := hostRequestTimeout
 		hdb.mu.RLock()
@@ -475,7 +503,9 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 
 		// Try opening a connection to the siamux, this is a very lightweight
 		// way of checking that RHP3 is supported.
+		start = time.Now()
 		_, err = fetchPriceTable(hdb.staticMux, siamuxAddr, timeout, modules.TurtleDexPKToMuxPK(entry.PublicKey))
+		priceTableLatency = time.Since(start)
 		if err != nil {
 			hdb.staticLog.Debugf("%v siamux ping not successful: %v\n", entry.PublicKey, err)
 			return err
@@ -490,6 +520,11 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 	}
 	success := err == nil
 
+	// Benchmark the host if it is online.
+	if success {
+		hdb.managedBenchmarkHost(&entry, latency, priceTableLatency)
+	}
+
 	hdb.mu.Lock()
 	defer hdb.mu.Unlock()
 	// We don't want to override the NetAddress during a scan so we need to
@@ -531,6 +566,39 @@ func (hdb *HostDB) managedWaitForScans() {
 	}
 }
 
+// managedBenchmarkHost adds the latencies measured during a successful scan to
+// the benchmarks of the entry if benchmarking is enabled. Hosts the renter has
+// a contract with are also sampled for their throughput, which costs money.
+func (hdb *HostDB) managedBenchmarkHost(entry *modules.HostDBEntry, rtt, priceTableLatency time.Duration) {
+	hdb.mu.RLock()
+	enabled := hdb.benchmarking
+	sampler := hdb.throughputSampler
+	_, known := hdb.knownContracts[entry.PublicKey.String()]
+	hdb.mu.RUnlock()
+	if !enabled {
+		return
+	}
+	entry.Benchmarks.AddRTT(rtt)
+	entry.Benchmarks.AddPriceTableLatency(priceTableLatency)
+	if sampler == nil || !known {
+		return
+	}
+
+	// Sample the throughput. The sampler doesn't sample every scan and might
+	// only be able to measure one direction, so measurements are added even
+	// if there was an error.
+	readBPS, writeBPS, err := sampler.SampleThroughput(entry.PublicKey)
+	if err != nil {
+		hdb.staticLog.Debugf("Failed to sample throughput of host %v: %v", entry.PublicKey, err)
+	}
+	if readBPS > 0 {
+		entry.Benchmarks.AddReadThroughput(readBPS)
+	}
+	if writeBPS > 0 {
+		entry.Benchmarks.AddWriteThroughput(writeBPS)
+	}
+}
+
 // threadedProbeHosts pulls hosts from the thread pool and runs a scan on them.
 func (hdb *HostDB) threadedProbeHosts(scanPool <-chan modules.HostDBEntry) {
 	for hostEntry := range scanPool {
@@ -320,3 +320,62 @@ func TestUpdateEntryWithKnownHost(t *testing.T) {
 		t.Fatal("Entry did not get removed from the host tree")
 	}
 }
+
+// testThroughputSampler is a modules.HostThroughputSampler which returns
+// fixed throughputs.
+type testThroughputSampler struct {
+	readBPS, writeBPS uint64
+	samples           int
+}
+
+// SampleThroughput implements modules.HostThroughputSampler.
+func (s *testThroughputSampler) SampleThroughput(types.TurtleDexPublicKey) (uint64, uint64, error) {
+	s.samples++
+	return s.readBPS, s.writeBPS, nil
+}
+
+// TestBenchmarkHost checks that managedBenchmarkHost only benchmarks hosts if
+// benchmarking is enabled and only samples the throughput of hosts the renter
+// has a contract with.
+func TestBenchmarkHost(t *testing.T) {
+	hdb := bareHostDB()
+	sampler := &testThroughputSampler{readBPS: 100, writeBPS: 200}
+	hdb.SetThroughputSampler(sampler)
+	entry := makeHostDBEntry()
+
+	// Benchmarking is disabled by default.
+	hdb.managedBenchmarkHost(&entry, time.Millisecond, time.Second)
+	if len(entry.Benchmarks.RTT) != 0 || len(entry.Benchmarks.PriceTableLatency) != 0 {
+		t.Fatal("host was benchmarked while benchmarking is disabled")
+	}
+
+	// Without a contract only the latencies are measured.
+	hdb.benchmarking = true
+	hdb.managedBenchmarkHost(&entry, time.Millisecond, time.Second)
+	if entry.Benchmarks.MedianRTT() != time.Millisecond || entry.Benchmarks.MedianPriceTableLatency() != time.Second {
+		t.Fatal("wrong latencies", entry.Benchmarks)
+	}
+	if sampler.samples != 0 || len(entry.Benchmarks.ReadThroughput) != 0 {
+		t.Fatal("host without contract shouldn't be sampled")
+	}
+
+	// With a contract the throughput is sampled too.
+	hdb.knownContracts[entry.PublicKey.String()] = contractInfo{HostPublicKey: entry.PublicKey}
+	hdb.managedBenchmarkHost(&entry, time.Millisecond, time.Second)
+	if sampler.samples != 1 {
+		t.Fatal("host with contract should be sampled")
+	}
+	if entry.Benchmarks.MedianReadThroughput() != 100 || entry.Benchmarks.MedianWriteThroughput() != 200 {
+		t.Fatal("wrong throughput", entry.Benchmarks)
+	}
+	if len(entry.Benchmarks.RTT) != 2 {
+		t.Fatal("expected 2 rtt measurements but got", len(entry.Benchmarks.RTT))
+	}
+
+	// Measurements of 0 aren't added.
+	sampler.writeBPS = 0
+	hdb.managedBenchmarkHost(&entry, time.Millisecond, time.Second)
+	if sampler.samples != 2 || len(entry.Benchmarks.ReadThroughput) != 2 || len(entry.Benchmarks.WriteThroughput) != 1 {
+		t.Fatal("unexpected number of throughput measurements", entry.Benchmarks)
+	}
+}
@@ -31,8 +31,9 @@ type bubbledTurtleDexDirMetadata struct {
 // bubbledTurtleDexFileMetadata is a wrapper for siafile.BubbledMetadata that also
 // contains the siapath for convenience.
 type bubbledTurtleDexFileMetadata struct {
-	sp modules.TurtleDexPath
-	bm siafile.BubbledMetadata
+	sp      modules.TurtleDexPath
+	bm      siafile.BubbledMetadata
+	indexed modules.IndexedFile
 }
 
 // bubbleError, bubbleInit, bubbleActive, and bubblePending are the constants
@@ -107,6 +108,7 @@ func (r *Renter) managedCalculateDirectoryMetadata(siaPath modules.TurtleDexPath
 		AggregateNumFiles:            uint64(0),
 		AggregateNumStuckChunks:      uint64(0),
 		AggregateNumSubDirs:          uint64(0),
+		AggregateRedundantSize:       uint64(0),
 		AggregateRemoteHealth:        ttdxdir.DefaultDirHealth,
 		AggregateRepairSize:          uint64(0),
 		AggregateSize:                uint64(0),
@@ -116,6 +118,9 @@ func (r *Renter) managedCalculateDirectoryMetadata(siaPath modules.TurtleDexPath
 		AggregateSkynetFiles: uint64(0),
 		AggregateSkynetSize:  uint64(0),
 
+		AggregateNumPendingMigrations: uint64(0),
+		AggregatePendingMigrationSize: uint64(0),
+
 		Health:              ttdxdir.DefaultDirHealth,
 		LastHealthCheckTime: now,
 		MinRedundancy:       math.MaxFloat64,
@@ -123,6 +128,7 @@ func (r *Renter) managedCalculateDirectoryMetadata(siaPath modules.TurtleDexPath
 		NumFiles:            uint64(0),
 		NumStuckChunks:      uint64(0),
 		NumSubDirs:          uint64(0),
+		RedundantSize:       uint64(0),
 		RemoteHealth:        ttdxdir.DefaultDirHealth,
 		RepairSize:          uint64(0),
 		Size:                uint64(0),
@@ -131,6 +137,9 @@ func (r *Renter) managedCalculateDirectoryMetadata(siaPath modules.TurtleDexPath
 
 		SkynetFiles: uint64(0),
 		SkynetSize:  uint64(0),
+
+		NumPendingMigrations: uint64(0),
+		PendingMigrationSize: uint64(0),
 	}
 	// Read directory
 	fileinfos, err := r.staticFileSystem.ReadDir(siaPath)
@@ -139,6 +148,14 @@ func (r *Renter) managedCalculateDirectoryMetadata(siaPath modules.TurtleDexPath
 		return ttdxdir.Metadata{}, err
 	}
 
+	// Get the redundancy policy that applies to the files of the directory.
+	// Note: We don't need to abort on error. The files are just not counted
+	// towards the pending migrations.
+	policy, err := r.staticFileSystem.RedundancyPolicy(siaPath)
+	if err != nil {
+		r.log.Printf("WARN: unable to get redundancy policy of %v: %v", siaPath, err)
+	}
+
 	// Iterate over directory and collect the file and dir siapaths.
 	var fileTurtleDexPaths, dirTurtleDexPaths []modules.TurtleDexPath
 	for _, fi := range fileinfos {
@@ -178,6 +195,15 @@ func (r *Renter) managedCalculateDirectoryMetadata(siaPath modules.TurtleDexPath
 		r.log.Printf("failed to calculate file metadata: %v", err)
 	}
 
+	// Update the search index with the new health of the files.
+	indexedFiles := make([]modules.IndexedFile, 0, len(bubbledMetadatas))
+	for _, bm := range bubbledMetadatas {
+		indexedFiles = append(indexedFiles, bm.indexed)
+	}
+	if err := r.staticFileSystem.UpdateSearchIndex(indexedFiles...); err != nil {
+		r.log.Printf("WARN: failed to update search index for %v: %v", siaPath, err)
+	}
+
 	// Get all the Directory Metadata
 	// Note: We don't need to abort on error. It's likely that only one or a few
 	// directories failed and that the remaining metadatas are good to use.
@@ -239,6 +265,7 @@ func (r *Renter) managedCalculateDirectoryMetadata(siaPath modules.TurtleDexPath
 			// Update aggregate fields.
 			metadata.AggregateNumFiles++
 			metadata.AggregateNumStuckChunks += fileMetadata.NumStuckChunks
+			metadata.AggregateRedundantSize += fileMetadata.RedundantSize
 			metadata.AggregateSize += fileMetadata.Size
 
 			// Update ttdxdir fields.
@@ -257,6 +284,7 @@ func (r *Renter) managedCalculateDirectoryMetadata(siaPath modules.TurtleDexPath
 			if !fileMetadata.OnDisk {
 				metadata.RemoteHealth = math.Max(metadata.RemoteHealth, fileMetadata.Health)
 			}
+			metadata.RedundantSize += fileMetadata.RedundantSize
 			metadata.Size += fileMetadata.Size
 			metadata.StuckHealth = math.Max(metadata.StuckHealth, fileMetadata.StuckHealth)
 
@@ -282,6 +310,14 @@ func (r *Renter) managedCalculateDirectoryMetadata(siaPath modules.TurtleDexPath
 				metadata.AggregateSkynetFiles++
 				metadata.SkynetFiles++
 			}
+
+			// Update Redundancy Migration Fields
+			if _, migrate := redundancyMigrationTier(policy, fileTurtleDexPath, fileMetadata, now); migrate {
+				metadata.AggregateNumPendingMigrations++
+				metadata.AggregatePendingMigrationSize += fileMetadata.Size
+				metadata.NumPendingMigrations++
+				metadata.PendingMigrationSize += fileMetadata.Size
+			}
 		} else if len(dirMetadatas) > 0 {
 			// Get next dir's metadata.
 			dirMetadata := dirMetadatas[0]
@@ -321,6 +357,7 @@ func (r *Renter) managedCalculateDirectoryMetadata(siaPath modules.TurtleDexPath
 			metadata.AggregateNumFiles += dirMetadata.AggregateNumFiles
 			metadata.AggregateNumStuckChunks += dirMetadata.AggregateNumStuckChunks
 			metadata.AggregateNumSubDirs += dirMetadata.AggregateNumSubDirs
+			metadata.AggregateRedundantSize += dirMetadata.AggregateRedundantSize
 			metadata.AggregateRepairSize += dirMetadata.AggregateRepairSize
 			metadata.AggregateSize += dirMetadata.AggregateSize
 			metadata.AggregateStuckSize += dirMetadata.AggregateStuckSize
@@ -329,6 +366,10 @@ func (r *Renter) managedCalculateDirectoryMetadata(siaPath modules.TurtleDexPath
 			metadata.AggregateSkynetFiles += dirMetadata.AggregateSkynetFiles
 			metadata.AggregateSkynetSize += dirMetadata.AggregateSkynetSize
 
+			// Update aggregate Redundancy Migration fields
+			metadata.AggregateNumPendingMigrations += dirMetadata.AggregateNumPendingMigrations
+			metadata.AggregatePendingMigrationSize += dirMetadata.AggregatePendingMigrationSize
+
 			// Add 1 to the AggregateNumSubDirs to account for this subdirectory.
 			metadata.AggregateNumSubDirs++
 
@@ -415,8 +456,11 @@ func (r *Renter) managedCalculateFileMetadata(siaPath modules.TurtleDexPath, hos
 
 	// Return the metadata
 	return bubbledTurtleDexFileMetadata{
-		sp: siaPath,
+		sp:      siaPath,
+		indexed: sf.IndexedFile(siaPath),
 		bm: siafile.BubbledMetadata{
+			AccessTime:          sf.AccessTime(),
+			ErasureCode:         sf.ErasureCode(),
 			Health:              health,
 			LastHealthCheckTime: sf.LastHealthCheckTime(),
 			ModTime:             sf.ModTime(),
@@ -424,6 +468,7 @@ func (r *Renter) managedCalculateFileMetadata(siaPath modules.TurtleDexPath, hos
 			NumStuckChunks:      numStuckChunks,
 			OnDisk:              onDisk,
 			Redundancy:          redundancy,
+			RedundantSize:       sf.NumChunks() * uint64(sf.ErasureCode().NumPieces()) * modules.SectorSize,
 			RepairBytes:         repairBytes,
 			Size:                sf.Size(),
 			StuckHealth:         stuckHealth,
@@ -701,6 +746,8 @@ func (r *Renter) managedPerformBubbleMetadata(siaPath modules.TurtleDexPath) (er
 		if err != nil {
 			e := fmt.Sprintf("could not update the metadata of the directory %v", siaPath.String())
 			err = errors.AddContext(err, e)
+		} else {
+			r.managedUpdateDirQuotaAlert(siaPath, siaDir)
 		}
 	}
 
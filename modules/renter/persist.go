@@ -57,10 +57,11 @@ var (
 type (
 	// persist contains all of the persistent renter data.
 	persistence struct {
-		MaxDownloadSpeed int64
-		MaxUploadSpeed   int64
-		UploadedBackups  []modules.UploadedBackup
-		SyncedContracts  []types.FileContractID
+		MaxDownloadSpeed  int64
+		MaxUploadSpeed    int64
+		BandwidthSchedule modules.BandwidthSchedule
+		UploadedBackups   []modules.UploadedBackup
+		SyncedContracts   []types.FileContractID
 	}
 )
 
@@ -111,7 +112,7 @@ func (r *Renter) managedLoadSettings() error {
 
 	// Set the bandwidth limits on the contractor, which was already initialized
 	// without bandwidth limits.
-	return r.setBandwidthLimits(r.persist.MaxDownloadSpeed, r.persist.MaxUploadSpeed)
+	return r.setBandwidthLimits(r.persist.MaxDownloadSpeed, r.persist.MaxUploadSpeed, r.persist.BandwidthSchedule)
 }
 
 // managedInitPersist handles all of the persistence initialization, such as creating
@@ -175,10 +176,13 @@ func (r *Renter) managedInitPersist() error {
 	}
 
 	// Create the filesystem.
-	fs, err := filesystem.New(fsRoot, r.log, wal)
+	fs, err := filesystem.New(fsRoot, r.persistDir, r.log, wal)
 	if err != nil {
 		return err
 	}
+	if err := r.tg.AfterStop(fs.Close); err != nil {
+		return err
+	}
 
 	// Initialize the wal, staticFileSet and the staticDirSet. With the
 	// staticDirSet finish the initialization of the files directory
@@ -174,12 +174,12 @@ type projectChunkWorkerSet struct {
 // chunkFetcher is an interface that exposes a download function, the PCWS
 // implements this interface.
 type chunkFetcher interface {
-	Download(ctx context.Context, pricePerMS types.Currency, offset, length uint64) (chan *downloadResponse, error)
+	Download(ctx context.Context, pricePerMS types.Currency, priority modules.DownloadPriority, offset, length uint64) (chan *downloadResponse, error)
 }
 
 // Download will download a range from a chunk.
-func (pcws *projectChunkWorkerSet) Download(ctx context.Context, pricePerMS types.Currency, offset, length uint64) (chan *downloadResponse, error) {
-	return pcws.managedDownload(ctx, pricePerMS, offset, length)
+func (pcws *projectChunkWorkerSet) Download(ctx context.Context, pricePerMS types.Currency, priority modules.DownloadPriority, offset, length uint64) (chan *downloadResponse, error) {
+	return pcws.managedDownload(ctx, pricePerMS, priority, offset, length)
 }
 
 // checkPCWSGouging verifies the cost of grabbing the HasSector information from
@@ -517,7 +517,10 @@ func (pcws *projectChunkWorkerSet) managedTryUpdateWorkerState() error {
 // expected to trim 100 milliseconds off of the download time, the download code
 // will select those workers only if the additional expense of using those
 // workers is less than 100 * pricePerMS.
-func (pcws *projectChunkWorkerSet) managedDownload(ctx context.Context, pricePerMS types.Currency, offset, length uint64) (chan *downloadResponse, error) {
+//
+// The priority orders the read jobs of the download relative to the jobs of
+// other downloads and determines how eagerly the download overdrives.
+func (pcws *projectChunkWorkerSet) managedDownload(ctx context.Context, pricePerMS types.Currency, priority modules.DownloadPriority, offset, length uint64) (chan *downloadResponse, error) {
 	// Potentially force a timeout via a disrupt for testing.
 	if pcws.staticRenter.deps.Disrupt("timeoutProjectDownloadByRoot") {
 		return nil, errors.Compose(ErrProjectTimedOut, ErrRootNotFound)
@@ -590,6 +593,7 @@ func (pcws *projectChunkWorkerSet) managedDownload(ctx context.Context, pricePer
 		pieceLength: pieceLength,
 
 		pricePerMS: pricePerMS,
+		priority:   priority,
 
 		availablePieces: make([][]*pieceDownload, ec.NumPieces()),
 		dataPieces:      make([][]byte, ec.NumPieces()),
@@ -74,6 +74,11 @@ type (
 		// favor the faster and more expensive worker set.
 		pricePerMS types.Currency
 
+		// priority is the download priority of the chunk. It orders the read
+		// jobs of the chunk relative to the jobs of other downloads and
+		// determines how eagerly the chunk overdrives.
+		priority modules.DownloadPriority
+
 		// availablePieces are pieces that resolved workers think they can
 		// fetch.
 		//
@@ -382,6 +387,7 @@ func (pdc *projectDownloadChunk) launchWorker(w *worker, pieceIndex uint64) (tim
 		jobRead: jobRead{
 			staticResponseChan: pdc.workerResponseChan,
 			staticLength:       pdc.pieceLength,
+			staticPriority:     pdc.priority,
 
 			jobGeneric: newJobGeneric(pdc.ctx, w.staticJobReadQueue, jobReadMetadata{
 				staticWorker:              w,
@@ -253,8 +253,9 @@ func (pdc *projectDownloadChunk) overdriveStatus() (int, time.Time) {
 	}
 
 	// If the latest worker should have already completed its job, return that
-	// an overdrive worker should be launched.
-	if time.Now().After(latestReturn) {
+	// an overdrive worker should be launched. How late the worker may be
+	// depends on the priority of the download.
+	if time.Now().After(latestReturn.Add(overdriveDelay(pdc.priority))) {
 		return 1, latestReturn
 	}
 
@@ -295,7 +296,7 @@ func (pdc *projectDownloadChunk) tryOverdrive() (<-chan struct{}, <-chan time.Ti
 
 	// All needed overdrive workers have been launched. No need to try again
 	// until the current set of workers are late.
-	return nil, time.After(time.Until(latestReturn))
+	return nil, time.After(time.Until(latestReturn.Add(overdriveDelay(pdc.priority))))
 }
 
 // addCostPenalty takes a certain job time and adds a penalty to it depending on
@@ -320,3 +320,61 @@ func TestProjectDownloadChunk_overdriveStatus(t *testing.T) {
 		t.Fatal("unexpected")
 	}
 }
+
+// TestProjectDownloadChunk_overdriveStatusPriority verifies that the priority
+// of a pdc determines how late its workers may be before it overdrives.
+func TestProjectDownloadChunk_overdriveStatusPriority(t *testing.T) {
+	t.Parallel()
+
+	rsc, err := modules.NewRSCode(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pcws := new(projectChunkWorkerSet)
+	pcws.staticErasureCoder = rsc
+
+	// overdriveWanted returns whether a pdc with the given priority wants to
+	// launch an overdrive worker if its only launched worker is expected to
+	// return at the given time.
+	overdriveWanted := func(priority modules.DownloadPriority, expected time.Time) bool {
+		pdc := new(projectDownloadChunk)
+		pdc.workerSet = pcws
+		pdc.priority = priority
+		pdc.availablePieces = [][]*pieceDownload{
+			{
+				{launched: true, expectedCompleteTime: expected},
+			},
+			{},
+		}
+		toLaunch, _ := pdc.overdriveStatus()
+		return toLaunch == 1
+	}
+
+	// A slightly late worker causes an overdrive for all but bulk downloads.
+	slightlyLate := time.Now().Add(-bulkOverdriveGracePeriod / 2)
+	if !overdriveWanted(modules.DownloadPriorityInteractive, slightlyLate) {
+		t.Fatal("interactive download should overdrive")
+	}
+	if !overdriveWanted(modules.DownloadPriorityNormal, slightlyLate) {
+		t.Fatal("normal download should overdrive")
+	}
+	if overdriveWanted(modules.DownloadPriorityBulk, slightlyLate) {
+		t.Fatal("bulk download shouldn't overdrive")
+	}
+
+	// Once the grace period passed, bulk downloads overdrive as well.
+	veryLate := time.Now().Add(-2 * bulkOverdriveGracePeriod)
+	if !overdriveWanted(modules.DownloadPriorityBulk, veryLate) {
+		t.Fatal("bulk download should overdrive")
+	}
+
+	// A worker that is about to return causes only interactive downloads to
+	// overdrive.
+	almostDue := time.Now().Add(interactiveOverdriveHeadStart / 2)
+	if !overdriveWanted(modules.DownloadPriorityInteractive, almostDue) {
+		t.Fatal("interactive download should overdrive")
+	}
+	if overdriveWanted(modules.DownloadPriorityNormal, almostDue) {
+		t.Fatal("normal download shouldn't overdrive")
+	}
+}
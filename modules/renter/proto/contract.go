@@ -545,6 +545,32 @@ func (c *SafeContract) managedCommitAppend(t *unappliedWalTxn, signedTxn types.T
 	return nil
 }
 
+// managedRecordSampleIntent creates a WAL update that updates the header with
+// the upload costs of a write which doesn't change the contract's sectors. It
+// is committed with managedCommitAppend.
+func (c *SafeContract) managedRecordSampleIntent(rev types.FileContractRevision, bandwidthCost types.Currency) (*unappliedWalTxn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// construct new header
+	// NOTE: this header will not include the host signature
+	newHeader := c.header
+	newHeader.Transaction.FileContractRevisions = []types.FileContractRevision{rev}
+	newHeader.Transaction.TransactionSignatures = nil
+	newHeader.UploadSpending = newHeader.UploadSpending.Add(bandwidthCost)
+
+	t, err := c.newWalTxn([]writeaheadlog.Update{
+		c.makeUpdateSetHeader(newHeader),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := <-t.SignalSetupComplete(); err != nil {
+		return nil, err
+	}
+	c.unappliedTxns = append(c.unappliedTxns, t)
+	return t, nil
+}
+
 // managedRecordDownloadIntent creates a WAL update that updates the header with
 // the new download costs.
 func (c *SafeContract) managedRecordDownloadIntent(rev types.FileContractRevision, bandwidthCost types.Currency) (*unappliedWalTxn, error) {
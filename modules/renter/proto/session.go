@@ -148,6 +148,23 @@ func (s *Session) Append(data []byte) (_ modules.RenterContract, _ crypto.Hash,
 	return rc, crypto.MerkleRoot(data), err
 }
 
+// AppendAndTrim calls the Write RPC with an Append action followed by a Trim
+// action which removes the appended sector again. The host receives the data
+// but the sectors of the contract don't change, which allows for sampling the
+// upload throughput of the host. Only the bandwidth is paid for.
+func (s *Session) AppendAndTrim(data []byte) (_ modules.RenterContract, err error) {
+	sc, haveContract := s.contractSet.Acquire(s.contractID)
+	if !haveContract {
+		return modules.RenterContract{}, errors.New("contract not present in contract set")
+	}
+	defer s.contractSet.Return(sc)
+	actions := []modules.LoopWriteAction{
+		{Type: modules.WriteActionAppend, Data: data},
+		{Type: modules.WriteActionTrim, A: 1},
+	}
+	return s.write(sc, actions, true)
+}
+
 // Replace calls the Write RPC with a series of actions that replace the sector
 // at the specified index with data, returning the updated contract and the
 // Merkle root of the new sector.
@@ -170,7 +187,7 @@ func (s *Session) Replace(data []byte, sectorIndex uint64, trim bool) (_ modules
 		actions = append(actions, modules.LoopWriteAction{Type: modules.WriteActionTrim, A: 1})
 	}
 
-	rc, err := s.write(sc, actions)
+	rc, err := s.write(sc, actions, false)
 	return rc, crypto.MerkleRoot(data), errors.AddContext(err, "write to host failed")
 }
 
@@ -182,10 +199,12 @@ func (s *Session) Write(actions []modules.LoopWriteAction) (_ modules.RenterCont
 		return modules.RenterContract{}, errors.New("contract not present in contract set")
 	}
 	defer s.contractSet.Return(sc)
-	return s.write(sc, actions)
+	return s.write(sc, actions, false)
 }
 
-func (s *Session) write(sc *SafeContract, actions []modules.LoopWriteAction) (_ modules.RenterContract, err error) {
+// write executes the Write RPC. sample is set for writes which don't change the
+// sectors of the contract.
+func (s *Session) write(sc *SafeContract, actions []modules.LoopWriteAction, sample bool) (_ modules.RenterContract, err error) {
 	contract := sc.header // for convenience
 
 	// calculate price per sector
@@ -288,7 +307,12 @@ func (s *Session) write(sc *SafeContract, actions []modules.LoopWriteAction) (_
 	// post-revision contract.
 	//
 	// TODO: update this for non-local root storage
-	walTxn, err := sc.managedRecordAppendIntent(rev, crypto.Hash{}, storagePrice, bandwidthPrice)
+	var walTxn *unappliedWalTxn
+	if sample {
+		walTxn, err = sc.managedRecordSampleIntent(rev, bandwidthPrice)
+	} else {
+		walTxn, err = sc.managedRecordAppendIntent(rev, crypto.Hash{}, storagePrice, bandwidthPrice)
+	}
 	if err != nil {
 		return modules.RenterContract{}, err
 	}
@@ -0,0 +1,103 @@
+package renter
+
+import (
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
+	"github.com/turtledex/errors"
+)
+
+// SetDirQuota sets the quota of the directory at siaPath. The quota limits the
+// number of bytes stored within the directory and its subdirectories before
+// and after redundancy. A quota of 0 means that there is no limit.
+func (r *Renter) SetDirQuota(siaPath modules.TurtleDexPath, quota, redundantQuota uint64) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	err := r.staticFileSystem.SetDirQuota(siaPath, quota, redundantQuota)
+	if err != nil {
+		return errors.AddContext(err, "unable to set quota")
+	}
+	// Bubble the directory to update the quota alert.
+	go r.callThreadedBubbleMetadata(siaPath)
+	return nil
+}
+
+// managedCheckDirQuotas checks whether a file at siaPath with the provided
+// size and number of chunks fits within the quotas of its parent directories.
+// If replace is set, the file currently stored at siaPath is about to be
+// replaced and its size is subtracted from the usage of the directories.
+func (r *Renter) managedCheckDirQuotas(siaPath modules.TurtleDexPath, size, numChunks uint64, ec modules.ErasureCoder, replace bool) error {
+	dirTurtleDexPath, err := siaPath.Dir()
+	if err != nil {
+		return err
+	}
+	redundantSize := numChunks * uint64(ec.NumPieces()) * modules.SectorSize
+	if replace {
+		replacedSize, replacedRedundantSize := r.managedFileQuotaUsage(siaPath)
+		size = subtractUsage(size, replacedSize)
+		redundantSize = subtractUsage(redundantSize, replacedRedundantSize)
+	}
+	return r.staticFileSystem.CheckDirQuotas(dirTurtleDexPath, size, redundantSize)
+}
+
+// managedFileQuotaUsage returns the size and the redundant size that the file
+// at siaPath contributes to the usage of its directories. A file that doesn't
+// exist doesn't use anything.
+func (r *Renter) managedFileQuotaUsage(siaPath modules.TurtleDexPath) (size, redundantSize uint64) {
+	node, err := r.staticFileSystem.OpenTurtleDexFile(siaPath)
+	if err != nil {
+		return 0, 0
+	}
+	defer func() {
+		if err := node.Close(); err != nil {
+			r.log.Printf("Unable to close file %v: %v", siaPath, err)
+		}
+	}()
+	return node.Size(), node.NumChunks() * uint64(node.ErasureCode().NumPieces()) * modules.SectorSize
+}
+
+// managedUpdateDirQuotaAlert registers an alert for the dir at siaPath if it
+// is using more than AlertDirQuotaThreshold of its quota and unregisters it
+// otherwise.
+func (r *Renter) managedUpdateDirQuotaAlert(siaPath modules.TurtleDexPath, dir *filesystem.DirNode) {
+	md, err := dir.Metadata()
+	if err != nil {
+		r.log.Printf("WARN: unable to fetch metadata of '%v' to check its quota: %v", siaPath, err)
+		return
+	}
+	alertID := modules.AlertIDRenterDirQuota(siaPath.String())
+	if quotaAlertTriggered(md.AggregateSize, md.Quota) {
+		r.staticAlerter.RegisterAlert(alertID, AlertMSGDirQuota, AlertCauseDirQuota(siaPath, md.AggregateSize, md.Quota, false), modules.SeverityWarning)
+	} else if quotaAlertTriggered(md.AggregateRedundantSize, md.RedundantQuota) {
+		r.staticAlerter.RegisterAlert(alertID, AlertMSGDirQuota, AlertCauseDirQuota(siaPath, md.AggregateRedundantSize, md.RedundantQuota, true), modules.SeverityWarning)
+	} else {
+		r.staticAlerter.UnregisterAlert(alertID)
+	}
+}
+
+// quotaAlertTriggered returns whether usage is above AlertDirQuotaThreshold
+// of quota. A quota of 0 never triggers an alert.
+func quotaAlertTriggered(usage, quota uint64) bool {
+	return quota > 0 && float64(usage) > AlertDirQuotaThreshold*float64(quota)
+}
+
+// numChunksForSize returns the number of chunks a file with the provided size
+// would be split into when uploaded with the provided erasure coder.
+func numChunksForSize(size uint64, ec modules.ErasureCoder) uint64 {
+	chunkSize := modules.SectorSize * uint64(ec.MinPieces())
+	numChunks := size / chunkSize
+	if size%chunkSize != 0 || numChunks == 0 {
+		numChunks++
+	}
+	return numChunks
+}
+
+// subtractUsage subtracts the usage of a replaced file from the usage of its
+// replacement without underflowing.
+func subtractUsage(usage, replaced uint64) uint64 {
+	if replaced > usage {
+		return 0
+	}
+	return usage - replaced
+}
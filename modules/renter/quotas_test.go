@@ -0,0 +1,155 @@
+package renter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
+	"github.com/turtledex/TurtleDexCore/persist"
+	"github.com/turtledex/errors"
+	"github.com/turtledex/fastrand"
+)
+
+// TestNumChunksForSize is a unit test for numChunksForSize.
+func TestNumChunksForSize(t *testing.T) {
+	ec := modules.NewRSSubCodeDefault()
+	chunkSize := modules.SectorSize * uint64(ec.MinPieces())
+	tests := []struct {
+		size      uint64
+		numChunks uint64
+	}{
+		{0, 1},
+		{1, 1},
+		{chunkSize, 1},
+		{chunkSize + 1, 2},
+		{10 * chunkSize, 10},
+	}
+	for _, test := range tests {
+		if numChunks := numChunksForSize(test.size, ec); numChunks != test.numChunks {
+			t.Errorf("size %v: expected %v chunks but got %v", test.size, test.numChunks, numChunks)
+		}
+	}
+}
+
+// TestDirQuota tests that uploads respect dir quotas and that the quota alert
+// is registered.
+func TestDirQuota(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create a dir with a quota. The quota is set on the filesystem directly
+	// to avoid a bubble racing with the simulated usage below.
+	dirPath, err := modules.NewTurtleDexPath("quota")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.renter.CreateDir(dirPath, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.renter.staticFileSystem.SetDirQuota(dirPath, 100, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// Uploading a file that exceeds the quota should fail.
+	source := filepath.Join(rt.dir, "file")
+	if err := ioutil.WriteFile(source, fastrand.Bytes(101), 0600); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.Remove(source); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	filePath, err := dirPath.Join("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = rt.renter.Upload(modules.FileUploadParams{
+		Source:        source,
+		TurtleDexPath: filePath,
+	})
+	if !errors.Contains(err, filesystem.ErrQuotaExceeded) {
+		t.Fatal("expected ErrQuotaExceeded but got", err)
+	}
+	if _, err := rt.renter.File(filePath); !errors.Contains(err, filesystem.ErrNotExist) {
+		t.Fatal("file shouldn't exist", err)
+	}
+
+	// quotaAlerts returns the number of registered quota alerts.
+	quotaAlerts := func() (n int) {
+		_, _, warn := rt.renter.staticAlerter.Alerts()
+		for _, alert := range warn {
+			if alert.Msg == AlertMSGDirQuota {
+				n++
+			}
+		}
+		return
+	}
+
+	// Simulate the dir using 95% of its quota. This should register an alert.
+	dir, err := rt.renter.staticFileSystem.OpenTurtleDexDir(dirPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := dir.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	md, err := dir.Metadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+	md.AggregateSize = 95
+	if err := dir.UpdateBubbledMetadata(md); err != nil {
+		t.Fatal(err)
+	}
+	rt.renter.managedUpdateDirQuotaAlert(dirPath, dir)
+	if n := quotaAlerts(); n != 1 {
+		t.Fatal("expected 1 quota alert but got", n)
+	}
+
+	// Raising the quota should unregister the alert again.
+	if err := rt.renter.staticFileSystem.SetDirQuota(dirPath, 1000, 0); err != nil {
+		t.Fatal(err)
+	}
+	rt.renter.managedUpdateDirQuotaAlert(dirPath, dir)
+	if n := quotaAlerts(); n != 0 {
+		t.Fatal("expected no quota alerts but got", n)
+	}
+
+	// Add a file that uses 90 of the dir's 95 bytes. Replacing it with a file
+	// of the same size should fit within a quota of 100 bytes while adding
+	// another file of that size shouldn't.
+	if err := rt.renter.staticFileSystem.SetDirQuota(dirPath, 100, 0); err != nil {
+		t.Fatal(err)
+	}
+	ec := modules.NewRSSubCodeDefault()
+	err = rt.renter.staticFileSystem.NewTurtleDexFile(filePath, "", ec, crypto.GenerateTurtleDexKey(crypto.TypeDefaultRenter), 90, persist.DefaultDiskPermissionsTest, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.renter.managedCheckDirQuotas(filePath, 90, 1, ec, true); err != nil {
+		t.Fatal(err)
+	}
+	err = rt.renter.managedCheckDirQuotas(filePath, 90, 1, ec, false)
+	if !errors.Contains(err, filesystem.ErrQuotaExceeded) {
+		t.Fatal("expected ErrQuotaExceeded but got", err)
+	}
+}
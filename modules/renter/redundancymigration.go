@@ -0,0 +1,509 @@
+package renter
+
+// The redundancy migration moves files to the erasure coding settings of the
+// redundancy policy of their directory. Policies consist of tiers which apply
+// to files depending on how long ago they were last accessed. Whenever a file
+// doesn't match the tier that applies to it, e.g. because the policy changed
+// or because the file aged into the next tier, the file is downloaded and
+// uploaded again with the erasure coding settings of the tier. Once the new
+// upload is available, it replaces the original file and the pieces of the
+// original file are dropped.
+//
+// The bubble keeps track of the number of files that need to be migrated
+// within each directory. The migration loop uses that information to find the
+// next file to migrate. Repairs take priority over migrations so the loop
+// yields whenever the upload heap contains chunks.
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem/siafile"
+	"github.com/turtledex/errors"
+)
+
+// redundancyMigrator holds the state of the redundancy migration loop.
+type redundancyMigrator struct {
+	// Progress of the migration.
+	activeFile    modules.TurtleDexPath
+	migratedFiles uint64
+	migratedSize  uint64
+
+	// wakeChan is used to wake up the migration loop after a redundancy
+	// policy changed.
+	wakeChan chan struct{}
+
+	// External control channels
+	pauseChan     chan struct{}
+	pauseDuration time.Duration
+	pauseStart    time.Time
+	pauseTimer    *time.Timer
+
+	mu sync.Mutex
+}
+
+// newRedundancyMigrator creates a new redundancyMigrator which is not paused.
+func newRedundancyMigrator() *redundancyMigrator {
+	rm := &redundancyMigrator{
+		pauseChan: make(chan struct{}),
+		wakeChan:  make(chan struct{}, 1),
+	}
+	close(rm.pauseChan)
+	return rm
+}
+
+// callWake wakes up the migration loop if it is waiting for work.
+func (rm *redundancyMigrator) callWake() {
+	select {
+	case rm.wakeChan <- struct{}{}:
+	default:
+	}
+}
+
+// managedFinishMigration updates the progress after a migration finished.
+func (rm *redundancyMigrator) managedFinishMigration(size uint64, success bool) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.activeFile = modules.TurtleDexPath{}
+	if success {
+		rm.migratedFiles++
+		rm.migratedSize += size
+	}
+}
+
+// managedIsPaused returns the boolean indicating whether or not the user has
+// paused the migration and the channel that is closed once it is resumed.
+func (rm *redundancyMigrator) managedIsPaused() (bool, <-chan struct{}) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	select {
+	case <-rm.pauseChan:
+		return false, rm.pauseChan
+	default:
+		return true, rm.pauseChan
+	}
+}
+
+// managedPause creates the pauseChan and initiates the pauseTimer for the
+// duration requested
+func (rm *redundancyMigrator) managedPause(duration time.Duration) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.pauseDuration = duration
+	rm.pauseStart = time.Now()
+	select {
+	case <-rm.pauseChan:
+		// The migration is not currently paused so pause it
+		rm.pauseChan = make(chan struct{})
+		rm.pauseTimer = time.AfterFunc(duration, func() {
+			rm.mu.Lock()
+			close(rm.pauseChan)
+			rm.pauseDuration = 0
+			rm.pauseStart = time.Time{}
+			rm.mu.Unlock()
+		})
+	default:
+		// The migration is paused so reset the timer duration
+		rm.pauseTimer.Reset(duration)
+	}
+}
+
+// managedResume will close the pauseChan and stop the pauseTimer
+func (rm *redundancyMigrator) managedResume() {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	select {
+	case <-rm.pauseChan:
+		// The migration isn't paused, nothing to do
+		return
+	default:
+	}
+
+	// Stop the timer and reset the duration
+	stopped := rm.pauseTimer.Stop()
+	rm.pauseDuration = 0
+	rm.pauseStart = time.Time{}
+
+	// We only want to close the channel if we were able to stop the timer,
+	// otherwise the channel is already closed because the timer reached its
+	// duration
+	if stopped {
+		close(rm.pauseChan)
+	}
+}
+
+// managedStartMigration marks the file at siaPath as being migrated.
+func (rm *redundancyMigrator) managedStartMigration(siaPath modules.TurtleDexPath) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.activeFile = siaPath
+}
+
+// managedStatus returns the status of the migration.
+func (rm *redundancyMigrator) managedStatus() modules.RedundancyMigrationStatus {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	status := modules.RedundancyMigrationStatus{
+		PauseEndTime:  rm.pauseStart.Add(rm.pauseDuration),
+		ActiveFile:    rm.activeFile,
+		MigratedFiles: rm.migratedFiles,
+		MigratedSize:  rm.migratedSize,
+	}
+	select {
+	case <-rm.pauseChan:
+	default:
+		status.Paused = true
+	}
+	return status
+}
+
+// PauseRedundancyMigration pauses the migration of files to the erasure coding
+// settings of their redundancy policy for a time duration. A migration that is
+// in progress is finished first.
+func (r *Renter) PauseRedundancyMigration(duration time.Duration) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	r.staticRedundancyMigrator.managedPause(duration)
+	return nil
+}
+
+// ResumeRedundancyMigration resumes the migration of files to the erasure
+// coding settings of their redundancy policy.
+func (r *Renter) ResumeRedundancyMigration() error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	r.staticRedundancyMigrator.managedResume()
+	return nil
+}
+
+// SetDirRedundancyPolicy sets the redundancy policy of the directory at
+// siaPath. An unset policy inherits the policy of the parent directory. The
+// directory's subtree is bubbled afterwards to update the number of files
+// which need to be migrated.
+func (r *Renter) SetDirRedundancyPolicy(siaPath modules.TurtleDexPath, policy modules.RedundancyPolicy) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	err := r.staticFileSystem.SetRedundancyPolicy(siaPath, policy)
+	if err != nil {
+		return errors.AddContext(err, "unable to set redundancy policy")
+	}
+	urp, err := r.managedPrepareForBubble(siaPath, true)
+	if err != nil {
+		return errors.AddContext(err, "unable to prepare subtree for bubble")
+	}
+	return r.tg.Launch(func() {
+		if err := urp.callRefreshAllBlocking(); err != nil {
+			r.log.Printf("Unable to bubble %v after setting its redundancy policy: %v", siaPath, err)
+		}
+		r.staticRedundancyMigrator.callWake()
+	})
+}
+
+// redundancyMigrationTier returns the tier that the file with the provided
+// bubbled metadata needs to be migrated to. If the file doesn't need to be
+// migrated, false is returned.
+func redundancyMigrationTier(policy modules.RedundancyPolicy, siaPath modules.TurtleDexPath, bm siafile.BubbledMetadata, now time.Time) (modules.RedundancyTier, bool) {
+	// Skyfiles are referenced by their skylinks which depend on their
+	// erasure coding settings. Backups, directory snapshots and files which
+	// are being migrated are system files.
+	if bm.NumSkylinks > 0 || bm.ErasureCode == nil {
+		return modules.RedundancyTier{}, false
+	}
+	for _, folder := range []modules.TurtleDexPath{modules.BackupFolder, modules.DirectorySnapshotFolder, modules.RedundancyMigrationFolder, modules.SkynetFolder} {
+		if siaPath.IsWithin(folder) {
+			return modules.RedundancyTier{}, false
+		}
+	}
+	tier, ok := policy.Tier(bm.AccessTime, now)
+	if !ok || tier.Matches(bm.ErasureCode) {
+		return modules.RedundancyTier{}, false
+	}
+	return tier, true
+}
+
+// managedMigrateFile downloads the file at siaPath and uploads it again using
+// the erasure coding settings of tier. Once the upload is available on the
+// network, the new file replaces the original one.
+func (r *Renter) managedMigrateFile(siaPath modules.TurtleDexPath, tier modules.RedundancyTier) (err error) {
+	ec, err := tier.ErasureCode()
+	if err != nil {
+		return errors.AddContext(err, "invalid redundancy tier")
+	}
+	node, err := r.staticFileSystem.OpenTurtleDexFile(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to open file")
+	}
+	defer func() {
+		err = errors.Compose(err, node.Close())
+	}()
+	uid := node.UID()
+
+	// Track the progress.
+	rm := r.staticRedundancyMigrator
+	rm.managedStartMigration(siaPath)
+	defer func() {
+		rm.managedFinishMigration(node.Size(), err == nil)
+	}()
+
+	// Remove leftovers of a previous attempt.
+	migrationPath, err := modules.RedundancyMigrationFolder.Join(string(uid))
+	if err != nil {
+		return err
+	}
+	err = r.DeleteFile(migrationPath)
+	if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+		return errors.AddContext(err, "unable to delete leftover migration")
+	}
+
+	// Stream the file's data into a new upload. Migrations are background
+	// work, so the stream uses the bulk priority.
+	stream, err := r.StreamerByNode(node, false, modules.DownloadPriorityBulk, "")
+	if err != nil {
+		return errors.AddContext(err, "unable to open stream")
+	}
+	defer func() {
+		err = errors.Compose(err, stream.Close())
+	}()
+	up := modules.FileUploadParams{
+		TurtleDexPath: migrationPath,
+		ErasureCode:   ec,
+		CipherType:    crypto.TypeDefaultRenter,
+		UserMetadata:  node.UserMetadata(),
+	}
+	newNode, err := r.callUploadStreamFromReader(up, stream)
+	if err != nil {
+		return errors.AddContext(err, "unable to upload file")
+	}
+	// Migrating a file is not an access, carry over the metadata of the
+	// original file.
+	err = errors.Compose(newNode.SetAccessTime(node.AccessTime()), newNode.SetMode(node.Mode()), newNode.SetLocalPath(node.LocalPath()))
+	err = errors.Compose(err, newNode.Close())
+	if err != nil {
+		return errors.Compose(errors.AddContext(err, "unable to update migrated file"), r.DeleteFile(migrationPath))
+	}
+
+	// Replace the original file.
+	err = r.managedReplaceMigratedFile(siaPath, migrationPath, uid)
+	if err != nil {
+		return errors.Compose(errors.AddContext(err, "unable to replace file"), r.DeleteFile(migrationPath))
+	}
+	r.repairLog.Printf("Migrated %v to %v", siaPath, tier)
+	return nil
+}
+
+// managedNextRedundancyMigration returns the next file within the dir at
+// dirPath that needs to be migrated and the tier it needs to be migrated to.
+// Files in skip are ignored.
+func (r *Renter) managedNextRedundancyMigration(dirPath modules.TurtleDexPath, skip map[modules.TurtleDexPath]struct{}) (modules.TurtleDexPath, modules.RedundancyTier, bool, error) {
+	di, err := r.staticFileSystem.DirInfo(dirPath)
+	if err != nil {
+		return modules.TurtleDexPath{}, modules.RedundancyTier{}, false, err
+	}
+	if di.AggregateNumPendingMigrations == 0 {
+		return modules.TurtleDexPath{}, modules.RedundancyTier{}, false, nil
+	}
+
+	// Check the files of the dir first.
+	if di.NumPendingMigrations > 0 {
+		siaPath, tier, found, err := r.managedNextRedundancyMigrationInDir(dirPath, skip)
+		if err != nil || found {
+			return siaPath, tier, found, err
+		}
+	}
+
+	// Check the sub dirs.
+	subDirs, err := r.managedSubDirectories(dirPath)
+	if err != nil {
+		return modules.TurtleDexPath{}, modules.RedundancyTier{}, false, err
+	}
+	for _, subDir := range subDirs {
+		siaPath, tier, found, err := r.managedNextRedundancyMigration(subDir, skip)
+		if err != nil {
+			r.repairLog.Printf("WARN: unable to check %v for redundancy migrations: %v", subDir, err)
+			continue
+		}
+		if found {
+			return siaPath, tier, true, nil
+		}
+	}
+	return modules.TurtleDexPath{}, modules.RedundancyTier{}, false, nil
+}
+
+// managedNextRedundancyMigrationInDir returns the next file of the dir at
+// dirPath that needs to be migrated. Only files which can be recovered are
+// considered.
+func (r *Renter) managedNextRedundancyMigrationInDir(dirPath modules.TurtleDexPath, skip map[modules.TurtleDexPath]struct{}) (modules.TurtleDexPath, modules.RedundancyTier, bool, error) {
+	policy, err := r.staticFileSystem.RedundancyPolicy(dirPath)
+	if err != nil {
+		return modules.TurtleDexPath{}, modules.RedundancyTier{}, false, errors.AddContext(err, "unable to fetch redundancy policy")
+	}
+	fileinfos, err := r.staticFileSystem.ReadDir(dirPath)
+	if err != nil {
+		return modules.TurtleDexPath{}, modules.RedundancyTier{}, false, err
+	}
+	var siaPaths []modules.TurtleDexPath
+	for _, fi := range fileinfos {
+		if fi.IsDir() || filepath.Ext(fi.Name()) != modules.TurtleDexFileExtension {
+			continue
+		}
+		siaPath, err := dirPath.Join(strings.TrimSuffix(fi.Name(), modules.TurtleDexFileExtension))
+		if err != nil {
+			return modules.TurtleDexPath{}, modules.RedundancyTier{}, false, err
+		}
+		if _, skipped := skip[siaPath]; !skipped {
+			siaPaths = append(siaPaths, siaPath)
+		}
+	}
+	// Ignore errors since they are usually caused by individual files.
+	mds, err := r.managedCalculateFileMetadatas(siaPaths)
+	if err != nil {
+		r.repairLog.Printf("WARN: unable to calculate some file metadatas of %v: %v", dirPath, err)
+	}
+	now := time.Now()
+	for _, md := range mds {
+		if md.bm.Redundancy < 1 {
+			continue // not recoverable
+		}
+		if tier, migrate := redundancyMigrationTier(policy, md.sp, md.bm, now); migrate {
+			return md.sp, tier, true, nil
+		}
+	}
+	return modules.TurtleDexPath{}, modules.RedundancyTier{}, false, nil
+}
+
+// managedReplaceMigratedFile replaces the file at siaPath with the migrated
+// file at migrationPath. If the file at siaPath isn't the file with the
+// provided uid anymore, it was replaced while it was being migrated and the
+// migration is discarded.
+func (r *Renter) managedReplaceMigratedFile(siaPath, migrationPath modules.TurtleDexPath, uid siafile.TurtleDexfileUID) error {
+	dedupRefs, err := r.managedFileDedupReferences(siaPath)
+	if err != nil {
+		r.log.Printf("Unable to fetch dedup references of %v: %v", siaPath, err)
+	}
+
+	// Delete the original file and move the migrated file into its place.
+	// The filesystem checks that the original file wasn't replaced while
+	// holding the lock of its dir.
+	err = r.staticFileSystem.ReplaceFile(migrationPath, siaPath, uid)
+	if errors.Contains(err, filesystem.ErrFileReplaced) {
+		return errors.New("file was replaced during the migration")
+	}
+	if err != nil {
+		return errors.AddContext(err, "unable to move migrated file into place")
+	}
+
+	// Release the dedup references of the original file. Its pieces stay on
+	// the hosts until its contracts expire, but new uploads are no longer
+	// deduplicated against them.
+	err = r.staticDedupIndex.managedRemoveReferences(dedupRefs)
+	if err != nil {
+		r.log.Printf("Unable to release dedup references of %v: %v", siaPath, err)
+	}
+
+	// Update the metadata of both dirs.
+	bubblePaths := r.newUniqueRefreshPaths()
+	for _, sp := range []modules.TurtleDexPath{siaPath, migrationPath} {
+		dirTurtleDexPath, err := sp.Dir()
+		if err == nil {
+			err = bubblePaths.callAdd(dirTurtleDexPath)
+		}
+		if err != nil {
+			r.log.Printf("Unable to add refresh path of %v: %v", sp, err)
+		}
+	}
+	bubblePaths.callRefreshAll()
+	return nil
+}
+
+// threadedRedundancyMigrationLoop migrates files to the erasure coding
+// settings of their redundancy policy one at a time.
+func (r *Renter) threadedRedundancyMigrationLoop() {
+	err := r.tg.Add()
+	if err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	// skip contains the files which failed to migrate. They are retried once
+	// the loop ran out of other files to migrate.
+	rm := r.staticRedundancyMigrator
+	skip := make(map[modules.TurtleDexPath]struct{})
+	for {
+		// Return if the renter has shut down.
+		select {
+		case <-r.tg.StopChan():
+			return
+		default:
+		}
+
+		// Wait until the renter is online to proceed.
+		if !r.managedBlockUntilOnline() {
+			return
+		}
+
+		// Check if the migration has been paused.
+		if paused, pauseChan := rm.managedIsPaused(); paused {
+			r.repairLog.Println("Redundancy migration has been paused")
+			select {
+			case <-r.tg.StopChan():
+				return
+			case <-pauseChan:
+				r.repairLog.Println("Redundancy migration has been resumed")
+			}
+			continue
+		}
+
+		// Migrations upload data so they are paused together with uploads.
+		// Repairs take priority over migrations.
+		if r.uploadHeap.managedIsPaused() || r.uploadHeap.managedLen() > 0 {
+			select {
+			case <-r.tg.StopChan():
+				return
+			case <-time.After(redundancyMigrationInterval):
+			}
+			continue
+		}
+
+		// Find the next file to migrate.
+		siaPath, tier, found, err := r.managedNextRedundancyMigration(modules.RootTurtleDexPath(), skip)
+		if err != nil {
+			r.repairLog.Println("WARN: unable to find next file to migrate:", err)
+			select {
+			case <-r.tg.StopChan():
+				return
+			case <-time.After(redundancyMigrationErrorSleepDuration):
+			}
+			continue
+		}
+		if !found {
+			// Nothing left to migrate, give the skipped files another chance
+			// next time.
+			skip = make(map[modules.TurtleDexPath]struct{})
+			select {
+			case <-r.tg.StopChan():
+				return
+			case <-time.After(redundancyMigrationInterval):
+			case <-rm.wakeChan:
+			}
+			continue
+		}
+
+		// Migrate the file.
+		err = r.managedMigrateFile(siaPath, tier)
+		if err != nil {
+			r.repairLog.Printf("WARN: unable to migrate %v to %v: %v", siaPath, tier, err)
+			skip[siaPath] = struct{}{}
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem/siafile"
+)
+
+// TestRedundancyMigrationTier is a unit test for redundancyMigrationTier.
+func TestRedundancyMigrationTier(t *testing.T) {
+	hot := modules.RedundancyTier{DataPieces: 10, ParityPieces: 20}
+	cold := modules.RedundancyTier{After: time.Hour, DataPieces: 10, ParityPieces: 10}
+	policy := modules.RedundancyPolicy{Tiers: []modules.RedundancyTier{hot, cold}}
+
+	hotEC, err := modules.NewRSSubCode(10, 20, crypto.SegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	coldEC, err := modules.NewRSSubCode(10, 10, crypto.SegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	hotMD := siafile.BubbledMetadata{AccessTime: now, ErasureCode: hotEC}
+	coldMD := siafile.BubbledMetadata{AccessTime: now.Add(-2 * time.Hour), ErasureCode: hotEC}
+	skyMD := coldMD
+	skyMD.NumSkylinks = 1
+	noECMD := siafile.BubbledMetadata{AccessTime: now}
+
+	file := modules.RandomTurtleDexPath()
+	skyfile, err := modules.SkynetFolder.Join("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	migration, err := modules.RedundancyMigrationFolder.Join("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	backup, err := modules.BackupFolder.Join("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		policy  modules.RedundancyPolicy
+		siaPath modules.TurtleDexPath
+		md      siafile.BubbledMetadata
+		tier    modules.RedundancyTier
+		migrate bool
+	}{
+		{"matching", policy, file, hotMD, modules.RedundancyTier{}, false},
+		{"aged", policy, file, coldMD, cold, true},
+		{"no policy", modules.RedundancyPolicy{}, file, coldMD, modules.RedundancyTier{}, false},
+		{"skylinks", policy, file, skyMD, modules.RedundancyTier{}, false},
+		{"no erasure code", policy, file, noECMD, modules.RedundancyTier{}, false},
+		{"skynet folder", policy, skyfile, coldMD, modules.RedundancyTier{}, false},
+		{"migration folder", policy, migration, coldMD, modules.RedundancyTier{}, false},
+		{"backup folder", policy, backup, coldMD, modules.RedundancyTier{}, false},
+	}
+	for _, test := range tests {
+		tier, migrate := redundancyMigrationTier(test.policy, test.siaPath, test.md, now)
+		if migrate != test.migrate || tier != test.tier {
+			t.Errorf("%v: expected %v %v but got %v %v", test.name, test.tier, test.migrate, tier, migrate)
+		}
+	}
+
+	// A file that was migrated to the cold tier shouldn't be migrated again.
+	coldMD.ErasureCode = coldEC
+	if _, migrate := redundancyMigrationTier(policy, file, coldMD, now); migrate {
+		t.Fatal("migrated file shouldn't need another migration")
+	}
+}
+
+// TestRedundancyMigratorPause probes the pause and resume methods of the
+// redundancyMigrator.
+func TestRedundancyMigratorPause(t *testing.T) {
+	rm := newRedundancyMigrator()
+	if paused, _ := rm.managedIsPaused(); paused {
+		t.Fatal("new migrator shouldn't be paused")
+	}
+
+	// Pause and resume the migrator.
+	rm.managedPause(time.Hour)
+	paused, pauseChan := rm.managedIsPaused()
+	if !paused {
+		t.Fatal("migrator should be paused")
+	}
+	status := rm.managedStatus()
+	if !status.Paused || status.PauseEndTime.Before(time.Now().Add(59*time.Minute)) {
+		t.Fatal("unexpected status", status)
+	}
+	rm.managedResume()
+	select {
+	case <-pauseChan:
+	default:
+		t.Fatal("pauseChan should be closed after resuming")
+	}
+	if status := rm.managedStatus(); status.Paused {
+		t.Fatal("migrator shouldn't be paused", status)
+	}
+	// Resuming again should be a no-op.
+	rm.managedResume()
+
+	// The pause should end after its duration.
+	rm.managedPause(100 * time.Millisecond)
+	_, pauseChan = rm.managedIsPaused()
+	select {
+	case <-pauseChan:
+	case <-time.After(10 * time.Second):
+		t.Fatal("pause didn't end")
+	}
+	if paused, _ := rm.managedIsPaused(); paused {
+		t.Fatal("migrator shouldn't be paused")
+	}
+
+	// Finished migrations should be tracked.
+	file := modules.RandomTurtleDexPath()
+	rm.managedStartMigration(file)
+	if status := rm.managedStatus(); !status.ActiveFile.Equals(file) {
+		t.Fatal("wrong active file", status.ActiveFile)
+	}
+	rm.managedFinishMigration(100, true)
+	rm.managedStartMigration(file)
+	rm.managedFinishMigration(100, false)
+	status = rm.managedStatus()
+	if !status.ActiveFile.IsEmpty() || status.MigratedFiles != 1 || status.MigratedSize != 100 {
+		t.Fatal("unexpected status", status)
+	}
+}
@@ -95,6 +95,10 @@ type hostContractor interface {
 	// ChurnStatus returns contract churn stats for the current period.
 	ChurnStatus() modules.ContractorChurnStatus
 
+	// ContractMaintenancePlan returns what contract maintenance would do in
+	// its next iteration with the provided allowance.
+	ContractMaintenancePlan(modules.Allowance) (modules.ContractMaintenancePlan, error)
+
 	// ContractUtility returns the utility field for a given contract, along
 	// with a bool indicating if it exists.
 	ContractUtility(types.TurtleDexPublicKey) (modules.ContractUtility, bool)
@@ -115,6 +119,10 @@ type hostContractor interface {
 	// billing period.
 	PeriodSpending() (modules.ContractorSpending, error)
 
+	// SpendingHistory returns the records of the spending ledger which match
+	// the filter.
+	SpendingHistory(modules.SpendingHistoryFilter) ([]modules.SpendingRecord, error)
+
 	modules.PaymentProvider
 
 	// OldContracts returns the oldContracts of the renter's hostContractor.
@@ -223,8 +231,10 @@ type Renter struct {
 	bubbleUpdatesMu sync.Mutex
 	cachedUtilities cachedUtilities
 
-	// The renter's bandwidth ratelimit.
-	rl *ratelimit.RateLimit
+	// The renter's bandwidth ratelimit and the scheduler which adjusts it
+	// according to the bandwidth schedule.
+	rl                       *ratelimit.RateLimit
+	staticBandwidthScheduler *modules.BandwidthScheduler
 
 	// stats cache related fields.
 	stats     *modules.SkynetStats
@@ -261,10 +271,16 @@ type Renter struct {
 	repairLog                          *persist.Logger
 	staticAccountManager               *accountManager
 	staticAlerter                      *modules.GenericAlerter
+	staticBudgets                      *budgetTracker
+	staticDedupIndex                   *dedupIndex
+	staticDirSnapshots                 *dirSnapshots
 	staticFileSystem                   *filesystem.FileSystem
 	staticFuseManager                  renterFuseManager
+	staticRedundancyMigrator           *redundancyMigrator
+	staticRepairThrottle               *repairThrottle
 	staticSkykeyManager                *skykey.SkykeyManager
 	staticStreamBufferSet              *streamBufferSet
+	staticUploadSessions               *uploadSessions
 	tg                                 threadgroup.ThreadGroup
 	tpool                              modules.TransactionPool
 	wal                                *writeaheadlog.WAL
@@ -578,21 +594,10 @@ func (r *Renter) managedUpdateRenterContractsAndUtilities() {
 }
 
 // setBandwidthLimits will change the bandwidth limits of the renter based on
-// the persist values for the bandwidth.
-func (r *Renter) setBandwidthLimits(downloadSpeed int64, uploadSpeed int64) error {
-	// Input validation.
-	if downloadSpeed < 0 || uploadSpeed < 0 {
-		return errors.New("download/upload rate limit can't be below 0")
-	}
-
-	// Check for sentinel "no limits" value.
-	if downloadSpeed == 0 && uploadSpeed == 0 {
-		r.rl.SetLimits(0, 0, 0)
-	} else {
-		// Set the rate limits according to the provided values.
-		r.rl.SetLimits(downloadSpeed, uploadSpeed, 4*4096)
-	}
-	return nil
+// the persist values for the bandwidth. The default limits apply outside of the
+// windows of the schedule.
+func (r *Renter) setBandwidthLimits(downloadSpeed int64, uploadSpeed int64, schedule modules.BandwidthSchedule) error {
+	return r.staticBandwidthScheduler.SetSchedule(downloadSpeed, uploadSpeed, schedule)
 }
 
 // SetSettings will update the settings for the renter.
@@ -611,6 +616,12 @@ func (r *Renter) SetSettings(s modules.RenterSettings) error {
 	if s.MaxDownloadSpeed < 0 || s.MaxUploadSpeed < 0 {
 		return errors.New("bandwidth limits cannot be negative")
 	}
+	if err := s.BandwidthSchedule.Validate(); err != nil {
+		return err
+	}
+	if err := s.HostDiversity.Validate(); err != nil {
+		return errors.AddContext(err, "invalid host diversity constraints")
+	}
 
 	// Set allowance.
 	err := r.hostContractor.SetAllowance(s.Allowance)
@@ -621,8 +632,14 @@ func (r *Renter) SetSettings(s modules.RenterSettings) error {
 	// Set IPViolationsCheck
 	r.hostDB.SetIPViolationCheck(s.IPViolationCheck)
 
+	// Set the host diversity constraints.
+	err = r.hostDB.SetDiversityConstraints(s.HostDiversity)
+	if err != nil {
+		return err
+	}
+
 	// Set the bandwidth limits.
-	err = r.setBandwidthLimits(s.MaxDownloadSpeed, s.MaxUploadSpeed)
+	err = r.setBandwidthLimits(s.MaxDownloadSpeed, s.MaxUploadSpeed, s.BandwidthSchedule)
 	if err != nil {
 		return err
 	}
@@ -630,6 +647,7 @@ func (r *Renter) SetSettings(s modules.RenterSettings) error {
 	id := r.mu.Lock()
 	r.persist.MaxDownloadSpeed = s.MaxDownloadSpeed
 	r.persist.MaxUploadSpeed = s.MaxUploadSpeed
+	r.persist.BandwidthSchedule = s.BandwidthSchedule
 	err = r.saveSync()
 	r.mu.Unlock(id)
 	if err != nil {
@@ -721,6 +739,77 @@ func (r *Renter) SetFilterMode(lm modules.FilterMode, hosts []types.TurtleDexPub
 	return nil
 }
 
+// HostScoringPolicy returns the policy the renter's hostdb uses to score hosts.
+func (r *Renter) HostScoringPolicy() (modules.HostScoringPolicy, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.HostScoringPolicy{}, err
+	}
+	defer r.tg.Done()
+	return r.hostDB.ScoringPolicy()
+}
+
+// SetHostScoringPolicy sets the policy the renter's hostdb uses to score hosts.
+// The hosttree is rebuilt with the new policy right away.
+func (r *Renter) SetHostScoringPolicy(policy modules.HostScoringPolicy) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if err := r.hostDB.SetScoringPolicy(policy); err != nil {
+		return errors.AddContext(err, "unable to set scoring policy")
+	}
+	r.log.Printf("Host scoring policy set to '%v'", policy.Name)
+	return nil
+}
+
+// HostBenchmarking returns whether the renter's hostdb benchmarks hosts while
+// scanning them.
+func (r *Renter) HostBenchmarking() (bool, error) {
+	if err := r.tg.Add(); err != nil {
+		return false, err
+	}
+	defer r.tg.Done()
+	return r.hostDB.Benchmarking()
+}
+
+// SetHostBenchmarking enables or disables benchmarking hosts while scanning
+// them. Benchmarks of hosts the renter has a contract with include paid
+// throughput samples.
+func (r *Renter) SetHostBenchmarking(enabled bool) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if err := r.hostDB.SetBenchmarking(enabled); err != nil {
+		return errors.AddContext(err, "unable to set host benchmarking")
+	}
+	r.log.Printf("Host benchmarking enabled: %v", enabled)
+	return nil
+}
+
+// HostGroups returns the host groups of the renter's hostdb.
+func (r *Renter) HostGroups() (map[string][]types.TurtleDexPublicKey, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+	return r.hostDB.HostGroups()
+}
+
+// SetHostGroup sets the hosts of a host group of the renter's hostdb. An empty
+// list of hosts removes the group.
+func (r *Renter) SetHostGroup(name string, hosts []types.TurtleDexPublicKey) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if err := r.hostDB.SetHostGroup(name, hosts); err != nil {
+		return errors.AddContext(err, "unable to set host group")
+	}
+	r.log.Printf("Host group '%v' set to %v hosts", name, len(hosts))
+	return nil
+}
+
 // Host returns the host associated with the given public key
 func (r *Renter) Host(spk types.TurtleDexPublicKey) (modules.HostDBEntry, bool, error) {
 	return r.hostDB.Host(spk)
@@ -778,6 +867,12 @@ func (r *Renter) ContractorChurnStatus() modules.ContractorChurnStatus {
 	return r.hostContractor.ChurnStatus()
 }
 
+// ContractMaintenancePlan returns what contract maintenance would do in its
+// next iteration with the provided allowance without doing it.
+func (r *Renter) ContractMaintenancePlan(a modules.Allowance) (modules.ContractMaintenancePlan, error) {
+	return r.hostContractor.ContractMaintenancePlan(a)
+}
+
 // InitRecoveryScan starts scanning the whole blockchain for recoverable
 // contracts within a separate thread.
 func (r *Renter) InitRecoveryScan() error {
@@ -800,6 +895,12 @@ func (r *Renter) PeriodSpending() (modules.ContractorSpending, error) {
 	return r.hostContractor.PeriodSpending()
 }
 
+// SpendingHistory returns the records of the host contractor's spending
+// ledger which match the filter.
+func (r *Renter) SpendingHistory(filter modules.SpendingHistoryFilter) ([]modules.SpendingRecord, error) {
+	return r.hostContractor.SpendingHistory(filter)
+}
+
 // RecoverableContracts returns the host contractor's recoverable contracts.
 func (r *Renter) RecoverableContracts() []modules.RecoverableContract {
 	return r.hostContractor.RecoverableContracts()
@@ -817,21 +918,29 @@ func (r *Renter) Settings() (modules.RenterSettings, error) {
 		return modules.RenterSettings{}, err
 	}
 	defer r.tg.Done()
-	download, upload, _ := r.rl.Limits()
+	download, upload, schedule := r.staticBandwidthScheduler.Schedule()
 	enabled, err := r.hostDB.IPViolationsCheck()
 	if err != nil {
 		return modules.RenterSettings{}, errors.AddContext(err, "error getting IPViolationsCheck:")
 	}
+	diversity, err := r.hostDB.DiversityConstraints()
+	if err != nil {
+		return modules.RenterSettings{}, errors.AddContext(err, "error getting host diversity constraints:")
+	}
 	paused, endTime := r.uploadHeap.managedPauseStatus()
 	return modules.RenterSettings{
 		Allowance:        r.hostContractor.Allowance(),
 		IPViolationCheck: enabled,
+		HostDiversity:    diversity,
 		MaxDownloadSpeed: download,
 		MaxUploadSpeed:   upload,
+
+		BandwidthSchedule: schedule,
 		UploadsStatus: modules.UploadsStatus{
 			Paused:       paused,
 			PauseEndTime: endTime,
 		},
+		RedundancyMigrationStatus: r.staticRedundancyMigrator.managedStatus(),
 	}, nil
 }
 
@@ -1013,6 +1122,8 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 	}
 	r.staticStreamBufferSet = newStreamBufferSet(&r.tg)
 	r.staticUploadChunkDistributionQueue = newUploadChunkDistributionQueue(r)
+	r.staticRedundancyMigrator = newRedundancyMigrator()
+	r.staticBandwidthScheduler = modules.NewBandwidthScheduler(rl)
 	close(r.uploadHeap.pauseChan)
 
 	// Init the statsChan and close it right away to signal that no scan is
@@ -1072,12 +1183,58 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 		return nil, err
 	}
 
+	// Keep the bandwidth limits in sync with the loaded bandwidth schedule.
+	r.staticBandwidthScheduler.Start(r.tg.StopChan())
+
+	// Load the dedup index and close its persist file on shutdown.
+	r.staticDedupIndex, err = newDedupIndex(r.persistDir)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create dedup index")
+	}
+	err = r.tg.AfterStop(r.staticDedupIndex.managedClose)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load the directory snapshots.
+	r.staticDirSnapshots, err = newDirSnapshots(r.persistDir)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to load directory snapshots")
+	}
+
+	// Initialize the upload sessions.
+	r.staticUploadSessions, err = newUploadSessions(r.persistDir)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to initialize upload sessions")
+	}
+
+	// Load the budgets and make sure the spending is saved on shutdown.
+	r.staticBudgets, err = newBudgetTracker(r.persistDir)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to load budgets")
+	}
+	err = r.tg.AfterStop(r.staticBudgets.managedSave)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load the repair settings.
+	r.staticRepairThrottle, err = newRepairThrottle(r.persistDir)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to load repair settings")
+	}
+
 	// After persist is initialized, create the worker pool.
 	r.staticWorkerPool = r.newWorkerPool()
 
 	// Set the worker pool on the contractor.
 	r.hostContractor.UpdateWorkerPool(r.staticWorkerPool)
 
+	// Let the hostdb use the workers to sample the throughput of hosts.
+	if r.hostDB != nil {
+		r.hostDB.SetThroughputSampler(r)
+	}
+
 	// Create the skykey manager.
 	// In testing, keep the skykeys with the rest of the renter data.
 	skykeyManDir := build.SkynetDir()
@@ -1094,6 +1251,9 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 	r.managedUpdateRenterContractsAndUtilities()
 	go r.threadedUpdateRenterContractsAndUtilities()
 
+	// Kick off a thread that removes expired upload sessions.
+	go r.threadedPruneUploadSessions()
+
 	// Spin up background threads which are not depending on the renter being
 	// up-to-date with consensus.
 	if !r.deps.Disrupt("DisableRepairAndHealthLoops") {
@@ -1137,6 +1297,7 @@ func renterAsyncStartup(r *Renter, cs modules.ConsensusSet) error {
 	if !r.deps.Disrupt("DisableRepairAndHealthLoops") {
 		go r.threadedUploadAndRepair()
 		go r.threadedStuckFileLoop()
+		go r.threadedRedundancyMigrationLoop()
 	}
 	// Spin up the snapshot synchronization thread.
 	if !r.deps.Disrupt("DisableSnapshotSync") {
@@ -79,6 +79,10 @@ func equalBubbledAggregateMetadata(md1, md2 ttdxdir.Metadata, delta time.Duratio
 	if md1.AggregateNumSubDirs != md2.AggregateNumSubDirs {
 		return fmt.Errorf("AggregateNumSubDirs not equal, %v and %v", md1.AggregateNumSubDirs, md2.AggregateNumSubDirs)
 	}
+	// Check AggregateRedundantSize
+	if md1.AggregateRedundantSize != md2.AggregateRedundantSize {
+		return fmt.Errorf("AggregateRedundantSize not equal, %v and %v", md1.AggregateRedundantSize, md2.AggregateRedundantSize)
+	}
 	// Check AggregateRemoteHealth
 	if md1.AggregateRemoteHealth != md2.AggregateRemoteHealth {
 		return fmt.Errorf("AggregateRemoteHealth not equal, %v and %v", md1.AggregateRemoteHealth, md2.AggregateRemoteHealth)
@@ -0,0 +1,296 @@
+package renter
+
+// The repair throttle limits the resources used for repairing chunks
+// independently of new uploads. A chunk counts as a repair if some of its
+// pieces were uploaded before. Before the repair loop hands a repair to the
+// workers, it acquires a slot from the throttle. The number of slots limits
+// the number of concurrent repairs and the slots are handed out no faster than
+// the configured number of bytes per second allows. Repairs that can't be
+// started yet are deferred while new uploads keep flowing.
+//
+// In repair-only mode, new uploads are paused. Chunks of new files are dropped
+// from the upload heap and streaming uploads block until the mode is turned off
+// again.
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/persist"
+)
+
+const (
+	// repairPersistFile is the name of the file the repair settings are
+	// persisted to.
+	repairPersistFile = "repair.json"
+
+	// repairPersistVersion is the version of the repair settings' persist
+	// file.
+	repairPersistVersion = "1.5.5"
+)
+
+var (
+	// repairMetadata is the metadata of the repair settings' persist file.
+	repairMetadata = persist.Metadata{
+		Header:  "Renter Repair Settings",
+		Version: repairPersistVersion,
+	}
+
+	// repairThroughputWindow is the amount of time over which the repair
+	// throughput is averaged.
+	repairThroughputWindow = build.Select(build.Var{
+		Standard: 10 * time.Minute,
+		Dev:      time.Minute,
+		Testing:  10 * time.Second,
+	}).(time.Duration)
+)
+
+var (
+	// errRepairThrottleStopped is returned when waiting for the repair
+	// throttle is interrupted by the renter shutting down.
+	errRepairThrottleStopped = errors.New("stopped waiting for repair throttle")
+)
+
+type (
+	// repairSample is the number of bytes repaired at a point in time.
+	repairSample struct {
+		bytes     uint64
+		timestamp time.Time
+	}
+
+	// repairThrottle limits the concurrency and the rate of repairs and keeps
+	// track of the repair throughput.
+	repairThrottle struct {
+		activeChunks uint64
+		nextStart    time.Time
+		samples      []repairSample
+		settings     modules.RepairSettings
+
+		// wakeChan is closed and replaced whenever a slot is released or the
+		// settings change. uploadsChan is closed while new uploads are
+		// allowed.
+		wakeChan    chan struct{}
+		uploadsChan chan struct{}
+
+		staticPath string
+		mu         sync.Mutex
+	}
+)
+
+// newRepairThrottle loads the repair settings from disk or creates a new repair
+// throttle without limits.
+func newRepairThrottle(persistDir string) (*repairThrottle, error) {
+	rt := &repairThrottle{
+		wakeChan:    make(chan struct{}),
+		uploadsChan: make(chan struct{}),
+		staticPath:  filepath.Join(persistDir, repairPersistFile),
+	}
+	err := persist.LoadJSON(repairMetadata, &rt.settings, rt.staticPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.AddContext(err, "failed to load repair settings")
+	}
+	if !rt.settings.RepairOnly {
+		close(rt.uploadsChan)
+	}
+	return rt, nil
+}
+
+// wake wakes up all threads waiting for a slot.
+func (rt *repairThrottle) wake() {
+	close(rt.wakeChan)
+	rt.wakeChan = make(chan struct{})
+}
+
+// managedRepairOnly returns whether new uploads are paused.
+func (rt *repairThrottle) managedRepairOnly() bool {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return rt.settings.RepairOnly
+}
+
+// managedSetSettings updates and persists the repair settings.
+func (rt *repairThrottle) managedSetSettings(settings modules.RepairSettings) error {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if settings.RepairOnly && !rt.settings.RepairOnly {
+		rt.uploadsChan = make(chan struct{})
+	} else if !settings.RepairOnly && rt.settings.RepairOnly {
+		close(rt.uploadsChan)
+	}
+	rt.settings = settings
+	rt.nextStart = time.Time{}
+	rt.wake()
+	return persist.SaveJSON(repairMetadata, rt.settings, rt.staticPath)
+}
+
+// managedStatus returns the settings, the number of active repairs and the
+// average repair throughput in bytes per second.
+func (rt *repairThrottle) managedStatus() (modules.RepairSettings, uint64, uint64) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.pruneSamples()
+	var total uint64
+	for _, sample := range rt.samples {
+		total += sample.bytes
+	}
+	throughput := uint64(float64(total) / repairThroughputWindow.Seconds())
+	return rt.settings, rt.activeChunks, throughput
+}
+
+// pruneSamples removes the samples which are older than the throughput
+// window.
+func (rt *repairThrottle) pruneSamples() {
+	i := 0
+	for i < len(rt.samples) && time.Since(rt.samples[i].timestamp) > repairThroughputWindow {
+		i++
+	}
+	rt.samples = rt.samples[i:]
+}
+
+// managedTryStart acquires a slot for a repair of the given number of bytes if
+// the limits allow it. It returns false if the repair can't be started yet.
+func (rt *repairThrottle) managedTryStart(bytes uint64) bool {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if rt.settings.MaxConcurrentChunks > 0 && rt.activeChunks >= rt.settings.MaxConcurrentChunks {
+		return false
+	}
+	now := time.Now()
+	if rt.settings.MaxBytesPerSecond > 0 {
+		if now.Before(rt.nextStart) {
+			return false
+		}
+		if rt.nextStart.Before(now) {
+			rt.nextStart = now
+		}
+		rt.nextStart = rt.nextStart.Add(time.Duration(float64(bytes) / float64(rt.settings.MaxBytesPerSecond) * float64(time.Second)))
+	}
+	rt.activeChunks++
+	return true
+}
+
+// managedChunkDone releases the slot of a repair and records the number of
+// bytes which were repaired.
+func (rt *repairThrottle) managedChunkDone(repairedBytes uint64) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if rt.activeChunks == 0 {
+		build.Critical("repair slot released without being acquired")
+		return
+	}
+	rt.activeChunks--
+	if repairedBytes > 0 {
+		rt.samples = append(rt.samples, repairSample{
+			bytes:     repairedBytes,
+			timestamp: time.Now(),
+		})
+		rt.pruneSamples()
+	}
+	rt.wake()
+}
+
+// managedWait blocks until a slot might have become available or until
+// something is sent on newWork. The latter allows the caller to process other
+// work, such as new uploads, while repairs are deferred.
+func (rt *repairThrottle) managedWait(stop, newWork <-chan struct{}) error {
+	rt.mu.Lock()
+	wakeChan := rt.wakeChan
+	var timer <-chan time.Time
+	concurrencyLimited := rt.settings.MaxConcurrentChunks > 0 && rt.activeChunks >= rt.settings.MaxConcurrentChunks
+	if !concurrencyLimited {
+		timer = time.After(time.Until(rt.nextStart))
+	}
+	rt.mu.Unlock()
+
+	select {
+	case <-stop:
+		return errRepairThrottleStopped
+	case <-wakeChan:
+	case <-timer:
+	case <-newWork:
+	}
+	return nil
+}
+
+// managedWaitForUploads blocks while the renter is in repair-only mode.
+func (rt *repairThrottle) managedWaitForUploads(stop <-chan struct{}) error {
+	rt.mu.Lock()
+	uploadsChan := rt.uploadsChan
+	rt.mu.Unlock()
+
+	select {
+	case <-stop:
+		return errRepairThrottleStopped
+	case <-uploadsChan:
+	}
+	return nil
+}
+
+// RepairStatus returns the repair settings of the renter together with the
+// amount of data that needs to be repaired and an estimate of how long the
+// repairs take at the current throughput.
+func (r *Renter) RepairStatus() (modules.RepairStatus, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.RepairStatus{}, err
+	}
+	defer r.tg.Done()
+	dir, err := r.staticFileSystem.OpenTurtleDexDir(modules.RootTurtleDexPath())
+	if err != nil {
+		return modules.RepairStatus{}, errors.AddContext(err, "unable to open root directory")
+	}
+	defer dir.Close()
+	md, err := dir.Metadata()
+	if err != nil {
+		return modules.RepairStatus{}, errors.AddContext(err, "unable to get root directory metadata")
+	}
+	settings, active, throughput := r.staticRepairThrottle.managedStatus()
+	stuckInHeap, _ := r.uploadHeap.managedNumStuckChunks()
+	eta, known := modules.RepairETA(md.AggregateRepairSize+md.AggregateStuckSize, throughput)
+	return modules.RepairStatus{
+		RepairSettings: settings,
+
+		ActiveChunks: active,
+		RepairBytes:  md.AggregateRepairSize,
+		StuckBytes:   md.AggregateStuckSize,
+
+		NumStuckChunks:               md.AggregateNumStuckChunks,
+		StuckChunksInHeap:            uint64(stuckInHeap),
+		RecentlySuccessfulStuckFiles: uint64(r.stuckStack.managedLen()),
+
+		Throughput: throughput,
+		ETA:        eta,
+		ETAUnknown: !known,
+	}, nil
+}
+
+// SetRepairSettings sets the limits for repairs and whether new uploads are
+// paused in favor of repairs.
+func (r *Renter) SetRepairSettings(settings modules.RepairSettings) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	wasRepairOnly := r.staticRepairThrottle.managedRepairOnly()
+	if err := r.staticRepairThrottle.managedSetSettings(settings); err != nil {
+		return errors.AddContext(err, "unable to save repair settings")
+	}
+	// When new uploads are resumed, the chunks of new files which were dropped
+	// from the upload heap need to be found again.
+	if wasRepairOnly && !settings.RepairOnly {
+		r.directoryHeap.managedReset()
+		if err := r.managedPushUnexploredDirectory(modules.RootTurtleDexPath()); err != nil {
+			return errors.AddContext(err, "unable to push root directory")
+		}
+		select {
+		case r.uploadHeap.newUploads <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package renter
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/modules"
+)
+
+// TestRepairThrottle tests limiting, pausing uploads and persisting the repair
+// throttle.
+func TestRepairThrottle(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	rt, err := newRepairThrottle(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without limits, repairs can always be started and uploads are not
+	// paused.
+	for i := 0; i < 10; i++ {
+		if !rt.managedTryStart(modules.SectorSize) {
+			t.Fatal("repair should be allowed")
+		}
+	}
+	if err := rt.managedWaitForUploads(nil); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		rt.managedChunkDone(modules.SectorSize)
+	}
+	settings, active, throughput := rt.managedStatus()
+	if active != 0 || throughput == 0 || settings != (modules.RepairSettings{}) {
+		t.Fatal("unexpected status", settings, active, throughput)
+	}
+
+	// Limit the number of concurrent repairs.
+	err = rt.managedSetSettings(modules.RepairSettings{MaxConcurrentChunks: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rt.managedTryStart(modules.SectorSize) || !rt.managedTryStart(modules.SectorSize) {
+		t.Fatal("repairs should be allowed")
+	}
+	if rt.managedTryStart(modules.SectorSize) {
+		t.Fatal("third repair shouldn't be allowed")
+	}
+
+	// Releasing a slot wakes up waiting threads.
+	done := make(chan error)
+	go func() {
+		done <- rt.managedWait(nil, nil)
+	}()
+	rt.managedChunkDone(0)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Minute):
+		t.Fatal("waiting thread wasn't woken up")
+	}
+	if !rt.managedTryStart(modules.SectorSize) {
+		t.Fatal("repair should be allowed after a slot was released")
+	}
+	rt.managedChunkDone(0)
+	rt.managedChunkDone(0)
+
+	// Waiting is interrupted by the stop channel.
+	if !rt.managedTryStart(modules.SectorSize) || !rt.managedTryStart(modules.SectorSize) {
+		t.Fatal("repairs should be allowed")
+	}
+	stop := make(chan struct{})
+	close(stop)
+	if err := rt.managedWait(stop, nil); !errors.Contains(err, errRepairThrottleStopped) {
+		t.Fatal("expected errRepairThrottleStopped", err)
+	}
+
+	// Waiting is interrupted by new work even though the concurrency limit
+	// doesn't set a timer.
+	newWork := make(chan struct{}, 1)
+	newWork <- struct{}{}
+	if err := rt.managedWait(nil, newWork); err != nil {
+		t.Fatal(err)
+	}
+	rt.managedChunkDone(0)
+	rt.managedChunkDone(0)
+
+	// Limit the repair rate. The first repair starts immediately and delays
+	// the next one by the time it takes to repair its bytes.
+	err = rt.managedSetSettings(modules.RepairSettings{MaxBytesPerSecond: modules.SectorSize})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rt.managedTryStart(modules.SectorSize) {
+		t.Fatal("first repair should be allowed")
+	}
+	if rt.managedTryStart(modules.SectorSize) {
+		t.Fatal("second repair should be delayed")
+	}
+	start := time.Now()
+	if err := rt.managedWait(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) < 500*time.Millisecond {
+		t.Fatal("wait returned too early", time.Since(start))
+	}
+	if !rt.managedTryStart(modules.SectorSize) {
+		t.Fatal("second repair should be allowed after waiting")
+	}
+	rt.managedChunkDone(0)
+	rt.managedChunkDone(0)
+
+	// Pause new uploads.
+	err = rt.managedSetSettings(modules.RepairSettings{RepairOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rt.managedRepairOnly() {
+		t.Fatal("repair-only mode should be enabled")
+	}
+	if err := rt.managedWaitForUploads(stop); !errors.Contains(err, errRepairThrottleStopped) {
+		t.Fatal("uploads should be paused", err)
+	}
+
+	// The settings are persisted.
+	rt, err = newRepairThrottle(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rt.managedRepairOnly() {
+		t.Fatal("repair-only mode wasn't persisted")
+	}
+
+	// Resuming uploads unblocks waiting uploads.
+	go func() {
+		done <- rt.managedWaitForUploads(nil)
+	}()
+	if err := rt.managedSetSettings(modules.RepairSettings{}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Minute):
+		t.Fatal("upload wasn't resumed")
+	}
+}
@@ -112,7 +112,12 @@ func baseSectorUploadParamsFromSUP(sup modules.SkyfileUploadParameters) (modules
 	// encryption. This should cause all of the pieces to have the same Merkle
 	// root, which is critical to making the file discoverable to viewnodes and
 	// also resilient to host failures.
-	return fileUploadParams(sup.TurtleDexPath, 1, int(sup.BaseChunkRedundancy)-1, sup.Force, crypto.TypePlain)
+	up, err := fileUploadParams(sup.TurtleDexPath, 1, int(sup.BaseChunkRedundancy)-1, sup.Force, crypto.TypePlain)
+	if err != nil {
+		return modules.FileUploadParams{}, err
+	}
+	up.BudgetIdentity = sup.BudgetIdentity
+	return up, nil
 }
 
 // streamerFromReader wraps a bytes.Reader to give it a Close() method, which
@@ -505,6 +510,7 @@ func (r *Renter) managedUploadSkyfileLargeFile(sup modules.SkyfileUploadParamete
 	if err != nil {
 		return modules.Skylink{}, errors.AddContext(err, "unable to create FileUploadParams for large file")
 	}
+	fup.BudgetIdentity = sup.BudgetIdentity
 
 	// Generate a Cipher Key for the FileUploadParams.
 	err = generateCipherKey(&fup, sup)
@@ -553,8 +559,9 @@ func (r *Renter) managedUploadSkyfileLargeFile(sup modules.SkyfileUploadParamete
 }
 
 // DownloadByRoot will fetch data using the merkle root of that data. This uses
-// all of the async worker primitives to improve speed and throughput.
-func (r *Renter) DownloadByRoot(root crypto.Hash, offset, length uint64, timeout time.Duration, pricePerMS types.Currency) ([]byte, error) {
+// all of the async worker primitives to improve speed and throughput. If the
+// priority is not set, the download is interactive.
+func (r *Renter) DownloadByRoot(root crypto.Hash, offset, length uint64, timeout time.Duration, pricePerMS types.Currency, priority modules.DownloadPriority) ([]byte, error) {
 	if err := r.tg.Add(); err != nil {
 		return nil, err
 	}
@@ -574,7 +581,8 @@ func (r *Renter) DownloadByRoot(root crypto.Hash, offset, length uint64, timeout
 	}
 
 	// Fetch the data
-	data, err := r.managedDownloadByRoot(ctx, root, offset, length, pricePerMS)
+	priority = downloadPriorityOrDefault(priority, modules.DownloadPriorityInteractive)
+	data, err := r.managedDownloadByRoot(ctx, root, offset, length, pricePerMS, priority)
 	if errors.Contains(err, ErrProjectTimedOut) {
 		err = errors.AddContext(err, fmt.Sprintf("timed out after %vs", timeout.Seconds()))
 	}
@@ -582,8 +590,8 @@ func (r *Renter) DownloadByRoot(root crypto.Hash, offset, length uint64, timeout
 }
 
 // DownloadSkylink will take a link and turn it into the metadata and data of a
-// download.
-func (r *Renter) DownloadSkylink(link modules.Skylink, timeout time.Duration, pricePerMS types.Currency) (modules.SkyfileLayout, modules.SkyfileMetadata, modules.Streamer, error) {
+// download. If the priority is not set, the download is interactive.
+func (r *Renter) DownloadSkylink(link modules.Skylink, timeout time.Duration, pricePerMS types.Currency, priority modules.DownloadPriority) (modules.SkyfileLayout, modules.SkyfileMetadata, modules.Streamer, error) {
 	if err := r.tg.Add(); err != nil {
 		return modules.SkyfileLayout{}, modules.SkyfileMetadata{}, nil, err
 	}
@@ -595,7 +603,8 @@ func (r *Renter) DownloadSkylink(link modules.Skylink, timeout time.Duration, pr
 	}
 
 	// Download the data
-	layout, metadata, streamer, err := r.managedDownloadSkylink(link, timeout, pricePerMS)
+	priority = downloadPriorityOrDefault(priority, modules.DownloadPriorityInteractive)
+	layout, metadata, streamer, err := r.managedDownloadSkylink(link, timeout, pricePerMS, priority)
 	if errors.Contains(err, ErrProjectTimedOut) {
 		err = errors.AddContext(err, fmt.Sprintf("timed out after %vs", timeout.Seconds()))
 	}
@@ -603,8 +612,9 @@ func (r *Renter) DownloadSkylink(link modules.Skylink, timeout time.Duration, pr
 }
 
 // DownloadSkylinkBaseSector will take a link and turn it into the data of
-// a basesector without any decoding of the metadata, fanout, or decryption.
-func (r *Renter) DownloadSkylinkBaseSector(link modules.Skylink, timeout time.Duration, pricePerMS types.Currency) (modules.Streamer, error) {
+// a basesector without any decoding of the metadata, fanout, or decryption. If
+// the priority is not set, the download is interactive.
+func (r *Renter) DownloadSkylinkBaseSector(link modules.Skylink, timeout time.Duration, pricePerMS types.Currency, priority modules.DownloadPriority) (modules.Streamer, error) {
 	if err := r.tg.Add(); err != nil {
 		return nil, err
 	}
@@ -630,13 +640,14 @@ func (r *Renter) DownloadSkylinkBaseSector(link modules.Skylink, timeout time.Du
 	}
 
 	// Download the base sector
-	baseSector, err := r.managedDownloadByRoot(ctx, link.MerkleRoot(), offset, fetchSize, pricePerMS)
+	priority = downloadPriorityOrDefault(priority, modules.DownloadPriorityInteractive)
+	baseSector, err := r.managedDownloadByRoot(ctx, link.MerkleRoot(), offset, fetchSize, pricePerMS, priority)
 	return StreamerFromSlice(baseSector), err
 }
 
 // managedDownloadSkylink will take a link and turn it into the metadata and
 // data of a download.
-func (r *Renter) managedDownloadSkylink(link modules.Skylink, timeout time.Duration, pricePerMS types.Currency) (modules.SkyfileLayout, modules.SkyfileMetadata, modules.Streamer, error) {
+func (r *Renter) managedDownloadSkylink(link modules.Skylink, timeout time.Duration, pricePerMS types.Currency, priority modules.DownloadPriority) (modules.SkyfileLayout, modules.SkyfileMetadata, modules.Streamer, error) {
 	if r.deps.Disrupt("resolveSkylinkToFixture") {
 		sf, err := fixtures.LoadSkylinkFixture(link)
 		if err != nil {
@@ -649,17 +660,17 @@ func (r *Renter) managedDownloadSkylink(link modules.Skylink, timeout time.Durat
 	// skip the lookup procedure and use any data that other threads have
 	// cached.
 	id := link.DataSourceID()
-	streamer, exists := r.staticStreamBufferSet.callNewStreamFromID(id, 0, timeout)
+	streamer, exists := r.staticStreamBufferSet.callNewStreamFromID(id, 0, timeout, priority)
 	if exists {
 		return streamer.Layout(), streamer.Metadata(), streamer, nil
 	}
 
 	// Create the data source and add it to the stream buffer set.
-	dataSource, err := r.skylinkDataSource(link, timeout, pricePerMS)
+	dataSource, err := r.skylinkDataSource(link, timeout, pricePerMS, priority)
 	if err != nil {
 		return modules.SkyfileLayout{}, modules.SkyfileMetadata{}, nil, errors.AddContext(err, "unable to create data source for skylink")
 	}
-	stream := r.staticStreamBufferSet.callNewStream(dataSource, 0, timeout, pricePerMS)
+	stream := r.staticStreamBufferSet.callNewStream(dataSource, 0, timeout, pricePerMS, priority)
 	return dataSource.Layout(), dataSource.Metadata(), stream, nil
 }
 
@@ -671,8 +682,9 @@ func (r *Renter) PinSkylink(skylink modules.Skylink, lup modules.SkyfileUploadPa
 		return ErrSkylinkBlocked
 	}
 
-	// Fetch the leading chunk.
-	baseSector, err := r.DownloadByRoot(skylink.MerkleRoot(), 0, modules.SectorSize, timeout, pricePerMS)
+	// Fetch the leading chunk. Pinning is background work, so it uses the
+	// bulk priority.
+	baseSector, err := r.DownloadByRoot(skylink.MerkleRoot(), 0, modules.SectorSize, timeout, pricePerMS, modules.DownloadPriorityBulk)
 	if err != nil {
 		return errors.AddContext(err, "unable to fetch base sector of skylink")
 	}
@@ -705,6 +717,7 @@ func (r *Renter) PinSkylink(skylink modules.Skylink, lup modules.SkyfileUploadPa
 		DisablePartialChunk: true,  // must be set to true - partial chunks change, content addressed files must not change.
 		Repair:              false, // indicates whether this is a repair operation
 		CipherType:          crypto.TypePlain,
+		BudgetIdentity:      lup.BudgetIdentity,
 	}
 
 	// Re-encrypt the baseSector for upload and add the fanout key to the fup.
@@ -754,11 +767,11 @@ func (r *Renter) PinSkylink(skylink modules.Skylink, lup modules.SkyfileUploadPa
 	}
 
 	// Create the data source and add it to the stream buffer set.
-	dataSource, err := r.skylinkDataSource(skylink, timeout, pricePerMS)
+	dataSource, err := r.skylinkDataSource(skylink, timeout, pricePerMS, modules.DownloadPriorityBulk)
 	if err != nil {
 		return errors.AddContext(err, "unable to create data source for skylink")
 	}
-	stream := r.staticStreamBufferSet.callNewStream(dataSource, 0, timeout, pricePerMS)
+	stream := r.staticStreamBufferSet.callNewStream(dataSource, 0, timeout, pricePerMS, modules.DownloadPriorityBulk)
 
 	// Upload directly from the stream.
 	fileNode, err := r.callUploadStreamFromReader(fup, stream)
@@ -951,6 +964,19 @@ func (r *Renter) UploadSkyfile(sup modules.SkyfileUploadParameters, reader modul
 		return modules.Skylink{}, errors.AddContext(err, "unable to upload skyfile")
 	}
 
+	// Fail early if the skyfile's directory can't fit the base sector. The
+	// quota is enforced for the rest of the data while it is streamed.
+	if !sup.DryRun {
+		bsup, err := baseSectorUploadParamsFromSUP(sup)
+		if err != nil {
+			return modules.Skylink{}, errors.AddContext(err, "unable to upload skyfile")
+		}
+		err = r.managedCheckDirQuotas(sup.TurtleDexPath, 0, 1, bsup.ErasureCode, false)
+		if err != nil {
+			return modules.Skylink{}, errors.AddContext(err, "unable to upload skyfile")
+		}
+	}
+
 	// defer a function that cleans up the siafiles after a failed upload
 	// attempt or after a dry run
 	defer func() {
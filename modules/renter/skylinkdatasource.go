@@ -83,7 +83,7 @@ func (sds *skylinkDataSource) SilentClose() {
 }
 
 // ReadStream implements streamBufferDataSource
-func (sds *skylinkDataSource) ReadStream(ctx context.Context, off, fetchSize uint64, pricePerMS types.Currency) chan *readResponse {
+func (sds *skylinkDataSource) ReadStream(ctx context.Context, off, fetchSize uint64, pricePerMS types.Currency, priority modules.DownloadPriority) chan *readResponse {
 	// Prepare the response channel
 	responseChan := make(chan *readResponse, 1)
 	if off+fetchSize > sds.staticLayout.Filesize {
@@ -136,7 +136,7 @@ func (sds *skylinkDataSource) ReadStream(ctx context.Context, off, fetchSize uin
 		}
 
 		// Schedule the download.
-		respChan, err := sds.staticChunkFetchers[chunkIndex].Download(ctx, pricePerMS, offsetInChunk, downloadSize)
+		respChan, err := sds.staticChunkFetchers[chunkIndex].Download(ctx, pricePerMS, priority, offsetInChunk, downloadSize)
 		if err != nil {
 			responseChan <- &readResponse{
 				staticErr: errors.AddContext(err, "unable to start download"),
@@ -182,7 +182,7 @@ func (sds *skylinkDataSource) ReadStream(ctx context.Context, off, fetchSize uin
 }
 
 // managedDownloadByRoot will fetch data using the merkle root of that data.
-func (r *Renter) managedDownloadByRoot(ctx context.Context, root crypto.Hash, offset, length uint64, pricePerMS types.Currency) ([]byte, error) {
+func (r *Renter) managedDownloadByRoot(ctx context.Context, root crypto.Hash, offset, length uint64, pricePerMS types.Currency, priority modules.DownloadPriority) ([]byte, error) {
 	// Create a context that dies when the function ends, this will cancel all
 	// of the worker jobs that get created by this function.
 	ctx, cancel := context.WithCancel(ctx)
@@ -209,7 +209,7 @@ func (r *Renter) managedDownloadByRoot(ctx context.Context, root crypto.Hash, of
 	//
 	// NOTE: we pass in the provided context here, if the user imposed a timeout
 	// on the download request, this will fire if it takes too long.
-	respChan, err := pcws.managedDownload(ctx, pricePerMS, offset, length)
+	respChan, err := pcws.managedDownload(ctx, pricePerMS, priority, offset, length)
 	if err != nil {
 		return nil, errors.AddContext(err, "unable to start download")
 	}
@@ -235,7 +235,7 @@ func (r *Renter) managedDownloadByRoot(ctx context.Context, root crypto.Hash, of
 // timeout. This can be optimized to always create the data source when it was
 // requested, but we should only do so after gathering some real world feedback
 // that indicates we would benefit from this.
-func (r *Renter) skylinkDataSource(link modules.Skylink, timeout time.Duration, pricePerMS types.Currency) (streamBufferDataSource, error) {
+func (r *Renter) skylinkDataSource(link modules.Skylink, timeout time.Duration, pricePerMS types.Currency, priority modules.DownloadPriority) (streamBufferDataSource, error) {
 	// Create the context using the given timeout, this timeout should only be
 	// applicable to downloading the base sector because the data source might
 	// outlive the request.
@@ -258,7 +258,7 @@ func (r *Renter) skylinkDataSource(link modules.Skylink, timeout time.Duration,
 	//
 	// NOTE: we pass in the provided context here, if the user imposed a timeout
 	// on the download request, this will fire if it takes too long.
-	baseSector, err := r.managedDownloadByRoot(ctx, link.MerkleRoot(), offset, fetchSize, pricePerMS)
+	baseSector, err := r.managedDownloadByRoot(ctx, link.MerkleRoot(), offset, fetchSize, pricePerMS, priority)
 	if err != nil {
 		return nil, errors.AddContext(err, "unable to download base sector")
 	}
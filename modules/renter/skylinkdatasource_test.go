@@ -23,7 +23,7 @@ type mockProjectChunkWorkerSet struct {
 }
 
 // Download implements the chunkFetcher interface.
-func (m *mockProjectChunkWorkerSet) Download(ctx context.Context, pricePerMS types.Currency, offset, length uint64) (chan *downloadResponse, error) {
+func (m *mockProjectChunkWorkerSet) Download(ctx context.Context, pricePerMS types.Currency, priority modules.DownloadPriority, offset, length uint64) (chan *downloadResponse, error) {
 	m.staticDownloadResponseChan <- &downloadResponse{
 		data: m.staticDownloadData[offset : offset+length],
 		err:  nil,
@@ -99,7 +99,7 @@ func testSkylinkDataSourceSmallFile(t *testing.T) {
 	}
 
 	// verify invalid offset and length
-	responseChan := sds.ReadStream(context.Background(), 1, modules.SectorSize, types.ZeroCurrency)
+	responseChan := sds.ReadStream(context.Background(), 1, modules.SectorSize, types.ZeroCurrency, modules.DownloadPriorityInteractive)
 	select {
 	case resp := <-responseChan:
 		if resp == nil || resp.staticErr == nil {
@@ -114,7 +114,7 @@ func testSkylinkDataSourceSmallFile(t *testing.T) {
 
 	length := fastrand.Uint64n(datasize/4) + 1
 	offset := fastrand.Uint64n(datasize - length)
-	responseChan = sds.ReadStream(context.Background(), offset, length, types.ZeroCurrency)
+	responseChan = sds.ReadStream(context.Background(), offset, length, types.ZeroCurrency, modules.DownloadPriorityInteractive)
 	select {
 	case resp := <-responseChan:
 		if resp == nil || resp.staticErr != nil {
@@ -198,7 +198,7 @@ func testSkylinkDataSourceLargeFile(t *testing.T) {
 	length := fastrand.Uint64n(datasize/4) + 1
 	offset := fastrand.Uint64n(datasize - length)
 
-	responseChan := sds.ReadStream(context.Background(), offset, length, types.ZeroCurrency)
+	responseChan := sds.ReadStream(context.Background(), offset, length, types.ZeroCurrency, modules.DownloadPriorityInteractive)
 	select {
 	case resp := <-responseChan:
 		if resp == nil || resp.staticErr != nil {
@@ -248,7 +248,7 @@ func (r *Renter) DownloadBackup(dst string, name string) (err error) {
 	if err != nil {
 		return err
 	}
-	s := r.managedStreamer(snap, false)
+	s := r.managedStreamer(snap, false, modules.DownloadPriorityBulk, "")
 	_, err = io.Copy(dstFile, s)
 	return errors.Compose(err, s.Close())
 }
@@ -131,7 +131,7 @@ type streamBufferDataSource interface {
 
 	// ReadStream allows the stream buffer to request specific data chunks from
 	// the data source. It returns a channel containing a read response.
-	ReadStream(context.Context, uint64, uint64, types.Currency) chan *readResponse
+	ReadStream(context.Context, uint64, uint64, types.Currency, modules.DownloadPriority) chan *readResponse
 }
 
 // readResponse is a helper struct that is returned when reading from the data
@@ -189,6 +189,11 @@ type streamBuffer struct {
 	// creation and deletion of the streamBuffer.
 	externRefCount uint64
 
+	// priority is the highest download priority of all streams that were
+	// created for the stream buffer. It is used for all data sections that
+	// are fetched from the data source.
+	priority modules.DownloadPriority
+
 	mu                    sync.Mutex
 	staticTG              threadgroup.ThreadGroup
 	staticDataSize        uint64
@@ -232,7 +237,10 @@ func newStreamBufferSet(tg *threadgroup.ThreadGroup) *streamBufferSet {
 // Each stream has a separate LRU for determining what data to buffer. Because
 // the LRU is distinct to the stream, the shared cache feature will not result
 // in one stream evicting data from another stream's LRU.
-func (sbs *streamBufferSet) callNewStream(dataSource streamBufferDataSource, initialOffset uint64, timeout time.Duration, pricePerMS types.Currency) *stream {
+//
+// If the stream buffer already exists with a lower priority, its priority is
+// raised to the priority of the new stream.
+func (sbs *streamBufferSet) callNewStream(dataSource streamBufferDataSource, initialOffset uint64, timeout time.Duration, pricePerMS types.Currency, priority modules.DownloadPriority) *stream {
 	// Grab the streamBuffer for the provided sourceID. If no streamBuffer for
 	// the sourceID exists, create a new one.
 	sourceID := dataSource.ID()
@@ -241,6 +249,7 @@ func (sbs *streamBufferSet) callNewStream(dataSource streamBufferDataSource, ini
 	if !exists {
 		streamBuf = &streamBuffer{
 			dataSections: make(map[uint64]*dataSection),
+			priority:     priority,
 
 			staticDataSize:        dataSource.DataSize(),
 			staticDataSource:      dataSource,
@@ -257,6 +266,7 @@ func (sbs *streamBufferSet) callNewStream(dataSource streamBufferDataSource, ini
 	}
 	streamBuf.externRefCount++
 	sbs.mu.Unlock()
+	streamBuf.managedRaisePriority(priority)
 	return streamBuf.managedPrepareNewStream(initialOffset, timeout)
 }
 
@@ -264,7 +274,7 @@ func (sbs *streamBufferSet) callNewStream(dataSource streamBufferDataSource, ini
 // buffer exists for the given data source id. If so, a new stream will be
 // created using the data source, and the bool will be set to 'true'. Otherwise,
 // the stream returned will be nil and the bool will be set to 'false'.
-func (sbs *streamBufferSet) callNewStreamFromID(id modules.DataSourceID, initialOffset uint64, timeout time.Duration) (*stream, bool) {
+func (sbs *streamBufferSet) callNewStreamFromID(id modules.DataSourceID, initialOffset uint64, timeout time.Duration, priority modules.DownloadPriority) (*stream, bool) {
 	sbs.mu.Lock()
 	streamBuf, exists := sbs.streams[id]
 	if !exists {
@@ -273,6 +283,7 @@ func (sbs *streamBufferSet) callNewStreamFromID(id modules.DataSourceID, initial
 	}
 	streamBuf.externRefCount++
 	sbs.mu.Unlock()
+	streamBuf.managedRaisePriority(priority)
 	return streamBuf.managedPrepareNewStream(initialOffset, timeout), true
 }
 
@@ -527,6 +538,17 @@ func (sb *streamBuffer) managedPrepareNewStream(initialOffset uint64, timeout ti
 	return stream
 }
 
+// managedRaisePriority raises the priority of the stream buffer if the provided
+// priority is higher. Data sections that are already being fetched keep their
+// priority.
+func (sb *streamBuffer) managedRaisePriority(priority modules.DownloadPriority) {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	if priority > sb.priority {
+		sb.priority = priority
+	}
+}
+
 // newDataSection will create a new data section for the streamBuffer and spin
 // up a goroutine to pull the data from the data source.
 func (sb *streamBuffer) newDataSection(index uint64) *dataSection {
@@ -552,6 +574,7 @@ func (sb *streamBuffer) newDataSection(index uint64) *dataSection {
 		externData:    make([]byte, fetchSize),
 	}
 	sb.dataSections[index] = ds
+	priority := sb.priority
 
 	// Perform the data fetch in a goroutine. The dataAvailable channel will be
 	// closed when the data is available.
@@ -567,7 +590,7 @@ func (sb *streamBuffer) newDataSection(index uint64) *dataSection {
 		defer sb.staticTG.Done()
 
 		// Grab the data from the data source.
-		responseChan := sb.staticDataSource.ReadStream(sb.staticTG.StopCtx(), index*dataSectionSize, fetchSize, sb.staticPricePerMS)
+		responseChan := sb.staticDataSource.ReadStream(sb.staticTG.StopCtx(), index*dataSectionSize, fetchSize, sb.staticPricePerMS, priority)
 
 		select {
 		case response := <-responseChan:
@@ -70,7 +70,7 @@ func (mds *mockDataSource) RequestSize() uint64 {
 }
 
 // ReadStream implements streamBufferDataSource.
-func (mds *mockDataSource) ReadStream(ctx context.Context, offset, fetchSize uint64, pricePerMS types.Currency) chan *readResponse {
+func (mds *mockDataSource) ReadStream(ctx context.Context, offset, fetchSize uint64, pricePerMS types.Currency, priority modules.DownloadPriority) chan *readResponse {
 	mds.mu.Lock()
 	defer mds.mu.Unlock()
 
@@ -107,6 +107,44 @@ func (mds *mockDataSource) SilentClose() {
 	mds.mu.Unlock()
 }
 
+// TestStreamBufferPriority checks that a stream buffer uses the highest
+// priority of its streams.
+func TestStreamBufferPriority(t *testing.T) {
+	var tg threadgroup.ThreadGroup
+	data := fastrand.Bytes(100)
+	dataSource := newMockDataSource(data, 10)
+	sbs := newStreamBufferSet(&tg)
+
+	// priority returns the priority of the stream buffer of the data source.
+	priority := func() modules.DownloadPriority {
+		sbs.mu.Lock()
+		sb := sbs.streams[dataSource.ID()]
+		sbs.mu.Unlock()
+		sb.mu.Lock()
+		defer sb.mu.Unlock()
+		return sb.priority
+	}
+
+	sbs.callNewStream(dataSource, 0, 0, types.ZeroCurrency, modules.DownloadPriorityBulk)
+	if p := priority(); p != modules.DownloadPriorityBulk {
+		t.Fatal("unexpected priority", p)
+	}
+
+	// Joining the stream buffer with a higher priority raises it.
+	if _, exists := sbs.callNewStreamFromID(dataSource.ID(), 0, 0, modules.DownloadPriorityInteractive); !exists {
+		t.Fatal("stream buffer should exist")
+	}
+	if p := priority(); p != modules.DownloadPriorityInteractive {
+		t.Fatal("unexpected priority", p)
+	}
+
+	// Joining it with a lower priority doesn't lower it.
+	sbs.callNewStream(newMockDataSource(data, 10), 0, 0, types.ZeroCurrency, modules.DownloadPriorityNormal)
+	if p := priority(); p != modules.DownloadPriorityInteractive {
+		t.Fatal("unexpected priority", p)
+	}
+}
+
 // TestStreamSmoke checks basic logic on the stream to see that reading and
 // seeking and closing works.
 func TestStreamSmoke(t *testing.T) {
@@ -120,7 +158,7 @@ func TestStreamSmoke(t *testing.T) {
 	dataSectionSize := uint64(16)
 	dataSource := newMockDataSource(data, dataSectionSize)
 	sbs := newStreamBufferSet(&tg)
-	stream := sbs.callNewStream(dataSource, 0, 0, types.ZeroCurrency)
+	stream := sbs.callNewStream(dataSource, 0, 0, types.ZeroCurrency, modules.DownloadPriorityInteractive)
 
 	// Check that there is one reference in the stream buffer.
 	sbs.mu.Lock()
@@ -130,7 +168,7 @@ func TestStreamSmoke(t *testing.T) {
 		t.Fatal("bad")
 	}
 	// Create a new stream from an id, check that the ref count goes up.
-	streamFromID, exists := sbs.callNewStreamFromID(dataSource.ID(), 0, 0)
+	streamFromID, exists := sbs.callNewStreamFromID(dataSource.ID(), 0, 0, modules.DownloadPriorityInteractive)
 	if !exists {
 		t.Fatal("bad")
 	}
@@ -143,7 +181,7 @@ func TestStreamSmoke(t *testing.T) {
 	// Create a second, different data source with the same id and try to use
 	// that.
 	dataSource2 := newMockDataSource(data, dataSectionSize)
-	repeatStream := sbs.callNewStream(dataSource2, 0, 0, types.ZeroCurrency)
+	repeatStream := sbs.callNewStream(dataSource2, 0, 0, types.ZeroCurrency, modules.DownloadPriorityInteractive)
 	sbs.mu.Lock()
 	refs = stream.staticStreamBuffer.externRefCount
 	sbs.mu.Unlock()
@@ -314,7 +352,7 @@ func TestStreamSmoke(t *testing.T) {
 	// the same ID, they are actually separate objects which need to be closed
 	// individually.
 	dataSource3 := newMockDataSource(data, dataSectionSize)
-	stream2 := sbs.callNewStream(dataSource3, 0, 0, types.ZeroCurrency)
+	stream2 := sbs.callNewStream(dataSource3, 0, 0, types.ZeroCurrency, modules.DownloadPriorityInteractive)
 	bytesRead, err = io.ReadFull(stream2, buf)
 	if err != nil {
 		t.Fatal(err)
@@ -442,7 +480,7 @@ func TestStreamSmoke(t *testing.T) {
 
 	// Check that if the tg is stopped, the stream closes immediately.
 	dataSource4 := newMockDataSource(data, dataSectionSize)
-	stream3 := sbs.callNewStream(dataSource4, 0, 0, types.ZeroCurrency)
+	stream3 := sbs.callNewStream(dataSource4, 0, 0, types.ZeroCurrency, modules.DownloadPriorityInteractive)
 	bytesRead, err = io.ReadFull(stream3, buf)
 	if err != nil {
 		t.Fatal(err)
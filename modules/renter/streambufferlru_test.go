@@ -3,6 +3,7 @@ package renter
 import (
 	"testing"
 
+	"github.com/turtledex/TurtleDexCore/modules"
 	"github.com/turtledex/TurtleDexCore/types"
 	"github.com/turtledex/fastrand"
 	"github.com/turtledex/threadgroup"
@@ -23,7 +24,7 @@ func TestStreamLRU(t *testing.T) {
 	data := fastrand.Bytes(15999) // 1 byte short of 1000 data sections.
 	dataSource := newMockDataSource(data, 16)
 	sbs := newStreamBufferSet(&tg)
-	stream := sbs.callNewStream(dataSource, 0, 0, types.ZeroCurrency)
+	stream := sbs.callNewStream(dataSource, 0, 0, types.ZeroCurrency, modules.DownloadPriorityInteractive)
 
 	// Extract the LRU from the stream to test it directly.
 	lru := stream.lru
@@ -32,6 +32,9 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 		return err
 	}
 	defer r.tg.Done()
+	if err := checkDirSnapshotWritable(up.TurtleDexPath); err != nil {
+		return err
+	}
 
 	// Check if the file is a directory.
 	sourceInfo, err := os.Stat(up.Source)
@@ -52,12 +55,9 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 		return errors.AddContext(err, "unable to close file after checking permissions")
 	}
 
-	// Delete existing file if overwrite flag is set. Ignore ErrUnknownPath.
-	if up.Force {
-		err := r.DeleteFile(up.TurtleDexPath)
-		if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
-			return errors.AddContext(err, "unable to delete existing file")
-		}
+	// Check the user metadata.
+	if err := modules.ValidateUserMetadata(up.UserMetadata); err != nil {
+		return errors.AddContext(err, "invalid user metadata")
 	}
 
 	// Fill in any missing upload params with sensible defaults.
@@ -65,6 +65,24 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 		up.ErasureCode = modules.NewRSSubCodeDefault()
 	}
 
+	// Check that the file fits within the quotas of its directories. A file
+	// that is replaced doesn't count towards the quotas.
+	size := uint64(sourceInfo.Size())
+	err = r.managedCheckDirQuotas(up.TurtleDexPath, size, numChunksForSize(size, up.ErasureCode), up.ErasureCode, up.Force)
+	if err != nil {
+		return errors.AddContext(err, "unable to upload file")
+	}
+
+	// Replace existing file if overwrite flag is set. Depending on the
+	// directory's version retention the file is either deleted or kept as a
+	// prior version. Ignore ErrUnknownPath.
+	if up.Force {
+		err := r.managedReplaceFile(up.TurtleDexPath)
+		if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+			return errors.AddContext(err, "unable to replace existing file")
+		}
+	}
+
 	// Check that we have contracts to upload to. We need at least data +
 	// parity/2 contracts. NumPieces is equal to data+parity, and min pieces is
 	// equal to parity. Therefore (NumPieces+MinPieces)/2 = (data+data+parity)/2
@@ -91,6 +109,13 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 	// Generate a key using the cipher type.
 	cipherKey := crypto.GenerateTurtleDexKey(up.CipherType)
 
+	// Charge the upload to the budget of the uploader before any chunks are
+	// pushed to the upload heap.
+	err = r.managedChargeBudget(up.BudgetIdentity, modules.BudgetCategoryUpload, size)
+	if err != nil {
+		return errors.AddContext(err, "unable to upload file")
+	}
+
 	// Create the TurtleDexfile and add to renter
 	err = r.staticFileSystem.NewTurtleDexFile(up.TurtleDexPath, up.Source, up.ErasureCode, cipherKey, uint64(sourceInfo.Size()), sourceInfo.Mode(), up.DisablePartialChunk)
 	if err != nil {
@@ -100,6 +125,10 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 	if err != nil {
 		return errors.AddContext(err, "could not open the new sia file")
 	}
+	err = r.managedSetNewFileUserMetadata(up.TurtleDexPath, entry, up.UserMetadata)
+	if err != nil {
+		return err
+	}
 
 	// No need to upload zero-byte files.
 	if sourceInfo.Size() == 0 {
@@ -44,6 +44,23 @@ type unfinishedUploadChunk struct {
 
 	staticMemoryManager *memoryManager
 
+	// chunkKey is the key the keys of the chunk's pieces are derived from
+	// using chunkKeyIndex. For regular chunks this is the file's master key
+	// and the chunk's index. Deduplicated chunks use a convergent key
+	// instead.
+	chunkKey      crypto.CipherKey
+	chunkKeyIndex uint64
+
+	// deduplicated indicates that the chunk's pieces were already uploaded
+	// for a chunk with the same content and don't need to be uploaded again.
+	deduplicated bool
+
+	// staticRepairPieces is the number of pieces missing from a chunk which
+	// was uploaded before. It is 0 for chunks which are uploaded for the
+	// first time.
+	staticRepairPieces int
+	staticPieceSize    uint64
+
 	// Static cached fields.
 	staticIndex    uint64
 	staticTurtleDexPath  string
@@ -68,6 +85,10 @@ type unfinishedUploadChunk struct {
 	// available it will be tried before the repair path or remote repair.
 	sourceReader io.ReadCloser
 
+	// budgetIdentity is the identity whose budget is charged for the data
+	// read from the sourceReader. Chunks without an identity aren't charged.
+	budgetIdentity string
+
 	// Performance information.
 	chunkCreationTime        time.Time
 	chunkPoppedFromHeapTime  time.Time
@@ -114,6 +135,7 @@ type unfinishedUploadChunk struct {
 	piecesCompleted  int                 // number of pieces that have been fully uploaded.
 	piecesRegistered int                 // number of pieces that are being uploaded, but aren't finished yet (may fail).
 	released         bool                // whether this chunk has been released from the active chunks set.
+	repairThrottled  bool                // whether this chunk holds a slot of the repair throttle.
 	unusedHosts      map[string]struct{} // hosts that aren't yet storing any pieces or performing any work.
 	workersRemaining int                 // number of inactive workers still able to upload a piece.
 	workersStandby   []*worker           // workers that can be used if other workers fail.
@@ -123,6 +145,12 @@ type unfinishedUploadChunk struct {
 	cancelWG sync.WaitGroup // WaitGroup to wait on after canceling the uploadchunk.
 }
 
+// staticRepairBytes returns the number of bytes which need to be uploaded to
+// repair the chunk.
+func (uc *unfinishedUploadChunk) staticRepairBytes() uint64 {
+	return uint64(uc.staticRepairPieces) * uc.staticPieceSize
+}
+
 // staticAvailable returns whether or not the chunk is available yet on the TurtleDex
 // network.
 func (uc *unfinishedUploadChunk) staticAvailable() bool {
@@ -211,7 +239,7 @@ func readDataPieces(r io.Reader, ec modules.ErasureCoder, pieceSize uint64) ([][
 // padAndEncryptPiece will add padding to a unfinishedUploadChunk's piece at
 // index i and then encrypt it.
 func (uc *unfinishedUploadChunk) padAndEncryptPiece(i int) {
-	padAndEncryptPiece(uc.staticIndex, uint64(i), uc.logicalChunkData, uc.fileEntry.MasterKey())
+	padAndEncryptPiece(uc.chunkKeyIndex, uint64(i), uc.logicalChunkData, uc.chunkKey)
 }
 
 // padAndEncryptPiece will add padding to a piece and then encrypt it.
@@ -367,7 +395,7 @@ func (r *Renter) threadedFetchAndRepairChunk(chunk *unfinishedUploadChunk) {
 		chunk.logicalChunkData = nil
 		// Set the error to indicate the failure happened when fetching the
 		// data.
-		err := fmt.Errorf("Unable to fetch the logical data for chunk %v of %s - marking as stuck: %v", chunk.staticIndex, chunk.staticTurtleDexPath, err)
+		err := errors.AddContext(err, fmt.Sprintf("Unable to fetch the logical data for chunk %v of %s - marking as stuck", chunk.staticIndex, chunk.staticTurtleDexPath))
 		chunk.err = err
 		chunk.mu.Unlock()
 
@@ -377,9 +405,9 @@ func (r *Renter) threadedFetchAndRepairChunk(chunk *unfinishedUploadChunk) {
 		// Cleanup the failed chunk without holding the lock.
 		r.managedCleanUpUploadChunk(chunk)
 
-		// If TurtleDex is not currently online, the chunk doesn't need to be marked
-		// as stuck.
-		if !r.g.Online() {
+		// If TurtleDex is not currently online or the uploader's budget is
+		// exhausted, the chunk doesn't need to be marked as stuck.
+		if !r.g.Online() || errors.Contains(err, modules.ErrBudgetExhausted) {
 			return
 		}
 
@@ -395,6 +423,20 @@ func (r *Renter) threadedFetchAndRepairChunk(chunk *unfinishedUploadChunk) {
 	// fetching, where the erasure coding occurs.
 	chunk.staticMemoryManager.Return(erasureCodingMemory + pieceCompletedMemory)
 	chunk.memoryReleased += erasureCodingMemory + pieceCompletedMemory
+
+	// If the chunk was deduplicated, there is nothing left to upload. Mark
+	// the chunk as complete and clean it up.
+	if chunk.deduplicated {
+		chunk.mu.Lock()
+		chunk.piecesCompleted = chunk.staticPiecesNeeded
+		chunk.workersRemaining = 0
+		chunk.logicalChunkData = nil
+		chunk.mu.Unlock()
+		r.repairLog.Printf("Chunk %v of %s was deduplicated", chunk.staticIndex, chunk.staticTurtleDexPath)
+		r.managedCleanUpUploadChunk(chunk)
+		return
+	}
+
 	// Swap the physical chunk data and the logical chunk data. There is
 	// probably no point to having both, given that we perform such a clean
 	// handoff here, but since the code is already written this way, it may be
@@ -478,9 +520,10 @@ func (uc *unfinishedUploadChunk) staticReadLogicalData(r io.Reader) (uint64, err
 	return total, nil
 }
 
-// staticFetchLogicalDataFromReader will load the logical data for a chunk from
-// a reader, and perform an integrity check on the chunk to ensure correctness.
-func (r *Renter) staticFetchLogicalDataFromReader(uc *unfinishedUploadChunk) (err error) {
+// managedFetchLogicalDataFromReader will load the logical data for a chunk
+// from a reader, and perform an integrity check on the chunk to ensure
+// correctness. Chunks which are read for the first time are deduplicated.
+func (r *Renter) managedFetchLogicalDataFromReader(uc *unfinishedUploadChunk) (err error) {
 	defer func() {
 		err = errors.Compose(err, uc.sourceReader.Close())
 	}()
@@ -491,10 +534,24 @@ func (r *Renter) staticFetchLogicalDataFromReader(uc *unfinishedUploadChunk) (er
 		return errors.AddContext(err, "unable to read the chunk data from the source reader")
 	}
 
-	// Perform an integrity check on the data that was pulled from the reader.
-	err = uc.staticEncryptAndCheckIntegrity()
-	if err != nil {
-		return errors.AddContext(err, "source data does not match previously uploaded data - blocking corrupt repair")
+	// Check whether the chunk was uploaded before. If it was, the data
+	// doesn't need to be encrypted anymore. Otherwise the upload of the data
+	// is charged to the budget of the uploader before it is handed to the
+	// workers.
+	uc.deduplicated = r.managedDeduplicateChunk(uc, n)
+	if uc.deduplicated {
+		uc.logicalChunkData = nil
+	} else {
+		err = r.managedChargeBudget(uc.budgetIdentity, modules.BudgetCategoryUpload, n)
+		if err != nil {
+			return errors.AddContext(err, "unable to charge the upload of the chunk")
+		}
+		// Perform an integrity check on the data that was pulled from the
+		// reader.
+		err = uc.staticEncryptAndCheckIntegrity()
+		if err != nil {
+			return errors.AddContext(err, "source data does not match previously uploaded data - blocking corrupt repair")
+		}
 	}
 
 	// Adjust the filesize. Since we don't know the length of the stream
@@ -509,14 +566,15 @@ func (r *Renter) staticFetchLogicalDataFromReader(uc *unfinishedUploadChunk) (er
 }
 
 // managedFetchLogicalChunkData will get the raw data for a chunk, pulling it from disk if
-// possible but otherwise queueing a download.
+// possible but otherwise queueing a download. Chunks which are read for the
+// first time from a source reader or a local file are deduplicated.
 //
 // uc.data should be passed as 'nil' to the download, to keep memory usage as
 // light as possible.
 func (r *Renter) managedFetchLogicalChunkData(uc *unfinishedUploadChunk) error {
 	// Use a sourceReader if one is available.
 	if uc.sourceReader != nil {
-		err := r.staticFetchLogicalDataFromReader(uc)
+		err := r.managedFetchLogicalDataFromReader(uc)
 		if err != nil {
 			return errors.AddContext(err, "unable to load logical data from source reader")
 		}
@@ -549,11 +607,19 @@ func (r *Renter) managedFetchLogicalChunkData(uc *unfinishedUploadChunk) error {
 			err = errors.Compose(err, osFile.Close())
 		}()
 		sr := io.NewSectionReader(osFile, uc.offset, int64(uc.length))
-		dataPieces, _, err := readDataPieces(sr, uc.fileEntry.ErasureCode(), uc.fileEntry.PieceSize())
+		dataPieces, n, err := readDataPieces(sr, uc.fileEntry.ErasureCode(), uc.fileEntry.PieceSize())
 		if err != nil {
 			return errors.AddContext(err, "unable to read the data from the local file")
 		}
 		uc.logicalChunkData, _ = uc.fileEntry.ErasureCode().EncodeShards(dataPieces)
+
+		// Check whether the chunk was uploaded before, just like chunks read
+		// from a source reader.
+		uc.deduplicated = r.managedDeduplicateChunk(uc, n)
+		if uc.deduplicated {
+			uc.logicalChunkData = nil
+			return nil
+		}
 		err = uc.staticEncryptAndCheckIntegrity()
 		if err != nil {
 			return errors.AddContext(err, "local file failed the integrity check")
@@ -629,6 +695,15 @@ func (r *Renter) managedCleanUpUploadChunk(uc *unfinishedUploadChunk) {
 		}
 		uc.released = true
 
+		// Release the slot of the repair throttle.
+		if uc.repairThrottled {
+			var repairedBytes uint64
+			if repaired := uc.piecesCompleted - (uc.staticPiecesNeeded - uc.staticRepairPieces); repaired > 0 {
+				repairedBytes = uint64(repaired) * uc.staticPieceSize
+			}
+			r.staticRepairThrottle.managedChunkDone(repairedBytes)
+		}
+
 		// Create a log message with all of the timings of the chunk uploading.
 		failedTimes := make([]int, 0, len(uc.chunkFailedProcessTimes))
 		for _, ft := range uc.chunkFailedProcessTimes {
@@ -672,6 +747,9 @@ func (r *Renter) managedCleanUpUploadChunk(uc *unfinishedUploadChunk) {
 			r.log.Print("managedCleanUpUploadChunk: failed to update file metadata", err)
 		}
 
+		// Keep the dedup index up-to-date with the chunk's pieces.
+		r.managedRegisterDedupChunk(uc)
+
 		// Close the file entry for the completed chunk unless disrupted.
 		if !r.deps.Disrupt("disableCloseUploadEntry") {
 			err := uc.fileEntry.Close()
@@ -518,6 +518,15 @@ func (r *Renter) managedBuildUnfinishedChunk(entry *filesystem.FileNode, chunkIn
 		r.log.Println("WARN: unable to get 'stuck' status:", err)
 		return nil, errors.AddContext(err, "unable to get 'stuck' status")
 	}
+	ei, err := entry.ChunkExtensionInfo(chunkIndex)
+	if err != nil {
+		r.log.Println("WARN: unable to get chunk extension info:", err)
+		return nil, errors.AddContext(err, "unable to get chunk extension info")
+	}
+	chunkKey, chunkKeyIndex, err := r.managedChunkKey(entry.MasterKey(), ei, chunkIndex)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to get the chunk's encryption key")
+	}
 	_, err = os.Stat(entryCopy.LocalPath())
 	onDisk := err == nil
 	uuc := &unfinishedUploadChunk{
@@ -538,6 +547,9 @@ func (r *Renter) managedBuildUnfinishedChunk(entry *filesystem.FileNode, chunkIn
 
 		staticMemoryManager: mm,
 
+		chunkKey:      chunkKey,
+		chunkKeyIndex: chunkKeyIndex,
+
 		// staticMemoryNeeded has to also include the logical data, and also
 		// include the overhead for encryption.
 		//
@@ -547,6 +559,7 @@ func (r *Renter) managedBuildUnfinishedChunk(entry *filesystem.FileNode, chunkIn
 		staticMemoryNeeded:  entry.PieceSize()*uint64(entry.ErasureCode().NumPieces()+entry.ErasureCode().MinPieces()) + uint64(entry.ErasureCode().NumPieces())*entry.MasterKey().Type().Overhead(),
 		staticMinimumPieces: entry.ErasureCode().MinPieces(),
 		staticPiecesNeeded:  entry.ErasureCode().NumPieces(),
+		staticPieceSize:     entry.PieceSize(),
 		stuck:               stuck,
 
 		physicalChunkData:        make([][]byte, entry.ErasureCode().NumPieces()),
@@ -575,6 +588,7 @@ func (r *Renter) managedBuildUnfinishedChunk(entry *filesystem.FileNode, chunkIn
 		}
 		return nil, errors.AddContext(err, "error trying to get the pieces for the chunk")
 	}
+	var uploadedBefore bool
 	for pieceIndex, pieceSet := range pieces {
 		for _, piece := range pieceSet {
 			// Determine whether this piece counts towards the redundancy.
@@ -607,11 +621,18 @@ func (r *Renter) managedBuildUnfinishedChunk(entry *filesystem.FileNode, chunkIn
 		// a local (and therefore potentially altered or corrupt) file.
 		if len(pieceSet) > 0 {
 			uuc.staticExpectedPieceRoots[pieceIndex] = pieceSet[0].MerkleRoot
+			uploadedBefore = true
 		}
 	}
 	// Now that we have calculated the completed pieces for the chunk we can
 	// calculate the health of the chunk to avoid a call to ChunkHealth
 	uuc.health = 1 - (float64(uuc.piecesCompleted-uuc.staticMinimumPieces) / float64(uuc.staticPiecesNeeded-uuc.staticMinimumPieces))
+
+	// Chunks which were uploaded before are repairs and subject to the repair
+	// throttle.
+	if uploadedBefore {
+		uuc.staticRepairPieces = uuc.staticPiecesNeeded - uuc.piecesCompleted
+	}
 	return uuc, nil
 }
 
@@ -1355,6 +1376,18 @@ func (r *Renter) managedRepairLoop() error {
 	// that changes to the directory heap take effect sooner rather than later.
 	repairBreakTime := time.Now().Add(maxRepairLoopTime)
 
+	// deferred contains repairs which can't be started yet because of the
+	// repair throttle. New uploads are processed in the meantime. Repairs which
+	// are still deferred when the loop returns are dropped and picked up again
+	// when the upload heap is rebuilt.
+	var deferred []*unfinishedUploadChunk
+	defer func() {
+		for _, chunk := range deferred {
+			chunk.fileEntry.Close()
+			r.uploadHeap.managedMarkRepairDone(chunk)
+		}
+	}()
+
 	// Work through the heap repairing chunks until heap is empty for
 	// smallRepairs or heap drops below minUploadHeapSize for larger repairs, or
 	// until the total amount of time spent in one repair iteration has elapsed.
@@ -1380,15 +1413,63 @@ func (r *Renter) managedRepairLoop() error {
 			return errors.Compose(err, errPaused)
 		}
 
-		// Check if there is work by trying to pop off the next chunk from the
-		// heap.
-		nextChunk := r.uploadHeap.managedPop()
+		// Deferred repairs are resumed as soon as the repair throttle allows
+		// it.
+		var nextChunk *unfinishedUploadChunk
+		if len(deferred) > 0 && r.staticRepairThrottle.managedTryStart(deferred[0].staticRepairBytes()) {
+			nextChunk, deferred = deferred[0], deferred[1:]
+			nextChunk.mu.Lock()
+			nextChunk.repairThrottled = true
+			nextChunk.mu.Unlock()
+		}
+
+		// Otherwise check if there is work by trying to pop off the next chunk
+		// from the heap.
+		if nextChunk == nil {
+			nextChunk = r.uploadHeap.managedPop()
+		}
+		if nextChunk == nil && len(deferred) > 0 {
+			// Only deferred repairs are left, wait for the throttle. New
+			// uploads wake the loop up since they don't need a slot.
+			err := r.staticRepairThrottle.managedWait(r.tg.StopChan(), r.uploadHeap.newUploads)
+			if err != nil {
+				return errors.AddContext(err, "Repair loop interrupted because renter is shutting down")
+			}
+			continue
+		}
 		if nextChunk == nil {
 			// The heap is empty so reset it to free memory and return.
 			r.uploadHeap.managedReset()
 			return nil
 		}
 		chunkPath := nextChunk.staticTurtleDexPath
+
+		// In repair-only mode, chunks of new files are dropped. They are added
+		// to the heap again once new uploads are resumed. Streamed chunks
+		// which are already in the heap are uploaded since a caller is
+		// waiting for them.
+		isRepair := nextChunk.staticRepairPieces > 0
+		if !isRepair && nextChunk.sourceReader == nil && r.staticRepairThrottle.managedRepairOnly() {
+			nextChunk.fileEntry.Close()
+			r.uploadHeap.managedMarkRepairDone(nextChunk)
+			continue
+		}
+
+		// Defer repairs which the repair throttle doesn't allow to be started
+		// yet.
+		nextChunk.mu.Lock()
+		throttled := nextChunk.repairThrottled
+		nextChunk.mu.Unlock()
+		if isRepair && !throttled {
+			if !r.staticRepairThrottle.managedTryStart(nextChunk.staticRepairBytes()) {
+				deferred = append(deferred, nextChunk)
+				continue
+			}
+			nextChunk.mu.Lock()
+			nextChunk.repairThrottled = true
+			nextChunk.mu.Unlock()
+			throttled = true
+		}
 		r.repairLog.Printf("Repairing chunk %v of %s, currently have %v out of %v pieces", nextChunk.staticIndex, chunkPath, nextChunk.piecesCompleted, nextChunk.staticPiecesNeeded)
 
 		// Make sure we have enough workers for this chunk to reach minimum
@@ -1423,6 +1504,9 @@ func (r *Renter) managedRepairLoop() error {
 			nextChunk.fileEntry.Close()
 			// Remove the chunk from the repairingChunks map
 			r.uploadHeap.managedMarkRepairDone(nextChunk)
+			if throttled {
+				r.staticRepairThrottle.managedChunkDone(0)
+			}
 			continue
 		}
 
@@ -1442,6 +1526,9 @@ func (r *Renter) managedRepairLoop() error {
 			nextChunk.fileEntry.Close()
 			// Remove the chunk from the repairingChunks map
 			r.uploadHeap.managedMarkRepairDone(nextChunk)
+			if throttled {
+				r.staticRepairThrottle.managedChunkDone(0)
+			}
 			continue
 		}
 	}
@@ -0,0 +1,636 @@
+package renter
+
+// Upload sessions allow for uploading a file across multiple requests. A
+// session is created for a new siafile and data is appended to it at the
+// session's offset. The data of a write is read one chunk at a time and every
+// full chunk is passed to the upload streamer right away. Since the upload
+// streamer can only upload full chunks until the end of the stream is
+// reached, the data that doesn't fill a whole chunk yet is staged on disk next
+// to the session's persist file. The next write first fills up the staged
+// chunk before it is uploaded. Once the session is finalized, the remaining
+// staged data is uploaded as the file's last chunk.
+//
+// The staging file's name contains the offset of its first byte within the
+// file. That way a session is always consistent on disk, even if the daemon
+// is shut down while the staged data is moved to a new staging file.
+//
+// Sessions which aren't written to for uploadSessionTimeout expire. Expired
+// sessions are aborted in the background, which deletes their partially
+// uploaded files.
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem/siafile"
+	"github.com/turtledex/TurtleDexCore/persist"
+	"github.com/turtledex/errors"
+	"github.com/turtledex/fastrand"
+)
+
+const (
+	// uploadSessionsDir is the name of the directory within the renter's
+	// persist directory which contains the upload sessions.
+	uploadSessionsDir = "uploadsessions"
+
+	// uploadSessionExtension is the extension of an upload session's persist
+	// file.
+	uploadSessionExtension = ".json"
+
+	// uploadSessionPersistVersion is the version of an upload session's persist
+	// file.
+	uploadSessionPersistVersion = "1.5.5"
+
+	// uploadSessionStagingExtension is the extension of an upload session's
+	// staging file.
+	uploadSessionStagingExtension = ".staged"
+
+	// uploadSessionIDLen is the length of an upload session's id in bytes.
+	uploadSessionIDLen = 16
+)
+
+var (
+	// ErrUnknownUploadSession is returned when an upload session can't be
+	// found.
+	ErrUnknownUploadSession = errors.New("upload session does not exist")
+
+	// ErrUploadSessionBusy is returned when an upload session is used by
+	// multiple requests at the same time.
+	ErrUploadSessionBusy = errors.New("upload session is in use by another request")
+
+	// ErrUploadSessionFileReplaced is returned when the file of an upload
+	// session was replaced since the session was created.
+	ErrUploadSessionFileReplaced = errors.New("file was replaced since the upload session was created")
+
+	// ErrUploadSessionOffset is returned when data is written to an upload
+	// session at an offset other than the session's offset.
+	ErrUploadSessionOffset = errors.New("offset doesn't match the offset of the upload session")
+
+	// uploadSessionMetadata is the metadata of an upload session's persist
+	// file.
+	uploadSessionMetadata = persist.Metadata{
+		Header:  "Renter Upload Session",
+		Version: uploadSessionPersistVersion,
+	}
+
+	// uploadSessionPruneInterval is the interval at which the renter checks
+	// for expired upload sessions.
+	uploadSessionPruneInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Hour,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// uploadSessionTimeout is the amount of time after the last write after
+	// which an upload session expires.
+	uploadSessionTimeout = build.Select(build.Var{
+		Dev:      time.Hour,
+		Standard: 7 * 24 * time.Hour,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+)
+
+type (
+	// uploadSession is the persisted state of an upload session.
+	uploadSession struct {
+		BudgetIdentity string                   `json:"budgetidentity"`
+		CreateTime     time.Time                `json:"createtime"`
+		ID             string                   `json:"id"`
+		LastWriteTime  time.Time                `json:"lastwritetime"`
+		TurtleDexPath  modules.TurtleDexPath    `json:"siapath"`
+		UID            siafile.TurtleDexfileUID `json:"uid"`
+
+		// UploadedOffset is the number of bytes which were uploaded to the
+		// siafile. Any data after that is staged on disk.
+		UploadedOffset uint64 `json:"uploadedoffset"`
+	}
+
+	// uploadSessions manages the upload sessions on disk and makes sure that
+	// every session is only used by a single request at a time.
+	uploadSessions struct {
+		active    map[string]struct{}
+		staticDir string
+		mu        sync.Mutex
+	}
+)
+
+// newUploadSessions creates a new uploadSessions object which persists the
+// sessions within the provided dir.
+func newUploadSessions(persistDir string) (*uploadSessions, error) {
+	dir := filepath.Join(persistDir, uploadSessionsDir)
+	if err := os.MkdirAll(dir, modules.DefaultDirPerm); err != nil {
+		return nil, errors.AddContext(err, "failed to create upload sessions dir")
+	}
+	return &uploadSessions{
+		active:    make(map[string]struct{}),
+		staticDir: dir,
+	}, nil
+}
+
+// expiryTime returns the time at which the session expires.
+func (s uploadSession) expiryTime() time.Time {
+	lastActivity := s.CreateTime
+	if s.LastWriteTime.After(lastActivity) {
+		lastActivity = s.LastWriteTime
+	}
+	return lastActivity.Add(uploadSessionTimeout)
+}
+
+// ids returns the ids of all sessions on disk.
+func (us *uploadSessions) ids() ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(us.staticDir, "*"+uploadSessionExtension))
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(paths))
+	for _, path := range paths {
+		ids = append(ids, strings.TrimSuffix(filepath.Base(path), uploadSessionExtension))
+	}
+	return ids, nil
+}
+
+// info returns the information about the session.
+func (us *uploadSessions) info(s uploadSession) (modules.UploadSessionInfo, error) {
+	staged, err := us.stagedSize(s)
+	if err != nil {
+		return modules.UploadSessionInfo{}, err
+	}
+	return modules.UploadSessionInfo{
+		CreateTime:    s.CreateTime,
+		ExpiryTime:    s.expiryTime(),
+		ID:            s.ID,
+		Offset:        s.UploadedOffset + staged,
+		TurtleDexPath: s.TurtleDexPath,
+	}, nil
+}
+
+// loadUnexpired loads the session with the provided id from disk. Expired
+// sessions are treated like sessions that don't exist.
+func (us *uploadSessions) loadUnexpired(id string) (uploadSession, error) {
+	s, err := us.load(id)
+	if err != nil {
+		return uploadSession{}, err
+	}
+	if !time.Now().Before(s.expiryTime()) {
+		return uploadSession{}, errors.AddContext(ErrUnknownUploadSession, "upload session expired")
+	}
+	return s, nil
+}
+
+// load loads the session with the provided id from disk.
+func (us *uploadSessions) load(id string) (uploadSession, error) {
+	b, err := hex.DecodeString(id)
+	if err != nil || len(b) != uploadSessionIDLen {
+		return uploadSession{}, errors.AddContext(ErrUnknownUploadSession, "invalid id")
+	}
+	var s uploadSession
+	err = persist.LoadJSON(uploadSessionMetadata, &s, us.persistPath(id))
+	if os.IsNotExist(err) {
+		return uploadSession{}, ErrUnknownUploadSession
+	}
+	if err != nil {
+		return uploadSession{}, errors.AddContext(err, "failed to load upload session")
+	}
+	return s, nil
+}
+
+// managedAcquire marks the session with the provided id as active. It fails
+// if the session is already active.
+func (us *uploadSessions) managedAcquire(id string) error {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	if _, active := us.active[id]; active {
+		return ErrUploadSessionBusy
+	}
+	us.active[id] = struct{}{}
+	return nil
+}
+
+// managedRelease marks the session with the provided id as inactive.
+func (us *uploadSessions) managedRelease(id string) {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	delete(us.active, id)
+}
+
+// persistPath returns the path of the persist file of the session with the
+// provided id.
+func (us *uploadSessions) persistPath(id string) string {
+	return filepath.Join(us.staticDir, id+uploadSessionExtension)
+}
+
+// remove deletes the persist file and the staging files of a session.
+func (us *uploadSessions) remove(id string) error {
+	stagingFiles, err := filepath.Glob(filepath.Join(us.staticDir, id+"-*"+uploadSessionStagingExtension))
+	if err != nil {
+		return err
+	}
+	for _, path := range stagingFiles {
+		err = errors.Compose(err, os.Remove(path))
+	}
+	return errors.Compose(err, os.Remove(us.persistPath(id)))
+}
+
+// save persists the session.
+func (us *uploadSessions) save(s uploadSession) error {
+	return persist.SaveJSON(uploadSessionMetadata, s, us.persistPath(s.ID))
+}
+
+// stagedSize returns the number of bytes that are staged for the session.
+func (us *uploadSessions) stagedSize(s uploadSession) (uint64, error) {
+	fi, err := os.Stat(us.stagingPath(s))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.AddContext(err, "failed to stat staging file")
+	}
+	return uint64(fi.Size()), nil
+}
+
+// stagingPath returns the path of the session's current staging file.
+func (us *uploadSessions) stagingPath(s uploadSession) string {
+	return us.stagingPathAt(s.ID, s.UploadedOffset)
+}
+
+// stagingPathAt returns the path of the staging file of the session with the
+// provided id that starts at the provided offset.
+func (us *uploadSessions) stagingPathAt(id string, offset uint64) string {
+	return filepath.Join(us.staticDir, fmt.Sprintf("%v-%v%v", id, offset, uploadSessionStagingExtension))
+}
+
+// AbortUploadSession cancels the upload session with the provided id and
+// deletes the partially uploaded file.
+func (r *Renter) AbortUploadSession(id string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.managedAbortUploadSession(id)
+}
+
+// managedAbortUploadSession cancels the upload session with the provided id
+// and deletes the partially uploaded file.
+func (r *Renter) managedAbortUploadSession(id string) error {
+	if err := r.staticUploadSessions.managedAcquire(id); err != nil {
+		return err
+	}
+	defer r.staticUploadSessions.managedRelease(id)
+
+	s, err := r.staticUploadSessions.load(id)
+	if err != nil {
+		return err
+	}
+	// Only delete the file if it still belongs to the session.
+	fileNode, err := r.managedOpenUploadSessionFile(s)
+	if err == nil {
+		err = fileNode.Close()
+		err = errors.Compose(err, r.DeleteFile(s.TurtleDexPath))
+	} else if errors.Contains(err, filesystem.ErrNotExist) || errors.Contains(err, ErrUploadSessionFileReplaced) {
+		err = nil
+	}
+	if err != nil {
+		return errors.AddContext(err, "failed to delete partially uploaded file")
+	}
+	return r.staticUploadSessions.remove(id)
+}
+
+// CreateUploadSession creates a new resumable upload session for the file
+// described by up. The file is created right away and grows as data is
+// written to the session.
+func (r *Renter) CreateUploadSession(up modules.FileUploadParams) (modules.UploadSessionInfo, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.UploadSessionInfo{}, err
+	}
+	defer r.tg.Done()
+	if up.Repair {
+		return modules.UploadSessionInfo{}, errors.New("upload sessions can't be used for repairs")
+	}
+	var ct crypto.CipherType
+	if up.CipherType == ct {
+		up.CipherType = crypto.TypeDefaultRenter
+	}
+
+	// Create the empty siafile.
+	fileNode, err := r.managedInitUploadStream(up)
+	if err != nil {
+		return modules.UploadSessionInfo{}, errors.AddContext(err, "unable to create file for upload session")
+	}
+	s := uploadSession{
+		BudgetIdentity: up.BudgetIdentity,
+		CreateTime:     time.Now(),
+		ID:             hex.EncodeToString(fastrand.Bytes(uploadSessionIDLen)),
+		TurtleDexPath:  up.TurtleDexPath,
+		UID:            fileNode.UID(),
+	}
+	err = fileNode.Close()
+	if err != nil {
+		return modules.UploadSessionInfo{}, err
+	}
+	err = r.staticUploadSessions.save(s)
+	if err != nil {
+		return modules.UploadSessionInfo{}, errors.AddContext(err, "unable to save upload session")
+	}
+	return r.staticUploadSessions.info(s)
+}
+
+// FinalizeUploadSession uploads the data that is still staged for the upload
+// session with the provided id and closes the session.
+func (r *Renter) FinalizeUploadSession(id string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if err := r.staticUploadSessions.managedAcquire(id); err != nil {
+		return err
+	}
+	defer r.staticUploadSessions.managedRelease(id)
+
+	s, err := r.staticUploadSessions.loadUnexpired(id)
+	if err != nil {
+		return err
+	}
+	fileNode, err := r.managedOpenUploadSessionFile(s)
+	if err != nil {
+		return err
+	}
+	err = r.managedUploadStagedData(&s, fileNode, true)
+	err = errors.Compose(err, fileNode.Close())
+	if err != nil {
+		return errors.AddContext(err, "unable to upload staged data")
+	}
+	return r.staticUploadSessions.remove(id)
+}
+
+// UploadSession returns information about the upload session with the
+// provided id.
+func (r *Renter) UploadSession(id string) (modules.UploadSessionInfo, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.UploadSessionInfo{}, err
+	}
+	defer r.tg.Done()
+	s, err := r.staticUploadSessions.loadUnexpired(id)
+	if err != nil {
+		return modules.UploadSessionInfo{}, err
+	}
+	return r.staticUploadSessions.info(s)
+}
+
+// UploadSessionWrite appends the data read from reader to the upload session
+// with the provided id. The offset needs to match the session's offset. If
+// reading from the reader fails, the data read up to that point is still
+// committed to the session and the returned info contains the offset to
+// resume from.
+func (r *Renter) UploadSessionWrite(id string, offset uint64, reader io.Reader) (modules.UploadSessionInfo, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.UploadSessionInfo{}, err
+	}
+	defer r.tg.Done()
+	if err := r.staticUploadSessions.managedAcquire(id); err != nil {
+		return modules.UploadSessionInfo{}, err
+	}
+	defer r.staticUploadSessions.managedRelease(id)
+
+	us := r.staticUploadSessions
+	s, err := us.loadUnexpired(id)
+	if err != nil {
+		return modules.UploadSessionInfo{}, err
+	}
+	info, err := us.info(s)
+	if err != nil {
+		return modules.UploadSessionInfo{}, err
+	}
+	if offset != info.Offset {
+		return info, errors.AddContext(ErrUploadSessionOffset, fmt.Sprintf("expected offset %v but got %v", info.Offset, offset))
+	}
+	fileNode, err := r.managedOpenUploadSessionFile(s)
+	if err != nil {
+		return info, err
+	}
+
+	// Write the data. The data that was read is committed to the session even
+	// if reading from the reader failed at some point.
+	readErr, writeErr := r.managedWriteUploadSession(&s, fileNode, reader)
+	if readErr != nil {
+		readErr = errors.AddContext(readErr, "failed to read data")
+	}
+	s.LastWriteTime = time.Now()
+	err = errors.Compose(writeErr, us.save(s), fileNode.Close())
+	if err != nil {
+		err = errors.AddContext(err, "unable to write data")
+	}
+	info, infoErr := us.info(s)
+	if infoErr != nil {
+		return modules.UploadSessionInfo{}, infoErr
+	}
+	return info, errors.Compose(readErr, err)
+}
+
+// managedPruneUploadSessions aborts all upload sessions which expired.
+func (r *Renter) managedPruneUploadSessions() {
+	us := r.staticUploadSessions
+	ids, err := us.ids()
+	if err != nil {
+		r.log.Println("Unable to list upload sessions:", err)
+		return
+	}
+	for _, id := range ids {
+		s, err := us.load(id)
+		if err != nil {
+			r.log.Printf("Unable to load upload session %v: %v", id, err)
+			continue
+		}
+		if time.Now().Before(s.expiryTime()) {
+			continue
+		}
+		// Sessions which are in use are pruned the next time.
+		err = r.managedAbortUploadSession(id)
+		if errors.Contains(err, ErrUploadSessionBusy) {
+			continue
+		}
+		if err != nil {
+			r.log.Printf("Unable to abort expired upload session %v: %v", id, err)
+			continue
+		}
+		r.log.Printf("Aborted expired upload session %v of %v", id, s.TurtleDexPath)
+	}
+}
+
+// managedWriteUploadSession reads the data from the reader one chunk at a
+// time. Full chunks are uploaded right away, unless there is staged data in
+// which case the staged chunk is filled up and uploaded first. The data which
+// doesn't fill a chunk is staged. It returns the error that occurred while
+// reading separately from the error that occurred while writing.
+func (r *Renter) managedWriteUploadSession(s *uploadSession, fileNode *filesystem.FileNode, reader io.Reader) (readErr error, err error) {
+	us := r.staticUploadSessions
+	chunkSize := fileNode.ChunkSize()
+	buf := make([]byte, chunkSize)
+	for readErr == nil {
+		staged, err := us.stagedSize(*s)
+		if err != nil {
+			return nil, err
+		}
+		// Staged data that fills a chunk is left behind if uploading it failed
+		// before, it needs to be uploaded first.
+		if staged >= chunkSize {
+			err = r.managedUploadStagedData(s, fileNode, false)
+			if err != nil {
+				return nil, errors.AddContext(err, "unable to upload staged data")
+			}
+			continue
+		}
+		var n int
+		n, readErr = io.ReadFull(reader, buf[:chunkSize-staged])
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			// The end of the data was reached.
+			readErr = io.EOF
+		}
+		if n == 0 {
+			break
+		}
+
+		// Upload full chunks right away.
+		if staged == 0 && uint64(n) == chunkSize {
+			up := modules.FileUploadParams{TurtleDexPath: s.TurtleDexPath, BudgetIdentity: s.BudgetIdentity}
+			err = r.managedUploadStreamChunks(up, fileNode, bytes.NewReader(buf), s.UploadedOffset/chunkSize)
+			if err != nil {
+				return nil, errors.AddContext(err, "failed to upload chunk")
+			}
+			s.UploadedOffset += chunkSize
+			err = us.save(*s)
+			if err != nil {
+				return nil, errors.AddContext(err, "failed to save upload session")
+			}
+			continue
+		}
+
+		// Stage the data and upload the staged chunk once it is full.
+		f, err := os.OpenFile(us.stagingPath(*s), os.O_WRONLY|os.O_CREATE|os.O_APPEND, modules.DefaultFilePerm)
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to open staging file")
+		}
+		_, err = f.Write(buf[:n])
+		err = errors.Compose(err, f.Sync(), f.Close())
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to write to staging file")
+		}
+		if staged+uint64(n) == chunkSize {
+			err = r.managedUploadStagedData(s, fileNode, false)
+			if err != nil {
+				return nil, errors.AddContext(err, "unable to upload staged data")
+			}
+		}
+	}
+	if readErr == io.EOF {
+		readErr = nil
+	}
+	return readErr, nil
+}
+
+// threadedPruneUploadSessions periodically aborts the upload sessions which
+// expired.
+func (r *Renter) threadedPruneUploadSessions() {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+	for {
+		r.managedPruneUploadSessions()
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-time.After(uploadSessionPruneInterval):
+		}
+	}
+}
+
+// managedOpenUploadSessionFile opens the siafile of an upload session. It
+// fails if the file was replaced since the session was created.
+func (r *Renter) managedOpenUploadSessionFile(s uploadSession) (*filesystem.FileNode, error) {
+	fileNode, err := r.staticFileSystem.OpenTurtleDexFile(s.TurtleDexPath)
+	if err != nil {
+		return nil, err
+	}
+	if fileNode.UID() != s.UID {
+		return nil, errors.Compose(ErrUploadSessionFileReplaced, fileNode.Close())
+	}
+	return fileNode, nil
+}
+
+// managedUploadStagedData uploads the full chunks within the session's
+// staging file to the session's fileNode. If final is true, the remaining data
+// is uploaded as the last chunk of the file as well. The data that wasn't
+// uploaded is moved to a new staging file and the session is updated
+// accordingly.
+func (r *Renter) managedUploadStagedData(s *uploadSession, fileNode *filesystem.FileNode, final bool) (err error) {
+	us := r.staticUploadSessions
+	// Figure out how much data can be uploaded.
+	stagingPath := us.stagingPath(*s)
+	f, err := os.OpenFile(stagingPath, os.O_RDONLY|os.O_CREATE, modules.DefaultFilePerm)
+	if err != nil {
+		return errors.AddContext(err, "failed to open staging file")
+	}
+	defer func() {
+		if f != nil {
+			err = errors.Compose(err, f.Close())
+		}
+	}()
+	fi, err := f.Stat()
+	if err != nil {
+		return errors.AddContext(err, "failed to stat staging file")
+	}
+	staged := uint64(fi.Size())
+	chunkSize := fileNode.ChunkSize()
+	toUpload := staged - staged%chunkSize
+	if final {
+		toUpload = staged
+	}
+	if toUpload == 0 {
+		return nil
+	}
+
+	// Upload the data.
+	up := modules.FileUploadParams{TurtleDexPath: s.TurtleDexPath, BudgetIdentity: s.BudgetIdentity}
+	err = r.managedUploadStreamChunks(up, fileNode, io.NewSectionReader(f, 0, int64(toUpload)), s.UploadedOffset/chunkSize)
+	if err != nil {
+		return err
+	}
+
+	// Move the remaining data to a new staging file before updating the
+	// session on disk.
+	newOffset := s.UploadedOffset + toUpload
+	if staged > toUpload {
+		nf, err := os.OpenFile(us.stagingPathAt(s.ID, newOffset), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, modules.DefaultFilePerm)
+		if err != nil {
+			return errors.AddContext(err, "failed to create staging file")
+		}
+		_, err = io.Copy(nf, io.NewSectionReader(f, int64(toUpload), int64(staged-toUpload)))
+		err = errors.Compose(err, nf.Sync(), nf.Close())
+		if err != nil {
+			return errors.AddContext(err, "failed to write staging file")
+		}
+	}
+	// Close the old staging file before removing it.
+	err = f.Close()
+	f = nil
+	if err != nil {
+		return errors.AddContext(err, "failed to close staging file")
+	}
+	s.UploadedOffset = newOffset
+	err = us.save(*s)
+	if err != nil {
+		return errors.AddContext(err, "failed to save upload session")
+	}
+	return errors.AddContext(os.Remove(stagingPath), "failed to remove old staging file")
+}
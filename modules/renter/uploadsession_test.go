@@ -0,0 +1,125 @@
+package renter
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/errors"
+	"github.com/turtledex/fastrand"
+)
+
+// TestUploadSessions tests persisting, loading and removing upload sessions.
+func TestUploadSessions(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	us, err := newUploadSessions(build.TempDir("renter", t.Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Loading a session with an invalid or unknown id should fail.
+	if _, err := us.load("foo"); !errors.Contains(err, ErrUnknownUploadSession) {
+		t.Fatal("expected ErrUnknownUploadSession but got", err)
+	}
+	id := hex.EncodeToString(fastrand.Bytes(uploadSessionIDLen))
+	if _, err := us.load(id); !errors.Contains(err, ErrUnknownUploadSession) {
+		t.Fatal("expected ErrUnknownUploadSession but got", err)
+	}
+
+	// Save a session and load it again.
+	s := uploadSession{
+		CreateTime:     time.Now().Round(0),
+		ID:             id,
+		TurtleDexPath:  modules.RandomTurtleDexPath(),
+		UploadedOffset: 100,
+	}
+	if err := us.save(s); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := us.load(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.CreateTime.Equal(s.CreateTime) || loaded.ID != s.ID || !loaded.TurtleDexPath.Equals(s.TurtleDexPath) || loaded.UploadedOffset != s.UploadedOffset {
+		t.Fatal("loaded session doesn't match saved one", loaded, s)
+	}
+
+	// The offset of the session should include the staged data.
+	info, err := us.info(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Offset != s.UploadedOffset {
+		t.Fatal("wrong offset", info.Offset)
+	}
+	if err := ioutil.WriteFile(us.stagingPath(s), fastrand.Bytes(10), modules.DefaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+	info, err = us.info(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Offset != s.UploadedOffset+10 {
+		t.Fatal("wrong offset", info.Offset)
+	}
+
+	// A session can only be acquired once at a time.
+	if err := us.managedAcquire(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := us.managedAcquire(id); !errors.Contains(err, ErrUploadSessionBusy) {
+		t.Fatal("expected ErrUploadSessionBusy but got", err)
+	}
+	us.managedRelease(id)
+	if err := us.managedAcquire(id); err != nil {
+		t.Fatal(err)
+	}
+	us.managedRelease(id)
+
+	// The session should be listed and expire after the timeout since its
+	// last write.
+	ids, err := us.ids()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0] != id {
+		t.Fatal("unexpected ids", ids)
+	}
+	if !s.expiryTime().Equal(s.CreateTime.Add(uploadSessionTimeout)) {
+		t.Fatal("wrong expiry time", s.expiryTime())
+	}
+	s.LastWriteTime = s.CreateTime.Add(time.Hour)
+	if !s.expiryTime().Equal(s.LastWriteTime.Add(uploadSessionTimeout)) {
+		t.Fatal("wrong expiry time", s.expiryTime())
+	}
+	if _, err := us.loadUnexpired(id); err != nil {
+		t.Fatal(err)
+	}
+	s.CreateTime = time.Now().Add(-2 * uploadSessionTimeout)
+	s.LastWriteTime = time.Time{}
+	if err := us.save(s); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := us.loadUnexpired(id); !errors.Contains(err, ErrUnknownUploadSession) {
+		t.Fatal("expected ErrUnknownUploadSession but got", err)
+	}
+
+	// Removing the session should also remove the staged data.
+	if err := us.remove(id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := us.load(id); !errors.Contains(err, ErrUnknownUploadSession) {
+		t.Fatal("expected ErrUnknownUploadSession but got", err)
+	}
+	if _, err := os.Stat(us.stagingPath(s)); !os.IsNotExist(err) {
+		t.Fatal("staging file should be gone", err)
+	}
+}
@@ -17,8 +17,8 @@ import (
 // Upload Streaming Overview:
 // Most of the logic that enables upload streaming can be found within
 // UploadStreamFromReader and the StreamShard. As seen at the beginning of the
-// big for - loop in UploadStreamFromReader, the streamer currently always
-// assumes that the data provided by the user starts at index 0 of chunk 0. In
+// big for - loop in managedUploadStreamChunks, the streamer currently always
+// assumes that the data provided by the user starts at index 0 of a chunk. In
 // every iteration the siafile is grown by a single chunk to prepare for the
 // upload of the next chunk. To allow the upload code to repair a chunk from a
 // stream, the stream is passed into the unfinished chunk as a new field. If the
@@ -150,10 +150,26 @@ func (r *Renter) managedInitUploadStream(up modules.FileUploadParams) (*filesyst
 	if force && repair {
 		return nil, errors.New("'force' and 'repair' can't both be set")
 	}
+	// Repairs don't change the contents of a file, which is why they are
+	// allowed for the files of directory snapshots.
+	if !repair {
+		if err := checkDirSnapshotWritable(siaPath); err != nil {
+			return nil, err
+		}
+	}
+	// The user metadata is only set on new files.
+	if len(up.UserMetadata) > 0 && repair {
+		return nil, errors.New("can't provide user metadata when doing repairs")
+	}
+	if err := modules.ValidateUserMetadata(up.UserMetadata); err != nil {
+		return nil, errors.AddContext(err, "invalid user metadata")
+	}
 
-	// Delete existing file if overwrite flag is set. Ignore ErrUnknownPath.
+	// Replace existing file if overwrite flag is set. Depending on the
+	// directory's version retention the file is either deleted or kept as a
+	// prior version. Ignore ErrUnknownPath.
 	if force {
-		err := r.DeleteFile(siaPath)
+		err := r.managedReplaceFile(siaPath)
 		if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
 			return nil, err
 		}
@@ -188,7 +204,15 @@ func (r *Renter) managedInitUploadStream(up modules.FileUploadParams) (*filesyst
 	if err != nil {
 		return nil, err
 	}
-	return r.staticFileSystem.OpenTurtleDexFile(siaPath)
+	fileNode, err := r.staticFileSystem.OpenTurtleDexFile(siaPath)
+	if err != nil {
+		return nil, err
+	}
+	err = r.managedSetNewFileUserMetadata(siaPath, fileNode, up.UserMetadata)
+	if err != nil {
+		return nil, err
+	}
+	return fileNode, nil
 }
 
 // callUploadStreamFromReader reads from the provided reader until io.EOF is
@@ -216,8 +240,35 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 		if err != nil {
 			err = errors.Compose(err, fn.Close())
 		}
+		// A partial upload that exceeded a quota or the budget of the
+		// uploader is removed again to free up the quota. The file might
+		// reference deduplicated chunks already, so it is deleted like any
+		// other file.
+		if errors.Contains(err, filesystem.ErrQuotaExceeded) || errors.Contains(err, modules.ErrBudgetExhausted) {
+			err = errors.Compose(err, r.DeleteFile(up.TurtleDexPath))
+		}
 	}()
 
+	// Upload the data.
+	err = r.managedUploadStreamChunks(up, fileNode, reader, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// Disrupt to force an error and ensure the fileNode is being closed
+	// correctly.
+	if r.deps.Disrupt("failUploadStreamFromReader") {
+		return nil, errors.New("disrupted by failUploadStreamFromReader")
+	}
+	return fileNode, nil
+}
+
+// managedUploadStreamChunks reads from the provided reader until io.EOF is
+// reached and uploads the data to the chunks of fileNode, starting at
+// firstChunkIndex. All chunks but the last one read from the reader need to be
+// full chunks. managedUploadStreamChunks returns as soon as the data is
+// available on the TurtleDex network.
+func (r *Renter) managedUploadStreamChunks(up modules.FileUploadParams, fileNode *filesystem.FileNode, reader io.Reader, firstChunkIndex uint64) (err error) {
 	// Build a map of host public keys.
 	pks := make(map[string]types.TurtleDexPublicKey)
 	for _, pk := range fileNode.HostPublicKeys() {
@@ -233,7 +284,7 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 	availableWorkers := len(r.staticWorkerPool.workers)
 	r.staticWorkerPool.mu.RUnlock()
 	if availableWorkers < minWorkers {
-		return nil, fmt.Errorf("Need at least %v workers for upload but got only %v", minWorkers, availableWorkers)
+		return fmt.Errorf("Need at least %v workers for upload but got only %v", minWorkers, availableWorkers)
 	}
 
 	// Read the chunks we want to upload one by one from the input stream using
@@ -241,7 +292,23 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 	// before the upload is done.
 	var peek []byte
 	var chunks []*unfinishedUploadChunk
-	for chunkIndex := uint64(0); ; chunkIndex++ {
+	var streamed uint64
+	for chunkIndex := firstChunkIndex; ; chunkIndex++ {
+		// Wait while new uploads are paused in favor of repairs. Since the
+		// size of the stream isn't known upfront, the chunks are charged to
+		// the uploader's budget once their data is read. Stop reading from
+		// the stream once the budget is used up.
+		if !up.Repair {
+			err = r.staticRepairThrottle.managedWaitForUploads(r.tg.StopChan())
+			if err != nil {
+				return errors.AddContext(err, "upload interrupted while waiting for repair-only mode to end")
+			}
+			err = r.managedCheckBudget(up.BudgetIdentity, modules.BudgetCategoryUpload)
+			if err != nil {
+				return errors.AddContext(err, "unable to upload stream")
+			}
+		}
+
 		// Disrupt the upload by closing the reader and simulating losing
 		// connectivity during the upload.
 		if r.deps.Disrupt("DisruptUploadStream") {
@@ -253,33 +320,36 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 		// Grow the TurtleDexFile to the right size. Otherwise buildUnfinishedChunk
 		// won't realize that there are pieces which haven't been repaired yet.
 		if err := fileNode.TurtleDexFile.GrowNumChunks(chunkIndex + 1); err != nil {
-			return nil, err
+			return err
 		}
 
 		// Start the chunk upload.
 		offline, goodForRenew, _ := r.managedContractUtilityMaps()
 		uuc, err := r.managedBuildUnfinishedChunk(fileNode, chunkIndex, hosts, pks, memoryPriorityHigh, offline, goodForRenew, r.userUploadMemoryManager)
 		if err != nil {
-			return nil, errors.AddContext(err, "unable to fetch chunk for stream")
+			return errors.AddContext(err, "unable to fetch chunk for stream")
 		}
 
 		// Create a new shard set it to be the source reader of the chunk.
 		ss := NewStreamShard(reader, peek)
 		uuc.sourceReader = ss
+		if !up.Repair {
+			uuc.budgetIdentity = up.BudgetIdentity
+		}
 
 		// Check if the chunk needs any work or if we can skip it.
 		if uuc.piecesCompleted < uuc.staticPiecesNeeded {
 			// Add the chunk to the upload heap's repair map.
 			pushed, err := r.managedPushChunkForRepair(uuc, chunkTypeStreamChunk)
 			if err != nil {
-				return nil, errors.AddContext(err, "unable to push chunk")
+				return errors.AddContext(err, "unable to push chunk")
 			}
 			if !pushed {
 				// The chunk wasn't added to the repair map meaning it must have
 				// already been in the repair map
 				_, _ = io.ReadFull(ss, make([]byte, fileNode.ChunkSize()))
 				if err := ss.Close(); err != nil {
-					return nil, err
+					return err
 				}
 			}
 			chunks = append(chunks, uuc)
@@ -290,23 +360,35 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 			// since we check that anyway at the end of the loop.
 			_, _ = io.ReadFull(ss, make([]byte, fileNode.ChunkSize()))
 			if err := ss.Close(); err != nil {
-				return nil, err
+				return err
 			}
 		}
 		// Wait for the shard to be read.
 		select {
 		case <-r.tg.StopChan():
-			return nil, errors.New("interrupted by shutdown")
+			return errors.New("interrupted by shutdown")
 		case <-ss.signalChan:
 		}
 
+		// Since the size of the stream isn't known upfront, the quotas of the
+		// file's directories are checked after every chunk. Repairs don't add
+		// any data.
+		if !up.Repair {
+			n, _ := ss.Result()
+			streamed += uint64(n)
+			err := r.managedCheckDirQuotas(up.TurtleDexPath, streamed, chunkIndex-firstChunkIndex+1, fileNode.ErasureCode(), false)
+			if err != nil {
+				return err
+			}
+		}
+
 		// If an io.EOF error occurred or less than chunkSize was read, we are
 		// done. Otherwise we report the error.
 		if _, err := ss.Result(); errors.Contains(err, io.EOF) {
 			// All chunks successfully submitted.
 			break
 		} else if ss.err != nil {
-			return nil, ss.err
+			return ss.err
 		}
 
 		// Call Peek to make sure that there's more data for another shard.
@@ -314,7 +396,7 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 		if errors.Contains(err, io.EOF) || errors.Contains(err, io.ErrUnexpectedEOF) {
 			break
 		} else if err != nil {
-			return nil, ss.err
+			return ss.err
 		}
 	}
 
@@ -329,14 +411,8 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 			chunk.mu.Unlock()
 		}
 		if err != nil {
-			return nil, errors.AddContext(err, "upload streamer failed to get all data available")
+			return errors.AddContext(err, "upload streamer failed to get all data available")
 		}
 	}
-
-	// Disrupt to force an error and ensure the fileNode is being closed
-	// correctly.
-	if r.deps.Disrupt("failUploadStreamFromReader") {
-		return nil, errors.New("disrupted by failUploadStreamFromReader")
-	}
-	return fileNode, nil
+	return nil
 }
@@ -0,0 +1,50 @@
+package renter
+
+import (
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
+	"github.com/turtledex/errors"
+)
+
+// SetDirUserMetadata sets the keys of the user metadata of the directory at
+// siaPath to the values in set and removes the keys in remove.
+func (r *Renter) SetDirUserMetadata(siaPath modules.TurtleDexPath, set map[string]string, remove []string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	err := r.staticFileSystem.SetDirUserMetadata(siaPath, set, remove)
+	if err != nil {
+		return errors.AddContext(err, "unable to set user metadata of directory")
+	}
+	return nil
+}
+
+// SetFileUserMetadata sets the keys of the user metadata of the file at
+// siaPath to the values in set and removes the keys in remove.
+func (r *Renter) SetFileUserMetadata(siaPath modules.TurtleDexPath, set map[string]string, remove []string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	err := r.staticFileSystem.SetFileUserMetadata(siaPath, set, remove)
+	if err != nil {
+		return errors.AddContext(err, "unable to set user metadata of file")
+	}
+	return nil
+}
+
+// managedSetNewFileUserMetadata sets the user metadata of a file that was just
+// created for an upload. If that fails, the file is closed and deleted again
+// to not leave a file without its metadata behind.
+func (r *Renter) managedSetNewFileUserMetadata(siaPath modules.TurtleDexPath, fileNode *filesystem.FileNode, md map[string]string) error {
+	if len(md) == 0 {
+		return nil
+	}
+	err := fileNode.SetUserMetadata(md, nil)
+	if err == nil {
+		return nil
+	}
+	err = errors.AddContext(err, "unable to set user metadata of new file")
+	return errors.Compose(err, fileNode.Close(), r.staticFileSystem.DeleteFile(siaPath))
+}
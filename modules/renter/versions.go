@@ -0,0 +1,219 @@
+package renter
+
+import (
+	"sync"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
+	"github.com/turtledex/errors"
+)
+
+// FileVersions returns the prior versions of the file at siaPath, sorted from
+// oldest to newest.
+func (r *Renter) FileVersions(siaPath modules.TurtleDexPath) ([]modules.FileVersionInfo, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+
+	ids, err := r.staticFileSystem.FileVersions(siaPath)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to fetch file versions")
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	// Grab the infos of all the versions at once.
+	versionsDir, err := filesystem.VersionsTurtleDexPath(siaPath)
+	if err != nil {
+		return nil, err
+	}
+	var mu sync.Mutex
+	infos := make(map[string]modules.FileInfo)
+	flf := func(fi modules.FileInfo) {
+		mu.Lock()
+		infos[fi.Name()] = fi
+		mu.Unlock()
+	}
+	err = r.staticFileSystem.CachedList(versionsDir, false, flf, func(modules.DirectoryInfo) {})
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to list file versions")
+	}
+
+	versions := make([]modules.FileVersionInfo, 0, len(ids))
+	for _, id := range ids {
+		fi, exists := infos[id]
+		if !exists {
+			continue
+		}
+		archiveTime, err := filesystem.VersionTime(id)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, modules.FileVersionInfo{
+			FileInfo:    fi,
+			ArchiveTime: archiveTime,
+			VersionID:   id,
+		})
+	}
+	return versions, nil
+}
+
+// RestoreFileVersion replaces the file at siaPath with the prior version with
+// the provided id. The replaced file is retained as a new version.
+func (r *Renter) RestoreFileVersion(siaPath modules.TurtleDexPath, versionID string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	retention, err := r.staticFileSystem.VersionRetention(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to fetch version retention")
+	}
+	// Without versioning, the current file is deleted. Deleting it through
+	// the renter releases its dedup references. Make sure the version exists
+	// before deleting anything.
+	if retention == 0 {
+		ids, err := r.staticFileSystem.FileVersions(siaPath)
+		if err != nil {
+			return errors.AddContext(err, "unable to fetch file versions")
+		}
+		found := false
+		for _, id := range ids {
+			found = found || id == versionID
+		}
+		if !found {
+			return errors.AddContext(filesystem.ErrUnknownVersion, "unable to restore file version")
+		}
+		exists, err := r.staticFileSystem.FileExists(siaPath)
+		if err != nil {
+			return errors.AddContext(err, "unable to check for current file")
+		}
+		if exists {
+			if err := r.DeleteFile(siaPath); err != nil {
+				return errors.AddContext(err, "unable to delete current file")
+			}
+		}
+	}
+	versionRefs := r.managedVersionDedupReferences(siaPath)
+	err = r.staticFileSystem.RestoreFileVersion(siaPath, versionID, retention)
+	if err != nil {
+		return errors.AddContext(err, "unable to restore file version")
+	}
+	r.managedReleasePrunedVersions(siaPath, versionRefs, versionID)
+	r.managedBubbleVersionedFile(siaPath)
+	return nil
+}
+
+// SetDirVersionRetention sets the number of prior versions that are kept for
+// files within the directory at siaPath when they are overwritten. A
+// retention of 0 inherits the setting of the parent directory and
+// modules.VersionRetentionDisabled disables versioning.
+func (r *Renter) SetDirVersionRetention(siaPath modules.TurtleDexPath, retention int64) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.staticFileSystem.SetVersionRetention(siaPath, retention)
+}
+
+// managedReplaceFile removes the file at siaPath to make room for a new upload.
+// Depending on the version retention of the file's directory, the file is
+// either kept around as a prior version or deleted.
+func (r *Renter) managedReplaceFile(siaPath modules.TurtleDexPath) error {
+	retention, err := r.staticFileSystem.VersionRetention(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to fetch version retention")
+	}
+	// Without versioning, the file is deleted.
+	if retention == 0 {
+		return r.DeleteFile(siaPath)
+	}
+	versionRefs := r.managedVersionDedupReferences(siaPath)
+	err = r.staticFileSystem.ArchiveFile(siaPath, retention)
+	if err != nil {
+		return err
+	}
+	r.managedReleasePrunedVersions(siaPath, versionRefs, "")
+	r.managedBubbleVersionedFile(siaPath)
+	return nil
+}
+
+// managedBubbleVersionedFile bubbles the directories of a file and its versions
+// after the file was archived or restored.
+func (r *Renter) managedBubbleVersionedFile(siaPath modules.TurtleDexPath) {
+	dirTurtleDexPath, err := siaPath.Dir()
+	if err != nil {
+		r.log.Printf("Unable to fetch the directory from a siaPath %v for versioned siafile: %v", siaPath, err)
+		return
+	}
+	versionsDir, err := filesystem.VersionsTurtleDexPath(siaPath)
+	if err != nil {
+		r.log.Printf("Unable to fetch the versions directory of siaPath %v: %v", siaPath, err)
+		return
+	}
+	bubblePaths := r.newUniqueRefreshPaths()
+	err = errors.Compose(bubblePaths.callAdd(dirTurtleDexPath), bubblePaths.callAdd(versionsDir))
+	if err != nil {
+		r.log.Printf("failed to add bubble paths for versioned siafile %v: %v", siaPath, err)
+	}
+	bubblePaths.callRefreshAll()
+}
+
+// managedVersionDedupReferences returns the references to deduplicated chunks
+// of the versions of the file at siaPath by version id.
+func (r *Renter) managedVersionDedupReferences(siaPath modules.TurtleDexPath) map[string][]dedupReference {
+	refs := make(map[string][]dedupReference)
+	ids, err := r.staticFileSystem.FileVersions(siaPath)
+	if err != nil {
+		r.log.Printf("Unable to fetch versions of %v: %v", siaPath, err)
+		return refs
+	}
+	versionsDir, err := filesystem.VersionsTurtleDexPath(siaPath)
+	if err != nil {
+		r.log.Printf("Unable to fetch the versions directory of siaPath %v: %v", siaPath, err)
+		return refs
+	}
+	for _, id := range ids {
+		versionPath, err := versionsDir.Join(id)
+		if err != nil {
+			r.log.Printf("Invalid version %v of %v: %v", id, siaPath, err)
+			continue
+		}
+		versionRefs, err := r.managedFileDedupReferences(versionPath)
+		if err != nil {
+			r.log.Printf("Unable to fetch dedup references of %v: %v", versionPath, err)
+			continue
+		}
+		refs[id] = versionRefs
+	}
+	return refs
+}
+
+// managedReleasePrunedVersions releases the dedup references of the versions
+// in refs that no longer exist. The version with the id restoredID was
+// restored and therefore still exists.
+func (r *Renter) managedReleasePrunedVersions(siaPath modules.TurtleDexPath, refs map[string][]dedupReference, restoredID string) {
+	ids, err := r.staticFileSystem.FileVersions(siaPath)
+	if err != nil {
+		r.log.Printf("Unable to fetch versions of %v: %v", siaPath, err)
+		return
+	}
+	remaining := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		remaining[id] = struct{}{}
+	}
+	var pruned []dedupReference
+	for id, versionRefs := range refs {
+		if _, exists := remaining[id]; exists || id == restoredID {
+			continue
+		}
+		pruned = append(pruned, versionRefs...)
+	}
+	err = r.staticDedupIndex.managedRemoveReferences(pruned)
+	if err != nil {
+		r.log.Printf("Unable to release dedup references of pruned versions of %v: %v", siaPath, err)
+	}
+}
@@ -94,6 +94,10 @@ type (
 		uploadRecentFailureErr    error         // What was the reason for the last failure?
 		uploadTerminated          bool          // Have we stopped uploading?
 
+		// throughputSampleTime is the last time the hostdb sampled the
+		// throughput of the worker's host.
+		throughputSampleTime time.Time
+
 		// The staticAccount represent the renter's ephemeral account on the
 		// host. It keeps track of the available balance in the account, the
 		// worker has a refill mechanism that keeps the account balance filled
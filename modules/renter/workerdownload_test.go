@@ -279,7 +279,7 @@ func TestProcessDownloadChunk(t *testing.T) {
 	// helper to add jobs to the queue.
 	addBlankJobs := func(n int) {
 		for i := 0; i < n; i++ {
-			j := wt.newJobReadSector(context.Background(), wt.staticJobLowPrioReadQueue, make(chan *jobReadResponse), crypto.Hash{}, 0, 0)
+			j := wt.newJobReadSector(context.Background(), wt.staticJobLowPrioReadQueue, make(chan *jobReadResponse), modules.DownloadPriorityNormal, crypto.Hash{}, 0, 0)
 			wt.staticJobLowPrioReadQueue.mu.Lock()
 			wt.staticJobLowPrioReadQueue.jobs.PushBack(j)
 			wt.staticJobLowPrioReadQueue.mu.Unlock()
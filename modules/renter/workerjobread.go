@@ -26,11 +26,21 @@ type (
 	// jobRead contains information about a Read query.
 	jobRead struct {
 		staticLength       uint64
+		staticPriority     modules.DownloadPriority
 		staticResponseChan chan *jobReadResponse
 
 		*jobGeneric
 	}
 
+	// readJob is a job in the read queue. All read jobs carry the priority of
+	// the download they belong to.
+	readJob interface {
+		workerJob
+
+		// staticDownloadPriority returns the download priority of the job.
+		staticDownloadPriority() modules.DownloadPriority
+	}
+
 	// jobReadQueue is a list of Read queries that have been assigned to the
 	// worker. The queue also tracks performance metrics, which can then be used
 	// by projects to optimize job scheduling between workers.
@@ -184,6 +194,40 @@ func (j *jobRead) managedRead(w *worker, program modules.Program, programData []
 	return responses, nil
 }
 
+// staticDownloadPriority returns the download priority of the job.
+func (j *jobRead) staticDownloadPriority() modules.DownloadPriority {
+	return j.staticPriority
+}
+
+// add will add a job to the queue. Jobs are ordered by their download
+// priority, a job is inserted behind all jobs with the same or a higher
+// priority but in front of all jobs with a lower priority. That way queued
+// bulk downloads are preempted by interactive downloads.
+func (jq *jobReadQueue) add(j readJob) bool {
+	if jq.killed || jq.onCooldown() {
+		return false
+	}
+	priority := j.staticDownloadPriority()
+	e := jq.jobs.Back()
+	for e != nil && e.Value.(readJob).staticDownloadPriority() < priority {
+		e = e.Prev()
+	}
+	if e == nil {
+		jq.jobs.PushFront(j)
+	} else {
+		jq.jobs.InsertAfter(j, e)
+	}
+	jq.staticWorkerObj.staticWake()
+	return true
+}
+
+// callAdd will add a job to the queue.
+func (jq *jobReadQueue) callAdd(j readJob) bool {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+	return jq.add(j)
+}
+
 // callAddWithEstimate will add a job to the job read queue while providing an
 // estimate for when the job is expected to return.
 func (jq *jobReadQueue) callAddWithEstimate(j *jobReadSector) (time.Time, bool) {
@@ -197,6 +241,17 @@ func (jq *jobReadQueue) callAddWithEstimate(j *jobReadSector) (time.Time, bool)
 	return time.Now().Add(estimate), true
 }
 
+// callQueueDepths returns the number of queued jobs per download priority.
+func (jq *jobReadQueue) callQueueDepths() modules.DownloadPriorityCounts {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+	var depths modules.DownloadPriorityCounts
+	for e := jq.jobs.Front(); e != nil; e = e.Next() {
+		depths.Add(e.Value.(readJob).staticDownloadPriority())
+	}
+	return depths
+}
+
 // callExpectedJobTime will return the recent performance of the worker
 // attempting to complete read jobs. The call distinguishes based on the
 // size of the job, breaking the jobs into 3 categories: less than 64kb, less
@@ -131,3 +131,51 @@ func TestJobReadMetadata(t *testing.T) {
 		t.Fatal("unexpected")
 	}
 }
+
+// TestJobReadQueuePriority verifies that the read queue orders jobs by their
+// download priority.
+func TestJobReadQueuePriority(t *testing.T) {
+	t.Parallel()
+
+	w := new(worker)
+	w.initJobReadQueue()
+	jrq := w.staticJobReadQueue
+
+	// Add jobs of different priorities, using the length to identify them.
+	priorities := []modules.DownloadPriority{
+		modules.DownloadPriorityBulk,
+		modules.DownloadPriorityNormal,
+		0, // repair
+		modules.DownloadPriorityBulk,
+		modules.DownloadPriorityInteractive,
+		modules.DownloadPriorityNormal,
+		modules.DownloadPriorityInteractive,
+	}
+	for i, priority := range priorities {
+		j := w.newJobReadSector(context.Background(), jrq, make(chan *jobReadResponse), priority, crypto.Hash{}, 0, uint64(i))
+		if !jrq.callAdd(j) {
+			t.Fatal("failed to add job")
+		}
+	}
+
+	// Check the queue depths.
+	depths := jrq.callQueueDepths()
+	if depths.Interactive != 2 || depths.Normal != 2 || depths.Bulk != 3 {
+		t.Fatal("unexpected depths", depths)
+	}
+
+	// Higher priorities should be returned first, jobs of the same priority
+	// in the order they were added.
+	for _, expected := range []uint64{4, 6, 1, 5, 0, 3, 2} {
+		j := jrq.callNext()
+		if j == nil {
+			t.Fatal("queue is empty")
+		}
+		if length := j.(*jobReadSector).staticLength; length != expected {
+			t.Fatalf("expected job %v but got %v", expected, length)
+		}
+	}
+	if jrq.callNext() != nil {
+		t.Fatal("queue should be empty")
+	}
+}
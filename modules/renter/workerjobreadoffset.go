@@ -104,6 +104,7 @@ func (w *worker) ReadOffset(ctx context.Context, offset, length uint64) ([]byte,
 		jobRead: jobRead{
 			staticResponseChan: readOffsetRespChan,
 			staticLength:       length,
+			staticPriority:     modules.DownloadPriorityNormal,
 			jobGeneric:         newJobGeneric(ctx, w.staticJobReadQueue, nil),
 		},
 		staticOffset: offset,
@@ -62,11 +62,12 @@ func (j *jobReadSector) managedReadSector() ([]byte, error) {
 }
 
 // newJobReadSector creates a new read sector job.
-func (w *worker) newJobReadSector(ctx context.Context, queue *jobReadQueue, respChan chan *jobReadResponse, root crypto.Hash, offset, length uint64) *jobReadSector {
+func (w *worker) newJobReadSector(ctx context.Context, queue *jobReadQueue, respChan chan *jobReadResponse, priority modules.DownloadPriority, root crypto.Hash, offset, length uint64) *jobReadSector {
 	return &jobReadSector{
 		jobRead: jobRead{
 			staticResponseChan: respChan,
 			staticLength:       length,
+			staticPriority:     priority,
 
 			jobGeneric: newJobGeneric(ctx, w.staticJobReadQueue, &jobReadMetadata{
 				staticSectorRoot: root,
@@ -79,10 +80,10 @@ func (w *worker) newJobReadSector(ctx context.Context, queue *jobReadQueue, resp
 }
 
 // ReadSector is a helper method to run a ReadSector job with low priority on a
-// worker.
-func (w *worker) ReadSectorLowPrio(ctx context.Context, root crypto.Hash, offset, length uint64) ([]byte, error) {
+// worker. The download priority orders the job relative to other read jobs.
+func (w *worker) ReadSectorLowPrio(ctx context.Context, priority modules.DownloadPriority, root crypto.Hash, offset, length uint64) ([]byte, error) {
 	readSectorRespChan := make(chan *jobReadResponse)
-	jro := w.newJobReadSector(ctx, w.staticJobLowPrioReadQueue, readSectorRespChan, root, offset, length)
+	jro := w.newJobReadSector(ctx, w.staticJobLowPrioReadQueue, readSectorRespChan, priority, root, offset, length)
 
 	// Add the job to the queue.
 	if !w.staticJobReadQueue.callAdd(jro) {
@@ -102,7 +103,7 @@ func (w *worker) ReadSectorLowPrio(ctx context.Context, root crypto.Hash, offset
 // ReadSector is a helper method to run a ReadSector job on a worker.
 func (w *worker) ReadSector(ctx context.Context, root crypto.Hash, offset, length uint64) ([]byte, error) {
 	readSectorRespChan := make(chan *jobReadResponse)
-	jro := w.newJobReadSector(ctx, w.staticJobReadQueue, readSectorRespChan, root, offset, length)
+	jro := w.newJobReadSector(ctx, w.staticJobReadQueue, readSectorRespChan, modules.DownloadPriorityNormal, root, offset, length)
 
 	// Add the job to the queue.
 	if !w.staticJobReadQueue.callAdd(jro) {
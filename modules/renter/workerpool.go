@@ -40,6 +40,7 @@ func (wp *workerPool) callStatus() modules.WorkerPoolStatus {
 	// Fetch the list of workers from the worker pool.
 
 	var totalDownloadCoolDown, totalMaintenanceCoolDown, totalUploadCoolDown int
+	var readJobQueueDepths modules.DownloadPriorityCounts
 	var statuss []modules.WorkerStatus // Plural of status is statuss, deal with it.
 	workers := wp.callWorkers()
 
@@ -55,6 +56,7 @@ func (wp *workerPool) callStatus() modules.WorkerPoolStatus {
 		if status.UploadOnCoolDown {
 			totalUploadCoolDown++
 		}
+		readJobQueueDepths = readJobQueueDepths.Sum(status.ReadJobsStatus.JobQueueDepths)
 		statuss = append(statuss, status)
 	}
 	return modules.WorkerPoolStatus{
@@ -63,6 +65,9 @@ func (wp *workerPool) callStatus() modules.WorkerPoolStatus {
 		TotalMaintenanceCoolDown: totalMaintenanceCoolDown,
 		TotalUploadCoolDown:      totalUploadCoolDown,
 		Workers:                  statuss,
+
+		DownloadHeapDepths: wp.renter.managedDownloadHeapDepths(),
+		ReadJobQueueDepths: readJobQueueDepths,
 	}
 }
 
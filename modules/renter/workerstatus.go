@@ -126,12 +126,17 @@ func (w *worker) callReadJobStatus() modules.WorkerReadJobsStatus {
 		return 0
 	}
 
+	// The depths include the jobs of the legacy download code, which are
+	// queued in the low priority queue.
+	depths := jrq.callQueueDepths().Sum(w.staticJobLowPrioReadQueue.callQueueDepths())
+
 	return modules.WorkerReadJobsStatus{
 		AvgJobTime64k:       avgJobTimeInMs(1 << 16),
 		AvgJobTime1m:        avgJobTimeInMs(1 << 20),
 		AvgJobTime4m:        avgJobTimeInMs(1 << 22),
 		ConsecutiveFailures: status.consecutiveFailures,
 		JobQueueSize:        status.size,
+		JobQueueDepths:      depths,
 		RecentErr:           recentErrString,
 		RecentErrTime:       status.recentErrTime,
 	}
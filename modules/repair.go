@@ -0,0 +1,70 @@
+package modules
+
+import (
+	"time"
+)
+
+type (
+	// RepairSettings limit the resources used by the renter to repair files
+	// independently of new uploads. A limit of 0 means that the resource is
+	// unlimited.
+	RepairSettings struct {
+		// MaxBytesPerSecond limits the rate at which repaired pieces are
+		// handed to the workers.
+		MaxBytesPerSecond uint64 `json:"maxbytespersecond"`
+
+		// MaxConcurrentChunks limits the number of chunks that are repaired
+		// at the same time.
+		MaxConcurrentChunks uint64 `json:"maxconcurrentchunks"`
+
+		// RepairOnly pauses new uploads while repairs keep running.
+		RepairOnly bool `json:"repaironly"`
+	}
+
+	// RepairStatus reports how far behind the repairs of the renter are.
+	RepairStatus struct {
+		RepairSettings
+
+		// ActiveChunks is the number of chunks that are currently being
+		// repaired.
+		ActiveChunks uint64 `json:"activechunks"`
+
+		// RepairBytes and StuckBytes are the number of bytes that need to be
+		// uploaded to bring all unstuck and stuck chunks back to full
+		// redundancy.
+		RepairBytes uint64 `json:"repairbytes"`
+		StuckBytes  uint64 `json:"stuckbytes"`
+
+		// NumStuckChunks is the number of stuck chunks of the renter's files,
+		// StuckChunksInHeap is the number of stuck chunks that are currently
+		// queued for repair and RecentlySuccessfulStuckFiles is the number of
+		// files with a recent successful stuck chunk repair whose remaining
+		// stuck chunks are repaired first.
+		NumStuckChunks               uint64 `json:"numstuckchunks"`
+		StuckChunksInHeap            uint64 `json:"stuckchunksinheap"`
+		RecentlySuccessfulStuckFiles uint64 `json:"recentlysuccessfulstuckfiles"`
+
+		// Throughput is the average number of bytes per second that were
+		// repaired recently.
+		Throughput uint64 `json:"throughput"`
+
+		// ETA is the estimated time until all stuck and unstuck chunks are
+		// repaired at the current throughput. ETAUnknown is set if there is
+		// data to repair but no recent throughput to base an estimate on.
+		ETA        time.Duration `json:"eta"`
+		ETAUnknown bool          `json:"etaunknown"`
+	}
+)
+
+// RepairETA estimates how long it takes to repair the given number of bytes
+// at the given throughput in bytes per second. The second return value is
+// false if there is data to repair but the throughput is zero.
+func RepairETA(bytes, throughput uint64) (time.Duration, bool) {
+	if bytes == 0 {
+		return 0, true
+	}
+	if throughput == 0 {
+		return 0, false
+	}
+	return time.Duration(float64(bytes) / float64(throughput) * float64(time.Second)), true
+}
@@ -0,0 +1,19 @@
+package modules
+
+import (
+	"testing"
+	"time"
+)
+
+// TestRepairETA tests estimating the duration of repairs.
+func TestRepairETA(t *testing.T) {
+	if eta, known := RepairETA(0, 0); !known || eta != 0 {
+		t.Fatal("nothing to repair should take no time", eta, known)
+	}
+	if _, known := RepairETA(100, 0); known {
+		t.Fatal("eta shouldn't be known without throughput")
+	}
+	if eta, known := RepairETA(100, 10); !known || eta != 10*time.Second {
+		t.Fatal("wrong eta", eta, known)
+	}
+}
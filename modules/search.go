@@ -0,0 +1,94 @@
+package modules
+
+import (
+	"path"
+	"strings"
+	"time"
+)
+
+type (
+	// IndexedFile is the entry of a file in the renter's search index.
+	IndexedFile struct {
+		TurtleDexPath TurtleDexPath `json:"siapath"`
+		Filesize      uint64        `json:"filesize"`
+		CreateTime    time.Time     `json:"createtime"`
+		ModTime       time.Time     `json:"modtime"`
+		Health        float64       `json:"health"`
+		Skylinks      []string      `json:"skylinks"`
+	}
+
+	// FileSearchParams are the filters of a search for files in the renter's
+	// search index. A file needs to match all of the filters that are set. The
+	// path filters are applied to the path of a file relative to the searched
+	// directory.
+	FileSearchParams struct {
+		// Glob is a shell pattern as accepted by path.Match. Patterns without
+		// a '/' are matched against the name of a file, all other patterns
+		// are matched against its path.
+		Glob string `json:"glob"`
+
+		// Prefix and Substring match the start of a file's path and any part
+		// of it. Substring is case-insensitive.
+		Prefix    string `json:"prefix"`
+		Substring string `json:"substring"`
+
+		// MinSize and MaxSize are the bounds of a file's size. A MaxSize of 0
+		// means that there is no upper bound.
+		MinSize uint64 `json:"minsize"`
+		MaxSize uint64 `json:"maxsize"`
+
+		// MinHealth and MaxHealth are the bounds of a file's health. Since a
+		// lower health is better, MaxHealth can be used to find files which
+		// are at least as healthy as the threshold and MinHealth to find files
+		// which need repairs.
+		MinHealth *float64 `json:"minhealth,omitempty"`
+		MaxHealth *float64 `json:"maxhealth,omitempty"`
+
+		// HasSkylink filters files by whether they have a skylink.
+		HasSkylink *bool `json:"hasskylink,omitempty"`
+
+		// Limit is the maximum number of files returned. 0 means that there is
+		// no limit.
+		Limit uint64 `json:"limit"`
+	}
+)
+
+// ValidateFileSearchParams checks that the patterns of the params are valid.
+func ValidateFileSearchParams(params FileSearchParams) error {
+	_, err := path.Match(params.Glob, "")
+	return err
+}
+
+// Matches returns whether the file at relPath, which is the path of the file
+// relative to the searched directory, matches the params. The params are
+// expected to be validated.
+func (params FileSearchParams) Matches(relPath string, file IndexedFile) bool {
+	if params.Glob != "" {
+		name := relPath
+		if !strings.Contains(params.Glob, "/") {
+			name = path.Base(relPath)
+		}
+		if matched, _ := path.Match(params.Glob, name); !matched {
+			return false
+		}
+	}
+	if !strings.HasPrefix(relPath, params.Prefix) {
+		return false
+	}
+	if params.Substring != "" && !strings.Contains(strings.ToLower(relPath), strings.ToLower(params.Substring)) {
+		return false
+	}
+	if file.Filesize < params.MinSize || (params.MaxSize > 0 && file.Filesize > params.MaxSize) {
+		return false
+	}
+	if params.MinHealth != nil && file.Health < *params.MinHealth {
+		return false
+	}
+	if params.MaxHealth != nil && file.Health > *params.MaxHealth {
+		return false
+	}
+	if params.HasSkylink != nil && (len(file.Skylinks) > 0) != *params.HasSkylink {
+		return false
+	}
+	return true
+}
@@ -119,6 +119,10 @@ type (
 		// a Skykey will be derived from the Master Skykey found under that
 		// name/ID to be used for this specific upload.
 		FileSpecificSkykey skykey.Skykey
+
+		// BudgetIdentity is the identity whose budget is charged for the
+		// upload.
+		BudgetIdentity string
 	}
 
 	// SkyfileMultipartUploadParameters defines the parameters specific to
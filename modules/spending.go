@@ -0,0 +1,183 @@
+package modules
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+const (
+	// SpendingTypeFormation is the type of the records for the funds
+	// allocated to newly formed contracts, including the fees.
+	SpendingTypeFormation SpendingType = "formation"
+
+	// SpendingTypeRenewal is the type of the records for the funds allocated
+	// to renewed contracts, including the fees.
+	SpendingTypeRenewal SpendingType = "renewal"
+
+	// SpendingTypeUpload is the type of the records for the bandwidth spent on
+	// uploads.
+	SpendingTypeUpload SpendingType = "upload"
+
+	// SpendingTypeDownload is the type of the records for the bandwidth spent
+	// on downloads.
+	SpendingTypeDownload SpendingType = "download"
+
+	// SpendingTypeStorage is the type of the records for the storage paid for
+	// with uploads.
+	SpendingTypeStorage SpendingType = "storage"
+
+	// SpendingTypeAccountFunding is the type of the records for the funds
+	// moved from contracts to ephemeral accounts.
+	SpendingTypeAccountFunding SpendingType = "accountfunding"
+
+	// SpendingTypeRPC is the type of the records for other RPCs paid for with
+	// contracts, e.g. price table updates.
+	SpendingTypeRPC SpendingType = "rpc"
+)
+
+var (
+	// ErrInvalidSpendingType is returned when a spending type is unknown.
+	ErrInvalidSpendingType = errors.New("invalid spending type")
+
+	// SpendingTypes contains all spending types.
+	SpendingTypes = []SpendingType{
+		SpendingTypeFormation,
+		SpendingTypeRenewal,
+		SpendingTypeUpload,
+		SpendingTypeDownload,
+		SpendingTypeStorage,
+		SpendingTypeAccountFunding,
+		SpendingTypeRPC,
+	}
+)
+
+type (
+	// SpendingType describes what money was spent on.
+	SpendingType string
+
+	// SpendingRecord is an entry of the renter's spending ledger.
+	SpendingRecord struct {
+		Type   SpendingType   `json:"type"`
+		Amount types.Currency `json:"amount"`
+
+		// Fees are the contract, transaction and siafund fees of formations
+		// and renewals. They are included in Amount.
+		Fees types.Currency `json:"fees"`
+
+		HostPublicKey types.TurtleDexPublicKey `json:"hostpublickey"`
+		ContractID    types.FileContractID     `json:"contractid"`
+		BlockHeight   types.BlockHeight        `json:"blockheight"`
+		Timestamp     time.Time                `json:"timestamp"`
+	}
+
+	// SpendingHistoryFilter selects records of the spending ledger. Unset
+	// fields match all records.
+	SpendingHistoryFilter struct {
+		Types         []SpendingType            `json:"types"`
+		HostPublicKey *types.TurtleDexPublicKey `json:"hostpublickey,omitempty"`
+		ContractID    *types.FileContractID     `json:"contractid,omitempty"`
+
+		// MinHeight and MaxHeight are the bounds of the block height of a
+		// record. A MaxHeight of 0 means that there is no upper bound.
+		MinHeight types.BlockHeight `json:"minheight"`
+		MaxHeight types.BlockHeight `json:"maxheight"`
+
+		// Start and End are the bounds of the timestamp of a record. The zero
+		// time means that there is no bound.
+		Start time.Time `json:"start"`
+		End   time.Time `json:"end"`
+
+		// Limit is the maximum number of records returned. If more records
+		// match, the most recent ones are returned. 0 means that there is no
+		// limit.
+		Limit uint64 `json:"limit"`
+	}
+)
+
+// ParseSpendingType parses a spending type.
+func ParseSpendingType(str string) (SpendingType, error) {
+	for _, t := range SpendingTypes {
+		if string(t) == str {
+			return t, nil
+		}
+	}
+	return "", errors.AddContext(ErrInvalidSpendingType, str)
+}
+
+// ParseSpendingTypes parses a comma separated list of spending types.
+func ParseSpendingTypes(str string) ([]SpendingType, error) {
+	if str == "" {
+		return nil, nil
+	}
+	var spendingTypes []SpendingType
+	for _, s := range strings.Split(str, ",") {
+		t, err := ParseSpendingType(strings.TrimSpace(s))
+		if err != nil {
+			return nil, err
+		}
+		spendingTypes = append(spendingTypes, t)
+	}
+	return spendingTypes, nil
+}
+
+// Matches returns whether the record matches the filter.
+func (f SpendingHistoryFilter) Matches(record SpendingRecord) bool {
+	if len(f.Types) > 0 {
+		found := false
+		for _, t := range f.Types {
+			found = found || t == record.Type
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.HostPublicKey != nil && !f.HostPublicKey.Equals(record.HostPublicKey) {
+		return false
+	}
+	if f.ContractID != nil && *f.ContractID != record.ContractID {
+		return false
+	}
+	if record.BlockHeight < f.MinHeight || (f.MaxHeight > 0 && record.BlockHeight > f.MaxHeight) {
+		return false
+	}
+	if !f.Start.IsZero() && record.Timestamp.Before(f.Start) {
+		return false
+	}
+	if !f.End.IsZero() && record.Timestamp.After(f.End) {
+		return false
+	}
+	return true
+}
+
+// WriteSpendingRecordsCSV writes the records to w in CSV format with a header
+// line. Amounts are written in hastings.
+func WriteSpendingRecordsCSV(w io.Writer, records []SpendingRecord) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"timestamp", "blockheight", "type", "amount", "fees", "hostpublickey", "contractid"})
+	if err != nil {
+		return err
+	}
+	for _, r := range records {
+		err = cw.Write([]string{
+			r.Timestamp.UTC().Format(time.RFC3339),
+			fmt.Sprint(r.BlockHeight),
+			string(r.Type),
+			r.Amount.String(),
+			r.Fees.String(),
+			r.HostPublicKey.String(),
+			r.ContractID.String(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,100 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+// TestParseSpendingTypes tests parsing lists of spending types.
+func TestParseSpendingTypes(t *testing.T) {
+	spendingTypes, err := ParseSpendingTypes("")
+	if err != nil || spendingTypes != nil {
+		t.Fatal("empty string should parse to nil", spendingTypes, err)
+	}
+	spendingTypes, err = ParseSpendingTypes("upload, storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(spendingTypes, []SpendingType{SpendingTypeUpload, SpendingTypeStorage}) {
+		t.Fatal("unexpected types", spendingTypes)
+	}
+	if _, err := ParseSpendingTypes("upload,fees"); !errors.Contains(err, ErrInvalidSpendingType) {
+		t.Fatal("expected ErrInvalidSpendingType", err)
+	}
+}
+
+// TestSpendingHistoryFilter tests matching spending records against filters.
+func TestSpendingHistoryFilter(t *testing.T) {
+	var hpk types.TurtleDexPublicKey
+	hpk.Key = []byte{1}
+	fcid := types.FileContractID{1}
+	now := time.Now()
+	record := SpendingRecord{
+		Type:          SpendingTypeDownload,
+		Amount:        types.NewCurrency64(100),
+		HostPublicKey: hpk,
+		ContractID:    fcid,
+		BlockHeight:   10,
+		Timestamp:     now,
+	}
+
+	var otherHost types.TurtleDexPublicKey
+	otherHost.Key = []byte{2}
+	otherContract := types.FileContractID{2}
+	tests := []struct {
+		filter  SpendingHistoryFilter
+		matches bool
+	}{
+		{SpendingHistoryFilter{}, true},
+		{SpendingHistoryFilter{Types: []SpendingType{SpendingTypeUpload, SpendingTypeDownload}}, true},
+		{SpendingHistoryFilter{Types: []SpendingType{SpendingTypeUpload}}, false},
+		{SpendingHistoryFilter{HostPublicKey: &hpk}, true},
+		{SpendingHistoryFilter{HostPublicKey: &otherHost}, false},
+		{SpendingHistoryFilter{ContractID: &fcid}, true},
+		{SpendingHistoryFilter{ContractID: &otherContract}, false},
+		{SpendingHistoryFilter{MinHeight: 10, MaxHeight: 10}, true},
+		{SpendingHistoryFilter{MinHeight: 11}, false},
+		{SpendingHistoryFilter{MaxHeight: 9}, false},
+		{SpendingHistoryFilter{Start: now.Add(-time.Hour), End: now.Add(time.Hour)}, true},
+		{SpendingHistoryFilter{Start: now.Add(time.Hour)}, false},
+		{SpendingHistoryFilter{End: now.Add(-time.Hour)}, false},
+	}
+	for i, test := range tests {
+		if test.filter.Matches(record) != test.matches {
+			t.Errorf("test %v: expected match to be %v", i, test.matches)
+		}
+	}
+}
+
+// TestWriteSpendingRecordsCSV tests exporting spending records to CSV.
+func TestWriteSpendingRecordsCSV(t *testing.T) {
+	records := []SpendingRecord{
+		{Type: SpendingTypeFormation, Amount: types.NewCurrency64(100), Fees: types.NewCurrency64(10), BlockHeight: 1, Timestamp: time.Unix(0, 0)},
+		{Type: SpendingTypeUpload, Amount: types.NewCurrency64(5), BlockHeight: 2, Timestamp: time.Unix(60, 0)},
+	}
+	var buf bytes.Buffer
+	if err := WriteSpendingRecordsCSV(&buf, records); err != nil {
+		t.Fatal(err)
+	}
+	lines, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != len(records)+1 {
+		t.Fatal("wrong number of lines", len(lines))
+	}
+	expected := []string{"1970-01-01T00:00:00Z", "1", "formation", "100", "10", records[0].HostPublicKey.String(), records[0].ContractID.String()}
+	if !reflect.DeepEqual(lines[1], expected) {
+		t.Fatal("unexpected line", lines[1])
+	}
+	if lines[2][2] != "upload" || lines[2][3] != "5" {
+		t.Fatal("unexpected line", lines[2])
+	}
+}
@@ -1,7 +1,11 @@
 package modules
 
 import (
+	"time"
+
+	"github.com/turtledex/TurtleDexCore/build"
 	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/types"
 )
 
 const (
@@ -14,7 +18,34 @@ const (
 	StorageManagerDir = "storagemanager"
 )
 
+const (
+	// StorageTierFast is the tier of storage folders on fast disks like SSDs.
+	// The most read sectors are moved to folders of this tier.
+	StorageTierFast StorageTier = "fast"
+
+	// StorageTierSlow is the tier of storage folders on slow disks like HDDs.
+	// It is the default tier of a storage folder.
+	StorageTierSlow StorageTier = "slow"
+)
+
+var (
+	// DefaultStorageScrubInterval is the default amount of time between the
+	// starts of two scrubs of the host's storage.
+	DefaultStorageScrubInterval = build.Select(build.Var{
+		Standard: 30 * 24 * time.Hour,
+		Dev:      24 * time.Hour,
+		Testing:  time.Hour,
+	}).(time.Duration)
+
+	// DefaultStorageScrubMaxBytesPerSecond is the default rate at which the
+	// scrubber reads sectors from disk.
+	DefaultStorageScrubMaxBytesPerSecond = uint64(16 << 20) // 16 MiB/s
+)
+
 type (
+	// StorageTier describes the speed of the disk backing a storage folder.
+	StorageTier string
+
 	// StorageFolderMetadata contains metadata about a storage folder that is
 	// tracked by the storage folder manager.
 	StorageFolderMetadata struct {
@@ -23,6 +54,11 @@ type (
 		Index             uint16 `json:"index"`
 		Path              string `json:"path"`
 
+		// Tier is the tier of the storage folder and HotSectors is the number
+		// of frequently read sectors which are stored in the folder.
+		Tier       StorageTier `json:"tier"`
+		HotSectors uint64      `json:"hotsectors"`
+
 		// Below are statistics about the filesystem. FailedReads and
 		// FailedWrites are only incremented if the filesystem is returning
 		// errors when operations are being performed. A large number of
@@ -42,6 +78,60 @@ type (
 		ProgressDenominator uint64
 	}
 
+	// StorageScrubSettings configure the scrubber which periodically reads
+	// every sector stored by the host and verifies it against its root to
+	// detect bit rot.
+	StorageScrubSettings struct {
+		// Enabled enables periodic scrubs and Interval is the amount of time
+		// between the starts of two scrubs.
+		Enabled  bool          `json:"enabled"`
+		Interval time.Duration `json:"interval"`
+
+		// MaxBytesPerSecond limits the rate at which sectors are read to
+		// leave enough I/O for the renters. A limit of 0 means that the rate
+		// is unlimited.
+		MaxBytesPerSecond uint64 `json:"maxbytespersecond"`
+	}
+
+	// StorageScrubFinding is a sector which failed verification during a
+	// scrub.
+	StorageScrubFinding struct {
+		Path          string    `json:"path"`
+		StorageFolder uint16    `json:"storagefolder"`
+		SectorIndex   uint32    `json:"sectorindex"`
+		DetectedAt    time.Time `json:"detectedat"`
+
+		// Missing is set if the sector couldn't be read at all, otherwise the
+		// data of the sector doesn't match its root.
+		Missing bool `json:"missing"`
+	}
+
+	// StorageScrubStatus reports the progress and the findings of the
+	// scrubber.
+	StorageScrubStatus struct {
+		StorageScrubSettings
+
+		// Running indicates whether a scrub is in progress. SectorsScrubbed
+		// and TotalSectors report its progress.
+		Running         bool   `json:"running"`
+		SectorsScrubbed uint64 `json:"sectorsscrubbed"`
+		TotalSectors    uint64 `json:"totalsectors"`
+
+		LastStart  time.Time `json:"laststart"`
+		LastFinish time.Time `json:"lastfinish"`
+
+		// CorruptSectors and MissingSectors are the number of sectors which
+		// failed verification. Findings lists them.
+		CorruptSectors uint64                `json:"corruptsectors"`
+		MissingSectors uint64                `json:"missingsectors"`
+		Findings       []StorageScrubFinding `json:"findings"`
+
+		// AtRiskContracts are the contracts with at least one sector that
+		// failed verification. The host can't provide valid storage proofs
+		// for them if a corrupt sector is challenged.
+		AtRiskContracts []types.FileContractID `json:"atriskcontracts"`
+	}
+
 	// A StorageManager is responsible for managing storage folders and
 	// sectors. Sectors are the base unit of storage that gets moved between
 	// renters and hosts, and primarily is stored on the hosts.
@@ -78,6 +168,10 @@ type (
 		// The storage manager needs to be able to shut down.
 		Close() error
 
+		// CorruptSectors returns the sectors among the provided ones which
+		// failed verification during a scrub.
+		CorruptSectors(sectorRoots []crypto.Hash) []crypto.Hash
+
 		// DeleteSector deletes a sector, meaning that the manager will be
 		// unable to upload that sector and be unable to provide a storage
 		// proof on that sector. DeleteSector is for removing the data
@@ -128,6 +222,20 @@ type (
 		// that data will be lost.
 		ResizeStorageFolder(index uint16, newSize uint64, force bool) error
 
+		// ScrubStatus returns the progress and the findings of the scrubber.
+		ScrubStatus() StorageScrubStatus
+
+		// SetScrubSettings configures the scrubber.
+		SetScrubSettings(StorageScrubSettings) error
+
+		// SetStorageFolderTier sets the tier of a storage folder. Frequently
+		// read sectors are moved to the storage folders of the fast tier.
+		SetStorageFolderTier(index uint16, tier StorageTier) error
+
+		// StartScrub starts a scrub immediately unless one is already in
+		// progress.
+		StartScrub() error
+
 		// StorageFolders will return a list of storage folders tracked by the
 		// manager.
 		StorageFolders() []StorageFolderMetadata
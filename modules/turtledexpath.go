@@ -49,10 +49,19 @@ var (
 	// siafiles are stored by default.
 	BackupFolder = NewGlobalTurtleDexPath("/snapshots")
 
+	// DirectorySnapshotFolder is the TurtleDex folder where the read-only
+	// snapshots of directories are stored.
+	DirectorySnapshotFolder = NewGlobalTurtleDexPath("/var/dirsnapshots")
+
 	// HomeFolder is the TurtleDex folder that is used to store all of the user
 	// accessible data.
 	HomeFolder = NewGlobalTurtleDexPath("/home")
 
+	// RedundancyMigrationFolder is the TurtleDex folder where siafiles are
+	// re-uploaded to while they are migrated to the erasure coding settings of
+	// their redundancy policy.
+	RedundancyMigrationFolder = NewGlobalTurtleDexPath("/var/migrations")
+
 	// SkynetFolder is the TurtleDex folder where all of the skyfiles are stored by
 	// default.
 	SkynetFolder = NewGlobalTurtleDexPath("/var/skynet")
@@ -62,6 +71,10 @@ var (
 
 	// VarFolder is the TurtleDex folder that contains the skynet folder.
 	VarFolder = NewGlobalTurtleDexPath("/var")
+
+	// VersionsFolder is the TurtleDex folder where the prior versions of
+	// overwritten siafiles are stored.
+	VersionsFolder = NewGlobalTurtleDexPath("/var/versions")
 )
 
 type (
@@ -162,6 +175,12 @@ func (sp TurtleDexPath) IsRoot() bool {
 	return sp.Path == ""
 }
 
+// IsWithin returns whether the TurtleDexPath is within dir or one of its
+// subdirs. Every TurtleDexPath is within the root directory.
+func (sp TurtleDexPath) IsWithin(dir TurtleDexPath) bool {
+	return dir.IsRoot() || strings.HasPrefix(sp.Path, dir.Path+"/")
+}
+
 // Join joins the string to the end of the TurtleDexPath with a "/" and returns the
 // new TurtleDexPath.
 func (sp TurtleDexPath) Join(s string) (TurtleDexPath, error) {
@@ -253,6 +253,32 @@ func TestTurtleDexpathRebase(t *testing.T) {
 	}
 }
 
+// TestTurtleDexpathIsWithin probes the IsWithin function for TurtleDexPaths.
+func TestTurtleDexpathIsWithin(t *testing.T) {
+	var withintests = []struct {
+		dir     string
+		siaPath string
+		within  bool
+	}{
+		{"", "a", true},         // dir is root
+		{"", "", true},          // both are root
+		{"a", "a/b", true},      // direct child
+		{"a", "a/b/c", true},    // nested child
+		{"a", "a", false},       // dir itself
+		{"a", "ab", false},      // shared prefix
+		{"a/b", "a", false},     // parent
+		{"a", "", false},        // root isn't within a dir
+		{"a/b", "a/c/b", false}, // sibling
+	}
+	for _, test := range withintests {
+		dir := TurtleDexPath{Path: test.dir}
+		siaPath := TurtleDexPath{Path: test.siaPath}
+		if siaPath.IsWithin(dir) != test.within {
+			t.Errorf("expected IsWithin of '%v' and '%v' to be %v", test.siaPath, test.dir, test.within)
+		}
+	}
+}
+
 // TestTurtleDexpathDir probes the Dir function for TurtleDexPaths.
 func TestTurtleDexpathDir(t *testing.T) {
 	var pathtests = []struct {
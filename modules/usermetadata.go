@@ -0,0 +1,123 @@
+package modules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/turtledex/errors"
+)
+
+const (
+	// MaxUserMetadataKeys is the maximum number of user metadata keys that
+	// can be attached to a single file or directory.
+	MaxUserMetadataKeys = 64
+
+	// MaxUserMetadataKeyLen is the maximum length of a user metadata key.
+	MaxUserMetadataKeyLen = 255
+
+	// MaxUserMetadataValueLen is the maximum length of a user metadata value.
+	MaxUserMetadataValueLen = 4096
+)
+
+var (
+	// ErrEmptyUserMetadataKey is returned if a user metadata key is empty.
+	ErrEmptyUserMetadataKey = errors.New("user metadata key can't be empty")
+
+	// ErrInvalidUserMetadataKey is returned if a user metadata key contains
+	// characters that can't be used in a key.
+	ErrInvalidUserMetadataKey = errors.New("user metadata key can't contain '=', ',' or control characters")
+
+	// ErrTooManyUserMetadataKeys is returned if more than MaxUserMetadataKeys
+	// keys are attached to a file or directory.
+	ErrTooManyUserMetadataKeys = fmt.Errorf("can't have more than %v user metadata keys", MaxUserMetadataKeys)
+
+	// ErrUserMetadataKeyTooLong is returned if a key exceeds
+	// MaxUserMetadataKeyLen.
+	ErrUserMetadataKeyTooLong = fmt.Errorf("user metadata key can't be longer than %v bytes", MaxUserMetadataKeyLen)
+
+	// ErrUserMetadataValueTooLong is returned if a value exceeds
+	// MaxUserMetadataValueLen.
+	ErrUserMetadataValueTooLong = fmt.Errorf("user metadata value can't be longer than %v bytes", MaxUserMetadataValueLen)
+)
+
+// MatchesUserMetadata returns true if the user metadata md contains all the
+// key/value pairs of filter. An empty value in the filter only requires the
+// key to be present.
+func MatchesUserMetadata(md, filter map[string]string) bool {
+	for k, v := range filter {
+		mdv, exists := md[k]
+		if !exists || (v != "" && v != mdv) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseUserMetadata parses a list of 'key=value' pairs into a user metadata
+// map. A pair without '=' results in a key with an empty value.
+func ParseUserMetadata(pairs []string) (map[string]string, error) {
+	md := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		kv := strings.SplitN(pair, "=", 2)
+		key := strings.TrimSpace(kv[0])
+		var value string
+		if len(kv) == 2 {
+			value = kv[1]
+		}
+		if err := ValidateUserMetadataKey(key); err != nil {
+			return nil, errors.AddContext(err, fmt.Sprintf("invalid pair '%v'", pair))
+		}
+		md[key] = value
+	}
+	return md, ValidateUserMetadata(md)
+}
+
+// UpdateUserMetadata returns a copy of md with the keys in set updated and the
+// keys in remove removed. md itself is not modified.
+func UpdateUserMetadata(md, set map[string]string, remove []string) map[string]string {
+	updated := make(map[string]string, len(md)+len(set))
+	for k, v := range md {
+		updated[k] = v
+	}
+	for k, v := range set {
+		updated[k] = v
+	}
+	for _, k := range remove {
+		delete(updated, k)
+	}
+	return updated
+}
+
+// ValidateUserMetadata checks the provided user metadata against the limits
+// for user metadata.
+func ValidateUserMetadata(md map[string]string) error {
+	if len(md) > MaxUserMetadataKeys {
+		return ErrTooManyUserMetadataKeys
+	}
+	for k, v := range md {
+		if err := ValidateUserMetadataKey(k); err != nil {
+			return err
+		}
+		if len(v) > MaxUserMetadataValueLen {
+			return errors.AddContext(ErrUserMetadataValueTooLong, fmt.Sprintf("invalid value for key '%v'", k))
+		}
+	}
+	return nil
+}
+
+// ValidateUserMetadataKey checks whether key can be used as a user metadata
+// key.
+func ValidateUserMetadataKey(key string) error {
+	if key == "" {
+		return ErrEmptyUserMetadataKey
+	}
+	if len(key) > MaxUserMetadataKeyLen {
+		return ErrUserMetadataKeyTooLong
+	}
+	for _, c := range key {
+		if c == '=' || c == ',' || c < 0x20 || c == 0x7f {
+			return ErrInvalidUserMetadataKey
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package modules
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/turtledex/errors"
+)
+
+// TestParseUserMetadata is a unit test for ParseUserMetadata.
+func TestParseUserMetadata(t *testing.T) {
+	tests := []struct {
+		pairs []string
+		md    map[string]string
+		err   error
+	}{
+		{nil, map[string]string{}, nil},
+		{[]string{"project=x"}, map[string]string{"project": "x"}, nil},
+		{[]string{"project=x", "owner"}, map[string]string{"project": "x", "owner": ""}, nil},
+		{[]string{"project=a=b,c"}, map[string]string{"project": "a=b,c"}, nil},
+		{[]string{" project =x"}, map[string]string{"project": "x"}, nil},
+		{[]string{"=x"}, nil, ErrEmptyUserMetadataKey},
+		{[]string{"project=x", ""}, nil, ErrEmptyUserMetadataKey},
+		{[]string{"pro\nject=x"}, nil, ErrInvalidUserMetadataKey},
+		{[]string{"pro,ject=x"}, nil, ErrInvalidUserMetadataKey},
+		{[]string{strings.Repeat("a", MaxUserMetadataKeyLen+1)}, nil, ErrUserMetadataKeyTooLong},
+		{[]string{"project=" + strings.Repeat("a", MaxUserMetadataValueLen+1)}, nil, ErrUserMetadataValueTooLong},
+	}
+	for _, test := range tests {
+		md, err := ParseUserMetadata(test.pairs)
+		if (err == nil) != (test.err == nil) || (err != nil && !errors.Contains(err, test.err)) {
+			t.Fatalf("%v: expected error %v but got %v", test.pairs, test.err, err)
+		}
+		if test.err == nil && !reflect.DeepEqual(md, test.md) {
+			t.Fatalf("%v: expected %v but got %v", test.pairs, test.md, md)
+		}
+	}
+}
+
+// TestValidateUserMetadata checks the limits enforced by
+// ValidateUserMetadata.
+func TestValidateUserMetadata(t *testing.T) {
+	md := make(map[string]string)
+	if err := ValidateUserMetadata(md); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < MaxUserMetadataKeys; i++ {
+		md[strings.Repeat("a", i+1)] = "value"
+	}
+	if err := ValidateUserMetadata(md); err != nil {
+		t.Fatal(err)
+	}
+	md["b"] = "value"
+	if err := ValidateUserMetadata(md); !errors.Contains(err, ErrTooManyUserMetadataKeys) {
+		t.Fatal("expected ErrTooManyUserMetadataKeys but got", err)
+	}
+}
+
+// TestMatchesUserMetadata is a unit test for MatchesUserMetadata.
+func TestMatchesUserMetadata(t *testing.T) {
+	md := map[string]string{"project": "x", "owner": "bob"}
+	tests := []struct {
+		filter  map[string]string
+		matches bool
+	}{
+		{nil, true},
+		{map[string]string{"project": "x"}, true},
+		{map[string]string{"project": "x", "owner": "bob"}, true},
+		{map[string]string{"project": ""}, true},
+		{map[string]string{"project": "y"}, false},
+		{map[string]string{"project": "x", "owner": "alice"}, false},
+		{map[string]string{"team": ""}, false},
+	}
+	for _, test := range tests {
+		if MatchesUserMetadata(md, test.filter) != test.matches {
+			t.Errorf("%v: expected match to be %v", test.filter, test.matches)
+		}
+	}
+	if MatchesUserMetadata(nil, map[string]string{"project": ""}) {
+		t.Error("nil metadata shouldn't match")
+	}
+}
@@ -58,6 +58,15 @@ func (c *Client) GatewayRateLimitPost(downloadSpeed, uploadSpeed int64) (err err
 	return
 }
 
+// GatewayBandwidthSchedulePost uses the /gateway endpoint to change the
+// gateway's bandwidth schedule. An empty schedule removes all windows.
+func (c *Client) GatewayBandwidthSchedulePost(schedule modules.BandwidthSchedule) (err error) {
+	values := url.Values{}
+	values.Set("bandwidthschedule", schedule.String())
+	err = c.post("/gateway", values.Encode(), nil)
+	return
+}
+
 // GatewayBlocklistGet uses the /gateway/blocklist endpoint to request the
 // Gateway's blocklist
 func (c *Client) GatewayBlocklistGet() (gbg api.GatewayBlocklistGET, err error) {
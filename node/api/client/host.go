@@ -1,9 +1,11 @@
 package client
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/turtledex/TurtleDexCore/crypto"
 	"github.com/turtledex/TurtleDexCore/modules"
@@ -67,6 +69,33 @@ const (
 	// HostParamCustomRegistryPath is the locataion of the host's registry on
 	// disk.
 	HostParamCustomRegistryPath = HostParam("customregistrypath")
+	// HostParamMaxDownloadSpeed is the maximum speed in bytes per second at
+	// which the host receives data.
+	HostParamMaxDownloadSpeed = HostParam("maxdownloadspeed")
+	// HostParamMaxUploadSpeed is the maximum speed in bytes per second at
+	// which the host sends data.
+	HostParamMaxUploadSpeed = HostParam("maxuploadspeed")
+	// HostParamBandwidthSchedule is the schedule of windows during which
+	// different speeds apply, as parsed by modules.ParseBandwidthSchedule.
+	HostParamBandwidthSchedule = HostParam("bandwidthschedule")
+	// HostParamPeerMaxConcurrentStreams is the maximum number of concurrent
+	// streams of a single renter key or ephemeral account.
+	HostParamPeerMaxConcurrentStreams = HostParam("peermaxconcurrentstreams")
+	// HostParamPeerMaxDownloadSpeed is the maximum speed in bytes per second
+	// at which the host receives data from a single renter key or ephemeral
+	// account.
+	HostParamPeerMaxDownloadSpeed = HostParam("peermaxdownloadspeed")
+	// HostParamPeerMaxUploadSpeed is the maximum speed in bytes per second at
+	// which the host sends data to a single renter key or ephemeral account.
+	HostParamPeerMaxUploadSpeed = HostParam("peermaxuploadspeed")
+	// HostParamPeerMaxProgramsPerMinute is the maximum number of programs a
+	// single ephemeral account can execute per minute.
+	HostParamPeerMaxProgramsPerMinute = HostParam("peermaxprogramsperminute")
+	// HostParamPeerBanThreshold is the number of invalid payments or programs
+	// after which a peer is banned.
+	HostParamPeerBanThreshold = HostParam("peerbanthreshold")
+	// HostParamPeerBanDuration is the duration of a ban in seconds.
+	HostParamPeerBanDuration = HostParam("peerbanduration")
 )
 
 // HostAnnouncePost uses the /host/announce endpoint to announce the host to
@@ -92,7 +121,7 @@ func (c *Client) HostContractInfoGet() (cg api.ContractInfoGET, err error) {
 
 // HostEstimateScoreGet requests the /host/estimatescore endpoint.
 func (c *Client) HostEstimateScoreGet(param, value string) (eg api.HostEstimateScoreGET, err error) {
-	err = c.get(fmt.Sprintf("/host/estimatescore?%v=%v", param, value), &eg)
+	err = c.get(fmt.Sprintf("/host/estimatescore?%v=%v", param, url.QueryEscape(value)), &eg)
 	return
 }
 
@@ -105,7 +134,7 @@ func (c *Client) HostGet() (hg api.HostGET, err error) {
 // HostModifySettingPost uses the /host endpoint to change a param of the host
 // settings to a certain value.
 func (c *Client) HostModifySettingPost(param HostParam, value interface{}) (err error) {
-	err = c.post("/host", string(param)+"="+fmt.Sprint(value), nil)
+	err = c.post("/host", string(param)+"="+url.QueryEscape(fmt.Sprint(value)), nil)
 	return
 }
 
@@ -115,6 +144,51 @@ func (c *Client) HostBandwidthGet() (gbg api.GatewayBandwidthGET, err error) {
 	return
 }
 
+// HostFinancialsGet requests the /host/financials endpoint to get the entries
+// of the host's revenue ledger for the days between from and to, inclusive.
+func (c *Client) HostFinancialsGet(from, to time.Time) (hfg api.HostFinancialsGET, err error) {
+	values := financialsValues(from, to)
+	err = c.get("/host/financials?"+values.Encode(), &hfg)
+	return
+}
+
+// HostFinancialsCSVGet requests the /host/financials endpoint to get the
+// entries of the host's revenue ledger for the days between from and to,
+// inclusive, as CSV.
+func (c *Client) HostFinancialsCSVGet(from, to time.Time) ([]byte, error) {
+	values := financialsValues(from, to)
+	values.Set("format", "csv")
+	_, csv, err := c.getRawResponse("/host/financials?" + values.Encode())
+	return csv, err
+}
+
+// financialsValues returns the query values for the range of days of a
+// /host/financials call.
+func financialsValues(from, to time.Time) url.Values {
+	values := url.Values{}
+	values.Set("from", from.UTC().Format(api.HostFinancialsDateFormat))
+	values.Set("to", to.UTC().Format(api.HostFinancialsDateFormat))
+	return values
+}
+
+// HostPricingGet requests the /host/pricing endpoint to get the state of the
+// host's dynamic pricing engine.
+func (c *Client) HostPricingGet() (hpg api.HostPricingGET, err error) {
+	err = c.get("/host/pricing", &hpg)
+	return
+}
+
+// HostPricingPost uses the /host/pricing endpoint to configure the host's
+// dynamic pricing engine.
+func (c *Client) HostPricingPost(settings modules.HostPricingSettings) (err error) {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	err = c.post("/host/pricing", string(data), nil)
+	return
+}
+
 // HostStorageFoldersAddPost uses the /host/storage/folders/add api endpoint to
 // add a storage folder to a host
 func (c *Client) HostStorageFoldersAddPost(path string, size uint64) (err error) {
@@ -145,12 +219,40 @@ func (c *Client) HostStorageFoldersResizePost(path string, size uint64) (err err
 	return
 }
 
+// HostStorageFoldersTierPost uses the /host/storage/folders/tier api endpoint
+// to set the tier of an existing storage folder.
+func (c *Client) HostStorageFoldersTierPost(path string, tier modules.StorageTier) (err error) {
+	values := url.Values{}
+	values.Set("path", path)
+	values.Set("tier", string(tier))
+	err = c.post("/host/storage/folders/tier", values.Encode(), nil)
+	return
+}
+
 // HostStorageGet requests the /host/storage endpoint.
 func (c *Client) HostStorageGet() (sg api.StorageGET, err error) {
 	err = c.get("/host/storage", &sg)
 	return
 }
 
+// HostStorageScrubGet requests the /host/storage/scrub endpoint.
+func (c *Client) HostStorageScrubGet() (ssg api.StorageScrubGET, err error) {
+	err = c.get("/host/storage/scrub", &ssg)
+	return
+}
+
+// HostStorageScrubPost uses the /host/storage/scrub endpoint to configure the
+// host's storage scrubber and optionally start a scrub.
+func (c *Client) HostStorageScrubPost(settings modules.StorageScrubSettings, start bool) (err error) {
+	values := url.Values{}
+	values.Set("enabled", strconv.FormatBool(settings.Enabled))
+	values.Set("interval", strconv.FormatUint(uint64(settings.Interval.Seconds()), 10))
+	values.Set("maxbytespersecond", strconv.FormatUint(settings.MaxBytesPerSecond, 10))
+	values.Set("start", strconv.FormatBool(start))
+	err = c.post("/host/storage/scrub", values.Encode(), nil)
+	return
+}
+
 // HostStorageSectorsDeletePost uses the /host/storage/sectors/delete endpoint
 // to delete a sector from the host.
 func (c *Client) HostStorageSectorsDeletePost(root crypto.Hash) (err error) {
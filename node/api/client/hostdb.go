@@ -48,8 +48,50 @@ func (c *Client) HostDbFilterModePost(fm modules.FilterMode, hosts []types.Turtl
 	return
 }
 
+// HostDbBenchmarkingPost requests the /hostdb/benchmarking POST endpoint
+func (c *Client) HostDbBenchmarkingPost(enabled bool) (err error) {
+	data, err := json.Marshal(api.HostdbBenchmarkingPOST{Enabled: enabled})
+	if err != nil {
+		return err
+	}
+	err = c.post("/hostdb/benchmarking", string(data), nil)
+	return
+}
+
+// HostDbGroupsGet requests the /hostdb/groups GET endpoint
+func (c *Client) HostDbGroupsGet() (hgg api.HostdbGroupsGET, err error) {
+	err = c.get("/hostdb/groups", &hgg)
+	return
+}
+
+// HostDbGroupsPost requests the /hostdb/groups POST endpoint
+func (c *Client) HostDbGroupsPost(group string, hosts []types.TurtleDexPublicKey) (err error) {
+	data, err := json.Marshal(api.HostdbGroupsPOST{Group: group, Hosts: hosts})
+	if err != nil {
+		return err
+	}
+	err = c.post("/hostdb/groups", string(data), nil)
+	return
+}
+
 // HostDbHostsGet request the /hostdb/hosts/:pubkey endpoint's resources.
 func (c *Client) HostDbHostsGet(pk types.TurtleDexPublicKey) (hhg api.HostdbHostsGET, err error) {
 	err = c.get("/hostdb/hosts/"+pk.String(), &hhg)
 	return
 }
+
+// HostDbScoringPolicyGet requests the /hostdb/scoringpolicy GET endpoint
+func (c *Client) HostDbScoringPolicyGet() (hdspg api.HostdbScoringPolicyGET, err error) {
+	err = c.get("/hostdb/scoringpolicy", &hdspg)
+	return
+}
+
+// HostDbScoringPolicyPost requests the /hostdb/scoringpolicy POST endpoint
+func (c *Client) HostDbScoringPolicyPost(params api.HostdbScoringPolicyPOST) (err error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	err = c.post("/hostdb/scoringpolicy", string(data), nil)
+	return
+}
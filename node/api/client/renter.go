@@ -1,6 +1,7 @@
 package client
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
@@ -130,6 +131,13 @@ func (a *AllowanceRequestPost) WithMaxUploadBandwidthPrice(price types.Currency)
 	return a
 }
 
+// WithHostGroupTargets adds the hostgrouptargets field to the request. Empty
+// targets remove the existing targets.
+func (a *AllowanceRequestPost) WithHostGroupTargets(targets map[string]uint64) *AllowanceRequestPost {
+	a.values.Set("hostgrouptargets", modules.FormatHostGroupTargets(targets))
+	return a
+}
+
 // Send finalizes and sends the request.
 func (a *AllowanceRequestPost) Send() (err error) {
 	if a.sent {
@@ -140,6 +148,13 @@ func (a *AllowanceRequestPost) Send() (err error) {
 	return
 }
 
+// Plan returns the contract maintenance plan for the allowance of the request
+// without setting the allowance.
+func (a *AllowanceRequestPost) Plan() (plan modules.ContractMaintenancePlan, err error) {
+	err = a.c.get("/renter/contracts/plan?"+a.values.Encode(), &plan)
+	return
+}
+
 // escapeTurtleDexPath escapes the siapath to make it safe to use within a URL. This
 // should only be used on TurtleDexPaths which are used as part of the URL path.
 // Paths within the query have to be escaped with url.PathEscape.
@@ -154,6 +169,28 @@ func escapeTurtleDexPath(siaPath modules.TurtleDexPath) string {
 	return strings.Join(escapedSegments, "/")
 }
 
+// encodeUserMetadata encodes user metadata as a JSON object to be used as an
+// API parameter.
+func encodeUserMetadata(md map[string]string) string {
+	if len(md) == 0 {
+		return ""
+	}
+	// Encoding a map of strings can't fail.
+	b, _ := json.Marshal(md)
+	return string(b)
+}
+
+// encodeRedundancyPolicy encodes a redundancy policy for the API. An unset
+// policy is encoded as an empty string.
+func encodeRedundancyPolicy(policy modules.RedundancyPolicy) string {
+	if !policy.IsSet() {
+		return ""
+	}
+	// Encoding a policy can't fail.
+	b, _ := json.Marshal(policy)
+	return string(b)
+}
+
 // RenterCleanPost uses the /renter/clean endpoint to clean any lost files from
 // the renter
 func (c *Client) RenterCleanPost() (err error) {
@@ -168,6 +205,13 @@ func (c *Client) RenterContractorChurnStatus() (churnStatus modules.ContractorCh
 	return
 }
 
+// RenterContractsPlanGet requests the /renter/contracts/plan resource, which
+// returns what contract maintenance would do with the current allowance.
+func (c *Client) RenterContractsPlanGet() (plan modules.ContractMaintenancePlan, err error) {
+	err = c.get("/renter/contracts/plan", &plan)
+	return
+}
+
 // RenterContractCancelPost uses the /renter/contract/cancel endpoint to cancel
 // a contract
 func (c *Client) RenterContractCancelPost(id types.FileContractID) (err error) {
@@ -281,6 +325,13 @@ func (c *Client) RenterFileDeletePost(siaPath modules.TurtleDexPath) (err error)
 // RenterDownloadGet uses the /renter/download endpoint to download a file to a
 // destination on disk.
 func (c *Client) RenterDownloadGet(siaPath modules.TurtleDexPath, destination string, offset, length uint64, async bool, disableLocalFetch bool, root bool) (modules.DownloadID, error) {
+	return c.RenterDownloadWithPriorityGet(siaPath, destination, offset, length, async, disableLocalFetch, root, 0)
+}
+
+// RenterDownloadWithPriorityGet uses the /renter/download endpoint to download
+// a file to a destination on disk using the given download priority. If the
+// priority is not set, the renter's default is used.
+func (c *Client) RenterDownloadWithPriorityGet(siaPath modules.TurtleDexPath, destination string, offset, length uint64, async, disableLocalFetch, root bool, priority modules.DownloadPriority) (modules.DownloadID, error) {
 	sp := escapeTurtleDexPath(siaPath)
 	values := url.Values{}
 	values.Set("destination", destination)
@@ -289,6 +340,9 @@ func (c *Client) RenterDownloadGet(siaPath modules.TurtleDexPath, destination st
 	values.Set("length", fmt.Sprint(length))
 	values.Set("async", fmt.Sprint(async))
 	values.Set("root", fmt.Sprint(root))
+	if priority != 0 {
+		values.Set("priority", priority.String())
+	}
 	h, _, err := c.getRawResponse(fmt.Sprintf("/renter/download/%s?%s", sp, values.Encode()))
 	if err != nil {
 		return "", err
@@ -359,12 +413,22 @@ func (c *Client) RenterRecoverLocalBackupPost(src string) (err error) {
 // RenterDownloadFullGet uses the /renter/download endpoint to download a full
 // file.
 func (c *Client) RenterDownloadFullGet(siaPath modules.TurtleDexPath, destination string, async, root bool) (modules.DownloadID, error) {
+	return c.RenterDownloadFullWithPriorityGet(siaPath, destination, async, root, 0)
+}
+
+// RenterDownloadFullWithPriorityGet uses the /renter/download endpoint to
+// download a full file using the given download priority. If the priority is
+// not set, the renter's default is used.
+func (c *Client) RenterDownloadFullWithPriorityGet(siaPath modules.TurtleDexPath, destination string, async, root bool, priority modules.DownloadPriority) (modules.DownloadID, error) {
 	sp := escapeTurtleDexPath(siaPath)
 	values := url.Values{}
 	values.Set("destination", destination)
 	values.Set("httpresp", fmt.Sprint(false))
 	values.Set("async", fmt.Sprint(async))
 	values.Set("root", fmt.Sprint(root))
+	if priority != 0 {
+		values.Set("priority", priority.String())
+	}
 	h, _, err := c.getRawResponse(fmt.Sprintf("/renter/download/%s?%s", sp, values.Encode()))
 	if err != nil {
 		return "", err
@@ -452,12 +516,211 @@ func (c *Client) RenterFileGet(siaPath modules.TurtleDexPath) (rf api.RenterFile
 	return
 }
 
+// RenterDirSnapshotsGet requests the /renter/dirsnapshots resource.
+func (c *Client) RenterDirSnapshotsGet() (rds api.RenterDirSnapshotsGET, err error) {
+	err = c.get("/renter/dirsnapshots", &rds)
+	return
+}
+
+// RenterDirSnapshotCreatePost uses the /renter/dirsnapshots/create/:siapath
+// endpoint to create a snapshot of the directory at siaPath. If root is set,
+// siaPath is relative to the root instead of the user's home directory.
+func (c *Client) RenterDirSnapshotCreatePost(siaPath modules.TurtleDexPath, name string, root bool) (snapshot modules.DirectorySnapshot, err error) {
+	sp := escapeTurtleDexPath(siaPath)
+	values := url.Values{}
+	values.Set("name", name)
+	values.Set("root", fmt.Sprint(root))
+	err = c.post("/renter/dirsnapshots/create/"+sp, values.Encode(), &snapshot)
+	return
+}
+
+// RenterDirSnapshotDeletePost uses the /renter/dirsnapshots/delete endpoint to
+// delete a directory snapshot.
+func (c *Client) RenterDirSnapshotDeletePost(name string) (err error) {
+	values := url.Values{}
+	values.Set("name", name)
+	err = c.post("/renter/dirsnapshots/delete", values.Encode(), nil)
+	return
+}
+
+// RenterDirSnapshotDiffGet requests the /renter/dirsnapshots/diff resource.
+func (c *Client) RenterDirSnapshotDiffGet(name string) (diff modules.DirectorySnapshotDiff, err error) {
+	values := url.Values{}
+	values.Set("name", name)
+	err = c.get("/renter/dirsnapshots/diff?"+values.Encode(), &diff)
+	return
+}
+
+// RenterDirSnapshotRestorePost uses the /renter/dirsnapshots/restore/:siapath
+// endpoint to restore a directory snapshot into the new directory at siaPath.
+// If root is set, siaPath is relative to the root instead of the user's home
+// directory.
+func (c *Client) RenterDirSnapshotRestorePost(name string, siaPath modules.TurtleDexPath, root bool) (err error) {
+	sp := escapeTurtleDexPath(siaPath)
+	values := url.Values{}
+	values.Set("name", name)
+	values.Set("root", fmt.Sprint(root))
+	err = c.post("/renter/dirsnapshots/restore/"+sp, values.Encode(), nil)
+	return
+}
+
+// RenterDirSnapshotUploadPost uses the /renter/dirsnapshots/upload endpoint to
+// upload a directory snapshot to hosts as a backup.
+func (c *Client) RenterDirSnapshotUploadPost(name string) (err error) {
+	values := url.Values{}
+	values.Set("name", name)
+	err = c.post("/renter/dirsnapshots/upload", values.Encode(), nil)
+	return
+}
+
+// RenterFileVersionsGet requests the /renter/fileversions/:siapath resource.
+func (c *Client) RenterFileVersionsGet(siaPath modules.TurtleDexPath) (rfv api.RenterFileVersions, err error) {
+	sp := escapeTurtleDexPath(siaPath)
+	err = c.get("/renter/fileversions/"+sp, &rfv)
+	return
+}
+
+// RenterFileVersionRestorePost uses the /renter/fileversions/:siapath endpoint
+// to restore a prior version of a file.
+func (c *Client) RenterFileVersionRestorePost(siaPath modules.TurtleDexPath, versionID string) (err error) {
+	sp := escapeTurtleDexPath(siaPath)
+	values := url.Values{}
+	values.Set("versionid", versionID)
+	err = c.post("/renter/fileversions/"+sp, values.Encode(), nil)
+	return
+}
+
 // RenterFilesGet requests the /renter/files resource.
 func (c *Client) RenterFilesGet(cached bool) (rf api.RenterFiles, err error) {
 	err = c.get("/renter/files?cached="+fmt.Sprint(cached), &rf)
 	return
 }
 
+// RenterFilesUserMetadataGet requests the /renter/files resource and only
+// returns the files whose user metadata matches the provided filter.
+func (c *Client) RenterFilesUserMetadataGet(cached bool, filter map[string]string) (rf api.RenterFiles, err error) {
+	values := url.Values{}
+	values.Set("cached", fmt.Sprint(cached))
+	values.Set("usermetadata", encodeUserMetadata(filter))
+	err = c.get("/renter/files?"+values.Encode(), &rf)
+	return
+}
+
+// spendingHistoryFilterValues returns the query values for a filter of the
+// spending ledger.
+func spendingHistoryFilterValues(filter modules.SpendingHistoryFilter) url.Values {
+	values := url.Values{}
+	var spendingTypes []string
+	for _, t := range filter.Types {
+		spendingTypes = append(spendingTypes, string(t))
+	}
+	values.Set("types", strings.Join(spendingTypes, ","))
+	if filter.HostPublicKey != nil {
+		values.Set("host", filter.HostPublicKey.String())
+	}
+	if filter.ContractID != nil {
+		values.Set("contractid", filter.ContractID.String())
+	}
+	values.Set("minheight", fmt.Sprint(filter.MinHeight))
+	values.Set("maxheight", fmt.Sprint(filter.MaxHeight))
+	if !filter.Start.IsZero() {
+		values.Set("start", fmt.Sprint(filter.Start.Unix()))
+	}
+	if !filter.End.IsZero() {
+		values.Set("end", fmt.Sprint(filter.End.Unix()))
+	}
+	values.Set("limit", fmt.Sprint(filter.Limit))
+	return values
+}
+
+// RenterSpendingHistoryGet uses the /renter/spending/history endpoint to get
+// the records of the renter's spending ledger which match the filter.
+func (c *Client) RenterSpendingHistoryGet(filter modules.SpendingHistoryFilter) (rshg api.RenterSpendingHistoryGET, err error) {
+	values := spendingHistoryFilterValues(filter)
+	err = c.get("/renter/spending/history?"+values.Encode(), &rshg)
+	return
+}
+
+// RenterSpendingHistoryCSVGet uses the /renter/spending/history endpoint to
+// export the records of the renter's spending ledger which match the filter as
+// CSV.
+func (c *Client) RenterSpendingHistoryCSVGet(filter modules.SpendingHistoryFilter) ([]byte, error) {
+	values := spendingHistoryFilterValues(filter)
+	values.Set("format", "csv")
+	_, csv, err := c.getRawResponse("/renter/spending/history?" + values.Encode())
+	return csv, err
+}
+
+// RenterBudgetsGet uses the /renter/budgets endpoint to get the budgets of the
+// renter's API callers and their spending in the current period.
+func (c *Client) RenterBudgetsGet() (rbg api.RenterBudgetsGET, err error) {
+	err = c.get("/renter/budgets", &rbg)
+	return
+}
+
+// RenterBudgetPost uses the /renter/budgets endpoint to set the budget of an
+// API caller.
+func (c *Client) RenterBudgetPost(budget modules.RenterBudget) error {
+	values := url.Values{}
+	values.Set("identity", budget.Identity)
+	values.Set("upload", budget.Upload.String())
+	values.Set("download", budget.Download.String())
+	values.Set("registry", budget.Registry.String())
+	return c.post("/renter/budgets", values.Encode(), nil)
+}
+
+// RenterBudgetRemovePost uses the /renter/budgets endpoint to remove the
+// budget of an API caller.
+func (c *Client) RenterBudgetRemovePost(identity string) error {
+	values := url.Values{}
+	values.Set("identity", identity)
+	values.Set("remove", "true")
+	return c.post("/renter/budgets", values.Encode(), nil)
+}
+
+// RenterRepairGet uses the /renter/repair endpoint to get the repair settings
+// and the repair progress of the renter.
+func (c *Client) RenterRepairGet() (rrg api.RenterRepairGET, err error) {
+	err = c.get("/renter/repair", &rrg)
+	return
+}
+
+// RenterRepairPost uses the /renter/repair endpoint to set the repair settings
+// of the renter.
+func (c *Client) RenterRepairPost(settings modules.RepairSettings) error {
+	values := url.Values{}
+	values.Set("maxbytespersecond", strconv.FormatUint(settings.MaxBytesPerSecond, 10))
+	values.Set("maxconcurrentchunks", strconv.FormatUint(settings.MaxConcurrentChunks, 10))
+	values.Set("repaironly", strconv.FormatBool(settings.RepairOnly))
+	return c.post("/renter/repair", values.Encode(), nil)
+}
+
+// RenterSearchGet uses the /renter/search endpoint to search the directory at
+// siaPath and its subdirectories for files matching the params. If root is
+// false, siaPath is relative to the user's home directory.
+func (c *Client) RenterSearchGet(siaPath modules.TurtleDexPath, root bool, params modules.FileSearchParams) (rs api.RenterSearchGET, err error) {
+	values := url.Values{}
+	values.Set("siapath", siaPath.String())
+	values.Set("root", fmt.Sprint(root))
+	values.Set("glob", params.Glob)
+	values.Set("prefix", params.Prefix)
+	values.Set("substring", params.Substring)
+	values.Set("minsize", fmt.Sprint(params.MinSize))
+	values.Set("maxsize", fmt.Sprint(params.MaxSize))
+	if params.MinHealth != nil {
+		values.Set("minhealth", fmt.Sprint(*params.MinHealth))
+	}
+	if params.MaxHealth != nil {
+		values.Set("maxhealth", fmt.Sprint(*params.MaxHealth))
+	}
+	if params.HasSkylink != nil {
+		values.Set("hasskylink", fmt.Sprint(*params.HasSkylink))
+	}
+	values.Set("limit", fmt.Sprint(params.Limit))
+	err = c.get("/renter/search?"+values.Encode(), &rs)
+	return
+}
+
 // RenterGet requests the /renter resource.
 func (c *Client) RenterGet() (rg api.RenterGET, err error) {
 	err = c.get("/renter", &rg)
@@ -505,6 +768,15 @@ func (c *Client) RenterRateLimitPost(readBPS, writeBPS int64) (err error) {
 	return
 }
 
+// RenterBandwidthSchedulePost uses the /renter endpoint to change the
+// renter's bandwidth schedule. An empty schedule removes all windows.
+func (c *Client) RenterBandwidthSchedulePost(schedule modules.BandwidthSchedule) (err error) {
+	values := url.Values{}
+	values.Set("bandwidthschedule", schedule.String())
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
 // RenterRenamePost uses the /renter/rename/:siapath endpoint to rename a file.
 func (c *Client) RenterRenamePost(siaPathOld, siaPathNew modules.TurtleDexPath, root bool) (err error) {
 	spo := escapeTurtleDexPath(siaPathOld)
@@ -533,12 +805,33 @@ func (c *Client) RenterSetCheckIPViolationPost(enabled bool) (err error) {
 	return
 }
 
+// RenterSetHostDiversityPost uses the /renter endpoint to set the constraints
+// that spread the renter's contracts across networks and locations.
+func (c *Client) RenterSetHostDiversityPost(constraints modules.HostDiversityConstraints) (err error) {
+	values := url.Values{}
+	values.Set("maxasnpercentage", fmt.Sprint(constraints.MaxASNPercentage))
+	values.Set("requiredregions", strings.Join(constraints.RequiredRegions, ","))
+	values.Set("excludedcountries", strings.Join(constraints.ExcludedCountries, ","))
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
 // RenterStreamGet uses the /renter/stream endpoint to download data as a
 // stream.
 func (c *Client) RenterStreamGet(siaPath modules.TurtleDexPath, disableLocalFetch, root bool) (resp []byte, err error) {
+	return c.RenterStreamWithPriorityGet(siaPath, disableLocalFetch, root, 0)
+}
+
+// RenterStreamWithPriorityGet uses the /renter/stream endpoint to download
+// data as a stream using the given download priority. If the priority is not
+// set, the stream is interactive.
+func (c *Client) RenterStreamWithPriorityGet(siaPath modules.TurtleDexPath, disableLocalFetch, root bool, priority modules.DownloadPriority) (resp []byte, err error) {
 	values := url.Values{}
 	values.Set("disablelocalfetch", fmt.Sprint(disableLocalFetch))
 	values.Set("root", fmt.Sprint(root))
+	if priority != 0 {
+		values.Set("priority", priority.String())
+	}
 	sp := escapeTurtleDexPath(siaPath)
 	_, resp, err = c.getRawResponse(fmt.Sprintf("/renter/stream/%s?%s", sp, values.Encode()))
 	return
@@ -576,6 +869,17 @@ func (c *Client) RenterSetFileStuckPost(siaPath modules.TurtleDexPath, root, stu
 	return
 }
 
+// RenterSetFileUserMetadataPost sets the keys of the user metadata of the
+// siafile at siaPath to the values in set and removes the keys in remove.
+func (c *Client) RenterSetFileUserMetadataPost(siaPath modules.TurtleDexPath, set map[string]string, remove []string) (err error) {
+	sp := escapeTurtleDexPath(siaPath)
+	values := url.Values{}
+	values.Set("usermetadata", encodeUserMetadata(set))
+	values.Set("removeusermetadata", strings.Join(remove, ","))
+	err = c.post(fmt.Sprintf("/renter/file/%v", sp), values.Encode(), nil)
+	return
+}
+
 // RenterUploadPost uses the /renter/upload endpoint to upload a file
 func (c *Client) RenterUploadPost(path string, siaPath modules.TurtleDexPath, dataPieces, parityPieces uint64) (err error) {
 	return c.RenterUploadForcePost(path, siaPath, dataPieces, parityPieces, false)
@@ -594,6 +898,20 @@ func (c *Client) RenterUploadForcePost(path string, siaPath modules.TurtleDexPat
 	return
 }
 
+// RenterUploadUserMetadataPost uses the /renter/upload endpoint to upload a
+// file with the provided user metadata attached.
+func (c *Client) RenterUploadUserMetadataPost(path string, siaPath modules.TurtleDexPath, dataPieces, parityPieces uint64, force bool, md map[string]string) (err error) {
+	sp := escapeTurtleDexPath(siaPath)
+	values := url.Values{}
+	values.Set("source", path)
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("force", strconv.FormatBool(force))
+	values.Set("usermetadata", encodeUserMetadata(md))
+	err = c.post(fmt.Sprintf("/renter/upload/%s", sp), values.Encode(), nil)
+	return
+}
+
 // RenterUploadDefaultPost uses the /renter/upload endpoint with default
 // redundancy settings to upload a file.
 func (c *Client) RenterUploadDefaultPost(path string, siaPath modules.TurtleDexPath) (err error) {
@@ -616,6 +934,20 @@ func (c *Client) RenterUploadStreamPost(r io.Reader, siaPath modules.TurtleDexPa
 	return err
 }
 
+// RenterUploadStreamUserMetadataPost uploads data using a stream and attaches
+// the provided user metadata to the new file.
+func (c *Client) RenterUploadStreamUserMetadataPost(r io.Reader, siaPath modules.TurtleDexPath, dataPieces, parityPieces uint64, force bool, md map[string]string) error {
+	sp := escapeTurtleDexPath(siaPath)
+	values := url.Values{}
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("force", strconv.FormatBool(force))
+	values.Set("stream", strconv.FormatBool(true))
+	values.Set("usermetadata", encodeUserMetadata(md))
+	_, _, err := c.postRawResponse(fmt.Sprintf("/renter/uploadstream/%s?%s", sp, values.Encode()), r)
+	return err
+}
+
 // RenterUploadStreamRepairPost a siafile using a stream. If the data provided
 // by r is not the same as the previously uploaded data, the data will be
 // corrupted.
@@ -628,6 +960,54 @@ func (c *Client) RenterUploadStreamRepairPost(r io.Reader, siaPath modules.Turtl
 	return err
 }
 
+// RenterUploadSessionCreatePost uses the /renter/uploadsessions/ endpoint to
+// create a resumable upload session for a new file.
+func (c *Client) RenterUploadSessionCreatePost(siaPath modules.TurtleDexPath, dataPieces, parityPieces uint64, force bool) (info modules.UploadSessionInfo, err error) {
+	sp := escapeTurtleDexPath(siaPath)
+	values := url.Values{}
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("force", strconv.FormatBool(force))
+	err = c.post(fmt.Sprintf("/renter/uploadsessions/%s?%s", sp, values.Encode()), "", &info)
+	return
+}
+
+// RenterUploadSessionGet uses the /renter/uploadsession/ endpoint to query a
+// resumable upload session.
+func (c *Client) RenterUploadSessionGet(id string) (info modules.UploadSessionInfo, err error) {
+	err = c.get(fmt.Sprintf("/renter/uploadsession/%s", id), &info)
+	return
+}
+
+// RenterUploadSessionWritePost uses the /renter/uploadsession/ endpoint to
+// append the data read from r to a resumable upload session at the provided
+// offset.
+func (c *Client) RenterUploadSessionWritePost(id string, offset uint64, r io.Reader) (info modules.UploadSessionInfo, err error) {
+	values := url.Values{}
+	values.Set("action", "write")
+	values.Set("offset", strconv.FormatUint(offset, 10))
+	_, resp, err := c.postRawResponse(fmt.Sprintf("/renter/uploadsession/%s?%s", id, values.Encode()), r)
+	if err != nil {
+		return modules.UploadSessionInfo{}, err
+	}
+	err = json.Unmarshal(resp, &info)
+	return
+}
+
+// RenterUploadSessionFinalizePost uses the /renter/uploadsession/ endpoint to
+// finalize a resumable upload session.
+func (c *Client) RenterUploadSessionFinalizePost(id string) (err error) {
+	err = c.post(fmt.Sprintf("/renter/uploadsession/%s?action=finalize", id), "", nil)
+	return
+}
+
+// RenterUploadSessionAbortPost uses the /renter/uploadsession/ endpoint to
+// abort a resumable upload session.
+func (c *Client) RenterUploadSessionAbortPost(id string) (err error) {
+	err = c.post(fmt.Sprintf("/renter/uploadsession/%s?action=abort", id), "", nil)
+	return
+}
+
 // RenterDirCreatePost uses the /renter/dir/ endpoint to create a directory for the
 // renter
 func (c *Client) RenterDirCreatePost(siaPath modules.TurtleDexPath) (err error) {
@@ -669,6 +1049,54 @@ func (c *Client) RenterDirRenamePost(siaPath, newTurtleDexPath modules.TurtleDex
 	return
 }
 
+// RenterDirSetQuotaPost uses the /renter/dir/ endpoint to set the quota of a
+// directory before and after redundancy.
+func (c *Client) RenterDirSetQuotaPost(siaPath modules.TurtleDexPath, quota, redundantQuota uint64) (err error) {
+	sp := escapeTurtleDexPath(siaPath)
+	values := url.Values{}
+	values.Set("action", "setquota")
+	values.Set("quota", fmt.Sprint(quota))
+	values.Set("redundantquota", fmt.Sprint(redundantQuota))
+	err = c.post(fmt.Sprintf("/renter/dir/%s", sp), values.Encode(), nil)
+	return
+}
+
+// RenterDirSetVersionRetentionPost uses the /renter/dir/ endpoint to set the
+// number of prior versions that are kept for files within a directory.
+func (c *Client) RenterDirSetVersionRetentionPost(siaPath modules.TurtleDexPath, retention int64) (err error) {
+	sp := escapeTurtleDexPath(siaPath)
+	values := url.Values{}
+	values.Set("action", "setversionretention")
+	values.Set("versionretention", fmt.Sprint(retention))
+	err = c.post(fmt.Sprintf("/renter/dir/%s", sp), values.Encode(), nil)
+	return
+}
+
+// RenterDirSetUserMetadataPost uses the /renter/dir/ endpoint to set the keys
+// of the user metadata of a directory to the values in set and to remove the
+// keys in remove.
+func (c *Client) RenterDirSetUserMetadataPost(siaPath modules.TurtleDexPath, set map[string]string, remove []string) (err error) {
+	sp := escapeTurtleDexPath(siaPath)
+	values := url.Values{}
+	values.Set("action", "setusermetadata")
+	values.Set("usermetadata", encodeUserMetadata(set))
+	values.Set("removeusermetadata", strings.Join(remove, ","))
+	err = c.post(fmt.Sprintf("/renter/dir/%s", sp), values.Encode(), nil)
+	return
+}
+
+// RenterDirSetRedundancyPolicyPost uses the /renter/dir/ endpoint to set the
+// redundancy policy of a directory. An unset policy makes the directory
+// inherit the policy of its parent.
+func (c *Client) RenterDirSetRedundancyPolicyPost(siaPath modules.TurtleDexPath, policy modules.RedundancyPolicy) (err error) {
+	sp := escapeTurtleDexPath(siaPath)
+	values := url.Values{}
+	values.Set("action", "setredundancypolicy")
+	values.Set("redundancypolicy", encodeRedundancyPolicy(policy))
+	err = c.post(fmt.Sprintf("/renter/dir/%s", sp), values.Encode(), nil)
+	return
+}
+
 // RenterDirRootGet uses the /renter/dir/ endpoint to query a directory,
 // starting from the root path.
 func (c *Client) RenterDirRootGet(siaPath modules.TurtleDexPath) (rd api.RenterDirectory, err error) {
@@ -757,6 +1185,23 @@ func (c *Client) RenterUploadsResumePost() (err error) {
 	return
 }
 
+// RenterRedundancyMigrationPausePost uses the /renter/redundancymigration/pause
+// endpoint to pause the migration of files to their redundancy policy
+func (c *Client) RenterRedundancyMigrationPausePost(duration time.Duration) (err error) {
+	values := url.Values{}
+	values.Set("duration", fmt.Sprint(uint64(math.Round(duration.Seconds()))))
+	err = c.post("/renter/redundancymigration/pause", values.Encode(), nil)
+	return
+}
+
+// RenterRedundancyMigrationResumePost uses the
+// /renter/redundancymigration/resume endpoint to resume the migration of files
+// to their redundancy policy
+func (c *Client) RenterRedundancyMigrationResumePost() (err error) {
+	err = c.post("/renter/redundancymigration/resume", "", nil)
+	return
+}
+
 // RenterPost uses the /renter POST endpoint to set fields of the renter. Values
 // are encoded as a query string in the body
 func (c *Client) RenterPost(values url.Values) (err error) {
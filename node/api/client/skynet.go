@@ -119,6 +119,15 @@ func (c *Client) SkynetSkylinkGetWithTimeout(skylink string, timeout int) ([]byt
 	return c.skynetSkylinkGetWithParameters(skylink, params)
 }
 
+// SkynetSkylinkGetWithPriority uses the /skynet/skylink endpoint to download a
+// skylink file using the given download priority.
+func (c *Client) SkynetSkylinkGetWithPriority(skylink string, priority modules.DownloadPriority) ([]byte, modules.SkyfileMetadata, error) {
+	params := map[string]string{
+		"priority": priority.String(),
+	}
+	return c.skynetSkylinkGetWithParameters(skylink, params)
+}
+
 // SkynetSkylinkGetWithLayout uses the /skynet/skylink endpoint to download
 // a skylink file, specifying the given value for the 'include-layout'
 // parameter.
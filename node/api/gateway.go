@@ -18,8 +18,9 @@ type (
 		Peers      []modules.Peer     `json:"peers"`
 		Online     bool               `json:"online"`
 
-		MaxDownloadSpeed int64 `json:"maxdownloadspeed"`
-		MaxUploadSpeed   int64 `json:"maxuploadspeed"`
+		MaxDownloadSpeed  int64                     `json:"maxdownloadspeed"`
+		MaxUploadSpeed    int64                     `json:"maxuploadspeed"`
+		BandwidthSchedule modules.BandwidthSchedule `json:"bandwidthschedule"`
 	}
 
 	// GatewayBandwidthGET contains the bandwidth usage of the gateway
@@ -53,7 +54,7 @@ func (api *API) gatewayHandlerGET(w http.ResponseWriter, _ *http.Request, _ http
 	if peers == nil {
 		peers = make([]modules.Peer, 0)
 	}
-	WriteJSON(w, GatewayGET{api.gateway.Address(), peers, api.gateway.Online(), mds, mus})
+	WriteJSON(w, GatewayGET{api.gateway.Address(), peers, api.gateway.Online(), mds, mus, api.gateway.BandwidthSchedule()})
 }
 
 // gatewayHandlerPOST handles the API call changing gateway specific settings.
@@ -77,12 +78,27 @@ func (api *API) gatewayHandlerPOST(w http.ResponseWriter, req *http.Request, _ h
 		}
 		maxUploadSpeed = uploadSpeed
 	}
+	// Scan the bandwidth schedule. An empty schedule removes all windows.
+	// (optional parameter)
+	_, setSchedule := req.Form["bandwidthschedule"]
+	schedule, err := modules.ParseBandwidthSchedule(req.FormValue("bandwidthschedule"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse bandwidthschedule: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
 	// Try to set the limits.
-	err := api.gateway.SetRateLimits(maxDownloadSpeed, maxUploadSpeed)
+	err = api.gateway.SetRateLimits(maxDownloadSpeed, maxUploadSpeed)
 	if err != nil {
 		WriteError(w, Error{"failed to set new rate limit: " + err.Error()}, http.StatusBadRequest)
 		return
 	}
+	if setSchedule {
+		err = api.gateway.SetBandwidthSchedule(schedule)
+		if err != nil {
+			WriteError(w, Error{"failed to set new bandwidth schedule: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
 	WriteSuccess(w)
 }
 
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -13,6 +15,12 @@ import (
 	"github.com/turtledex/TurtleDexCore/types"
 )
 
+const (
+	// HostFinancialsDateFormat is the format of the days which limit the
+	// range of the /host/financials call.
+	HostFinancialsDateFormat = "2006-01-02"
+)
+
 var (
 	// errNoPath is returned when a call fails to provide a nonempty string
 	// for the path parameter.
@@ -44,6 +52,7 @@ type (
 	// HostGET contains the information that is returned after a GET request to
 	// /host - a bunch of information about the status of the host.
 	HostGET struct {
+		BannedPeers          []modules.HostBannedPeer         `json:"bannedpeers"`
 		ConnectabilityStatus modules.HostConnectabilityStatus `json:"connectabilitystatus"`
 		ExternalSettings     modules.HostExternalSettings     `json:"externalsettings"`
 		FinancialMetrics     modules.HostFinancialMetrics     `json:"financialmetrics"`
@@ -61,12 +70,29 @@ type (
 		ConversionRate float64        `json:"conversionrate"`
 	}
 
+	// HostFinancialsGET contains the entries of the host's revenue ledger.
+	HostFinancialsGET struct {
+		Entries []modules.HostLedgerEntry `json:"entries"`
+	}
+
+	// HostPricingGET contains the settings and the current state of the
+	// host's dynamic pricing engine.
+	HostPricingGET struct {
+		modules.HostPricingStatus
+	}
+
 	// StorageGET contains the information that is returned after a GET request
 	// to /host/storage - a bunch of information about the status of storage
 	// management on the host.
 	StorageGET struct {
 		Folders []modules.StorageFolderMetadata `json:"folders"`
 	}
+
+	// StorageScrubGET contains the progress and the findings of the host's
+	// storage scrubber.
+	StorageScrubGET struct {
+		modules.StorageScrubStatus
+	}
 )
 
 // folderIndex determines the index of the storage folder with the provided
@@ -100,7 +126,9 @@ func (api *API) hostHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprou
 	ws := api.host.WorkingStatus()
 	pk := api.host.PublicKey()
 	pt := api.host.PriceTable()
+	bp := api.host.BannedPeers()
 	hg := HostGET{
+		BannedPeers:          bp,
 		ConnectabilityStatus: cs,
 		ExternalSettings:     es,
 		FinancialMetrics:     fm,
@@ -133,6 +161,72 @@ func (api *API) hostBandwidthHandlerGET(w http.ResponseWriter, _ *http.Request,
 	})
 }
 
+// hostFinancialsHandlerGET handles GET requests to the /host/financials API
+// endpoint, returning the entries of the host's revenue ledger either as JSON
+// or as CSV.
+func (api *API) hostFinancialsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse the range of days. By default the whole ledger is returned.
+	from, to := time.Unix(0, 0), time.Now()
+	var err error
+	if f := req.FormValue("from"); f != "" {
+		from, err = time.Parse(HostFinancialsDateFormat, f)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'from': " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if t := req.FormValue("to"); t != "" {
+		to, err = time.Parse(HostFinancialsDateFormat, t)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'to': " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	format := req.FormValue("format")
+	if format != "" && format != "json" && format != "csv" {
+		WriteError(w, Error{"format needs to be either 'json' or 'csv'"}, http.StatusBadRequest)
+		return
+	}
+
+	entries, err := api.host.FinancialLedger(from, to)
+	if err != nil {
+		WriteError(w, Error{"failed to get the host's ledger: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if format != "csv" {
+		WriteJSON(w, HostFinancialsGET{
+			Entries: entries,
+		})
+		return
+	}
+
+	// Write the entries as CSV. All amounts are in hastings.
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	cw := csv.NewWriter(w)
+	records := [][]string{{"day", "contractid", "accountfunding", "contractcompensation", "downloadrevenue", "storagerevenue", "uploadrevenue", "registryrevenue", "rpcrevenue", "accountregistryspending", "accountrpcspending", "lockedcollateral", "lostcollateral", "unlockedcollateral", "transactionfees"}}
+	for _, e := range entries {
+		records = append(records, []string{
+			e.Day.Format(HostFinancialsDateFormat),
+			e.ContractID.String(),
+			e.AccountFunding.String(),
+			e.ContractCompensation.String(),
+			e.DownloadRevenue.String(),
+			e.StorageRevenue.String(),
+			e.UploadRevenue.String(),
+			e.RegistryRevenue.String(),
+			e.RPCRevenue.String(),
+			e.AccountRegistrySpending.String(),
+			e.AccountRPCSpending.String(),
+			e.LockedCollateral.String(),
+			e.LostCollateral.String(),
+			e.UnlockedCollateral.String(),
+			e.TransactionFees.String(),
+		})
+	}
+	// The error is ignored since the response is already being written.
+	_ = cw.WriteAll(records)
+}
+
 // parseHostSettings a request's query strings and returns a
 // modules.HostInternalSettings configured with the request's query string
 // parameters.
@@ -296,6 +390,78 @@ func (api *API) parseHostSettings(req *http.Request) (modules.HostInternalSettin
 	if req.FormValue("customregistrypath") != "" {
 		settings.CustomRegistryPath = req.FormValue("customregistrypath")
 	}
+	if req.FormValue("maxdownloadspeed") != "" {
+		var x int64
+		_, err := fmt.Sscan(req.FormValue("maxdownloadspeed"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxDownloadSpeed = x
+	}
+	if req.FormValue("maxuploadspeed") != "" {
+		var x int64
+		_, err := fmt.Sscan(req.FormValue("maxuploadspeed"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxUploadSpeed = x
+	}
+	// An empty bandwidth schedule removes all windows.
+	if _, ok := req.Form["bandwidthschedule"]; ok {
+		schedule, err := modules.ParseBandwidthSchedule(req.FormValue("bandwidthschedule"))
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.BandwidthSchedule = schedule
+	}
+	if req.FormValue("peermaxconcurrentstreams") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("peermaxconcurrentstreams"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.PeerMaxConcurrentStreams = x
+	}
+	if req.FormValue("peermaxdownloadspeed") != "" {
+		var x int64
+		_, err := fmt.Sscan(req.FormValue("peermaxdownloadspeed"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.PeerMaxDownloadSpeed = x
+	}
+	if req.FormValue("peermaxuploadspeed") != "" {
+		var x int64
+		_, err := fmt.Sscan(req.FormValue("peermaxuploadspeed"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.PeerMaxUploadSpeed = x
+	}
+	if req.FormValue("peermaxprogramsperminute") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("peermaxprogramsperminute"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.PeerMaxProgramsPerMinute = x
+	}
+	if req.FormValue("peerbanthreshold") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("peerbanthreshold"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.PeerBanThreshold = x
+	}
+	if req.FormValue("peerbanduration") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("peerbanduration"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.PeerBanDuration = time.Duration(x) * time.Second
+	}
 
 	// Validate the RPC, Sector Access, and Download Prices
 	minBaseRPCPrice := settings.MinBaseRPCPrice
@@ -388,6 +554,31 @@ func (api *API) hostHandlerPOST(w http.ResponseWriter, req *http.Request, _ http
 	WriteSuccess(w)
 }
 
+// hostPricingHandlerGET handles GET requests to the /host/pricing API
+// endpoint, returning the state of the host's dynamic pricing engine.
+func (api *API) hostPricingHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, HostPricingGET{
+		HostPricingStatus: api.host.PricingStatus(),
+	})
+}
+
+// hostPricingHandlerPOST handles POST requests to the /host/pricing API
+// endpoint, which configure the host's dynamic pricing engine. The settings
+// are provided as JSON in the request body.
+func (api *API) hostPricingHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var settings modules.HostPricingSettings
+	err := json.NewDecoder(req.Body).Decode(&settings)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.host.SetPricingSettings(settings); err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // hostAnnounceHandler handles the API call to get the host to announce itself
 // to the network.
 func (api *API) hostAnnounceHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
@@ -412,6 +603,66 @@ func (api *API) storageHandler(w http.ResponseWriter, _ *http.Request, _ httprou
 	})
 }
 
+// storageScrubHandlerGET returns the progress and the findings of the host's
+// storage scrubber.
+func (api *API) storageScrubHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, StorageScrubGET{
+		StorageScrubStatus: api.host.ScrubStatus(),
+	})
+}
+
+// storageScrubHandlerPOST configures the host's storage scrubber and
+// optionally starts a scrub.
+func (api *API) storageScrubHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	settings := api.host.ScrubStatus().StorageScrubSettings
+	if e := req.FormValue("enabled"); e != "" {
+		var enabled bool
+		_, err := fmt.Sscan(e, &enabled)
+		if err != nil {
+			WriteError(w, Error{"unable to parse enabled: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Enabled = enabled
+	}
+	if i := req.FormValue("interval"); i != "" {
+		var x uint64
+		_, err := fmt.Sscan(i, &x)
+		if err != nil {
+			WriteError(w, Error{"unable to parse interval: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Interval = time.Duration(x) * time.Second
+	}
+	if m := req.FormValue("maxbytespersecond"); m != "" {
+		var x uint64
+		_, err := fmt.Sscan(m, &x)
+		if err != nil {
+			WriteError(w, Error{"unable to parse maxbytespersecond: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.MaxBytesPerSecond = x
+	}
+	var start bool
+	if s := req.FormValue("start"); s != "" {
+		_, err := fmt.Sscan(s, &start)
+		if err != nil {
+			WriteError(w, Error{"unable to parse start: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if err := api.host.SetScrubSettings(settings); err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if start {
+		if err := api.host.StartScrub(); err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	WriteSuccess(w)
+}
+
 // storageFoldersAddHandler adds a storage folder to the storage manager.
 func (api *API) storageFoldersAddHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	folderPath := req.FormValue("path")
@@ -458,6 +709,30 @@ func (api *API) storageFoldersResizeHandler(w http.ResponseWriter, req *http.Req
 	WriteSuccess(w)
 }
 
+// storageFoldersTierHandler sets the tier of a storage folder.
+func (api *API) storageFoldersTierHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	folderPath := req.FormValue("path")
+	if folderPath == "" {
+		WriteError(w, Error{"path parameter is required"}, http.StatusBadRequest)
+		return
+	}
+
+	storageFolders := api.host.StorageFolders()
+	folderIndex, err := folderIndex(folderPath, storageFolders)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	tier := modules.StorageTier(req.FormValue("tier"))
+	err = api.host.SetStorageFolderTier(uint16(folderIndex), tier)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // storageFoldersRemoveHandler removes a storage folder from the storage
 // manager.
 func (api *API) storageFoldersRemoveHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
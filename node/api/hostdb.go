@@ -39,9 +39,28 @@ type (
 
 	// HostdbGet holds information about the hostdb.
 	HostdbGet struct {
+		Benchmarking        bool `json:"benchmarking"`
 		InitialScanComplete bool `json:"initialscancomplete"`
 	}
 
+	// HostdbBenchmarkingPOST contains the information needed to enable or
+	// disable benchmarking hosts while scanning them.
+	HostdbBenchmarkingPOST struct {
+		Enabled bool `json:"enabled"`
+	}
+
+	// HostdbGroupsGET contains the host groups of the hostdb.
+	HostdbGroupsGET struct {
+		Groups map[string][]types.TurtleDexPublicKey `json:"groups"`
+	}
+
+	// HostdbGroupsPOST contains the information needed to set the hosts of a
+	// host group. An empty list of hosts removes the group.
+	HostdbGroupsPOST struct {
+		Group string                     `json:"group"`
+		Hosts []types.TurtleDexPublicKey `json:"hosts"`
+	}
+
 	// HostdbFilterModeGET contains the information about the HostDB's
 	// filtermode
 	HostdbFilterModeGET struct {
@@ -55,8 +74,66 @@ type (
 		FilterMode string               `json:"filtermode"`
 		Hosts      []types.TurtleDexPublicKey `json:"hosts"`
 	}
+
+	// HostdbScoringPolicyGET contains the scoring policy of the hostDB and the
+	// predefined profiles it can be composed of.
+	HostdbScoringPolicyGET struct {
+		Policy   modules.HostScoringPolicy            `json:"policy"`
+		Profiles map[string]modules.HostScoringPolicy `json:"profiles"`
+	}
+
+	// HostdbScoringPolicyPOST contains the information needed to set the
+	// scoring policy of the hostDB. The policy is composed of the profiles
+	// and the weights that are set override the weights of the composed
+	// policy.
+	HostdbScoringPolicyPOST struct {
+		Name     string   `json:"name"`
+		Profiles []string `json:"profiles"`
+
+		AgeWeight              *float64 `json:"ageweight,omitempty"`
+		CollateralWeight       *float64 `json:"collateralweight,omitempty"`
+		InteractionWeight      *float64 `json:"interactionweight,omitempty"`
+		PerformanceWeight      *float64 `json:"performanceweight,omitempty"`
+		PriceWeight            *float64 `json:"priceweight,omitempty"`
+		StorageRemainingWeight *float64 `json:"storageremainingweight,omitempty"`
+		UptimeWeight           *float64 `json:"uptimeweight,omitempty"`
+	}
 )
 
+// policy creates the scoring policy described by the parameters. Policies
+// with tuned weights are named 'custom' unless a name is provided.
+func (p HostdbScoringPolicyPOST) policy() (modules.HostScoringPolicy, error) {
+	policy, err := modules.ComposeHostScoringPolicy(p.Profiles...)
+	if err != nil {
+		return modules.HostScoringPolicy{}, err
+	}
+	overrides := []struct {
+		weight *float64
+		field  *float64
+	}{
+		{p.AgeWeight, &policy.AgeWeight},
+		{p.CollateralWeight, &policy.CollateralWeight},
+		{p.InteractionWeight, &policy.InteractionWeight},
+		{p.PerformanceWeight, &policy.PerformanceWeight},
+		{p.PriceWeight, &policy.PriceWeight},
+		{p.StorageRemainingWeight, &policy.StorageRemainingWeight},
+		{p.UptimeWeight, &policy.UptimeWeight},
+	}
+	tuned := false
+	for _, o := range overrides {
+		if o.weight != nil {
+			*o.field = *o.weight
+			tuned = true
+		}
+	}
+	if p.Name != "" {
+		policy.Name = p.Name
+	} else if tuned {
+		policy.Name = "custom"
+	}
+	return policy, policy.Validate()
+}
+
 // hostdbHandler handles the API call asking for the list of active
 // hosts.
 func (api *API) hostdbHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
@@ -65,11 +142,64 @@ func (api *API) hostdbHandler(w http.ResponseWriter, _ *http.Request, _ httprout
 		WriteError(w, Error{"Failed to get initial scan status: " + err.Error()}, http.StatusInternalServerError)
 		return
 	}
+	benchmarking, err := api.renter.HostBenchmarking()
+	if err != nil {
+		WriteError(w, Error{"Failed to get benchmarking status: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
 	WriteJSON(w, HostdbGet{
+		Benchmarking:        benchmarking,
 		InitialScanComplete: isc,
 	})
 }
 
+// hostdbBenchmarkingHandlerPOST handles the API call to enable or disable
+// benchmarking hosts while scanning them.
+func (api *API) hostdbBenchmarkingHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse parameters
+	var params HostdbBenchmarkingPOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	if err := api.renter.SetHostBenchmarking(params.Enabled); err != nil {
+		WriteError(w, Error{"failed to set benchmarking: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostdbGroupsHandlerGET handles the API call to get the host groups of the
+// hostdb.
+func (api *API) hostdbGroupsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	groups, err := api.renter.HostGroups()
+	if err != nil {
+		WriteError(w, Error{"failed to get host groups: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, HostdbGroupsGET{Groups: groups})
+}
+
+// hostdbGroupsHandlerPOST handles the API call to set the hosts of a host
+// group.
+func (api *API) hostdbGroupsHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse parameters
+	var params HostdbGroupsPOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	if err := api.renter.SetHostGroup(params.Group, params.Hosts); err != nil {
+		WriteError(w, Error{"failed to set host group: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // hostdbActiveHandler handles the API call asking for the list of active
 // hosts.
 func (api *API) hostdbActiveHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
@@ -208,3 +338,41 @@ func (api *API) hostdbFilterModeHandlerPOST(w http.ResponseWriter, req *http.Req
 	}
 	WriteSuccess(w)
 }
+
+// hostdbScoringPolicyHandlerGET handles the API call to get the hostdb's
+// scoring policy.
+func (api *API) hostdbScoringPolicyHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	policy, err := api.renter.HostScoringPolicy()
+	if err != nil {
+		WriteError(w, Error{"unable to get scoring policy: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, HostdbScoringPolicyGET{
+		Policy:   policy,
+		Profiles: modules.HostScoringProfiles,
+	})
+}
+
+// hostdbScoringPolicyHandlerPOST handles the API call to set the hostdb's
+// scoring policy.
+func (api *API) hostdbScoringPolicyHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse parameters
+	var params HostdbScoringPolicyPOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	policy, err := params.policy()
+	if err != nil {
+		WriteError(w, Error{"invalid scoring policy: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Set the policy
+	if err := api.renter.SetHostScoringPolicy(policy); err != nil {
+		WriteError(w, Error{"failed to set the scoring policy: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
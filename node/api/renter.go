@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -23,6 +24,7 @@ import (
 	"github.com/turtledex/TurtleDexCore/modules"
 	"github.com/turtledex/TurtleDexCore/modules/renter"
 	"github.com/turtledex/TurtleDexCore/modules/renter/contractor"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
 	"github.com/turtledex/TurtleDexCore/persist"
 	"github.com/turtledex/TurtleDexCore/types"
 )
@@ -85,6 +87,7 @@ type (
 		CurrentPeriod    types.BlockHeight          `json:"currentperiod"`
 		NextPeriod       types.BlockHeight          `json:"nextperiod"`
 
+		DedupStatus  modules.DedupStatus  `json:"dedupstatus"`
 		MemoryStatus modules.MemoryStatus `json:"memorystatus"`
 	}
 
@@ -172,6 +175,39 @@ type (
 		Files []modules.FileInfo `json:"files"`
 	}
 
+	// RenterSearchGET contains the files that matched a search.
+	RenterSearchGET struct {
+		Files []modules.IndexedFile `json:"files"`
+	}
+
+	// RenterSpendingHistoryGET contains the records of the spending ledger
+	// that matched a filter.
+	RenterSpendingHistoryGET struct {
+		Records []modules.SpendingRecord `json:"records"`
+	}
+
+	// RenterBudgetsGET contains the budgets of the renter's API callers and
+	// their spending in the current period.
+	RenterBudgetsGET struct {
+		Budgets []modules.RenterBudgetUsage `json:"budgets"`
+	}
+
+	// RenterRepairGET contains the repair settings of the renter and the
+	// progress of its repairs.
+	RenterRepairGET struct {
+		modules.RepairStatus
+	}
+
+	// RenterDirSnapshotsGET lists the renter's directory snapshots.
+	RenterDirSnapshotsGET struct {
+		Snapshots []modules.DirectorySnapshot `json:"snapshots"`
+	}
+
+	// RenterFileVersions lists the prior versions of a file.
+	RenterFileVersions struct {
+		Versions []modules.FileVersionInfo `json:"versions"`
+	}
+
 	// RenterFuseInfo contains information about mounted fuse filesystems.
 	RenterFuseInfo struct {
 		MountPoints []modules.MountInfo `json:"mountpoints"`
@@ -645,6 +681,58 @@ func ParseDataAndParityPieces(strDataPieces, strParityPieces string) (dataPieces
 	return dataPieces, parityPieces, nil
 }
 
+// parseUserMetadata parses user metadata that was passed to the API as a JSON
+// object. An empty string results in no metadata.
+func parseUserMetadata(str string) (map[string]string, error) {
+	if str == "" {
+		return nil, nil
+	}
+	var md map[string]string
+	err := json.Unmarshal([]byte(str), &md)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to decode user metadata")
+	}
+	return md, modules.ValidateUserMetadata(md)
+}
+
+// parseRedundancyPolicy parses a redundancy policy that was passed to the API
+// as a JSON object. An empty string results in an unset policy. Every tier
+// needs to satisfy the same requirements as the erasure coding parameters of
+// uploads.
+func parseRedundancyPolicy(str string) (modules.RedundancyPolicy, error) {
+	var policy modules.RedundancyPolicy
+	if str == "" {
+		return policy, nil
+	}
+	err := json.Unmarshal([]byte(str), &policy)
+	if err != nil {
+		return modules.RedundancyPolicy{}, errors.AddContext(err, "unable to decode redundancy policy")
+	}
+	for _, tier := range policy.Tiers {
+		_, err = parseErasureCodingParameters(strconv.Itoa(tier.DataPieces), strconv.Itoa(tier.ParityPieces))
+		if err != nil {
+			return modules.RedundancyPolicy{}, errors.AddContext(err, fmt.Sprintf("invalid tier '%v'", tier))
+		}
+	}
+	return policy, policy.Validate()
+}
+
+// parseUserMetadataKeys parses a comma separated list of user metadata keys.
+func parseUserMetadataKeys(str string) []string {
+	if str == "" {
+		return nil
+	}
+	return strings.Split(str, ",")
+}
+
+// parseLocationCodes parses a comma separated list of region or country codes.
+func parseLocationCodes(str string) []string {
+	if str == "" {
+		return nil
+	}
+	return strings.Split(str, ",")
+}
+
 // renterHandlerGET handles the API call to /renter.
 func (api *API) renterHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	settings, err := api.renter.Settings()
@@ -664,268 +752,266 @@ func (api *API) renterHandlerGET(w http.ResponseWriter, _ *http.Request, _ httpr
 		WriteError(w, Error{"unable to get renter memory information: " + err.Error()}, http.StatusBadRequest)
 		return
 	}
+	dedupStatus, err := api.renter.DedupStatus()
+	if err != nil {
+		WriteError(w, Error{"unable to get renter dedup information: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
 	WriteJSON(w, RenterGET{
 		Settings:         settings,
 		FinancialMetrics: spending,
 		CurrentPeriod:    currentPeriod,
 		NextPeriod:       nextPeriod,
 
+		DedupStatus:  dedupStatus,
 		MemoryStatus: memoryStatus,
 	})
 }
 
-// renterHandlerPOST handles the API call to set the Renter's settings. This API
-// call handles multiple settings and so each setting is optional on it's own.
-// Groups of settings, such as the allowance, have certain requirements if they
-// are being set in which case certain fields are no longer optional.
-func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
-	// Get the existing settings
-	settings, err := api.renter.Settings()
-	if err != nil {
-		WriteError(w, Error{"unable able to get renter settings: " + err.Error()}, http.StatusBadRequest)
-		return
-	}
-
+// parseAllowance parses the allowance fields of the request and applies them to
+// the provided allowance. Fields that aren't set keep their value, unset fields
+// of a partially set allowance are set to their defaults.
+func parseAllowance(req *http.Request, allowance modules.Allowance) (modules.Allowance, error) {
 	// Scan for all allowance fields
 	var hostsSet, renewWindowSet, expectedStorageSet,
 		expectedUploadSet, expectedDownloadSet, expectedRedundancySet, maxPeriodChurnSet bool
 	if f := req.FormValue("funds"); f != "" {
 		funds, ok := scanAmount(f)
 		if !ok {
-			WriteError(w, Error{"unable to parse funds"}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse funds")
 		}
-		settings.Allowance.Funds = funds
+		allowance.Funds = funds
 	}
 	if h := req.FormValue("hosts"); h != "" {
 		var hosts uint64
 		if _, err := fmt.Sscan(h, &hosts); err != nil {
-			WriteError(w, Error{"unable to parse hosts: " + err.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.AddContext(err, "unable to parse hosts")
 		} else if hosts != 0 && hosts < requiredHosts {
-			WriteError(w, Error{fmt.Sprintf("insufficient number of hosts, need at least %v but have %v", requiredHosts, hosts)}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, fmt.Errorf("insufficient number of hosts, need at least %v but have %v", requiredHosts, hosts)
 		}
-		settings.Allowance.Hosts = hosts
+		allowance.Hosts = hosts
 		hostsSet = true
 	}
 	if p := req.FormValue("period"); p != "" {
 		var period types.BlockHeight
 		if _, err := fmt.Sscan(p, &period); err != nil {
-			WriteError(w, Error{"unable to parse period: " + err.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.AddContext(err, "unable to parse period")
 		}
-		settings.Allowance.Period = types.BlockHeight(period)
+		allowance.Period = types.BlockHeight(period)
 	}
 	if rw := req.FormValue("renewwindow"); rw != "" {
 		var renewWindow types.BlockHeight
 		if _, err := fmt.Sscan(rw, &renewWindow); err != nil {
-			WriteError(w, Error{"unable to parse renewwindow: " + err.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.AddContext(err, "unable to parse renewwindow")
 		} else if renewWindow != 0 && types.BlockHeight(renewWindow) < requiredRenewWindow {
-			WriteError(w, Error{fmt.Sprintf("renew window is too small, must be at least %v blocks but have %v blocks", requiredRenewWindow, renewWindow)}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, fmt.Errorf("renew window is too small, must be at least %v blocks but have %v blocks", requiredRenewWindow, renewWindow)
 		}
-		settings.Allowance.RenewWindow = types.BlockHeight(renewWindow)
+		allowance.RenewWindow = types.BlockHeight(renewWindow)
 		renewWindowSet = true
 	}
 	if pcipStr := req.FormValue("paymentcontractinitialfunding"); pcipStr != "" {
 		vcip, ok := scanAmount(pcipStr)
 		if !ok {
-			WriteError(w, Error{"unable to parse paymentcontractinitialfunding"}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse paymentcontractinitialfunding")
 		}
-		settings.Allowance.PaymentContractInitialFunding = vcip
+		allowance.PaymentContractInitialFunding = vcip
 	}
 	if es := req.FormValue("expectedstorage"); es != "" {
 		var expectedStorage uint64
 		if _, err := fmt.Sscan(es, &expectedStorage); err != nil {
-			WriteError(w, Error{"unable to parse expectedStorage: " + err.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.AddContext(err, "unable to parse expectedStorage")
 		}
-		settings.Allowance.ExpectedStorage = expectedStorage
+		allowance.ExpectedStorage = expectedStorage
 		expectedStorageSet = true
 	}
 	if euf := req.FormValue("expectedupload"); euf != "" {
 		var expectedUpload uint64
 		if _, err := fmt.Sscan(euf, &expectedUpload); err != nil {
-			WriteError(w, Error{"unable to parse expectedUpload: " + err.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.AddContext(err, "unable to parse expectedUpload")
 		}
-		settings.Allowance.ExpectedUpload = expectedUpload
+		allowance.ExpectedUpload = expectedUpload
 		expectedUploadSet = true
 	}
 	if edf := req.FormValue("expecteddownload"); edf != "" {
 		var expectedDownload uint64
 		if _, err := fmt.Sscan(edf, &expectedDownload); err != nil {
-			WriteError(w, Error{"unable to parse expectedDownload: " + err.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.AddContext(err, "unable to parse expectedDownload")
 		}
-		settings.Allowance.ExpectedDownload = expectedDownload
+		allowance.ExpectedDownload = expectedDownload
 		expectedDownloadSet = true
 	}
 	if er := req.FormValue("expectedredundancy"); er != "" {
 		var expectedRedundancy float64
 		if _, err := fmt.Sscan(er, &expectedRedundancy); err != nil {
-			WriteError(w, Error{"unable to parse expectedRedundancy: " + err.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.AddContext(err, "unable to parse expectedRedundancy")
 		}
-		settings.Allowance.ExpectedRedundancy = expectedRedundancy
+		allowance.ExpectedRedundancy = expectedRedundancy
 		expectedRedundancySet = true
 	}
 	if mpc := req.FormValue("maxperiodchurn"); mpc != "" {
 		var maxPeriodChurn uint64
 		if _, err := fmt.Sscan(mpc, &maxPeriodChurn); err != nil {
-			WriteError(w, Error{"unable to parse new max churn per period: " + err.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.AddContext(err, "unable to parse new max churn per period")
 		}
-		settings.Allowance.MaxPeriodChurn = maxPeriodChurn
+		allowance.MaxPeriodChurn = maxPeriodChurn
 		maxPeriodChurnSet = true
 	}
 	if str := req.FormValue("maxrpcprice"); str != "" {
 		price, ok := scanAmount(str)
 		if !ok {
-			WriteError(w, Error{"unable to parse maxrpcprice"}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse maxrpcprice")
 		}
-		settings.Allowance.MaxRPCPrice = price
+		allowance.MaxRPCPrice = price
 	}
 	if str := req.FormValue("maxcontractprice"); str != "" {
 		price, ok := scanAmount(str)
 		if !ok {
-			WriteError(w, Error{"unable to parse maxcontractprice"}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse maxcontractprice")
 		}
-		settings.Allowance.MaxContractPrice = price
+		allowance.MaxContractPrice = price
 	}
 	if str := req.FormValue("maxdownloadbandwidthprice"); str != "" {
 		price, ok := scanAmount(str)
 		if !ok {
-			WriteError(w, Error{"unable to parse maxdownloadbandwidthprice"}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse maxdownloadbandwidthprice")
 		}
-		settings.Allowance.MaxDownloadBandwidthPrice = price
+		allowance.MaxDownloadBandwidthPrice = price
 	}
 	if str := req.FormValue("maxsectoraccessprice"); str != "" {
 		price, ok := scanAmount(str)
 		if !ok {
-			WriteError(w, Error{"unable to parse maxsectoraccessprice"}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse maxsectoraccessprice")
 		}
-		settings.Allowance.MaxSectorAccessPrice = price
+		allowance.MaxSectorAccessPrice = price
 	}
 	if str := req.FormValue("maxstorageprice"); str != "" {
 		price, ok := scanAmount(str)
 		if !ok {
-			WriteError(w, Error{"unable to parse maxstorageprice"}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse maxstorageprice")
 		}
-		settings.Allowance.MaxStoragePrice = price
+		allowance.MaxStoragePrice = price
 	}
 	if str := req.FormValue("maxuploadbandwidthprice"); str != "" {
 		price, ok := scanAmount(str)
 		if !ok {
-			WriteError(w, Error{"unable to parse maxuploadbandwidthprice"}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse maxuploadbandwidthprice")
 		}
-		settings.Allowance.MaxUploadBandwidthPrice = price
+		allowance.MaxUploadBandwidthPrice = price
+	}
+	if _, ok := req.Form["hostgrouptargets"]; ok {
+		targets, err := modules.ParseHostGroupTargets(req.FormValue("hostgrouptargets"))
+		if err != nil {
+			return modules.Allowance{}, errors.AddContext(err, "unable to parse hostgrouptargets")
+		}
+		allowance.HostGroupTargets = targets
 	}
 
 	// Validate any allowance changes. Funds and Period are the only required
 	// fields.
-	zeroFunds := settings.Allowance.Funds.Cmp(types.ZeroCurrency) == 0
-	zeroPeriod := settings.Allowance.Period == 0
+	zeroFunds := allowance.Funds.Cmp(types.ZeroCurrency) == 0
+	zeroPeriod := allowance.Period == 0
 	if zeroFunds && zeroPeriod {
 		// If both the funds and period are zero then the allowance should be
 		// cancelled. Make sure that the rest of the fields are zeroed out
-		settings.Allowance = modules.Allowance{}
-	} else if !reflect.DeepEqual(settings.Allowance, modules.Allowance{}) {
+		allowance = modules.Allowance{}
+	} else if !reflect.DeepEqual(allowance, modules.Allowance{}) {
 		// Allowance has been set at least partially. Validate that all fields
 		// are set correctly
 
 		// If Funds is still 0 return an error since we need the user to set the
 		// period initially
 		if zeroFunds {
-			WriteError(w, Error{ErrFundsNeedToBeSet.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, ErrFundsNeedToBeSet
 		}
 
 		// If Period is still 0 return an error since we need the user to set
 		// the period initially
 		if zeroPeriod {
-			WriteError(w, Error{ErrPeriodNeedToBeSet.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, ErrPeriodNeedToBeSet
 		}
 
 		// If the user set Hosts to 0 return an error, otherwise if Hosts was
 		// not set by the user then set it to the sane default
-		if settings.Allowance.Hosts == 0 && hostsSet {
-			WriteError(w, Error{contractor.ErrAllowanceNoHosts.Error()}, http.StatusBadRequest)
-			return
-		} else if settings.Allowance.Hosts == 0 {
-			settings.Allowance.Hosts = modules.DefaultAllowance.Hosts
+		if allowance.Hosts == 0 && hostsSet {
+			return modules.Allowance{}, contractor.ErrAllowanceNoHosts
+		} else if allowance.Hosts == 0 {
+			allowance.Hosts = modules.DefaultAllowance.Hosts
 		}
 
 		// If the user set the Renew Window to 0 return an error, otherwise if
 		// the Renew Window was not set by the user then set it to the sane
 		// default
-		if settings.Allowance.RenewWindow == 0 && renewWindowSet {
-			WriteError(w, Error{contractor.ErrAllowanceZeroWindow.Error()}, http.StatusBadRequest)
-			return
-		} else if settings.Allowance.RenewWindow == 0 {
-			settings.Allowance.RenewWindow = settings.Allowance.Period / 2
+		if allowance.RenewWindow == 0 && renewWindowSet {
+			return modules.Allowance{}, contractor.ErrAllowanceZeroWindow
+		} else if allowance.RenewWindow == 0 {
+			allowance.RenewWindow = allowance.Period / 2
 		}
 
 		// If the user set ExpectedStorage to 0 return an error, otherwise if
 		// ExpectedStorage was not set by the user then set it to the sane
 		// default
-		if settings.Allowance.ExpectedStorage == 0 && expectedStorageSet {
-			WriteError(w, Error{contractor.ErrAllowanceZeroExpectedStorage.Error()}, http.StatusBadRequest)
-			return
-		} else if settings.Allowance.ExpectedStorage == 0 {
-			settings.Allowance.ExpectedStorage = modules.DefaultAllowance.ExpectedStorage
+		if allowance.ExpectedStorage == 0 && expectedStorageSet {
+			return modules.Allowance{}, contractor.ErrAllowanceZeroExpectedStorage
+		} else if allowance.ExpectedStorage == 0 {
+			allowance.ExpectedStorage = modules.DefaultAllowance.ExpectedStorage
 		}
 
 		// If the user set ExpectedUpload to 0 return an error, otherwise if
 		// ExpectedUpload was not set by the user then set it to the sane
 		// default
-		if settings.Allowance.ExpectedUpload == 0 && expectedUploadSet {
-			WriteError(w, Error{contractor.ErrAllowanceZeroExpectedUpload.Error()}, http.StatusBadRequest)
-			return
-		} else if settings.Allowance.ExpectedUpload == 0 {
-			settings.Allowance.ExpectedUpload = modules.DefaultAllowance.ExpectedUpload
+		if allowance.ExpectedUpload == 0 && expectedUploadSet {
+			return modules.Allowance{}, contractor.ErrAllowanceZeroExpectedUpload
+		} else if allowance.ExpectedUpload == 0 {
+			allowance.ExpectedUpload = modules.DefaultAllowance.ExpectedUpload
 		}
 
 		// If the user set ExpectedDownload to 0 return an error, otherwise if
 		// ExpectedDownload was not set by the user then set it to the sane
 		// default
-		if settings.Allowance.ExpectedDownload == 0 && expectedDownloadSet {
-			WriteError(w, Error{contractor.ErrAllowanceZeroExpectedDownload.Error()}, http.StatusBadRequest)
-			return
-		} else if settings.Allowance.ExpectedDownload == 0 {
-			settings.Allowance.ExpectedDownload = modules.DefaultAllowance.ExpectedDownload
+		if allowance.ExpectedDownload == 0 && expectedDownloadSet {
+			return modules.Allowance{}, contractor.ErrAllowanceZeroExpectedDownload
+		} else if allowance.ExpectedDownload == 0 {
+			allowance.ExpectedDownload = modules.DefaultAllowance.ExpectedDownload
 		}
 
 		// If the user set ExpectedRedundancy to 0 return an error, otherwise if
 		// ExpectedRedundancy was not set by the user then set it to the sane
 		// default
-		if settings.Allowance.ExpectedRedundancy == 0 && expectedRedundancySet {
-			WriteError(w, Error{contractor.ErrAllowanceZeroExpectedRedundancy.Error()}, http.StatusBadRequest)
-			return
-		} else if settings.Allowance.ExpectedRedundancy == 0 {
-			settings.Allowance.ExpectedRedundancy = modules.DefaultAllowance.ExpectedRedundancy
+		if allowance.ExpectedRedundancy == 0 && expectedRedundancySet {
+			return modules.Allowance{}, contractor.ErrAllowanceZeroExpectedRedundancy
+		} else if allowance.ExpectedRedundancy == 0 {
+			allowance.ExpectedRedundancy = modules.DefaultAllowance.ExpectedRedundancy
 		}
 
 		// If the user set MaxPeriodChurn to 0 return an error, otherwise if
 		// MaxPeriodChurn was not set by the user then set it to the sane
 		// default
-		if settings.Allowance.MaxPeriodChurn == 0 && maxPeriodChurnSet {
-			WriteError(w, Error{contractor.ErrAllowanceZeroMaxPeriodChurn.Error()}, http.StatusBadRequest)
-			return
-		} else if settings.Allowance.MaxPeriodChurn == 0 {
-			settings.Allowance.MaxPeriodChurn = modules.DefaultAllowance.MaxPeriodChurn
+		if allowance.MaxPeriodChurn == 0 && maxPeriodChurnSet {
+			return modules.Allowance{}, contractor.ErrAllowanceZeroMaxPeriodChurn
+		} else if allowance.MaxPeriodChurn == 0 {
+			allowance.MaxPeriodChurn = modules.DefaultAllowance.MaxPeriodChurn
 		}
 	}
+	return allowance, nil
+}
+
+// renterHandlerPOST handles the API call to set the Renter's settings. This API
+// call handles multiple settings and so each setting is optional on it's own.
+// Groups of settings, such as the allowance, have certain requirements if they
+// are being set in which case certain fields are no longer optional.
+func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Get the existing settings
+	settings, err := api.renter.Settings()
+	if err != nil {
+		WriteError(w, Error{"unable able to get renter settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	settings.Allowance, err = parseAllowance(req, settings.Allowance)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
 
 	// Scan the download speed limit. (optional parameter)
 	if d := req.FormValue("maxdownloadspeed"); d != "" {
@@ -945,6 +1031,16 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 		}
 		settings.MaxUploadSpeed = uploadSpeed
 	}
+	// Scan the bandwidth schedule. An empty schedule removes all windows.
+	// (optional parameter)
+	if _, ok := req.Form["bandwidthschedule"]; ok {
+		schedule, err := modules.ParseBandwidthSchedule(req.FormValue("bandwidthschedule"))
+		if err != nil {
+			WriteError(w, Error{"unable to parse bandwidthschedule: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.BandwidthSchedule = schedule
+	}
 
 	// Scan the checkforipviolation flag.
 	if ipc := req.FormValue("checkforipviolation"); ipc != "" {
@@ -956,6 +1052,23 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 		settings.IPViolationCheck = ipviolationcheck
 	}
 
+	// Scan the host diversity constraints. The region and country lists are
+	// replaced whenever they are provided, so an empty list clears them.
+	if p := req.FormValue("maxasnpercentage"); p != "" {
+		var maxASNPercentage float64
+		if _, err := fmt.Sscan(p, &maxASNPercentage); err != nil {
+			WriteError(w, Error{"unable to parse maxasnpercentage: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.HostDiversity.MaxASNPercentage = maxASNPercentage
+	}
+	if _, ok := req.Form["requiredregions"]; ok {
+		settings.HostDiversity.RequiredRegions = parseLocationCodes(req.FormValue("requiredregions"))
+	}
+	if _, ok := req.Form["excludedcountries"]; ok {
+		settings.HostDiversity.ExcludedCountries = parseLocationCodes(req.FormValue("excludedcountries"))
+	}
+
 	// Set the settings in the renter.
 	err = api.renter.SetSettings(settings)
 	if err != nil {
@@ -1261,6 +1374,281 @@ func (api *API) renterContractorChurnStatus(w http.ResponseWriter, _ *http.Reque
 	WriteJSON(w, api.renter.ContractorChurnStatus())
 }
 
+// budgetIdentity returns the identity of the caller of the request which is
+// used to look up its budget. Callers can identify themselves with an API token
+// which is passed as the username of the basic auth credentials. Usernames are
+// ignored for authentication. Callers without a token are identified by their
+// user agent. Callers without either share the default budget, just like
+// callers without a budget of their own.
+func budgetIdentity(req *http.Request) string {
+	if token, _, ok := req.BasicAuth(); ok && token != "" {
+		return token
+	}
+	if ua := req.UserAgent(); ua != "" {
+		return ua
+	}
+	return modules.DefaultBudgetIdentity
+}
+
+// budgetErrorStatus returns http.StatusPaymentRequired if err was caused by
+// an exhausted budget and status otherwise.
+func budgetErrorStatus(err error, status int) int {
+	if errors.Contains(err, modules.ErrBudgetExhausted) {
+		return http.StatusPaymentRequired
+	}
+	return status
+}
+
+// chargeBudget charges the cost of an operation transferring size bytes to
+// the budget of the caller. If the budget is exhausted or charging it fails, an
+// error is written to w and false is returned.
+func (api *API) chargeBudget(w http.ResponseWriter, req *http.Request, category modules.BudgetCategory, size uint64) bool {
+	err := api.renter.ChargeBudget(budgetIdentity(req), category, size)
+	if errors.Contains(err, modules.ErrBudgetExhausted) {
+		WriteError(w, Error{err.Error()}, http.StatusPaymentRequired)
+		return false
+	}
+	if err != nil {
+		WriteError(w, Error{"unable to charge budget: " + err.Error()}, http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+// renterBudgetsHandlerGET handles the API call to request the budgets of the
+// renter's API callers.
+func (api *API) renterBudgetsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	budgets, err := api.renter.Budgets()
+	if err != nil {
+		WriteError(w, Error{"unable to get budgets: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, RenterBudgetsGET{
+		Budgets: budgets,
+	})
+}
+
+// renterBudgetsHandlerPOST handles the API call to set or remove the budget of
+// an API caller. Limits are provided in hastings and a limit of 0 means that
+// the category is unlimited.
+func (api *API) renterBudgetsHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	identity := req.FormValue("identity")
+	if identity == "" {
+		WriteError(w, Error{modules.ErrEmptyBudgetIdentity.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Check whether the budget should be removed.
+	var remove bool
+	if r := req.FormValue("remove"); r != "" {
+		var err error
+		remove, err = scanBool(r)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'remove' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if remove {
+		if err := api.renter.RemoveBudget(identity); err != nil {
+			WriteError(w, Error{"unable to remove budget: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		WriteSuccess(w)
+		return
+	}
+
+	// Parse the limits.
+	budget := modules.RenterBudget{Identity: identity}
+	limits := []struct {
+		param string
+		limit *types.Currency
+	}{
+		{"upload", &budget.Upload},
+		{"download", &budget.Download},
+		{"registry", &budget.Registry},
+	}
+	for _, l := range limits {
+		str := req.FormValue(l.param)
+		if str == "" {
+			continue
+		}
+		limit, ok := scanAmount(str)
+		if !ok {
+			WriteError(w, Error{fmt.Sprintf("unable to parse '%v' parameter", l.param)}, http.StatusBadRequest)
+			return
+		}
+		*l.limit = limit
+	}
+	if err := api.renter.SetBudget(budget); err != nil {
+		WriteError(w, Error{"unable to set budget: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterRepairHandlerGET handles the API call to request the repair settings
+// and the repair progress of the renter.
+func (api *API) renterRepairHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	status, err := api.renter.RepairStatus()
+	if err != nil {
+		WriteError(w, Error{"unable to get repair status: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, RenterRepairGET{
+		RepairStatus: status,
+	})
+}
+
+// renterRepairHandlerPOST handles the API call to change the repair settings
+// of the renter. Settings which are not provided are left unchanged.
+func (api *API) renterRepairHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	status, err := api.renter.RepairStatus()
+	if err != nil {
+		WriteError(w, Error{"unable to get repair settings: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	settings := status.RepairSettings
+
+	limits := []struct {
+		param string
+		limit *uint64
+	}{
+		{"maxbytespersecond", &settings.MaxBytesPerSecond},
+		{"maxconcurrentchunks", &settings.MaxConcurrentChunks},
+	}
+	for _, l := range limits {
+		str := req.FormValue(l.param)
+		if str == "" {
+			continue
+		}
+		limit, err := strconv.ParseUint(str, 10, 64)
+		if err != nil {
+			WriteError(w, Error{fmt.Sprintf("unable to parse '%v' parameter: %v", l.param, err)}, http.StatusBadRequest)
+			return
+		}
+		*l.limit = limit
+	}
+	if r := req.FormValue("repaironly"); r != "" {
+		settings.RepairOnly, err = scanBool(r)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'repaironly' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if err := api.renter.SetRepairSettings(settings); err != nil {
+		WriteError(w, Error{"unable to set repair settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// parseSpendingHistoryFilter parses the filter for the spending ledger from
+// the request. Times are provided as unix timestamps.
+func parseSpendingHistoryFilter(req *http.Request) (filter modules.SpendingHistoryFilter, err error) {
+	filter.Types, err = modules.ParseSpendingTypes(req.FormValue("types"))
+	if err != nil {
+		return modules.SpendingHistoryFilter{}, errors.AddContext(err, "unable to parse 'types'")
+	}
+	if s := req.FormValue("host"); s != "" {
+		var hpk types.TurtleDexPublicKey
+		if err := hpk.LoadString(s); err != nil {
+			return modules.SpendingHistoryFilter{}, errors.AddContext(err, "unable to parse 'host'")
+		}
+		filter.HostPublicKey = &hpk
+	}
+	if s := req.FormValue("contractid"); s != "" {
+		var fcid types.FileContractID
+		if err := fcid.LoadString(s); err != nil {
+			return modules.SpendingHistoryFilter{}, errors.AddContext(err, "unable to parse 'contractid'")
+		}
+		filter.ContractID = &fcid
+	}
+	if s := req.FormValue("minheight"); s != "" {
+		if _, err := fmt.Sscan(s, &filter.MinHeight); err != nil {
+			return modules.SpendingHistoryFilter{}, errors.AddContext(err, "unable to parse 'minheight'")
+		}
+	}
+	if s := req.FormValue("maxheight"); s != "" {
+		if _, err := fmt.Sscan(s, &filter.MaxHeight); err != nil {
+			return modules.SpendingHistoryFilter{}, errors.AddContext(err, "unable to parse 'maxheight'")
+		}
+	}
+	if s := req.FormValue("start"); s != "" {
+		start, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return modules.SpendingHistoryFilter{}, errors.AddContext(err, "unable to parse 'start'")
+		}
+		filter.Start = time.Unix(start, 0)
+	}
+	if s := req.FormValue("end"); s != "" {
+		end, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return modules.SpendingHistoryFilter{}, errors.AddContext(err, "unable to parse 'end'")
+		}
+		filter.End = time.Unix(end, 0)
+	}
+	if s := req.FormValue("limit"); s != "" {
+		filter.Limit, err = strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return modules.SpendingHistoryFilter{}, errors.AddContext(err, "unable to parse 'limit'")
+		}
+	}
+	return filter, nil
+}
+
+// renterSpendingHistoryHandlerGET handles the API call to request the records
+// of the renter's spending ledger. The records are returned as JSON or, if
+// 'format' is 'csv', as CSV.
+func (api *API) renterSpendingHistoryHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	format := req.FormValue("format")
+	if format != "" && format != "json" && format != "csv" {
+		WriteError(w, Error{fmt.Sprintf("unknown format '%v', must be 'json' or 'csv'", format)}, http.StatusBadRequest)
+		return
+	}
+	filter, err := parseSpendingHistoryFilter(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	records, err := api.renter.SpendingHistory(filter)
+	if err != nil {
+		WriteError(w, Error{"unable to get spending history: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		if err := modules.WriteSpendingRecordsCSV(w, records); err != nil {
+			WriteError(w, Error{"unable to write spending history: " + err.Error()}, http.StatusInternalServerError)
+		}
+		return
+	}
+	WriteJSON(w, RenterSpendingHistoryGET{
+		Records: records,
+	})
+}
+
+// renterContractsPlanHandlerGET handles the API call to preview what contract
+// maintenance would do with the current allowance. The allowance fields of
+// /renter can be provided to preview an allowance change instead.
+func (api *API) renterContractsPlanHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	settings, err := api.renter.Settings()
+	if err != nil {
+		WriteError(w, Error{"unable able to get renter settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	allowance, err := parseAllowance(req, settings.Allowance)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	plan, err := api.renter.ContractMaintenancePlan(allowance)
+	if err != nil {
+		WriteError(w, Error{"unable to create contract maintenance plan: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, plan)
+}
+
 // renterDownloadsHandler handles the API call to request the download queue.
 func (api *API) renterDownloadsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	var downloads []DownloadInfo
@@ -1465,38 +1853,207 @@ func (api *API) renterRenameHandler(w http.ResponseWriter, req *http.Request, ps
 	WriteSuccess(w)
 }
 
-// renterFileHandler handles GET requests to the /renter/file/:siapath API endpoint.
-func (api *API) renterFileHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-	// Determine the siapath that the user wants to get the file from.
+// parseRenterTurtleDexPath parses the siapath of a request and rebases it to the
+// user folder unless the root flag is set.
+func parseRenterTurtleDexPath(req *http.Request, ps httprouter.Params) (modules.TurtleDexPath, error) {
 	siaPath, err := modules.NewTurtleDexPath(ps.ByName("siapath"))
+	if err != nil {
+		return modules.TurtleDexPath{}, err
+	}
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		return modules.TurtleDexPath{}, err
+	}
+	if root {
+		return siaPath, nil
+	}
+	return rebaseInputTurtleDexPath(siaPath)
+}
+
+// renterVersionsHandlerGET handles GET requests to the
+// /renter/fileversions/:siapath API endpoint.
+func (api *API) renterVersionsHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := parseRenterTurtleDexPath(req, ps)
 	if err != nil {
 		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
 		return
 	}
-
-	// Determine whether the user is requesting a user siapath, or a root siapath.
-	root, err := isCalledWithRootFlag(req)
+	versions, err := api.renter.FileVersions(siaPath)
 	if err != nil {
 		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
 		return
 	}
-	// Rebase the user's input to the user folder if the user is requesting a user siapath.
-	if !root {
-		siaPath, err = rebaseInputTurtleDexPath(siaPath)
-		if err != nil {
-			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
-			return
-		}
+	if versions == nil {
+		versions = []modules.FileVersionInfo{}
 	}
+	WriteJSON(w, RenterFileVersions{
+		Versions: versions,
+	})
+}
 
-	// Fetch the file.
-	file, err := api.renter.File(siaPath)
+// renterVersionsHandlerPOST handles POST requests to the
+// /renter/fileversions/:siapath API endpoint. It restores the version with the
+// provided id.
+func (api *API) renterVersionsHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := parseRenterTurtleDexPath(req, ps)
 	if err != nil {
 		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
 		return
 	}
-
-	// If the user requested the user siapath, trim the dir folder so that the
+	versionID := req.FormValue("versionid")
+	if versionID == "" {
+		WriteError(w, Error{"versionid must be set"}, http.StatusBadRequest)
+		return
+	}
+	err = api.renter.RestoreFileVersion(siaPath, versionID)
+	if errors.Contains(err, filesystem.ErrUnknownVersion) {
+		WriteError(w, Error{err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"failed to restore version: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterDirSnapshotsHandlerGET handles GET requests to the
+// /renter/dirsnapshots API endpoint.
+func (api *API) renterDirSnapshotsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	snapshots, err := api.renter.DirSnapshots()
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterDirSnapshotsGET{
+		Snapshots: snapshots,
+	})
+}
+
+// renterDirSnapshotsCreateHandlerPOST handles POST requests to the
+// /renter/dirsnapshots/create/:siapath API endpoint.
+func (api *API) renterDirSnapshotsCreateHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := parseRenterTurtleDexPath(req, ps)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	name := req.FormValue("name")
+	if name == "" {
+		WriteError(w, Error{"name not specified"}, http.StatusBadRequest)
+		return
+	}
+	snapshot, err := api.renter.CreateDirSnapshot(siaPath, name)
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		WriteError(w, Error{err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"failed to create directory snapshot: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, snapshot)
+}
+
+// renterDirSnapshotsDeleteHandlerPOST handles POST requests to the
+// /renter/dirsnapshots/delete API endpoint.
+func (api *API) renterDirSnapshotsDeleteHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	err := api.renter.DeleteDirSnapshot(req.FormValue("name"))
+	if errors.Contains(err, renter.ErrUnknownDirSnapshot) {
+		WriteError(w, Error{err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"failed to delete directory snapshot: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterDirSnapshotsDiffHandlerGET handles GET requests to the
+// /renter/dirsnapshots/diff API endpoint.
+func (api *API) renterDirSnapshotsDiffHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	diff, err := api.renter.DiffDirSnapshot(req.FormValue("name"))
+	if errors.Contains(err, renter.ErrUnknownDirSnapshot) {
+		WriteError(w, Error{err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"failed to diff directory snapshot: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, diff)
+}
+
+// renterDirSnapshotsRestoreHandlerPOST handles POST requests to the
+// /renter/dirsnapshots/restore/:siapath API endpoint. It restores the snapshot
+// into the new directory at siapath.
+func (api *API) renterDirSnapshotsRestoreHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := parseRenterTurtleDexPath(req, ps)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = api.renter.RestoreDirSnapshot(req.FormValue("name"), siaPath)
+	if errors.Contains(err, renter.ErrUnknownDirSnapshot) {
+		WriteError(w, Error{err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"failed to restore directory snapshot: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterDirSnapshotsUploadHandlerPOST handles POST requests to the
+// /renter/dirsnapshots/upload API endpoint. It uploads the snapshot to hosts
+// as a backup of the same name.
+func (api *API) renterDirSnapshotsUploadHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	err := api.renter.UploadDirSnapshot(req.FormValue("name"))
+	if errors.Contains(err, renter.ErrUnknownDirSnapshot) {
+		WriteError(w, Error{err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"failed to upload directory snapshot: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterFileHandler handles GET requests to the /renter/file/:siapath API endpoint.
+func (api *API) renterFileHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	// Determine the siapath that the user wants to get the file from.
+	siaPath, err := modules.NewTurtleDexPath(ps.ByName("siapath"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Determine whether the user is requesting a user siapath, or a root siapath.
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// Rebase the user's input to the user folder if the user is requesting a user siapath.
+	if !root {
+		siaPath, err = rebaseInputTurtleDexPath(siaPath)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Fetch the file.
+	file, err := api.renter.File(siaPath)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// If the user requested the user siapath, trim the dir folder so that the
 	// output is all centered around the user's folder.
 	if !root {
 		files, err := trimTurtleDexDirFolderOnFiles(file)
@@ -1516,6 +2073,12 @@ func (api *API) renterFileHandlerGET(w http.ResponseWriter, req *http.Request, p
 func (api *API) renterFileHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	newTrackingPath := req.FormValue("trackingpath")
 	stuck := req.FormValue("stuck")
+	setMetadata, err := parseUserMetadata(req.FormValue("usermetadata"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse 'usermetadata' arg: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	removeMetadata := parseUserMetadataKeys(req.FormValue("removeusermetadata"))
 	root, err := scanBool(req.FormValue("root"))
 	if err != nil {
 		WriteError(w, Error{"unable to parse root flag: " + err.Error()}, http.StatusBadRequest)
@@ -1552,6 +2115,13 @@ func (api *API) renterFileHandlerPOST(w http.ResponseWriter, req *http.Request,
 			return
 		}
 	}
+	// Handle changing the user metadata of a file.
+	if len(setMetadata) > 0 || len(removeMetadata) > 0 {
+		if err := api.renter.SetFileUserMetadata(siaPath, setMetadata, removeMetadata); err != nil {
+			WriteError(w, Error{"failed to change user metadata: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
 	WriteSuccess(w)
 }
 
@@ -1566,9 +2136,17 @@ func (api *API) renterFilesHandler(w http.ResponseWriter, req *http.Request, _ h
 			return
 		}
 	}
+	filter, err := parseUserMetadata(req.FormValue("usermetadata"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse 'usermetadata' arg: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
 	var files []modules.FileInfo
 	var mu sync.Mutex
 	err = api.renter.FileList(modules.UserFolder, true, c, func(fi modules.FileInfo) {
+		if !modules.MatchesUserMetadata(fi.UserMetadata, filter) {
+			return
+		}
 		mu.Lock()
 		files = append(files, fi)
 		mu.Unlock()
@@ -1591,6 +2169,108 @@ func (api *API) renterFilesHandler(w http.ResponseWriter, req *http.Request, _ h
 	})
 }
 
+// parseFileSearchParams parses the filters of a search from the request. Only
+// the filters that are provided are set.
+func parseFileSearchParams(req *http.Request) (params modules.FileSearchParams, err error) {
+	params.Glob = req.FormValue("glob")
+	params.Prefix = req.FormValue("prefix")
+	params.Substring = req.FormValue("substring")
+	if s := req.FormValue("minsize"); s != "" {
+		params.MinSize, err = strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return modules.FileSearchParams{}, errors.AddContext(err, "unable to parse 'minsize'")
+		}
+	}
+	if s := req.FormValue("maxsize"); s != "" {
+		params.MaxSize, err = strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return modules.FileSearchParams{}, errors.AddContext(err, "unable to parse 'maxsize'")
+		}
+	}
+	if s := req.FormValue("minhealth"); s != "" {
+		minHealth, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return modules.FileSearchParams{}, errors.AddContext(err, "unable to parse 'minhealth'")
+		}
+		params.MinHealth = &minHealth
+	}
+	if s := req.FormValue("maxhealth"); s != "" {
+		maxHealth, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return modules.FileSearchParams{}, errors.AddContext(err, "unable to parse 'maxhealth'")
+		}
+		params.MaxHealth = &maxHealth
+	}
+	if s := req.FormValue("hasskylink"); s != "" {
+		hasSkylink, err := scanBool(s)
+		if err != nil {
+			return modules.FileSearchParams{}, errors.AddContext(err, "unable to parse 'hasskylink'")
+		}
+		params.HasSkylink = &hasSkylink
+	}
+	if s := req.FormValue("limit"); s != "" {
+		params.Limit, err = strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return modules.FileSearchParams{}, errors.AddContext(err, "unable to parse 'limit'")
+		}
+	}
+	return params, modules.ValidateFileSearchParams(params)
+}
+
+// renterSearchHandlerGET handles the API call to search for files using the
+// renter's search index.
+func (api *API) renterSearchHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse the 'root' parameter.
+	var root bool
+	var err error
+	if r := req.FormValue("root"); r != "" {
+		root, err = scanBool(r)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'root' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	// Parse the siapath of the dir to search. It defaults to the root.
+	siaPath := modules.RootTurtleDexPath()
+	if s := req.FormValue("siapath"); s != "" {
+		err = siaPath.LoadString(s)
+		if err != nil {
+			WriteError(w, Error{"unable to parse siapath: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if !root {
+		siaPath, err = rebaseInputTurtleDexPath(siaPath)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	params, err := parseFileSearchParams(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	files, err := api.renter.SearchFiles(siaPath, params)
+	if err != nil {
+		WriteError(w, Error{"unable to search files: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if !root {
+		for i := range files {
+			files[i].TurtleDexPath, err = files[i].TurtleDexPath.Rebase(modules.UserFolder, modules.RootTurtleDexPath())
+			if err != nil {
+				WriteError(w, Error{err.Error()}, http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+	WriteJSON(w, RenterSearchGET{
+		Files: files,
+	})
+}
+
 // renterPricesHandler reports the expected costs of various actions given the
 // renter settings and the set of available hosts.
 func (api *API) renterPricesHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
@@ -1755,7 +2435,7 @@ func (api *API) renterDownloadHandler(w http.ResponseWriter, req *http.Request,
 		id, start, err = api.renter.Download(params)
 	}
 	if err != nil {
-		WriteError(w, Error{"download creation failed: " + err.Error()}, http.StatusInternalServerError)
+		WriteError(w, Error{"download creation failed: " + err.Error()}, budgetErrorStatus(err, http.StatusInternalServerError))
 		return
 	}
 	// Set ID before starting download.
@@ -1860,13 +2540,20 @@ func parseDownloadParameters(w http.ResponseWriter, req *http.Request, ps httpro
 		}
 	}
 
+	priority, err := scanDownloadPriority(req.FormValue("priority"))
+	if err != nil {
+		return modules.RenterDownloadParameters{}, errors.AddContext(err, "error parsing the priority")
+	}
+
 	dp := modules.RenterDownloadParameters{
 		Destination:      destination,
 		DisableDiskFetch: disableLocalFetch,
+		Priority:         priority,
 		Async:            async,
 		Length:           length,
 		Offset:           offset,
 		TurtleDexPath:          siaPath,
+		BudgetIdentity:   budgetIdentity(req),
 	}
 	if httpresp {
 		dp.Httpwriter = w
@@ -1905,7 +2592,13 @@ func (api *API) renterStreamHandler(w http.ResponseWriter, req *http.Request, ps
 			return
 		}
 	}
-	fileName, streamer, err := api.renter.Streamer(siaPath, disableLocalFetch)
+	priority, err := scanDownloadPriority(req.FormValue("priority"))
+	if err != nil {
+		err = errors.AddContext(err, "error parsing the priority")
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	fileName, streamer, err := api.renter.Streamer(siaPath, disableLocalFetch, priority, budgetIdentity(req))
 	if err != nil {
 		WriteError(w, Error{fmt.Sprintf("failed to create download streamer: %v", err)},
 			http.StatusInternalServerError)
@@ -1942,6 +2635,12 @@ func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps
 		WriteError(w, Error{"unable to parse erasure code settings: " + err.Error()}, http.StatusBadRequest)
 		return
 	}
+	// Parse the user metadata.
+	userMetadata, err := parseUserMetadata(req.FormValue("usermetadata"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse 'usermetadata' parameter: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
 
 	// Call the renter to upload the file.
 	siaPath, err := modules.NewTurtleDexPath(ps.ByName("siapath"))
@@ -1960,12 +2659,14 @@ func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps
 		ErasureCode:         ec,
 		Force:               force,
 		DisablePartialChunk: true, // TODO: remove this
+		UserMetadata:        userMetadata,
+		BudgetIdentity:      budgetIdentity(req),
 
 		// NOTE: can make this an optional param.
 		CipherType: crypto.TypeDefaultRenter,
 	})
 	if err != nil {
-		WriteError(w, Error{"upload failed: " + err.Error()}, http.StatusInternalServerError)
+		WriteError(w, Error{"upload failed: " + err.Error()}, budgetErrorStatus(err, http.StatusInternalServerError))
 		return
 	}
 	WriteSuccess(w)
@@ -2036,6 +2737,39 @@ func (api *API) renterUploadsResumeHandler(w http.ResponseWriter, _ *http.Reques
 	WriteSuccess(w)
 }
 
+// renterRedundancyMigrationPauseHandler handles the api call to pause the
+// migration of files to the erasure coding settings of their redundancy policy
+func (api *API) renterRedundancyMigrationPauseHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	durationStr := req.FormValue("duration")
+	duration := renter.DefaultPauseDuration
+	if durationStr != "" {
+		durationInt, err := strconv.ParseUint(durationStr, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"failed to parse duration: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		duration = time.Second * time.Duration(durationInt)
+	}
+
+	err := api.renter.PauseRedundancyMigration(duration)
+	if err != nil {
+		WriteError(w, Error{"failed to pause redundancy migration: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterRedundancyMigrationResumeHandler handles the api call to resume the
+// migration of files to the erasure coding settings of their redundancy policy
+func (api *API) renterRedundancyMigrationResumeHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	err := api.renter.ResumeRedundancyMigration()
+	if err != nil {
+		WriteError(w, Error{"failed to resume redundancy migration: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // renterUploadStreamHandler handles the API call to upload a file using a
 // stream.
 func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
@@ -2073,6 +2807,12 @@ func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Reque
 		WriteError(w, Error{"can't provide erasure code settings when doing a repair"}, http.StatusBadRequest)
 		return
 	}
+	// Parse the user metadata.
+	userMetadata, err := parseUserMetadata(queryForm.Get("usermetadata"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse 'usermetadata' parameter: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
 
 	// Call the renter to upload the file.
 	siaPath, err := modules.NewTurtleDexPath(ps.ByName("siapath"))
@@ -2090,13 +2830,136 @@ func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Reque
 		ErasureCode: ec,
 		Force:       force,
 		Repair:      repair,
+		UserMetadata: userMetadata,
 
 		// NOTE: can make this an optional param.
 		CipherType: crypto.TypeDefaultRenter,
 	}
+	if !repair {
+		up.BudgetIdentity = budgetIdentity(req)
+	}
 	err = api.renter.UploadStreamFromReader(up, req.Body)
 	if err != nil {
-		WriteError(w, Error{"upload failed: " + err.Error()}, http.StatusInternalServerError)
+		WriteError(w, Error{"upload failed: " + err.Error()}, budgetErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterUploadSessionsHandlerPOST handles the API call to create a resumable
+// upload session for a new file.
+func (api *API) renterUploadSessionsHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	// Parse the query params.
+	queryForm, err := url.ParseQuery(req.URL.RawQuery)
+	if err != nil {
+		WriteError(w, Error{"failed to parse query params"}, http.StatusBadRequest)
+		return
+	}
+	// Check whether existing file should be overwritten
+	force := false
+	if f := queryForm.Get("force"); f != "" {
+		force, err = strconv.ParseBool(f)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'force' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	// Parse the erasure coder.
+	ec, err := parseErasureCodingParameters(queryForm.Get("datapieces"), queryForm.Get("paritypieces"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse erasure code settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// Parse the user metadata.
+	userMetadata, err := parseUserMetadata(queryForm.Get("usermetadata"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse 'usermetadata' parameter: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	siaPath, err := parseRenterTurtleDexPath(req, ps)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	up := modules.FileUploadParams{
+		TurtleDexPath:  siaPath,
+		ErasureCode:    ec,
+		Force:          force,
+		CipherType:     crypto.TypeDefaultRenter,
+		UserMetadata:   userMetadata,
+		BudgetIdentity: budgetIdentity(req),
+	}
+	info, err := api.renter.CreateUploadSession(up)
+	if err != nil {
+		WriteError(w, Error{"failed to create upload session: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, info)
+}
+
+// renterUploadSessionHandlerGET handles the API call to query a resumable
+// upload session.
+func (api *API) renterUploadSessionHandlerGET(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	info, err := api.renter.UploadSession(ps.ByName("id"))
+	if errors.Contains(err, renter.ErrUnknownUploadSession) {
+		WriteError(w, Error{err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"failed to get upload session: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, info)
+}
+
+// renterUploadSessionHandlerPOST handles the API calls to write data to,
+// finalize or abort a resumable upload session.
+func (api *API) renterUploadSessionHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	// Parse the query params. The body can't be parsed as a form since it
+	// contains the uploaded data.
+	queryForm, err := url.ParseQuery(req.URL.RawQuery)
+	if err != nil {
+		WriteError(w, Error{"failed to parse query params"}, http.StatusBadRequest)
+		return
+	}
+	id := ps.ByName("id")
+	action := queryForm.Get("action")
+	switch action {
+	case "write":
+		offset, err := strconv.ParseUint(queryForm.Get("offset"), 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'offset' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		info, err := api.renter.UploadSessionWrite(id, offset, req.Body)
+		if errors.Contains(err, renter.ErrUnknownUploadSession) {
+			WriteError(w, Error{err.Error()}, http.StatusNotFound)
+			return
+		}
+		if errors.Contains(err, renter.ErrUploadSessionOffset) || errors.Contains(err, renter.ErrUploadSessionBusy) {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			WriteError(w, Error{"failed to write to upload session: " + err.Error()}, budgetErrorStatus(err, http.StatusInternalServerError))
+			return
+		}
+		WriteJSON(w, info)
+		return
+	case "finalize":
+		err = api.renter.FinalizeUploadSession(id)
+	case "abort":
+		err = api.renter.AbortUploadSession(id)
+	default:
+		WriteError(w, Error{"unknown action: " + action}, http.StatusBadRequest)
+		return
+	}
+	if errors.Contains(err, renter.ErrUnknownUploadSession) {
+		WriteError(w, Error{err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("failed to %v upload session: %v", action, err)}, budgetErrorStatus(err, http.StatusInternalServerError))
 		return
 	}
 	WriteSuccess(w)
@@ -2264,6 +3127,69 @@ func (api *API) renterDirHandlerPOST(w http.ResponseWriter, req *http.Request, p
 		return
 	}
 
+	if action == "setquota" {
+		quota, err := strconv.ParseUint(req.FormValue("quota"), 10, 64)
+		if err != nil {
+			WriteError(w, Error{"failed to parse quota: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		redundantQuota, err := strconv.ParseUint(req.FormValue("redundantquota"), 10, 64)
+		if err != nil {
+			WriteError(w, Error{"failed to parse redundantquota: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		err = api.renter.SetDirQuota(siaPath, quota, redundantQuota)
+		if err != nil {
+			WriteError(w, Error{"failed to set quota: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		WriteSuccess(w)
+		return
+	}
+	if action == "setversionretention" {
+		retention, err := strconv.ParseInt(req.FormValue("versionretention"), 10, 64)
+		if err != nil {
+			WriteError(w, Error{"failed to parse versionretention: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		err = api.renter.SetDirVersionRetention(siaPath, retention)
+		if err != nil {
+			WriteError(w, Error{"failed to set version retention: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		WriteSuccess(w)
+		return
+	}
+	if action == "setusermetadata" {
+		setMetadata, err := parseUserMetadata(req.FormValue("usermetadata"))
+		if err != nil {
+			WriteError(w, Error{"failed to parse usermetadata: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		removeMetadata := parseUserMetadataKeys(req.FormValue("removeusermetadata"))
+		err = api.renter.SetDirUserMetadata(siaPath, setMetadata, removeMetadata)
+		if err != nil {
+			WriteError(w, Error{"failed to set user metadata: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		WriteSuccess(w)
+		return
+	}
+	if action == "setredundancypolicy" {
+		policy, err := parseRedundancyPolicy(req.FormValue("redundancypolicy"))
+		if err != nil {
+			WriteError(w, Error{"failed to parse redundancypolicy: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		err = api.renter.SetDirRedundancyPolicy(siaPath, policy)
+		if err != nil {
+			WriteError(w, Error{"failed to set redundancy policy: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		WriteSuccess(w)
+		return
+	}
+
 	// Report that no calls were made
 	WriteError(w, Error{"no calls were made, please check your submission and try again"}, http.StatusInternalServerError)
 	return
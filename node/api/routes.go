@@ -94,13 +94,19 @@ func (api *API) buildHTTPRoutes() {
 		router.GET("/host/contracts", api.hostContractInfoHandler)                                // Get info about contracts.
 		router.GET("/host/estimatescore", api.hostEstimateScoreGET)
 		router.GET("/host/bandwidth", api.hostBandwidthHandlerGET)
+		router.GET("/host/financials", api.hostFinancialsHandlerGET)
+		router.GET("/host/pricing", api.hostPricingHandlerGET)
+		router.POST("/host/pricing", RequirePassword(api.hostPricingHandlerPOST, requiredPassword))
 
 		// Calls pertaining to the storage manager that the host uses.
 		router.GET("/host/storage", api.storageHandler)
 		router.POST("/host/storage/folders/add", RequirePassword(api.storageFoldersAddHandler, requiredPassword))
 		router.POST("/host/storage/folders/remove", RequirePassword(api.storageFoldersRemoveHandler, requiredPassword))
 		router.POST("/host/storage/folders/resize", RequirePassword(api.storageFoldersResizeHandler, requiredPassword))
+		router.POST("/host/storage/folders/tier", RequirePassword(api.storageFoldersTierHandler, requiredPassword))
 		router.POST("/host/storage/sectors/delete/:merkleroot", RequirePassword(api.storageSectorsDeleteHandler, requiredPassword))
+		router.GET("/host/storage/scrub", api.storageScrubHandlerGET)
+		router.POST("/host/storage/scrub", RequirePassword(api.storageScrubHandlerPOST, requiredPassword))
 	}
 
 	// Miner API Calls
@@ -126,10 +132,17 @@ func (api *API) buildHTTPRoutes() {
 		router.POST("/renter/contract/cancel", RequirePassword(api.renterContractCancelHandler, requiredPassword))
 		router.GET("/renter/contracts", api.renterContractsHandler)
 		router.GET("/renter/contractorchurnstatus", api.renterContractorChurnStatus)
+		router.GET("/renter/contracts/plan", api.renterContractsPlanHandlerGET)
+		router.GET("/renter/spending/history", api.renterSpendingHistoryHandlerGET)
+		router.GET("/renter/budgets", api.renterBudgetsHandlerGET)
+		router.POST("/renter/budgets", RequirePassword(api.renterBudgetsHandlerPOST, requiredPassword))
+		router.GET("/renter/repair", api.renterRepairHandlerGET)
+		router.POST("/renter/repair", RequirePassword(api.renterRepairHandlerPOST, requiredPassword))
 		router.GET("/renter/downloadinfo/*uid", api.renterDownloadByUIDHandlerGET)
 		router.GET("/renter/downloads", api.renterDownloadsHandler)
 		router.POST("/renter/downloads/clear", RequirePassword(api.renterClearDownloadsHandler, requiredPassword))
 		router.GET("/renter/files", api.renterFilesHandler)
+		router.GET("/renter/search", api.renterSearchHandlerGET)
 		router.GET("/renter/file/*siapath", api.renterFileHandlerGET)
 		router.POST("/renter/file/*siapath", RequirePassword(api.renterFileHandlerPOST, requiredPassword))
 		router.GET("/renter/prices", api.renterPricesHandler)
@@ -147,9 +160,22 @@ func (api *API) buildHTTPRoutes() {
 		router.GET("/renter/stream/*siapath", api.renterStreamHandler)
 		router.POST("/renter/upload/*siapath", RequirePassword(api.renterUploadHandler, requiredPassword))
 		router.GET("/renter/uploadready", api.renterUploadReadyHandler)
+		router.GET("/renter/fileversions/*siapath", api.renterVersionsHandlerGET)
+		router.GET("/renter/dirsnapshots", api.renterDirSnapshotsHandlerGET)
+		router.POST("/renter/dirsnapshots/create/*siapath", RequirePassword(api.renterDirSnapshotsCreateHandlerPOST, requiredPassword))
+		router.POST("/renter/dirsnapshots/delete", RequirePassword(api.renterDirSnapshotsDeleteHandlerPOST, requiredPassword))
+		router.GET("/renter/dirsnapshots/diff", api.renterDirSnapshotsDiffHandlerGET)
+		router.POST("/renter/dirsnapshots/restore/*siapath", RequirePassword(api.renterDirSnapshotsRestoreHandlerPOST, requiredPassword))
+		router.POST("/renter/dirsnapshots/upload", RequirePassword(api.renterDirSnapshotsUploadHandlerPOST, requiredPassword))
+		router.POST("/renter/fileversions/*siapath", RequirePassword(api.renterVersionsHandlerPOST, requiredPassword))
 		router.POST("/renter/uploads/pause", RequirePassword(api.renterUploadsPauseHandler, requiredPassword))
 		router.POST("/renter/uploads/resume", RequirePassword(api.renterUploadsResumeHandler, requiredPassword))
+		router.POST("/renter/redundancymigration/pause", RequirePassword(api.renterRedundancyMigrationPauseHandler, requiredPassword))
+		router.POST("/renter/redundancymigration/resume", RequirePassword(api.renterRedundancyMigrationResumeHandler, requiredPassword))
 		router.POST("/renter/uploadstream/*siapath", RequirePassword(api.renterUploadStreamHandler, requiredPassword))
+		router.GET("/renter/uploadsession/:id", api.renterUploadSessionHandlerGET)
+		router.POST("/renter/uploadsession/:id", RequirePassword(api.renterUploadSessionHandlerPOST, requiredPassword))
+		router.POST("/renter/uploadsessions/*siapath", RequirePassword(api.renterUploadSessionsHandlerPOST, requiredPassword))
 		router.POST("/renter/validatesiapath/*siapath", RequirePassword(api.renterValidateTurtleDexPathHandler, requiredPassword))
 		router.GET("/renter/workers", api.renterWorkersHandler)
 
@@ -183,8 +209,13 @@ func (api *API) buildHTTPRoutes() {
 		router.GET("/hostdb/active", api.hostdbActiveHandler)
 		router.GET("/hostdb/all", api.hostdbAllHandler)
 		router.GET("/hostdb/hosts/:pubkey", api.hostdbHostsHandler)
+		router.POST("/hostdb/benchmarking", RequirePassword(api.hostdbBenchmarkingHandlerPOST, requiredPassword))
+		router.GET("/hostdb/groups", api.hostdbGroupsHandlerGET)
+		router.POST("/hostdb/groups", RequirePassword(api.hostdbGroupsHandlerPOST, requiredPassword))
 		router.GET("/hostdb/filtermode", api.hostdbFilterModeHandlerGET)
 		router.POST("/hostdb/filtermode", RequirePassword(api.hostdbFilterModeHandlerPOST, requiredPassword))
+		router.GET("/hostdb/scoringpolicy", api.hostdbScoringPolicyHandlerGET)
+		router.POST("/hostdb/scoringpolicy", RequirePassword(api.hostdbScoringPolicyHandlerPOST, requiredPassword))
 
 		// Renter watchdog endpoints.
 		router.GET("/renter/contractstatus", api.renterContractStatusHandler)
@@ -0,0 +1,481 @@
+package s3gateway
+
+// The gateway authenticates requests using AWS Signature Version 4. Both the
+// Authorization header and presigned urls are supported. The payload of a
+// request is either unsigned, signed as a whole by its SHA256 hash or signed
+// chunk by chunk using the aws-chunked content encoding.
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// signV4Algorithm is the algorithm used to sign requests.
+	signV4Algorithm = "AWS4-HMAC-SHA256"
+
+	// signV4ChunkAlgorithm is the algorithm used to sign the chunks of a
+	// streaming payload.
+	signV4ChunkAlgorithm = "AWS4-HMAC-SHA256-PAYLOAD"
+
+	// emptySHA256 is the hex encoded SHA256 hash of an empty payload.
+	emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	// streamingPayload is the payload hash of a request that uses the
+	// aws-chunked content encoding.
+	streamingPayload = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+
+	// unsignedPayload is the payload hash of a request with an unsigned
+	// payload.
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+
+	// iso8601Format is the format of the request date.
+	iso8601Format = "20060102T150405Z"
+
+	// scopeDateFormat is the format of the date within the credential scope.
+	scopeDateFormat = "20060102"
+
+	// scopeService is the service within the credential scope.
+	scopeService = "s3"
+
+	// scopeTerminator is the last element of the credential scope.
+	scopeTerminator = "aws4_request"
+
+	// maxChunkSize is the maximum size of a single chunk of a streaming
+	// payload.
+	maxChunkSize = 1 << 24
+
+	// maxClockSkew is the maximum difference between the date of a request
+	// and the gateway's clock.
+	maxClockSkew = 15 * time.Minute
+
+	// maxPresignExpiry is the maximum time a presigned url can be valid for.
+	maxPresignExpiry = 7 * 24 * time.Hour
+)
+
+const (
+	headerAmzContentSHA256        = "X-Amz-Content-Sha256"
+	headerAmzDate                 = "X-Amz-Date"
+	headerAmzDecodedContentLength = "X-Amz-Decoded-Content-Length"
+	headerAuthorization           = "Authorization"
+)
+
+type (
+	// signatureV4 contains the information of a request's signature.
+	signatureV4 struct {
+		accessKeyID   string
+		date          time.Time
+		expires       time.Duration
+		payloadHash   string
+		presigned     bool
+		region        string
+		signature     string
+		signedHeaders []string
+	}
+
+	// chunkedReader decodes a payload that uses the aws-chunked content
+	// encoding and verifies the signature of every chunk.
+	chunkedReader struct {
+		body          io.ReadCloser
+		r             *bufio.Reader
+		decodedLength int64
+		read          int64
+
+		date          string
+		prevSignature string
+		scope         string
+		signingKey    []byte
+
+		buf []byte
+		err error
+	}
+
+	// payloadVerifier verifies the SHA256 hash of a payload once it was read
+	// completely.
+	payloadVerifier struct {
+		io.ReadCloser
+		expected []byte
+		h        hash.Hash
+	}
+)
+
+// SignRequest signs a request with the provided credentials using AWS
+// Signature Version 4. The payload of the request is not signed.
+func SignRequest(req *http.Request, creds Credentials, region string) {
+	sig := signatureV4{
+		accessKeyID:   creds.AccessKeyID,
+		date:          time.Now().UTC(),
+		payloadHash:   unsignedPayload,
+		region:        region,
+		signedHeaders: []string{"host", "x-amz-content-sha256", "x-amz-date"},
+	}
+	req.Header.Set(headerAmzContentSHA256, sig.payloadHash)
+	req.Header.Set(headerAmzDate, sig.date.Format(iso8601Format))
+	sig.signature = computeSignature(req, sig, creds.SecretAccessKey)
+	req.Header.Set(headerAuthorization, fmt.Sprintf("%v Credential=%v/%v, SignedHeaders=%v, Signature=%v",
+		signV4Algorithm, sig.accessKeyID, sig.scope(), strings.Join(sig.signedHeaders, ";"), sig.signature))
+}
+
+// scope returns the credential scope of the signature.
+func (sig signatureV4) scope() string {
+	return strings.Join([]string{sig.date.Format(scopeDateFormat), sig.region, scopeService, scopeTerminator}, "/")
+}
+
+// Close implements io.Closer.
+func (cr *chunkedReader) Close() error {
+	return cr.body.Close()
+}
+
+// Read implements io.Reader.
+func (cr *chunkedReader) Read(p []byte) (int, error) {
+	for len(cr.buf) == 0 {
+		if cr.err != nil {
+			return 0, cr.err
+		}
+		cr.err = cr.readChunk()
+	}
+	n := copy(p, cr.buf)
+	cr.buf = cr.buf[n:]
+	return n, nil
+}
+
+// readChunk reads the next chunk of the payload and verifies its signature.
+// io.EOF is returned after the final chunk was read.
+func (cr *chunkedReader) readChunk() error {
+	// Every chunk starts with a line of the form
+	// "<hex size>;chunk-signature=<signature>\r\n".
+	line, err := cr.r.ReadSlice('\n')
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return errIncompleteBody
+	} else if err != nil {
+		return errInvalidArgument
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return errInvalidArgument
+	}
+	header := strings.SplitN(string(line[:len(line)-2]), ";chunk-signature=", 2)
+	if len(header) != 2 {
+		return errInvalidArgument
+	}
+	size, err := strconv.ParseUint(header[0], 16, 64)
+	if err != nil || size > maxChunkSize {
+		return errInvalidArgument
+	}
+	// The data of the chunk is followed by "\r\n".
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(cr.r, data); err != nil {
+		return errIncompleteBody
+	}
+	if !bytes.HasSuffix(data, []byte("\r\n")) {
+		return errInvalidArgument
+	}
+	data = data[:size]
+
+	// Every chunk's signature depends on the signature of the previous chunk.
+	// The first chunk's signature depends on the signature of the request.
+	dataHash := sha256.Sum256(data)
+	stringToSign := strings.Join([]string{
+		signV4ChunkAlgorithm,
+		cr.date,
+		cr.scope,
+		cr.prevSignature,
+		emptySHA256,
+		hex.EncodeToString(dataHash[:]),
+	}, "\n")
+	signature := hex.EncodeToString(hmacSHA256(cr.signingKey, stringToSign))
+	if !hmac.Equal([]byte(signature), []byte(header[1])) {
+		return errSignatureDoesNotMatch
+	}
+	cr.prevSignature = signature
+
+	// A chunk of size 0 marks the end of the payload.
+	cr.read += int64(size)
+	if size == 0 {
+		if cr.read != cr.decodedLength {
+			return errIncompleteBody
+		}
+		return io.EOF
+	}
+	cr.buf = data
+	return nil
+}
+
+// Read implements io.Reader.
+func (pv *payloadVerifier) Read(p []byte) (int, error) {
+	n, err := pv.ReadCloser.Read(p)
+	pv.h.Write(p[:n])
+	if err == io.EOF && !bytes.Equal(pv.h.Sum(nil), pv.expected) {
+		return n, errXAmzContentSHA256Mismatch
+	}
+	return n, err
+}
+
+// authenticate verifies the signature of a request and returns the access key
+// ID it was signed with. If the payload of the request is signed, its body is
+// replaced with a reader that verifies the payload while it is read.
+func (g *Gateway) authenticate(req *http.Request) (string, error) {
+	sig, err := parseSignature(req)
+	if err != nil {
+		return "", err
+	}
+	secret, exists := g.staticCredentials[sig.accessKeyID]
+	if !exists {
+		return "", errInvalidAccessKeyID
+	}
+
+	// Check the date of the request.
+	now := time.Now()
+	if sig.presigned && now.After(sig.date.Add(sig.expires)) {
+		return "", errExpiredPresignRequest
+	} else if !sig.presigned && (now.Sub(sig.date) > maxClockSkew || sig.date.Sub(now) > maxClockSkew) {
+		return "", errRequestTimeTooSkewed
+	}
+
+	// Check the signature.
+	signature := computeSignature(req, sig, secret)
+	if !hmac.Equal([]byte(signature), []byte(sig.signature)) {
+		return "", errSignatureDoesNotMatch
+	}
+
+	// Verify the payload.
+	switch sig.payloadHash {
+	case unsignedPayload:
+	case streamingPayload:
+		decodedLength, err := strconv.ParseInt(req.Header.Get(headerAmzDecodedContentLength), 10, 64)
+		if err != nil || decodedLength < 0 {
+			return "", errInvalidArgument
+		}
+		req.Body = &chunkedReader{
+			body:          req.Body,
+			r:             bufio.NewReader(req.Body),
+			decodedLength: decodedLength,
+			date:          sig.date.Format(iso8601Format),
+			prevSignature: sig.signature,
+			scope:         sig.scope(),
+			signingKey:    signingKey(secret, sig.date, sig.region),
+		}
+		req.ContentLength = decodedLength
+	default:
+		expected, err := hex.DecodeString(sig.payloadHash)
+		if err != nil || len(expected) != sha256.Size {
+			return "", errInvalidArgument
+		}
+		req.Body = &payloadVerifier{
+			ReadCloser: req.Body,
+			expected:   expected,
+			h:          sha256.New(),
+		}
+	}
+	return sig.accessKeyID, nil
+}
+
+// canonicalHeaderValue returns the canonical value of the header with the
+// provided lowercase name.
+func canonicalHeaderValue(req *http.Request, name string) string {
+	var values []string
+	switch name {
+	case "host":
+		values = []string{req.Host}
+		if req.Host == "" {
+			values = []string{req.URL.Host}
+		}
+	case "content-length":
+		values = req.Header["Content-Length"]
+		if len(values) == 0 && req.ContentLength >= 0 {
+			values = []string{strconv.FormatInt(req.ContentLength, 10)}
+		}
+	default:
+		values = req.Header[http.CanonicalHeaderKey(name)]
+	}
+	// Trim the values and collapse sequential spaces.
+	canonicalValues := make([]string, 0, len(values))
+	for _, v := range values {
+		canonicalValues = append(canonicalValues, strings.Join(strings.Fields(v), " "))
+	}
+	return strings.Join(canonicalValues, ",")
+}
+
+// canonicalRequest returns the canonical form of a request which is signed by
+// the client.
+func canonicalRequest(req *http.Request, sig signatureV4) string {
+	// Sort the query parameters by key and value. The signature of a presigned
+	// url is not part of the canonical request.
+	query := req.URL.Query()
+	if sig.presigned {
+		query.Del("X-Amz-Signature")
+	}
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var params []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			params = append(params, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+
+	// Every signed header is followed by a newline.
+	var headers strings.Builder
+	for _, name := range sig.signedHeaders {
+		headers.WriteString(name + ":" + canonicalHeaderValue(req, name) + "\n")
+	}
+
+	path := req.URL.Path
+	if path == "" {
+		path = "/"
+	}
+	return strings.Join([]string{
+		req.Method,
+		uriEncode(path, false),
+		strings.Join(params, "&"),
+		headers.String(),
+		strings.Join(sig.signedHeaders, ";"),
+		sig.payloadHash,
+	}, "\n")
+}
+
+// computeSignature computes the hex encoded signature of a request.
+func computeSignature(req *http.Request, sig signatureV4, secret string) string {
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest(req, sig)))
+	stringToSign := strings.Join([]string{
+		signV4Algorithm,
+		sig.date.Format(iso8601Format),
+		sig.scope(),
+		hex.EncodeToString(canonicalHash[:]),
+	}, "\n")
+	return hex.EncodeToString(hmacSHA256(signingKey(secret, sig.date, sig.region), stringToSign))
+}
+
+// containsString returns whether the slice contains the provided string.
+func containsString(s []string, str string) bool {
+	for _, v := range s {
+		if v == str {
+			return true
+		}
+	}
+	return false
+}
+
+// hmacSHA256 computes the HMAC-SHA256 of data using the provided key.
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// parseCredential parses a credential of the form
+// "<access key id>/<date>/<region>/s3/aws4_request".
+func parseCredential(credential string) (accessKeyID, date, region string, err error) {
+	parts := strings.Split(credential, "/")
+	if len(parts) != 5 || parts[0] == "" || parts[3] != scopeService || parts[4] != scopeTerminator {
+		return "", "", "", errAuthorizationHeaderMalformed
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+// parseSignature parses the signature of a request from either its
+// Authorization header or its query string in case of a presigned url.
+func parseSignature(req *http.Request) (sig signatureV4, err error) {
+	var credential, date, signedHeaders string
+	query := req.URL.Query()
+	if auth := req.Header.Get(headerAuthorization); auth != "" {
+		// The header has the form "AWS4-HMAC-SHA256 Credential=<credential>,
+		// SignedHeaders=<headers>, Signature=<signature>".
+		if !strings.HasPrefix(auth, signV4Algorithm+" ") {
+			return signatureV4{}, errAuthorizationHeaderMalformed
+		}
+		fields := make(map[string]string)
+		for _, field := range strings.Split(strings.TrimPrefix(auth, signV4Algorithm+" "), ",") {
+			kv := strings.SplitN(strings.TrimSpace(field), "=", 2)
+			if len(kv) != 2 {
+				return signatureV4{}, errAuthorizationHeaderMalformed
+			}
+			fields[kv[0]] = kv[1]
+		}
+		credential = fields["Credential"]
+		signedHeaders = fields["SignedHeaders"]
+		sig.signature = fields["Signature"]
+		date = req.Header.Get(headerAmzDate)
+		sig.payloadHash = req.Header.Get(headerAmzContentSHA256)
+	} else if algorithm := query.Get("X-Amz-Algorithm"); algorithm != "" {
+		if algorithm != signV4Algorithm {
+			return signatureV4{}, errAuthorizationHeaderMalformed
+		}
+		credential = query.Get("X-Amz-Credential")
+		signedHeaders = query.Get("X-Amz-SignedHeaders")
+		sig.signature = query.Get("X-Amz-Signature")
+		date = query.Get("X-Amz-Date")
+		sig.payloadHash = unsignedPayload
+		if payloadHash := query.Get("X-Amz-Content-Sha256"); payloadHash != "" {
+			sig.payloadHash = payloadHash
+		}
+		sig.presigned = true
+		expires, err := strconv.ParseUint(query.Get("X-Amz-Expires"), 10, 64)
+		if err != nil || expires == 0 || time.Duration(expires)*time.Second > maxPresignExpiry {
+			return signatureV4{}, errAuthorizationHeaderMalformed
+		}
+		sig.expires = time.Duration(expires) * time.Second
+	} else {
+		return signatureV4{}, errAccessDenied
+	}
+
+	var scopeDate string
+	sig.accessKeyID, scopeDate, sig.region, err = parseCredential(credential)
+	if err != nil {
+		return signatureV4{}, err
+	}
+	if signedHeaders == "" || sig.signature == "" || sig.payloadHash == "" {
+		return signatureV4{}, errAuthorizationHeaderMalformed
+	}
+	sig.signedHeaders = strings.Split(signedHeaders, ";")
+	// The host header always needs to be signed.
+	if !containsString(sig.signedHeaders, "host") {
+		return signatureV4{}, errAuthorizationHeaderMalformed
+	}
+	sig.date, err = time.Parse(iso8601Format, date)
+	if err != nil || sig.date.Format(scopeDateFormat) != scopeDate {
+		return signatureV4{}, errAuthorizationHeaderMalformed
+	}
+	return sig, nil
+}
+
+// signingKey derives the key which is used to sign requests from the secret
+// access key.
+func signingKey(secret string, date time.Time, region string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), date.Format(scopeDateFormat))
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, scopeService)
+	return hmacSHA256(key, scopeTerminator)
+}
+
+// uriEncode encodes a string as described by the AWS Signature Version 4
+// specification. Slashes are only encoded if encodeSlash is true.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		unreserved := (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~'
+		if unreserved || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
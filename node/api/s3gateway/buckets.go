@@ -0,0 +1,437 @@
+package s3gateway
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
+	"github.com/turtledex/errors"
+)
+
+const (
+	// maxListKeys is the maximum number of entries returned by a single
+	// listing.
+	maxListKeys = 1000
+
+	// minBucketNameLen and maxBucketNameLen are the bounds for the length of
+	// a new bucket's name.
+	minBucketNameLen = 3
+	maxBucketNameLen = 63
+)
+
+var (
+	// gatewayOwner is the owner of all buckets.
+	gatewayOwner = owner{
+		ID:          "turtledex",
+		DisplayName: "turtledex",
+	}
+
+	// listParams are the query parameters of listing requests. Requests for
+	// any other sub-resource of a bucket are not supported.
+	listParams = map[string]struct{}{
+		"continuation-token": {},
+		"delimiter":          {},
+		"encoding-type":      {},
+		"fetch-owner":        {},
+		"list-type":          {},
+		"marker":             {},
+		"max-keys":           {},
+		"prefix":             {},
+		"start-after":        {},
+	}
+)
+
+type (
+	// listEntry is an entry of a listing which is either an object or a
+	// common prefix.
+	listEntry struct {
+		name     string
+		fileInfo modules.FileInfo
+		isPrefix bool
+	}
+
+	// listing is the result of listing the objects of a bucket.
+	listing struct {
+		nextMarker string
+		objects    []objectInfo
+		prefixes   []commonPrefix
+		truncated  bool
+	}
+)
+
+// bucketHandlerDELETE handles DeleteBucket requests. Only empty buckets can be
+// deleted.
+func (g *Gateway) bucketHandlerDELETE(w http.ResponseWriter, req *http.Request, bucket string) {
+	if hasSubresource(req.URL.Query(), nil) {
+		writeError(w, req, errNotImplemented)
+		return
+	}
+	siaPath, err := g.checkBucket(bucket)
+	if err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+	var numFiles int
+	var mu sync.Mutex
+	err = g.staticRenter.FileList(siaPath, true, true, func(modules.FileInfo) {
+		mu.Lock()
+		numFiles++
+		mu.Unlock()
+	})
+	if err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+	if numFiles > 0 {
+		writeError(w, req, errBucketNotEmpty)
+		return
+	}
+	if err := g.staticRenter.DeleteDir(siaPath); err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// bucketHandlerGET handles GetBucketLocation, ListObjects and ListObjectsV2
+// requests.
+func (g *Gateway) bucketHandlerGET(w http.ResponseWriter, req *http.Request, bucket string) {
+	query := req.URL.Query()
+	switch {
+	case hasParam(query, "location"):
+		if _, err := g.checkBucket(bucket); err != nil {
+			writeError(w, req, toAPIError(err))
+			return
+		}
+		writeXML(w, http.StatusOK, locationResponse{})
+	case hasSubresource(query, listParams):
+		writeError(w, req, errNotImplemented)
+	case query.Get("list-type") == "2":
+		g.listObjectsV2Handler(w, req, bucket)
+	default:
+		g.listObjectsHandler(w, req, bucket)
+	}
+}
+
+// bucketHandlerHEAD handles HeadBucket requests.
+func (g *Gateway) bucketHandlerHEAD(w http.ResponseWriter, req *http.Request, bucket string) {
+	if _, err := g.checkBucket(bucket); err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// bucketHandlerPUT handles CreateBucket requests.
+func (g *Gateway) bucketHandlerPUT(w http.ResponseWriter, req *http.Request, bucket string) {
+	if hasSubresource(req.URL.Query(), nil) {
+		writeError(w, req, errNotImplemented)
+		return
+	}
+	if !validBucketName(bucket) {
+		writeError(w, req, errInvalidBucketName)
+		return
+	}
+	siaPath, err := bucketTurtleDexPath(bucket)
+	if err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+	err = g.staticRenter.CreateDir(siaPath, modules.DefaultDirPerm)
+	if errors.Contains(err, filesystem.ErrExists) {
+		writeError(w, req, errBucketAlreadyOwnedByYou)
+		return
+	}
+	if err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+	w.Header().Set("Location", "/"+bucket)
+	w.WriteHeader(http.StatusOK)
+}
+
+// listBucketsHandler handles ListBuckets requests. Every top-level directory
+// of the renter that isn't reserved is a bucket.
+func (g *Gateway) listBucketsHandler(w http.ResponseWriter, req *http.Request) {
+	dirs, err := g.staticRenter.DirList(modules.RootTurtleDexPath())
+	if err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+	resp := listBucketsResponse{
+		Owner: gatewayOwner,
+	}
+	for _, di := range dirs {
+		if di.TurtleDexPath.IsRoot() || isReservedBucket(di.Name()) {
+			continue
+		}
+		// Directories don't have a creation time so their most recent mod time
+		// is reported instead.
+		resp.Buckets = append(resp.Buckets, bucketInfo{
+			Name:         di.Name(),
+			CreationDate: di.MostRecentModTime.UTC().Format(timeFormat),
+		})
+	}
+	writeXML(w, http.StatusOK, resp)
+}
+
+// listObjectsHandler handles ListObjects requests.
+func (g *Gateway) listObjectsHandler(w http.ResponseWriter, req *http.Request, bucket string) {
+	query := req.URL.Query()
+	maxKeys, encodingType, err := parseListParams(query)
+	if err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+	prefix, delimiter, marker := query.Get("prefix"), query.Get("delimiter"), query.Get("marker")
+	l, err := g.listObjects(bucket, prefix, delimiter, marker, maxKeys)
+	if err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+	resp := listObjectsResponse{
+		Name:           bucket,
+		Prefix:         encodeKey(prefix, encodingType),
+		Marker:         encodeKey(marker, encodingType),
+		MaxKeys:        maxKeys,
+		Delimiter:      encodeKey(delimiter, encodingType),
+		IsTruncated:    l.truncated,
+		EncodingType:   encodingType,
+		Contents:       encodeObjects(l.objects, encodingType),
+		CommonPrefixes: encodePrefixes(l.prefixes, encodingType),
+	}
+	if l.truncated {
+		resp.NextMarker = encodeKey(l.nextMarker, encodingType)
+	}
+	writeXML(w, http.StatusOK, resp)
+}
+
+// listObjectsV2Handler handles ListObjectsV2 requests. The continuation token
+// is the encoded name of the last entry of the previous page.
+func (g *Gateway) listObjectsV2Handler(w http.ResponseWriter, req *http.Request, bucket string) {
+	query := req.URL.Query()
+	maxKeys, encodingType, err := parseListParams(query)
+	if err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+	prefix, delimiter, startAfter := query.Get("prefix"), query.Get("delimiter"), query.Get("start-after")
+	token := query.Get("continuation-token")
+	marker := startAfter
+	if token != "" {
+		b, err := base64.StdEncoding.DecodeString(token)
+		if err != nil {
+			writeError(w, req, errInvalidArgument)
+			return
+		}
+		marker = string(b)
+	}
+	l, err := g.listObjects(bucket, prefix, delimiter, marker, maxKeys)
+	if err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+	resp := listObjectsV2Response{
+		Name:              bucket,
+		Prefix:            encodeKey(prefix, encodingType),
+		StartAfter:        encodeKey(startAfter, encodingType),
+		ContinuationToken: token,
+		KeyCount:          len(l.objects) + len(l.prefixes),
+		MaxKeys:           maxKeys,
+		Delimiter:         encodeKey(delimiter, encodingType),
+		IsTruncated:       l.truncated,
+		EncodingType:      encodingType,
+		Contents:          encodeObjects(l.objects, encodingType),
+		CommonPrefixes:    encodePrefixes(l.prefixes, encodingType),
+	}
+	if l.truncated {
+		resp.NextContinuationToken = base64.StdEncoding.EncodeToString([]byte(l.nextMarker))
+	}
+	writeXML(w, http.StatusOK, resp)
+}
+
+// checkBucket returns the siapath of a bucket or errNoSuchBucket if the bucket
+// doesn't exist.
+func (g *Gateway) checkBucket(bucket string) (modules.TurtleDexPath, error) {
+	siaPath, err := bucketTurtleDexPath(bucket)
+	if err != nil {
+		return modules.TurtleDexPath{}, err
+	}
+	_, err = g.staticRenter.DirList(siaPath)
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return modules.TurtleDexPath{}, errNoSuchBucket
+	}
+	if err != nil {
+		return modules.TurtleDexPath{}, err
+	}
+	return siaPath, nil
+}
+
+// listObjects lists the objects of a bucket whose keys start with prefix and
+// come after marker in lexicographical order. If a delimiter is provided, keys
+// which contain the delimiter after the prefix are grouped into common
+// prefixes. At most maxKeys objects and prefixes are returned.
+func (g *Gateway) listObjects(bucket, prefix, delimiter, marker string, maxKeys int) (listing, error) {
+	bucketPath, err := g.checkBucket(bucket)
+	if err != nil {
+		return listing{}, err
+	}
+	// Only the directory which contains all the keys with the prefix needs to
+	// be listed. A prefix that isn't a valid siapath can't match any keys.
+	dirPath := bucketPath
+	if i := strings.LastIndex(prefix, "/"); i > 0 {
+		dirPath, err = bucketPath.Join(prefix[:i])
+		if err != nil {
+			return listing{}, nil
+		}
+	}
+
+	// If "/" is used as the delimiter, all keys in subdirectories are grouped
+	// into common prefixes. That means listing the directory itself is
+	// sufficient. Otherwise the whole directory tree is listed.
+	recursive := delimiter != "/"
+	var entries []listEntry
+	var mu sync.Mutex
+	err = g.staticRenter.FileList(dirPath, recursive, true, func(fi modules.FileInfo) {
+		key := objectKey(bucket, fi.TurtleDexPath)
+		if !strings.HasPrefix(key, prefix) || isTempObject(fi.Name()) {
+			return
+		}
+		mu.Lock()
+		entries = append(entries, listEntry{name: key, fileInfo: fi})
+		mu.Unlock()
+	})
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return listing{}, nil
+	}
+	if err != nil {
+		return listing{}, err
+	}
+	if !recursive {
+		dirs, err := g.staticRenter.DirList(dirPath)
+		if err != nil {
+			return listing{}, err
+		}
+		for _, di := range dirs {
+			if di.TurtleDexPath.Equals(dirPath) {
+				continue
+			}
+			name := objectKey(bucket, di.TurtleDexPath) + "/"
+			if strings.HasPrefix(name, prefix) {
+				entries = append(entries, listEntry{name: name, isPrefix: true})
+			}
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].name < entries[j].name
+	})
+
+	// Group the keys into common prefixes and apply the marker and maxKeys.
+	// Keys with the same common prefix are adjacent since the entries are
+	// sorted.
+	var l listing
+	var numEntries int
+	for _, entry := range entries {
+		if !entry.isPrefix && delimiter != "" {
+			if i := strings.Index(entry.name[len(prefix):], delimiter); i >= 0 {
+				entry = listEntry{name: entry.name[:len(prefix)+i+len(delimiter)], isPrefix: true}
+			}
+		}
+		if entry.name <= marker || (numEntries > 0 && entry.name == l.nextMarker) {
+			continue
+		}
+		if numEntries == maxKeys {
+			l.truncated = true
+			break
+		}
+		if entry.isPrefix {
+			l.prefixes = append(l.prefixes, commonPrefix{Prefix: entry.name})
+		} else {
+			l.objects = append(l.objects, newObjectInfo(entry.name, entry.fileInfo))
+		}
+		l.nextMarker = entry.name
+		numEntries++
+	}
+	return l, nil
+}
+
+// encodeKey encodes a key of a listing using the requested encoding type.
+func encodeKey(key, encodingType string) string {
+	if encodingType == "url" {
+		return url.QueryEscape(key)
+	}
+	return key
+}
+
+// encodeObjects encodes the keys of the objects of a listing using the
+// requested encoding type.
+func encodeObjects(objects []objectInfo, encodingType string) []objectInfo {
+	for i := range objects {
+		objects[i].Key = encodeKey(objects[i].Key, encodingType)
+	}
+	return objects
+}
+
+// encodePrefixes encodes the common prefixes of a listing using the requested
+// encoding type.
+func encodePrefixes(prefixes []commonPrefix, encodingType string) []commonPrefix {
+	for i := range prefixes {
+		prefixes[i].Prefix = encodeKey(prefixes[i].Prefix, encodingType)
+	}
+	return prefixes
+}
+
+// hasSubresource returns whether the query of a request for a bucket contains
+// parameters other than the allowed ones. The parameters of presigned urls are
+// always allowed.
+func hasSubresource(query url.Values, allowed map[string]struct{}) bool {
+	for param := range query {
+		if _, ok := allowed[param]; ok || strings.HasPrefix(param, "X-Amz-") {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// parseListParams parses the parameters which are shared by ListObjects and
+// ListObjectsV2 requests.
+func parseListParams(query url.Values) (maxKeys int, encodingType string, err error) {
+	maxKeys = maxListKeys
+	if mk := query.Get("max-keys"); mk != "" {
+		maxKeys, err = strconv.Atoi(mk)
+		if err != nil || maxKeys < 0 {
+			return 0, "", errInvalidArgument
+		}
+		if maxKeys > maxListKeys {
+			maxKeys = maxListKeys
+		}
+	}
+	encodingType = query.Get("encoding-type")
+	if encodingType != "" && encodingType != "url" {
+		return 0, "", errInvalidArgument
+	}
+	return maxKeys, encodingType, nil
+}
+
+// validBucketName returns whether the name of a new bucket follows the S3
+// bucket naming rules.
+func validBucketName(name string) bool {
+	if len(name) < minBucketNameLen || len(name) > maxBucketNameLen {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		alphaNum := (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9')
+		if !alphaNum && ((c != '.' && c != '-') || i == 0 || i == len(name)-1) {
+			return false
+		}
+	}
+	return !strings.Contains(name, "..")
+}
@@ -0,0 +1,83 @@
+package s3gateway
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+
+	"github.com/turtledex/TurtleDexCore/persist"
+	"github.com/turtledex/errors"
+	"github.com/turtledex/fastrand"
+)
+
+const (
+	// credentialsFile is the name of the file within the gateway's persist
+	// directory which contains the credentials of the gateway's users.
+	credentialsFile = "credentials.json"
+
+	// credentialsVersion is the version of the credentials file.
+	credentialsVersion = "1.5.5"
+
+	// accessKeyIDAlphabet contains the characters of a generated access key
+	// id.
+	accessKeyIDAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+	// accessKeyIDLen is the length of a generated access key id.
+	accessKeyIDLen = 20
+
+	// secretAccessKeyEntropy is the number of random bytes a generated secret
+	// access key is derived from.
+	secretAccessKeyEntropy = 30
+)
+
+var (
+	// credentialsMetadata is the metadata of the credentials file.
+	credentialsMetadata = persist.Metadata{
+		Header:  "S3 Gateway Credentials",
+		Version: credentialsVersion,
+	}
+)
+
+// Credentials are the credentials a client uses to sign its requests.
+type Credentials struct {
+	AccessKeyID     string `json:"accesskeyid"`
+	SecretAccessKey string `json:"secretaccesskey"`
+}
+
+// CredentialsPath returns the path of the credentials file within the
+// provided persist directory.
+func CredentialsPath(persistDir string) string {
+	return filepath.Join(persistDir, credentialsFile)
+}
+
+// LoadCredentials loads the credentials from the provided persist directory.
+// If no credentials exist yet, a new set of credentials is generated and
+// persisted.
+func LoadCredentials(persistDir string) ([]Credentials, error) {
+	var creds []Credentials
+	err := persist.LoadJSON(credentialsMetadata, &creds, CredentialsPath(persistDir))
+	if err == nil {
+		return creds, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, errors.AddContext(err, "failed to load credentials")
+	}
+	creds = []Credentials{newCredentials()}
+	err = persist.SaveJSON(credentialsMetadata, creds, CredentialsPath(persistDir))
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to save generated credentials")
+	}
+	return creds, nil
+}
+
+// newCredentials generates a new set of random credentials.
+func newCredentials() Credentials {
+	accessKeyID := make([]byte, accessKeyIDLen)
+	for i := range accessKeyID {
+		accessKeyID[i] = accessKeyIDAlphabet[fastrand.Intn(len(accessKeyIDAlphabet))]
+	}
+	return Credentials{
+		AccessKeyID:     string(accessKeyID),
+		SecretAccessKey: base64.StdEncoding.EncodeToString(fastrand.Bytes(secretAccessKeyEntropy)),
+	}
+}
@@ -0,0 +1,129 @@
+package s3gateway
+
+import (
+	"encoding/xml"
+	"net/http"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
+	"github.com/turtledex/errors"
+)
+
+type (
+	// apiError is an error that can be returned to S3 clients.
+	apiError struct {
+		Code           string
+		Description    string
+		HTTPStatusCode int
+	}
+
+	// errorResponse is the XML body of a failed request.
+	errorResponse struct {
+		XMLName   xml.Name `xml:"Error"`
+		Code      string   `xml:"Code"`
+		Message   string   `xml:"Message"`
+		Resource  string   `xml:"Resource"`
+		RequestID string   `xml:"RequestId"`
+	}
+)
+
+var (
+	errAccessDenied                 = &apiError{"AccessDenied", "Access Denied.", http.StatusForbidden}
+	errAuthorizationHeaderMalformed = &apiError{"AuthorizationHeaderMalformed", "The authorization header is malformed.", http.StatusBadRequest}
+	errBadDigest                    = &apiError{"BadDigest", "The Content-MD5 you specified did not match what we received.", http.StatusBadRequest}
+	errBucketAlreadyOwnedByYou      = &apiError{"BucketAlreadyOwnedByYou", "The bucket you tried to create already exists, and you own it.", http.StatusConflict}
+	errBucketNotEmpty               = &apiError{"BucketNotEmpty", "The bucket you tried to delete is not empty.", http.StatusConflict}
+	errBudgetExhausted              = &apiError{"AccessDenied", "The budget of your access key is exhausted.", http.StatusForbidden}
+	errExpiredPresignRequest        = &apiError{"AccessDenied", "Request has expired.", http.StatusForbidden}
+	errIncompleteBody               = &apiError{"IncompleteBody", "You did not provide the number of bytes specified by the Content-Length HTTP header.", http.StatusBadRequest}
+	errInternalError                = &apiError{"InternalError", "We encountered an internal error, please try again.", http.StatusInternalServerError}
+	errInvalidAccessKeyID           = &apiError{"InvalidAccessKeyId", "The access key ID you provided does not exist in our records.", http.StatusForbidden}
+	errInvalidArgument              = &apiError{"InvalidArgument", "Invalid argument.", http.StatusBadRequest}
+	errInvalidBucketName            = &apiError{"InvalidBucketName", "The specified bucket is not valid.", http.StatusBadRequest}
+	errInvalidDigest                = &apiError{"InvalidDigest", "The Content-MD5 you specified is not valid.", http.StatusBadRequest}
+	errInvalidPart                  = &apiError{"InvalidPart", "One or more of the specified parts could not be found.", http.StatusBadRequest}
+	errInvalidPartOrder             = &apiError{"InvalidPartOrder", "The list of parts was not in ascending order.", http.StatusBadRequest}
+	errMalformedXML                 = &apiError{"MalformedXML", "The XML you provided was not well-formed or did not validate against our published schema.", http.StatusBadRequest}
+	errMethodNotAllowed             = &apiError{"MethodNotAllowed", "The specified method is not allowed against this resource.", http.StatusMethodNotAllowed}
+	errNoSuchBucket                 = &apiError{"NoSuchBucket", "The specified bucket does not exist.", http.StatusNotFound}
+	errNoSuchKey                    = &apiError{"NoSuchKey", "The specified key does not exist.", http.StatusNotFound}
+	errNoSuchUpload                 = &apiError{"NoSuchUpload", "The specified multipart upload does not exist.", http.StatusNotFound}
+	errNotImplemented               = &apiError{"NotImplemented", "A header or query you provided implies functionality that is not implemented.", http.StatusNotImplemented}
+	errRequestTimeTooSkewed         = &apiError{"RequestTimeTooSkewed", "The difference between the request time and the server's time is too large.", http.StatusForbidden}
+	errSignatureDoesNotMatch        = &apiError{"SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided.", http.StatusForbidden}
+	errXAmzContentSHA256Mismatch    = &apiError{"XAmzContentSHA256Mismatch", "The provided 'x-amz-content-sha256' header does not match what was computed.", http.StatusBadRequest}
+
+	// apiErrors contains all the errors which are returned to the client as
+	// they are. All other errors are returned as internal errors.
+	apiErrors = []*apiError{
+		errAccessDenied,
+		errAuthorizationHeaderMalformed,
+		errBadDigest,
+		errBucketAlreadyOwnedByYou,
+		errBucketNotEmpty,
+		errExpiredPresignRequest,
+		errIncompleteBody,
+		errInvalidAccessKeyID,
+		errInvalidArgument,
+		errInvalidBucketName,
+		errInvalidDigest,
+		errInvalidPart,
+		errInvalidPartOrder,
+		errMalformedXML,
+		errMethodNotAllowed,
+		errNoSuchBucket,
+		errNoSuchKey,
+		errNoSuchUpload,
+		errNotImplemented,
+		errRequestTimeTooSkewed,
+		errSignatureDoesNotMatch,
+		errXAmzContentSHA256Mismatch,
+	}
+)
+
+// Error implements the error interface.
+func (e *apiError) Error() string {
+	return e.Description
+}
+
+// toAPIError converts an error into the apiError that is returned to the
+// client.
+func toAPIError(err error) *apiError {
+	if errors.Contains(err, modules.ErrBudgetExhausted) {
+		return errBudgetExhausted
+	}
+	for _, apiErr := range apiErrors {
+		if errors.Contains(err, apiErr) {
+			return apiErr
+		}
+	}
+	return &apiError{
+		Code:           errInternalError.Code,
+		Description:    err.Error(),
+		HTTPStatusCode: errInternalError.HTTPStatusCode,
+	}
+}
+
+// objectError converts an error returned by the renter for an operation on an
+// object into the apiError that is returned to the client.
+func objectError(err error) *apiError {
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return errNoSuchKey
+	}
+	return toAPIError(err)
+}
+
+// writeError writes an error response to the client.
+func writeError(w http.ResponseWriter, req *http.Request, err *apiError) {
+	// HEAD responses don't have a body.
+	if req.Method == http.MethodHead {
+		w.WriteHeader(err.HTTPStatusCode)
+		return
+	}
+	writeXML(w, err.HTTPStatusCode, errorResponse{
+		Code:      err.Code,
+		Message:   err.Description,
+		Resource:  req.URL.Path,
+		RequestID: w.Header().Get(headerRequestID),
+	})
+}
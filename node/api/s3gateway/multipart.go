@@ -0,0 +1,323 @@
+package s3gateway
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/persist"
+	"github.com/turtledex/errors"
+	"github.com/turtledex/fastrand"
+)
+
+// Multipart uploads are staged on disk. Every upload has its own directory
+// within the gateway's multipart directory which contains the upload's
+// metadata and the parts uploaded so far. The name of a part's file contains
+// the part's number and ETag. Once the upload is completed, the parts are
+// streamed to the renter in order and the upload's directory is removed.
+
+const (
+	// maxCompleteBodySize is the maximum size of the body of a
+	// CompleteMultipartUpload request.
+	maxCompleteBodySize = 1 << 20
+
+	// maxPartNumber is the highest number a part can have.
+	maxPartNumber = 10000
+
+	// multipartUploadFile is the name of the file within an upload's
+	// directory that contains the upload's metadata.
+	multipartUploadFile = "upload.json"
+
+	// multipartUploadVersion is the version of a multipart upload's
+	// metadata file.
+	multipartUploadVersion = "1.5.5"
+
+	// partExtension is the extension of a part's file.
+	partExtension = ".part"
+
+	// uploadIDLen is the length of an upload's id in bytes.
+	uploadIDLen = 16
+)
+
+var (
+	// multipartUploadMetadata is the metadata of a multipart upload's
+	// metadata file.
+	multipartUploadMetadata = persist.Metadata{
+		Header:  "S3 Gateway Multipart Upload",
+		Version: multipartUploadVersion,
+	}
+)
+
+type (
+	// multipartUpload is the persisted metadata of a multipart upload.
+	multipartUpload struct {
+		Bucket    string    `json:"bucket"`
+		Initiated time.Time `json:"initiated"`
+		Key       string    `json:"key"`
+	}
+
+	// partsReader reads the files of multiple parts one after another.
+	partsReader struct {
+		f     *os.File
+		paths []string
+	}
+)
+
+// Close closes the part that is currently being read.
+func (pr *partsReader) Close() error {
+	if pr.f == nil {
+		return nil
+	}
+	return pr.f.Close()
+}
+
+// Read implements io.Reader.
+func (pr *partsReader) Read(p []byte) (int, error) {
+	for {
+		if pr.f == nil {
+			if len(pr.paths) == 0 {
+				return 0, io.EOF
+			}
+			f, err := os.Open(pr.paths[0])
+			if err != nil {
+				return 0, err
+			}
+			pr.f, pr.paths = f, pr.paths[1:]
+		}
+		n, err := pr.f.Read(p)
+		if err != io.EOF {
+			return n, err
+		}
+		err = pr.f.Close()
+		pr.f = nil
+		if n > 0 || err != nil {
+			return n, err
+		}
+	}
+}
+
+// abortMultipartUploadHandler handles AbortMultipartUpload requests.
+func (g *Gateway) abortMultipartUploadHandler(w http.ResponseWriter, req *http.Request, bucket, key string) {
+	id := req.URL.Query().Get("uploadId")
+	if err := g.checkMultipartUpload(id, bucket, key); err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+	if err := os.RemoveAll(g.uploadDir(id)); err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// completeMultipartUploadHandler handles CompleteMultipartUpload requests.
+func (g *Gateway) completeMultipartUploadHandler(w http.ResponseWriter, req *http.Request, bucket, key, identity string) {
+	id := req.URL.Query().Get("uploadId")
+	if err := g.checkMultipartUpload(id, bucket, key); err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+	siaPath, err := g.checkObject(bucket, key)
+	if err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+	var cmu completeMultipartUploadRequest
+	err = xml.NewDecoder(io.LimitReader(req.Body, maxCompleteBodySize)).Decode(&cmu)
+	if err != nil || len(cmu.Parts) == 0 {
+		writeError(w, req, errMalformedXML)
+		return
+	}
+
+	// Collect the files of the parts. The parts need to be in ascending order
+	// and their ETags need to match.
+	paths := make([]string, 0, len(cmu.Parts))
+	var sums []byte
+	for i, part := range cmu.Parts {
+		if i > 0 && part.PartNumber <= cmu.Parts[i-1].PartNumber {
+			writeError(w, req, errInvalidPartOrder)
+			return
+		}
+		etag := strings.ToLower(strings.Trim(part.ETag, "\""))
+		sum, err := hex.DecodeString(etag)
+		if err != nil || len(sum) != md5.Size {
+			writeError(w, req, errInvalidPart)
+			return
+		}
+		path := partPath(g.uploadDir(id), part.PartNumber, etag)
+		if _, err := os.Stat(path); err != nil {
+			writeError(w, req, errInvalidPart)
+			return
+		}
+		paths = append(paths, path)
+		sums = append(sums, sum...)
+	}
+
+	// Upload the parts. The parts were verified when they were uploaded.
+	pr := &partsReader{paths: paths}
+	_, err = g.uploadObject(siaPath, pr, nil, identity)
+	err = errors.Compose(err, pr.Close())
+	if err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+	if err := os.RemoveAll(g.uploadDir(id)); err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+
+	// The ETag of a multipart upload is the MD5 hash of the parts' MD5 hashes
+	// followed by the number of parts.
+	sum := md5.Sum(sums)
+	writeXML(w, http.StatusOK, completeMultipartUploadResponse{
+		Bucket: bucket,
+		Key:    key,
+		ETag:   fmt.Sprintf("\"%x-%d\"", sum, len(cmu.Parts)),
+	})
+}
+
+// createMultipartUploadHandler handles CreateMultipartUpload requests.
+func (g *Gateway) createMultipartUploadHandler(w http.ResponseWriter, req *http.Request, bucket, key string) {
+	if strings.HasSuffix(key, "/") {
+		writeError(w, req, errInvalidArgument)
+		return
+	}
+	if _, err := g.checkObject(bucket, key); err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+	id := hex.EncodeToString(fastrand.Bytes(uploadIDLen))
+	if err := os.MkdirAll(g.uploadDir(id), modules.DefaultDirPerm); err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+	mu := multipartUpload{
+		Bucket:    bucket,
+		Initiated: time.Now(),
+		Key:       key,
+	}
+	err := persist.SaveJSON(multipartUploadMetadata, mu, filepath.Join(g.uploadDir(id), multipartUploadFile))
+	if err != nil {
+		writeError(w, req, toAPIError(errors.Compose(err, os.RemoveAll(g.uploadDir(id)))))
+		return
+	}
+	writeXML(w, http.StatusOK, initiateMultipartUploadResponse{
+		Bucket:   bucket,
+		Key:      key,
+		UploadID: id,
+	})
+}
+
+// uploadPartHandler handles UploadPart requests. The part is written to disk
+// and replaces any previously uploaded part with the same number.
+func (g *Gateway) uploadPartHandler(w http.ResponseWriter, req *http.Request, bucket, key string) {
+	if req.Header.Get("X-Amz-Copy-Source") != "" {
+		writeError(w, req, errNotImplemented)
+		return
+	}
+	query := req.URL.Query()
+	partNumber, err := strconv.Atoi(query.Get("partNumber"))
+	if err != nil || partNumber < 1 || partNumber > maxPartNumber {
+		writeError(w, req, errInvalidArgument)
+		return
+	}
+	id := query.Get("uploadId")
+	if err := g.checkMultipartUpload(id, bucket, key); err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+	contentMD5, err := parseContentMD5(req.Header.Get("Content-MD5"))
+	if err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+	sum, err := g.writePart(id, partNumber, req.Body, contentMD5)
+	if err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+	w.Header().Set("ETag", quoteETag(sum))
+	w.WriteHeader(http.StatusOK)
+}
+
+// checkMultipartUpload checks that the multipart upload with the provided id
+// exists and belongs to the object with the provided key.
+func (g *Gateway) checkMultipartUpload(id, bucket, key string) error {
+	b, err := hex.DecodeString(id)
+	if err != nil || len(b) != uploadIDLen {
+		return errNoSuchUpload
+	}
+	var mu multipartUpload
+	err = persist.LoadJSON(multipartUploadMetadata, &mu, filepath.Join(g.uploadDir(id), multipartUploadFile))
+	if os.IsNotExist(err) {
+		return errNoSuchUpload
+	}
+	if err != nil {
+		return errors.AddContext(err, "failed to load multipart upload")
+	}
+	if mu.Bucket != bucket || mu.Key != key {
+		return errNoSuchUpload
+	}
+	return nil
+}
+
+// uploadDir returns the directory of the multipart upload with the provided
+// id.
+func (g *Gateway) uploadDir(id string) string {
+	return filepath.Join(g.staticMultipartDir, id)
+}
+
+// writePart writes a part of a multipart upload to disk and returns its MD5
+// hash. The part is written to a temporary file first which is then renamed
+// to make sure that a part is never only partially written.
+func (g *Gateway) writePart(id string, partNumber int, r io.Reader, expectedMD5 []byte) ([]byte, error) {
+	dir := g.uploadDir(id)
+	f, err := ioutil.TempFile(dir, "part-*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	h := md5.New()
+	_, err = io.Copy(io.MultiWriter(f, h), r)
+	err = errors.Compose(err, f.Sync(), f.Close())
+	sum := h.Sum(nil)
+	if err == nil && expectedMD5 != nil && !bytes.Equal(sum, expectedMD5) {
+		err = errBadDigest
+	}
+	// Move the part into place.
+	path := partPath(dir, partNumber, hex.EncodeToString(sum))
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		return nil, errors.Compose(err, os.Remove(f.Name()))
+	}
+
+	// Remove any previous upload of the same part.
+	oldParts, err := filepath.Glob(filepath.Join(dir, fmt.Sprintf("%d-*%v", partNumber, partExtension)))
+	if err != nil {
+		return nil, err
+	}
+	for _, oldPart := range oldParts {
+		if oldPart != path {
+			err = errors.Compose(err, os.Remove(oldPart))
+		}
+	}
+	return sum, err
+}
+
+// partPath returns the path of the file of a part with the provided number
+// and hex encoded ETag.
+func partPath(dir string, partNumber int, etag string) string {
+	return filepath.Join(dir, fmt.Sprintf("%d-%v%v", partNumber, etag, partExtension))
+}
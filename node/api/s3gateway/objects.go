@@ -0,0 +1,344 @@
+package s3gateway
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
+	"github.com/turtledex/errors"
+	"github.com/turtledex/fastrand"
+)
+
+const (
+	// tempObjectPrefix is the prefix of the name of the temporary file an
+	// object is uploaded to before it replaces the existing object.
+	tempObjectPrefix = ".s3upload-"
+)
+
+var (
+	// objectSubresources are the sub-resources of objects which are not
+	// supported by the gateway.
+	objectSubresources = []string{"acl", "attributes", "legal-hold", "restore", "retention", "select", "tagging", "torrent", "versionId"}
+)
+
+// errorRecorder remembers the last error other than io.EOF that was returned
+// by the reader it wraps.
+type errorRecorder struct {
+	io.Reader
+	err error
+}
+
+// Read implements io.Reader.
+func (er *errorRecorder) Read(p []byte) (int, error) {
+	n, err := er.Reader.Read(p)
+	if err != nil && err != io.EOF {
+		er.err = err
+	}
+	return n, err
+}
+
+// objectHandlerDELETE handles DeleteObject requests. Deleting an object that
+// doesn't exist is not an error.
+func (g *Gateway) objectHandlerDELETE(w http.ResponseWriter, req *http.Request, bucket, key string) {
+	if hasObjectSubresource(req.URL.Query()) {
+		writeError(w, req, errNotImplemented)
+		return
+	}
+	siaPath, err := g.checkObject(bucket, key)
+	if err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+	if strings.HasSuffix(key, "/") {
+		err = g.deleteEmptyDir(siaPath)
+	} else {
+		err = g.staticRenter.DeleteFile(siaPath)
+	}
+	if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// objectHandlerGET handles GetObject requests including range requests.
+func (g *Gateway) objectHandlerGET(w http.ResponseWriter, req *http.Request, bucket, key, identity string) {
+	if hasObjectSubresource(req.URL.Query()) {
+		writeError(w, req, errNotImplemented)
+		return
+	}
+	siaPath, err := g.checkObject(bucket, key)
+	if err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+	if strings.HasSuffix(key, "/") {
+		g.folderHandler(w, req, siaPath)
+		return
+	}
+	fi, err := g.staticRenter.File(siaPath)
+	if err != nil {
+		writeError(w, req, objectError(err))
+		return
+	}
+	_, streamer, err := g.staticRenter.Streamer(siaPath, false, modules.DownloadPriorityNormal, identity)
+	if err != nil {
+		writeError(w, req, objectError(err))
+		return
+	}
+	defer streamer.Close()
+	w.Header().Set("ETag", objectETag(fi))
+	http.ServeContent(w, req, fi.Name(), fi.ModificationTime, streamer)
+}
+
+// objectHandlerHEAD handles HeadObject requests.
+func (g *Gateway) objectHandlerHEAD(w http.ResponseWriter, req *http.Request, bucket, key string) {
+	siaPath, err := g.checkObject(bucket, key)
+	if err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+	if strings.HasSuffix(key, "/") {
+		g.folderHandler(w, req, siaPath)
+		return
+	}
+	fi, err := g.staticRenter.File(siaPath)
+	if err != nil {
+		writeError(w, req, objectError(err))
+		return
+	}
+	contentType := mime.TypeByExtension(filepath.Ext(fi.Name()))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("Content-Length", strconv.FormatUint(fi.Filesize, 10))
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", objectETag(fi))
+	w.Header().Set("Last-Modified", fi.ModificationTime.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusOK)
+}
+
+// objectHandlerPUT handles PutObject requests. Existing objects are replaced.
+// Keys that end with a slash are folder markers which create a directory.
+func (g *Gateway) objectHandlerPUT(w http.ResponseWriter, req *http.Request, bucket, key, identity string) {
+	if hasObjectSubresource(req.URL.Query()) || req.Header.Get("X-Amz-Copy-Source") != "" {
+		writeError(w, req, errNotImplemented)
+		return
+	}
+	siaPath, err := g.checkObject(bucket, key)
+	if err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+	contentMD5, err := parseContentMD5(req.Header.Get("Content-MD5"))
+	if err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+
+	if strings.HasSuffix(key, "/") {
+		if req.ContentLength > 0 {
+			writeError(w, req, errInvalidArgument)
+			return
+		}
+		err = g.staticRenter.CreateDir(siaPath, modules.DefaultDirPerm)
+		if err != nil && !errors.Contains(err, filesystem.ErrExists) {
+			writeError(w, req, toAPIError(err))
+			return
+		}
+		emptyMD5 := md5.Sum(nil)
+		w.Header().Set("ETag", quoteETag(emptyMD5[:]))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	etag, err := g.uploadObject(siaPath, req.Body, contentMD5, identity)
+	if err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+	w.Header().Set("ETag", etag)
+	w.WriteHeader(http.StatusOK)
+}
+
+// checkObject returns the siapath of an object after making sure that its
+// bucket exists.
+func (g *Gateway) checkObject(bucket, key string) (modules.TurtleDexPath, error) {
+	if _, err := g.checkBucket(bucket); err != nil {
+		return modules.TurtleDexPath{}, err
+	}
+	return objectTurtleDexPath(bucket, key)
+}
+
+// deleteEmptyDir deletes the directory at siaPath if it doesn't contain any
+// files or subdirectories.
+func (g *Gateway) deleteEmptyDir(siaPath modules.TurtleDexPath) error {
+	empty, err := g.dirEmpty(siaPath)
+	if err != nil || !empty {
+		return err
+	}
+	return g.staticRenter.DeleteDir(siaPath)
+}
+
+// deleteTempObject deletes the temporary file of a failed upload. The file
+// might not exist if the upload failed before it was created.
+func (g *Gateway) deleteTempObject(tmpPath modules.TurtleDexPath) error {
+	err := g.staticRenter.DeleteFile(tmpPath)
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// dirEmpty returns whether the directory at siaPath doesn't contain any files
+// or subdirectories.
+func (g *Gateway) dirEmpty(siaPath modules.TurtleDexPath) (bool, error) {
+	dirs, err := g.staticRenter.DirList(siaPath)
+	if err != nil {
+		return false, err
+	}
+	var numFiles int
+	var mu sync.Mutex
+	err = g.staticRenter.FileList(siaPath, false, true, func(modules.FileInfo) {
+		mu.Lock()
+		numFiles++
+		mu.Unlock()
+	})
+	if err != nil {
+		return false, err
+	}
+	return len(dirs) <= 1 && numFiles == 0, nil
+}
+
+// folderHandler handles GetObject and HeadObject requests for folder markers.
+// A folder marker is an empty object that exists if the corresponding
+// directory exists.
+func (g *Gateway) folderHandler(w http.ResponseWriter, req *http.Request, siaPath modules.TurtleDexPath) {
+	_, err := g.staticRenter.DirList(siaPath)
+	if err != nil {
+		writeError(w, req, objectError(err))
+		return
+	}
+	emptyMD5 := md5.Sum(nil)
+	w.Header().Set("Content-Length", "0")
+	w.Header().Set("ETag", quoteETag(emptyMD5[:]))
+	w.WriteHeader(http.StatusOK)
+}
+
+// uploadObject uploads the data read from r to the file at siaPath and
+// returns the object's ETag, which is the MD5 hash of the data. The data is
+// uploaded to a temporary file first which only replaces the existing object
+// once the data was read completely and its digests were verified. On any
+// failure the temporary file is deleted and the existing object is kept. The
+// upload is charged to the budget of the identity.
+func (g *Gateway) uploadObject(siaPath modules.TurtleDexPath, r io.Reader, expectedMD5 []byte, identity string) (string, error) {
+	tmpPath, err := tempObjectTurtleDexPath(siaPath)
+	if err != nil {
+		return "", err
+	}
+	h := md5.New()
+	er := &errorRecorder{Reader: io.TeeReader(r, h)}
+	up := modules.FileUploadParams{
+		TurtleDexPath:  tmpPath,
+		CipherType:     crypto.TypeDefaultRenter,
+		BudgetIdentity: identity,
+	}
+	err = g.staticRenter.UploadStreamFromReader(up, er)
+	// The renter treats an unexpected EOF as the end of the stream, so the
+	// upload might have succeeded even though the body wasn't read completely
+	// or failed verification.
+	if er.err != nil {
+		return "", errors.Compose(er.err, err, g.deleteTempObject(tmpPath))
+	}
+	if err != nil {
+		return "", errors.Compose(err, g.deleteTempObject(tmpPath))
+	}
+	sum := h.Sum(nil)
+	if expectedMD5 != nil && !bytes.Equal(sum, expectedMD5) {
+		return "", errors.Compose(errBadDigest, g.deleteTempObject(tmpPath))
+	}
+	err = g.staticRenter.ReplaceFile(tmpPath, siaPath)
+	if err != nil {
+		return "", errors.Compose(err, g.deleteTempObject(tmpPath))
+	}
+	return quoteETag(sum), nil
+}
+
+// hasObjectSubresource returns whether the query of a request for an object
+// contains a sub-resource which isn't supported.
+func hasObjectSubresource(query map[string][]string) bool {
+	for _, sr := range objectSubresources {
+		if hasParam(query, sr) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTempObject returns whether the file with the provided name is the
+// temporary file of an upload in progress.
+func isTempObject(name string) bool {
+	return strings.HasPrefix(name, tempObjectPrefix)
+}
+
+// newObjectInfo creates the information about an object within a listing.
+func newObjectInfo(key string, fi modules.FileInfo) objectInfo {
+	return objectInfo{
+		Key:          key,
+		LastModified: fi.ModificationTime.UTC().Format(timeFormat),
+		ETag:         objectETag(fi),
+		Size:         fi.Filesize,
+		StorageClass: storageClass,
+	}
+}
+
+// objectETag returns the ETag of an existing object. The renter doesn't store
+// the MD5 hash of a file's data so the ETag is derived from the file's
+// metadata instead. It has the form of a multipart ETag which tells clients
+// that the ETag is not the MD5 hash of the object's data.
+func objectETag(fi modules.FileInfo) string {
+	h := crypto.HashAll(fi.TurtleDexPath.String(), fi.Filesize, fi.CreateTime.UnixNano(), fi.ModificationTime.UnixNano())
+	return fmt.Sprintf("\"%x-1\"", h[:md5.Size])
+}
+
+// parseContentMD5 parses the base64 encoded value of a Content-MD5 header.
+// It returns nil if the header wasn't set.
+func parseContentMD5(header string) ([]byte, error) {
+	if header == "" {
+		return nil, nil
+	}
+	b, err := base64.StdEncoding.DecodeString(header)
+	if err != nil || len(b) != md5.Size {
+		return nil, errInvalidDigest
+	}
+	return b, nil
+}
+
+// quoteETag returns the quoted hex encoding of an MD5 hash.
+func quoteETag(sum []byte) string {
+	return fmt.Sprintf("\"%x\"", sum)
+}
+
+// tempObjectTurtleDexPath returns a random siapath for the temporary file of
+// an upload to siaPath. The temporary file is created within the same
+// directory so that the directory's quotas apply to the upload.
+func tempObjectTurtleDexPath(siaPath modules.TurtleDexPath) (modules.TurtleDexPath, error) {
+	dir, err := siaPath.Dir()
+	if err != nil {
+		return modules.TurtleDexPath{}, err
+	}
+	return dir.Join(tempObjectPrefix + hex.EncodeToString(fastrand.Bytes(8)))
+}
@@ -0,0 +1,194 @@
+// Package s3gateway implements a gateway which exposes the renter's files
+// through a subset of the Amazon S3 REST API. Buckets are mapped to the
+// top-level directories of the renter's filesystem and the key of an object is
+// its path relative to its bucket. Only path-style requests are supported.
+package s3gateway
+
+import (
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/errors"
+	"github.com/turtledex/fastrand"
+)
+
+const (
+	// headerRequestID is the header that contains the id of a request.
+	headerRequestID = "X-Amz-Request-Id"
+
+	// PersistDir is the name of the directory within the renter's persist
+	// directory which contains the gateway's persist files.
+	PersistDir = "s3gateway"
+
+	// multipartDir is the name of the directory within the gateway's persist
+	// directory which contains the parts of pending multipart uploads.
+	multipartDir = "multipart"
+)
+
+var (
+	// reservedBuckets are the names of the top-level directories of the
+	// renter which hold internal data. They are not exposed as buckets.
+	reservedBuckets = map[string]struct{}{
+		modules.BackupFolder.Name(): {},
+		modules.VarFolder.Name():    {},
+	}
+)
+
+// Gateway is an http.Handler that serves the S3 API in front of a renter.
+type Gateway struct {
+	staticCredentials  map[string]string
+	staticMultipartDir string
+	staticRenter       modules.Renter
+}
+
+// New creates a new gateway in front of the provided renter. The gateway's
+// credentials and pending multipart uploads are stored within persistDir.
+func New(renter modules.Renter, persistDir string) (*Gateway, error) {
+	if err := os.MkdirAll(filepath.Join(persistDir, multipartDir), modules.DefaultDirPerm); err != nil {
+		return nil, errors.AddContext(err, "failed to create gateway dir")
+	}
+	creds, err := LoadCredentials(persistDir)
+	if err != nil {
+		return nil, err
+	}
+	g := &Gateway{
+		staticCredentials:  make(map[string]string),
+		staticMultipartDir: filepath.Join(persistDir, multipartDir),
+		staticRenter:       renter,
+	}
+	for _, c := range creds {
+		g.staticCredentials[c.AccessKeyID] = c.SecretAccessKey
+	}
+	return g, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set(headerRequestID, hex.EncodeToString(fastrand.Bytes(8)))
+	// The access key ID identifies the budget that uploads and downloads are
+	// charged to.
+	identity, err := g.authenticate(req)
+	if err != nil {
+		writeError(w, req, toAPIError(err))
+		return
+	}
+
+	bucket, key := splitPath(req.URL.Path)
+	query := req.URL.Query()
+	switch {
+	case bucket == "" && req.Method == http.MethodGet:
+		g.listBucketsHandler(w, req)
+	case bucket == "":
+		writeError(w, req, errMethodNotAllowed)
+
+	// Bucket operations.
+	case key == "" && req.Method == http.MethodGet:
+		g.bucketHandlerGET(w, req, bucket)
+	case key == "" && req.Method == http.MethodHead:
+		g.bucketHandlerHEAD(w, req, bucket)
+	case key == "" && req.Method == http.MethodPut:
+		g.bucketHandlerPUT(w, req, bucket)
+	case key == "" && req.Method == http.MethodDelete:
+		g.bucketHandlerDELETE(w, req, bucket)
+	case key == "":
+		writeError(w, req, errMethodNotAllowed)
+
+	// Multipart operations.
+	case req.Method == http.MethodPost && hasParam(query, "uploads"):
+		g.createMultipartUploadHandler(w, req, bucket, key)
+	case req.Method == http.MethodPost && hasParam(query, "uploadId"):
+		g.completeMultipartUploadHandler(w, req, bucket, key, identity)
+	case req.Method == http.MethodPut && hasParam(query, "uploadId"):
+		g.uploadPartHandler(w, req, bucket, key)
+	case req.Method == http.MethodDelete && hasParam(query, "uploadId"):
+		g.abortMultipartUploadHandler(w, req, bucket, key)
+	case hasParam(query, "uploadId"):
+		writeError(w, req, errNotImplemented)
+
+	// Object operations.
+	case req.Method == http.MethodGet:
+		g.objectHandlerGET(w, req, bucket, key, identity)
+	case req.Method == http.MethodHead:
+		g.objectHandlerHEAD(w, req, bucket, key)
+	case req.Method == http.MethodPut:
+		g.objectHandlerPUT(w, req, bucket, key, identity)
+	case req.Method == http.MethodDelete:
+		g.objectHandlerDELETE(w, req, bucket, key)
+	default:
+		writeError(w, req, errMethodNotAllowed)
+	}
+}
+
+// bucketTurtleDexPath returns the siapath of a bucket. Reserved top-level
+// directories are not valid buckets.
+func bucketTurtleDexPath(bucket string) (modules.TurtleDexPath, error) {
+	if bucket == "" || strings.Contains(bucket, "/") || isReservedBucket(bucket) {
+		return modules.TurtleDexPath{}, errInvalidBucketName
+	}
+	siaPath, err := modules.NewTurtleDexPath(bucket)
+	if err != nil {
+		return modules.TurtleDexPath{}, errInvalidBucketName
+	}
+	return siaPath, nil
+}
+
+// hasParam returns whether the query contains the provided parameter, even if
+// the parameter has no value.
+func hasParam(query map[string][]string, param string) bool {
+	_, exists := query[param]
+	return exists
+}
+
+// isReservedBucket returns whether the top-level directory with the provided
+// name is reserved for internal use.
+func isReservedBucket(name string) bool {
+	_, reserved := reservedBuckets[name]
+	return reserved
+}
+
+// objectTurtleDexPath returns the siapath of the object with the provided key
+// within the provided bucket.
+func objectTurtleDexPath(bucket, key string) (modules.TurtleDexPath, error) {
+	bucketPath, err := bucketTurtleDexPath(bucket)
+	if err != nil {
+		return modules.TurtleDexPath{}, err
+	}
+	siaPath, err := bucketPath.Join(strings.TrimSuffix(key, "/"))
+	if err != nil {
+		return modules.TurtleDexPath{}, errors.Compose(errInvalidArgument, err)
+	}
+	// The temporary files of uploads in progress can't be accessed directly.
+	if isTempObject(siaPath.Name()) {
+		return modules.TurtleDexPath{}, errInvalidArgument
+	}
+	return siaPath, nil
+}
+
+// objectKey returns the key of the object at siaPath within the provided
+// bucket.
+func objectKey(bucket string, siaPath modules.TurtleDexPath) string {
+	return strings.TrimPrefix(siaPath.String(), bucket+"/")
+}
+
+// splitPath splits the path of a path-style request into its bucket and key.
+func splitPath(path string) (bucket, key string) {
+	path = strings.TrimPrefix(path, "/")
+	if i := strings.Index(path, "/"); i >= 0 {
+		return path[:i], path[i+1:]
+	}
+	return path, ""
+}
+
+// writeXML writes a successful XML response to the client.
+func writeXML(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(code)
+	_, _ = io.WriteString(w, xml.Header)
+	_ = xml.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,118 @@
+package s3gateway
+
+import (
+	"encoding/xml"
+)
+
+const (
+	// storageClass is the storage class reported for every object.
+	storageClass = "STANDARD"
+
+	// timeFormat is the format of timestamps within XML responses.
+	timeFormat = "2006-01-02T15:04:05.000Z"
+)
+
+type (
+	// bucketInfo describes a bucket within a listBucketsResponse.
+	bucketInfo struct {
+		Name         string `xml:"Name"`
+		CreationDate string `xml:"CreationDate"`
+	}
+
+	// commonPrefix is a prefix within a listing that is shared by multiple
+	// keys.
+	commonPrefix struct {
+		Prefix string `xml:"Prefix"`
+	}
+
+	// objectInfo describes an object within a listing.
+	objectInfo struct {
+		Key          string `xml:"Key"`
+		LastModified string `xml:"LastModified"`
+		ETag         string `xml:"ETag"`
+		Size         uint64 `xml:"Size"`
+		StorageClass string `xml:"StorageClass"`
+	}
+
+	// owner is the owner of all buckets.
+	owner struct {
+		ID          string `xml:"ID"`
+		DisplayName string `xml:"DisplayName"`
+	}
+
+	// completeMultipartUploadRequest is the body of a
+	// CompleteMultipartUpload request.
+	completeMultipartUploadRequest struct {
+		XMLName xml.Name       `xml:"CompleteMultipartUpload"`
+		Parts   []completePart `xml:"Part"`
+	}
+
+	// completePart is a part within a completeMultipartUploadRequest.
+	completePart struct {
+		PartNumber int    `xml:"PartNumber"`
+		ETag       string `xml:"ETag"`
+	}
+
+	// completeMultipartUploadResponse is the response to a
+	// CompleteMultipartUpload request.
+	completeMultipartUploadResponse struct {
+		XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ CompleteMultipartUploadResult"`
+		Bucket  string   `xml:"Bucket"`
+		Key     string   `xml:"Key"`
+		ETag    string   `xml:"ETag"`
+	}
+
+	// initiateMultipartUploadResponse is the response to a
+	// CreateMultipartUpload request.
+	initiateMultipartUploadResponse struct {
+		XMLName  xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ InitiateMultipartUploadResult"`
+		Bucket   string   `xml:"Bucket"`
+		Key      string   `xml:"Key"`
+		UploadID string   `xml:"UploadId"`
+	}
+
+	// listBucketsResponse is the response to a ListBuckets request.
+	listBucketsResponse struct {
+		XMLName xml.Name     `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListAllMyBucketsResult"`
+		Owner   owner        `xml:"Owner"`
+		Buckets []bucketInfo `xml:"Buckets>Bucket"`
+	}
+
+	// listObjectsResponse is the response to a ListObjects request.
+	listObjectsResponse struct {
+		XMLName        xml.Name       `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketResult"`
+		Name           string         `xml:"Name"`
+		Prefix         string         `xml:"Prefix"`
+		Marker         string         `xml:"Marker"`
+		NextMarker     string         `xml:"NextMarker,omitempty"`
+		MaxKeys        int            `xml:"MaxKeys"`
+		Delimiter      string         `xml:"Delimiter,omitempty"`
+		IsTruncated    bool           `xml:"IsTruncated"`
+		EncodingType   string         `xml:"EncodingType,omitempty"`
+		Contents       []objectInfo   `xml:"Contents"`
+		CommonPrefixes []commonPrefix `xml:"CommonPrefixes"`
+	}
+
+	// listObjectsV2Response is the response to a ListObjectsV2 request.
+	listObjectsV2Response struct {
+		XMLName               xml.Name       `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketResult"`
+		Name                  string         `xml:"Name"`
+		Prefix                string         `xml:"Prefix"`
+		StartAfter            string         `xml:"StartAfter,omitempty"`
+		ContinuationToken     string         `xml:"ContinuationToken,omitempty"`
+		NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+		KeyCount              int            `xml:"KeyCount"`
+		MaxKeys               int            `xml:"MaxKeys"`
+		Delimiter             string         `xml:"Delimiter,omitempty"`
+		IsTruncated           bool           `xml:"IsTruncated"`
+		EncodingType          string         `xml:"EncodingType,omitempty"`
+		Contents              []objectInfo   `xml:"Contents"`
+		CommonPrefixes        []commonPrefix `xml:"CommonPrefixes"`
+	}
+
+	// locationResponse is the response to a GetBucketLocation request.
+	locationResponse struct {
+		XMLName  xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ LocationConstraint"`
+		Location string   `xml:",chardata"`
+	}
+)
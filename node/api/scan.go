@@ -6,6 +6,7 @@ import (
 	"errors"
 
 	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
 	"github.com/turtledex/TurtleDexCore/types"
 )
 
@@ -48,3 +49,13 @@ func scanBool(param string) (bool, error) {
 	}
 	return false, errors.New("could not decode boolean: value was not true or false")
 }
+
+// scanDownloadPriority scans a download priority from a string. An empty
+// string results in an unset priority, which lets the renter pick the default
+// priority of the endpoint.
+func scanDownloadPriority(param string) (modules.DownloadPriority, error) {
+	if len(param) == 0 {
+		return 0, nil
+	}
+	return modules.ParseDownloadPriority(param)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/turtledex/TurtleDexCore/modules"
 	"github.com/turtledex/TurtleDexCore/node"
 	"github.com/turtledex/TurtleDexCore/node/api"
+	"github.com/turtledex/TurtleDexCore/node/api/s3gateway"
 	"github.com/turtledex/TurtleDexCore/types"
 )
 
@@ -33,6 +34,8 @@ type Server struct {
 	listener          net.Listener
 	node              *node.Node
 	requiredUserAgent string
+	s3Listener        net.Listener
+	s3Server          *http.Server
 	Dir               string
 
 	serveChan chan struct{}
@@ -60,8 +63,13 @@ func (srv *Server) Close() error {
 	defer close(srv.closeChan)
 	srv.closeMu.Lock()
 	defer srv.closeMu.Unlock()
+	// Stop accepting S3 requests.
+	var err error
+	if srv.s3Server != nil {
+		err = srv.s3Server.Shutdown(context.Background())
+	}
 	// Stop accepting API requests.
-	err := srv.apiServer.Shutdown(context.Background())
+	err = errors.Compose(err, srv.apiServer.Shutdown(context.Background()))
 	// Wait for serve() to return and capture its error.
 	<-srv.serveChan
 	if !errors.Contains(srv.serveErr, http.ErrServerClosed) {
@@ -116,6 +124,52 @@ func (srv *Server) RenterSettings() (modules.RenterSettings, error) {
 	return srv.node.Renter.Settings()
 }
 
+// S3GatewayAddress returns the address of the S3 gateway or an empty string if
+// the gateway wasn't started.
+func (srv *Server) S3GatewayAddress() string {
+	srv.closeMu.Lock()
+	defer srv.closeMu.Unlock()
+	if srv.s3Listener == nil {
+		return ""
+	}
+	return srv.s3Listener.Addr().String()
+}
+
+// StartS3Gateway starts an S3 compatible gateway in front of the node's renter
+// which listens on the provided address. The gateway's credentials are stored
+// within the renter's persist directory.
+func (srv *Server) StartS3Gateway(addr string) error {
+	srv.closeMu.Lock()
+	defer srv.closeMu.Unlock()
+	if srv.node == nil || srv.node.Renter == nil {
+		return errors.New("can't start the S3 gateway for a non-renter node")
+	}
+	if srv.s3Server != nil {
+		return errors.New("S3 gateway is already running")
+	}
+	g, err := s3gateway.New(srv.node.Renter, filepath.Join(srv.Dir, modules.RenterDir, s3gateway.PersistDir))
+	if err != nil {
+		return errors.AddContext(err, "failed to create S3 gateway")
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	srv.s3Listener = listener
+	srv.s3Server = &http.Server{
+		Handler:           g,
+		ReadHeaderTimeout: time.Minute * 2,
+		IdleTimeout:       time.Minute * 5,
+	}
+	go func() {
+		err := srv.s3Server.Serve(listener)
+		if err != nil && !errors.Contains(err, http.ErrServerClosed) {
+			fmt.Println("S3 gateway stopped:", err)
+		}
+	}()
+	return nil
+}
+
 // ServeErr is a blocking call that will return the result of srv.serve after
 // the server stopped.
 func (srv *Server) ServeErr() <-chan error {
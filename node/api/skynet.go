@@ -228,8 +228,15 @@ func (api *API) skynetBaseSectorHandlerGET(w http.ResponseWriter, req *http.Requ
 		}
 	}
 
+	// Parse the priority.
+	priority, err := scanDownloadPriority(queryForm.Get("priority"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse 'priority' parameter: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
 	// Fetch the skyfile's streamer to serve the basesector of the file
-	streamer, err := api.renter.DownloadSkylinkBaseSector(skylink, timeout, pricePerMS)
+	streamer, err := api.renter.DownloadSkylinkBaseSector(skylink, timeout, pricePerMS, priority)
 	if errors.Contains(err, renter.ErrSkylinkBlocked) {
 		WriteError(w, Error{err.Error()}, http.StatusUnavailableForLegalReasons)
 		return
@@ -498,8 +505,15 @@ func (api *API) skynetRootHandlerGET(w http.ResponseWriter, req *http.Request, p
 		}
 	}
 
+	// Parse the priority.
+	priority, err := scanDownloadPriority(queryForm.Get("priority"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse 'priority' parameter: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
 	// Fetch the skyfile's  streamer to serve the basesector of the file
-	sector, err := api.renter.DownloadByRoot(root, offset, length, timeout, pricePerMS)
+	sector, err := api.renter.DownloadByRoot(root, offset, length, timeout, pricePerMS, priority)
 	if errors.Contains(err, renter.ErrSkylinkBlocked) {
 		WriteError(w, Error{err.Error()}, http.StatusUnavailableForLegalReasons)
 		return
@@ -656,8 +670,25 @@ func (api *API) skynetSkylinkHandlerGET(w http.ResponseWriter, req *http.Request
 		}
 	}
 
+	// Parse the priority.
+	priority, err := scanDownloadPriority(queryForm.Get("priority"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse 'priority' parameter: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Charge the download of the base sector to the caller's budget.
+	_, fetchSize, err := skylink.OffsetAndFetchSize()
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("error parsing skylink: %v", err)}, http.StatusBadRequest)
+		return
+	}
+	if !api.chargeBudget(w, req, modules.BudgetCategoryDownload, fetchSize) {
+		return
+	}
+
 	// Fetch the skyfile's metadata and a streamer to download the file
-	layout, metadata, streamer, err := api.renter.DownloadSkylink(skylink, timeout, pricePerMS)
+	layout, metadata, streamer, err := api.renter.DownloadSkylink(skylink, timeout, pricePerMS, priority)
 	if errors.Contains(err, renter.ErrSkylinkBlocked) {
 		WriteError(w, Error{err.Error()}, http.StatusUnavailableForLegalReasons)
 		return
@@ -674,6 +705,12 @@ func (api *API) skynetSkylinkHandlerGET(w http.ResponseWriter, req *http.Request
 		_ = streamer.Close()
 	}()
 
+	// If the data isn't stored in the base sector, charge the download of the
+	// fanout to the caller's budget before the data is fetched.
+	if layout.FanoutSize > 0 && req.Method == http.MethodGet && !api.chargeBudget(w, req, modules.BudgetCategoryDownload, metadata.Length) {
+		return
+	}
+
 	// Validate Metadata
 	if metadata.DefaultPath != "" && len(metadata.Subfiles) == 0 {
 		WriteError(w, Error{"defaultpath is not allowed on single files, please specify a format"}, http.StatusBadRequest)
@@ -1000,6 +1037,7 @@ func (api *API) skynetSkylinkPinHandlerPOST(w http.ResponseWriter, req *http.Req
 		TurtleDexPath:             siaPath,
 		Force:               force,
 		BaseChunkRedundancy: redundancy,
+		BudgetIdentity:      budgetIdentity(req),
 	}
 
 	err = api.renter.PinSkylink(skylink, lup, timeout, pricePerMS)
@@ -1010,7 +1048,7 @@ func (api *API) skynetSkylinkPinHandlerPOST(w http.ResponseWriter, req *http.Req
 		WriteError(w, Error{fmt.Sprintf("Failed to pin file to Skynet: %v", err)}, http.StatusNotFound)
 		return
 	} else if err != nil {
-		WriteError(w, Error{fmt.Sprintf("Failed to pin file to Skynet: %v", err)}, http.StatusInternalServerError)
+		WriteError(w, Error{fmt.Sprintf("Failed to pin file to Skynet: %v", err)}, budgetErrorStatus(err, http.StatusInternalServerError))
 		return
 	}
 
@@ -1052,6 +1090,9 @@ func (api *API) skynetSkyfileHandlerPOST(w http.ResponseWriter, req *http.Reques
 		// Set encryption key details
 		SkykeyName: params.skyKeyName,
 		SkykeyID:   params.skyKeyID,
+
+		// The upload is charged to the caller's budget one chunk at a time.
+		BudgetIdentity: budgetIdentity(req),
 	}
 
 	// set the reader
@@ -1075,7 +1116,7 @@ func (api *API) skynetSkyfileHandlerPOST(w http.ResponseWriter, req *http.Reques
 			WriteError(w, Error{err.Error()}, http.StatusUnavailableForLegalReasons)
 			return
 		} else if err != nil {
-			WriteError(w, Error{fmt.Sprintf("failed to upload file to Skynet: %v", err)}, http.StatusBadRequest)
+			WriteError(w, Error{fmt.Sprintf("failed to upload file to Skynet: %v", err)}, budgetErrorStatus(err, http.StatusBadRequest))
 			return
 		}
 
@@ -1423,6 +1464,11 @@ func (api *API) registryHandlerPOST(w http.ResponseWriter, req *http.Request, _
 		return
 	}
 
+	// Charge the update to the caller's budget.
+	if !api.chargeBudget(w, req, modules.BudgetCategoryRegistry, 0) {
+		return
+	}
+
 	// Update the registry.
 	srv := modules.NewSignedRegistryValue(rhp.DataKey, rhp.Data, rhp.Revision, rhp.Signature)
 	err = api.renter.UpdateRegistry(rhp.PublicKey, srv, renter.DefaultRegistryUpdateTimeout)
@@ -1478,6 +1524,11 @@ func (api *API) registryHandlerGET(w http.ResponseWriter, req *http.Request, _ h
 		}
 	}
 
+	// Charge the lookup to the caller's budget.
+	if !api.chargeBudget(w, req, modules.BudgetCategoryRegistry, 0) {
+		return
+	}
+
 	// Read registry.
 	srv, err := api.renter.ReadRegistry(spk, dataKey, timeout)
 	if errors.Contains(err, renter.ErrRegistryEntryNotFound) ||
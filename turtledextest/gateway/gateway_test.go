@@ -72,6 +72,75 @@ func TestGatewayRatelimit(t *testing.T) {
 	}
 }
 
+// TestGatewayBandwidthSchedule makes sure that we can set the gateway's
+// bandwidth schedule using the API and that it is persisted correctly.
+func TestGatewayBandwidthSchedule(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	testDir := gatewayTestDir(t.Name())
+
+	// Create a new server
+	testNode, err := siatest.NewCleanNode(node.Gateway(testDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	// Set a schedule.
+	schedule, err := modules.ParseBandwidthSchedule("mon-fri 09:00-17:00 100 200; * 22:00-06:00 0 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := testNode.GatewayBandwidthSchedulePost(schedule); err != nil {
+		t.Fatal(err)
+	}
+	// The schedule should be returned and persisted.
+	gg, err := testNode.GatewayGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gg.BandwidthSchedule.String() != schedule.String() {
+		t.Fatalf("Schedule should be '%v' but was '%v'", schedule, gg.BandwidthSchedule)
+	}
+	if err := testNode.RestartNode(); err != nil {
+		t.Fatal(err)
+	}
+	gg, err = testNode.GatewayGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gg.BandwidthSchedule.String() != schedule.String() {
+		t.Fatalf("Schedule should be '%v' but was '%v'", schedule, gg.BandwidthSchedule)
+	}
+	// Changing the ratelimit keeps the schedule.
+	if err := testNode.GatewayRateLimitPost(1000, 1000); err != nil {
+		t.Fatal(err)
+	}
+	gg, err = testNode.GatewayGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gg.BandwidthSchedule) != 2 {
+		t.Fatal("Schedule was removed", gg.BandwidthSchedule)
+	}
+	// Remove the schedule.
+	if err := testNode.GatewayBandwidthSchedulePost(nil); err != nil {
+		t.Fatal(err)
+	}
+	gg, err = testNode.GatewayGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gg.BandwidthSchedule) != 0 {
+		t.Fatal("Schedule wasn't removed", gg.BandwidthSchedule)
+	}
+}
+
 // TestGatewayBlocklist probes the gateway blocklist endpoints
 func TestGatewayBlocklist(t *testing.T) {
 	if testing.Short() {
@@ -732,9 +732,177 @@ func TestFuse(t *testing.T) {
 		t.Fatal("should not be able to make a directory in a read-only fuse system")
 	}
 
-	// TODO: When read-write fuse is supported, switch over to read-write mode
-	// here and begin testing the write features. Extend the concurrency test to
-	// probe write features as well, probably by adding more phases.
+	// Mount the root in read-write mode and test the write features.
+	rwOpts := defaultOpts
+	rwOpts.ReadOnly = false
+	rwMount := filepath.Join(testDir, "rwMount")
+	err = os.MkdirAll(rwMount, persist.DefaultDiskPermissionsTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.RenterFuseMount(rwMount, modules.RootTurtleDexPath(), rwOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Overwrite the beginning of the renamed custom file and extend it.
+	rwFilePath, err := siaPathToFusePath(customFileRenamedTurtleDexPath, modules.RootTurtleDexPath(), rwMount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rwFile, err := os.OpenFile(rwFilePath, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	overwriteData := fastrand.Bytes(100)
+	appendData := fastrand.Bytes(1000)
+	_, err = rwFile.WriteAt(overwriteData, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = rwFile.WriteAt(appendData, int64(len(sourceData)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = rwFile.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedData := append(append(overwriteData, sourceData[len(overwriteData):]...), appendData...)
+	rwData, err := ioutil.ReadFile(rwFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(rwData, expectedData) {
+		t.Fatal("data mismatch after writing through fuse")
+	}
+	// The change should be visible through the API as well.
+	rf, err := r.RenterFileGet(customFileRenamedTurtleDexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rf.File.Filesize != uint64(len(expectedData)) {
+		t.Fatal("filesize mismatch after writing through fuse", rf.File.Filesize, len(expectedData))
+	}
+	// Create a directory, move the file into it, then delete both.
+	rwDirPath := filepath.Join(rwMount, "rw-dir")
+	err = os.Mkdir(rwDirPath, persist.DefaultDiskPermissionsTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rwMovedPath := filepath.Join(rwDirPath, "moved")
+	err = os.Rename(rwFilePath, rwMovedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rwDirTurtleDexPath, err := modules.RootTurtleDexPath().Join("rw-dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rwMovedTurtleDexPath, err := rwDirTurtleDexPath.Join("moved")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.RenterFileGet(rwMovedTurtleDexPath)
+	if err != nil {
+		t.Fatal("moved file should exist in the renter", err)
+	}
+	err = os.Remove(rwMovedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.RenterFileGet(rwMovedTurtleDexPath)
+	if err == nil {
+		t.Fatal("removed file should no longer exist in the renter")
+	}
+	// Deleting a file while a handle with unwritten data is still open
+	// shouldn't bring the file back once the handle is closed.
+	unlinkedPath := filepath.Join(rwDirPath, "unlinked")
+	unlinkedFile, err := os.Create(unlinkedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = unlinkedFile.Write(fastrand.Bytes(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Remove(unlinkedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = unlinkedFile.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlinkedTurtleDexPath, err := rwDirTurtleDexPath.Join("unlinked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.RenterFileGet(unlinkedTurtleDexPath)
+	if err == nil {
+		t.Fatal("deleted file was uploaded again when its handle was closed")
+	}
+	// Renaming a file while a handle with unwritten data is still open
+	// should upload the data to the new path only.
+	srcPath := filepath.Join(rwDirPath, "src")
+	dstPath := filepath.Join(rwDirPath, "dst")
+	srcFile, err := os.Create(srcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	renamedData := fastrand.Bytes(100)
+	_, err = srcFile.Write(renamedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Rename(srcPath, dstPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = srcFile.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcTurtleDexPath, err := rwDirTurtleDexPath.Join("src")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dstTurtleDexPath, err := rwDirTurtleDexPath.Join("dst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.RenterFileGet(srcTurtleDexPath)
+	if err == nil {
+		t.Fatal("renamed file was uploaded to its old path when its handle was closed")
+	}
+	rf, err = r.RenterFileGet(dstTurtleDexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rf.File.Filesize != uint64(len(renamedData)) {
+		t.Fatal("filesize mismatch after renaming an open file", rf.File.Filesize, len(renamedData))
+	}
+	dstData, err := ioutil.ReadFile(dstPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dstData, renamedData) {
+		t.Fatal("data mismatch after renaming an open file")
+	}
+	err = os.Remove(dstPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Remove(rwDirPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.RenterFuseUnmount(rwMount)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// TODO: Extend the concurrency test to probe write features as well,
+	// probably by adding more phases.
 
 	// Inode check. Mount the root siafile to a special inode mountpoint then
 	// open several files and directoriesk. Grab their inodes. Keep the folder
@@ -1,6 +1,7 @@
 package renter
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -279,6 +280,8 @@ func TestRenterFour(t *testing.T) {
 		{Name: "TestPauseAndResumeRepairAndUploads", Test: testPauseAndResumeRepairAndUploads},
 		{Name: "TestDownloadServedFromDisk", Test: testDownloadServedFromDisk},
 		{Name: "TestDirMode", Test: testDirMode},
+		{Name: "TestUserMetadata", Test: testUserMetadata},
+		{Name: "TestRedundancyPolicy", Test: testRedundancyPolicy},
 		{Name: "TestEscapeTurtleDexPath", Test: testEscapeTurtleDexPath}, // Runs last because it uploads many files
 	}
 
@@ -4723,6 +4726,91 @@ func testDirMode(t *testing.T, tg *siatest.TestGroup) {
 	}
 }
 
+// testUserMetadata tests attaching user metadata to files and directories and
+// filtering files by their metadata.
+func testUserMetadata(t *testing.T, tg *siatest.TestGroup) {
+	// Grab the first of the group's renters
+	r := tg.Renters()[0]
+
+	// Upload a file with metadata attached.
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	dirSP, err := modules.NewTurtleDexPath("metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileSP, err := dirSP.Join("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := map[string]string{"project": "x", "owner": "bob"}
+	data := fastrand.Bytes(int(modules.SectorSize))
+	err = r.RenterUploadStreamUserMetadataPost(bytes.NewReader(data), fileSP, dataPieces, parityPieces, false, md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rf, err := r.RenterFileGet(fileSP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rf.File.UserMetadata, md) {
+		t.Fatalf("expected metadata %v but got %v", md, rf.File.UserMetadata)
+	}
+
+	// Update the metadata.
+	err = r.RenterSetFileUserMetadataPost(fileSP, map[string]string{"project": "y"}, []string{"owner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	md = map[string]string{"project": "y"}
+	rf, err = r.RenterFileGet(fileSP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rf.File.UserMetadata, md) {
+		t.Fatalf("expected metadata %v but got %v", md, rf.File.UserMetadata)
+	}
+
+	// Filter the files by their metadata.
+	rfs, err := r.RenterFilesUserMetadataGet(false, md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rfs.Files) != 1 || !rfs.Files[0].TurtleDexPath.Equals(fileSP) {
+		t.Fatal("expected only the uploaded file but got", rfs.Files)
+	}
+	rfs, err = r.RenterFilesUserMetadataGet(true, map[string]string{"owner": ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rfs.Files) != 0 {
+		t.Fatal("expected no files but got", rfs.Files)
+	}
+
+	// Set the metadata of the directory.
+	err = r.RenterDirSetUserMetadataPost(dirSP, md, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rd, err := r.RenterDirGet(dirSP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rd.Directories[0].UserMetadata, md) {
+		t.Fatalf("expected metadata %v but got %v", md, rd.Directories[0].UserMetadata)
+	}
+
+	// Invalid metadata should be rejected.
+	err = r.RenterSetFileUserMetadataPost(fileSP, map[string]string{"a=b": "c"}, nil)
+	if err == nil || !strings.Contains(err.Error(), modules.ErrInvalidUserMetadataKey.Error()) {
+		t.Fatal("expected ErrInvalidUserMetadataKey but got", err)
+	}
+	err = r.RenterDirSetUserMetadataPost(dirSP, map[string]string{"": "c"}, nil)
+	if err == nil || !strings.Contains(err.Error(), modules.ErrEmptyUserMetadataKey.Error()) {
+		t.Fatal("expected ErrEmptyUserMetadataKey but got", err)
+	}
+}
+
 // TestWorkerStatus probes the WorkerPoolStatus
 func TestWorkerStatus(t *testing.T) {
 	if testing.Short() {
@@ -5934,3 +6022,124 @@ func TestRenterBubble(t *testing.T) {
 		}
 	}
 }
+
+// testRedundancyPolicy tests setting the redundancy policy of a directory and
+// that its files are migrated to the policy.
+func testRedundancyPolicy(t *testing.T, tg *siatest.TestGroup) {
+	// Grab the first of the group's renters
+	r := tg.Renters()[0]
+
+	// Upload a file with the maximum redundancy.
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	dirSP, err := modules.NewTurtleDexPath("redundancypolicy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileSP, err := dirSP.Join("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := fastrand.Bytes(int(modules.SectorSize))
+	err = r.RenterUploadStreamPost(bytes.NewReader(data), fileSP, dataPieces, parityPieces, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		rf, err := r.RenterFileGet(fileSP)
+		if err != nil {
+			return err
+		}
+		if rf.File.Redundancy != float64(dataPieces+parityPieces) {
+			return fmt.Errorf("file not fully uploaded yet: %v", rf.File.Redundancy)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid policies should be rejected.
+	invalid := modules.RedundancyPolicy{
+		Tiers: []modules.RedundancyTier{{After: time.Hour, DataPieces: 1, ParityPieces: 1}},
+	}
+	if err := r.RenterDirSetRedundancyPolicyPost(dirSP, invalid); err == nil {
+		t.Fatal("invalid policy should be rejected")
+	}
+
+	// Set a policy with less redundancy on the directory.
+	policy := modules.RedundancyPolicy{
+		Tiers: []modules.RedundancyTier{{DataPieces: 1, ParityPieces: 1}},
+	}
+	err = r.RenterDirSetRedundancyPolicyPost(dirSP, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rd, err := r.RenterDirGet(dirSP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rd.Directories[0].RedundancyPolicy, policy) {
+		t.Fatalf("expected policy %v but got %v", policy, rd.Directories[0].RedundancyPolicy)
+	}
+
+	// The file should be migrated to the policy while keeping its data.
+	err = build.Retry(100, 200*time.Millisecond, func() error {
+		rf, err := r.RenterFileGet(fileSP)
+		if err != nil {
+			return err
+		}
+		if rf.File.Redundancy != 2 {
+			return fmt.Errorf("file not migrated yet: %v", rf.File.Redundancy)
+		}
+		rd, err := r.RenterDirGet(dirSP)
+		if err != nil {
+			return err
+		}
+		if n := rd.Directories[0].AggregateNumPendingMigrations; n != 0 {
+			return fmt.Errorf("expected no pending migrations but got %v", n)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, downloaded, err := r.RenterDownloadHTTPResponseGet(fileSP, 0, uint64(len(data)), true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, downloaded) {
+		t.Fatal("migrated file has different data")
+	}
+	rg, err := r.RenterGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rg.Settings.RedundancyMigrationStatus.MigratedFiles == 0 {
+		t.Fatal("migration wasn't reported", rg.Settings.RedundancyMigrationStatus)
+	}
+
+	// Pause and resume the migration.
+	err = r.RenterRedundancyMigrationPausePost(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rg, err = r.RenterGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rg.Settings.RedundancyMigrationStatus.Paused {
+		t.Fatal("migration should be paused")
+	}
+	err = r.RenterRedundancyMigrationResumePost()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rg, err = r.RenterGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rg.Settings.RedundancyMigrationStatus.Paused {
+		t.Fatal("migration should be resumed")
+	}
+}
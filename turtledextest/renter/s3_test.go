@@ -0,0 +1,190 @@
+package renter
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/node/api/s3gateway"
+	"github.com/turtledex/TurtleDexCore/siatest"
+	"github.com/turtledex/fastrand"
+)
+
+// TestS3Gateway tests the S3 gateway in front of a renter.
+func TestS3Gateway(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a group for the test.
+	groupParams := siatest.GroupParams{
+		Hosts:   2,
+		Renters: 1,
+		Miners:  1,
+	}
+	tg, err := siatest.NewGroupFromTemplate(renterTestDir(t.Name()), groupParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Start the gateway.
+	r := tg.Renters()[0]
+	if err := r.StartS3Gateway("localhost:0"); err != nil {
+		t.Fatal(err)
+	}
+	creds, err := s3gateway.LoadCredentials(filepath.Join(r.Dir, modules.RenterDir, s3gateway.PersistDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(creds) != 1 {
+		t.Fatal("expected a single set of credentials but got", len(creds))
+	}
+
+	// do performs a signed request against the gateway and returns the
+	// response's status code, headers and body.
+	do := func(method, path string, body io.Reader, header http.Header) (int, http.Header, []byte) {
+		req, err := http.NewRequest(method, fmt.Sprintf("http://%v%v", r.S3GatewayAddress(), path), body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		s3gateway.SignRequest(req, creds[0], "us-east-1")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, resp.Header, b
+	}
+
+	// Unsigned requests are rejected.
+	resp, err := http.Get(fmt.Sprintf("http://%v/", r.S3GatewayAddress()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := resp.Body.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatal("unsigned request should be rejected", resp.StatusCode)
+	}
+
+	// Create a bucket. Creating it twice fails.
+	if code, _, body := do(http.MethodPut, "/testbucket", nil, nil); code != http.StatusOK {
+		t.Fatal("failed to create bucket", code, string(body))
+	}
+	if code, _, _ := do(http.MethodPut, "/testbucket", nil, nil); code != http.StatusConflict {
+		t.Fatal("creating a bucket twice should fail", code)
+	}
+	if code, _, body := do(http.MethodGet, "/", nil, nil); code != http.StatusOK || !bytes.Contains(body, []byte("<Name>testbucket</Name>")) {
+		t.Fatal("bucket is missing from listing", code, string(body))
+	}
+
+	// Upload an object.
+	data := fastrand.Bytes(int(modules.SectorSize) + siatest.Fuzz() + 100)
+	code, header, body := do(http.MethodPut, "/testbucket/dir/file", bytes.NewReader(data), nil)
+	if code != http.StatusOK {
+		t.Fatal("failed to upload object", code, string(body))
+	}
+	if etag := fmt.Sprintf("\"%x\"", md5.Sum(data)); header.Get("ETag") != etag {
+		t.Fatalf("expected ETag %v but got %v", etag, header.Get("ETag"))
+	}
+
+	// Download it again, both completely and partially.
+	code, _, body = do(http.MethodGet, "/testbucket/dir/file", nil, nil)
+	if code != http.StatusOK || !bytes.Equal(body, data) {
+		t.Fatal("downloaded data doesn't match", code)
+	}
+	code, _, body = do(http.MethodGet, "/testbucket/dir/file", nil, http.Header{"Range": []string{"bytes=10-19"}})
+	if code != http.StatusPartialContent || !bytes.Equal(body, data[10:20]) {
+		t.Fatal("downloaded range doesn't match", code)
+	}
+	code, header, _ = do(http.MethodHead, "/testbucket/dir/file", nil, nil)
+	if code != http.StatusOK || header.Get("Content-Length") != fmt.Sprint(len(data)) {
+		t.Fatal("wrong HEAD response", code, header.Get("Content-Length"))
+	}
+	if code, _, body := do(http.MethodGet, "/testbucket/dir/missing", nil, nil); code != http.StatusNotFound || !bytes.Contains(body, []byte("NoSuchKey")) {
+		t.Fatal("expected NoSuchKey", code, string(body))
+	}
+
+	// List the bucket with and without a delimiter.
+	code, _, body = do(http.MethodGet, "/testbucket?list-type=2&delimiter=%2F", nil, nil)
+	if code != http.StatusOK || !bytes.Contains(body, []byte("<Prefix>dir/</Prefix>")) || bytes.Contains(body, []byte("<Key>")) {
+		t.Fatal("wrong listing", code, string(body))
+	}
+	code, _, body = do(http.MethodGet, "/testbucket?list-type=2&prefix=dir%2Ff", nil, nil)
+	if code != http.StatusOK || !bytes.Contains(body, []byte("<Key>dir/file</Key>")) {
+		t.Fatal("wrong listing", code, string(body))
+	}
+
+	// Upload an object using a multipart upload.
+	code, _, body = do(http.MethodPost, "/testbucket/multipart?uploads", nil, nil)
+	if code != http.StatusOK {
+		t.Fatal("failed to create multipart upload", code, string(body))
+	}
+	var initResp struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.Unmarshal(body, &initResp); err != nil {
+		t.Fatal(err)
+	}
+	parts := [][]byte{fastrand.Bytes(int(modules.SectorSize)), fastrand.Bytes(100)}
+	var complete strings.Builder
+	complete.WriteString("<CompleteMultipartUpload>")
+	for i, part := range parts {
+		path := fmt.Sprintf("/testbucket/multipart?partNumber=%v&uploadId=%v", i+1, initResp.UploadID)
+		code, header, body := do(http.MethodPut, path, bytes.NewReader(part), nil)
+		if code != http.StatusOK {
+			t.Fatal("failed to upload part", code, string(body))
+		}
+		fmt.Fprintf(&complete, "<Part><PartNumber>%v</PartNumber><ETag>%v</ETag></Part>", i+1, header.Get("ETag"))
+	}
+	complete.WriteString("</CompleteMultipartUpload>")
+	path := "/testbucket/multipart?uploadId=" + initResp.UploadID
+	if code, _, body := do(http.MethodPost, path, strings.NewReader(complete.String()), nil); code != http.StatusOK {
+		t.Fatal("failed to complete multipart upload", code, string(body))
+	}
+	code, _, body = do(http.MethodGet, "/testbucket/multipart", nil, nil)
+	if code != http.StatusOK || !bytes.Equal(body, append(parts[0], parts[1]...)) {
+		t.Fatal("downloaded multipart data doesn't match", code)
+	}
+	if code, _, _ := do(http.MethodPost, path, strings.NewReader(complete.String()), nil); code != http.StatusNotFound {
+		t.Fatal("completed upload should be gone", code)
+	}
+
+	// A bucket can only be deleted once it's empty.
+	if code, _, _ := do(http.MethodDelete, "/testbucket", nil, nil); code != http.StatusConflict {
+		t.Fatal("deleting a non-empty bucket should fail", code)
+	}
+	for _, key := range []string{"dir/file", "multipart"} {
+		if code, _, body := do(http.MethodDelete, "/testbucket/"+key, nil, nil); code != http.StatusNoContent {
+			t.Fatal("failed to delete object", code, string(body))
+		}
+	}
+	if code, _, body := do(http.MethodDelete, "/testbucket", nil, nil); code != http.StatusNoContent {
+		t.Fatal("failed to delete bucket", code, string(body))
+	}
+	if code, _, _ := do(http.MethodHead, "/testbucket", nil, nil); code != http.StatusNotFound {
+		t.Fatal("bucket should be gone", code)
+	}
+}
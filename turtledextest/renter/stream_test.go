@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/turtledex/TurtleDexCore/build"
 	"github.com/turtledex/TurtleDexCore/crypto"
 	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter"
 	"github.com/turtledex/TurtleDexCore/node"
 	"github.com/turtledex/TurtleDexCore/siatest"
 	"github.com/turtledex/TurtleDexCore/siatest/dependencies"
@@ -182,6 +184,7 @@ func TestRenterStream(t *testing.T) {
 		{Name: "TestStreamRepair", Test: testStreamRepair},
 		{Name: "TestUploadStreaming", Test: testUploadStreaming},
 		{Name: "TestUploadStreamingWithBadDeps", Test: testUploadStreamingWithBadDeps},
+		{Name: "TestUploadSession", Test: testUploadSession},
 	}
 
 	// Run tests
@@ -344,6 +347,103 @@ func testUploadStreaming(t *testing.T, tg *siatest.TestGroup) {
 	}
 }
 
+// testUploadSession uploads random data across multiple requests using a
+// resumable upload session and restarts the renter in between.
+func testUploadSession(t *testing.T, tg *siatest.TestGroup) {
+	if len(tg.Renters()) == 0 {
+		t.Fatal("Test requires at least 1 renter")
+	}
+	// Create some random data that spans multiple chunks and doesn't end at a
+	// chunk boundary.
+	chunkSize := int(modules.SectorSize)
+	data := fastrand.Bytes(2*chunkSize + chunkSize/2)
+
+	// Create the session.
+	siaPath, err := modules.NewTurtleDexPath("/session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := tg.Renters()[0]
+	info, err := r.RenterUploadSessionCreatePost(siaPath, 1, uint64(len(tg.Hosts())-1), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Offset != 0 {
+		t.Fatal("expected offset 0 but got", info.Offset)
+	}
+
+	// Write the first part of the data which doesn't fill the second chunk.
+	firstPart := chunkSize + chunkSize/4
+	info, err = r.RenterUploadSessionWritePost(info.ID, 0, bytes.NewReader(data[:firstPart]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Offset != uint64(firstPart) {
+		t.Fatalf("expected offset %v but got %v", firstPart, info.Offset)
+	}
+
+	// Restart the renter. The session should survive.
+	if err := tg.RestartNode(r); err != nil {
+		t.Fatal(err)
+	}
+	info, err = r.RenterUploadSessionGet(info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Offset != uint64(firstPart) {
+		t.Fatalf("expected offset %v after restart but got %v", firstPart, info.Offset)
+	}
+
+	// Writing at the wrong offset should fail.
+	_, err = r.RenterUploadSessionWritePost(info.ID, 0, bytes.NewReader(data))
+	if err == nil || !strings.Contains(err.Error(), renter.ErrUploadSessionOffset.Error()) {
+		t.Fatal("expected offset error but got", err)
+	}
+
+	// Write the remaining data and finalize the session.
+	info, err = r.RenterUploadSessionWritePost(info.ID, info.Offset, bytes.NewReader(data[firstPart:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Offset != uint64(len(data)) {
+		t.Fatalf("expected offset %v but got %v", len(data), info.Offset)
+	}
+	if err := r.RenterUploadSessionFinalizePost(info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.RenterUploadSessionGet(info.ID); err == nil {
+		t.Fatal("session should be gone after finalizing it")
+	}
+
+	// Make sure the file reached full redundancy.
+	err = build.Retry(100, 600*time.Millisecond, func() error {
+		rfg, err := r.RenterFileGet(siaPath)
+		if err != nil {
+			return err
+		}
+		if rfg.File.Redundancy < float64(len(tg.Hosts())) {
+			return fmt.Errorf("expected redundancy %v but was %v",
+				len(tg.Hosts()), rfg.File.Redundancy)
+		}
+		if rfg.File.Filesize != uint64(len(data)) {
+			return fmt.Errorf("expected uploaded file to have size %v but was %v",
+				len(data), rfg.File.Filesize)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Download the file again and compare it to the original data.
+	_, downloadedData, err := r.RenterDownloadHTTPResponseGet(siaPath, 0, uint64(len(data)), true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, downloadedData) {
+		t.Fatal("Downloaded data doesn't match uploaded data")
+	}
+}
+
 // testUploadStreamingWithBadDeps uploads random data using the upload streaming
 // API, depending on a disrupt to cause a failure. This is a regression test
 // that would have caused a production build panic.
@@ -378,3 +478,117 @@ func testUploadStreamingWithBadDeps(t *testing.T, tg *siatest.TestGroup) {
 		t.Fatal("dependency injection should have caused the upload to fail")
 	}
 }
+
+// TestUploadStreamingDeferredRepairs tests that uploads aren't held up by
+// repairs which are deferred by the repair throttle.
+func TestUploadStreamingDeferredRepairs(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a group for testing.
+	groupParams := siatest.GroupParams{
+		Hosts:   3,
+		Renters: 1,
+		Miners:  1,
+	}
+	testDir := renterTestDir(t.Name())
+	tg, err := siatest.NewGroupFromTemplate(testDir, groupParams)
+	if err != nil {
+		t.Fatal("Failed to create group:", err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := tg.Renters()[0]
+
+	// Upload a file with multiple chunks.
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	chunkSize := siatest.ChunkSize(dataPieces, crypto.TypeDefaultRenter)
+	_, rf, err := r.UploadNewFileBlocking(int(3*chunkSize), dataPieces, parityPieces, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Limit repairs to a single byte per second. Only the first repair can be
+	// started right away, the others are deferred.
+	err = r.RenterRepairPost(modules.RepairSettings{MaxBytesPerSecond: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Replace a host to make the file's chunks need repairs.
+	if err := tg.RemoveNode(tg.Hosts()[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.WaitForDecreasingRedundancy(rf, float64(parityPieces)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tg.AddNodes(node.HostTemplate); err != nil {
+		t.Fatal(err)
+	}
+
+	// Wait for the first repair to finish.
+	err = build.Retry(100, 600*time.Millisecond, func() error {
+		rrg, err := r.RenterRepairGet()
+		if err != nil {
+			return err
+		}
+		if rrg.Throughput == 0 {
+			return fmt.Errorf("no repair finished yet")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Start a stream upload while the remaining repairs are deferred. It
+	// should reach full redundancy.
+	data := fastrand.Bytes(int(chunkSize))
+	siaPath := modules.RandomTurtleDexPath()
+	err = r.RenterUploadStreamPost(bytes.NewReader(data), siaPath, dataPieces, parityPieces, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 600*time.Millisecond, func() error {
+		rfg, err := r.RenterFileGet(siaPath)
+		if err != nil {
+			return err
+		}
+		if rfg.File.Redundancy < float64(len(tg.Hosts())) {
+			return fmt.Errorf("expected redundancy %v but was %v", len(tg.Hosts()), rfg.File.Redundancy)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The same is true for a regular upload which goes through the repair
+	// loop.
+	if _, _, err := r.UploadNewFileBlocking(int(chunkSize), dataPieces, parityPieces, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// The repairs are still deferred.
+	file, err := r.File(rf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Redundancy >= float64(len(tg.Hosts())) {
+		t.Fatal("repairs should still be deferred")
+	}
+
+	// Lifting the limit lets the deferred repairs continue.
+	if err := r.RenterRepairPost(modules.RepairSettings{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.WaitForUploadHealth(rf); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -62,6 +62,23 @@ func ParseExchangeRate(s string) (*ExchangeRate, error) {
 	return rate, nil
 }
 
+// Convert converts an amount in the currency of the exchange rate into
+// hastings. Assumes that amount cannot be negative.
+func (r *ExchangeRate) Convert(amount float64) Currency {
+	amountRat, _ := new(big.Float).SetFloat64(amount).Rat(nil)
+	rateRat, _ := r.staticValue.Rat(nil)
+	precisionRat := new(big.Rat).SetInt(TurtleDexcoinPrecision.Big())
+
+	// calculate (amountRat * precisionRat) / rateRat
+	resultRat := new(big.Rat).Quo(new(big.Rat).Mul(amountRat, precisionRat), rateRat)
+	return NewCurrency(new(big.Int).Quo(resultRat.Num(), resultRat.Denom()))
+}
+
+// Symbol returns the symbol of the exchange rate's currency.
+func (r *ExchangeRate) Symbol() string {
+	return r.staticSymbol
+}
+
 // ApplyAndFormat applies the exchange rate to a currency amount and formats the
 // result. Assumes that c cannot be negative. The output will use two decimal
 // places, expect for small values where three or four decimal places are used.
@@ -122,3 +122,28 @@ func TestApplyAndFormat(t *testing.T) {
 		}
 	}
 }
+
+// TestExchangeRateConvert checks that amounts are converted into hastings
+// correctly.
+func TestExchangeRateConvert(t *testing.T) {
+	tests := []struct {
+		rate   string
+		amount float64
+		result Currency
+	}{
+		{"1 USD", 1, TurtleDexcoinPrecision},
+		{"1 USD", 0.5, TurtleDexcoinPrecision.Div64(2)},
+		{"0.5 USD", 1, TurtleDexcoinPrecision.Mul64(2)},
+		{"0.25 USD", 2, TurtleDexcoinPrecision.Mul64(8)},
+		{"1 USD", 0, ZeroCurrency},
+	}
+	for _, test := range tests {
+		rate, err := ParseExchangeRate(test.rate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result := rate.Convert(test.amount); !result.Equals(test.result) {
+			t.Errorf("Convert(%v) with %v: expected %v, got %v", test.amount, test.rate, test.result, result)
+		}
+	}
+}